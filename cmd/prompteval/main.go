@@ -0,0 +1,193 @@
+// Command prompteval scores two variants of the itinerary pipeline, prompt versions or
+// models, over a golden set of cases and writes a comparison report.
+//
+// Compare version 2 of the personalised itinerary prompt with version 1 on recorded
+// answers:
+//
+//	go run ./cmd/prompteval -a personalized_itinerary=1 -b personalized_itinerary=2 \
+//	  -cassette-mode replay -out report.md
+//
+// Compare two models on the same prompts:
+//
+//	go run ./cmd/prompteval -provider gemini -model-a gemini-2.0-flash -model-b gemini-2.5-flash
+//
+// The provider settings not given as flags come from LLM_BASE_URL and LLM_API_KEY, as for
+// the server. The command exits with status 2 when -fail-on-regression is set and the
+// candidate scores worse than the baseline on any metric.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompteval"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+type variant struct {
+	provider string
+	model    string
+	versions map[string]int
+}
+
+func (v variant) label() string {
+	label := v.provider
+	if v.model != "" {
+		label += "/" + v.model
+	}
+	for _, id := range slices.Sorted(maps.Keys(v.versions)) {
+		label += fmt.Sprintf(" %s@v%d", id, v.versions[id])
+	}
+	return label
+}
+
+// errRegressed stops the command with status 2 under -fail-on-regression.
+var errRegressed = errors.New("candidate regressed")
+
+func main() {
+	if err := run(); err != nil {
+		if errors.Is(err, errRegressed) {
+			log.Println(err)
+			os.Exit(2)
+		}
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	golden := flag.String("golden", "internal/pkg/prompteval/testdata/golden.json", "golden set of cases, a JSON array")
+	versionsA := flag.String("a", "", "prompt versions of the baseline, e.g. personalized_itinerary=1,dining=1 (latest when empty)")
+	versionsB := flag.String("b", "", "prompt versions of the candidate (latest when empty)")
+	provider := flag.String("provider", os.Getenv("LLM_PROVIDER"), "provider of both variants: gemini, openai or fake")
+	providerB := flag.String("provider-b", "", "provider of the candidate when it differs")
+	modelA := flag.String("model-a", os.Getenv("LLM_MODEL"), "model of the baseline")
+	modelB := flag.String("model-b", "", "model of the candidate (the baseline's when empty)")
+	cassetteMode := flag.String("cassette-mode", "", "record or replay answers, empty calls the provider")
+	cassetteDir := flag.String("cassette-dir", "internal/app/domain/chat_prompt/testdata/cassettes/recorded", "directory of recorded answers")
+	out := flag.String("out", "", "markdown report path, stdout when empty")
+	jsonOut := flag.String("json", "", "also write the full comparison as JSON to this path")
+	failOnRegression := flag.Bool("fail-on-regression", false, "exit with status 2 when the candidate regresses on any metric")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file, using environment variables")
+	}
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+
+	cases, err := prompteval.LoadCases(*golden)
+	if err != nil {
+		return err
+	}
+
+	baseline := variant{provider: *provider, model: *modelA}
+	candidate := variant{provider: *provider, model: *modelA}
+	if *providerB != "" {
+		candidate.provider = *providerB
+	}
+	if *modelB != "" {
+		candidate.model = *modelB
+	}
+	if baseline.versions, err = parseVersions(*versionsA); err != nil {
+		return err
+	}
+	if candidate.versions, err = parseVersions(*versionsB); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var runs []*prompteval.RunResult
+	for _, v := range []variant{baseline, candidate} {
+		llm, err := llmprovider.New(ctx, llmprovider.Config{
+			Provider:     v.provider,
+			Model:        v.model,
+			BaseURL:      os.Getenv("LLM_BASE_URL"),
+			APIKey:       os.Getenv("LLM_API_KEY"),
+			CassetteMode: *cassetteMode,
+			CassetteDir:  *cassetteDir,
+		}, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create provider for %s: %w", v.label(), err)
+		}
+		if v.model == "" {
+			v.model = llm.Model()
+		}
+		logger.Info("Evaluating variant", zap.String("variant", v.label()), zap.Int("cases", len(cases)))
+		pipeline := llmchat.NewEvalPipeline(llm, prompts.Default, v.versions, logger)
+		runs = append(runs, prompteval.Run(ctx, v.label(), pipeline, cases))
+	}
+	comparison := prompteval.Compare(runs[0], runs[1])
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := comparison.WriteMarkdown(w); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if *jsonOut != "" {
+		data, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode comparison: %w", err)
+		}
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write comparison: %w", err)
+		}
+	}
+
+	if regressions := comparison.Regressions(); *failOnRegression && len(regressions) > 0 {
+		for _, r := range regressions {
+			logger.Warn("Candidate regressed", zap.String("metric", r.Metric), zap.Float64("delta", r.Delta))
+		}
+		return errRegressed
+	}
+	return nil
+}
+
+// parseVersions reads prompt versions given as id=version pairs separated by commas,
+// checking that every version exists.
+func parseVersions(spec string) (map[string]int, error) {
+	versions := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, versionStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid prompt version %q, expected id=version", entry)
+		}
+		version, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(versionStr), "v"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt version %q: %w", entry, err)
+		}
+		id = strings.TrimSpace(id)
+		if !slices.Contains(prompts.Default.Versions(id), version) {
+			return nil, fmt.Errorf("prompt template %q has no version %d", id, version)
+		}
+		versions[id] = version
+	}
+	return versions, nil
+}
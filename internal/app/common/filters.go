@@ -3,6 +3,7 @@ package common
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -10,6 +11,7 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/domain/results"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 	"github.com/FACorreiaa/go-templui/internal/pkg/cache"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
 )
 
 type FilterHandlers struct {
@@ -29,12 +31,14 @@ func (h *FilterHandlers) HandleFilterRestaurants(c *gin.Context) {
 	priceRanges := c.QueryArray("priceRange[]")
 	ratingStr := c.Query("rating")
 	features := c.QueryArray("features[]")
+	open := openFilterFrom(c, cacheKey)
 
 	h.logger.Info("Filtering restaurants",
 		zap.String("cacheKey", cacheKey),
 		zap.Strings("categories", categories),
 		zap.Strings("priceRanges", priceRanges),
-		zap.String("rating", ratingStr))
+		zap.String("rating", ratingStr),
+		zap.Bool("openFilter", open != nil))
 
 	// Get restaurants from cache
 	restaurantsData, found := cache.RestaurantsCache.Get(cacheKey)
@@ -45,7 +49,7 @@ func (h *FilterHandlers) HandleFilterRestaurants(c *gin.Context) {
 	}
 
 	// Apply filters
-	filtered := filterRestaurantsByCriteria(restaurantsData, categories, priceRanges, ratingStr, features)
+	filtered := filterRestaurantsByCriteria(restaurantsData, categories, priceRanges, ratingStr, features, open)
 
 	h.logger.Info("Restaurants filtered",
 		zap.Int("original", len(restaurantsData)),
@@ -188,7 +192,44 @@ func (h *FilterHandlers) HandleClearFilters(c *gin.Context) {
 
 // Filter helper functions
 
-func filterRestaurantsByCriteria(restaurants []models.RestaurantDetailedInfo, categories []string, priceRanges []string, ratingStr string, features []string) []models.RestaurantDetailedInfo {
+// openAtLayout is how datetime-local inputs send the time to be open at, in city time.
+const openAtLayout = "2006-01-02T15:04"
+
+// openFilter keeps the places open now, or at a date and time of the city they are in.
+type openFilter struct {
+	now      time.Time
+	at       string
+	timezone string // of the city, from its cached data; estimated from longitude without
+}
+
+// openFilterFrom reads the openNow and openAt parameters, nil when neither is set.
+func openFilterFrom(c *gin.Context, cacheKey string) *openFilter {
+	at := c.Query("openAt")
+	if c.Query("openNow") == "" && at == "" {
+		return nil
+	}
+	f := &openFilter{now: time.Now(), at: at}
+	if completeData, found := cache.CompleteItineraryCache.Get(cacheKey); found {
+		f.timezone = completeData.GeneralCityData.Timezone
+	}
+	return f
+}
+
+// openAt reports whether a place with the given hours is open at the time filtered on.
+// Hours that cannot be read do not filter the place out.
+func (f *openFilter) openAt(hours string, longitude float64) bool {
+	loc := openinghours.Location(f.timezone, longitude)
+	when := f.now.In(loc)
+	if f.at != "" {
+		if t, err := time.ParseInLocation(openAtLayout, f.at, loc); err == nil {
+			when = t
+		}
+	}
+	open, _ := openinghours.ParseString(hours).OpenAt(when)
+	return open
+}
+
+func filterRestaurantsByCriteria(restaurants []models.RestaurantDetailedInfo, categories []string, priceRanges []string, ratingStr string, features []string, open *openFilter) []models.RestaurantDetailedInfo {
 	var filtered []models.RestaurantDetailedInfo
 
 	minRating := parseRating(ratingStr)
@@ -214,6 +255,11 @@ func filterRestaurantsByCriteria(restaurants []models.RestaurantDetailedInfo, ca
 			continue
 		}
 
+		// Opening hours filter
+		if open != nil && restaurant.OpeningHours != nil && !open.openAt(*restaurant.OpeningHours, restaurant.Longitude) {
+			continue
+		}
+
 		filtered = append(filtered, restaurant)
 	}
 
@@ -0,0 +1,66 @@
+package activities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+func TestFilterPOIsForActivities(t *testing.T) {
+	testPOIs := []models.POIDetailedInfo{
+		// Activities/Attractions
+		{ID: uuid.New(), Name: "Louvre Museum", Category: "museum", Rating: 4.8},
+		{ID: uuid.New(), Name: "Central Park", Category: "park", Rating: 4.7},
+		{ID: uuid.New(), Name: "Broadway Theater", Category: "theater", Rating: 4.6},
+		{ID: uuid.New(), Name: "Art Gallery", Category: "gallery", Rating: 4.5},
+		{ID: uuid.New(), Name: "Sports Stadium", Category: "sports", Rating: 4.4},
+		{ID: uuid.New(), Name: "Adventure Park", Category: "adventure", Rating: 4.3},
+		{ID: uuid.New(), Name: "Cultural Center", Category: "cultural", Rating: 4.2},
+		{ID: uuid.New(), Name: "Entertainment Complex", Category: "entertainment", Rating: 4.1},
+		{ID: uuid.New(), Name: "Outdoor Trail", Category: "outdoor", Rating: 4.0},
+		{ID: uuid.New(), Name: "Recreation Center", Category: "recreation", Rating: 3.9},
+
+		// Other domains and non-relevant categories
+		{ID: uuid.New(), Name: "Luxury Hotel", Category: "hotel", Rating: 4.9},
+		{ID: uuid.New(), Name: "Fine Restaurant", Category: "restaurant", Rating: 4.8},
+		{ID: uuid.New(), Name: "Wine Bar", Category: "bar", Rating: 4.6},
+		{ID: uuid.New(), Name: "Transport Station", Category: "transport", Rating: 4.0},
+		{ID: uuid.New(), Name: "Shopping Mall", Category: "shopping", Rating: 4.1},
+	}
+
+	t.Run("includes only activity categories", func(t *testing.T) {
+		filtered := filterPOIsForActivities(testPOIs)
+		assert.Len(t, filtered, 10)
+
+		activityCategories := map[string]bool{
+			"museum": true, "park": true, "theater": true, "gallery": true, "sports": true,
+			"adventure": true, "cultural": true, "entertainment": true, "outdoor": true, "recreation": true,
+		}
+		var names []string
+		for _, poi := range filtered {
+			assert.True(t, activityCategories[poi.Category],
+				"POI '%s' with category '%s' should not be in activities filter", poi.Name, poi.Category)
+			names = append(names, poi.Name)
+		}
+		assert.Contains(t, names, "Louvre Museum")
+		assert.Contains(t, names, "Central Park")
+		assert.NotContains(t, names, "Luxury Hotel")
+		assert.NotContains(t, names, "Fine Restaurant")
+		assert.NotContains(t, names, "Transport Station")
+	})
+
+	t.Run("is case insensitive", func(t *testing.T) {
+		filtered := filterPOIsForActivities([]models.POIDetailedInfo{
+			{ID: uuid.New(), Name: "MUSEUM", Category: "MUSEUM"},
+			{ID: uuid.New(), Name: "Hotel", Category: "HOTEL"},
+		})
+		assert.Len(t, filtered, 1)
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		assert.Empty(t, filterPOIsForActivities([]models.POIDetailedInfo{}))
+	})
+}
@@ -181,6 +181,9 @@ func (h *AuthHandlers) LoginHandler(c *gin.Context) {
 		true,
 	)
 
+	// Login only succeeds once whatever the guest did belongs to the user
+	middleware.ClearGuestCookie(c.Writer)
+
 	h.logger.Info("Successful login",
 		zap.String("email", email),
 		zap.Bool("remember_me", rememberMe),
@@ -253,12 +256,16 @@ func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.authService.Register(r.Context(), fullName, email, password, "user")
 	if err != nil {
 		h.logger.Error("Failed to register user", zap.Error(err))
+		description := "Email may already be registered. Please try signing in or use a different email."
+		if !errors.Is(err, models.ErrConflict) {
+			description = "An unexpected error occurred. Please try again."
+		}
 		w.Header().Set("HX-Retarget", "#signup-response")
 		w.WriteHeader(http.StatusBadRequest)
 		component := banner.Banner(banner.BannerProps{
 			Type:        banner.BannerError,
 			Message:     "Registration failed",
-			Description: "Email may already be registered. Please try signing in or use a different email.",
+			Description: description,
 			Dismissable: true,
 			ID:          "signup-failed",
 			AutoDismiss: 8,
@@ -290,6 +297,8 @@ func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		Path:     "/",
 	}
 	http.SetCookie(w, cookie)
+	// The guest was moved onto the user in the transaction creating it
+	middleware.ClearGuestCookie(w)
 
 	h.logger.Info("Successful registration",
 		zap.String("user_id", userID),
@@ -29,8 +29,9 @@ type AuthRepo interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.UserAuth, error)
 	// GetUserByID fetches user details by ID.
 	GetUserByID(ctx context.Context, userID string) (*models.UserAuth, error)
-	// Register stores a new user with a HASHED password. Returns new user ID.
-	Register(ctx context.Context, username, email, hashedPassword string) (string, error)
+	// Register stores a new user with a HASHED password. Returns new user ID. onCreate, when
+	// set, runs in the transaction creating the user, which is rolled back if it fails.
+	Register(ctx context.Context, username, email, hashedPassword string, onCreate func(ctx context.Context, tx pgx.Tx, userID string) error) (string, error)
 	// VerifyPassword checks if the given password matches the hash for the userID.
 	VerifyPassword(ctx context.Context, userID, password string) error // Password is plain text here
 	// UpdatePassword updates the user's HASHED password.
@@ -96,7 +97,7 @@ func (r *PostgresAuthRepo) GetUserByID(ctx context.Context, userID string) (*mod
 }
 
 // Register implements auth.AuthRepo. Expects HASHED password.
-func (r *PostgresAuthRepo) Register(ctx context.Context, username, email, hashedPassword string) (string, error) {
+func (r *PostgresAuthRepo) Register(ctx context.Context, username, email, hashedPassword string, onCreate func(ctx context.Context, tx pgx.Tx, userID string) error) (string, error) {
 	tracer := otel.Tracer("MyRESTAPI")
 
 	// Start a span for the repository layer
@@ -112,10 +113,22 @@ func (r *PostgresAuthRepo) Register(ctx context.Context, username, email, hashed
 
 	var userID string
 
+	tx, err := r.pgpool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to begin transaction")
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.logger.Error("transaction rollback failed", zap.Error(err))
+		}
+	}()
+
 	// Insert user - database trigger will automatically create default profile
 	// See migration 0008_user_profile.up.sql: trigger_create_user_profile_after_insert
 	userQuery := `INSERT INTO users (username, email, password_hash, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.pgpool.QueryRow(ctx, userQuery, username, email, hashedPassword, time.Now()).Scan(&userID)
+	err = tx.QueryRow(ctx, userQuery, username, email, hashedPassword, time.Now()).Scan(&userID)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Database error")
@@ -127,6 +140,20 @@ func (r *PostgresAuthRepo) Register(ctx context.Context, username, email, hashed
 		return "", fmt.Errorf("database error registering user: %w", err)
 	}
 
+	if onCreate != nil {
+		if err := onCreate(ctx, tx, userID); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to set up user")
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to commit user")
+		return "", fmt.Errorf("failed to commit user: %w", err)
+	}
+
 	span.SetStatus(codes.Ok, "User and default profile created via trigger")
 	r.logger.Info( "User registered successfully with default profile", zap.String("userID", userID))
 	return userID, nil
@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -45,11 +46,18 @@ type AuthService interface {
 	CheckPassword(hashedPassword, password string) bool
 }
 
+// GuestClaimer moves what a guest did in free mode onto a user.
+type GuestClaimer interface {
+	Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+	ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+}
+
 // AuthServiceImpl provides the implementation for AuthService.
 type AuthServiceImpl struct {
 	logger *zap.Logger
 	repo   AuthRepo // Use the interface
 	cfg    *config.Config
+	guests GuestClaimer
 }
 
 // NewAuthService creates a new authentication service instance.
@@ -58,6 +66,47 @@ func NewAuthService(repo AuthRepo, cfg *config.Config, logger *zap.Logger) *Auth
 	return &AuthServiceImpl{logger: logger, repo: repo, cfg: cfg}
 }
 
+// WithGuests moves the sessions, favourites and lists of the guest in the request context
+// onto the user that signs up or signs in.
+func (s *AuthServiceImpl) WithGuests(guests GuestClaimer) *AuthServiceImpl {
+	s.guests = guests
+	return s
+}
+
+// claimGuest hands the guest of the request over to the user, within tx when it is set.
+// It does nothing for requests without a guest. A failed claim fails the sign in or sign up,
+// so that the guest keeps its cookie and its work rather than losing both.
+func (s *AuthServiceImpl) claimGuest(ctx context.Context, l *zap.Logger, tx pgx.Tx, userID string) error {
+	if s.guests == nil {
+		return nil
+	}
+	guestID, ok := middleware.GuestIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", userID, models.ErrBadRequest)
+	}
+
+	var claim *models.GuestClaim
+	if tx != nil {
+		claim, err = s.guests.ClaimInTx(ctx, tx, guestID, uid)
+	} else {
+		claim, err = s.guests.Claim(ctx, guestID, uid)
+	}
+	if err != nil {
+		l.Error("Failed to claim guest", zap.String("guestID", guestID.String()), zap.Error(err))
+		return fmt.Errorf("failed to move guest onto user: %w", err)
+	}
+	l.Info("Guest moved onto user",
+		zap.String("guestID", guestID.String()),
+		zap.Int("sessions", claim.Sessions),
+		zap.Int("favorites", claim.Favorites),
+		zap.Int("lists", claim.Lists))
+	return nil
+}
+
 // Login validates credentials, generates tokens, stores refresh token.
 func (s *AuthServiceImpl) Login(ctx context.Context, email, password string) (string, string, error) {
 	l := s.logger.With(zap.String("method", "Login"), zap.String("email", email))
@@ -78,6 +127,11 @@ func (s *AuthServiceImpl) Login(ctx context.Context, email, password string) (st
 		return "", "", fmt.Errorf("invalid credentials: %w", models.ErrUnauthenticated)
 	}
 
+	// Moved before any session exists, so a failed claim leaves nothing behind
+	if err := s.claimGuest(ctx, l, nil, user.ID); err != nil {
+		return "", "", err
+	}
+
 	// --- Add models.Subscription Fetching Here Later ---
 	// sub, err := s.subsRepo.GetCurrentmodels.SubscriptionByUserID(ctx, user.ID) ...
 	// For now, create dummy/default sub info for token generation
@@ -127,8 +181,10 @@ func (s *AuthServiceImpl) Register(ctx context.Context, username, email, passwor
 	}
 	hashedPassword := string(hashedPasswordBytes)
 
-	// Call repository to store user
-	userID, err := s.repo.Register(ctx, username, email, hashedPassword)
+	// Call repository to store user, together with the guest of the request
+	userID, err := s.repo.Register(ctx, username, email, hashedPassword, func(ctx context.Context, tx pgx.Tx, userID string) error {
+		return s.claimGuest(ctx, l, tx, userID)
+	})
 	if err != nil {
 		l.Error("Repository registration failed", zap.Error(err))
 		span.RecordError(err)
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
@@ -37,8 +38,8 @@ func (m *MockAuthRepo) GetUserByID(ctx context.Context, userID string) (*models.
 	return args.Get(0).(*models.UserAuth), args.Error(1)
 }
 
-func (m *MockAuthRepo) Register(ctx context.Context, username, email, hashedPassword string) (string, error) {
-	args := m.Called(ctx, username, email, hashedPassword)
+func (m *MockAuthRepo) Register(ctx context.Context, username, email, hashedPassword string, onCreate func(ctx context.Context, tx pgx.Tx, userID string) error) (string, error) {
+	args := m.Called(ctx, username, email, hashedPassword, onCreate)
 	return args.String(0), args.Error(1)
 }
 
@@ -207,7 +208,7 @@ func TestRegister(t *testing.T) {
 
 		// Set up expectations - we can't predict the exact hashed password, so use mock.AnythingOfType
 		// Also use mock.Anything for context since service adds tracing context
-		mockRepo.On("Register", mock.Anything, username, email, mock.AnythingOfType("string")).Return(userID, nil).Once()
+		mockRepo.On("Register", mock.Anything, username, email, mock.AnythingOfType("string"), mock.Anything).Return(userID, nil).Once()
 
 		// Call the service method
 		_, err := service.Register(ctx, username, email, password, "user")
@@ -225,7 +226,7 @@ func TestRegister(t *testing.T) {
 		password := "password123"
 
 		// Set up expectations
-		mockRepo.On("Register", mock.Anything, username, email, mock.AnythingOfType("string")).Return("", models.ErrConflict).Once()
+		mockRepo.On("Register", mock.Anything, username, email, mock.AnythingOfType("string"), mock.Anything).Return("", models.ErrConflict).Once()
 
 		// Call the service method
 		_, err := service.Register(ctx, username, email, password, "user")
@@ -15,8 +15,10 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/quota"
 	"github.com/FACorreiaa/go-templui/internal/app/middleware"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	streamingpkg "github.com/FACorreiaa/go-templui/internal/app/streaming"
 	"github.com/FACorreiaa/go-templui/internal/pkg/config"
 )
 
@@ -25,6 +27,9 @@ type ChatHandlers struct {
 	llmService     LlmInteractiontService
 	profileService profiles.Service
 	chatRepository Repository
+	domainDetector DomainDetector
+	deadLetters    DeadLetterRedeliverer
+	replays        *streamingpkg.ReplayStore[models.StreamEvent]
 	logger         *zap.Logger
 }
 
@@ -42,10 +47,59 @@ func NewChatHandlers(llmService LlmInteractiontService,
 		llmService:     llmService,
 		profileService: profileService,
 		chatRepository: chatRepository,
+		domainDetector: &models.DomainDetector{},
+		replays:        streamingpkg.NewReplayStore[models.StreamEvent](streamingpkg.DefaultReplayCapacity, streamingpkg.DefaultReplayTTL),
 		logger:         logger,
 	}
 }
 
+// WithDomainDetector replaces the keyword based detector used to route search queries.
+func (h *ChatHandlers) WithDomainDetector(d DomainDetector) *ChatHandlers {
+	if d != nil {
+		h.domainDetector = d
+	}
+	return h
+}
+
+// WithDeadLetters re-delivers the events a session missed when its client reconnects.
+func (h *ChatHandlers) WithDeadLetters(r DeadLetterRedeliverer) *ChatHandlers {
+	h.deadLetters = r
+	return h
+}
+
+// redeliverDeadLetters writes the events earlier connections of the session missed before the
+// new stream starts. Terminal events are left out: the new stream ends with its own. Each event
+// is flushed before the next, so the ones the client never got stay queued for its next
+// connection.
+func (h *ChatHandlers) redeliverDeadLetters(c *gin.Context, sessionID uuid.UUID) {
+	if h.deadLetters == nil {
+		return
+	}
+	// gin's Flush drops the error of the connection, flush the writer it wraps instead
+	var w http.ResponseWriter = c.Writer
+	if u, ok := w.(interface{ Unwrap() http.ResponseWriter }); ok {
+		w = u.Unwrap()
+	}
+	rc := http.NewResponseController(w)
+	err := h.deadLetters.Redeliver(c.Request.Context(), sessionID, func(event models.StreamEvent) error {
+		if event.Type == models.EventTypeComplete || event.Type == models.EventTypeError || event.IsFinal {
+			return nil
+		}
+		eventData, err := json.Marshal(event)
+		if err != nil {
+			h.logger.Error("Failed to marshal re-delivered event", zap.Error(err))
+			return nil
+		}
+		if err := streamingpkg.WriteSSE(c.Writer, "", "", eventData); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err != nil {
+		h.logger.Warn("Failed to re-deliver missed stream events", zap.String("sessionID", sessionID.String()), zap.Error(err))
+	}
+}
+
 // HandleChatStreamConnect creates an SSE connection setup for HTMX
 func (h *ChatHandlers) HandleChatStreamConnect(c *gin.Context) {
 	h.logger.Info("Chat stream connect request received",
@@ -239,8 +293,8 @@ func (h *ChatHandlers) HandleChatStreamConnect(c *gin.Context) {
 						var eventData = JSON.parse(event.data);
 						console.log('Native SSE Event received:', eventData);
 						
-						// Handle completion event with navigation
-						if (eventData.type === 'complete' && eventData.navigation && !isProcessingComplete) {
+						// Handle completion event with navigation, errors navigate too (e.g. to /pricing when over quota)
+						if ((eventData.type === 'complete' || eventData.type === 'error') && eventData.navigation && !isProcessingComplete) {
 							isProcessingComplete = true;
 							console.log('Processing complete detected, navigating to:', eventData.navigation.url);
 							handleNavigation(eventData.navigation.url);
@@ -404,6 +458,11 @@ func (h *ChatHandlers) SendMessage(c *gin.Context) {
 		return
 	}
 
+	// The request is valid, count the search before generating anything
+	if !quota.ChargeSearch(c) {
+		return
+	}
+
 	// Process the message through AI service for itinerary modification
 	// Create event channel for potential streaming response
 	eventCh := make(chan models.StreamEvent, 100)
@@ -488,9 +547,13 @@ func (h *ChatHandlers) HandleSearch(c *gin.Context) {
 		zap.String("ip", c.ClientIP()),
 	)
 
+	// The search is counted here, the results page it redirects to generates the answer
+	if !quota.ChargeSearch(c) {
+		return
+	}
+
 	// Detect domain using DomainDetector
-	domainDetector := &models.DomainDetector{}
-	domain := domainDetector.DetectDomain(context.Background(), query)
+	domain := h.domainDetector.DetectDomain(c.Request.Context(), query)
 
 	h.logger.Info("Domain detected",
 		zap.String("query", query),
@@ -662,6 +725,9 @@ func (h *ChatHandlers) HandleDiscover(c *gin.Context) {
 		c.String(http.StatusUnauthorized, "Authentication required")
 		return
 	}
+	if !quota.ChargeSearch(c) {
+		return
+	}
 
 	// Call LLM service and get streaming data
 	llmData, redirectURL, err := h.callLLMStreamingServiceWithData(query, user.ID)
@@ -865,9 +931,13 @@ func (h *ChatHandlers) ProcessUnifiedChatMessageStream(c *gin.Context) {
 		return
 	}
 
-	// Get user ID for authenticated users
+	// Get user ID for authenticated users; guests get free mode
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
+		if _, ok := middleware.GetGuestFromContext(c); ok {
+			h.streamGuest(c, message)
+			return
+		}
 		c.Redirect(http.StatusFound, "/auth/signin")
 		return
 	}
@@ -933,27 +1003,20 @@ func (h *ChatHandlers) ProcessUnifiedChatMessageStream(c *gin.Context) {
 	}
 	fmt.Printf("profileID: %s\n", profileID.String())
 
+	// The request is valid, count the search before generating anything
+	if !quota.ChargeSearch(c) {
+		return
+	}
+
 	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Headers", "Cache-Control")
-	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
-
-	// Set up flusher for real-time streaming
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
+	if !setStreamHeaders(c) {
 		h.logger.Error("Response writer does not support flushing")
 		c.String(http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 
-	// Create event channel for streaming (larger buffer for Gemini SDK)
-	eventCh := make(chan models.StreamEvent, 200)
-
-	// Process the request in a goroutine
-	go func() {
+	// Process the request detached from the connection, so a dropped client can resume it
+	h.streamResumable(middleware.CreateContextWithUser(c), c, uuid.NewString(), func(ctx context.Context, eventCh chan models.StreamEvent) {
 		// Don't close eventCh here - let the service handle it
 		h.logger.Info("Processing authenticated user request",
 			zap.String("userID", userID.String()),
@@ -962,7 +1025,7 @@ func (h *ChatHandlers) ProcessUnifiedChatMessageStream(c *gin.Context) {
 
 		// Call the LLM service with proper user and profile IDs
 		err := h.llmService.ProcessUnifiedChatMessageStream(
-			middleware.CreateContextWithUser(c),
+			ctx,
 			userID,
 			profileID,
 			"", // cityName - empty for auto-detection
@@ -980,60 +1043,7 @@ func (h *ChatHandlers) ProcessUnifiedChatMessageStream(c *gin.Context) {
 				EventID:   uuid.New().String(),
 			}
 		}
-	}()
-
-	// Stream events to client as they arrive
-	for {
-		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				h.logger.Info("Event channel closed, ending stream")
-				return
-			}
-
-			eventData, err := json.Marshal(event)
-			if err != nil {
-				h.logger.Error("Failed to marshal event", zap.Error(err))
-				continue
-			}
-
-			// Print streamed response to terminal
-			fmt.Printf("SSE >> %s\n", eventData)
-
-			//debugger.DebugPrintEvents(_, eventData)
-			//filePath := "events.json" // Change to "events.txt" for text format
-			//f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-			//if err != nil {
-			//	h.logger.Error("Failed to open file", zap.Error(err), zap.String("file", filePath))
-			//	continue
-			//}
-			//defer f.Close()
-
-			// For JSON: Write eventData with a newline for separation
-			//if _, err := f.Write(append(eventData, '\n')); err != nil {
-			//	h.logger.Error("Failed to write to file", zap.Error(err), zap.String("file", filePath))
-			//	continue
-			//}
-
-			fmt.Fprintf(c.Writer, "data: %s\n\n", eventData)
-			flusher.Flush()
-
-			// End stream on complete or error
-			if event.Type == models.EventTypeComplete || event.Type == models.EventTypeError {
-				h.logger.Info("Stream completed", zap.String("eventType", event.Type))
-
-				// Send a final SSE close message to help HTMX understand the connection is intentionally closed
-				fmt.Fprintf(c.Writer, "data: {\"type\":\"sse-close\"}\n\n")
-				flusher.Flush()
-
-				return
-			}
-
-		case <-c.Request.Context().Done():
-			h.logger.Info("Client disconnected")
-			return
-		}
-	}
+	})
 }
 
 // HandleChatStream handles SSE streaming for chat messages in itinerary modification
@@ -1085,26 +1095,21 @@ func (h *ChatHandlers) HandleChatStream(c *gin.Context) {
 	}
 
 	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Headers", "Cache-Control")
-	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
-
-	// Set up flusher for real-time streaming
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
+	if !setStreamHeaders(c) {
 		h.logger.Error("Response writer does not support flushing")
 		c.String(http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 
-	// Create event channel for streaming
-	eventCh := make(chan models.StreamEvent, 200)
+	// Reconnecting to a session: send the events the previous connection missed first
+	streamKey := uuid.NewString()
+	if parsedSessionID, err := uuid.Parse(sessionID); err == nil {
+		h.redeliverDeadLetters(c, parsedSessionID)
+		streamKey = parsedSessionID.String()
+	}
 
-	// Process the request in a goroutine
-	go func() {
+	// Process the request detached from the connection, so a dropped client can resume it
+	h.streamResumable(c.Request.Context(), c, streamKey, func(ctx context.Context, eventCh chan models.StreamEvent) {
 		h.logger.Info("Processing chat stream request",
 			zap.String("userID", userID.String()),
 			zap.String("profileID", profile.ID.String()),
@@ -1113,7 +1118,7 @@ func (h *ChatHandlers) HandleChatStream(c *gin.Context) {
 
 		// Call the LLM service for itinerary modification
 		err := h.llmService.ProcessUnifiedChatMessageStream(
-			c.Request.Context(),
+			ctx,
 			userID,
 			profile.ID,
 			"", // cityName - empty for context-based
@@ -1131,45 +1136,7 @@ func (h *ChatHandlers) HandleChatStream(c *gin.Context) {
 				EventID:   uuid.New().String(),
 			}
 		}
-	}()
-
-	// Stream events to client as they arrive
-	for {
-		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				h.logger.Info("Chat stream channel closed, ending stream")
-				return
-			}
-
-			eventData, err := json.Marshal(event)
-			if err != nil {
-				h.logger.Error("Failed to marshal chat event", zap.Error(err))
-				continue
-			}
-
-			// Print streamed response to terminal
-			fmt.Printf("Chat SSE >> %s\n", eventData)
-
-			fmt.Fprintf(c.Writer, "data: %s\n\n", eventData)
-			flusher.Flush()
-
-			// End stream on complete or error
-			if event.Type == models.EventTypeComplete || event.Type == models.EventTypeError {
-				h.logger.Info("Chat stream completed", zap.String("eventType", event.Type))
-
-				// Send a final SSE close message
-				fmt.Fprintf(c.Writer, "data: {\"type\":\"sse-close\"}\n\n")
-				flusher.Flush()
-
-				return
-			}
-
-		case <-c.Request.Context().Done():
-			h.logger.Info("Chat stream client disconnected")
-			return
-		}
-	}
+	})
 }
 
 // HandleItineraryStream handles SSE streaming for itinerary queries using app service
@@ -1185,13 +1152,6 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 		return
 	}
 
-	// Set SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Headers", "Cache-Control")
-
 	// Get user info (same logic as ProcessUnifiedChatMessageStream)
 	user := middleware.GetUserFromContext(c)
 	if user == nil {
@@ -1199,17 +1159,20 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 		return
 	}
 	userIDStr := user.ID
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.String(http.StatusInternalServerError, "Streaming unsupported")
+
+	// The request is valid, count the search before generating anything
+	if !quota.ChargeSearch(c) {
 		return
 	}
 
-	// Create event channel for streaming
-	eventCh := make(chan models.StreamEvent, 100)
+	// Set SSE headers
+	if !setStreamHeaders(c) {
+		c.String(http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
 
-	// Process the request in a goroutine
-	go func() {
+	// Process the request detached from the connection, so a dropped client can resume it
+	h.streamResumable(c.Request.Context(), c, uuid.NewString(), func(ctx context.Context, eventCh chan models.StreamEvent) {
 		// Don't close eventCh here - let the service handle it
 
 		if userIDStr != "" && userIDStr != "anonymous" {
@@ -1225,7 +1188,7 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 			}
 
 			// Get profile ID
-			profileID, err := h.getDefaultProfileID(c.Request.Context(), userID)
+			profileID, err := h.getDefaultProfileID(ctx, userID)
 			if err != nil {
 				eventCh <- models.StreamEvent{
 					Type:    models.EventTypeError,
@@ -1237,7 +1200,7 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 
 			// Call the actual LLM service with profile
 			err = h.llmService.ProcessUnifiedChatMessageStream(
-				c.Request.Context(),
+				ctx,
 				userID,
 				profileID,
 				"", // cityName - empty for auto-detection
@@ -1255,7 +1218,7 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 		} else {
 			// Free/unauthenticated user path
 			err := h.llmService.ProcessUnifiedChatMessageStreamFree(
-				c.Request.Context(),
+				ctx,
 				"", // cityName
 				message,
 				nil, // userLocation
@@ -1269,40 +1232,12 @@ func (h *ChatHandlers) HandleItineraryStream(c *gin.Context) {
 				}
 			}
 		}
-	}()
-
-	// Stream events to client as they arrive
-	for {
-		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				// Channel closed, streaming complete
-				h.logger.Info("Itinerary stream completed",
-					zap.String("message", message),
-					zap.String("user", userIDStr),
-				)
-				return
-			}
+	})
 
-			// Convert event to JSON and send via SSE
-			eventData, err := json.Marshal(event)
-			if err != nil {
-				h.logger.Error("Failed to marshal event", zap.Error(err))
-				continue
-			}
-
-			// Print streamed response to terminal
-			fmt.Printf("SSE >> %s\n", eventData)
-
-			fmt.Fprintf(c.Writer, "data: %s\n\n", eventData)
-			flusher.Flush()
-
-		case <-c.Request.Context().Done():
-			// Client disconnected
-			h.logger.Info("Client disconnected from itinerary stream")
-			return
-		}
-	}
+	h.logger.Info("Itinerary stream ended",
+		zap.String("message", message),
+		zap.String("user", userIDStr),
+	)
 }
 
 // ContinueChatSession handles continuing an existing chat session with HTMX SSE
@@ -1351,30 +1286,21 @@ func (h *ChatHandlers) ContinueChatSession(c *gin.Context) {
 	)
 
 	// Set up SSE headers
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Headers", "Cache-Control")
-	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
-
-	// Get flusher
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
+	if !setStreamHeaders(c) {
 		h.logger.Error("Response writer does not support flushing")
 		c.HTML(http.StatusInternalServerError, "", `<div class="text-red-500 text-sm p-4">Streaming not supported</div>`)
 		return
 	}
 
-	// Create event channel
-	eventCh := make(chan models.StreamEvent, 100)
+	// Events the previous connection to this session missed come first
+	h.redeliverDeadLetters(c, sessionID)
 
-	// Start processing in a goroutine
-	go func() {
+	// Process the message detached from the connection, so a dropped client can resume it
+	h.streamResumable(c.Request.Context(), c, sessionID.String(), func(ctx context.Context, eventCh chan models.StreamEvent) {
 		defer close(eventCh)
 
 		err := h.llmService.ContinueSessionStreamed(
-			c.Request.Context(),
+			ctx,
 			sessionID,
 			req.Message,
 			req.UserLocation,
@@ -1391,42 +1317,9 @@ func (h *ChatHandlers) ContinueChatSession(c *gin.Context) {
 				EventID:   uuid.New().String(),
 				IsFinal:   true,
 			}:
-			case <-c.Request.Context().Done():
-				return
-			}
-		}
-	}()
-
-	// Process events in real-time as they arrive
-	for {
-		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				h.logger.Info("Event channel closed, ending stream")
+			case <-ctx.Done():
 				return
 			}
-
-			eventData, err := json.Marshal(event)
-			if err != nil {
-				h.logger.Error("Failed to marshal event", zap.Error(err))
-				continue
-			}
-
-			// Print streamed response to terminal
-			fmt.Printf("SSE >> %s\n", eventData)
-
-			fmt.Fprintf(c.Writer, "data: %s\n\n", eventData)
-			flusher.Flush()
-
-			if event.Type == models.EventTypeComplete || event.Type == models.EventTypeError {
-				h.logger.Info("Stream completed", zap.String("eventType", event.Type))
-				return
-			}
-
-		case <-c.Request.Context().Done():
-			// Client disconnected
-			h.logger.Info("Client disconnected from continue session stream")
-			return
 		}
-	}
+	})
 }
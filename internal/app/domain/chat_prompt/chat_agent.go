@@ -0,0 +1,346 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/costs"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/agent"
+	"github.com/FACorreiaa/go-templui/internal/pkg/jsonschema"
+)
+
+const (
+	agentMaxResults       = 10  // Items per tool result, the model only needs the best few
+	agentDescriptionRunes = 160 // Descriptions are cut to this in tool results
+	agentSemanticWeight   = 0.7 // Weight of the embedding similarity in search_pois_hybrid
+	agentDefaultRadiusKm  = 3
+)
+
+// AgentLists is the part of the lists service the chat agent uses.
+type AgentLists interface {
+	GetUserLists(ctx context.Context, userID uuid.UUID, isItinerary bool) ([]*models.List, error)
+	AddPOIListItem(ctx context.Context, userID, listID, poiID uuid.UUID, params models.AddListItemRequest) (*models.ListItem, error)
+}
+
+// WithAgent answers chat questions with a tool-calling loop over the stored POIs, cities,
+// favourites and lists instead of a single prompt. maxIterations caps the tool calls per turn;
+// lists may be nil to leave the list tools out.
+func (l *ServiceImpl) WithAgent(maxIterations int, lists AgentLists) *ServiceImpl {
+	l.agentIterations = maxIterations
+	l.agentLists = lists
+	if l.agentIterations <= 0 {
+		l.agentIterations = agent.DefaultMaxIterations
+	}
+	return l
+}
+
+// answerWithAgent answers a question, letting the model call the agent tools first. Tool calls
+// and results are streamed as they happen and the turn is logged with every call it made.
+func (l *ServiceImpl) answerWithAgent(ctx context.Context, session *models.ChatSession, question, conversation string,
+	city *models.CityDetail, userLocation *models.UserLocation, eventCh chan<- models.StreamEvent) (string, error) {
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "answerWithAgent")
+	defer span.End()
+
+	if l.budgetLevel(ctx, session.UserID) == costs.LevelHard {
+		span.SetAttributes(attribute.Bool("budget.exceeded", true))
+		return "I can't answer new questions right now because the AI usage limit has been reached. Your itinerary is still available, please try again later.", nil
+	}
+
+	loop := agent.New(l.llmProvider, l.agentIterations, l.agentTools(session, city, userLocation)...)
+	startTime := time.Now()
+	result, err := loop.Run(ctx, session.ID.String(), question, conversation, agent.Observer{
+		ToolCall: func(s agent.Step) {
+			l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeToolCall, Data: s}, 3)
+		},
+		ToolResult: func(s agent.Step) {
+			l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeToolResult, Data: s}, 3)
+		},
+	})
+	span.SetAttributes(
+		attribute.Int("agent.tool_calls", len(result.Steps)),
+		attribute.Int("agent.model_calls", result.ModelCalls),
+		attribute.Bool("agent.cap_reached", result.CapReached),
+	)
+
+	interaction := models.LlmInteraction{
+		SessionID:        session.ID,
+		UserID:           session.UserID,
+		Prompt:           result.Prompt.Text,
+		PromptID:         result.Prompt.ID,
+		PromptVersion:    result.Prompt.Version,
+		ResponseText:     result.Answer,
+		ModelUsed:        l.llmProvider.Model(),
+		Provider:         l.llmProvider.Name(),
+		LatencyMs:        int(time.Since(startTime).Milliseconds()),
+		CityName:         session.SessionContext.CityName,
+		Intent:           string(models.IntentAskQuestion),
+		SearchType:       "agent",
+		PromptTokens:     result.PromptTokens,
+		CompletionTokens: result.CompletionTokens,
+		TotalTokens:      result.PromptTokens + result.CompletionTokens,
+	}
+	if len(result.Steps) > 0 {
+		if toolCalls, err := json.Marshal(result.Steps); err == nil {
+			interaction.ToolCalls = toolCalls
+		}
+	}
+	if err != nil {
+		interaction.StatusCode = 500
+		interaction.ErrorMessage = err.Error()
+	}
+	if _, saveErr := l.llmInteractionRepo.SaveInteraction(ctx, interaction); saveErr != nil {
+		l.logger.Warn("Failed to save agent interaction", zap.Error(saveErr))
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	if result.Answer == "" {
+		return "", fmt.Errorf("empty answer from agent")
+	}
+	return result.Answer, nil
+}
+
+// agentPOI is a POI as tool results show it.
+type agentPOI struct {
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	Category       string    `json:"category,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	DistanceMeters float64   `json:"distance_meters,omitempty"`
+	Rating         float64   `json:"rating,omitempty"`
+}
+
+func agentPOIs(pois []models.POIDetailedInfo) []agentPOI {
+	out := make([]agentPOI, 0, min(len(pois), agentMaxResults))
+	for _, p := range pois[:min(len(pois), agentMaxResults)] {
+		description := p.DescriptionPOI
+		if description == "" {
+			description = p.Description
+		}
+		out = append(out, agentPOI{
+			ID:             p.ID,
+			Name:           p.Name,
+			Category:       p.Category,
+			Description:    truncateRunes(description, agentDescriptionRunes),
+			Latitude:       p.Latitude,
+			Longitude:      p.Longitude,
+			DistanceMeters: p.Distance,
+			Rating:         p.Rating,
+		})
+	}
+	return out
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "..."
+}
+
+var (
+	searchPOIsSchema = jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "minLength": 2, "description": "what the user is looking for, e.g. 'quiet rooftop bar'"},
+			"category": {"type": "string", "description": "optional POI category, e.g. restaurant, museum, bar"},
+			"radius_km": {"type": "number", "minimum": 0.1, "maximum": 50}
+		},
+		"required": ["query"]
+	}`))
+	poisNearSchema = jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"properties": {
+			"latitude": {"type": "number", "minimum": -90, "maximum": 90},
+			"longitude": {"type": "number", "minimum": -180, "maximum": 180},
+			"radius_km": {"type": "number", "minimum": 0.1, "maximum": 20}
+		}
+	}`))
+	similarCitiesSchema = jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"properties": {
+			"description": {"type": "string", "minLength": 3, "description": "the kind of city, e.g. 'small coastal town with seafood'"},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 10}
+		},
+		"required": ["description"]
+	}`))
+	noArgumentsSchema = jsonschema.MustCompile([]byte(`{"type": "object"}`))
+	getListsSchema    = jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"properties": {
+			"itineraries": {"type": "boolean", "description": "true for the user's itineraries, false for their other lists"}
+		}
+	}`))
+	addToListSchema = jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"properties": {
+			"list_id": {"type": "string", "minLength": 36},
+			"poi_id": {"type": "string", "minLength": 36},
+			"notes": {"type": "string"}
+		},
+		"required": ["list_id", "poi_id"]
+	}`))
+)
+
+// agentTools binds the agent tools to the user, city and location of one chat turn.
+func (l *ServiceImpl) agentTools(session *models.ChatSession, city *models.CityDetail, userLocation *models.UserLocation) []agent.Tool {
+	// Searches are centred on the user when their location is known, on the city otherwise
+	center := models.GeoPoint{Latitude: city.CenterLatitude, Longitude: city.CenterLongitude}
+	if userLocation != nil && (userLocation.UserLat != 0 || userLocation.UserLon != 0) {
+		center = models.GeoPoint{Latitude: userLocation.UserLat, Longitude: userLocation.UserLon}
+	}
+
+	tools := []agent.Tool{
+		agent.NewTool("search_pois_hybrid",
+			fmt.Sprintf("Stored POIs in %s ranked by how well they match the query and how close they are.", city.Name),
+			searchPOIsSchema,
+			func(ctx context.Context, args struct {
+				Query    string  `json:"query"`
+				Category string  `json:"category"`
+				RadiusKm float64 `json:"radius_km"`
+			}) (any, error) {
+				embedding, err := l.llmProvider.GenerateQueryEmbedding(ctx, args.Query)
+				if err != nil {
+					return nil, fmt.Errorf("failed to embed query: %w", err)
+				}
+				radius := args.RadiusKm
+				if radius == 0 {
+					radius = agentDefaultRadiusKm
+				}
+				pois, err := l.poiRepo.SearchPOIsHybrid(ctx, models.POIFilter{Location: center, Radius: radius, Category: args.Category}, embedding, agentSemanticWeight)
+				if err != nil {
+					return nil, err
+				}
+				return agentPOIs(pois), nil
+			}),
+		agent.NewTool("pois_near",
+			"Stored POIs within a radius of a point, nearest first. The point defaults to the user's location, or the city centre.",
+			poisNearSchema,
+			func(ctx context.Context, args struct {
+				Latitude  *float64 `json:"latitude"`
+				Longitude *float64 `json:"longitude"`
+				RadiusKm  float64  `json:"radius_km"`
+			}) (any, error) {
+				point := center
+				if args.Latitude != nil && args.Longitude != nil {
+					point = models.GeoPoint{Latitude: *args.Latitude, Longitude: *args.Longitude}
+				}
+				radius := args.RadiusKm
+				if radius == 0 {
+					radius = agentDefaultRadiusKm
+				}
+				pois, err := l.poiRepo.GetPOIsByLocationAndDistance(ctx, point.Latitude, point.Longitude, radius*1000)
+				if err != nil {
+					return nil, err
+				}
+				return agentPOIs(pois), nil
+			}),
+		agent.NewTool("find_similar_cities",
+			"Stored cities matching a description, for questions about where else to go.",
+			similarCitiesSchema,
+			func(ctx context.Context, args struct {
+				Description string `json:"description"`
+				Limit       int    `json:"limit"`
+			}) (any, error) {
+				embedding, err := l.llmProvider.GenerateQueryEmbedding(ctx, args.Description)
+				if err != nil {
+					return nil, fmt.Errorf("failed to embed description: %w", err)
+				}
+				limit := args.Limit
+				if limit == 0 {
+					limit = 5
+				}
+				cities, err := l.cityRepo.FindSimilarCities(ctx, embedding, limit)
+				if err != nil {
+					return nil, err
+				}
+				type agentCity struct {
+					Name    string `json:"name"`
+					Country string `json:"country"`
+					Summary string `json:"summary,omitempty"`
+				}
+				out := make([]agentCity, 0, len(cities))
+				for _, c := range cities {
+					out = append(out, agentCity{Name: c.Name, Country: c.Country, Summary: truncateRunes(c.AiSummary, agentDescriptionRunes)})
+				}
+				return out, nil
+			}),
+		agent.NewTool("get_favorites",
+			"The POIs the user marked as favourites.",
+			noArgumentsSchema,
+			func(ctx context.Context, _ struct{}) (any, error) {
+				pois, err := l.poiRepo.GetFavouritePOIsByUserID(ctx, session.UserID)
+				if err != nil {
+					return nil, err
+				}
+				return agentPOIs(pois), nil
+			}),
+	}
+	if l.agentLists == nil {
+		return tools
+	}
+
+	return append(tools,
+		agent.NewTool("get_lists",
+			"The user's lists, or their itineraries, with their ids and item counts.",
+			getListsSchema,
+			func(ctx context.Context, args struct {
+				Itineraries bool `json:"itineraries"`
+			}) (any, error) {
+				lists, err := l.agentLists.GetUserLists(ctx, session.UserID, args.Itineraries)
+				if err != nil {
+					return nil, err
+				}
+				type agentList struct {
+					ID        uuid.UUID `json:"id"`
+					Name      string    `json:"name"`
+					ItemCount int       `json:"item_count"`
+				}
+				out := make([]agentList, 0, len(lists))
+				for _, list := range lists {
+					out = append(out, agentList{ID: list.ID, Name: list.Name, ItemCount: list.ItemCount})
+				}
+				return out, nil
+			}),
+		agent.NewTool("add_poi_to_list",
+			"Adds a stored POI to one of the user's lists. Only use it when the user asked for it.",
+			addToListSchema,
+			func(ctx context.Context, args struct {
+				ListID string `json:"list_id"`
+				POIID  string `json:"poi_id"`
+				Notes  string `json:"notes"`
+			}) (any, error) {
+				listID, err := uuid.Parse(args.ListID)
+				if err != nil {
+					return nil, fmt.Errorf("list_id is not a valid id: %w", models.ErrBadRequest)
+				}
+				poiID, err := uuid.Parse(args.POIID)
+				if err != nil {
+					return nil, fmt.Errorf("poi_id is not a valid id: %w", models.ErrBadRequest)
+				}
+				item, err := l.agentLists.AddPOIListItem(ctx, session.UserID, listID, poiID, models.AddListItemRequest{
+					ItemID:      poiID,
+					ContentType: models.ContentTypePOI,
+					Notes:       args.Notes,
+				})
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"added": true, "list_id": listID, "position": item.Position}, nil
+			}),
+	)
+}
@@ -0,0 +1,166 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/costs"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	cache2 "github.com/FACorreiaa/go-templui/internal/pkg/cache"
+)
+
+// budgetFallbackModel is logged as the model of answers built from the database once the
+// hard budget is reached, so they show up on their own in the cost ledger.
+const budgetFallbackModel = "budget-fallback"
+
+// maxFallbackPOIs bounds how many stored POIs a database-only answer lists.
+const maxFallbackPOIs = 20
+
+// BudgetChecker reports how far today's LLM spend is over its budget.
+type BudgetChecker interface {
+	CheckBudget(ctx context.Context, userID uuid.UUID) (costs.BudgetStatus, error)
+}
+
+// budgetLevel returns the budget level for the user. Without a cost ledger, or when the spend
+// cannot be read, the model is called as usual.
+func (l *ServiceImpl) budgetLevel(ctx context.Context, userID uuid.UUID) costs.Level {
+	if l.budget == nil {
+		return costs.LevelOK
+	}
+	status, err := l.budget.CheckBudget(ctx, userID)
+	if err != nil {
+		l.logger.Warn("Failed to check LLM budget, calling the model", zap.String("user_id", userID.String()), zap.Error(err))
+		return costs.LevelOK
+	}
+	if status.Degraded() {
+		l.logger.Info("LLM budget exceeded, degrading answers",
+			zap.String("user_id", userID.String()),
+			zap.String("level", status.Level.String()),
+			zap.String("scope", string(status.Scope)),
+			zap.Float64("spent_usd", status.SpentUSD),
+			zap.Float64("limit_usd", status.LimitUSD))
+	}
+	return status.Level
+}
+
+// serveWithinBudget answers a streamed part without calling the model when the budget asks
+// for it: from an earlier answer to the same prompt past the soft limit, and from the
+// database only past the hard limit. It reports whether the part was answered.
+func (l *ServiceImpl) serveWithinBudget(ctx context.Context, level costs.Level, config LoggingConfig, partType, cityName string, domain models.DomainType, sendEvent func(models.StreamEvent)) bool {
+	if level == costs.LevelOK {
+		return false
+	}
+	startTime := time.Now()
+
+	source := "cache"
+	text, ok := cache2.Cache.PartResponses.Get(config.CacheKey)
+	if !ok {
+		if level < costs.LevelHard {
+			return false
+		}
+		var err error
+		text, err = l.partFromDatabase(ctx, partType, cityName)
+		if err != nil {
+			l.logger.Info("No database answer for part over budget",
+				zap.String("part", partType), zap.String("city", cityName), zap.Error(err))
+			if ctx.Err() == nil {
+				sendEvent(models.StreamEvent{
+					Type:  models.EventTypeError,
+					Error: fmt.Sprintf("%s is unavailable: the AI usage budget has been reached, try again later", partType),
+				})
+			}
+			return true
+		}
+		source = "database"
+		config.ModelName = budgetFallbackModel
+	}
+
+	if ctx.Err() == nil {
+		sendEvent(models.StreamEvent{
+			Type: models.EventTypeChunk,
+			Data: map[string]interface{}{
+				"part":            partType,
+				"chunk":           text,
+				"domain":          string(domain),
+				"cache_key":       config.CacheKey,
+				"cache_used":      source == "cache",
+				"budget_fallback": source,
+			},
+		})
+
+		// Same item events as a streamed answer, so clients render cards either way
+		items := newItemStreamer(partType)
+		l.streamItems(ctx, items, text, sendEvent)
+		l.reconcileStreamedItems(items, text, sendEvent)
+	}
+
+	chunks := 1
+	l.llmLogger.LogInteractionAsync(ctx, config, LLMResponse{
+		ResponseText:      text,
+		StatusCode:        200,
+		CacheHit:          true,
+		StreamChunksCount: &chunks,
+	}, time.Since(startTime).Milliseconds())
+	return true
+}
+
+// partFromDatabase builds a schema-shaped answer for a part from the cities and POIs already
+// stored for the city. Only the parts that need nothing but stored data are supported.
+func (l *ServiceImpl) partFromDatabase(ctx context.Context, partType, cityName string) (string, error) {
+	city, err := l.cityRepo.FindCityByNameAndCountry(ctx, cityName, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to find city %s: %w", cityName, err)
+	}
+	if city == nil {
+		return "", fmt.Errorf("city %s: %w", cityName, models.ErrNotFound)
+	}
+
+	var answer any
+	switch partType {
+	case "city_data":
+		answer = models.GeneralCityData{
+			City:            city.Name,
+			Country:         city.Country,
+			StateProvince:   city.StateProvince,
+			Description:     l.localizeCity(ctx, city.ID, city.AiSummary),
+			CenterLatitude:  city.CenterLatitude,
+			CenterLongitude: city.CenterLongitude,
+		}
+	case "general_pois", "itinerary":
+		pois, err := l.poiRepo.GetPOIsByCityID(ctx, city.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get POIs of %s: %w", cityName, err)
+		}
+		if len(pois) == 0 {
+			return "", fmt.Errorf("no stored POIs for %s: %w", cityName, models.ErrNotFound)
+		}
+		pois = l.localizePOIs(ctx, pois[:min(len(pois), maxFallbackPOIs)])
+		for i := range pois {
+			if pois[i].Category == "" {
+				pois[i].Category = "attraction"
+			}
+		}
+		if partType == "general_pois" {
+			answer = map[string]any{"points_of_interest": pois}
+		} else {
+			answer = models.AIItineraryResponse{
+				ItineraryName:      fmt.Sprintf("Highlights of %s", city.Name),
+				OverallDescription: fmt.Sprintf("A selection of places in %s from our catalogue.", city.Name),
+				PointsOfInterest:   pois,
+			}
+		}
+	default:
+		return "", fmt.Errorf("no database answer for %s: %w", partType, models.ErrNotFound)
+	}
+
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s answer: %w", partType, err)
+	}
+	return string(data), nil
+}
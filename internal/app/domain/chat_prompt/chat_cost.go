@@ -0,0 +1,130 @@
+package llmchat
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/budget"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+)
+
+// WithCostEstimates estimates what generated itineraries and trips cost, in the currency of the
+// user's profile, and flags those over its budget level.
+func (l *ServiceImpl) WithCostEstimates(estimator *budget.Estimator) *ServiceImpl {
+	l.estimator = estimator
+	return l
+}
+
+// EstimateCost sets the cost of an itinerary spread over nights nights, or of a day out when
+// nights is 0. Without an estimator, or when the estimate fails, the itinerary has no cost.
+func (l *ServiceImpl) EstimateCost(ctx context.Context, userID uuid.UUID, itinerary *models.AIItineraryResponse, nights int) {
+	if l.estimator == nil || itinerary == nil {
+		return
+	}
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "EstimateCost", trace.WithAttributes(
+		attribute.Int("nights", nights),
+	))
+	defer span.End()
+
+	plan, currency := l.costPlan(ctx, userID)
+	plan.Days, plan.Nights = max(nights, 1), nights
+	plan.Items = costItems(itinerary, 0, plan.Days)
+
+	cost, err := l.estimator.Estimate(ctx, plan, currency)
+	if err != nil {
+		span.RecordError(err)
+		l.logger.Warn("Failed to estimate itinerary cost", zap.String("currency", currency), zap.Error(err))
+		return
+	}
+	itinerary.Cost = cost
+	span.SetAttributes(attribute.Float64("cost.max", cost.Total.Total.Max), attribute.Bool("cost.over_budget", cost.OverBudget))
+}
+
+// TripCost sets the cost of a multi-city trip: its cities one after the other, a day per
+// night, and the transfers between them on the day of arrival.
+func (l *ServiceImpl) TripCost(ctx context.Context, userID uuid.UUID, trip *models.Trip) {
+	if l.estimator == nil || trip == nil {
+		return
+	}
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "TripCost", trace.WithAttributes(
+		attribute.Int("trip.cities", len(trip.Cities)),
+		attribute.Int("trip.nights", trip.TotalNights),
+	))
+	defer span.End()
+
+	plan, currency := l.costPlan(ctx, userID)
+	plan.Days, plan.Nights = max(trip.TotalNights, 1), trip.TotalNights
+	// The day each city starts on, 0-based
+	starts := make([]int, len(trip.Cities))
+	offset := 0
+	for i, c := range trip.Cities {
+		starts[i] = offset
+		if c.Itinerary != nil {
+			plan.Items = append(plan.Items, costItems(c.Itinerary, offset, max(c.Nights, 1))...)
+		}
+		offset += c.Nights
+	}
+	for i, leg := range trip.Legs {
+		if i+1 < len(starts) {
+			plan.Transfers = append(plan.Transfers, budget.Transfer{Mode: leg.Mode, DistanceKm: leg.DistanceKm, Day: starts[i+1] + 1})
+		}
+	}
+
+	cost, err := l.estimator.Estimate(ctx, plan, currency)
+	if err != nil {
+		span.RecordError(err)
+		l.logger.Warn("Failed to estimate trip cost", zap.String("currency", currency), zap.Error(err))
+		return
+	}
+	trip.Cost = cost
+}
+
+// costPlan starts a plan from the user's default profile: budget level, currency and means
+// of transport. Guests and users without a profile are costed as mid-range, in euros, on
+// foot.
+func (l *ServiceImpl) costPlan(ctx context.Context, userID uuid.UUID) (budget.Plan, string) {
+	plan := budget.Plan{Mode: string(routing.ModeWalk)}
+	if userID == uuid.Nil {
+		return plan, "EUR"
+	}
+	profile, err := l.searchProfileSvc.GetDefaultSearchProfile(ctx, userID)
+	if err != nil || profile == nil {
+		return plan, "EUR"
+	}
+	plan.Level = profile.BudgetLevel
+	plan.Mode = string(routing.ModeFor(string(profile.PreferredTransport)))
+	return plan, profile.Currency
+}
+
+// costItems lists what costs money in an itinerary, spread over days days from day offset.
+func costItems(itinerary *models.AIItineraryResponse, offset, days int) []budget.Item {
+	var items []budget.Item
+	i := 0
+	for _, pois := range [][]models.POIDetailedInfo{itinerary.PointsOfInterest, itinerary.Restaurants, itinerary.Bars} {
+		for _, p := range pois {
+			items = append(items, budget.Item{
+				Category: budget.CategoryOf(p.Category),
+				Price:    priceOf(p),
+				Day:      offset + i%days + 1,
+			})
+			i++
+		}
+	}
+	return items
+}
+
+// priceOf is the price of a POI as the model gave it, whichever field it used.
+func priceOf(p models.POIDetailedInfo) string {
+	for _, s := range []string{p.PriceRange, p.PriceLevel, p.Budget} {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package llmchat
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// deadLetterStoreTimeout bounds how long storing an undelivered event may take. The request
+// it belonged to is usually gone by then, so it is stored under a fresh context.
+const deadLetterStoreTimeout = 5 * time.Second
+
+// DeadLetterRecorder stores stream events that could not be delivered.
+type DeadLetterRecorder interface {
+	Record(ctx context.Context, sessionID, userID uuid.UUID, reason string, event models.StreamEvent) error
+}
+
+// DeadLetterRedeliverer hands the undelivered events of a session to deliver once its client
+// reconnects. Events deliver fails on stay undelivered.
+type DeadLetterRedeliverer interface {
+	Redeliver(ctx context.Context, sessionID uuid.UUID, deliver func(models.StreamEvent) error) error
+}
+
+type streamOwnerKey struct{}
+
+// streamOwner is the session and user the events sent on a context belong to.
+type streamOwner struct {
+	sessionID uuid.UUID
+	userID    uuid.UUID
+}
+
+// withStreamOwner records the session and user of a stream on ctx, so that events sendEvent
+// fails to deliver can be filed under them.
+func withStreamOwner(ctx context.Context, sessionID, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, streamOwnerKey{}, streamOwner{sessionID: sessionID, userID: userID})
+}
+
+type deadLetterEntry struct {
+	owner  streamOwner
+	reason string
+	event  models.StreamEvent
+}
+
+// deadLetter queues an undelivered event for storage. It never blocks the stream: when the
+// queue is full the event is only logged.
+func (l *ServiceImpl) deadLetter(ctx context.Context, event models.StreamEvent, reason string) {
+	owner, _ := ctx.Value(streamOwnerKey{}).(streamOwner)
+	select {
+	case l.deadLetterCh <- deadLetterEntry{owner: owner, reason: reason, event: event}:
+	default:
+		l.logger.Error("Dead letter queue full, dropping stream event",
+			zap.String("session_id", owner.sessionID.String()),
+			zap.String("eventType", event.Type),
+			zap.String("reason", reason))
+	}
+}
+
+func (l *ServiceImpl) processDeadLetterQueue() {
+	for entry := range l.deadLetterCh {
+		l.logger.Warn("Stream event sent to dead letter queue",
+			zap.String("session_id", entry.owner.sessionID.String()),
+			zap.String("eventType", entry.event.Type),
+			zap.String("eventID", entry.event.EventID),
+			zap.String("reason", entry.reason))
+		if l.deadLetters == nil {
+			continue
+		}
+		// Events of streams without a session cannot be re-delivered, keep them in the logs only
+		if entry.owner.sessionID == uuid.Nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), deadLetterStoreTimeout)
+		if err := l.deadLetters.Record(ctx, entry.owner.sessionID, entry.owner.userID, entry.reason, entry.event); err != nil {
+			l.logger.Error("Failed to store dead letter", zap.String("eventID", entry.event.EventID), zap.Error(err))
+		}
+		cancel()
+	}
+}
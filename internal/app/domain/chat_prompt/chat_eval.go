@@ -0,0 +1,188 @@
+package llmchat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompteval"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+var _ prompteval.Pipeline = (*EvalPipeline)(nil)
+
+// EvalPipeline runs golden cases through the personalised part of the unified chat stream:
+// the query picks the domain, the profile is rendered into the preferences block, and the
+// answer is validated against the part's schema and parsed like a streamed one. Prompt
+// versions are pinned instead of resolved from the A/B splits, and schema repair is left
+// out so the scores show what the prompt gets right on its own.
+type EvalPipeline struct {
+	provider llmprovider.Provider
+	registry *prompts.Registry
+	versions map[string]int // Prompt id to version, the latest one when missing
+	detector DomainDetector
+	logger   *zap.Logger
+}
+
+// NewEvalPipeline creates a pipeline generating with provider from the templates of
+// registry, at the versions given per prompt id.
+func NewEvalPipeline(provider llmprovider.Provider, registry *prompts.Registry, versions map[string]int, logger *zap.Logger) *EvalPipeline {
+	return &EvalPipeline{
+		provider: provider,
+		registry: registry,
+		versions: versions,
+		detector: &models.DomainDetector{},
+		logger:   logger,
+	}
+}
+
+// evalPart is the personalised prompt the unified stream sends for a domain.
+type evalPart struct {
+	partType string
+	promptID string
+	params   func(c prompteval.Case, preferences string) any
+}
+
+func evalPartFor(domain models.DomainType) evalPart {
+	located := func(c prompteval.Case, preferences string) any {
+		return prompts.CityLocationParams{CityName: c.City, Lat: c.CenterLatitude, Lon: c.CenterLongitude, Preferences: preferences}
+	}
+	switch domain {
+	case models.DomainAccommodation:
+		return evalPart{partType: "hotels", promptID: prompts.Accommodation.ID, params: located}
+	case models.DomainDining:
+		return evalPart{partType: "restaurants", promptID: prompts.Dining.ID, params: located}
+	case models.DomainActivities:
+		return evalPart{partType: "activities", promptID: prompts.Activities.ID, params: located}
+	default:
+		return evalPart{partType: "itinerary", promptID: prompts.PersonalizedItinerary.ID, params: func(c prompteval.Case, preferences string) any {
+			return prompts.CityPreferencesParams{CityName: c.City, Preferences: preferences}
+		}}
+	}
+}
+
+// Generate answers one case. Only provider and rendering failures are returned; answers
+// that fail validation or parsing are reported on the part for the scorer.
+func (p *EvalPipeline) Generate(ctx context.Context, c prompteval.Case) (*prompteval.Output, error) {
+	domain := p.detector.DetectDomain(ctx, c.Query)
+	part := evalPartFor(domain)
+	version, ok := p.versions[part.promptID]
+	if !ok {
+		version = p.registry.Latest(part.promptID)
+	}
+
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "EvalPipeline.Generate", trace.WithAttributes(
+		attribute.String("eval.case", c.Name),
+		attribute.String("eval.domain", string(domain)),
+		attribute.String("prompt.id", part.promptID),
+		attribute.Int("prompt.version", version),
+	))
+	defer span.End()
+
+	profile := c.Profile
+	prompt, err := p.registry.Render(part.promptID, version, part.params(c, getUserPreferencesPrompt(&profile)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to render prompt")
+		return nil, fmt.Errorf("failed to render prompt for case %s: %w", c.Name, err)
+	}
+
+	// Streamed like the unified chat so recorded stream cassettes replay here as well
+	stream, err := p.provider.GenerateContentStream(ctx, prompt.Text, &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](defaultTemperature)})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to generate")
+		return nil, fmt.Errorf("failed to generate case %s: %w", c.Name, err)
+	}
+	var text strings.Builder
+	for resp, err := range stream {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Stream failed")
+			return nil, fmt.Errorf("stream failed for case %s: %w", c.Name, err)
+		}
+		text.WriteString(llmprovider.TextFromResponse(resp))
+	}
+
+	out := prompteval.Part{
+		Name:          part.partType,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		Raw:           text.String(),
+	}
+	for _, e := range validatePartResponse(part.partType, out.Raw) {
+		out.SchemaErrors = append(out.SchemaErrors, e.Error())
+	}
+	out.POIs, err = p.parsePart(part.partType, out.Raw)
+	if err != nil {
+		out.ParseError = err.Error()
+	}
+
+	p.logger.Debug("Evaluated case",
+		zap.String("case", c.Name),
+		zap.String("part", part.partType),
+		zap.Int("prompt_version", prompt.Version),
+		zap.Int("schema_errors", len(out.SchemaErrors)),
+		zap.Int("pois", len(out.POIs)))
+	span.SetStatus(codes.Ok, "Case generated")
+	return &prompteval.Output{Parts: []prompteval.Part{out}}, nil
+}
+
+// parsePart reads the places of a part into POIs, the shape every part is scored in.
+func (p *EvalPipeline) parsePart(partType, text string) ([]models.POIDetailedInfo, error) {
+	switch partType {
+	case "restaurants":
+		restaurants, err := parseRestaurantsFromResponse(text, p.logger)
+		if err != nil {
+			return nil, err
+		}
+		pois := make([]models.POIDetailedInfo, len(restaurants))
+		for i, r := range restaurants {
+			pois[i] = models.POIDetailedInfo{
+				Name: r.Name, Latitude: r.Latitude, Longitude: r.Longitude, Category: r.Category,
+				Description: r.Description, Tags: r.Tags, Rating: r.Rating,
+				PriceLevel: valueOf(r.PriceLevel), CuisineType: valueOf(r.CuisineType),
+			}
+		}
+		return pois, nil
+	case "hotels":
+		hotels, err := parseHotelsFromResponse(text, p.logger)
+		if err != nil {
+			return nil, err
+		}
+		pois := make([]models.POIDetailedInfo, len(hotels))
+		for i, h := range hotels {
+			pois[i] = models.POIDetailedInfo{
+				Name: h.Name, Latitude: h.Latitude, Longitude: h.Longitude, Category: h.Category,
+				Description: h.Description, Tags: h.Tags, Rating: h.Rating, PriceRange: valueOf(h.PriceRange),
+			}
+		}
+		return pois, nil
+	case "activities":
+		return parseActivitiesFromResponse(text, p.logger)
+	default:
+		itinerary, err := parseItineraryFromResponse(text, p.logger)
+		if err != nil {
+			return nil, err
+		}
+		pois := append([]models.POIDetailedInfo{}, itinerary.PointsOfInterest...)
+		pois = append(pois, itinerary.Restaurants...)
+		return append(pois, itinerary.Bars...), nil
+	}
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,30 @@
+package llmchat
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/geocheck"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// GeoChecker validates the coordinates of generated POIs before they are saved.
+type GeoChecker interface {
+	Check(ctx context.Context, cityID uuid.UUID, pois []models.POIDetailedInfo, src geocheck.Source) ([]models.POIDetailedInfo, geocheck.Report)
+}
+
+// WithGeoCheck runs the geographic checks on every batch of generated POIs before it is saved.
+func (l *ServiceImpl) WithGeoCheck(c GeoChecker) *ServiceImpl {
+	l.geoCheck = c
+	return l
+}
+
+// checkPOIs returns the POIs of a part that may be saved. Without a checker all of them are.
+func (l *ServiceImpl) checkPOIs(ctx context.Context, cityID uuid.UUID, pois []models.POIDetailedInfo, part string, llmInteractionID uuid.UUID) []models.POIDetailedInfo {
+	if l.geoCheck == nil {
+		return pois
+	}
+	kept, _ := l.geoCheck.Check(ctx, cityID, pois, geocheck.Source{Part: part, LlmInteractionID: llmInteractionID})
+	return kept
+}
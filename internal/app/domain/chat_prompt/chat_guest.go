@@ -0,0 +1,53 @@
+package llmchat
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/quota"
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// streamGuest answers a visitor without an account in free mode. The session is kept under
+// the guest id in the request context and moved onto the account the guest signs up with.
+func (h *ChatHandlers) streamGuest(c *gin.Context, message string) {
+	guestID, _ := middleware.GetGuestFromContext(c)
+	if !quota.ChargeSearch(c) {
+		return
+	}
+	if !setStreamHeaders(c) {
+		h.logger.Error("Response writer does not support flushing")
+		c.String(http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	h.streamResumable(c.Request.Context(), c, uuid.NewString(), func(ctx context.Context, eventCh chan models.StreamEvent) {
+		h.logger.Info("Processing guest request",
+			zap.String("guestID", guestID.String()),
+			zap.String("message", message))
+
+		err := h.llmService.ProcessUnifiedChatMessageStreamFree(
+			ctx,
+			"", // cityName - empty for auto-detection
+			message,
+			nil, // userLocation
+			eventCh,
+		)
+		if err != nil {
+			h.logger.Error("Failed to process guest chat stream", zap.Error(err))
+			eventCh <- models.StreamEvent{
+				Type:      models.EventTypeError,
+				Message:   "Failed to process request",
+				Error:     err.Error(),
+				Timestamp: time.Now(),
+				EventID:   uuid.New().String(),
+			}
+		}
+	})
+}
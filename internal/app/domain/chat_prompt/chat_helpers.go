@@ -128,7 +128,7 @@ func (l *ServiceImpl) ProcessAndSaveUnifiedResponse(
 	if poisContent, ok := responses["general_pois"]; ok && poisContent.Len() > 0 {
 		l.logger.Info("Processing general POIs from unified response",
 			zap.Int("content_length", poisContent.Len()))
-		l.handleGeneralPoisFromResponse(ctx, poisContent.String(), cityID)
+		l.handleGeneralPoisFromResponse(ctx, poisContent.String(), cityID, "general_pois", llmInteractionID)
 	}
 
 	// Process itinerary POIs if available
@@ -142,7 +142,7 @@ func (l *ServiceImpl) ProcessAndSaveUnifiedResponse(
 	if activitiesContent, ok := responses["activities"]; ok && activitiesContent.Len() > 0 {
 		l.logger.Info("Processing activities POIs from unified response",
 			zap.Int("content_length", activitiesContent.Len()))
-		l.handleGeneralPoisFromResponse(ctx, activitiesContent.String(), cityID)
+		l.handleGeneralPoisFromResponse(ctx, activitiesContent.String(), cityID, "activities", llmInteractionID)
 	}
 
 	// Process hotel POIs if available (for DomainAccommodation)
@@ -175,7 +175,7 @@ func (l *ServiceImpl) ProcessAndSaveUnifiedResponseFree(
 	if poisContent, ok := responses["general_pois"]; ok && poisContent.Len() > 0 {
 		l.logger.Info("Processing general POIs from unified response",
 			zap.Int("content_length", poisContent.Len()))
-		l.handleGeneralPoisFromResponse(ctx, poisContent.String(), cityID)
+		l.handleGeneralPoisFromResponse(ctx, poisContent.String(), cityID, "general_pois", llmInteractionID)
 	}
 
 	// Process itinerary POIs if available
@@ -189,7 +189,7 @@ func (l *ServiceImpl) ProcessAndSaveUnifiedResponseFree(
 	if activitiesContent, ok := responses["activities"]; ok && activitiesContent.Len() > 0 {
 		l.logger.Info("Processing activities POIs from unified response",
 			zap.Int("content_length", activitiesContent.Len()))
-		l.handleGeneralPoisFromResponse(ctx, activitiesContent.String(), cityID)
+		l.handleGeneralPoisFromResponse(ctx, activitiesContent.String(), cityID, "activities", llmInteractionID)
 	}
 
 	// Process hotel POIs if available (for DomainAccommodation)
@@ -207,7 +207,7 @@ func (l *ServiceImpl) ProcessAndSaveUnifiedResponseFree(
 	}
 }
 
-func (l *ServiceImpl) handleGeneralPoisFromResponse(ctx context.Context, content string, cityID uuid.UUID) {
+func (l *ServiceImpl) handleGeneralPoisFromResponse(ctx context.Context, content string, cityID uuid.UUID, part string, llmInteractionID uuid.UUID) {
 	var poiData struct {
 		PointsOfInterest []models.POIDetailedInfo `json:"points_of_interest"`
 	}
@@ -216,7 +216,7 @@ func (l *ServiceImpl) handleGeneralPoisFromResponse(ctx context.Context, content
 		return
 	}
 
-	l.HandleGeneralPOIs(ctx, poiData.PointsOfInterest, cityID)
+	l.HandleGeneralPOIs(ctx, poiData.PointsOfInterest, cityID, part, llmInteractionID)
 }
 
 func (l *ServiceImpl) handleItineraryFromResponse(
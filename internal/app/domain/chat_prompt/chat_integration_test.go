@@ -4,25 +4,34 @@ package llmchat
 
 import (
 	"context"
-	"go.uber.org/zap"
 	"log"
 	"os"
 	"testing"
 
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"github.com/FACorreiaa/go-templui/internal/app/domain/city"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/interests"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/poi"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/tags"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
 )
 
 var testChatDB *pgxpool.Pool
 var testChatService LlmInteractiontService
 var testChatRepo Repository
 
+// testCassetteDir holds recorded LLM interactions. Tests replay them by default; set
+// LLM_CASSETTE_MODE=record with a real provider configured to refresh the fixtures.
+const testCassetteDir = "testdata/cassettes/recorded"
+
 func TestMain(m *testing.M) {
 	if err := godotenv.Load("../../../.env.test"); err != nil {
 		log.Println("Warning: .env.test file not found for chat integration tests.")
@@ -50,33 +59,46 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Unable to ping test database for chat tests: %v\n", err)
 	}
 
-	logger := zap.New(zap.NewTextHandler(os.Stdout, &zap.HandlerOptions{Level: zap.LevelDebug}))
+	logger, _ := zap.NewDevelopment()
 
 	// Initialize dependencies
-	testChatRepo = NewPostgresRepository(testChatDB, logger)
-	interestRepo := interests.NewPostgresinterestsRepo(testChatDB, logger)
-	profileRepo := profiles.NewPostgresprofilessRepo(testChatDB, logger)
-	tagsRepo := tags.NewPostgrestagsRepo(testChatDB, logger)
-	poiRepo := poi.NewPostgresPOIRepository(testChatDB, logger)
-	cityRepo := city.NewPostgresCityRepository(testChatDB, logger)
-
-	// Initialize AI client (may require API key)
-	var aiClient *generativeAI.AIClient
-	if os.Getenv("GEMINI_API_KEY") != "" {
-		aiClient, err = generativeAI.NewAIClient(context.Background())
-		if err != nil {
-			log.Printf("Warning: Could not initialize AI client for integration tests: %v", err)
-		}
+	testChatRepo = NewRepositoryImpl(testChatDB, logger)
+	interestRepo := interests.NewRepositoryImpl(testChatDB, logger)
+	profileRepo := profiles.NewPostgresUserRepo(testChatDB, logger)
+	tagsRepo := tags.NewRepositoryImpl(testChatDB, logger)
+	poiRepo := poi.NewRepository(testChatDB, logger)
+	cityRepo := city.NewCityRepository(testChatDB, logger)
+	profileService := profiles.NewUserProfilesService(profileRepo, interestRepo, tagsRepo, logger)
+
+	// LLM output comes from cassettes so runs are deterministic and offline
+	cassetteMode := os.Getenv("LLM_CASSETTE_MODE")
+	if cassetteMode == "" {
+		cassetteMode = llmprovider.CassetteReplay
+	}
+	provider, err := llmprovider.New(context.Background(), llmprovider.Config{
+		Provider:     os.Getenv("LLM_PROVIDER"),
+		Model:        os.Getenv("LLM_MODEL"),
+		BaseURL:      os.Getenv("LLM_BASE_URL"),
+		APIKey:       os.Getenv("LLM_API_KEY"),
+		CassetteMode: cassetteMode,
+		CassetteDir:  testCassetteDir,
+	}, logger)
+	if err != nil {
+		log.Fatalf("Unable to create LLM provider for chat tests: %v\n", err)
+	}
+	if cp, ok := provider.(*llmprovider.CassetteProvider); ok && os.Getenv("LLM_CASSETTE_REALTIME") == "" {
+		cp.WithTimeScale(0)
 	}
 
-	testChatService = NewServiceImpl(
-		testChatRepo,
+	testChatService = NewLlmInteractiontService(
 		interestRepo,
 		profileRepo,
+		profileService,
 		tagsRepo,
-		poiRepo,
+		testChatRepo,
 		cityRepo,
-		aiClient,
+		poiRepo,
+		provider,
 		logger,
 	)
 
@@ -0,0 +1,89 @@
+package llmchat
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/localization"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+// Localizer serves generated content in the user's language and keeps it per language.
+type Localizer interface {
+	Language(ctx context.Context, userID uuid.UUID) string
+	LocalizePOIs(ctx context.Context, language string, pois []models.POIDetailedInfo) []models.POIDetailedInfo
+	LocalizeCity(ctx context.Context, language string, cityID uuid.UUID, description string) string
+	RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool)
+	RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool)
+}
+
+// WithLocalizer generates answers in the language of users.language and serves stored cities
+// and POIs in it, translating them when they are only stored in another language.
+func (l *ServiceImpl) WithLocalizer(loc Localizer) *ServiceImpl {
+	l.localizer = loc
+	return l
+}
+
+type languageKey struct{}
+
+// withLanguage records the language content is generated and served in for the rest of a request.
+func withLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, languageKey{}, language)
+}
+
+// languageFrom returns the language of the request, localization.DefaultLanguage when unset.
+func languageFrom(ctx context.Context) string {
+	if language, ok := ctx.Value(languageKey{}).(string); ok && language != "" {
+		return language
+	}
+	return localization.DefaultLanguage
+}
+
+// withUserLanguage looks up the user's language and records it on ctx.
+func (l *ServiceImpl) withUserLanguage(ctx context.Context, userID uuid.UUID) context.Context {
+	if l.localizer == nil {
+		return ctx
+	}
+	return withLanguage(ctx, l.localizer.Language(ctx, userID))
+}
+
+// localizePrompt asks for the answer in the language of the request.
+func localizePrompt(ctx context.Context, prompt prompts.Rendered) prompts.Rendered {
+	return prompts.Localize(prompt, localization.Name(languageFrom(ctx)))
+}
+
+// languageCacheKey keeps answers in different languages apart. English keys stay as they were.
+func languageCacheKey(ctx context.Context, cacheKey string) string {
+	if language := languageFrom(ctx); language != localization.DefaultLanguage {
+		return cacheKey + "_" + language
+	}
+	return cacheKey
+}
+
+func (l *ServiceImpl) localizePOIs(ctx context.Context, pois []models.POIDetailedInfo) []models.POIDetailedInfo {
+	if l.localizer == nil {
+		return pois
+	}
+	return l.localizer.LocalizePOIs(ctx, languageFrom(ctx), pois)
+}
+
+func (l *ServiceImpl) localizeCity(ctx context.Context, cityID uuid.UUID, description string) string {
+	if l.localizer == nil {
+		return description
+	}
+	return l.localizer.LocalizeCity(ctx, languageFrom(ctx), cityID, description)
+}
+
+func (l *ServiceImpl) recordPOI(ctx context.Context, poiID uuid.UUID, description string, created bool) {
+	if l.localizer != nil {
+		l.localizer.RecordPOI(ctx, languageFrom(ctx), poiID, description, created)
+	}
+}
+
+func (l *ServiceImpl) recordCity(ctx context.Context, cityID uuid.UUID, description string, created bool) {
+	if l.localizer != nil {
+		l.localizer.RecordCity(ctx, languageFrom(ctx), cityID, description, created)
+	}
+}
@@ -51,55 +51,56 @@ func parseItineraryFromResponse(responseText string, logger *zap.Logger) (*model
 	return nil, fmt.Errorf("failed to parse itinerary: %w", err)
 }
 
-// parseCompleteResponseFromParts parses a complete AiCityResponse from individual SSE response parts
-func (l *ServiceImpl) parseCompleteResponseFromParts(responses map[string]*strings.Builder, sessionID uuid.UUID) (*models.AiCityResponse, error) {
+// parseCompleteResponseFromParts parses a complete AiCityResponse from individual SSE response
+// parts, validated and repaired by validateParts. Parts that stayed invalid are still parsed
+// leniently, and their schema errors explain an empty result.
+func (l *ServiceImpl) parseCompleteResponseFromParts(parts validatedParts, sessionID uuid.UUID) (*models.AiCityResponse, error) {
 	completeResponse := &models.AiCityResponse{
 		SessionID: sessionID,
 	}
+	invalidParts := parts.invalid
+	if len(invalidParts) > 0 {
+		l.logger.Warn("Response parts failed schema validation",
+			zap.String("session_id", sessionID.String()),
+			zap.Strings("parts", invalidParts))
+	}
 
 	// Parse city_data part
-	if cityDataBuilder, exists := responses["city_data"]; exists && cityDataBuilder != nil {
-		cityDataStr := cityDataBuilder.String()
-		if cityDataStr != "" {
-			cleanedCityData := cleanJSONResponse(cityDataStr)
-			var cityData models.GeneralCityData
-			if err := json.Unmarshal([]byte(cleanedCityData), &cityData); err != nil {
-				l.logger.Warn("Failed to parse city_data part", zap.Any("error", err))
-			} else {
-				completeResponse.GeneralCityData = cityData
-			}
+	if cityDataStr, exists := parts.texts["city_data"]; exists {
+		cleanedCityData := cleanJSONResponse(cityDataStr)
+		var cityData models.GeneralCityData
+		if err := json.Unmarshal([]byte(cleanedCityData), &cityData); err != nil {
+			l.logger.Warn("Failed to parse city_data part", zap.Any("error", err))
+		} else {
+			completeResponse.GeneralCityData = cityData
 		}
 	}
 
 	// Parse general_pois part
-	if poisBuilder, exists := responses["general_pois"]; exists && poisBuilder != nil {
-		poisStr := poisBuilder.String()
-		if poisStr != "" {
-			cleanedPOIs := cleanJSONResponse(poisStr)
-
-			// Try parsing as wrapper with points_of_interest field first (this is what the LLM returns)
-			var poisWrapper struct {
-				PointsOfInterest []models.POIDetailedInfo `json:"points_of_interest"`
-			}
-			if err := json.Unmarshal([]byte(cleanedPOIs), &poisWrapper); err == nil && len(poisWrapper.PointsOfInterest) > 0 {
-				completeResponse.PointsOfInterest = poisWrapper.PointsOfInterest
-				l.logger.Debug("Parsed general_pois as wrapped object", zap.Int("count", len(poisWrapper.PointsOfInterest)))
+	if poisStr, exists := parts.texts["general_pois"]; exists {
+		cleanedPOIs := cleanJSONResponse(poisStr)
+
+		// Try parsing as wrapper with points_of_interest field first (this is what the LLM returns)
+		var poisWrapper struct {
+			PointsOfInterest []models.POIDetailedInfo `json:"points_of_interest"`
+		}
+		if err := json.Unmarshal([]byte(cleanedPOIs), &poisWrapper); err == nil && len(poisWrapper.PointsOfInterest) > 0 {
+			completeResponse.PointsOfInterest = poisWrapper.PointsOfInterest
+			l.logger.Debug("Parsed general_pois as wrapped object", zap.Int("count", len(poisWrapper.PointsOfInterest)))
+		} else {
+			// Fallback: try parsing as direct array
+			var pois []models.POIDetailedInfo
+			if err := json.Unmarshal([]byte(cleanedPOIs), &pois); err != nil {
+				l.logger.Warn("Failed to parse general_pois part", zap.Any("error", err))
 			} else {
-				// Fallback: try parsing as direct array
-				var pois []models.POIDetailedInfo
-				if err := json.Unmarshal([]byte(cleanedPOIs), &pois); err != nil {
-					l.logger.Warn("Failed to parse general_pois part", zap.Any("error", err))
-				} else {
-					completeResponse.PointsOfInterest = pois
-					l.logger.Debug("Parsed general_pois as direct array", zap.Int("count", len(pois)))
-				}
+				completeResponse.PointsOfInterest = pois
+				l.logger.Debug("Parsed general_pois as direct array", zap.Int("count", len(pois)))
 			}
 		}
 	}
 
 	// Parse itinerary part
-	if itineraryBuilder, exists := responses["itinerary"]; exists && itineraryBuilder != nil {
-		itineraryStr := itineraryBuilder.String()
+	if itineraryStr, exists := parts.texts["itinerary"]; exists {
 		if parsedItinerary, err := parseItineraryFromResponse(itineraryStr, l.logger); err == nil && parsedItinerary != nil {
 			completeResponse.AIItineraryResponse = *parsedItinerary
 		} else {
@@ -108,8 +109,7 @@ func (l *ServiceImpl) parseCompleteResponseFromParts(responses map[string]*strin
 	}
 
 	// Parse restaurants part
-	if restaurantBuilder, exists := responses["restaurants"]; exists && restaurantBuilder != nil {
-		restaurantStr := restaurantBuilder.String()
+	if restaurantStr, exists := parts.texts["restaurants"]; exists {
 		if restaurants, err := parseRestaurantsFromResponse(restaurantStr, l.logger); err == nil && len(restaurants) > 0 {
 			var pois []models.POIDetailedInfo
 			for _, r := range restaurants {
@@ -163,6 +163,9 @@ func (l *ServiceImpl) parseCompleteResponseFromParts(responses map[string]*strin
 	if completeResponse.GeneralCityData.City == "" &&
 		len(completeResponse.PointsOfInterest) == 0 &&
 		completeResponse.AIItineraryResponse.ItineraryName == "" {
+		if len(invalidParts) > 0 {
+			return nil, fmt.Errorf("no valid data found in any response parts: %s", strings.Join(invalidParts, "; "))
+		}
 		return nil, fmt.Errorf("no valid data found in any response parts")
 	}
 
@@ -0,0 +1,33 @@
+package llmchat
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// POIResolver finds the stored POI a generated one names, under whatever name the model used.
+type POIResolver interface {
+	Resolve(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (uuid.UUID, bool, error)
+}
+
+// WithPOIResolver matches generated POIs to stored ones by name similarity, distance and
+// embeddings instead of exact names, so sessions stop creating duplicate records.
+func (l *ServiceImpl) WithPOIResolver(r POIResolver) *ServiceImpl {
+	l.poiResolver = r
+	return l
+}
+
+// poiStored returns the id of the stored POI poi names, reporting whether there is one.
+func (l *ServiceImpl) poiStored(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (uuid.UUID, bool, error) {
+	if l.poiResolver == nil {
+		existing, err := l.poiRepo.FindPoiByNameAndCity(ctx, poi.Name, cityID)
+		if err != nil || existing == nil {
+			return uuid.Nil, false, err
+		}
+		return existing.ID, true, nil
+	}
+	return l.poiResolver.Resolve(ctx, cityID, poi)
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
 )
 
 func getUserPreferencesPrompt(searchProfile *models.UserPreferenceProfileResponse) string {
@@ -282,377 +283,93 @@ ITINERARY PREFERENCES:`
 	return basePrefs
 }
 
-func getPOIDetailsPrompt(city string, lat, lon float64) string {
-	return fmt.Sprintf(`
-		Generate details for the following POI on the city of %s with the coordinates %0.2f , %0.2f.
-		The result should be in the following JSON format:
-		{
-			"name": "Name of the Point of Interest",
-			"description": "Detailed description of the POI and why it's relevant to the user's interest.",
-    		"address": "address of the point of interest",
-    		"website": "website of the POI if available",
-    		"phone_number": "phone number of the POI if available",
-    		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-    		"price_range": "price level if available",
-            "category": "Primary category (e.g., Museum, Historical Site, Park, Restaurant, Bar)",
-            "tags": ["tag1", "tag2", ...], -- Tags related to the POI
-            "images": ["image_url_1", "image_url_2", ...], // images from wikipedia or pininterest
-            "rating": <float> -- Average rating if available
-            "stars": type of stars if available (e.g., "3 stars", "5 stars")
-
-		}
-	`, city, lat, lon)
+// The prompt getters below render the templates in internal/pkg/prompts. subject is the
+// session or user id the prompt is rendered for, it keeps A/B splits sticky per conversation.
+
+func getPOIDetailsPrompt(subject, city string, lat, lon float64) prompts.Rendered {
+	return prompts.POIDetails.MustRender(subject, prompts.POIDetailsParams{CityName: city, Lat: lat, Lon: lon})
+}
+
+func generatedContinuedConversationPrompt(subject, poi, city, conversation string) prompts.Rendered {
+	return prompts.ContinueConversation.MustRender(subject, prompts.ContinueConversationParams{POIName: poi, CityName: city, Conversation: conversation})
 }
 
-func generatedContinuedConversationPrompt(poi, city string) string {
-	return fmt.Sprintf(
-		`Provide detailed information about "%s" in %s.
-        If user writes "Restaurant" add "cuisine_type" to final response and hide "description_poi"
-        If user writes "Hotel" add "star_rating" to final response and hide "description_poi"
-		Analise this POI (The user can insert a POI name, a Restaurant name or an Hotel/Hostel name) and return the following JSON structure:
-    {
-        "name": "string (the POI name)",
-        "latitude": number (approximate latitude as float),
-        "longitude": number (approximate longitude as float),
-        "category": "string (e.g., Museum, Park, Historical Site)",
-        "description_poi": "string (50-100 words description)"
-        "cuisine_type": "string (for Restaurant)",
-        "star_rating": "number (for Hotel/Hostel)"
-    }
-
-    If the POI is not found, return: {"name": "", "latitude": 0, "longitude": 0, "category": "", "description_poi": ""}`,
-		poi, city)
+// getAnswerQuestionPrompt answers a follow-up question with the compacted session context
+func getAnswerQuestionPrompt(subject, question, conversation string) prompts.Rendered {
+	return prompts.AnswerQuestion.MustRender(subject, prompts.AnswerQuestionParams{Question: question, Conversation: conversation})
 }
 
 // getCityDescriptionPrompt generates a prompt for city data
-func getCityDescriptionPrompt(cityName string) string {
-	return fmt.Sprintf(`
-        Provide detailed information about the city %s in JSON format with the following structure:
-        {
-            "city_name": "%s",
-            "country": "Country name",
-            "state_province": "State or province, if applicable",
-            "description": "A detailed description of the city",
-            "center_latitude": float64,
-            "center_longitude": float64
-        }
-    `, cityName, cityName)
+func getCityDescriptionPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.CityDescription.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
 /*
   Testing Fan in Fan out prompt
 */
 
-func getCityDataPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are a travel assistant. Provide general information about %s.
-Respond with JSON:
-{
-    "city": "%s",
-    "country": "Country name",
-    "state_province": "State/Province if applicable",
-    "description": "Detailed city description (100-150 words)",
-    "center_latitude": <float>,
-    "center_longitude": <float>,
-    "population": "",
-    "area": "",
-    "timezone": "",
-    "language": "",
-    "weather": "",
-    "attractions": "",
-    "history": ""
-}`, cityName, cityName)
+func getCityDataPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.CityData.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
-func getGeneralPOIPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are a travel assistant. List general points of interest in %s.
-Respond with JSON:
-{
-    "points_of_interest": [
-        {
-            "name": "POI Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Category (e.g., Museum, Historical Site)",
-            "description_poi": "",
-            "address": "",
-            "website": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-
-        }
-    ]
-}`, cityName)
+func getGeneralPOIPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.GeneralPOIs.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
-func getPersonalizedItineraryPrompt(cityName, basePreferences string) string {
-	return fmt.Sprintf(`
-You are a travel planning assistant. Create a personalized itinerary for %s based on user preferences.
-USER PREFERENCES:
-%s
-Respond with JSON:
-{
-    "itinerary_name": "Creative itinerary name",
-    "overall_description": "Detailed description (100-150 words)",
-    "points_of_interest": [
-        {
-            "name": "POI Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "",
-            "description_poi": "",
-            "address": "",
-            "website": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "distance": <float>
-        }
-    ]
-}`, cityName, basePreferences)
+func getPersonalizedItineraryPrompt(subject, cityName, basePreferences string) prompts.Rendered {
+	return prompts.PersonalizedItinerary.MustRender(subject, prompts.CityPreferencesParams{CityName: cityName, Preferences: basePreferences})
 }
 
-func getGeneralizedItineraryPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are a travel planning assistant. Create a personalized itinerary with a max of 5 results for %s with multi things to do and different activities.
-Respond with JSON:
-{
-    "itinerary_name": "Creative itinerary name",
-    "overall_description": "Detailed description (100-150 words)",
-    "points_of_interest": [
-        {
-            "name": "POI Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "",
-            "description_poi": "",
-            "address": "",
-            "website": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "distance": <float>
-        }
-    ]
-}`, cityName)
+func getGeneralizedItineraryPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.GeneralItinerary.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
-func getAccommodationPrompt(cityName string, lat, lon float64, basePreferences string) string {
-	return fmt.Sprintf(`
-You are a hotel recommendation assistant. Find suitable accommodation in %s near coordinates %.4f, %.4f.
-USER PREFERENCES:
-%s
-Respond with JSON:
-{
-    "hotels": [
-        {
-            "city": "%s",
-            "name": "Hotel Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Hotel|Hostel|Guesthouse|Apartment",
-            "description": "Description matching preferences",
-            "address": "",
-            "phone_number": null,
-            "website": null,
-            "opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')",
-            "price_range": null,
-            "rating": 0,
-            "tags": null,
-            "images": null,
-            "distance": <float>
-        }
-    ]
-}`, cityName, lat, lon, basePreferences, cityName)
+func getAccommodationPrompt(subject, cityName string, lat, lon float64, basePreferences string) prompts.Rendered {
+	return prompts.Accommodation.MustRender(subject, prompts.CityLocationParams{CityName: cityName, Lat: lat, Lon: lon, Preferences: basePreferences})
 }
 
-func getGeneralAccommodationPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are a hotel recommendation assistant. Find a max of 5 suitable accommodation in %s.
-Respond with JSON:
-{
-    "hotels": [
-        {
-            "city": "%s",
-            "name": "Hotel Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Hotel|Hostel|Guesthouse|Apartment",
-            "description": "Description matching preferences",
-            "address": "",
-            "phone_number": null,
-            "website": null,
-            "opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')",
-            "price_range": null,
-            "rating": 0,
-            "tags": null,
-            "images": null,
-            "distance": <float>
-        }
-    ]
-}`, cityName, cityName)
+func getGeneralAccommodationPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.GeneralAccommodation.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
-func getDiningPrompt(cityName string, lat, lon float64, basePreferences string) string {
-	return fmt.Sprintf(`
-You are a restaurant recommendation assistant. Find 10 dining options in %s near coordinates %.4f, %.4f.
-USER PREFERENCES:
-%s
-Respond with JSON:
-{
-    "restaurants": [
-        {
-            "city": "%s",
-            "name": "Restaurant Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Fine Dining|Casual Dining|Fast Food|Cafe|Bar",
-            "description": "Description matching preferences",
-            "address": "",
-            "website": "",
-            "phone_number": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "price_level": "$|$$|$$$|$$$$",
-            "cuisine_type": "",
-            "tags": [],
-            "images": [],
-            "rating": 0,
-            "distance": <float>
-        }
-    ]
-}`, cityName, lat, lon, basePreferences, cityName)
+func getDiningPrompt(subject, cityName string, lat, lon float64, basePreferences string) prompts.Rendered {
+	return prompts.Dining.MustRender(subject, prompts.CityLocationParams{CityName: cityName, Lat: lat, Lon: lon, Preferences: basePreferences})
 }
 
-func getGeneralDiningPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are a restaurant recommendation assistant. Find a max of 5 dining options in %s.
-Respond with JSON:
-{
-    "restaurants": [
-        {
-            "city": "%s",
-            "name": "Restaurant Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Fine Dining|Casual Dining|Fast Food|Cafe|Bar",
-            "description": "Description matching preferences",
-            "address": "",
-            "website": "",
-            "phone_number": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "price_level": "$|$|$$|$$",
-            "cuisine_type": "",
-            "tags": [],
-            "images": [],
-            "rating": 0,
-            "distance": <float>
-        }
-    ]
-}`, cityName, cityName)
+func getGeneralDiningPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.GeneralDining.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
-func getActivitiesPrompt(cityName string, lat, lon float64, basePreferences string) string {
-	return fmt.Sprintf(`
-You are an activity recommendation assistant. Find activities in %s near coordinates %.4f, %.4f.
-USER PREFERENCES:
-%s
-Respond with JSON:
-{
-    "activities": [
-        {
-            "city": "%s",
-            "name": "Activity Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Museum|Outdoor Activity|Entertainment|Cultural|Sports",
-            "description": "Description matching preferences",
-            "address": "",
-            "website": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "price_range": "Free|$|$$|$$$",
-            "rating": 0,
-            "tags": [],
-            "images": [],
-            "distance": <float>
-        }
-    ]
-}`, cityName, lat, lon, basePreferences, cityName)
+func getActivitiesPrompt(subject, cityName string, lat, lon float64, basePreferences string) prompts.Rendered {
+	return prompts.Activities.MustRender(subject, prompts.CityLocationParams{CityName: cityName, Lat: lat, Lon: lon, Preferences: basePreferences})
 }
 
-func getGeneralActivitiesPrompt(cityName string) string {
-	return fmt.Sprintf(`
-You are an activity recommendation assistant. Find a max of 5 activities in %s.
-Respond with JSON:
-{
-    "activities": [
-        {
-            "city": "%s",
-            "name": "Activity Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Museum|Outdoor Activity|Entertainment|Cultural|Sports",
-            "description": "Description matching preferences",
-            "address": "",
-            "website": "",
-                		"opening_hours": "Opening hours as string (e.g., 'Mon-Fri 9:00-17:00, Sat 10:00-15:00')"
-,
-            "price_range": "Free|$|$$|$$$",
-            "rating": 0,
-            "tags": [],
-            "images": [],
-            "distance": <float>
-        }
-    ]
-}`, cityName, cityName)
+func getGeneralActivitiesPrompt(subject, cityName string) prompts.Rendered {
+	return prompts.GeneralActivities.MustRender(subject, prompts.CityParams{CityName: cityName})
 }
 
 // GetDiscoverSearchPrompt creates a prompt for generic discovery searches
 // Example: "5 star hotel" in "Madrid", "romantic restaurants" in "Paris"
-func GetDiscoverSearchPrompt(query, location string) string {
-	return fmt.Sprintf(`
-You are a travel discovery assistant. Find places matching the search query in the specified location.
-
-SEARCH QUERY: "%s"
-LOCATION: "%s"
-
-Interpret the query intelligently:
-- If it mentions hotels/lodging, return hotels
-- If it mentions restaurants/dining/food, return restaurants
-- If it mentions activities/entertainment/things to do, return activities
-- If it mentions attractions/sights/landmarks, return points of interest
-- Handle quality indicators: "5 star", "luxury", "budget", "cheap", "romantic", "family-friendly", etc.
-
-Return 6-12 relevant results with accurate coordinates for the location.
-
-Respond with JSON:
-{
-    "results": [
-        {
-            "name": "Place Name",
-            "latitude": <float>,
-            "longitude": <float>,
-            "category": "Hotel|Restaurant|Activity|Attraction",
-            "description": "Detailed description highlighting why it matches the query",
-            "address": "Full address",
-            "website": "URL or null",
-            "phone_number": "Phone number or null",
-            "opening_hours": "Hours string or null",
-            "price_level": "$|$$|$$$|$$$$",
-            "rating": <float 0-5>,
-            "tags": ["tag1", "tag2"],
-            "images": [],
-            "cuisine_type": "For restaurants only, or null",
-            "star_rating": "For hotels only (e.g., '5 star'), or null"
-        }
-    ]
+func GetDiscoverSearchPrompt(subject, query, location string) prompts.Rendered {
+	return prompts.DiscoverSearch.MustRender(subject, prompts.DiscoverSearchParams{Query: query, Location: location})
+}
+
+// getSchemaRepairPrompt asks the model to fix a response that failed its JSON schema
+func getSchemaRepairPrompt(schemaName, schema, invalidResponse string, validationErrors []string) prompts.Rendered {
+	return prompts.SchemaRepair.MustRender("", prompts.SchemaRepairParams{
+		SchemaName: schemaName,
+		Schema:     schema,
+		Errors:     validationErrors,
+		Response:   invalidResponse,
+	})
 }
 
-IMPORTANT:
-- All coordinates must be accurate for %s
-- Prioritize well-known, highly-rated establishments
-- Match the quality level implied in the query (luxury vs budget)
-- Include specific details that match the search query
-- Ensure descriptions explain why each result matches the query
-`, query, location, location)
+// getPersonalizedPOI generates a prompt for personalized POIs
+func getPersonalizedPOI(subject string, interestNames []string, cityName, tagsPromptPart, userPrefs string) prompts.Rendered {
+	return prompts.PersonalizedPOI.MustRender(subject, prompts.PersonalizedPOIParams{
+		CityName:       cityName,
+		InterestNames:  interestNames,
+		TagsPromptPart: tagsPromptPart,
+		UserPrefs:      userPrefs,
+	})
 }
@@ -21,6 +21,7 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/FACorreiaa/go-templui/internal/app/domain/poimatch"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 )
 
@@ -110,10 +111,30 @@ func (r *RepositoryImpl) SaveInteraction(ctx context.Context, interaction models
 		}
 	}()
 
+	// Every interaction with token counts is priced so the cost ledger sees all of them, not
+	// only those written through the LLM logger
+	if interaction.CostEstimateUSD == nil && interaction.PromptTokens+interaction.CompletionTokens > 0 {
+		cost := CalculateCost(interaction.ModelUsed, interaction.PromptTokens, interaction.CompletionTokens)
+		interaction.CostEstimateUSD = &cost
+	}
+
+	// Callers that only fill the core fields keep the column defaults for provider and status_code
 	interactionQuery := `
         INSERT INTO llm_interactions (
-            user_id, session_id, prompt, response, model_name, latency_ms, city_name
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+            user_id, session_id, prompt, response, model_name, latency_ms, city_name,
+            provider, status_code, error_message, intent, search_type,
+            prompt_tokens, completion_tokens, total_tokens, temperature, cost_estimate_usd,
+            cache_hit, cache_key, prompt_hash, is_streaming, stream_chunks_count, stream_duration_ms,
+            schema_name, schema_valid, validation_errors, repair_attempts,
+            prompt_id, prompt_version, tool_calls
+        ) VALUES (
+            NULLIF($1, '00000000-0000-0000-0000-000000000000'::uuid), $2, $3, $4, $5, $6, $7,
+            COALESCE(NULLIF($8, ''), 'google'), COALESCE(NULLIF($9, 0), 200), NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, ''),
+            $13, $14, $15, $16, $17,
+            $18, NULLIF($19, ''), NULLIF($20, ''), $21, $22, $23,
+            NULLIF($24, ''), $25, $26, $27,
+            NULLIF($28, ''), NULLIF($29, 0), $30
+        )
         RETURNING id
     `
 	var interactionID uuid.UUID
@@ -125,6 +146,29 @@ func (r *RepositoryImpl) SaveInteraction(ctx context.Context, interaction models
 		interaction.ModelUsed,
 		interaction.LatencyMs,
 		interaction.CityName,
+		interaction.Provider,
+		interaction.StatusCode,
+		interaction.ErrorMessage,
+		interaction.Intent,
+		interaction.SearchType,
+		interaction.PromptTokens,
+		interaction.CompletionTokens,
+		interaction.TotalTokens,
+		interaction.Temperature,
+		interaction.CostEstimateUSD,
+		interaction.CacheHit,
+		interaction.CacheKey,
+		interaction.PromptHash,
+		interaction.IsStreaming,
+		interaction.StreamChunksCount,
+		interaction.StreamDurationMs,
+		interaction.SchemaName,
+		interaction.SchemaValid,
+		interaction.ValidationErrors,
+		interaction.RepairAttempts,
+		interaction.PromptID,
+		interaction.PromptVersion,
+		interaction.ToolCalls,
 	).Scan(&interactionID)
 	if err != nil {
 		span.RecordError(err)
@@ -859,11 +903,13 @@ func (r *RepositoryImpl) CreateSession(ctx context.Context, session models.ChatS
 		}
 	}()
 
+	// Free-mode sessions have no user or profile and belong to the guest instead
 	query := `
         INSERT INTO chat_sessions (
             id, user_id, profile_id, city_name, current_itinerary, conversation_history, session_context,
-            created_at, updated_at, expires_at, status, search_type
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+            created_at, updated_at, expires_at, status, search_type, guest_id
+        ) VALUES ($1, NULLIF($2, '00000000-0000-0000-0000-000000000000'::uuid), NULLIF($3, '00000000-0000-0000-0000-000000000000'::uuid),
+            $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
     `
 	itineraryJSON, err := json.Marshal(session.CurrentItinerary)
 	if err != nil {
@@ -887,8 +933,19 @@ func (r *RepositoryImpl) CreateSession(ctx context.Context, session models.ChatS
 		searchType = models.SearchTypeItinerary
 	}
 
+	if session.GuestID != nil {
+		_, err = tx.Exec(ctx, `
+            INSERT INTO guests (id) VALUES ($1)
+            ON CONFLICT (id) DO UPDATE SET last_seen_at = NOW()`, *session.GuestID)
+		if err != nil {
+			r.logger.Error("Failed to record guest", zap.Any("error", err))
+			return fmt.Errorf("failed to record guest: %w", err)
+		}
+	}
+
 	_, err = tx.Exec(ctx, query, session.ID, session.UserID, session.ProfileID, session.CityName,
-		itineraryJSON, historyJSON, contextJSON, session.CreatedAt, session.UpdatedAt, session.ExpiresAt, session.Status, searchType)
+		itineraryJSON, historyJSON, contextJSON, session.CreatedAt, session.UpdatedAt, session.ExpiresAt, session.Status, searchType,
+		session.GuestID)
 	if err != nil {
 		r.logger.Error("Failed to create session", zap.Any("error", err))
 		return fmt.Errorf("failed to create session: %w", err)
@@ -1764,8 +1821,12 @@ func parsePOIsFromResponse(responseText string, logger *zap.Logger) ([]models.PO
 
 func (r *RepositoryImpl) GetOrCreatePOI(ctx context.Context, tx pgx.Tx, POIDetailedInfo models.POIDetailedInfo, cityID uuid.UUID, _ uuid.UUID) (uuid.UUID, error) {
 	var poiDBID uuid.UUID
-	findPoiQuery := `SELECT id FROM points_of_interest WHERE name = $1 AND city_id = $2 LIMIT 1`
-	err := tx.QueryRow(ctx, findPoiQuery, POIDetailedInfo.Name, cityID).Scan(&poiDBID)
+	findPoiQuery := `
+        SELECT id FROM points_of_interest WHERE name = $1 AND city_id = $2
+        UNION ALL
+        SELECT poi_id FROM poi_aliases WHERE city_id = $2 AND alias_normalized = $3
+        LIMIT 1`
+	err := tx.QueryRow(ctx, findPoiQuery, POIDetailedInfo.Name, cityID, poimatch.Normalize(POIDetailedInfo.Name)).Scan(&poiDBID)
 
 	if err == pgx.ErrNoRows {
 		createPoiQuery := `
@@ -0,0 +1,153 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	streamingpkg "github.com/FACorreiaa/go-templui/internal/app/streaming"
+)
+
+// streamResumeGrace is how long a generation keeps running after its client dropped, waiting
+// for it to reconnect with Last-Event-ID.
+const streamResumeGrace = 30 * time.Second
+
+// sseCloseFrame tells HTMX the connection is closed on purpose.
+var sseCloseFrame = []byte(`{"type":"sse-close"}`)
+
+// ResumeStream serves a client reconnecting with Last-Event-ID from the replay buffer of its
+// stream: the events it missed, then the rest live. Requests without a stream to resume go on
+// to the handler. It sits before the quota check, resuming is not a new search.
+func (h *ChatHandlers) ResumeStream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, seq, ok := streamingpkg.LastEventID(c.Request)
+		if !ok {
+			c.Next()
+			return
+		}
+		replay, found := h.replays.Resume(key)
+		if !found || replay.Owner() != streamOwnerID(c) {
+			h.logger.Info("No stream to resume, starting over", zap.String("streamKey", key))
+			c.Next()
+			return
+		}
+
+		h.logger.Info("Resuming chat stream", zap.String("streamKey", key), zap.Uint64("lastEventSeq", seq))
+		if !setStreamHeaders(c) {
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		h.followStream(c, replay, seq)
+		c.Abort()
+	}
+}
+
+// streamResumable runs produce detached from the request and streams its events to the client.
+// Events are kept in a new replay buffer of key with ids of the form <key>.<nonce>:<seq>, so a
+// client that drops mid-generation resumes through ResumeStream, and only into this stream. The generation is cancelled once no
+// client has followed it for streamResumeGrace.
+func (h *ChatHandlers) streamResumable(ctx context.Context, c *gin.Context, key string, produce func(ctx context.Context, eventCh chan models.StreamEvent)) {
+	replay := h.replays.Open(key, streamOwnerID(c))
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	replay.OnAbandon(streamResumeGrace, cancel)
+
+	eventCh := make(chan models.StreamEvent, 200)
+	go produce(ctx, eventCh)
+	go func() {
+		defer cancel()
+		defer replay.Close()
+		for {
+			select {
+			case event, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				replay.Append(event)
+				if isTerminalEvent(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	h.followStream(c, replay, 0)
+}
+
+// followStream writes the events of the buffer after lastSeq to the client until the stream ends
+// or the client goes away.
+func (h *ChatHandlers) followStream(c *gin.Context, replay *streamingpkg.ReplayBuffer[models.StreamEvent], lastSeq uint64) {
+	flusher := c.Writer.(http.Flusher)
+	l := h.logger.With(zap.String("streamKey", replay.Key()))
+
+	err := replay.Follow(c.Request.Context(), lastSeq, func(e streamingpkg.Sequenced[models.StreamEvent]) error {
+		eventData, err := json.Marshal(e.Event)
+		if err != nil {
+			l.Error("Failed to marshal event", zap.Error(err))
+			return nil
+		}
+		if err := streamingpkg.WriteSSE(c.Writer, replay.EventID(e.Seq), "", eventData); err != nil {
+			return err
+		}
+
+		if isTerminalEvent(e.Event) {
+			l.Info("Stream completed", zap.String("eventType", e.Event.Type))
+			if err := streamingpkg.WriteSSE(c.Writer, "", "", sseCloseFrame); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+		return nil
+	})
+	switch {
+	case errors.Is(err, streamingpkg.ErrReplayGap):
+		l.Warn("Client fell too far behind to resume the stream")
+		eventData, _ := json.Marshal(models.StreamEvent{
+			Type:      models.EventTypeError,
+			Error:     "Some updates were lost, please try again",
+			Timestamp: time.Now(),
+			IsFinal:   true,
+		})
+		_ = streamingpkg.WriteSSE(c.Writer, "", "", eventData)
+		flusher.Flush()
+	case err != nil:
+		l.Info("Client disconnected, generation continues for a resume", zap.Error(err))
+	}
+}
+
+func setStreamHeaders(c *gin.Context) bool {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+
+	if _, ok := c.Writer.(http.Flusher); !ok {
+		return false
+	}
+	return true
+}
+
+// streamOwnerID is the user or guest a stream is resumable by, empty for anonymous clients.
+func streamOwnerID(c *gin.Context) string {
+	if user := middleware.GetUserFromContext(c); user != nil {
+		return user.ID
+	}
+	if guestID, ok := middleware.GetGuestFromContext(c); ok {
+		return "guest:" + guestID.String()
+	}
+	return ""
+}
+
+func isTerminalEvent(event models.StreamEvent) bool {
+	return event.Type == models.EventTypeComplete || event.Type == models.EventTypeError
+}
@@ -0,0 +1,84 @@
+package llmchat
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+)
+
+// WithRouting orders the points of interest of generated itineraries to keep travel short,
+// by the user's preferred means of transport, and reports the legs between them.
+func (l *ServiceImpl) WithRouting(provider routing.Provider) *ServiceImpl {
+	l.routing = provider
+	return l
+}
+
+// RouteItinerary orders the points of interest of an itinerary to minimise travel time and
+// sets its route. Points without coordinates go last. Without routing, or when the provider
+// fails, the itinerary keeps the order the model gave.
+func (l *ServiceImpl) RouteItinerary(ctx context.Context, userID uuid.UUID, itinerary *models.AIItineraryResponse) {
+	if l.routing == nil || itinerary == nil {
+		return
+	}
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "RouteItinerary", trace.WithAttributes(
+		attribute.Int("pois.count", len(itinerary.PointsOfInterest)),
+	))
+	defer span.End()
+
+	var located, unlocated []models.POIDetailedInfo
+	var points []routing.Point
+	for _, p := range itinerary.PointsOfInterest {
+		if p.Latitude == 0 && p.Longitude == 0 {
+			unlocated = append(unlocated, p)
+			continue
+		}
+		located = append(located, p)
+		points = append(points, routing.Point{Latitude: p.Latitude, Longitude: p.Longitude})
+	}
+
+	mode := l.transportMode(ctx, userID)
+	order, legs, err := routing.Route(ctx, l.routing, points, mode)
+	if err != nil {
+		span.RecordError(err)
+		l.logger.Warn("Failed to route itinerary", zap.String("mode", string(mode)), zap.Error(err))
+		return
+	}
+
+	ordered := make([]models.POIDetailedInfo, 0, len(itinerary.PointsOfInterest))
+	for _, i := range order {
+		ordered = append(ordered, located[i])
+	}
+	route := &models.Route{Mode: string(mode), Provider: l.routing.Name(), Legs: []models.RouteLeg{}}
+	for i, leg := range legs {
+		route.Legs = append(route.Legs, models.RouteLeg{
+			From:       ordered[i].Name,
+			To:         ordered[i+1].Name,
+			DistanceKm: leg.DistanceKm,
+			Minutes:    leg.Minutes,
+		})
+		route.DistanceKm += leg.DistanceKm
+		route.Minutes += leg.Minutes
+	}
+	itinerary.PointsOfInterest = append(ordered, unlocated...)
+	itinerary.Route = route
+	span.SetAttributes(attribute.String("routing.mode", string(mode)), attribute.Float64("route.minutes", route.Minutes))
+}
+
+// transportMode is the user's preferred means of transport, walking without a profile.
+func (l *ServiceImpl) transportMode(ctx context.Context, userID uuid.UUID) routing.Mode {
+	if userID == uuid.Nil {
+		return routing.ModeWalk
+	}
+	profile, err := l.searchProfileSvc.GetDefaultSearchProfile(ctx, userID)
+	if err != nil || profile == nil {
+		return routing.ModeWalk
+	}
+	return routing.ModeFor(string(profile.PreferredTransport))
+}
@@ -7,10 +7,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
-	"log/slog"
+	"maps"
 	"net/url"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
@@ -27,19 +25,26 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/genai"
 
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
-
 	"github.com/FACorreiaa/go-templui/internal/app/domain/city"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/costs"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/deadletter"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/interests"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/localization"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/poi"
 	profiles2 "github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/tags"
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/budget"
 	cache2 "github.com/FACorreiaa/go-templui/internal/pkg/cache"
+	"github.com/FACorreiaa/go-templui/internal/pkg/chatmemory"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+	"github.com/FACorreiaa/go-templui/internal/pkg/streamflight"
 )
 
 const (
-	model              = "gemini-2.0-flash"
 	defaultTemperature = 0.5
 )
 
@@ -81,6 +86,11 @@ type IntentClassifier interface {
 	Classify(ctx context.Context, message string) (models.IntentType, error) // e.g., "start_trip", "modify_itinerary"
 }
 
+// DomainDetector picks the search domain (hotels, restaurants, activities, itinerary) of a message.
+type DomainDetector interface {
+	DetectDomain(ctx context.Context, message string) models.DomainType
+}
+
 // ServiceImpl provides the implementation for LlmInteractiontService.
 type ServiceImpl struct {
 	logger             *zap.Logger
@@ -88,8 +98,7 @@ type ServiceImpl struct {
 	searchProfileRepo  profiles2.Repository
 	searchProfileSvc   profiles2.Service // Add service for enhanced methods
 	tagsRepo           tags.Repository
-	aiClient           *generativeAI.LLMChatClient
-	embeddingService   *generativeAI.EmbeddingService
+	llmProvider        llmprovider.Provider // Generation and embeddings backend (Gemini, OpenAI-compatible, fake)
 	llmInteractionRepo Repository
 	cityRepo           city.Repository
 	poiRepo            poi.Repository
@@ -97,9 +106,25 @@ type ServiceImpl struct {
 	streamProcessor    *StreamProcessor // Reusable stream processor
 	llmLogger          *LLMLogger       // Comprehensive LLM logging
 
+	schemaRepairAttempts int                 // Re-prompts allowed when a streamed part fails its JSON schema
+	memory               *chatmemory.Manager // Keeps the conversation sent to the model within a token budget
+	budget               BudgetChecker       // Daily LLM spend limits, nil when there are none
+	geoCheck             GeoChecker          // Validates generated POI coordinates before saving, nil saves them as they are
+	poiResolver          POIResolver         // Matches generated POIs to stored ones, nil matches exact names only
+	localizer            Localizer           // Generates and serves content in the user's language, nil keeps English
+	versions             ItineraryVersions   // Snapshots of every itinerary change, nil keeps no history
+	agentIterations      int                 // Tool calls allowed per answered question, 0 answers without tools
+	agentLists           AgentLists          // Lists the agent can read and add to, nil leaves the list tools out
+	routing              routing.Provider    // Orders itinerary POIs by travel time, nil keeps the model's order
+	estimator            *budget.Estimator   // Estimates what itineraries cost, nil leaves them without a cost
+
+	inflight streamflight.Group[models.StreamEvent] // Identical part generations in flight, keyed by cache key
+
 	// events
-	deadLetterCh     chan models.StreamEvent
+	deadLetterCh     chan deadLetterEntry
+	deadLetters      DeadLetterRecorder // Stores undelivered events for re-delivery, nil logs them only
 	intentClassifier IntentClassifier
+	domainDetector   DomainDetector
 }
 
 // NewLlmInteractiontService creates a new user service instance.
@@ -110,27 +135,8 @@ func NewLlmInteractiontService(interestRepo interests.Repository,
 	llmInteractionRepo Repository,
 	cityRepo city.Repository,
 	poiRepo poi.Repository,
+	llmProvider llmprovider.Provider,
 	logger *zap.Logger) *ServiceImpl {
-	ctx := context.Background()
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	aiClient, err := generativeAI.NewLLMChatClient(ctx, apiKey)
-	if err != nil {
-		panic(err)
-	}
-
-	// Initialize embedding service
-	// Create a standard log.Logger that writes to zap.Logger
-	stdLogger := zap.NewStdLog(logger)
-	// Create an slog.Logger from the standard log.Logger
-	slogLogger := slog.New(slog.NewTextHandler(stdLogger.Writer(), nil))
-
-	embeddingService, err := generativeAI.NewEmbeddingService(ctx, slogLogger)
-	if err != nil {
-		log.Fatalf("Failed to create embedding service: %v", err)
-	}
-
-	// Initialize RAG service
-
 	c := cache.New(24*time.Hour, 1*time.Hour) // Cache for 24 hours with cleanup every hour
 	service := &ServiceImpl{
 		logger:             logger,
@@ -138,77 +144,95 @@ func NewLlmInteractiontService(interestRepo interests.Repository,
 		interestRepo:       interestRepo,
 		searchProfileRepo:  searchProfileRepo,
 		searchProfileSvc:   searchProfileSvc,
-		aiClient:           aiClient,
-		embeddingService:   embeddingService,
+		llmProvider:        llmProvider,
 		llmInteractionRepo: llmInteractionRepo,
 		cityRepo:           cityRepo,
 		poiRepo:            poiRepo,
 		cache:              c,
 		streamProcessor:    NewStreamProcessor(logger),               // Initialize stream processor
 		llmLogger:          NewLLMLogger(logger, llmInteractionRepo), // Initialize LLM logger
-		deadLetterCh:       make(chan models.StreamEvent, 100),
+		deadLetterCh:       make(chan deadLetterEntry, 100),
 		intentClassifier:   &models.SimpleIntentClassifier{},
+		domainDetector:     &models.DomainDetector{},
+
+		schemaRepairAttempts: defaultSchemaRepairAttempts,
+		memory:               chatmemory.NewManager(chatmemory.NewLLMSummarizer(llmProvider), logger),
 	}
 	go service.processDeadLetterQueue()
 	return service
 }
 
-// getPersonalizedPOIWithSemanticContext creates an enhanced prompt with semantic POI context
-func (l *ServiceImpl) getPersonalizedPOIWithSemanticContext(interestNames []string, cityName, tagsPromptPart, userPrefs string, semanticPOIs []models.POIDetailedInfo) string {
-	prompt := fmt.Sprintf(`
-        Generate a personalized trip itinerary for %s, tailored to user interests [%s].
+// WithSchemaRepairAttempts sets how many times an invalid structured response is sent back
+// to the model for repair. Zero disables repairs, invalid responses are then only logged.
+func (l *ServiceImpl) WithSchemaRepairAttempts(n int) *ServiceImpl {
+	if n >= 0 {
+		l.schemaRepairAttempts = n
+	}
+	return l
+}
 
-        **SEMANTIC CONTEXT - Consider these highly relevant POIs found via semantic search:**
-        `, cityName, strings.Join(interestNames, ", "))
+// WithContextBudget sets the token budget of the conversation context sent to the model and
+// how many of the latest messages are always sent verbatim. Older messages are summarised.
+func (l *ServiceImpl) WithContextBudget(maxTokens, keepMessages int) *ServiceImpl {
+	l.memory.WithBudget(maxTokens, keepMessages)
+	return l
+}
 
-	// Add semantic POI context
-	if len(semanticPOIs) > 0 {
-		prompt += "\n**Contextually Relevant POIs:**\n"
-		for i, p := range semanticPOIs {
-			if i >= 10 { // Limit context to avoid token overuse
-				break
-			}
-			prompt += fmt.Sprintf("- %s (%s): %s [Lat: %.6f, Lon: %.6f]\n",
-				p.Name, p.Category, p.DescriptionPOI, p.Latitude, p.Longitude)
-		}
-		prompt += "\n**Instructions:** Use these semantic matches as inspiration and context. You may include them directly or use them to find similar places. Ensure variety and avoid exact duplicates.\n\n"
-	}
-
-	prompt += `Include:
-        1. An itinerary name that reflects both user interests and semantic context.
-        2. An overall description highlighting semantic relevance.
-        3. A lists of points of interest with name, category, coordinates, and detailed description.
-        Max points of interest allowed by tokens.
-
-        **PRIORITIZATION:**
-        - Highly weight POIs that align with the semantic context provided
-        - Ensure semantic relevance in descriptions
-        - Balance popular attractions with personalized semantic matches
-        - Include variety across different categories while maintaining semantic coherence
-
-        Format the response in JSON with the following structure:
-        {
-            "itinerary_name": "Name of the itinerary (reflecting semantic context)",
-            "overall_description": "Description emphasizing semantic relevance to user interests",
-            "points_of_interest": [
-                {
-                    "name": "POI name",
-                    "latitude": latitude_as_number,
-                    "longitude": longitude_as_number,
-                    "category": "Category",
-                    "description_poi": "Detailed description explaining semantic relevance to user interests and why this matches their preferences"
-                }
-            ]
-        }`
-
-	if tagsPromptPart != "" {
-		prompt += "\n**User Tags Context:** " + tagsPromptPart
-	}
-	if userPrefs != "" {
-		prompt += "\n**User Preferences:** " + userPrefs
-	}
-
-	return prompt
+// WithCostLedger makes streamed answers respect the daily LLM budgets: past the soft limit
+// earlier answers to the same prompt are reused, past the hard limit the model is not called.
+func (l *ServiceImpl) WithCostLedger(b BudgetChecker) *ServiceImpl {
+	l.budget = b
+	return l
+}
+
+// WithDeadLetters stores the stream events that cannot be delivered instead of only logging
+// them, so they can be re-delivered when the client reconnects to the session.
+func (l *ServiceImpl) WithDeadLetters(r DeadLetterRecorder) *ServiceImpl {
+	l.deadLetters = r
+	return l
+}
+
+// WithIntentClassifier replaces the keyword based intent classifier used for follow-up messages.
+func (l *ServiceImpl) WithIntentClassifier(c IntentClassifier) *ServiceImpl {
+	if c != nil {
+		l.intentClassifier = c
+	}
+	return l
+}
+
+// WithDomainDetector replaces the keyword based domain detector.
+func (l *ServiceImpl) WithDomainDetector(d DomainDetector) *ServiceImpl {
+	if d != nil {
+		l.domainDetector = d
+	}
+	return l
+}
+
+// getPersonalizedPOIWithSemanticContext creates an enhanced prompt with semantic POI context
+func (l *ServiceImpl) getPersonalizedPOIWithSemanticContext(subject string, interestNames []string, cityName, tagsPromptPart, userPrefs string, semanticPOIs []models.POIDetailedInfo) prompts.Rendered {
+	semanticContext := make([]prompts.SemanticPOI, 0, min(len(semanticPOIs), 10))
+	for i, p := range semanticPOIs {
+		if i >= 10 { // Limit context to avoid token overuse
+			break
+		}
+		semanticContext = append(semanticContext, prompts.SemanticPOI{
+			Name:        p.Name,
+			Category:    p.Category,
+			Description: p.DescriptionPOI,
+			Lat:         p.Latitude,
+			Lon:         p.Longitude,
+		})
+	}
+
+	return prompts.PersonalizedPOISemantic.MustRender(subject, prompts.PersonalizedPOISemanticParams{
+		PersonalizedPOIParams: prompts.PersonalizedPOIParams{
+			CityName:       cityName,
+			InterestNames:  interestNames,
+			TagsPromptPart: tagsPromptPart,
+			UserPrefs:      userPrefs,
+		},
+		SemanticPOIs: semanticContext,
+	})
 }
 
 func (l *ServiceImpl) FetchUserData(ctx context.Context, userID, profileID uuid.UUID) (interests []*models.Interest, searchProfile *models.UserPreferenceProfileResponse, tags []*models.Tags, err error) {
@@ -227,7 +251,9 @@ func (l *ServiceImpl) FetchUserData(ctx context.Context, userID, profileID uuid.
 	return interests, searchProfile, tags, nil
 }
 
-func (l *ServiceImpl) PreparePromptData(interests []*models.Interest, tags []*models.Tags, searchProfile *models.UserPreferenceProfileResponse) (interestNames []string, tagsPromptPart string, userPrefs string) {
+// PreparePromptData turns the user's data into prompt parts. language is the code of the
+// language answers are written in; English adds nothing to the preferences.
+func (l *ServiceImpl) PreparePromptData(interests []*models.Interest, tags []*models.Tags, searchProfile *models.UserPreferenceProfileResponse, language string) (interestNames []string, tagsPromptPart string, userPrefs string) {
 	if len(interests) == 0 {
 		interestNames = []string{"general sightseeing", "local experiences"}
 	} else {
@@ -251,6 +277,10 @@ func (l *ServiceImpl) PreparePromptData(interests []*models.Interest, tags []*mo
 		tagsPromptPart = fmt.Sprintf("\n    - Additionally, consider these specific user tags/preferences: [%s].", strings.Join(tagInfoForPrompt, "; "))
 	}
 	userPrefs = getUserPreferencesPrompt(searchProfile)
+	if code := localization.Normalize(language); code != localization.DefaultLanguage {
+		userPrefs += fmt.Sprintf(`
+    - Response Language: %s`, localization.Name(code))
+	}
 	return interestNames, tagsPromptPart, userPrefs
 }
 
@@ -297,30 +327,35 @@ func (l *ServiceImpl) HandleCityData(ctx context.Context, cityData models.Genera
 			AiSummary:       cityData.Description,
 			CenterLatitude:  cityData.CenterLatitude,
 			CenterLongitude: cityData.CenterLongitude,
+			Timezone:        cityData.Timezone,
 		}
 		cityID, err = l.cityRepo.SaveCity(ctx, cityDetail)
 		if err != nil {
 			return uuid.Nil, fmt.Errorf("failed to save city: %w", err)
 		}
+		l.recordCity(ctx, cityID, cityData.Description, true)
 	} else {
 		cityID = c.ID
+		l.recordCity(ctx, cityID, cityData.Description, false)
 	}
 	return cityID, nil
 }
 
-func (l *ServiceImpl) HandleGeneralPOIs(ctx context.Context, pois []models.POIDetailedInfo, cityID uuid.UUID) {
-	for _, p := range pois {
-		existingPoi, err := l.poiRepo.FindPoiByNameAndCity(ctx, p.Name, cityID)
+func (l *ServiceImpl) HandleGeneralPOIs(ctx context.Context, pois []models.POIDetailedInfo, cityID uuid.UUID, part string, llmInteractionID uuid.UUID) {
+	for _, p := range l.checkPOIs(ctx, cityID, pois, part, llmInteractionID) {
+		poiID, stored, err := l.poiStored(ctx, cityID, p)
 		if err != nil {
 			l.logger.Warn("Failed to check POI existence", zap.String("poi_name", p.Name), zap.Any("error", err))
 			continue
 		}
-		if existingPoi == nil {
-			_, err = l.poiRepo.SavePoi(ctx, p, cityID)
+		if !stored {
+			poiID, err = l.poiRepo.SavePoi(ctx, p, cityID)
 			if err != nil {
 				l.logger.Warn("Failed to save POI", zap.String("poi_name", p.Name), zap.Any("error", err))
+				continue
 			}
 		}
+		l.recordPOI(ctx, poiID, p.DescriptionPOI, !stored)
 	}
 }
 
@@ -336,6 +371,11 @@ func (l *ServiceImpl) HandlePersonalisedPOIs(ctx context.Context, pois []models.
 		return pois, nil // Return POIs without sorting/saving to avoid database errors
 	}
 
+	pois = l.checkPOIs(ctx, cityID, pois, "itinerary", llmInteractionID)
+	if len(pois) == 0 {
+		return pois, nil
+	}
+
 	err := l.llmInteractionRepo.SaveLlmSuggestedPOIsBatch(ctx, pois, userID, profileID, llmInteractionID, cityID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save personalised POIs: %w", err)
@@ -380,10 +420,14 @@ func (l *ServiceImpl) GenerateEnhancedPersonalisedPOIWorker(wg *sync.WaitGroup,
 	startTime := time.Now()
 
 	// Create enhanced prompt based on domain
-	prompt := l.getEnhancedPersonalizedPOIPrompt(cityName, enhancedPromptData, domain)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := l.getEnhancedPersonalizedPOIPrompt(userID.String(), cityName, enhancedPromptData, domain)
+	span.SetAttributes(
+		attribute.Int("prompt.length", len(prompt.Text)),
+		attribute.String("prompt.id", prompt.ID),
+		attribute.Int("prompt.version", prompt.Version),
+	)
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI generation failed")
@@ -430,61 +474,12 @@ func (l *ServiceImpl) GenerateEnhancedPersonalisedPOIWorker(wg *sync.WaitGroup,
 }
 
 // getEnhancedPersonalizedPOIPrompt creates a domain-aware prompt for personalized POI generation
-func (l *ServiceImpl) getEnhancedPersonalizedPOIPrompt(cityName, enhancedPromptData string, domain models.DomainType) string {
-	domainFocus := ""
-	switch domain {
-	case models.DomainAccommodation:
-		domainFocus = "Focus particularly on accommodation recommendations and nearby attractions that complement the user's accommodation preferences."
-	case models.DomainDining:
-		domainFocus = "Focus particularly on restaurant, food, and dining experiences that align with the user's culinary preferences."
-	case models.DomainActivities:
-		domainFocus = "Focus particularly on activities, attractions, and experiences that match the user's activity preferences and physical capabilities."
-	case models.DomainItinerary:
-		domainFocus = "Focus particularly on creating a well-structured itinerary that respects the user's planning style and pace preferences."
-	default:
-		domainFocus = "Provide a balanced mix of attractions, dining, and activities based on all user preferences."
-	}
-
-	prompt := fmt.Sprintf(`You are a travel AI assistant creating a personalized itinerary for %s.
-
-User Preferences and Filters:
-%s
-
-Domain Focus: %s
-
-%s
-
-Create a comprehensive and personalized itinerary that heavily weighs the user's specific preferences and filters. Ensure that every recommendation aligns with their stated preferences.
-
-Format the response in JSON with the following structure:
-{
-    "itinerary_name": "Personalized itinerary name reflecting user preferences",
-    "overall_description": "Description emphasizing how this itinerary matches user preferences",
-    "points_of_interest": [
-        {
-            "name": "POI name",
-            "category": "Category",
-            "coordinates": {
-                "latitude": float64,
-                "longitude": float64
-            },
-            "description": "Detailed description explaining why this POI matches the user's specific preferences and filters"
-        }
-    ]
-}`, cityName, enhancedPromptData, domainFocus, getBasePersonalizedPromptInstructions())
-
-	return prompt
-}
-
-func getBasePersonalizedPromptInstructions() string {
-	return `
-**Instructions:**
-- Prioritize POIs that directly align with user preferences and filters
-- Explain in descriptions how each POI matches their specific preferences
-- Ensure variety while maintaining preference alignment
-- Include practical details like accessibility if relevant to user preferences
-- Consider user's pace and planning style preferences in the selection
-- Maximum 8-10 POIs to maintain quality over quantity`
+func (l *ServiceImpl) getEnhancedPersonalizedPOIPrompt(subject, cityName, enhancedPromptData string, domain models.DomainType) prompts.Rendered {
+	return prompts.PersonalizedPOIEnhanced.MustRender(subject, prompts.EnhancedPOIParams{
+		CityName:    cityName,
+		Preferences: enhancedPromptData,
+		Domain:      string(domain),
+	})
 }
 
 func (l *ServiceImpl) SaveItenerary(ctx context.Context, userID uuid.UUID, req models.BookmarkRequest) (uuid.UUID, error) {
@@ -858,9 +853,9 @@ func (l *ServiceImpl) getPOIDetailedInfos(wg *sync.WaitGroup, ctx context.Contex
 
 	startTime := time.Now()
 
-	prompt := getPOIDetailsPrompt(city, lat, lon)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	prompt := localizePrompt(ctx, getPOIDetailsPrompt(userID.String(), city, lat, lon))
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate POI details")
@@ -896,12 +891,14 @@ func (l *ServiceImpl) getPOIDetailedInfos(wg *sync.WaitGroup, ctx context.Contex
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 	span.SetStatus(codes.Ok, "POI details generated successfully")
 	interaction := models.LlmInteraction{
-		UserID:       userID,
-		Prompt:       prompt,
-		ResponseText: txt,
-		ModelUsed:    model, // Adjust based on your AI client
-		LatencyMs:    latencyMs,
-		CityName:     city,
+		UserID:        userID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  txt,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     latencyMs,
+		CityName:      city,
 		// request payload
 		// response payload
 		// Add token counts if available from response (depends on genai API)
@@ -952,7 +949,8 @@ func (l *ServiceImpl) GetPOIDetailedInfosResponse(ctx context.Context, userID uu
 		zap.String("city", city), zap.Float64("latitude", lat), zap.Float64("longitude", lon), zap.String("userID", userID.String()))
 
 	// Generate cache key
-	cacheKey := generatePOICacheKey(city, lat, lon, 0.0, userID)
+	ctx = l.withUserLanguage(ctx, userID)
+	cacheKey := languageCacheKey(ctx, generatePOICacheKey(city, lat, lon, 0.0, userID))
 	span.SetAttributes(attribute.String("cache.key", cacheKey))
 
 	// Check cache
@@ -1025,12 +1023,19 @@ func (l *ServiceImpl) GetPOIDetailedInfosResponse(ctx context.Context, userID uu
 	}
 	poiResult = &res
 
-	// Save to database
-	_, err = l.poiRepo.SavePoi(ctx, *poiResult, cityID)
-	if err != nil {
-		l.logger.Warn("Failed to save POI details to database", zap.Any("error", err))
-		span.RecordError(err)
-		// Continue despite error to avoid blocking user
+	// Save to database, unless its coordinates cannot be trusted
+	if checked := l.checkPOIs(ctx, cityID, []models.POIDetailedInfo{*poiResult}, "poi_details", poiResult.LlmInteractionID); len(checked) == 1 {
+		poiResult = &checked[0]
+		poiID, err := l.poiRepo.SavePoi(ctx, *poiResult, cityID)
+		if err != nil {
+			l.logger.Warn("Failed to save POI details to database", zap.Any("error", err))
+			span.RecordError(err)
+			// Continue despite error to avoid blocking user
+		} else {
+			l.recordPOI(ctx, poiID, poiResult.DescriptionPOI, true)
+		}
+	} else {
+		span.AddEvent("POI details failed geographic checks, not saved")
 	}
 
 	// Store in cache
@@ -1043,7 +1048,7 @@ func (l *ServiceImpl) GetPOIDetailedInfosResponse(ctx context.Context, userID uu
 }
 
 // generatePOIData queries the LLM for POI details and calculates distance using PostGIS
-func (l *ServiceImpl) generatePOIData(ctx context.Context, poiName, cityName string, userLocation *models.UserLocation, userID, cityID uuid.UUID) (models.POIDetailedInfo, error) {
+func (l *ServiceImpl) generatePOIData(ctx context.Context, poiName, cityName, conversation string, userLocation *models.UserLocation, userID, cityID uuid.UUID) (models.POIDetailedInfo, error) {
 	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "GeneratePOIData", trace.WithAttributes(
 		attribute.String("p.name", poiName),
 		attribute.String("city.name", cityName),
@@ -1051,21 +1056,24 @@ func (l *ServiceImpl) generatePOIData(ctx context.Context, poiName, cityName str
 	defer span.End()
 
 	// Create a prompt for the LLM
-	prompt := generatedContinuedConversationPrompt(poiName, cityName)
+	prompt := generatedContinuedConversationPrompt(userID.String(), poiName, cityName, conversation)
 
 	// Generate LLM response
-	response, err := l.aiClient.GenerateContent(ctx, prompt, "", nil)
+	resp, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, nil)
 	if err != nil {
 		span.RecordError(err)
 		return models.POIDetailedInfo{}, fmt.Errorf("failed to generate POI data: %w", err)
 	}
+	response := llmprovider.TextFromResponse(resp)
 
 	interaction := models.LlmInteraction{
-		UserID:       userID,
-		Prompt:       prompt,
-		ResponseText: response,
-		ModelUsed:    model,
-		CityName:     cityName,
+		UserID:        userID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  response,
+		ModelUsed:     l.llmProvider.Model(),
+		CityName:      cityName,
 	}
 	savedLlmInteractionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
 	if err != nil {
@@ -1154,7 +1162,7 @@ func (l *ServiceImpl) generatePOIData(ctx context.Context, poiName, cityName str
 //		zap.String("message", userMessage),
 //		zap.String("city_id", cityID.String()))
 //
-//	if l.embeddingService == nil {
+//	if l.llmProvider == nil {
 //		l.logger.Warn("Embedding service not available, falling back to traditional search")
 //		span.AddEvent("Embedding service not available")
 //		return []models.POIDetailedInfo{}, nil
@@ -1166,7 +1174,7 @@ func (l *ServiceImpl) generatePOIData(ctx context.Context, poiName, cityName str
 //		searchQuery += " " + strings.Join(userPreferences, " ")
 //	}
 //
-//	queryEmbedding, err := l.embeddingService.GenerateQueryEmbedding(ctx, searchQuery)
+//	queryEmbedding, err := l.llmProvider.GenerateQueryEmbedding(ctx, searchQuery)
 //	if err != nil {
 //		l.logger.Error("Failed to generate query embedding",
 //			zap.Any("error", err),
@@ -1212,7 +1220,7 @@ func (l *ServiceImpl) generateSemanticPOIRecommendations(ctx context.Context, us
 		zap.String("city_id", cityID.String()),
 		zap.Float64("semantic_weight", semanticWeight))
 
-	if l.embeddingService == nil {
+	if l.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.logger.Error("Embedding service not available", zap.Any("error", err))
 		span.RecordError(err)
@@ -1221,7 +1229,7 @@ func (l *ServiceImpl) generateSemanticPOIRecommendations(ctx context.Context, us
 	}
 
 	// Generate embedding for user message
-	queryEmbedding, err := l.embeddingService.GenerateQueryEmbedding(ctx, userMessage)
+	queryEmbedding, err := l.llmProvider.GenerateQueryEmbedding(ctx, userMessage)
 	if err != nil {
 		l.logger.Error("Failed to generate query embedding", zap.Any("error", err))
 		span.RecordError(err)
@@ -1276,7 +1284,7 @@ func (l *ServiceImpl) generateSemanticPOIRecommendations(ctx context.Context, us
 		}
 
 		// Generate embedding for this POI if it doesn't have one
-		embedding, err := l.embeddingService.GeneratePOIEmbedding(ctx, p.Name, p.DescriptionPOI, p.Category)
+		embedding, err := l.llmProvider.GeneratePOIEmbedding(ctx, p.Name, p.DescriptionPOI, p.Category)
 		if err != nil {
 			l.logger.Warn("Failed to generate embedding for POI",
 				zap.Any("error", err),
@@ -1371,6 +1379,7 @@ func (l *ServiceImpl) handleSemanticRemovePOI(ctx context.Context, message strin
 			strings.Contains(strings.ToLower(poiName), strings.ToLower(p.Name)) {
 
 			removedName := p.Name
+			chatmemory.Reject(session, removedName)
 
 			// Remove from itinerary
 			session.CurrentItinerary.AIItineraryResponse.PointsOfInterest = append(
@@ -1389,6 +1398,9 @@ func (l *ServiceImpl) handleSemanticRemovePOI(ctx context.Context, message strin
 		}
 	}
 
+	// Nothing to remove, but keep the preference so later suggestions avoid it
+	chatmemory.Reject(session, message)
+
 	return fmt.Sprintf("I couldn't find %s in your itinerary. Here's what you currently have: %s",
 		poiName, strings.Join(func() []string {
 			var names []string
@@ -1399,6 +1411,48 @@ func (l *ServiceImpl) handleSemanticRemovePOI(ctx context.Context, message strin
 		}(), ", "))
 }
 
+// answerQuestion answers a follow-up question using the compacted conversation context.
+func (l *ServiceImpl) answerQuestion(ctx context.Context, session *models.ChatSession, question, conversation string) (string, error) {
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "answerQuestion")
+	defer span.End()
+
+	if l.budgetLevel(ctx, session.UserID) == costs.LevelHard {
+		span.SetAttributes(attribute.Bool("budget.exceeded", true))
+		return "I can't answer new questions right now because the AI usage limit has been reached. Your itinerary is still available, please try again later.", nil
+	}
+
+	prompt := getAnswerQuestionPrompt(session.ID.String(), question, conversation)
+	span.SetAttributes(attribute.Int("prompt.tokens_estimate", chatmemory.EstimateTokens(prompt.Text)))
+
+	startTime := time.Now()
+	resp, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](0.4),
+	})
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("failed to generate answer: %w", err)
+	}
+	answer := strings.TrimSpace(llmprovider.TextFromResponse(resp))
+	if answer == "" {
+		return "", fmt.Errorf("empty answer from LLM")
+	}
+
+	if _, err := l.llmInteractionRepo.SaveInteraction(ctx, models.LlmInteraction{
+		SessionID:     session.ID,
+		UserID:        session.UserID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  answer,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     int(time.Since(startTime).Milliseconds()),
+		CityName:      session.SessionContext.CityName,
+	}); err != nil {
+		l.logger.Warn("Failed to save question answer interaction", zap.Error(err))
+	}
+	return answer, nil
+}
+
 // min helper function
 func min(a, b int) int {
 	if a < b {
@@ -1409,28 +1463,9 @@ func min(a, b int) int {
 
 // extractCityFromMessage uses AI to extract city name and clean the message
 func (l *ServiceImpl) extractCityFromMessage(ctx context.Context, message string) (cityName, cleanedMessage string, err error) {
-	prompt := fmt.Sprintf(`
-You are a text parser. Extract the city name from the user's travel request and return a clean version of the message.
-
-User message: "%s"
+	prompt := prompts.ExtractCity.MustRender("", prompts.MessageParams{Message: message})
 
-Respond with ONLY a JSON object in this exact format:
-{
-    "city": "City Name",
-    "message": "cleaned message without city"
-}
-
-Examples:
-- "Find restaurants in Barcelona" → {"city": "Barcelona", "message": "Find restaurants"}
-- "What to do in Paris?" → {"city": "Paris", "message": "What to do"}
-- "Barcelona restaurants" → {"city": "Barcelona", "message": "restaurants"}
-- "Show me hotels in New York" → {"city": "New York", "message": "Show me hotels"}
-- "Things to do Madrid" → {"city": "Madrid", "message": "Things to do"}
-
-If no city is mentioned, use empty string for city.
-`, message)
-
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, &genai.GenerateContentConfig{
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
 		Temperature: genai.Ptr[float32](0.1), // Low temperature for consistent parsing
 	})
 	if err != nil {
@@ -1471,18 +1506,18 @@ If no city is mentioned, use empty string for city.
 }
 
 func (l *ServiceImpl) sendEvent(ctx context.Context, ch chan<- models.StreamEvent, event models.StreamEvent, retries int) bool {
-	for i := 0; i < retries; i++ {
-		if event.EventID == "" {
-			event.EventID = uuid.New().String()
-		}
-		if event.Timestamp.IsZero() {
-			event.Timestamp = time.Now()
-		}
+	if event.EventID == "" {
+		event.EventID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
 
+	for i := 0; i < retries; i++ {
 		select {
 		case <-ctx.Done():
 			l.logger.Warn("Context cancelled, not sending stream event", zap.String("eventType", event.Type))
-			l.deadLetterCh <- event // Send to dead letter queue
+			l.deadLetter(ctx, event, deadletter.ReasonContextCancelled)
 			return false
 		default:
 			select {
@@ -1490,60 +1525,20 @@ func (l *ServiceImpl) sendEvent(ctx context.Context, ch chan<- models.StreamEven
 				return true
 			case <-ctx.Done():
 				l.logger.Warn("Context cancelled while trying to send stream event", zap.String("eventType", event.Type))
-				l.deadLetterCh <- event // Send to dead letter queue
+				l.deadLetter(ctx, event, deadletter.ReasonContextCancelled)
 				return false
 			case <-time.After(2 * time.Second): // Use a reasonable timeout
-				l.logger.Warn("Dropped stream event due to slow consumer or blocked channel (timeout)", zap.String("eventType", event.Type))
-				l.deadLetterCh <- event // Send to dead letter queue
+				l.logger.Warn("Stream event not delivered due to slow consumer or blocked channel (timeout), retrying", zap.String("eventType", event.Type))
 				// Continue to retry after backoff
 			}
 		}
 		time.Sleep(100 * time.Millisecond) // Backoff
 	}
+	// Only dead-letter once every retry failed, an event delivered on a retry must not come back
+	l.deadLetter(ctx, event, deadletter.ReasonTimeout)
 	return false
 }
 
-func (l *ServiceImpl) processDeadLetterQueue() {
-	for event := range l.deadLetterCh {
-		l.logger.Error("Unprocessed event sent to dead letter queue", zap.Any("event", event))
-
-	}
-}
-
-// getPersonalizedPOI generates a prompt for personalized POIs
-func getPersonalizedPOI(interestNames []string, cityName, tagsPromptPart, userPrefs string) string {
-	prompt := fmt.Sprintf(`
-        Generate a personalized trip itinerary for %s, tailored to user interests [%s]. Include:
-        1. An itinerary name.
-        2. An overall description.
-        3. A lists of points of interest with name, category, coordinates, and detailed description.
-		Max points of interest allowed by tokens.
-        Format the response in JSON with the following structure:
-        {
-            "itinerary_name": "Name of the itinerary",
-            "overall_description": "Description of the itinerary",
-            "points_of_interest": [
-                {
-                    "name": "POI name",
-                    "category": "Category",
-                    "coordinates": {
-                        "latitude": float64,
-                        "longitude": float64
-                    },
-                    "description": "Detailed description of why this POI matches the user's interests"
-                }
-            ]
-        }
-    `, cityName, strings.Join(interestNames, ", "))
-	if tagsPromptPart != "" {
-		prompt += "\n" + tagsPromptPart
-	}
-	if userPrefs != "" {
-		prompt += "\n" + userPrefs
-	}
-	return prompt
-}
-
 // ContinueSessionStreamed handles subsequent messages in an existing session and streams responses/updates.
 func (l *ServiceImpl) ContinueSessionStreamed(
 	ctx context.Context, sessionID uuid.UUID,
@@ -1558,6 +1553,8 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 
 	l.logger.Debug("Continuing streamed chat session", zap.String("sessionID", sessionID.String()), zap.String("message", message))
 
+	ctx = withStreamOwner(ctx, sessionID, uuid.Nil)
+
 	// --- 1. Fetch Session & Basic Validation ---
 	session, err := l.llmInteractionRepo.GetSession(ctx, sessionID)
 	if err != nil {
@@ -1570,7 +1567,10 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeError, Error: err.Error(), IsFinal: true}, 3)
 		return err
 	}
+	ctx = withStreamOwner(ctx, sessionID, session.UserID)
+	ctx = l.withUserLanguage(ctx, session.UserID)
 	l.sendEvent(ctx, eventCh, models.StreamEvent{Type: "session_validated", Data: map[string]string{"status": "active"}}, 3)
+	previousItinerary := l.snapshotItinerary(session)
 
 	// --- 2. Fetch City ID ---
 	cityData, err := l.cityRepo.FindCityByNameAndCountry(ctx, session.SessionContext.CityName, "")
@@ -1600,6 +1600,19 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 	}
 	session.ConversationHistory = append(session.ConversationHistory, userMessage)
 
+	// Roll older turns into the session summary once they outgrow the context budget. The
+	// compacted window, not the raw history, is what the model sees for this turn. Summaries
+	// cost a model call, so they wait while the hard LLM budget is reached.
+	if l.budgetLevel(ctx, session.UserID) == costs.LevelHard {
+		span.SetAttributes(attribute.Bool("conversation.compaction_skipped", true))
+	} else if compacted, err := l.memory.Compact(ctx, session); err != nil {
+		l.logger.Warn("Failed to summarise conversation, sending the most recent messages only", zap.Error(err))
+		span.RecordError(err)
+	} else if compacted {
+		span.SetAttributes(attribute.Int("conversation.summarized_messages", session.SessionContext.SummarizedMessages))
+	}
+	conversation := l.memory.Window(session).String()
+
 	// --- 4. Classify Intent ---
 	intent, err := l.intentClassifier.Classify(ctx, message)
 	if err != nil {
@@ -1645,7 +1658,7 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 	case models.IntentAddPOI:
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeProgress, Data: "Processing: Adding Point of Interest with semantic enhancement..."}, 3)
 		var genErr error
-		finalResponseMessage, genErr = l.handleSemanticAddPOIStreamed(ctx, message, session, semanticPOIs, userLocation, cityID, eventCh)
+		finalResponseMessage, genErr = l.handleSemanticAddPOIStreamed(ctx, message, conversation, session, semanticPOIs, userLocation, cityID, eventCh)
 		if genErr != nil {
 			finalResponseMessage = "I had trouble understanding your request. Could you please specify which POI you'd like to add?"
 			assistantMessageType = models.TypeError
@@ -1662,7 +1675,23 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 
 	case models.IntentAskQuestion:
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeProgress, Data: "Processing: Answering your question with semantic context..."}, 3)
-		finalResponseMessage = "I’m here to help! For now, I’ll assume you’re asking about your trip. What specifically would you like to know?"
+		var answer string
+		var err error
+		if l.agentIterations > 0 {
+			answer, err = l.answerWithAgent(ctx, session, message, conversation, cityData, userLocation, eventCh)
+			if err != nil {
+				l.logger.Warn("Agent failed to answer, answering without tools", zap.Error(err))
+			}
+		}
+		if answer == "" {
+			answer, err = l.answerQuestion(ctx, session, message, conversation)
+		}
+		if err != nil {
+			l.logger.Warn("Failed to answer question", zap.Error(err))
+			span.RecordError(err)
+			answer = "I’m here to help! For now, I’ll assume you’re asking about your trip. What specifically would you like to know?"
+		}
+		finalResponseMessage = answer
 
 	case "replace_poi":
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeProgress, Data: "Processing: Replacing Point of Interest..."}, 3)
@@ -1671,7 +1700,7 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 			newPOIName := matches[2]
 			for i, p := range session.CurrentItinerary.AIItineraryResponse.PointsOfInterest {
 				if strings.Contains(strings.ToLower(p.Name), oldPOI) {
-					newPOI, err := l.generatePOIDataStream(ctx, newPOIName, session.SessionContext.CityName, userLocation, session.UserID, cityID, eventCh)
+					newPOI, err := l.generatePOIDataStream(ctx, newPOIName, session.SessionContext.CityName, conversation, userLocation, session.UserID, cityID, eventCh)
 					if err != nil {
 						finalResponseMessage = fmt.Sprintf("Could not replace %s with %s due to an error: %v", oldPOI, newPOIName, err)
 						assistantMessageType = models.TypeError
@@ -1698,7 +1727,7 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 			newPOIName := matches[2]
 			for i, p := range session.CurrentItinerary.AIItineraryResponse.PointsOfInterest {
 				if strings.Contains(strings.ToLower(p.Name), oldPOI) {
-					newPOI, err := l.generatePOIData(ctx, newPOIName, session.SessionContext.CityName, userLocation, session.UserID, cityID)
+					newPOI, err := l.generatePOIData(ctx, newPOIName, session.SessionContext.CityName, conversation, userLocation, session.UserID, cityID)
 					if err != nil {
 						l.logger.Error("Failed to generate POI data", zap.Any("error", err))
 						span.RecordError(err)
@@ -1747,6 +1776,8 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 		}
 	}
 
+	l.recordItineraryVersion(ctx, session, previousItinerary, string(intent), message)
+
 	// Add assistant's final response to history
 	assistantMessage := models.ConversationMessage{
 		ID: uuid.New(), Role: models.RoleAssistant, Content: finalResponseMessage, Timestamp: time.Now(), MessageType: assistantMessageType,
@@ -1816,7 +1847,7 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 //	startTime := time.Now()
 //
 //	var responseTextBuilder strings.Builder
-//	iter, err := l.aiClient.GenerateContentStream(ctx, prompt, config)
+//	iter, err := l.llmProvider.GenerateContentStream(ctx, prompt, config)
 //	if err != nil {
 //		l.sendEvent(ctx, eventCh, models.StreamEvent{
 //			Type:      models.EventTypeError,
@@ -1956,7 +1987,7 @@ func (l *ServiceImpl) ContinueSessionStreamed(
 
 // generatePOIDataStream queries the LLM for POI details and streams updates
 func (l *ServiceImpl) generatePOIDataStream(
-	ctx context.Context, poiName, cityName string,
+	ctx context.Context, poiName, cityName, conversation string,
 	userLocation *models.UserLocation, userID, cityID uuid.UUID,
 	eventCh chan<- models.StreamEvent,
 ) (models.POIDetailedInfo, error) {
@@ -1964,25 +1995,27 @@ func (l *ServiceImpl) generatePOIDataStream(
 		trace.WithAttributes(attribute.String("p.name", poiName), attribute.String("city.name", cityName)))
 	defer span.End()
 
-	prompt := generatedContinuedConversationPrompt(poiName, cityName)
+	prompt := generatedContinuedConversationPrompt(userID.String(), poiName, cityName, conversation)
 	config := &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.2)}
 	startTime := time.Now()
 
 	// Prepare logging configuration
 	sessionID := uuid.New() // Generate a session ID for this POI generation
 	logConfig := LoggingConfig{
-		UserID:      userID,
-		SessionID:   sessionID,
-		Intent:      "add_poi",
-		Prompt:      prompt,
-		CityName:    cityName,
-		ModelName:   "gemini-2.0-flash",
-		Provider:    "google",
-		Temperature: config.Temperature,
-		IsStreaming: true,
-	}
-
-	iter, err := l.aiClient.GenerateContentStream(ctx, prompt, config)
+		UserID:        userID,
+		SessionID:     sessionID,
+		Intent:        "add_poi",
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		CityName:      cityName,
+		ModelName:     l.llmProvider.Model(),
+		Provider:      l.llmProvider.Name(),
+		Temperature:   config.Temperature,
+		IsStreaming:   true,
+	}
+
+	iter, err := l.llmProvider.GenerateContentStream(ctx, prompt.Text, config)
 	if err != nil {
 		// Log failed LLM interaction
 		llmResponse := LLMResponse{
@@ -2036,9 +2069,9 @@ func (l *ServiceImpl) generatePOIDataStream(
 		StatusCode:   200,
 		// Token counts not available from streamProcessor - would need to be extracted from raw response
 		// We'll set them to 0 for now, or estimate based on text length
-		PromptTokens:     len(prompt) / 4, // Rough estimate: ~4 chars per token
+		PromptTokens:     len(prompt.Text) / 4, // Rough estimate: ~4 chars per token
 		CompletionTokens: len(fullText) / 4,
-		TotalTokens:      (len(prompt) + len(fullText)) / 4,
+		TotalTokens:      (len(prompt.Text) + len(fullText)) / 4,
 	}
 	l.llmLogger.LogInteractionAsync(ctx, logConfig, llmResponse, time.Since(startTime).Milliseconds())
 
@@ -2118,7 +2151,7 @@ func (l *ServiceImpl) saveCityInteraction(ctx context.Context, interaction model
 		interaction.LatencyMs = int(time.Since(interaction.Timestamp).Milliseconds())
 	}
 	if interaction.ModelUsed == "" {
-		interaction.ModelUsed = model // Default model
+		interaction.ModelUsed = l.llmProvider.Model()
 	}
 
 	interactionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
@@ -2133,7 +2166,7 @@ func (l *ServiceImpl) saveCityInteraction(ctx context.Context, interaction model
 }
 
 // handleSemanticAddPOIStreamed handles adding POIs with semantic search enhancement and streaming updates
-func (l *ServiceImpl) handleSemanticAddPOIStreamed(ctx context.Context, message string, session *models.ChatSession, semanticPOIs []models.POIDetailedInfo, userLocation *models.UserLocation, cityID uuid.UUID, eventCh chan<- models.StreamEvent) (string, error) {
+func (l *ServiceImpl) handleSemanticAddPOIStreamed(ctx context.Context, message, conversation string, session *models.ChatSession, semanticPOIs []models.POIDetailedInfo, userLocation *models.UserLocation, cityID uuid.UUID, eventCh chan<- models.StreamEvent) (string, error) {
 	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "handleSemanticAddPOIStreamed")
 	defer span.End()
 
@@ -2258,7 +2291,7 @@ func (l *ServiceImpl) handleSemanticAddPOIStreamed(ctx context.Context, message
 		},
 	}, 3)
 
-	newPOI, err := l.generatePOIDataStream(ctx, poiName, session.SessionContext.CityName, userLocation, session.UserID, cityID, eventCh)
+	newPOI, err := l.generatePOIDataStream(ctx, poiName, session.SessionContext.CityName, conversation, userLocation, session.UserID, cityID, eventCh)
 	if err != nil {
 		l.logger.Error("Failed to generate POI data for streaming", zap.Any("error", err))
 		span.RecordError(err)
@@ -2301,8 +2334,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	span.SetAttributes(attribute.String("extracted.city", cityName), attribute.String("cleaned.message", cleanedMessage))
 
 	// Detect domain
-	domainDetector := &models.DomainDetector{}
-	domain := domainDetector.DetectDomain(ctx, cleanedMessage)
+	domain := l.domainDetector.DetectDomain(ctx, cleanedMessage)
 	span.SetAttributes(attribute.String("detected.domain", string(domain)))
 
 	// Step 3: Fetch user data
@@ -2312,7 +2344,8 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeError, Error: err.Error()}, 3)
 		return fmt.Errorf("failed to fetch user data: %w", err)
 	}
-	basePreferences := getUserPreferencesPrompt(searchProfile)
+	ctx = l.withUserLanguage(ctx, userID)
+	_, _, basePreferences := l.PreparePromptData(nil, nil, searchProfile, languageFrom(ctx))
 
 	// Use default location if not provided
 	var lat, lon float64
@@ -2332,6 +2365,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	//var finalItineraryStream models.AIItineraryResponse
 	//var finalPois []models.POIDetailedInfo
 	sessionID := uuid.New()
+	ctx = withStreamOwner(ctx, sessionID, userID)
 
 	// Initialize session
 	session := models.ChatSession{
@@ -2392,10 +2426,22 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 
 	// Step 5: Collect responses for saving interaction
 	responses := make(map[string]*strings.Builder)
+	validated := make(map[string]*partValidation) // Parts generatePart validated, not to be repaired again
 	responsesMutex := sync.Mutex{}
 
 	// Modified sendEventWithResponse to capture responses
 	sendEventWithResponse := func(event models.StreamEvent) {
+		if event.Type == models.EventTypePartValidated {
+			if data, ok := event.Data.(map[string]interface{}); ok {
+				partType, _ := data["part"].(string)
+				if validation, ok := data["validation"].(*partValidation); ok {
+					responsesMutex.Lock()
+					validated[partType] = validation
+					responsesMutex.Unlock()
+				}
+			}
+			return // Only for the service, clients never see it
+		}
 		if event.Type == models.EventTypeChunk {
 			responsesMutex.Lock()
 			if data, ok := event.Data.(map[string]interface{}); ok {
@@ -2410,6 +2456,19 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 			}
 			responsesMutex.Unlock()
 		}
+		if event.Type == models.EventTypePartRepaired {
+			// The streamed text failed schema validation, keep the repaired JSON instead
+			responsesMutex.Lock()
+			if data, ok := event.Data.(map[string]interface{}); ok {
+				if partType, exists := data["part"].(string); exists {
+					if content, contentExists := data["content"].(string); contentExists {
+						responses[partType] = &strings.Builder{}
+						responses[partType].WriteString(content)
+					}
+				}
+			}
+			responsesMutex.Unlock()
+		}
 		l.sendEvent(ctx, eventCh, event, 3)
 	}
 
@@ -2418,21 +2477,21 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	case models.DomainItinerary, models.DomainGeneral:
 		// Worker 1: Stream City Data with cache
 		wg.Go(func() {
-			prompt := getCityDataPrompt(cityName)
+			prompt := getCityDataPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_city_data"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "city_data", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
 
 		// Worker 2: Stream General POIs with cache
 		wg.Go(func() {
-			prompt := getGeneralPOIPrompt(cityName)
+			prompt := getGeneralPOIPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_general_pois"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "general_pois", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
 
 		// Worker 3: Stream Personalized Itinerary with cache
 		wg.Go(func() {
-			prompt := getPersonalizedItineraryPrompt(cityName, basePreferences)
+			prompt := getPersonalizedItineraryPrompt(sessionID.String(), cityName, basePreferences)
 			partCacheKey := cacheKey + "_itinerary"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "itinerary", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
@@ -2440,13 +2499,13 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	case models.DomainAccommodation:
 		// Worker 1: Stream City Data with cache
 		wg.Go(func() {
-			prompt := getCityDataPrompt(cityName)
+			prompt := getCityDataPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_city_data"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "city_data", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
 
 		wg.Go(func() {
-			prompt := getAccommodationPrompt(cityName, lat, lon, basePreferences)
+			prompt := getAccommodationPrompt(sessionID.String(), cityName, lat, lon, basePreferences)
 			partCacheKey := cacheKey + "_hotels"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "hotels", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
@@ -2454,13 +2513,13 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	case models.DomainDining:
 		// Worker 1: Stream City Data with cache
 		wg.Go(func() {
-			prompt := getCityDataPrompt(cityName)
+			prompt := getCityDataPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_city_data"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "city_data", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
 
 		wg.Go(func() {
-			prompt := getDiningPrompt(cityName, lat, lon, basePreferences)
+			prompt := getDiningPrompt(sessionID.String(), cityName, lat, lon, basePreferences)
 			partCacheKey := cacheKey + "_restaurants"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "restaurants", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
@@ -2468,13 +2527,13 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 	case models.DomainActivities:
 		// Worker 1: Stream City Data with cache
 		wg.Go(func() {
-			prompt := getCityDataPrompt(cityName)
+			prompt := getCityDataPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_city_data"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "city_data", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
 
 		wg.Go(func() {
-			prompt := getActivitiesPrompt(cityName, lat, lon, basePreferences)
+			prompt := getActivitiesPrompt(sessionID.String(), cityName, lat, lon, basePreferences)
 			partCacheKey := cacheKey + "_activities"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "activities", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, userID)
 		})
@@ -2511,7 +2570,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 			//	l.cacheItineraryIfAvailable(ctx, sessionID, responses, &responsesMutex)
 			//}
 			// Cache result-specific data for restaurants, activities, and hotels
-			l.cacheResultsIfAvailable(ctx, sessionID, cacheKey, routeType, responses, &responsesMutex)
+			l.cacheResultsIfAvailable(ctx, sessionID, userID, cacheKey, routeType, responses, validated, &responsesMutex)
 
 			l.sendEvent(ctx, eventCh, models.StreamEvent{
 				Type: models.EventTypeComplete,
@@ -2536,7 +2595,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 
 	go func() {
 		//wg.Wait() // Wait for all workers to complete
-		asyncCtx := context.Background()
+		asyncCtx := withLanguage(context.Background(), languageFrom(ctx))
 
 		var fullResponseBuilder strings.Builder
 		responsesMutex.Lock()
@@ -2596,7 +2655,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStream(ctx context.Context, userI
 			CityName:     cityName,
 			Prompt:       fmt.Sprintf("Loci - Domain: %s, Message: %s", domain, cleanedMessage),
 			ResponseText: fullResponse,
-			ModelUsed:    model,
+			ModelUsed:    l.llmProvider.Model(),
 			LatencyMs:    int(time.Since(startTime).Milliseconds()),
 			Timestamp:    startTime,
 		}
@@ -2639,12 +2698,12 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 	span.SetAttributes(attribute.String("extracted.city", cityName), attribute.String("cleaned.message", cleanedMessage))
 
 	// Detect domain
-	domainDetector := &models.DomainDetector{}
-	domain := domainDetector.DetectDomain(ctx, cleanedMessage)
+	domain := l.domainDetector.DetectDomain(ctx, cleanedMessage)
 	span.SetAttributes(attribute.String("detected.domain", string(domain)))
 
 	// Step 4: Cache Integration - Generate cache key based on session parameters
 	sessionID := uuid.New()
+	ctx = withStreamOwner(ctx, sessionID, uuid.Nil)
 
 	// Initialize session
 	session := models.ChatSession{
@@ -2662,6 +2721,11 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 		ExpiresAt: time.Now().Add(24 * time.Hour),
 		Status:    "active",
 	}
+	// Keep the session under the visitor's guest id, so it follows them when they sign up
+	if guestID, ok := middleware.GuestIDFromContext(ctx); ok {
+		session.GuestID = &guestID
+		span.SetAttributes(attribute.String("guest.id", guestID.String()))
+	}
 	if err := l.llmInteractionRepo.CreateSession(ctx, session); err != nil {
 		span.RecordError(err)
 		l.sendEvent(ctx, eventCh, models.StreamEvent{Type: models.EventTypeError, Error: err.Error()}, 3)
@@ -2707,10 +2771,22 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 
 	// Step 5: Collect responses for saving interaction
 	responses := make(map[string]*strings.Builder)
+	validated := make(map[string]*partValidation) // Parts generatePart validated, not to be repaired again
 	responsesMutex := sync.Mutex{}
 
 	// Modified sendEventWithResponse to capture responses
 	sendEventWithResponse := func(event models.StreamEvent) {
+		if event.Type == models.EventTypePartValidated {
+			if data, ok := event.Data.(map[string]interface{}); ok {
+				partType, _ := data["part"].(string)
+				if validation, ok := data["validation"].(*partValidation); ok {
+					responsesMutex.Lock()
+					validated[partType] = validation
+					responsesMutex.Unlock()
+				}
+			}
+			return // Only for the service, clients never see it
+		}
 		if event.Type == models.EventTypeChunk {
 			responsesMutex.Lock()
 			if data, ok := event.Data.(map[string]interface{}); ok {
@@ -2725,6 +2801,19 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 			}
 			responsesMutex.Unlock()
 		}
+		if event.Type == models.EventTypePartRepaired {
+			// The streamed text failed schema validation, keep the repaired JSON instead
+			responsesMutex.Lock()
+			if data, ok := event.Data.(map[string]interface{}); ok {
+				if partType, exists := data["part"].(string); exists {
+					if content, contentExists := data["content"].(string); contentExists {
+						responses[partType] = &strings.Builder{}
+						responses[partType].WriteString(content)
+					}
+				}
+			}
+			responsesMutex.Unlock()
+		}
 		l.sendEvent(ctx, eventCh, event, 3)
 	}
 
@@ -2732,42 +2821,42 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 	switch domain {
 	case models.DomainItinerary, models.DomainGeneral:
 		wg.Go(func() {
-			prompt := getCityDataPrompt(cityName)
+			prompt := getCityDataPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_city_data"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "city_data", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
 
 		// Worker 2: Stream General POIs with cache
 		wg.Go(func() {
-			prompt := getGeneralPOIPrompt(cityName)
+			prompt := getGeneralPOIPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_general_pois"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "general_pois", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
 
 		// Worker 3: Stream Personalized Itinerary with cache
 		wg.Go(func() {
-			prompt := getGeneralizedItineraryPrompt(cityName)
+			prompt := getGeneralizedItineraryPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_itinerary"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "itinerary", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
 
 	case models.DomainAccommodation:
 		wg.Go(func() {
-			prompt := getGeneralAccommodationPrompt(cityName)
+			prompt := getGeneralAccommodationPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_hotels"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "hotels", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
 
 	case models.DomainDining:
 		wg.Go(func() {
-			prompt := getGeneralDiningPrompt(cityName)
+			prompt := getGeneralDiningPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_restaurants"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "restaurants", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
 
 	case models.DomainActivities:
 		wg.Go(func() {
-			prompt := getGeneralActivitiesPrompt(cityName)
+			prompt := getGeneralActivitiesPrompt(sessionID.String(), cityName)
 			partCacheKey := cacheKey + "_activities"
 			l.streamWorkerWithResponseAndCache(ctx, prompt, "activities", cityName, sendEventWithResponse, domain, partCacheKey, sessionID, uuid.Nil)
 		})
@@ -2799,7 +2888,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 				baseURL = "/itinerary"
 			}
 
-			l.cacheResultsIfAvailable(ctx, sessionID, cacheKey, routeType, responses, &responsesMutex)
+			l.cacheResultsIfAvailable(ctx, sessionID, uuid.Nil, cacheKey, routeType, responses, validated, &responsesMutex)
 			// Cache itinerary data if this was an itinerary request
 			//if routeType == "itinerary" {
 			//	l.cacheItineraryIfAvailable(ctx, sessionID, responses, &responsesMutex)
@@ -2833,7 +2922,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 		wg.Wait() // Wait for all workers to complete
 
 		// Save interaction with complete response
-		asyncCtx := context.Background()
+		asyncCtx := withLanguage(context.Background(), languageFrom(ctx))
 
 		// Combine all responses into a single response text
 		var fullResponseBuilder strings.Builder
@@ -2892,7 +2981,7 @@ func (l *ServiceImpl) ProcessUnifiedChatMessageStreamFree(ctx context.Context, c
 			CityName:     cityName,
 			Prompt:       fmt.Sprintf("Unified Chat Stream - Domain: %s, Message: %s", domain, cleanedMessage),
 			ResponseText: fullResponse,
-			ModelUsed:    model,
+			ModelUsed:    l.llmProvider.Model(),
 			LatencyMs:    int(time.Since(startTime).Milliseconds()),
 			Timestamp:    startTime,
 		}
@@ -2968,8 +3057,11 @@ func (l *ServiceImpl) parseCityDataFromResponse(_ context.Context, responseConte
 }
 
 // streamWorkerWithResponseAndCache handles streaming for a single worker with response capture and cache support
-func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prompt, partType, cityName string, sendEvent func(models.StreamEvent), domain models.DomainType, cacheKey string, sessionID, userID uuid.UUID) {
+func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prompt prompts.Rendered, partType, cityName string, sendEvent func(models.StreamEvent), domain models.DomainType, cacheKey string, sessionID, userID uuid.UUID) {
 	startTime := time.Now()
+	// A response cached for one template version must not be served to a session split onto another
+	cacheKey = fmt.Sprintf("%s_v%d", languageCacheKey(ctx, cacheKey), prompt.Version)
+	prompt = localizePrompt(ctx, prompt)
 
 	// Prepare logging configuration
 	intent := string(domain) // Use domain as intent (e.g., "itinerary", "dining", "accommodation")
@@ -2978,20 +3070,74 @@ func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prom
 	}
 
 	config := LoggingConfig{
-		UserID:      userID,
-		SessionID:   sessionID,
-		Intent:      intent,
-		Prompt:      prompt,
-		CityName:    cityName,
-		ModelName:   "gemini-2.0-flash", // Default model
-		Provider:    "google",
-		Temperature: genai.Ptr[float32](defaultTemperature),
-		IsStreaming: true,
-		CacheKey:    cacheKey,
+		UserID:        userID,
+		SessionID:     sessionID,
+		Intent:        intent,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		CityName:      cityName,
+		ModelName:     l.llmProvider.Model(),
+		Provider:      l.llmProvider.Name(),
+		Temperature:   genai.Ptr[float32](defaultTemperature),
+		IsStreaming:   true,
+		CacheKey:      cacheKey,
+	}
+
+	// Past the budget, answer from earlier responses or stored data instead of the model
+	if l.serveWithinBudget(ctx, l.budgetLevel(ctx, userID), config, partType, cityName, domain, sendEvent) {
+		return
+	}
+
+	// An identical generation already in flight is shared: this caller replays the chunks
+	// produced so far, then follows the live stream
+	var received strings.Builder
+	var failure string
+	shared, _ := l.inflight.Do(ctx, cacheKey, func(genCtx context.Context, publish func(models.StreamEvent)) error {
+		l.generatePart(genCtx, prompt, partType, domain, cacheKey, config, startTime, publish)
+		return nil
+	}, func(event models.StreamEvent) {
+		if event.Type == models.EventTypeError {
+			failure = event.Error
+		}
+		if data, ok := event.Data.(map[string]interface{}); ok {
+			switch event.Type {
+			case models.EventTypeChunk:
+				chunk, _ := data["chunk"].(string)
+				received.WriteString(chunk)
+			case models.EventTypePartRepaired:
+				content, _ := data["content"].(string)
+				received.Reset()
+				received.WriteString(content)
+			}
+		}
+		if ctx.Err() == nil {
+			sendEvent(event)
+		}
+	})
+
+	// The generation is logged once by the caller that started it, the others record a cache hit
+	if shared && ctx.Err() == nil {
+		chunks := 1
+		llmResponse := LLMResponse{
+			ResponseText:      received.String(),
+			StatusCode:        200,
+			CacheHit:          true,
+			StreamChunksCount: &chunks,
+		}
+		if failure != "" {
+			llmResponse.StatusCode = 500
+			llmResponse.ErrorMessage = failure
+		}
+		l.llmLogger.LogInteractionAsync(ctx, config, llmResponse, time.Since(startTime).Milliseconds())
 	}
+}
 
+// generatePart streams a part from the model to sendEvent, validates it against the part's
+// schema, keeps it for budget fallbacks and logs the interaction.
+func (l *ServiceImpl) generatePart(ctx context.Context, prompt prompts.Rendered, partType string, domain models.DomainType, cacheKey string, config LoggingConfig, startTime time.Time, sendEvent func(models.StreamEvent)) {
 	// Make the LLM call
-	iter, err := l.aiClient.GenerateContentStreamWithCache(ctx, prompt, &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](defaultTemperature)}, cacheKey)
+	iter, err := l.llmProvider.GenerateContentStreamWithCache(ctx, prompt.Text, &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](defaultTemperature)}, cacheKey)
 	if err != nil {
 		// Log failed LLM interaction
 		llmResponse := LLMResponse{
@@ -3013,6 +3159,7 @@ func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prom
 	var promptTokens, completionTokens, totalTokens int
 	var lastResp *genai.GenerateContentResponse
 	chunkCount := 0
+	items := newItemStreamer(partType)
 
 	for resp, err := range iter {
 		if ctx.Err() != nil {
@@ -3061,6 +3208,7 @@ func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prom
 								"cache_used": cacheKey != "",
 							},
 						})
+						l.streamItems(ctx, items, chunk, sendEvent)
 					}
 				}
 			}
@@ -3074,6 +3222,8 @@ func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prom
 		totalTokens = int(lastResp.UsageMetadata.TotalTokenCount)
 	}
 
+	streamDuration := int(time.Since(startTime).Milliseconds())
+
 	// Log successful LLM interaction
 	llmResponse := LLMResponse{
 		ResponseText:      fullResponse.String(),
@@ -3083,24 +3233,78 @@ func (l *ServiceImpl) streamWorkerWithResponseAndCache(ctx context.Context, prom
 		StatusCode:        200,
 		CacheHit:          cacheKey != "",
 		StreamChunksCount: &chunkCount,
+		StreamDurationMs:  &streamDuration,
+	}
+
+	// Validate the streamed JSON against the part's schema, re-prompting for a repair if needed
+	if validation := l.validateAndRepairPart(ctx, partType, fullResponse.String()); validation != nil {
+		llmResponse.Validation = &validation.SchemaValidation
+		llmResponse.PromptTokens += validation.PromptTokens
+		llmResponse.CompletionTokens += validation.CompletionTokens
+		llmResponse.TotalTokens += validation.TotalTokens
+		if validation.Repaired {
+			llmResponse.ResponseText = validation.Text
+			if ctx.Err() == nil {
+				sendEvent(models.StreamEvent{
+					Type: models.EventTypePartRepaired,
+					Data: map[string]interface{}{
+						"part":            partType,
+						"content":         validation.Text,
+						"domain":          string(domain),
+						"repair_attempts": validation.RepairAttempts,
+					},
+				})
+			}
+		}
+		if ctx.Err() == nil {
+			sendEvent(models.StreamEvent{
+				Type: models.EventTypePartValidated,
+				Data: map[string]interface{}{"part": partType, "validation": validation},
+			})
+		}
+	}
+
+	// Cards were sent as their items streamed in; the full parse settles the final list
+	if ctx.Err() == nil {
+		l.reconcileStreamedItems(items, llmResponse.ResponseText, sendEvent)
+	}
+
+	// Keep valid answers around for when the budget runs out
+	if llmResponse.ResponseText != "" && (llmResponse.Validation == nil || llmResponse.Validation.Valid) {
+		cache2.Cache.PartResponses.Set(cacheKey, llmResponse.ResponseText)
 	}
-	streamDuration := int(time.Since(startTime).Milliseconds())
-	llmResponse.StreamDurationMs = &streamDuration
 
 	l.llmLogger.LogInteractionAsync(ctx, config, llmResponse, time.Since(startTime).Milliseconds())
 }
 
-// cacheResultsIfAvailable caches result-specific data for restaurants, activities, and hotels
-func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uuid.UUID, cacheKey string, routeType string, responses map[string]*strings.Builder, responsesMutex *sync.Mutex) {
+// cacheResultsIfAvailable caches result-specific data for restaurants, activities, and hotels.
+// Itineraries are routed for userID first; uuid.Nil routes them on foot.
+func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID, userID uuid.UUID, cacheKey string, routeType string, responses map[string]*strings.Builder, validated map[string]*partValidation, responsesMutex *sync.Mutex) {
+	// Repairs call the model, so they run on a copy without holding up the workers
 	responsesMutex.Lock()
-	defer responsesMutex.Unlock()
+	texts := make(map[string]string, len(responses))
+	for partType, builder := range responses {
+		if builder != nil {
+			texts[partType] = builder.String()
+		}
+	}
+	validations := maps.Clone(validated)
+	responsesMutex.Unlock()
+
+	parts := l.validateParts(ctx, LoggingConfig{
+		UserID:    userID,
+		SessionID: sessionID,
+		ModelName: l.llmProvider.Model(),
+		Provider:  l.llmProvider.Name(),
+	}, texts, validations)
 
 	switch routeType {
 	case "itinerary":
 		// Cache both individual itinerary part and complete response
-		if itineraryBuilder, exists := responses["itinerary"]; exists && itineraryBuilder != nil {
-			itineraryResponse := itineraryBuilder.String()
+		if itineraryResponse, exists := parts.texts["itinerary"]; exists {
 			if itinerary, err := parseItineraryFromResponse(itineraryResponse, l.logger); err == nil {
+				l.RouteItinerary(ctx, userID, itinerary)
+				l.EstimateCost(ctx, userID, itinerary, 0)
 				// Print JSON data for debugging (individual itinerary part)
 				jsonData, err := json.MarshalIndent(itinerary, "", "  ")
 				if err != nil {
@@ -3117,7 +3321,9 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 		}
 
 		// Cache complete response with all parts (city_data + general_pois + itinerary)
-		if completeResponse, err := l.parseCompleteResponseFromParts(responses, sessionID); err == nil {
+		if completeResponse, err := l.parseCompleteResponseFromParts(parts, sessionID); err == nil {
+			l.RouteItinerary(ctx, userID, &completeResponse.AIItineraryResponse)
+			l.EstimateCost(ctx, userID, &completeResponse.AIItineraryResponse, 0)
 			// Print JSON data for debugging
 			jsonData, err := json.MarshalIndent(completeResponse, "", "  ")
 			if err != nil {
@@ -3139,8 +3345,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 				zap.Any("error", err))
 		}
 	case "restaurants":
-		if restaurantBuilder, exists := responses["restaurants"]; exists && restaurantBuilder != nil {
-			restaurantResponse := restaurantBuilder.String()
+		if restaurantResponse, exists := parts.texts["restaurants"]; exists {
 			if restaurants, err := parseRestaurantsFromResponse(restaurantResponse, l.logger); err == nil && len(restaurants) > 0 {
 				// Print JSON data for debugging
 				jsonData, err := json.MarshalIndent(restaurants, "", "  ")
@@ -3157,7 +3362,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 				cache2.RestaurantsCache.Set(cacheKey, restaurants)
 
 				// Also cache to CompleteItineraryCache using cacheKey for reusability
-				if completeResponse, err := l.parseCompleteResponseFromParts(responses, sessionID); err == nil {
+				if completeResponse, err := l.parseCompleteResponseFromParts(parts, sessionID); err == nil {
 					cache2.CompleteItineraryCache.Set(cacheKey, *completeResponse)
 					l.logger.Info("Caching complete response for restaurants",
 						zap.String("sessionID", sessionID.String()),
@@ -3170,8 +3375,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 			}
 		}
 	case "activities":
-		if activityBuilder, exists := responses["activities"]; exists && activityBuilder != nil {
-			activityResponse := activityBuilder.String()
+		if activityResponse, exists := parts.texts["activities"]; exists {
 			if activities, err := parseActivitiesFromResponse(activityResponse, l.logger); err == nil && len(activities) > 0 {
 				// Print JSON data for debugging
 				jsonData, err := json.MarshalIndent(activities, "", "  ")
@@ -3188,7 +3392,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 				cache2.ActivitiesCache.Set(cacheKey, activities)
 
 				// Also cache to CompleteItineraryCache using cacheKey for reusability
-				if completeResponse, err := l.parseCompleteResponseFromParts(responses, sessionID); err == nil {
+				if completeResponse, err := l.parseCompleteResponseFromParts(parts, sessionID); err == nil {
 					cache2.CompleteItineraryCache.Set(cacheKey, *completeResponse)
 					l.logger.Info("Caching complete response for activities",
 						zap.String("sessionID", sessionID.String()),
@@ -3201,8 +3405,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 			}
 		}
 	case "hotels":
-		if hotelBuilder, exists := responses["hotels"]; exists && hotelBuilder != nil {
-			hotelResponse := hotelBuilder.String()
+		if hotelResponse, exists := parts.texts["hotels"]; exists {
 			if hotels, err := parseHotelsFromResponse(hotelResponse, l.logger); err == nil && len(hotels) > 0 {
 				// Print JSON data for debugging
 				jsonData, err := json.MarshalIndent(hotels, "", "  ")
@@ -3219,7 +3422,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 				cache2.HotelsCache.Set(cacheKey, hotels)
 
 				// Also cache to CompleteItineraryCache using cacheKey for reusability
-				if completeResponse, err := l.parseCompleteResponseFromParts(responses, sessionID); err == nil {
+				if completeResponse, err := l.parseCompleteResponseFromParts(parts, sessionID); err == nil {
 					cache2.CompleteItineraryCache.Set(cacheKey, *completeResponse)
 					l.logger.Info("Caching complete response for hotels",
 						zap.String("sessionID", sessionID.String()),
@@ -3236,7 +3439,7 @@ func (l *ServiceImpl) cacheResultsIfAvailable(ctx context.Context, sessionID uui
 
 // GenerateNearbyPOIs generates POI recommendations based on location coordinates
 func (l *ServiceImpl) GenerateNearbyPOIs(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (string, error) {
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt, config)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate nearby POIs: %w", err)
 	}
@@ -0,0 +1,170 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/jsonstream"
+)
+
+// streamedItemArrays lists, per part, the arrays whose items are sent one by one while the part
+// is still streaming. The empty key stands for an answer that is a bare array.
+var streamedItemArrays = map[string][]string{
+	"general_pois": {"points_of_interest"},
+	"itinerary":    {"points_of_interest"},
+	"restaurants":  {"restaurants", ""},
+	"hotels":       {"hotels", ""},
+}
+
+// itemStreamer scans one part as it streams and counts the items sent for it.
+type itemStreamer struct {
+	partType string
+	scanner  *jsonstream.Scanner
+	sent     int
+}
+
+// newItemStreamer returns nil for parts without items, such as city_data.
+func newItemStreamer(partType string) *itemStreamer {
+	arrays, ok := streamedItemArrays[partType]
+	if !ok {
+		return nil
+	}
+	return &itemStreamer{partType: partType, scanner: jsonstream.NewScanner(arrays...)}
+}
+
+// itemDomain is the results domain the items of a part are rendered and reported under.
+func itemDomain(partType string) string {
+	switch partType {
+	case "restaurants", "hotels":
+		return partType
+	default:
+		return "itinerary"
+	}
+}
+
+// streamItems feeds a chunk to the part's scanner and sends an event, with the rendered card,
+// for every item the chunk completed. Items that do not decode are left to the full parse.
+func (l *ServiceImpl) streamItems(ctx context.Context, s *itemStreamer, chunk string, sendEvent func(models.StreamEvent)) {
+	if s == nil {
+		return
+	}
+	for _, item := range s.scanner.Write(chunk) {
+		event, err := l.itemEvent(ctx, s.partType, item)
+		if err != nil {
+			l.logger.Debug("Skipping streamed item that does not decode",
+				zap.String("part", s.partType),
+				zap.Int("index", item.Index),
+				zap.Error(err))
+			continue
+		}
+		s.sent++
+		sendEvent(event)
+	}
+}
+
+func (l *ServiceImpl) itemEvent(ctx context.Context, partType string, item jsonstream.Item) (models.StreamEvent, error) {
+	var eventType string
+	var decoded interface{}
+	switch partType {
+	case "restaurants":
+		var restaurant models.RestaurantDetailedInfo
+		if err := json.Unmarshal(item.Raw, &restaurant); err != nil {
+			return models.StreamEvent{}, fmt.Errorf("failed to decode restaurant: %w", err)
+		}
+		eventType, decoded = models.EventTypeRestaurant, restaurant
+	case "hotels":
+		var hotel models.HotelDetailedInfo
+		if err := json.Unmarshal(item.Raw, &hotel); err != nil {
+			return models.StreamEvent{}, fmt.Errorf("failed to decode hotel: %w", err)
+		}
+		eventType, decoded = models.EventTypeHotel, hotel
+	default:
+		var poi models.POIDetailedInfo
+		if err := json.Unmarshal(item.Raw, &poi); err != nil {
+			return models.StreamEvent{}, fmt.Errorf("failed to decode POI: %w", err)
+		}
+		eventType, decoded = models.EventTypeGeneralPOI, poi
+		if partType == "itinerary" {
+			eventType = models.EventTypePersonalizedPOI
+		}
+	}
+
+	domain := itemDomain(partType)
+	html, err := l.RenderItemHTML(ctx, domain, decoded, item.Index+1)
+	if err != nil {
+		l.logger.Warn("Failed to render streamed item", zap.String("part", partType), zap.Error(err))
+	}
+
+	return models.StreamEvent{
+		Type:     eventType,
+		Domain:   domain,
+		ItemID:   fmt.Sprintf("%s-%d", partType, item.Index),
+		ItemData: decoded,
+		HTML:     html,
+		Data: map[string]interface{}{
+			"part":  partType,
+			"index": item.Index,
+		},
+	}, nil
+}
+
+// reconcileStreamedItems runs the full parse of a part once it has finished streaming and
+// sends its items, so clients replace the cards streamed from items the scanner got wrong or
+// missed. When the full parse fails the streamed cards stay.
+func (l *ServiceImpl) reconcileStreamedItems(s *itemStreamer, responseText string, sendEvent func(models.StreamEvent)) {
+	if s == nil {
+		return
+	}
+
+	var items interface{}
+	var count int
+	var err error
+	switch s.partType {
+	case "restaurants":
+		var restaurants []models.RestaurantDetailedInfo
+		restaurants, err = parseRestaurantsFromResponse(responseText, l.logger)
+		items, count = restaurants, len(restaurants)
+	case "hotels":
+		var hotels []models.HotelDetailedInfo
+		hotels, err = parseHotelsFromResponse(responseText, l.logger)
+		items, count = hotels, len(hotels)
+	case "itinerary":
+		var itinerary *models.AIItineraryResponse
+		itinerary, err = parseItineraryFromResponse(responseText, l.logger)
+		if err == nil {
+			items, count = itinerary.PointsOfInterest, len(itinerary.PointsOfInterest)
+		}
+	default:
+		var pois []models.POIDetailedInfo
+		pois, err = parsePOIsFromResponse(responseText, l.logger)
+		items, count = pois, len(pois)
+	}
+	if err != nil {
+		l.logger.Warn("Full parse of streamed part failed, keeping the streamed items",
+			zap.String("part", s.partType),
+			zap.Int("streamed", s.sent),
+			zap.Error(err))
+		return
+	}
+	if count != s.sent {
+		l.logger.Info("Full parse differs from the streamed items",
+			zap.String("part", s.partType),
+			zap.Int("streamed", s.sent),
+			zap.Int("parsed", count))
+	}
+
+	sendEvent(models.StreamEvent{
+		Type:   models.EventTypeItemsReconciled,
+		Domain: itemDomain(s.partType),
+		Data: map[string]interface{}{
+			"part":     s.partType,
+			"streamed": s.sent,
+			"total":    count,
+			"items":    items,
+		},
+	})
+}
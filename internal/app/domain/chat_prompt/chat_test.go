@@ -1,36 +1,56 @@
 package llmchat
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 
-	"github.com/FACorreiaa/go-templui/internal/app/common"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
 )
 
+// stubLlmService answers chat messages without generating anything.
+type stubLlmService struct {
+	LlmInteractiontService
+}
+
+func (s *stubLlmService) ProcessUnifiedChatMessageStream(_ context.Context, _, _ uuid.UUID, _, _ string, _ *models.UserLocation, eventCh chan<- models.StreamEvent) error {
+	close(eventCh)
+	return nil
+}
+
+// stubProfileService returns the same default profile for every user.
+type stubProfileService struct {
+	profiles.Service
+}
+
+func (s *stubProfileService) GetDefaultSearchProfile(_ context.Context, _ uuid.UUID) (*models.UserPreferenceProfileResponse, error) {
+	return &models.UserPreferenceProfileResponse{ID: uuid.New()}, nil
+}
+
 func TestChatHandlers_SendMessage(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
-	// Create mock dependencies
-	mockLlmService := &common.MockLlmService{}
-	mockProfileService := &common.MockProfileService{}
-	mockChatRepo := &common.MockRepository{}
-
 	// Setup the router
 	r := gin.Default()
 	r.Static("/static", "./assets/static")
 	r.StaticFile("/sw.js", "./static/sw.js")
 	r.Use(func(c *gin.Context) {
-		// Mock the user ID in the context with a valid UUID
-		c.Set("user_id", "550e8400-e29b-41d4-a716-446655440000")
+		// Mock the user in the context with a valid UUID
+		c.Set(string(middleware.UserContextKey), &models.User{ID: "550e8400-e29b-41d4-a716-446655440000"})
 		c.Next()
 	})
-	chatHandlers := NewChatHandlers(mockLlmService, mockProfileService, mockChatRepo)
+	chatHandlers := NewChatHandlers(&stubLlmService{}, &stubProfileService{}, nil, zap.NewNop())
 	r.POST("/chat/message", chatHandlers.SendMessage)
 
 	t.Run("it returns a successful response with a valid message", func(t *testing.T) {
@@ -80,3 +100,76 @@ func TestChatHandlers_SendMessage(t *testing.T) {
 		assert.Contains(t, responseBody, "Message cannot be empty", "Response should contain the error message")
 	})
 }
+
+// stubRedeliverer hands its events over like the dead letter service: in order, stopping at
+// the first one that fails.
+type stubRedeliverer struct {
+	events      []models.StreamEvent
+	undelivered []string
+	err         error
+}
+
+func (s *stubRedeliverer) Redeliver(_ context.Context, _ uuid.UUID, deliver func(models.StreamEvent) error) error {
+	for i, event := range s.events {
+		if s.err = deliver(event); s.err != nil {
+			for _, e := range s.events[i:] {
+				s.undelivered = append(s.undelivered, e.EventID)
+			}
+			return s.err
+		}
+	}
+	return nil
+}
+
+// brokenConn accepts writes and fails every flush after the first ok ones, like a connection
+// the client closed.
+type brokenConn struct {
+	*httptest.ResponseRecorder
+	ok int
+}
+
+func (w *brokenConn) FlushError() error {
+	if w.ok == 0 {
+		return errors.New("broken pipe")
+	}
+	w.ok--
+	w.Flush()
+	return nil
+}
+
+func TestChatHandlers_RedeliverDeadLetters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	events := []models.StreamEvent{
+		{Type: models.EventTypeChunk, EventID: "a", Data: "first"},
+		{Type: models.EventTypeChunk, EventID: "b", Data: "second"},
+		{Type: models.EventTypeComplete, EventID: "done"},
+	}
+
+	t.Run("writes the missed events without the terminal one", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/chat/stream", nil)
+		letters := &stubRedeliverer{events: events}
+		h := NewChatHandlers(&stubLlmService{}, nil, nil, zap.NewNop()).WithDeadLetters(letters)
+
+		h.redeliverDeadLetters(c, uuid.New())
+
+		assert.NoError(t, letters.err)
+		assert.Equal(t, 2, strings.Count(w.Body.String(), "data: "))
+		assert.Contains(t, w.Body.String(), `"event_id":"b"`)
+		assert.NotContains(t, w.Body.String(), `"event_id":"done"`)
+	})
+
+	t.Run("events the client did not get stay undelivered", func(t *testing.T) {
+		w := &brokenConn{ResponseRecorder: httptest.NewRecorder(), ok: 1}
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/chat/stream", nil)
+		letters := &stubRedeliverer{events: events}
+		h := NewChatHandlers(&stubLlmService{}, nil, nil, zap.NewNop()).WithDeadLetters(letters)
+
+		h.redeliverDeadLetters(c, uuid.New())
+
+		assert.Error(t, letters.err)
+		assert.Equal(t, []string{"b", "done"}, letters.undelivered)
+	})
+}
@@ -0,0 +1,218 @@
+package llmchat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/costs"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/trips"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+var _ trips.Planner = (*ServiceImpl)(nil)
+
+// StartTripSession creates the chat session a multi-city trip is kept in. The session is
+// named after the first city, where the trip starts.
+func (l *ServiceImpl) StartTripSession(ctx context.Context, userID, profileID uuid.UUID, stops []models.TripStop) (*models.ChatSession, error) {
+	if len(stops) == 0 {
+		return nil, fmt.Errorf("trip has no cities: %w", models.ErrBadRequest)
+	}
+	if profileID == uuid.Nil {
+		profile, err := l.searchProfileSvc.GetDefaultSearchProfile(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get default search profile: %w", err)
+		}
+		profileID = profile.ID
+	}
+
+	now := time.Now()
+	session := models.ChatSession{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ProfileID: profileID,
+		CityName:  stops[0].City,
+		ConversationHistory: []models.ConversationMessage{{
+			ID:          uuid.New(),
+			Role:        models.RoleUser,
+			Content:     describeTrip(stops),
+			MessageType: models.TypeInitialRequest,
+			Timestamp:   now,
+		}},
+		SessionContext: models.SessionContext{CityName: stops[0].City},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ExpiresAt:      now.Add(24 * time.Hour),
+		Status:         models.StatusActive,
+		SearchType:     models.SearchTypeItinerary,
+	}
+	if err := l.llmInteractionRepo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create trip session: %w", err)
+	}
+	return &session, nil
+}
+
+// GenerateTripCity generates the itinerary of one city of a trip, knowing the cities before
+// and after it so the stay fits the journey.
+func (l *ServiceImpl) GenerateTripCity(ctx context.Context, req trips.CityRequest) (*models.TripCity, error) {
+	stop := req.Stops[req.Position]
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "GenerateTripCity", trace.WithAttributes(
+		attribute.String("session.id", req.SessionID.String()),
+		attribute.String("city.name", stop.City),
+		attribute.Int("trip.position", req.Position),
+	))
+	defer span.End()
+
+	if l.budgetLevel(ctx, req.UserID) == costs.LevelHard {
+		span.SetAttributes(attribute.Bool("budget.exceeded", true))
+		return nil, fmt.Errorf("the AI usage limit has been reached, try again later")
+	}
+
+	ctx = l.withUserLanguage(ctx, req.UserID)
+	interests, searchProfile, tags, err := l.FetchUserData(ctx, req.UserID, req.ProfileID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	interestNames, tagsPromptPart, userPrefs := l.PreparePromptData(interests, tags, searchProfile, languageFrom(ctx))
+
+	params := prompts.TripCityParams{
+		CityName:     stop.City,
+		Nights:       stop.Nights,
+		Position:     req.Position + 1,
+		Preferences:  fmt.Sprintf("    - Interests: %s%s%s", strings.Join(interestNames, ", "), tagsPromptPart, userPrefs),
+		Instructions: req.Instructions,
+	}
+	for i, s := range req.Stops {
+		params.Stops = append(params.Stops, prompts.TripStop{Position: i + 1, City: s.City, Country: s.Country, Nights: s.Nights})
+	}
+	if req.Position > 0 {
+		params.Previous = req.Stops[req.Position-1].City
+	}
+	if req.Position < len(req.Stops)-1 {
+		params.Next = req.Stops[req.Position+1].City
+	}
+	prompt := localizePrompt(ctx, prompts.TripCity.MustRender(req.SessionID.String(), params))
+
+	startTime := time.Now()
+	resp, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](defaultTemperature),
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to generate trip city")
+		return nil, fmt.Errorf("failed to generate itinerary for %s: %w", stop.City, err)
+	}
+	validation := l.validateAndRepairPart(ctx, "itinerary", llmprovider.TextFromResponse(resp))
+
+	interaction := models.LlmInteraction{
+		SessionID:     req.SessionID,
+		UserID:        req.UserID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  validation.Text,
+		ModelUsed:     l.llmProvider.Model(),
+		Provider:      l.llmProvider.Name(),
+		LatencyMs:     int(time.Since(startTime).Milliseconds()),
+		CityName:      stop.City,
+		Intent:        "trip_city",
+		SearchType:    string(models.SearchTypeItinerary),
+	}
+	if resp.UsageMetadata != nil {
+		interaction.PromptTokens = int(resp.UsageMetadata.PromptTokenCount)
+		interaction.CompletionTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+		interaction.TotalTokens = int(resp.UsageMetadata.TotalTokenCount)
+	}
+	if err := validation.record(&interaction); err != nil {
+		l.logger.Warn("Failed to record trip city validation", zap.String("city", stop.City), zap.Error(err))
+	}
+
+	itinerary, err := parseItineraryFromResponse(validation.Text, l.logger)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to parse trip city")
+		// Saved all the same so the failed answer and its schema errors can be looked at
+		interaction.ErrorMessage = err.Error()
+		if _, saveErr := l.llmInteractionRepo.SaveInteraction(ctx, interaction); saveErr != nil {
+			l.logger.Warn("Failed to save trip city interaction", zap.String("city", stop.City), zap.Error(saveErr))
+		}
+		return nil, fmt.Errorf("failed to parse itinerary for %s: %w", stop.City, err)
+	}
+
+	city := &models.TripCity{
+		City:      stop.City,
+		Country:   stop.Country,
+		Itinerary: itinerary,
+		UpdatedAt: time.Now(),
+	}
+	interactionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
+	if err != nil {
+		l.logger.Warn("Failed to save trip city interaction", zap.String("city", stop.City), zap.Error(err))
+	} else {
+		city.LlmInteractionID = &interactionID
+	}
+
+	// Stored cities give the centre used for the transfer legs and let the POIs be checked
+	stored, err := l.cityRepo.FindCityByNameAndCountry(ctx, stop.City, stop.Country)
+	if err != nil {
+		l.logger.Warn("Failed to look up trip city", zap.String("city", stop.City), zap.Error(err))
+	}
+	if stored != nil {
+		city.CityID = &stored.ID
+		city.Country = stored.Country
+		city.Latitude, city.Longitude = stored.CenterLatitude, stored.CenterLongitude
+		itinerary.PointsOfInterest = l.checkPOIs(ctx, stored.ID, itinerary.PointsOfInterest, "trip_city", interactionID)
+	}
+	if city.Latitude == 0 && city.Longitude == 0 {
+		city.Latitude, city.Longitude = poiCentroid(itinerary.PointsOfInterest)
+	}
+	l.RouteItinerary(ctx, req.UserID, itinerary)
+	l.EstimateCost(ctx, req.UserID, itinerary, stop.Nights)
+
+	span.SetAttributes(attribute.Int("pois.count", len(itinerary.PointsOfInterest)))
+	span.SetStatus(codes.Ok, "Trip city generated")
+	return city, nil
+}
+
+// describeTrip is the first message of a trip session, e.g. "Plan a trip: Lisbon (3 nights),
+// Porto (2 nights)".
+func describeTrip(stops []models.TripStop) string {
+	parts := make([]string, len(stops))
+	for i, s := range stops {
+		nights := "nights"
+		if s.Nights == 1 {
+			nights = "night"
+		}
+		parts[i] = fmt.Sprintf("%s (%d %s)", s.City, s.Nights, nights)
+	}
+	return "Plan a trip: " + strings.Join(parts, ", ")
+}
+
+// poiCentroid approximates the centre of a city not stored yet from its generated POIs.
+func poiCentroid(pois []models.POIDetailedInfo) (lat, lon float64) {
+	n := 0
+	for _, p := range pois {
+		if p.Latitude == 0 && p.Longitude == 0 {
+			continue
+		}
+		lat += p.Latitude
+		lon += p.Longitude
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return lat / float64(n), lon / float64(n)
+}
@@ -0,0 +1,221 @@
+package llmchat
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/jsonschema"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmlogging"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+// defaultSchemaRepairAttempts is used unless LLM_SCHEMA_REPAIR_ATTEMPTS says otherwise
+const defaultSchemaRepairAttempts = 2
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// partSchemas maps a streamed part type (city_data, itinerary, restaurants, ...) to the
+// JSON schema its response must satisfy. File names under schemas/ are the part types.
+var partSchemas = loadPartSchemas()
+
+func loadPartSchemas() map[string]*jsonschema.Schema {
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		panic(fmt.Sprintf("failed to read embedded schemas: %v", err))
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(entries))
+	for _, entry := range entries {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read schema %s: %v", entry.Name(), err))
+		}
+		schemas[strings.TrimSuffix(entry.Name(), ".json")] = jsonschema.MustCompile(data)
+	}
+	return schemas
+}
+
+// validatePartResponse checks a part's response against its schema after stripping
+// markdown fences. It returns nil when the response is valid or the part has no schema.
+func validatePartResponse(partType, responseText string) []jsonschema.ValidationError {
+	schema, ok := partSchemas[partType]
+	if !ok {
+		return nil
+	}
+	return schema.ValidateJSON([]byte(cleanJSONResponse(responseText)))
+}
+
+// partValidation is the outcome of validating a streamed part and repairing it if needed.
+type partValidation struct {
+	llmlogging.SchemaValidation
+	Text             string // The repaired response when Repaired, the original one otherwise
+	Repaired         bool
+	RepairPrompt     prompts.Rendered // The last repair re-prompt, empty without repairs
+	PromptTokens     int              // Tokens spent on repair re-prompts
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// validateAndRepairPart validates a part's response and, while it is invalid, re-prompts
+// the model with the schema and the validation errors, up to schemaRepairAttempts times.
+// It returns nil for parts without a schema. When every repair fails the original text is
+// kept so the lenient parsers can still salvage what they can.
+func (l *ServiceImpl) validateAndRepairPart(ctx context.Context, partType, responseText string) *partValidation {
+	schema, ok := partSchemas[partType]
+	if !ok {
+		return nil
+	}
+
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "validateAndRepairPart", trace.WithAttributes(
+		attribute.String("schema.name", partType),
+		attribute.Int("schema.max_repair_attempts", l.schemaRepairAttempts),
+	))
+	defer span.End()
+
+	result := &partValidation{
+		SchemaValidation: llmlogging.SchemaValidation{SchemaName: partType},
+		Text:             responseText,
+	}
+	current := responseText
+
+	for attempt := 0; ; attempt++ {
+		errs := schema.ValidateJSON([]byte(cleanJSONResponse(current)))
+		if len(errs) == 0 {
+			result.Valid = true
+			result.Repaired = attempt > 0
+			result.Text = current
+			break
+		}
+
+		messages := make([]string, len(errs))
+		for i, e := range errs {
+			messages[i] = e.Error()
+		}
+		result.Failures = append(result.Failures, llmlogging.ValidationFailure{Attempt: attempt, Errors: messages})
+		l.logger.Warn("LLM response failed schema validation",
+			zap.String("schema", partType),
+			zap.Int("attempt", attempt),
+			zap.Strings("errors", messages))
+
+		if attempt >= l.schemaRepairAttempts || ctx.Err() != nil {
+			break
+		}
+
+		result.RepairAttempts++
+		repairPrompt := getSchemaRepairPrompt(partType, schema.Source(), cleanJSONResponse(current), messages)
+		result.RepairPrompt = repairPrompt
+		resp, err := l.llmProvider.GenerateResponse(ctx, repairPrompt.Text, &genai.GenerateContentConfig{
+			Temperature:      genai.Ptr[float32](0),
+			ResponseMIMEType: "application/json",
+		})
+		if err != nil {
+			span.RecordError(err)
+			result.Failures = append(result.Failures, llmlogging.ValidationFailure{
+				Attempt: attempt + 1,
+				Errors:  []string{fmt.Sprintf("repair request failed: %v", err)},
+			})
+			break
+		}
+		if resp.UsageMetadata != nil {
+			result.PromptTokens += int(resp.UsageMetadata.PromptTokenCount)
+			result.CompletionTokens += int(resp.UsageMetadata.CandidatesTokenCount)
+			result.TotalTokens += int(resp.UsageMetadata.TotalTokenCount)
+		}
+		current = llmprovider.TextFromResponse(resp)
+	}
+
+	span.SetAttributes(
+		attribute.Bool("schema.valid", result.Valid),
+		attribute.Int("schema.repair_attempts", result.RepairAttempts),
+	)
+	if result.Repaired {
+		l.logger.Info("LLM response repaired after schema validation failure",
+			zap.String("schema", partType),
+			zap.Int("repair_attempts", result.RepairAttempts))
+	}
+	return result
+}
+
+// record sets the outcome on the interaction of the response, with the tokens the repairs
+// spent on top of those of the response itself.
+func (v *partValidation) record(interaction *models.LlmInteraction) error {
+	interaction.PromptTokens += v.PromptTokens
+	interaction.CompletionTokens += v.CompletionTokens
+	interaction.TotalTokens += v.TotalTokens
+	return setSchemaValidation(interaction, &v.SchemaValidation)
+}
+
+// validatedParts holds the responses of a request's parts once validated, repaired ones in
+// place of the invalid responses they fix.
+type validatedParts struct {
+	texts   map[string]string
+	invalid []string // "<part>: <errors>" of the parts that stayed invalid, sorted
+}
+
+// noteInvalid keeps the last errors of a part that stayed invalid.
+func (p *validatedParts) noteInvalid(partType string, validation *partValidation) {
+	if validation.Valid || len(validation.Failures) == 0 {
+		return
+	}
+	errs := validation.Failures[len(validation.Failures)-1].Errors
+	p.invalid = append(p.invalid, fmt.Sprintf("%s: %s", partType, strings.Join(errs, "; ")))
+}
+
+// validateParts runs the parts of a request through validateAndRepairPart before they are
+// parsed. Parts in validated already went through it in generatePart and are only checked
+// for their outcome, so a part that stayed invalid is not repaired twice. The others, like
+// answers served past the budget, are repaired here, and the repairs are logged as
+// schema_repair interactions of the session.
+func (l *ServiceImpl) validateParts(ctx context.Context, config LoggingConfig, texts map[string]string, validated map[string]*partValidation) validatedParts {
+	parts := validatedParts{texts: make(map[string]string, len(texts))}
+	for partType, text := range texts {
+		if text == "" {
+			continue
+		}
+		if validation, ok := validated[partType]; ok {
+			parts.texts[partType] = text
+			parts.noteInvalid(partType, validation)
+			continue
+		}
+		startTime := time.Now()
+		validation := l.validateAndRepairPart(ctx, partType, text)
+		if validation == nil {
+			parts.texts[partType] = text
+			continue
+		}
+		parts.texts[partType] = validation.Text
+		parts.noteInvalid(partType, validation)
+		if validation.RepairAttempts == 0 {
+			continue
+		}
+
+		repairConfig := config
+		repairConfig.Intent = "schema_repair"
+		repairConfig.Prompt = validation.RepairPrompt.Text
+		repairConfig.PromptID = validation.RepairPrompt.ID
+		repairConfig.PromptVersion = validation.RepairPrompt.Version
+		l.llmLogger.LogInteractionAsync(ctx, repairConfig, LLMResponse{
+			ResponseText:     validation.Text,
+			PromptTokens:     validation.PromptTokens,
+			CompletionTokens: validation.CompletionTokens,
+			TotalTokens:      validation.TotalTokens,
+			StatusCode:       200,
+			Validation:       &validation.SchemaValidation,
+		}, time.Since(startTime).Milliseconds())
+	}
+	sort.Strings(parts.invalid)
+	return parts
+}
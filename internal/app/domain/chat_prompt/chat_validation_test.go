@@ -0,0 +1,265 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmlogging"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+const validRestaurantsJSON = `{"restaurants":[{"name":"Cervejaria Ramiro","latitude":38.7206,"longitude":-9.1357,"category":"Casual Dining","price_level":"$$","rating":4.6,"tags":["seafood"]}]}`
+
+func TestPartSchemasCoverStreamedParts(t *testing.T) {
+	for _, part := range []string{"city_data", "general_pois", "itinerary", "restaurants", "hotels", "activities"} {
+		assert.Contains(t, partSchemas, part, "streamed part %s has no schema", part)
+	}
+}
+
+func TestValidatePartResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		part     string
+		response string
+		wantErrs []string
+	}{
+		{
+			name:     "valid restaurants inside markdown fence",
+			part:     "restaurants",
+			response: "```json\n" + validRestaurantsJSON + "\n```",
+		},
+		{
+			name:     "itinerary with out of range coordinate",
+			part:     "itinerary",
+			response: `{"itinerary_name":"Lisbon","overall_description":"","points_of_interest":[{"name":"Belem Tower","latitude":138.69,"longitude":-9.21}]}`,
+			wantErrs: []string{"/points_of_interest/0/latitude: 138.69 is greater than the maximum 90"},
+		},
+		{
+			name:     "hotels with string rating and missing name",
+			part:     "hotels",
+			response: `{"hotels":[{"latitude":38.7,"longitude":-9.1,"category":"Hotel","rating":"4.5"}]}`,
+			wantErrs: []string{`/hotels/0: missing required property "name"`, "/hotels/0/rating: expected number or null, got string"},
+		},
+		{
+			name:     "part without schema is not validated",
+			part:     "free_text",
+			response: "not json at all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range validatePartResponse(tt.part, tt.response) {
+				got = append(got, e.Error())
+			}
+			assert.Equal(t, tt.wantErrs, got)
+		})
+	}
+}
+
+func TestValidateAndRepairPart(t *testing.T) {
+	invalid := `{"restaurants":[{"name":"Cervejaria Ramiro","latitude":"38.7206","longitude":-9.1357,"category":"Casual Dining"}]}`
+
+	t.Run("valid response needs no repair", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider()
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: fake, schemaRepairAttempts: 2}
+
+		result := svc.validateAndRepairPart(context.Background(), "restaurants", validRestaurantsJSON)
+		require.NotNil(t, result)
+		assert.True(t, result.Valid)
+		assert.False(t, result.Repaired)
+		assert.Zero(t, result.RepairAttempts)
+		assert.Empty(t, fake.Prompts())
+	})
+
+	t.Run("repaired on second attempt", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider().WithDefaultResponse(validRestaurantsJSON)
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: fake, schemaRepairAttempts: 2}
+
+		result := svc.validateAndRepairPart(context.Background(), "restaurants", invalid)
+		require.NotNil(t, result)
+		assert.True(t, result.Valid)
+		assert.True(t, result.Repaired)
+		assert.Equal(t, 1, result.RepairAttempts)
+		assert.Equal(t, validRestaurantsJSON, result.Text)
+		assert.Positive(t, result.TotalTokens)
+
+		require.Len(t, result.Failures, 1)
+		assert.Equal(t, 0, result.Failures[0].Attempt)
+		assert.Equal(t, []string{"/restaurants/0/latitude: expected number, got string"}, result.Failures[0].Errors)
+
+		require.Len(t, fake.Prompts(), 1)
+		assert.True(t, strings.Contains(fake.Prompts()[0], "expected number, got string"), "repair prompt must carry the validation errors")
+	})
+
+	t.Run("gives up after configured attempts", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider().WithDefaultResponse(`{"restaurants":[]}`)
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: fake, schemaRepairAttempts: 2}
+
+		result := svc.validateAndRepairPart(context.Background(), "restaurants", invalid)
+		require.NotNil(t, result)
+		assert.False(t, result.Valid)
+		assert.False(t, result.Repaired)
+		assert.Equal(t, 2, result.RepairAttempts)
+		assert.Len(t, result.Failures, 3)
+		assert.Equal(t, invalid, result.Text, "the original response is kept when repairs fail")
+	})
+
+	t.Run("repairs disabled", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider()
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: fake}
+
+		result := svc.validateAndRepairPart(context.Background(), "restaurants", invalid)
+		require.NotNil(t, result)
+		assert.False(t, result.Valid)
+		assert.Len(t, result.Failures, 1)
+		assert.Empty(t, fake.Prompts())
+	})
+
+	t.Run("part without schema", func(t *testing.T) {
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: llmprovider.NewFakeProvider()}
+		assert.Nil(t, svc.validateAndRepairPart(context.Background(), "free_text", "hello"))
+	})
+}
+
+// interactionRecorder keeps the interactions the LLM logger saves.
+type interactionRecorder struct {
+	mu           sync.Mutex
+	interactions []models.LlmInteraction
+}
+
+func (r *interactionRecorder) SaveInteraction(_ context.Context, interaction models.LlmInteraction) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+	return uuid.New(), nil
+}
+
+func (r *interactionRecorder) saved() []models.LlmInteraction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.LlmInteraction(nil), r.interactions...)
+}
+
+func TestValidateParts(t *testing.T) {
+	invalid := `{"restaurants":[{"name":"Cervejaria Ramiro","latitude":"38.7206","longitude":-9.1357,"category":"Casual Dining"}]}`
+	cityData := `{"city":"Lisbon","country":"Portugal","description":"Capital of Portugal","center_latitude":38.72,"center_longitude":-9.14}`
+	sessionID := uuid.New()
+
+	t.Run("repairs invalid parts and logs the repair", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider().WithDefaultResponse(validRestaurantsJSON)
+		recorder := &interactionRecorder{}
+		svc := &ServiceImpl{
+			logger:               zap.NewNop(),
+			llmProvider:          fake,
+			llmLogger:            NewLLMLogger(zap.NewNop(), recorder),
+			schemaRepairAttempts: 2,
+		}
+
+		parts := svc.validateParts(context.Background(), LoggingConfig{SessionID: sessionID, ModelName: fake.Model()},
+			map[string]string{"restaurants": invalid, "city_data": cityData, "hotels": ""}, nil)
+
+		assert.Equal(t, map[string]string{"restaurants": validRestaurantsJSON, "city_data": cityData}, parts.texts)
+		assert.Empty(t, parts.invalid)
+		require.Len(t, fake.Prompts(), 1, "only the invalid part is re-prompted")
+
+		require.Eventually(t, func() bool { return len(recorder.saved()) == 1 }, time.Second, 10*time.Millisecond)
+		repair := recorder.saved()[0]
+		assert.Equal(t, "schema_repair", repair.Intent)
+		assert.Equal(t, sessionID, repair.SessionID)
+		assert.Equal(t, "schema_repair", repair.PromptID)
+		assert.Equal(t, fake.Prompts()[0], repair.Prompt)
+		assert.Equal(t, validRestaurantsJSON, repair.ResponseText)
+		assert.Equal(t, "restaurants", repair.SchemaName)
+		require.NotNil(t, repair.SchemaValid)
+		assert.True(t, *repair.SchemaValid)
+		assert.Equal(t, 1, repair.RepairAttempts)
+		assert.Positive(t, repair.TotalTokens)
+	})
+
+	t.Run("reports parts that stay invalid", func(t *testing.T) {
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: llmprovider.NewFakeProvider()}
+
+		parts := svc.validateParts(context.Background(), LoggingConfig{SessionID: sessionID},
+			map[string]string{"restaurants": invalid, "city_data": cityData}, nil)
+
+		assert.Equal(t, invalid, parts.texts["restaurants"], "the original response is kept")
+		assert.Equal(t, []string{"restaurants: /restaurants/0/latitude: expected number, got string"}, parts.invalid)
+	})
+
+	t.Run("does not repair streamed parts again", func(t *testing.T) {
+		fake := llmprovider.NewFakeProvider().WithDefaultResponse(validRestaurantsJSON)
+		svc := &ServiceImpl{logger: zap.NewNop(), llmProvider: fake, schemaRepairAttempts: 2}
+		// generatePart already spent its repairs on the part and it stayed invalid
+		streamed := &partValidation{
+			SchemaValidation: llmlogging.SchemaValidation{
+				SchemaName:     "restaurants",
+				RepairAttempts: 2,
+				Failures:       []llmlogging.ValidationFailure{{Attempt: 2, Errors: []string{"still invalid"}}},
+			},
+			Text: invalid,
+		}
+
+		parts := svc.validateParts(context.Background(), LoggingConfig{SessionID: sessionID},
+			map[string]string{"restaurants": invalid, "city_data": cityData},
+			map[string]*partValidation{"restaurants": streamed})
+
+		assert.Equal(t, invalid, parts.texts["restaurants"])
+		assert.Equal(t, []string{"restaurants: still invalid"}, parts.invalid)
+		assert.Empty(t, fake.Prompts(), "no second round of repairs")
+	})
+}
+
+func TestParseCompleteResponseFromParts(t *testing.T) {
+	svc := &ServiceImpl{logger: zap.NewNop()}
+	sessionID := uuid.New()
+
+	response, err := svc.parseCompleteResponseFromParts(validatedParts{texts: map[string]string{
+		"city_data":   `{"city":"Lisbon","country":"Portugal"}`,
+		"restaurants": validRestaurantsJSON,
+	}}, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, sessionID, response.SessionID)
+	assert.Equal(t, "Lisbon", response.GeneralCityData.City)
+	require.Len(t, response.PointsOfInterest, 1)
+	assert.Equal(t, "Cervejaria Ramiro", response.PointsOfInterest[0].Name)
+}
+
+func TestPartValidationRecord(t *testing.T) {
+	interaction := models.LlmInteraction{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150}
+	validation := &partValidation{
+		SchemaValidation: llmlogging.SchemaValidation{
+			SchemaName:     "itinerary",
+			Valid:          true,
+			RepairAttempts: 1,
+			Failures:       []llmlogging.ValidationFailure{{Attempt: 0, Errors: []string{"/points_of_interest/0/latitude: expected number, got string"}}},
+		},
+		PromptTokens:     20,
+		CompletionTokens: 10,
+		TotalTokens:      30,
+	}
+
+	require.NoError(t, validation.record(&interaction))
+	assert.Equal(t, 120, interaction.PromptTokens)
+	assert.Equal(t, 60, interaction.CompletionTokens)
+	assert.Equal(t, 180, interaction.TotalTokens)
+	assert.Equal(t, "itinerary", interaction.SchemaName)
+	require.NotNil(t, interaction.SchemaValid)
+	assert.True(t, *interaction.SchemaValid)
+	assert.Equal(t, 1, interaction.RepairAttempts)
+
+	var failures []llmlogging.ValidationFailure
+	require.NoError(t, json.Unmarshal(interaction.ValidationErrors, &failures))
+	assert.Equal(t, validation.Failures, failures)
+}
@@ -0,0 +1,194 @@
+package llmchat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/domain/itineraryversion"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	cache2 "github.com/FACorreiaa/go-templui/internal/pkg/cache"
+)
+
+// ItineraryVersions stores the itinerary of a session after every change.
+type ItineraryVersions interface {
+	Record(ctx context.Context, sessionID uuid.UUID, previous, current *models.AiCityResponse, changeType, description string) (int, error)
+	RecordBased(ctx context.Context, sessionID uuid.UUID, snapshot *models.AiCityResponse, changeType, description string, basedOn int) (int, error)
+	Branch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int, snapshot *models.AiCityResponse) (int, error)
+	Get(ctx context.Context, userID, sessionID uuid.UUID, number int) (*itineraryversion.Version, error)
+}
+
+// WithItineraryVersions keeps a snapshot of the itinerary after every chat modification, so
+// sessions can be reverted to or branched from any earlier version.
+func (l *ServiceImpl) WithItineraryVersions(v ItineraryVersions) *ServiceImpl {
+	l.versions = v
+	return l
+}
+
+// snapshotItinerary copies the itinerary of a session before a turn changes it in place.
+func (l *ServiceImpl) snapshotItinerary(session *models.ChatSession) *models.AiCityResponse {
+	if l.versions == nil || session.CurrentItinerary == nil {
+		return nil
+	}
+	data, err := json.Marshal(session.CurrentItinerary)
+	if err != nil {
+		l.logger.Warn("Failed to snapshot itinerary", zap.String("session_id", session.ID.String()), zap.Error(err))
+		return nil
+	}
+	var snapshot models.AiCityResponse
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		l.logger.Warn("Failed to snapshot itinerary", zap.String("session_id", session.ID.String()), zap.Error(err))
+		return nil
+	}
+	return &snapshot
+}
+
+// recordItineraryVersion stores the itinerary of the session as a new version when the turn
+// changed it, and notes the change in the session's modification history.
+func (l *ServiceImpl) recordItineraryVersion(ctx context.Context, session *models.ChatSession, previous *models.AiCityResponse, changeType, description string) {
+	if l.versions == nil || session.CurrentItinerary == nil {
+		return
+	}
+	if previous != nil && itineraryversion.Compare(previous, session.CurrentItinerary).Empty() {
+		return
+	}
+	number, err := l.versions.Record(ctx, session.ID, previous, session.CurrentItinerary, changeType, description)
+	if err != nil {
+		l.logger.Warn("Failed to record itinerary version", zap.String("session_id", session.ID.String()), zap.Error(err))
+		return
+	}
+	l.noteModification(session, changeType, description, number)
+}
+
+func (l *ServiceImpl) noteModification(session *models.ChatSession, changeType, description string, version int) {
+	session.SessionContext.ModificationHistory = append(session.SessionContext.ModificationHistory, models.ModificationRecord{
+		Type:        changeType,
+		Description: description,
+		Timestamp:   time.Now(),
+		Applied:     true,
+		Version:     version,
+	})
+}
+
+// RevertItinerary makes a stored version the current itinerary of the session. The revert is
+// itself recorded as a version, so it can be undone by reverting to the version before it.
+func (l *ServiceImpl) RevertItinerary(ctx context.Context, userID, sessionID uuid.UUID, number int) (*models.AiCityResponse, int, error) {
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "RevertItinerary", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+		attribute.Int("version", number),
+	))
+	defer span.End()
+
+	if l.versions == nil {
+		return nil, 0, fmt.Errorf("itinerary versions are not enabled: %w", models.ErrNotFound)
+	}
+	// Looking the version up first also checks the session belongs to the user
+	version, err := l.versions.Get(ctx, userID, sessionID, number)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, err
+	}
+	if version.Snapshot == nil {
+		return nil, 0, fmt.Errorf("itinerary version %d has no itinerary: %w", number, models.ErrNotFound)
+	}
+	session, err := l.llmInteractionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to get session %s: %w", sessionID, err)
+	}
+
+	itinerary := version.Snapshot
+	itinerary.SessionID = session.ID
+	description := fmt.Sprintf("Reverted to version %d", number)
+	reverted, err := l.versions.RecordBased(ctx, session.ID, itinerary, itineraryversion.ChangeRevert, description, number)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, err
+	}
+
+	session.CurrentItinerary = itinerary
+	l.noteModification(session, itineraryversion.ChangeRevert, description, reverted)
+	session.UpdatedAt = time.Now()
+	if err := l.llmInteractionRepo.UpdateSession(ctx, *session); err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to update session %s: %w", sessionID, err)
+	}
+	l.refreshItineraryCaches(ctx, session)
+
+	span.SetAttributes(attribute.Int("reverted.version", reverted))
+	span.SetStatus(codes.Ok, "Itinerary reverted")
+	return session.CurrentItinerary, reverted, nil
+}
+
+// BranchSession starts a new session with the itinerary of a stored version and the
+// conversation of the original session, leaving the original as it is.
+func (l *ServiceImpl) BranchSession(ctx context.Context, userID, sessionID uuid.UUID, number int) (uuid.UUID, error) {
+	ctx, span := otel.Tracer("LlmInteractionService").Start(ctx, "BranchSession", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+		attribute.Int("version", number),
+	))
+	defer span.End()
+
+	if l.versions == nil {
+		return uuid.Nil, fmt.Errorf("itinerary versions are not enabled: %w", models.ErrNotFound)
+	}
+	version, err := l.versions.Get(ctx, userID, sessionID, number)
+	if err != nil {
+		span.RecordError(err)
+		return uuid.Nil, err
+	}
+	if version.Snapshot == nil {
+		return uuid.Nil, fmt.Errorf("itinerary version %d has no itinerary: %w", number, models.ErrNotFound)
+	}
+	source, err := l.llmInteractionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		return uuid.Nil, fmt.Errorf("failed to get session %s: %w", sessionID, err)
+	}
+
+	now := time.Now()
+	branch := *source
+	branch.ID = uuid.New()
+	branch.CurrentItinerary = version.Snapshot
+	branch.CurrentItinerary.SessionID = branch.ID
+	branch.ConversationHistory = append([]models.ConversationMessage(nil), source.ConversationHistory...)
+	branch.SessionContext.ModificationHistory = nil
+	branch.CreatedAt = now
+	branch.UpdatedAt = now
+	branch.ExpiresAt = now.Add(24 * time.Hour)
+	branch.Status = models.StatusActive
+	if err := l.llmInteractionRepo.CreateSession(ctx, branch); err != nil {
+		span.RecordError(err)
+		return uuid.Nil, fmt.Errorf("failed to create branched session: %w", err)
+	}
+
+	first, err := l.versions.Branch(ctx, branch.ID, sessionID, number, branch.CurrentItinerary)
+	if err != nil {
+		// The session exists and is usable, it only misses its history
+		l.logger.Warn("Failed to record branched session history", zap.String("session_id", branch.ID.String()), zap.Error(err))
+	} else {
+		l.noteModification(&branch, itineraryversion.ChangeBranch, fmt.Sprintf("Branched from version %d", number), first)
+		if err := l.llmInteractionRepo.UpdateSession(ctx, branch); err != nil {
+			l.logger.Warn("Failed to save branched session history", zap.String("session_id", branch.ID.String()), zap.Error(err))
+		}
+	}
+	l.refreshItineraryCaches(ctx, &branch)
+
+	span.SetAttributes(attribute.String("branch.id", branch.ID.String()))
+	span.SetStatus(codes.Ok, "Session branched")
+	return branch.ID, nil
+}
+
+// refreshItineraryCaches replaces what the itinerary pages serve for the session.
+func (l *ServiceImpl) refreshItineraryCaches(ctx context.Context, session *models.ChatSession) {
+	l.updateCacheAfterModification(ctx, session)
+	cache2.ItineraryCache.Set(session.ID.String(), session.CurrentItinerary.AIItineraryResponse)
+}
@@ -29,10 +29,10 @@ func (l *ServiceImpl) GenerateCityDataWorker(wg *sync.WaitGroup,
 		defer span.End()
 		defer wg.Done()
 
-		prompt := getCityDescriptionPrompt(cityName)
-		span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+		prompt := getCityDescriptionPrompt("", cityName)
+		span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-		response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+		response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 		if err != nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, "Failed to generate city data")
@@ -109,11 +109,11 @@ func (l *ServiceImpl) GenerateGeneralPOIWorker(wg *sync.WaitGroup,
 	defer span.End()
 	defer wg.Done()
 
-	prompt := getGeneralPOIPrompt(cityName)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getGeneralPOIPrompt("", cityName)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
 	startTime := time.Now()
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
@@ -171,10 +171,10 @@ func (l *ServiceImpl) GeneratePersonalisedPOIWorker(wg *sync.WaitGroup, ctx cont
 
 	startTime := time.Now()
 
-	prompt := getPersonalizedPOI(interestNames, cityName, tagsPromptPart, userPrefs)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getPersonalizedPOI(sessionID.String(), interestNames, cityName, tagsPromptPart, userPrefs)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate personalized itinerary")
@@ -220,13 +220,15 @@ func (l *ServiceImpl) GeneratePersonalisedPOIWorker(wg *sync.WaitGroup, ctx cont
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
 	interaction := models.LlmInteraction{
-		UserID:       userID,
-		SessionID:    sessionID,
-		Prompt:       prompt,
-		ResponseText: txt,
-		ModelUsed:    model, // Adjust based on your AI client
-		LatencyMs:    latencyMs,
-		CityName:     cityName,
+		UserID:        userID,
+		SessionID:     sessionID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  txt,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     latencyMs,
+		CityName:      cityName,
 		// request payload
 		// response payload
 		// Add token counts if available from response (depends on genai API)
@@ -269,10 +271,10 @@ func (l *ServiceImpl) GeneratePersonalisedPOIWorkerWithSemantics(wg *sync.WaitGr
 	startTime := time.Now()
 
 	// Create enhanced prompt with semantic context
-	prompt := l.getPersonalizedPOIWithSemanticContext(interestNames, cityName, tagsPromptPart, userPrefs, semanticPOIs)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := l.getPersonalizedPOIWithSemanticContext(sessionID.String(), interestNames, cityName, tagsPromptPart, userPrefs, semanticPOIs)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate semantic-enhanced personalized itinerary")
@@ -318,13 +320,15 @@ func (l *ServiceImpl) GeneratePersonalisedPOIWorkerWithSemantics(wg *sync.WaitGr
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
 	interaction := models.LlmInteraction{
-		UserID:       userID,
-		SessionID:    sessionID,
-		Prompt:       prompt,
-		ResponseText: txt,
-		ModelUsed:    model,
-		LatencyMs:    latencyMs,
-		CityName:     cityName,
+		UserID:        userID,
+		SessionID:     sessionID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  txt,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     latencyMs,
+		CityName:      cityName,
 	}
 	savedInteractionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
 	if err != nil {
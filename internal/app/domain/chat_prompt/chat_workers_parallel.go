@@ -113,10 +113,10 @@ func (l *ServiceImpl) processCityData(ctx context.Context, cityName string,
 	))
 	defer span.End()
 
-	prompt := getCityDescriptionPrompt(cityName)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getCityDescriptionPrompt("", cityName)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate city data")
@@ -255,11 +255,11 @@ func (l *ServiceImpl) processGeneralPOI(ctx context.Context, cityName string,
 	))
 	defer span.End()
 
-	prompt := getGeneralPOIPrompt(cityName)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getGeneralPOIPrompt("", cityName)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
 	startTime := time.Now()
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
@@ -413,10 +413,10 @@ func (l *ServiceImpl) processPersonalizedPOI(ctx context.Context, req Personaliz
 
 	startTime := time.Now()
 
-	prompt := getPersonalizedPOI(req.InterestNames, req.CityName, req.TagsPromptPart, req.UserPrefs)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getPersonalizedPOI(req.SessionID.String(), req.InterestNames, req.CityName, req.TagsPromptPart, req.UserPrefs)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate personalized itinerary")
@@ -462,13 +462,15 @@ func (l *ServiceImpl) processPersonalizedPOI(ctx context.Context, req Personaliz
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
 	interaction := models.LlmInteraction{
-		UserID:       req.UserID,
-		SessionID:    req.SessionID,
-		Prompt:       prompt,
-		ResponseText: txt,
-		ModelUsed:    model,
-		LatencyMs:    latencyMs,
-		CityName:     req.CityName,
+		UserID:        req.UserID,
+		SessionID:     req.SessionID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  txt,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     latencyMs,
+		CityName:      req.CityName,
 	}
 	savedInteractionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
 	if err != nil {
@@ -505,10 +507,10 @@ func (l *ServiceImpl) processPersonalizedPOIWithSemantics(ctx context.Context, r
 	startTime := time.Now()
 
 	// Create enhanced prompt with semantic context
-	prompt := l.getPersonalizedPOIWithSemanticContext(req.InterestNames, req.CityName, req.TagsPromptPart, req.UserPrefs, req.SemanticPOIs)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := l.getPersonalizedPOIWithSemanticContext(req.SessionID.String(), req.InterestNames, req.CityName, req.TagsPromptPart, req.UserPrefs, req.SemanticPOIs)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	response, err := l.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := l.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Failed to generate semantic-enhanced personalized itinerary")
@@ -554,13 +556,15 @@ func (l *ServiceImpl) processPersonalizedPOIWithSemantics(ctx context.Context, r
 	span.SetAttributes(attribute.Int("response.latency_ms", latencyMs))
 
 	interaction := models.LlmInteraction{
-		UserID:       req.UserID,
-		SessionID:    req.SessionID,
-		Prompt:       prompt,
-		ResponseText: txt,
-		ModelUsed:    model,
-		LatencyMs:    latencyMs,
-		CityName:     req.CityName,
+		UserID:        req.UserID,
+		SessionID:     req.SessionID,
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		ResponseText:  txt,
+		ModelUsed:     l.llmProvider.Model(),
+		LatencyMs:     latencyMs,
+		CityName:      req.CityName,
 	}
 	savedInteractionID, err := l.llmInteractionRepo.SaveInteraction(ctx, interaction)
 	if err != nil {
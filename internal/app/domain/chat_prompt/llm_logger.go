@@ -2,8 +2,7 @@ package llmchat
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -19,6 +18,7 @@ import (
 
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 	"github.com/FACorreiaa/go-templui/internal/pkg/llmlogging"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
 )
 
 // LLMLogger handles comprehensive logging of LLM interactions with async support
@@ -67,10 +67,10 @@ func CalculateCost(modelName string, promptTokens, completionTokens int) float64
 	return 0
 }
 
-// HashPrompt creates a SHA256 hash of the prompt for anonymized tracking
+// HashPrompt creates a SHA256 hash of the prompt for anonymized tracking.
+// It is also the key LLM cassettes are stored under, so both must stay in sync.
 func HashPrompt(prompt string) string {
-	hash := sha256.Sum256([]byte(prompt))
-	return hex.EncodeToString(hash[:])
+	return llmprovider.HashPrompt(prompt)
 }
 
 // DetermineDeviceType extracts device type from user agent
@@ -91,6 +91,23 @@ func DetermineDeviceType(userAgent string) string {
 	return "web"
 }
 
+// setSchemaValidation records how the response fared against its schema on the interaction.
+func setSchemaValidation(interaction *models.LlmInteraction, v *llmlogging.SchemaValidation) error {
+	valid := v.Valid
+	interaction.SchemaName = v.SchemaName
+	interaction.SchemaValid = &valid
+	interaction.RepairAttempts = v.RepairAttempts
+	if len(v.Failures) == 0 {
+		return nil
+	}
+	failures, err := json.Marshal(v.Failures)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema validation failures: %w", err)
+	}
+	interaction.ValidationErrors = failures
+	return nil
+}
+
 // LogInteractionAsync logs an LLM interaction asynchronously to avoid blocking the main request
 // This is the recommended method for production use
 func (l *LLMLogger) LogInteractionAsync(
@@ -175,6 +192,8 @@ func (l *LLMLogger) logInteractionWithID(
 		CityName:          config.CityName,
 		CityID:            config.CityID,
 		Prompt:            config.Prompt,
+		PromptID:          config.PromptID,
+		PromptVersion:     config.PromptVersion,
 		ResponseText:      response.ResponseText,
 		ModelUsed:         config.ModelName,
 		Provider:          provider,
@@ -213,6 +232,17 @@ func (l *LLMLogger) logInteractionWithID(
 		interaction.PromptHash = HashPrompt(config.Prompt)
 	}
 
+	if v := response.Validation; v != nil {
+		if err := setSchemaValidation(&interaction, v); err != nil {
+			l.logger.Warn("Failed to record schema validation", zap.Error(err))
+		}
+		span.SetAttributes(
+			attribute.String("schema.name", v.SchemaName),
+			attribute.Bool("schema.valid", v.Valid),
+			attribute.Int("schema.repair_attempts", v.RepairAttempts),
+		)
+	}
+
 	// Save to database
 	savedID, err := l.repo.SaveInteraction(ctx, interaction)
 	if err != nil {
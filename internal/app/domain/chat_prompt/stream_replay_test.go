@@ -0,0 +1,414 @@
+package llmchat_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/city"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/interests"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/poi"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/tags"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite golden stream transcripts from the current run")
+
+const (
+	// fixtureDir holds unit fixtures: scripted answers recorded through the fake provider,
+	// with its fixed-size chunks and no delays. They pin what the service makes of a
+	// stream, not how real models chunk one.
+	fixtureDir = "testdata/cassettes/fake"
+	// recordedDir holds answers recorded from a real provider, with the model's own chunk
+	// boundaries and the delays between chunks. Record them with:
+	//
+	//	LLM_CASSETTE_MODE=record GEMINI_API_KEY=... go test -run RecordedReplay ./internal/app/domain/chat_prompt
+	recordedDir = "testdata/cassettes/recorded"
+)
+
+// fixtureProvider serves the fake-recorded unit fixtures instantly, or with the recorded
+// timing when LLM_CASSETTE_REALTIME is set.
+func fixtureProvider(t *testing.T) llmprovider.Provider {
+	t.Helper()
+	player, err := llmprovider.NewCassetteProvider(llmprovider.CassetteReplay, fixtureDir, nil, zap.NewNop())
+	require.NoError(t, err)
+	if os.Getenv("LLM_CASSETTE_REALTIME") == "" {
+		player.WithTimeScale(0)
+	}
+	return player
+}
+
+// recordedProvider replays the real-provider cassettes with their recorded delays, or
+// records new ones from the provider set up by the LLM_* variables when
+// LLM_CASSETTE_MODE=record. The test fails when the cassettes were not recorded from a real
+// provider, since replaying the fake's chunking would test nothing new.
+func recordedProvider(t *testing.T) llmprovider.Provider {
+	t.Helper()
+	logger := zap.NewNop()
+	if os.Getenv("LLM_CASSETTE_MODE") == llmprovider.CassetteRecord {
+		provider, err := llmprovider.New(context.Background(), llmprovider.Config{
+			Provider:     os.Getenv("LLM_PROVIDER"),
+			Model:        os.Getenv("LLM_MODEL"),
+			BaseURL:      os.Getenv("LLM_BASE_URL"),
+			APIKey:       os.Getenv("LLM_API_KEY"),
+			CassetteMode: llmprovider.CassetteRecord,
+			CassetteDir:  recordedDir,
+		}, logger)
+		require.NoError(t, err)
+		require.NotEqual(t, llmprovider.ProviderFake, provider.Name(), "record the regression cassettes from a real provider")
+		return provider
+	}
+
+	files, _ := filepath.Glob(filepath.Join(recordedDir, "stream", "*.json"))
+	require.NotEmpty(t, files, "no stream cassettes recorded from a real provider in %s, record them with "+
+		"LLM_CASSETTE_MODE=record GEMINI_API_KEY=... go test -run RecordedReplay ./internal/app/domain/chat_prompt", recordedDir)
+	var delayed bool
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		var c llmprovider.Cassette
+		require.NoError(t, json.Unmarshal(data, &c), file)
+		require.NotEqual(t, llmprovider.ProviderFake, c.Provider, "%s was recorded from the fake provider, it belongs in %s", file, fixtureDir)
+		for _, chunk := range c.Chunks {
+			delayed = delayed || chunk.DelayMs > 0
+		}
+	}
+	require.True(t, delayed, "the cassettes in %s carry no delays between chunks", recordedDir)
+
+	player, err := llmprovider.NewCassetteProvider(llmprovider.CassetteReplay, recordedDir, nil, logger)
+	require.NoError(t, err)
+	return player
+}
+
+// chatStore keeps sessions in memory in place of the chat repository.
+type chatStore struct {
+	llmchat.Repository
+	mu       sync.Mutex
+	sessions map[uuid.UUID]models.ChatSession
+}
+
+func (s *chatStore) CreateSession(_ context.Context, session models.ChatSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *chatStore) GetSession(_ context.Context, sessionID uuid.UUID) (*models.ChatSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("session %s not found", sessionID)
+	}
+	return &session, nil
+}
+
+func (s *chatStore) UpdateSession(ctx context.Context, session models.ChatSession) error {
+	return s.CreateSession(ctx, session)
+}
+
+func (s *chatStore) AddMessageToSession(_ context.Context, sessionID uuid.UUID, message models.ConversationMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	session.ConversationHistory = append(session.ConversationHistory, message)
+	s.sessions[sessionID] = session
+	return nil
+}
+
+// SaveSinglePOI names POIs after themselves so their IDs are the same on every run.
+func (s *chatStore) SaveSinglePOI(_ context.Context, p models.POIDetailedInfo, _, _, _ uuid.UUID) (uuid.UUID, error) {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(p.Name)), nil
+}
+
+// GetPOIsBySessionSortedByDistance fails so itineraries keep the order they were built in.
+func (s *chatStore) GetPOIsBySessionSortedByDistance(context.Context, uuid.UUID, uuid.UUID, models.UserLocation) ([]models.POIDetailedInfo, error) {
+	return nil, errors.New("POIs are not stored in replay tests")
+}
+
+func (s *chatStore) SaveInteraction(context.Context, models.LlmInteraction) (uuid.UUID, error) {
+	return uuid.New(), nil
+}
+
+// replayProfile is the search profile every replayed request is made with.
+type replayProfile struct {
+	profiles.Repository
+}
+
+func (replayProfile) GetSearchProfile(_ context.Context, userID, profileID uuid.UUID) (*models.UserPreferenceProfileResponse, error) {
+	return &models.UserPreferenceProfileResponse{
+		ID:                 profileID,
+		UserID:             userID,
+		ProfileName:        "Replay",
+		IsDefault:          true,
+		SearchRadiusKm:     5,
+		PreferredTime:      models.DayPreferenceAny,
+		BudgetLevel:        2,
+		PreferredPace:      models.SearchPaceModerate,
+		PreferredTransport: models.TransportPreferenceWalk,
+	}, nil
+}
+
+type noInterests struct{ interests.Repository }
+
+func (noInterests) GetInterestsForProfile(context.Context, uuid.UUID) ([]*models.Interest, error) {
+	return nil, nil
+}
+
+type noTags struct{ tags.Repository }
+
+func (noTags) GetTagsForProfile(context.Context, uuid.UUID) ([]*models.Tags, error) {
+	return nil, nil
+}
+
+// replayCities knows Lisbon by fuzzy name only: continued sessions find their city while
+// new streams stop before saving what they generated.
+type replayCities struct{ city.Repository }
+
+func (replayCities) FindCityByNameAndCountry(context.Context, string, string) (*models.CityDetail, error) {
+	return nil, nil
+}
+
+func (replayCities) FindCityByFuzzyName(_ context.Context, name string) (*models.CityDetail, error) {
+	if name != "Lisbon" {
+		return nil, nil
+	}
+	return &models.CityDetail{
+		ID:              uuid.MustParse("5b7c1f0e-2d3a-4c8b-9e6f-1a2b3c4d5e6f"),
+		Name:            "Lisbon",
+		Country:         "Portugal",
+		CenterLatitude:  38.7223,
+		CenterLongitude: -9.1393,
+	}, nil
+}
+
+func (replayCities) SaveCity(context.Context, models.CityDetail) (uuid.UUID, error) {
+	return uuid.Nil, errors.New("cities are not stored in replay tests")
+}
+
+// replayPOIs finds the same stored POIs for every search.
+type replayPOIs struct{ poi.Repository }
+
+func (replayPOIs) SearchPOIsHybrid(context.Context, models.POIFilter, []float32, float64) ([]models.POIDetailedInfo, error) {
+	return []models.POIDetailedInfo{
+		{Name: "Time Out Market Lisboa", Latitude: 38.7071, Longitude: -9.1458, Category: "Food Hall", DescriptionPOI: "Food hall in the Mercado da Ribeira."},
+		{Name: "Praça do Comércio", Latitude: 38.7075, Longitude: -9.1364, Category: "Square", DescriptionPOI: "Riverside square of the Pombaline downtown."},
+	}, nil
+}
+
+func newReplayService(provider llmprovider.Provider) *llmchat.ServiceImpl {
+	store := &chatStore{sessions: make(map[uuid.UUID]models.ChatSession)}
+	return llmchat.NewLlmInteractiontService(noInterests{}, replayProfile{}, nil, noTags{}, store, replayCities{}, replayPOIs{}, provider, zap.NewNop())
+}
+
+// streamTranscript renders a stream as the text a client receives, minus what changes on
+// every run: event IDs, timestamps and the session ID. Parts stream concurrently, so the
+// events of each part are kept in the order they were sent and the parts follow each other
+// in name order, between the events that carry no part.
+func streamTranscript(t *testing.T, events []models.StreamEvent, sessionID uuid.UUID) string {
+	t.Helper()
+	var head, tail []string
+	parts := make(map[string][]string)
+	seenPart := false
+	for _, event := range events {
+		event.EventID = ""
+		event.Timestamp = time.Time{}
+		line, err := json.Marshal(event)
+		require.NoError(t, err)
+		text := strings.ReplaceAll(string(line), sessionID.String(), "<session>")
+
+		if part := eventPart(event); part != "" {
+			parts[part] = append(parts[part], text)
+			seenPart = true
+		} else if seenPart {
+			tail = append(tail, text)
+		} else {
+			head = append(head, text)
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range head {
+		b.WriteString(line + "\n")
+	}
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "# %s\n", name)
+		for _, line := range parts[name] {
+			b.WriteString(line + "\n")
+		}
+	}
+	for _, line := range tail {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+func eventPart(event models.StreamEvent) string {
+	if data, ok := event.Data.(map[string]interface{}); ok {
+		part, _ := data["part"].(string)
+		return part
+	}
+	return ""
+}
+
+// collectStream drains events until the channel closes or a final event arrives.
+func collectStream(t *testing.T, events <-chan models.StreamEvent) []models.StreamEvent {
+	t.Helper()
+	var all []models.StreamEvent
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return all
+			}
+			all = append(all, event)
+			if event.IsFinal {
+				return all
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the stream to finish")
+		}
+	}
+}
+
+func assertGoldenTranscript(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+		return
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run with -update-golden to write the transcript")
+	assert.Equal(t, string(want), got, "the replayed stream drifted from %s", path)
+}
+
+func startSessionID(t *testing.T, events []models.StreamEvent) uuid.UUID {
+	t.Helper()
+	require.NotEmpty(t, events)
+	start := events[0]
+	require.Equal(t, models.EventTypeStart, start.Type)
+	data, ok := start.Data.(map[string]interface{})
+	require.True(t, ok, "start event carries no data")
+	sessionID, err := uuid.Parse(data["session_id"].(string))
+	require.NoError(t, err)
+	return sessionID
+}
+
+var (
+	replayUserID    = uuid.MustParse("6f1c2a8e-3b4d-4e5f-9a6b-7c8d9e0f1a2b")
+	replayProfileID = uuid.MustParse("0a9b8c7d-6e5f-4a3b-2c1d-0e9f8a7b6c5d")
+	lisbon          = &models.UserLocation{UserLat: 38.7223, UserLon: -9.1393}
+)
+
+func TestProcessUnifiedChatMessageStream_FixtureReplay(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+	}{
+		{name: "unified_itinerary_lisbon", message: "Plan a 2 day itinerary in Lisbon"},
+		{name: "unified_dining_lisbon", message: "Where should I eat dinner in Lisbon"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newReplayService(fixtureProvider(t))
+			events := make(chan models.StreamEvent, 500)
+			require.NoError(t, svc.ProcessUnifiedChatMessageStream(context.Background(), replayUserID, replayProfileID, "", tc.message, lisbon, events))
+
+			all := collectStream(t, events)
+			for _, event := range all {
+				require.NotEqual(t, models.EventTypeError, event.Type, event.Error)
+			}
+			assertGoldenTranscript(t, tc.name, streamTranscript(t, all, startSessionID(t, all)))
+		})
+	}
+}
+
+func TestContinueSessionStreamed_FixtureReplay(t *testing.T) {
+	svc := newReplayService(fixtureProvider(t))
+	start := make(chan models.StreamEvent, 500)
+	require.NoError(t, svc.ProcessUnifiedChatMessageStream(context.Background(), replayUserID, replayProfileID, "", "Plan a 2 day itinerary in Lisbon", lisbon, start))
+	sessionID := startSessionID(t, collectStream(t, start))
+
+	events := make(chan models.StreamEvent, 500)
+	require.NoError(t, svc.ContinueSessionStreamed(context.Background(), sessionID, "Add Time Out Market", lisbon, events))
+
+	all := collectStream(t, events)
+	for _, event := range all {
+		require.NotEqual(t, models.EventTypeError, event.Type, event.Error)
+	}
+	assertGoldenTranscript(t, "continue_add_poi_lisbon", streamTranscript(t, all, sessionID))
+}
+
+// TestProcessUnifiedChatMessageStream_RecordedReplay replays answers recorded from a real
+// model, one domain per part type, with the recorded delays. Unlike the fixtures, the chunks
+// split JSON tokens and items wherever the model did, and the parts interleave as they did
+// live.
+func TestProcessUnifiedChatMessageStream_RecordedReplay(t *testing.T) {
+	provider := recordedProvider(t)
+	cases := []struct {
+		message string
+		parts   []string
+	}{
+		{message: "Plan a 2 day itinerary in Lisbon", parts: []string{"city_data", "general_pois", "itinerary"}},
+		{message: "Where should I eat dinner in Lisbon", parts: []string{"city_data", "restaurants"}},
+		{message: "Find a hotel in Lisbon", parts: []string{"city_data", "hotels"}},
+		{message: "Things to do in Lisbon", parts: []string{"city_data", "activities"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.message, func(t *testing.T) {
+			svc := newReplayService(provider)
+			events := make(chan models.StreamEvent, 2000)
+			require.NoError(t, svc.ProcessUnifiedChatMessageStream(context.Background(), replayUserID, replayProfileID, "", tc.message, lisbon, events))
+
+			chunks := make(map[string]int)
+			reconciled := make(map[string]map[string]interface{})
+			for _, event := range collectStream(t, events) {
+				require.NotEqual(t, models.EventTypeError, event.Type, event.Error)
+				switch event.Type {
+				case models.EventTypeChunk:
+					chunks[eventPart(event)]++
+				case models.EventTypeItemsReconciled:
+					reconciled[eventPart(event)] = event.Data.(map[string]interface{})
+				}
+			}
+
+			for _, part := range tc.parts {
+				assert.Greater(t, chunks[part], 1, "%s did not stream in several chunks", part)
+				if data, ok := reconciled[part]; ok {
+					assert.Positive(t, data["total"], "%s parsed no items", part)
+					assert.Equal(t, data["total"], data["streamed"], "the items of %s streamed across chunk boundaries must all be sent", part)
+				}
+			}
+		})
+	}
+}
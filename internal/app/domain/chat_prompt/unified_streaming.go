@@ -3,6 +3,7 @@ package llmchat
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -93,13 +94,18 @@ func (h *UnifiedStreamingHandlers) HandleStreamEvents(c *gin.Context) {
 	l := h.logger.With(zap.String("sessionId", sessionID))
 	l.Info("Client connected to streaming endpoint")
 
-	// Get the streaming channel
-	eventCh, found := h.streamManager.GetStream(sessionID)
+	// Get the session's replay buffer; a reconnecting client resumes after its Last-Event-ID
+	replay, found := h.streamManager.Replay(sessionID)
 	if !found {
 		l.Warn("No streaming session found")
 		c.Status(http.StatusNotFound)
 		return
 	}
+	var lastSeq uint64
+	if key, seq, ok := streamingpkg.LastEventID(c.Request); ok && key == replay.Key() {
+		lastSeq = seq
+		l.Info("Resuming stream", zap.Uint64("lastEventSeq", seq))
+	}
 
 	// Set SSE headers
 	w := c.Writer
@@ -117,39 +123,37 @@ func (h *UnifiedStreamingHandlers) HandleStreamEvents(c *gin.Context) {
 	}
 
 	// Stream events to client
-	for {
-		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				l.Info("Event channel closed, ending stream")
-				return
-			}
+	err := replay.Follow(c.Request.Context(), lastSeq, func(e streamingpkg.Sequenced[streamingpkg.UnifiedStreamEvent]) error {
+		event := e.Event
 
-			// Render the appropriate component based on event type and request type
-			htmlContent, err := h.renderEventAsHTML(ctx, event)
-			if err != nil {
-				l.Error("Failed to render event as HTML", zap.Any("error", err))
-				continue
-			}
-
-			// Send the HTML content as SSE
-			if htmlContent != "" {
-				sseEventType := h.getSSEEventType(event)
-				fmt.Fprintf(w, "event: %s\n", sseEventType)
-				fmt.Fprintf(w, "data: %s\n\n", htmlContent)
-				flusher.Flush()
-			}
+		// Render the appropriate component based on event type and request type
+		htmlContent, err := h.renderEventAsHTML(ctx, event)
+		if err != nil {
+			l.Error("Failed to render event as HTML", zap.Any("error", err))
+			return nil
+		}
 
-			// End stream if this is a final event
-			if event.IsFinal {
-				l.Info("Stream completed", zap.String("eventType", event.Type))
-				return
+		// Send the HTML content as SSE
+		if htmlContent != "" {
+			if err := streamingpkg.WriteSSE(w, replay.EventID(e.Seq), h.getSSEEventType(event), []byte(htmlContent)); err != nil {
+				return err
 			}
+			flusher.Flush()
+		}
 
-		case <-c.Request.Context().Done():
-			l.Info("Client disconnected")
-			return
+		if event.IsFinal {
+			l.Info("Stream completed", zap.String("eventType", event.Type))
 		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, streamingpkg.ErrReplayGap):
+		l.Warn("Client fell too far behind to resume the stream")
+		span.SetStatus(codes.Error, "Replay gap")
+	case err != nil:
+		l.Info("Client disconnected")
+	default:
+		l.Info("Event stream ended")
 	}
 }
 
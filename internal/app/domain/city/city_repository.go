@@ -50,7 +50,7 @@ func NewCityRepository(pgxpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImp
 func (r *RepositoryImpl) SaveCity(ctx context.Context, city models.CityDetail) (uuid.UUID, error) {
 	query := `
         INSERT INTO cities (
-            name, country, state_province, ai_summary, center_location 
+            name, country, state_province, ai_summary, center_location, timezone
             -- bounding_box will use its DEFAULT or be NULL if not specified
         ) VALUES (
             $1, $2, $3, $4, 
@@ -64,7 +64,8 @@ func (r *RepositoryImpl) SaveCity(ctx context.Context, city models.CityDetail) (
                      AND ($6::DOUBLE PRECISION >= -90 AND $6::DOUBLE PRECISION <= 90)   -- Latitude check
                 THEN ST_SetSRID(ST_MakePoint($5::DOUBLE PRECISION, $6::DOUBLE PRECISION), 4326) 
                 ELSE NULL 
-            END
+            END,
+            $7
         ) RETURNING id
     `
 	var id uuid.UUID
@@ -77,6 +78,7 @@ func (r *RepositoryImpl) SaveCity(ctx context.Context, city models.CityDetail) (
 		city.AiSummary,
 		NewNullFloat64(city.CenterLongitude),
 		NewNullFloat64(city.CenterLatitude),
+		NewNullString(city.Timezone),
 	).Scan(&id)
 
 	if err != nil {
@@ -117,7 +119,8 @@ func (r *RepositoryImpl) FindCityByNameAndCountry(ctx context.Context, cityName,
             COALESCE(state_province, '') as state_province, -- Handle NULL state_province
             ai_summary,
             ST_Y(center_location) as center_latitude,    -- Extract Y coordinate (latitude)
-            ST_X(center_location) as center_longitude   -- Extract X coordinate (longitude)
+            ST_X(center_location) as center_longitude,   -- Extract X coordinate (longitude)
+            COALESCE(timezone, '') as timezone
             -- Add bounding_box retrieval if you store it: ST_AsText(bounding_box) as bounding_box_wkt
         FROM cities
         WHERE LOWER(name) = LOWER($1)
@@ -135,6 +138,7 @@ func (r *RepositoryImpl) FindCityByNameAndCountry(ctx context.Context, cityName,
 		&cityDetail.AiSummary,
 		&lat,
 		&lon,
+		&cityDetail.Timezone,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -161,7 +165,8 @@ func (r *RepositoryImpl) FindCityByFuzzyName(ctx context.Context, cityName strin
 			COALESCE(state_province, '') as state_province, -- Handle NULL state_province
 			ai_summary,
 			ST_Y(center_location) as center_latitude,    -- Extract Y coordinate (latitude)
-			ST_X(center_location) as center_longitude   -- Extract X coordinate (longitude)
+			ST_X(center_location) as center_longitude,   -- Extract X coordinate (longitude)
+			COALESCE(timezone, '') as timezone
 		FROM cities
 		WHERE similarity(name, $1) > 0.3 -- you can adjust the threshold
 		ORDER BY similarity(name, $1) DESC
@@ -179,6 +184,7 @@ func (r *RepositoryImpl) FindCityByFuzzyName(ctx context.Context, cityName strin
 		&cityDetail.AiSummary,
 		&lat,
 		&lon,
+		&cityDetail.Timezone,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -0,0 +1,128 @@
+package costs
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+const (
+	defaultReportDays  = 7
+	maxReportDays      = 90
+	defaultReportLimit = 20
+	maxReportLimit     = 200
+)
+
+// Handler serves the admin cost report.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+	now     func() time.Time
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// RequireAdmin lets through only users whose role is admin. It expects AuthMiddleware to
+// have run first.
+func (h *Handler) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := middleware.GetUserFromContext(c)
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		userID, err := uuid.Parse(user.ID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user"})
+			return
+		}
+		isAdmin, err := h.service.IsAdmin(c.Request.Context(), userID)
+		if err != nil && !errors.Is(err, models.ErrNotFound) {
+			h.logger.Error("Failed to check admin role", zap.String("userID", user.ID), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Report handles GET /admin/costs/report?days=7&limit=20: top spenders, spend by intent and
+// model, and the cost per successful itinerary over the last days.
+func (h *Handler) Report(c *gin.Context) {
+	days := boundedQueryInt(c, "days", defaultReportDays, maxReportDays)
+	limit := boundedQueryInt(c, "limit", defaultReportLimit, maxReportLimit)
+	since := h.now().UTC().AddDate(0, 0, -days)
+
+	report, err := h.service.Report(c.Request.Context(), since, limit)
+	if err != nil {
+		h.logger.Error("Failed to build cost report", zap.Int("days", days), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build cost report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Rollups handles GET /admin/costs/rollups?bucket=hour|day&group_by=user|intent|model&days=1
+// with an optional user_id filter.
+func (h *Handler) Rollups(c *gin.Context) {
+	query := RollupQuery{
+		Bucket:  Bucket(c.DefaultQuery("bucket", string(BucketHour))),
+		GroupBy: GroupBy(c.DefaultQuery("group_by", string(GroupByModel))),
+		Until:   h.now().UTC(),
+	}
+	query.Since = query.Until.AddDate(0, 0, -boundedQueryInt(c, "days", 1, maxReportDays))
+	if raw := c.Query("user_id"); raw != "" {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		query.UserID = userID
+	}
+
+	rollups, err := h.service.Rollups(c.Request.Context(), query)
+	if errors.Is(err, models.ErrBadRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get cost rollups", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cost rollups"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"bucket":   query.Bucket,
+		"group_by": query.GroupBy,
+		"since":    query.Since,
+		"until":    query.Until,
+		"rollups":  rollups,
+	})
+}
+
+// boundedQueryInt reads a positive integer query parameter, falling back to def and capping
+// it at maxValue.
+func boundedQueryInt(c *gin.Context, key string, def, maxValue int) int {
+	n, err := strconv.Atoi(c.Query(key))
+	if err != nil || n <= 0 {
+		return def
+	}
+	return min(n, maxValue)
+}
@@ -0,0 +1,261 @@
+package costs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+// groupColumns whitelists the llm_cost_hourly columns rollups can be grouped by
+var groupColumns = map[GroupBy]string{
+	GroupByUser:   "COALESCE(user_id::text, 'anonymous')",
+	GroupByIntent: "intent",
+	GroupByModel:  "model_name",
+}
+
+type Repository interface {
+	// SpendSince sums the estimated cost of the user's interactions since the given time,
+	// or of every user's when userID is uuid.Nil.
+	SpendSince(ctx context.Context, userID uuid.UUID, since time.Time) (float64, error)
+	// Rollups aggregates the hourly cost ledger into buckets grouped by user, intent or model.
+	Rollups(ctx context.Context, query RollupQuery) ([]Rollup, error)
+	TopSpenders(ctx context.Context, since time.Time, limit int) ([]Spender, error)
+	// ItineraryCosts sums the cost of every search session that produced a valid itinerary.
+	ItineraryCosts(ctx context.Context, since time.Time) (ItineraryCost, error)
+	GetUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+// SpendSince reads llm_interactions directly, the hourly ledger lags behind by up to an hour
+func (r *RepositoryImpl) SpendSince(ctx context.Context, userID uuid.UUID, since time.Time) (float64, error) {
+	ctx, span := otel.Tracer("CostsRepository").Start(ctx, "SpendSince", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+		attribute.String("since", since.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	query := `
+		SELECT COALESCE(SUM(cost_estimate_usd), 0)::float8
+		FROM llm_interactions
+		WHERE created_at >= $1`
+	args := []any{since}
+	if userID != uuid.Nil {
+		query += ` AND user_id = $2`
+		args = append(args, userID)
+	}
+
+	var spent float64
+	if err := r.pgpool.QueryRow(ctx, query, args...).Scan(&spent); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to sum spend")
+		return 0, fmt.Errorf("failed to sum LLM spend: %w", err)
+	}
+
+	span.SetAttributes(attribute.Float64("spend.usd", spent))
+	span.SetStatus(codes.Ok, "Spend summed")
+	return spent, nil
+}
+
+// Rollups groups llm_cost_hourly by hour or day and by the requested dimension
+func (r *RepositoryImpl) Rollups(ctx context.Context, q RollupQuery) ([]Rollup, error) {
+	ctx, span := otel.Tracer("CostsRepository").Start(ctx, "Rollups", trace.WithAttributes(
+		attribute.String("bucket", string(q.Bucket)),
+		attribute.String("group_by", string(q.GroupBy)),
+		attribute.String("since", q.Since.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	column, ok := groupColumns[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported rollup grouping %q: %w", q.GroupBy, models.ErrBadRequest)
+	}
+	if q.Bucket != BucketHour && q.Bucket != BucketDay {
+		return nil, fmt.Errorf("unsupported rollup bucket %q: %w", q.Bucket, models.ErrBadRequest)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			date_trunc($1, hour) AS bucket,
+			%s AS key,
+			SUM(requests)::bigint,
+			SUM(successful_requests)::bigint,
+			SUM(cache_hits)::bigint,
+			SUM(prompt_tokens)::bigint,
+			SUM(completion_tokens)::bigint,
+			SUM(cost_usd)::float8
+		FROM llm_cost_hourly
+		WHERE hour >= $2 AND hour < $3
+		  AND ($4::uuid IS NULL OR user_id = $4)
+		GROUP BY 1, 2
+		ORDER BY 1, 8 DESC`, column)
+
+	var userFilter *uuid.UUID
+	if q.UserID != uuid.Nil {
+		userFilter = &q.UserID
+	}
+	rows, err := r.pgpool.Query(ctx, query, string(q.Bucket), q.Since, q.Until, userFilter)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query rollups")
+		return nil, fmt.Errorf("failed to query cost rollups: %w", err)
+	}
+	defer rows.Close()
+
+	var rollups []Rollup
+	for rows.Next() {
+		var ru Rollup
+		if err := rows.Scan(&ru.Bucket, &ru.Key, &ru.Requests, &ru.SuccessfulRequests, &ru.CacheHits,
+			&ru.PromptTokens, &ru.CompletionTokens, &ru.CostUSD); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to scan rollup")
+			return nil, fmt.Errorf("failed to scan cost rollup: %w", err)
+		}
+		rollups = append(rollups, ru)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to iterate rollups")
+		return nil, fmt.Errorf("failed to iterate cost rollups: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("rollups.count", len(rollups)))
+	span.SetStatus(codes.Ok, "Rollups retrieved")
+	return rollups, nil
+}
+
+// TopSpenders ranks users by estimated cost since the given time
+func (r *RepositoryImpl) TopSpenders(ctx context.Context, since time.Time, limit int) ([]Spender, error) {
+	ctx, span := otel.Tracer("CostsRepository").Start(ctx, "TopSpenders", trace.WithAttributes(
+		attribute.String("since", since.Format(time.RFC3339)),
+		attribute.Int("limit", limit),
+	))
+	defer span.End()
+
+	query := `
+		SELECT
+			c.user_id,
+			COALESCE(u.email::text, ''),
+			COALESCE(u.username::text, ''),
+			SUM(c.requests)::bigint,
+			SUM(c.total_tokens)::bigint,
+			SUM(c.cost_usd)::float8 AS cost
+		FROM llm_cost_hourly c
+		LEFT JOIN users u ON u.id = c.user_id
+		WHERE c.hour >= $1 AND c.user_id IS NOT NULL
+		GROUP BY c.user_id, u.email, u.username
+		ORDER BY cost DESC
+		LIMIT $2`
+
+	rows, err := r.pgpool.Query(ctx, query, since, limit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query top spenders")
+		return nil, fmt.Errorf("failed to query top spenders: %w", err)
+	}
+	defer rows.Close()
+
+	var spenders []Spender
+	for rows.Next() {
+		var s Spender
+		if err := rows.Scan(&s.UserID, &s.Email, &s.Username, &s.Requests, &s.TotalTokens, &s.CostUSD); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to scan spender")
+			return nil, fmt.Errorf("failed to scan top spender: %w", err)
+		}
+		spenders = append(spenders, s)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to iterate spenders")
+		return nil, fmt.Errorf("failed to iterate top spenders: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Top spenders retrieved")
+	return spenders, nil
+}
+
+// ItineraryCosts counts sessions whose itinerary part was generated and passed its schema,
+// and the cost of every call those sessions made (city data, POIs, repairs, follow-ups).
+func (r *RepositoryImpl) ItineraryCosts(ctx context.Context, since time.Time) (ItineraryCost, error) {
+	ctx, span := otel.Tracer("CostsRepository").Start(ctx, "ItineraryCosts", trace.WithAttributes(
+		attribute.String("since", since.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	query := `
+		WITH itineraries AS (
+			SELECT DISTINCT session_id
+			FROM llm_interactions
+			WHERE created_at >= $1
+			  AND session_id IS NOT NULL
+			  AND schema_name = 'itinerary'
+			  AND status_code = 200
+			  AND schema_valid IS NOT FALSE
+		)
+		SELECT
+			(SELECT COUNT(*) FROM itineraries),
+			COALESCE(SUM(li.cost_estimate_usd), 0)::float8,
+			(SELECT COALESCE(SUM(cost_estimate_usd), 0)::float8 FROM llm_interactions WHERE created_at >= $1)
+		FROM llm_interactions li
+		JOIN itineraries i ON i.session_id = li.session_id
+		WHERE li.created_at >= $1`
+
+	var ic ItineraryCost
+	err := r.pgpool.QueryRow(ctx, query, since).Scan(&ic.SuccessfulItineraries, &ic.ItinerarySessionsCostUSD, &ic.TotalCostUSD)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to compute itinerary costs")
+		return ItineraryCost{}, fmt.Errorf("failed to compute itinerary costs: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int64("itineraries.successful", ic.SuccessfulItineraries))
+	span.SetStatus(codes.Ok, "Itinerary costs computed")
+	return ic, nil
+}
+
+// GetUserRole returns the role column of the user, e.g. "admin" or "user"
+func (r *RepositoryImpl) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	ctx, span := otel.Tracer("CostsRepository").Start(ctx, "GetUserRole", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	var role string
+	err := r.pgpool.QueryRow(ctx, `SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", models.ErrNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to get user role")
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	span.SetStatus(codes.Ok, "User role retrieved")
+	return role, nil
+}
@@ -0,0 +1,327 @@
+package costs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Bucket is the width of a rollup bucket.
+type Bucket string
+
+const (
+	BucketHour Bucket = "hour"
+	BucketDay  Bucket = "day"
+)
+
+// GroupBy is the dimension rollups are split by.
+type GroupBy string
+
+const (
+	GroupByUser   GroupBy = "user"
+	GroupByIntent GroupBy = "intent"
+	GroupByModel  GroupBy = "model"
+)
+
+// Level is how far spend has gone past its budget.
+type Level int
+
+const (
+	// LevelOK allows every LLM call.
+	LevelOK Level = iota
+	// LevelSoft serves cached answers where there are any and calls the model otherwise.
+	LevelSoft
+	// LevelHard never calls the model: answers come from the cache or the database only.
+	LevelHard
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelSoft:
+		return "soft"
+	case LevelHard:
+		return "hard"
+	default:
+		return "ok"
+	}
+}
+
+// MarshalText reports the level by name in JSON.
+func (l Level) MarshalText() ([]byte, error) { return []byte(l.String()), nil }
+
+// Scope tells which budget a status was computed against.
+type Scope string
+
+const (
+	ScopeUser   Scope = "user"
+	ScopeGlobal Scope = "global"
+)
+
+// Budget holds the daily spend limits in USD. A zero limit is disabled.
+type Budget struct {
+	UserDailySoftUSD   float64
+	UserDailyHardUSD   float64
+	GlobalDailySoftUSD float64
+	GlobalDailyHardUSD float64
+}
+
+// Enabled reports whether any limit is set.
+func (b Budget) Enabled() bool {
+	return b.UserDailySoftUSD > 0 || b.UserDailyHardUSD > 0 || b.GlobalDailySoftUSD > 0 || b.GlobalDailyHardUSD > 0
+}
+
+// BudgetStatus is the most severe budget level reached by a user or by everyone together.
+type BudgetStatus struct {
+	Level    Level   `json:"level"`
+	Scope    Scope   `json:"scope,omitempty"`
+	SpentUSD float64 `json:"spent_usd"`
+	LimitUSD float64 `json:"limit_usd,omitempty"`
+}
+
+// Degraded reports whether LLM calls should be avoided.
+func (s BudgetStatus) Degraded() bool { return s.Level > LevelOK }
+
+type RollupQuery struct {
+	Bucket  Bucket
+	GroupBy GroupBy
+	Since   time.Time
+	Until   time.Time
+	UserID  uuid.UUID // uuid.Nil for every user
+}
+
+type Rollup struct {
+	Bucket             time.Time `json:"bucket"`
+	Key                string    `json:"key"`
+	Requests           int64     `json:"requests"`
+	SuccessfulRequests int64     `json:"successful_requests"`
+	CacheHits          int64     `json:"cache_hits"`
+	PromptTokens       int64     `json:"prompt_tokens"`
+	CompletionTokens   int64     `json:"completion_tokens"`
+	CostUSD            float64   `json:"cost_usd"`
+}
+
+type Spender struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email"`
+	Username    string    `json:"username"`
+	Requests    int64     `json:"requests"`
+	TotalTokens int64     `json:"total_tokens"`
+	CostUSD     float64   `json:"cost_usd"`
+}
+
+type ItineraryCost struct {
+	SuccessfulItineraries    int64   `json:"successful_itineraries"`
+	ItinerarySessionsCostUSD float64 `json:"itinerary_sessions_cost_usd"`
+	TotalCostUSD             float64 `json:"total_cost_usd"`
+	// CostPerItineraryUSD is what the sessions that produced an itinerary cost on average.
+	CostPerItineraryUSD float64 `json:"cost_per_itinerary_usd"`
+	// SpendPerItineraryUSD charges all spend, failed and non-itinerary calls included, to
+	// the itineraries that succeeded.
+	SpendPerItineraryUSD float64 `json:"spend_per_itinerary_usd"`
+}
+
+// Report is the admin overview of LLM spend.
+type Report struct {
+	Since       time.Time     `json:"since"`
+	Until       time.Time     `json:"until"`
+	Budget      Budget        `json:"budget"`
+	Global      BudgetStatus  `json:"global_today"`
+	TopSpenders []Spender     `json:"top_spenders"`
+	ByIntent    []Rollup      `json:"by_intent"`
+	ByModel     []Rollup      `json:"by_model"`
+	Itineraries ItineraryCost `json:"itineraries"`
+}
+
+type Service interface {
+	// CheckBudget returns the budget level reached today by the user or by all users together,
+	// whichever is more severe. Anonymous requests (uuid.Nil) only count against the global budget.
+	CheckBudget(ctx context.Context, userID uuid.UUID) (BudgetStatus, error)
+	Rollups(ctx context.Context, query RollupQuery) ([]Rollup, error)
+	Report(ctx context.Context, since time.Time, limit int) (*Report, error)
+	IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// spendCacheTTL bounds how stale the spend behind a budget decision can be. Calls are logged
+// asynchronously anyway, so budgets are soft by a few requests either way.
+const spendCacheTTL = 30 * time.Second
+
+type cachedSpend struct {
+	day     time.Time
+	spent   float64
+	expires time.Time
+}
+
+type ServiceImpl struct {
+	repo   Repository
+	logger *zap.Logger
+	budget Budget
+	now    func() time.Time
+
+	mu    sync.Mutex
+	spend map[uuid.UUID]cachedSpend // uuid.Nil holds the global spend
+}
+
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+		spend:  make(map[uuid.UUID]cachedSpend),
+	}
+}
+
+// WithBudget sets the daily soft and hard limits.
+func (s *ServiceImpl) WithBudget(b Budget) *ServiceImpl {
+	s.budget = b
+	return s
+}
+
+func (s *ServiceImpl) CheckBudget(ctx context.Context, userID uuid.UUID) (BudgetStatus, error) {
+	if !s.budget.Enabled() {
+		return BudgetStatus{}, nil
+	}
+	ctx, span := otel.Tracer("CostsService").Start(ctx, "CheckBudget", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	status := BudgetStatus{}
+	if s.budget.GlobalDailySoftUSD > 0 || s.budget.GlobalDailyHardUSD > 0 {
+		spent, err := s.spentToday(ctx, uuid.Nil)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to read global spend")
+			return BudgetStatus{}, err
+		}
+		status = evaluate(ScopeGlobal, spent, s.budget.GlobalDailySoftUSD, s.budget.GlobalDailyHardUSD)
+	}
+	if userID != uuid.Nil && (s.budget.UserDailySoftUSD > 0 || s.budget.UserDailyHardUSD > 0) {
+		spent, err := s.spentToday(ctx, userID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to read user spend")
+			return BudgetStatus{}, err
+		}
+		// On a tie the user's own spend is the more useful figure to report
+		if userStatus := evaluate(ScopeUser, spent, s.budget.UserDailySoftUSD, s.budget.UserDailyHardUSD); userStatus.Level >= status.Level {
+			status = userStatus
+		}
+	}
+
+	span.SetAttributes(attribute.String("budget.level", status.Level.String()), attribute.String("budget.scope", string(status.Scope)))
+	span.SetStatus(codes.Ok, "Budget checked")
+	return status, nil
+}
+
+// evaluate compares spend against a soft and a hard limit, either of which may be disabled.
+func evaluate(scope Scope, spent, soft, hard float64) BudgetStatus {
+	switch {
+	case hard > 0 && spent >= hard:
+		return BudgetStatus{Level: LevelHard, Scope: scope, SpentUSD: spent, LimitUSD: hard}
+	case soft > 0 && spent >= soft:
+		return BudgetStatus{Level: LevelSoft, Scope: scope, SpentUSD: spent, LimitUSD: soft}
+	default:
+		return BudgetStatus{Level: LevelOK, SpentUSD: spent}
+	}
+}
+
+// spentToday returns the spend since UTC midnight, cached briefly so that every streamed
+// part does not sum the table again.
+func (s *ServiceImpl) spentToday(ctx context.Context, userID uuid.UUID) (float64, error) {
+	now := s.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	s.mu.Lock()
+	cached, ok := s.spend[userID]
+	s.mu.Unlock()
+	if ok && cached.day.Equal(day) && now.Before(cached.expires) {
+		return cached.spent, nil
+	}
+
+	spent, err := s.repo.SpendSince(ctx, userID, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read today's spend: %w", err)
+	}
+
+	s.mu.Lock()
+	s.spend[userID] = cachedSpend{day: day, spent: spent, expires: now.Add(spendCacheTTL)}
+	s.mu.Unlock()
+	return spent, nil
+}
+
+func (s *ServiceImpl) Rollups(ctx context.Context, q RollupQuery) ([]Rollup, error) {
+	if q.Until.IsZero() {
+		q.Until = s.now()
+	}
+	if !q.Since.Before(q.Until) {
+		return nil, fmt.Errorf("rollup start must be before its end: %w", models.ErrBadRequest)
+	}
+	rollups, err := s.repo.Rollups(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cost rollups: %w", err)
+	}
+	return rollups, nil
+}
+
+func (s *ServiceImpl) Report(ctx context.Context, since time.Time, limit int) (*Report, error) {
+	ctx, span := otel.Tracer("CostsService").Start(ctx, "Report", trace.WithAttributes(
+		attribute.String("since", since.Format(time.RFC3339)),
+		attribute.Int("limit", limit),
+	))
+	defer span.End()
+
+	now := s.now()
+	report := &Report{Since: since, Until: now, Budget: s.budget}
+
+	var err error
+	if report.TopSpenders, err = s.repo.TopSpenders(ctx, since, limit); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get top spenders: %w", err)
+	}
+	if report.ByIntent, err = s.repo.Rollups(ctx, RollupQuery{Bucket: BucketDay, GroupBy: GroupByIntent, Since: since, Until: now}); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get cost by intent: %w", err)
+	}
+	if report.ByModel, err = s.repo.Rollups(ctx, RollupQuery{Bucket: BucketDay, GroupBy: GroupByModel, Since: since, Until: now}); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get cost by model: %w", err)
+	}
+	if report.Itineraries, err = s.repo.ItineraryCosts(ctx, since); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get itinerary costs: %w", err)
+	}
+	if n := report.Itineraries.SuccessfulItineraries; n > 0 {
+		report.Itineraries.CostPerItineraryUSD = report.Itineraries.ItinerarySessionsCostUSD / float64(n)
+		report.Itineraries.SpendPerItineraryUSD = report.Itineraries.TotalCostUSD / float64(n)
+	}
+
+	globalSpent, err := s.repo.SpendSince(ctx, uuid.Nil, time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get today's spend: %w", err)
+	}
+	report.Global = evaluate(ScopeGlobal, globalSpent, s.budget.GlobalDailySoftUSD, s.budget.GlobalDailyHardUSD)
+
+	span.SetStatus(codes.Ok, "Cost report built")
+	return report, nil
+}
+
+func (s *ServiceImpl) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	role, err := s.repo.GetUserRole(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role == "admin", nil
+}
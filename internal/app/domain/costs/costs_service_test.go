@@ -0,0 +1,186 @@
+package costs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) SpendSince(ctx context.Context, userID uuid.UUID, since time.Time) (float64, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockRepository) Rollups(ctx context.Context, query RollupQuery) ([]Rollup, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Rollup), args.Error(1)
+}
+
+func (m *MockRepository) TopSpenders(ctx context.Context, since time.Time, limit int) ([]Spender, error) {
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Spender), args.Error(1)
+}
+
+func (m *MockRepository) ItineraryCosts(ctx context.Context, since time.Time) (ItineraryCost, error) {
+	args := m.Called(ctx, since)
+	return args.Get(0).(ItineraryCost), args.Error(1)
+}
+
+func (m *MockRepository) GetUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+var (
+	testNow      = time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	testMidnight = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestService(repo Repository, budget Budget) *ServiceImpl {
+	s := NewService(repo, zap.NewNop()).WithBudget(budget)
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func TestCheckBudget_Levels(t *testing.T) {
+	budget := Budget{UserDailySoftUSD: 0.5, UserDailyHardUSD: 1, GlobalDailySoftUSD: 50, GlobalDailyHardUSD: 100}
+	tests := []struct {
+		name      string
+		userSpent float64
+		allSpent  float64
+		want      BudgetStatus
+	}{
+		{name: "under every limit", userSpent: 0.1, allSpent: 10, want: BudgetStatus{Level: LevelOK, SpentUSD: 0.1}},
+		{name: "user soft limit", userSpent: 0.5, allSpent: 10, want: BudgetStatus{Level: LevelSoft, Scope: ScopeUser, SpentUSD: 0.5, LimitUSD: 0.5}},
+		{name: "user hard limit", userSpent: 1.2, allSpent: 10, want: BudgetStatus{Level: LevelHard, Scope: ScopeUser, SpentUSD: 1.2, LimitUSD: 1}},
+		{name: "global hard beats user soft", userSpent: 0.6, allSpent: 100, want: BudgetStatus{Level: LevelHard, Scope: ScopeGlobal, SpentUSD: 100, LimitUSD: 100}},
+		{name: "user hard beats global soft", userSpent: 2, allSpent: 60, want: BudgetStatus{Level: LevelHard, Scope: ScopeUser, SpentUSD: 2, LimitUSD: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			repo := new(MockRepository)
+			repo.On("SpendSince", mock.Anything, uuid.Nil, testMidnight).Return(tt.allSpent, nil)
+			repo.On("SpendSince", mock.Anything, userID, testMidnight).Return(tt.userSpent, nil)
+
+			status, err := newTestService(repo, budget).CheckBudget(context.Background(), userID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+			assert.Equal(t, tt.want.Level > LevelOK, status.Degraded())
+		})
+	}
+}
+
+func TestCheckBudget_Disabled(t *testing.T) {
+	repo := new(MockRepository)
+	status, err := newTestService(repo, Budget{}).CheckBudget(context.Background(), uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, LevelOK, status.Level)
+	repo.AssertNotCalled(t, "SpendSince", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckBudget_AnonymousOnlyCountsGlobally(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("SpendSince", mock.Anything, uuid.Nil, testMidnight).Return(5.0, nil).Once()
+
+	status, err := newTestService(repo, Budget{UserDailyHardUSD: 1, GlobalDailyHardUSD: 10}).CheckBudget(context.Background(), uuid.Nil)
+	require.NoError(t, err)
+	assert.Equal(t, LevelOK, status.Level)
+	repo.AssertExpectations(t)
+}
+
+func TestCheckBudget_CachesSpend(t *testing.T) {
+	userID := uuid.New()
+	repo := new(MockRepository)
+	repo.On("SpendSince", mock.Anything, userID, testMidnight).Return(0.2, nil).Once()
+	service := newTestService(repo, Budget{UserDailyHardUSD: 1})
+
+	for range 3 {
+		_, err := service.CheckBudget(context.Background(), userID)
+		require.NoError(t, err)
+	}
+	repo.AssertNumberOfCalls(t, "SpendSince", 1)
+
+	// Once stale the spend is read again, and a new day starts from its own midnight
+	service.now = func() time.Time { return testNow.Add(12 * time.Hour) }
+	repo.On("SpendSince", mock.Anything, userID, testMidnight.Add(24*time.Hour)).Return(0.0, nil).Once()
+	_, err := service.CheckBudget(context.Background(), userID)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestCheckBudget_RepositoryError(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("SpendSince", mock.Anything, uuid.Nil, mock.Anything).Return(0.0, errors.New("connection refused"))
+
+	_, err := newTestService(repo, Budget{GlobalDailySoftUSD: 1}).CheckBudget(context.Background(), uuid.New())
+	assert.Error(t, err)
+}
+
+func TestReport(t *testing.T) {
+	since := testNow.AddDate(0, 0, -7)
+	spenders := []Spender{{UserID: uuid.New(), Email: "a@example.com", CostUSD: 3.5}}
+	byIntent := []Rollup{{Key: "itinerary", CostUSD: 4}}
+	byModel := []Rollup{{Key: "gemini-2.0-flash", CostUSD: 6}}
+
+	repo := new(MockRepository)
+	repo.On("TopSpenders", mock.Anything, since, 10).Return(spenders, nil)
+	repo.On("Rollups", mock.Anything, RollupQuery{Bucket: BucketDay, GroupBy: GroupByIntent, Since: since, Until: testNow}).Return(byIntent, nil)
+	repo.On("Rollups", mock.Anything, RollupQuery{Bucket: BucketDay, GroupBy: GroupByModel, Since: since, Until: testNow}).Return(byModel, nil)
+	repo.On("ItineraryCosts", mock.Anything, since).Return(ItineraryCost{SuccessfulItineraries: 4, ItinerarySessionsCostUSD: 2, TotalCostUSD: 6}, nil)
+	repo.On("SpendSince", mock.Anything, uuid.Nil, testMidnight).Return(1.5, nil)
+
+	report, err := newTestService(repo, Budget{GlobalDailySoftUSD: 1}).Report(context.Background(), since, 10)
+	require.NoError(t, err)
+	assert.Equal(t, spenders, report.TopSpenders)
+	assert.Equal(t, byIntent, report.ByIntent)
+	assert.Equal(t, byModel, report.ByModel)
+	assert.InDelta(t, 0.5, report.Itineraries.CostPerItineraryUSD, 1e-9)
+	assert.InDelta(t, 1.5, report.Itineraries.SpendPerItineraryUSD, 1e-9)
+	assert.Equal(t, BudgetStatus{Level: LevelSoft, Scope: ScopeGlobal, SpentUSD: 1.5, LimitUSD: 1}, report.Global)
+	repo.AssertExpectations(t)
+}
+
+func TestRollups_RejectsEmptyRange(t *testing.T) {
+	repo := new(MockRepository)
+	_, err := newTestService(repo, Budget{}).Rollups(context.Background(), RollupQuery{Bucket: BucketHour, GroupBy: GroupByUser, Since: testNow})
+	assert.ErrorIs(t, err, models.ErrBadRequest)
+	repo.AssertNotCalled(t, "Rollups", mock.Anything, mock.Anything)
+}
+
+func TestIsAdmin(t *testing.T) {
+	admin, user := uuid.New(), uuid.New()
+	repo := new(MockRepository)
+	repo.On("GetUserRole", mock.Anything, admin).Return("admin", nil)
+	repo.On("GetUserRole", mock.Anything, user).Return("user", nil)
+	service := newTestService(repo, Budget{})
+
+	ok, err := service.IsAdmin(context.Background(), admin)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = service.IsAdmin(context.Background(), user)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,126 @@
+package costs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+type stubService struct {
+	admin     bool
+	adminErr  error
+	report    *Report
+	lastSince time.Time
+	lastLimit int
+	lastQuery RollupQuery
+}
+
+func (s *stubService) CheckBudget(context.Context, uuid.UUID) (BudgetStatus, error) {
+	return BudgetStatus{}, nil
+}
+
+func (s *stubService) Rollups(_ context.Context, q RollupQuery) ([]Rollup, error) {
+	s.lastQuery = q
+	return []Rollup{{Key: "gemini-2.0-flash", Requests: 3}}, nil
+}
+
+func (s *stubService) Report(_ context.Context, since time.Time, limit int) (*Report, error) {
+	s.lastSince, s.lastLimit = since, limit
+	return s.report, nil
+}
+
+func (s *stubService) IsAdmin(context.Context, uuid.UUID) (bool, error) { return s.admin, s.adminErr }
+
+func newTestRouter(h *Handler, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h.now = func() time.Time { return testNow }
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set(string(middleware.UserContextKey), &models.User{ID: userID})
+		}
+	})
+	admin := r.Group("/admin", h.RequireAdmin())
+	admin.GET("/costs/report", h.Report)
+	admin.GET("/costs/rollups", h.Rollups)
+	return r
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		service *stubService
+		want    int
+	}{
+		{name: "anonymous", service: &stubService{admin: true}, want: http.StatusUnauthorized},
+		{name: "regular user", userID: uuid.NewString(), service: &stubService{}, want: http.StatusForbidden},
+		{name: "unknown user", userID: uuid.NewString(), service: &stubService{adminErr: fmt.Errorf("failed to get user role: %w", models.ErrNotFound)}, want: http.StatusForbidden},
+		{name: "role lookup failure", userID: uuid.NewString(), service: &stubService{adminErr: fmt.Errorf("connection refused")}, want: http.StatusInternalServerError},
+		{name: "admin", userID: uuid.NewString(), service: &stubService{admin: true, report: &Report{}}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(NewHandler(tt.service, zap.NewNop()), tt.userID)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/costs/report", nil))
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestReportHandler(t *testing.T) {
+	service := &stubService{admin: true, report: &Report{
+		TopSpenders: []Spender{{Email: "a@example.com", CostUSD: 2}},
+		Itineraries: ItineraryCost{SuccessfulItineraries: 2, CostPerItineraryUSD: 0.25},
+	}}
+	r := newTestRouter(NewHandler(service, zap.NewNop()), uuid.NewString())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/costs/report?days=500&limit=5", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, testNow.AddDate(0, 0, -maxReportDays), service.lastSince, "days are capped")
+	assert.Equal(t, 5, service.lastLimit)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "a@example.com", body["top_spenders"].([]any)[0].(map[string]any)["email"])
+	assert.Equal(t, 0.25, body["itineraries"].(map[string]any)["cost_per_itinerary_usd"])
+}
+
+func TestRollupsHandler(t *testing.T) {
+	service := &stubService{admin: true}
+	r := newTestRouter(NewHandler(service, zap.NewNop()), uuid.NewString())
+	userID := uuid.New()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/costs/rollups?bucket=day&group_by=intent&days=3&user_id="+userID.String(), nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, RollupQuery{
+		Bucket:  BucketDay,
+		GroupBy: GroupByIntent,
+		Since:   testNow.AddDate(0, 0, -3),
+		Until:   testNow,
+		UserID:  userID,
+	}, service.lastQuery)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/costs/rollups?user_id=nope", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,116 @@
+package deadletter
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Handler serves the admin API over undelivered stream events. Routes are expected behind
+// an admin check.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List handles GET /admin/dead-letters?status=pending&session_id=&limit=50&offset=0.
+func (h *Handler) List(c *gin.Context) {
+	filter := Filter{Status: Status(c.Query("status"))}
+	if raw := c.Query("session_id"); raw != "" {
+		sessionID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session_id"})
+			return
+		}
+		filter.SessionID = &sessionID
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	letters, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		h.respondError(c, "Failed to list dead letters", err)
+		return
+	}
+	if letters == nil {
+		letters = []DeadLetter{}
+	}
+	c.JSON(http.StatusOK, gin.H{"dead_letters": letters})
+}
+
+// Get handles GET /admin/dead-letters/:id.
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	letter, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to get dead letter", err)
+		return
+	}
+	c.JSON(http.StatusOK, letter)
+}
+
+// Replay handles POST /admin/dead-letters/:id/replay, queueing the event for the next
+// connection to its session.
+func (h *Handler) Replay(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	letter, err := h.service.Replay(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to replay dead letter", err)
+		return
+	}
+	c.JSON(http.StatusOK, letter)
+}
+
+// Discard handles POST /admin/dead-letters/:id/discard.
+func (h *Handler) Discard(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+	letter, err := h.service.Discard(c.Request.Context(), id)
+	if err != nil {
+		h.respondError(c, "Failed to discard dead letter", err)
+		return
+	}
+	c.JSON(http.StatusOK, letter)
+}
+
+func (h *Handler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dead letter id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "dead letter not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
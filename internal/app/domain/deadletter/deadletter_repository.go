@@ -0,0 +1,251 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+const deadLetterColumns = `id, session_id, user_id, event_id, event_type, reason, payload, status, attempts, created_at, updated_at, delivered_at`
+
+type Repository interface {
+	// Save stores an undelivered event. An event already stored for the session is kept as is.
+	Save(ctx context.Context, letter *DeadLetter) error
+	List(ctx context.Context, filter Filter) ([]DeadLetter, error)
+	Get(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+	// TakePending marks the pending events of a session delivered and returns them oldest first.
+	TakePending(ctx context.Context, sessionID uuid.UUID) ([]DeadLetter, error)
+	// Requeue puts taken events that could not be written back to pending.
+	Requeue(ctx context.Context, ids []uuid.UUID) error
+	SetStatus(ctx context.Context, id uuid.UUID, status Status) (*DeadLetter, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) Save(ctx context.Context, letter *DeadLetter) error {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "Save", trace.WithAttributes(
+		attribute.String("event.id", letter.EventID),
+		attribute.String("event.type", letter.EventType),
+		attribute.String("reason", letter.Reason),
+	))
+	defer span.End()
+
+	payload, err := json.Marshal(letter.Event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to marshal event")
+		return fmt.Errorf("failed to marshal dead letter event: %w", err)
+	}
+
+	query := `
+		INSERT INTO stream_dead_letters (session_id, user_id, event_id, event_type, reason, payload)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (session_id, event_id) DO NOTHING
+		RETURNING id, status, created_at, updated_at`
+	err = r.pgpool.QueryRow(ctx, query, letter.SessionID, letter.UserID, letter.EventID, letter.EventType, letter.Reason, payload).
+		Scan(&letter.ID, &letter.Status, &letter.CreatedAt, &letter.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		span.SetStatus(codes.Ok, "Dead letter already stored")
+		return nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to insert dead letter")
+		return fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Dead letter stored")
+	return nil
+}
+
+func (r *RepositoryImpl) List(ctx context.Context, filter Filter) ([]DeadLetter, error) {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "List", trace.WithAttributes(
+		attribute.String("status", string(filter.Status)),
+		attribute.Int("limit", filter.Limit),
+		attribute.Int("offset", filter.Offset),
+	))
+	defer span.End()
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM stream_dead_letters
+		WHERE ($1::text = '' OR status = $1::text)
+		  AND ($2::uuid IS NULL OR session_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.pgpool.Query(ctx, query, string(filter.Status), filter.SessionID, filter.Limit, filter.Offset)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query dead letters")
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	letters, err := scanDeadLetters(rows)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to scan dead letters")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("dead_letters.count", len(letters)))
+	span.SetStatus(codes.Ok, "Dead letters listed")
+	return letters, nil
+}
+
+func (r *RepositoryImpl) Get(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "Get", trace.WithAttributes(
+		attribute.String("dead_letter.id", id.String()),
+	))
+	defer span.End()
+
+	rows, err := r.pgpool.Query(ctx, `SELECT `+deadLetterColumns+` FROM stream_dead_letters WHERE id = $1`, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query dead letter")
+		return nil, fmt.Errorf("failed to query dead letter: %w", err)
+	}
+	letters, err := scanDeadLetters(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if len(letters) == 0 {
+		return nil, models.ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Dead letter retrieved")
+	return &letters[0], nil
+}
+
+func (r *RepositoryImpl) TakePending(ctx context.Context, sessionID uuid.UUID) ([]DeadLetter, error) {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "TakePending", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+	))
+	defer span.End()
+
+	// SKIP LOCKED keeps two reconnects of the same session from both delivering an event
+	query := `
+		UPDATE stream_dead_letters
+		SET status = 'delivered', attempts = attempts + 1, delivered_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM stream_dead_letters
+			WHERE session_id = $1 AND status = 'pending'
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deadLetterColumns
+
+	rows, err := r.pgpool.Query(ctx, query, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to take pending dead letters")
+		return nil, fmt.Errorf("failed to take pending dead letters: %w", err)
+	}
+	letters, err := scanDeadLetters(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	slices.SortFunc(letters, func(a, b DeadLetter) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	span.SetAttributes(attribute.Int("dead_letters.count", len(letters)))
+	span.SetStatus(codes.Ok, "Pending dead letters taken")
+	return letters, nil
+}
+
+func (r *RepositoryImpl) Requeue(ctx context.Context, ids []uuid.UUID) error {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "Requeue", trace.WithAttributes(
+		attribute.Int("dead_letters.count", len(ids)),
+	))
+	defer span.End()
+
+	query := `
+		UPDATE stream_dead_letters
+		SET status = 'pending', delivered_at = NULL, updated_at = NOW()
+		WHERE id = ANY($1) AND status = 'delivered'`
+
+	if _, err := r.pgpool.Exec(ctx, query, ids); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to requeue dead letters")
+		return fmt.Errorf("failed to requeue dead letters: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Dead letters requeued")
+	return nil
+}
+
+func (r *RepositoryImpl) SetStatus(ctx context.Context, id uuid.UUID, status Status) (*DeadLetter, error) {
+	ctx, span := otel.Tracer("DeadLetterRepository").Start(ctx, "SetStatus", trace.WithAttributes(
+		attribute.String("dead_letter.id", id.String()),
+		attribute.String("status", string(status)),
+	))
+	defer span.End()
+
+	query := `
+		UPDATE stream_dead_letters
+		SET status = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + deadLetterColumns
+
+	rows, err := r.pgpool.Query(ctx, query, id, string(status))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to update dead letter")
+		return nil, fmt.Errorf("failed to update dead letter status: %w", err)
+	}
+	letters, err := scanDeadLetters(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if len(letters) == 0 {
+		return nil, models.ErrNotFound
+	}
+	span.SetStatus(codes.Ok, "Dead letter updated")
+	return &letters[0], nil
+}
+
+func scanDeadLetters(rows pgx.Rows) ([]DeadLetter, error) {
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		var l DeadLetter
+		var payload []byte
+		if err := rows.Scan(&l.ID, &l.SessionID, &l.UserID, &l.EventID, &l.EventType, &l.Reason, &payload,
+			&l.Status, &l.Attempts, &l.CreatedAt, &l.UpdatedAt, &l.DeliveredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		if err := json.Unmarshal(payload, &l.Event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead letter %s: %w", l.ID, err)
+		}
+		letters = append(letters, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dead letters: %w", err)
+	}
+	return letters, nil
+}
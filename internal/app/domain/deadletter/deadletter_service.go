@@ -0,0 +1,200 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Status is where a dead letter stands.
+type Status string
+
+const (
+	// StatusPending events are re-delivered when the client reconnects to the session.
+	StatusPending Status = "pending"
+	// StatusDelivered events reached the client on a later connection.
+	StatusDelivered Status = "delivered"
+	// StatusDiscarded events were dropped by an admin.
+	StatusDiscarded Status = "discarded"
+)
+
+// Reasons an event could not be delivered.
+const (
+	ReasonContextCancelled = "context_cancelled"
+	ReasonTimeout          = "timeout"
+	ReasonQueueFull        = "queue_full"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+	requeueTimeout   = 5 * time.Second
+)
+
+// DeadLetter is a stream event that could not be delivered to the client.
+type DeadLetter struct {
+	ID          uuid.UUID          `json:"id"`
+	SessionID   *uuid.UUID         `json:"session_id,omitempty"`
+	UserID      *uuid.UUID         `json:"user_id,omitempty"`
+	EventID     string             `json:"event_id"`
+	EventType   string             `json:"event_type"`
+	Reason      string             `json:"reason"`
+	Event       models.StreamEvent `json:"event"`
+	Status      Status             `json:"status"`
+	Attempts    int                `json:"attempts"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	DeliveredAt *time.Time         `json:"delivered_at,omitempty"`
+}
+
+type Filter struct {
+	Status    Status     // empty for every status
+	SessionID *uuid.UUID // nil for every session
+	Limit     int
+	Offset    int
+}
+
+type Service interface {
+	// Record stores an event that could not be delivered. sessionID and userID may be uuid.Nil
+	// when the event was not sent on behalf of a known session or user.
+	Record(ctx context.Context, sessionID, userID uuid.UUID, reason string, event models.StreamEvent) error
+	// Redeliver hands the pending events of a session to deliver, oldest first. When deliver
+	// fails, that event and the ones after it stay pending and the error is returned.
+	Redeliver(ctx context.Context, sessionID uuid.UUID, deliver func(models.StreamEvent) error) error
+	List(ctx context.Context, filter Filter) ([]DeadLetter, error)
+	Get(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+	// Replay queues an event again, it is delivered on the next connection to its session.
+	Replay(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+	Discard(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+}
+
+type ServiceImpl struct {
+	repo   Repository
+	logger *zap.Logger
+}
+
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *ServiceImpl) Record(ctx context.Context, sessionID, userID uuid.UUID, reason string, event models.StreamEvent) error {
+	letter := &DeadLetter{
+		SessionID: nilIfZero(sessionID),
+		UserID:    nilIfZero(userID),
+		EventID:   event.EventID,
+		EventType: event.Type,
+		Reason:    reason,
+		Event:     event,
+	}
+	if letter.EventID == "" {
+		letter.EventID = uuid.NewString()
+	}
+	if err := s.repo.Save(ctx, letter); err != nil {
+		return fmt.Errorf("failed to record dead letter: %w", err)
+	}
+	return nil
+}
+
+func (s *ServiceImpl) Redeliver(ctx context.Context, sessionID uuid.UUID, deliver func(models.StreamEvent) error) error {
+	// Taking the events first keeps a second reconnect of the session from writing them too
+	letters, err := s.repo.TakePending(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to take pending events of session %s: %w", sessionID, err)
+	}
+	if len(letters) > 0 {
+		s.logger.Info("Re-delivering dead-lettered stream events",
+			zap.String("session_id", sessionID.String()),
+			zap.Int("count", len(letters)))
+	}
+	for i, l := range letters {
+		if err := deliver(l.Event); err != nil {
+			s.requeue(ctx, sessionID, letters[i:])
+			return fmt.Errorf("failed to re-deliver event %s of session %s: %w", l.EventID, sessionID, err)
+		}
+	}
+	return nil
+}
+
+// requeue puts letters back to pending for the next connection. The write usually failed
+// because the client went away, so the request context is not waited on.
+func (s *ServiceImpl) requeue(ctx context.Context, sessionID uuid.UUID, letters []DeadLetter) {
+	ids := make([]uuid.UUID, len(letters))
+	for i, l := range letters {
+		ids[i] = l.ID
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), requeueTimeout)
+	defer cancel()
+	if err := s.repo.Requeue(ctx, ids); err != nil {
+		s.logger.Error("Failed to put undelivered dead letters back to pending",
+			zap.String("session_id", sessionID.String()),
+			zap.Int("count", len(ids)),
+			zap.Error(err))
+	}
+}
+
+func (s *ServiceImpl) List(ctx context.Context, filter Filter) ([]DeadLetter, error) {
+	switch filter.Status {
+	case "", StatusPending, StatusDelivered, StatusDiscarded:
+	default:
+		return nil, fmt.Errorf("unknown dead letter status %q: %w", filter.Status, models.ErrBadRequest)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	filter.Limit = min(filter.Limit, maxListLimit)
+	filter.Offset = max(filter.Offset, 0)
+
+	letters, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+func (s *ServiceImpl) Get(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	letter, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter %s: %w", id, err)
+	}
+	return letter, nil
+}
+
+func (s *ServiceImpl) Replay(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	letter, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter %s: %w", id, err)
+	}
+	if letter.SessionID == nil {
+		return nil, fmt.Errorf("dead letter %s has no session to replay it to: %w", id, models.ErrBadRequest)
+	}
+	letter, err = s.repo.SetStatus(ctx, id, StatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue dead letter %s for replay: %w", id, err)
+	}
+	return letter, nil
+}
+
+func (s *ServiceImpl) Discard(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	letter, err := s.repo.SetStatus(ctx, id, StatusDiscarded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discard dead letter %s: %w", id, err)
+	}
+	return letter, nil
+}
+
+func nilIfZero(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,221 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Save(ctx context.Context, letter *DeadLetter) error {
+	args := m.Called(ctx, letter)
+	return args.Error(0)
+}
+
+func (m *MockRepository) List(ctx context.Context, filter Filter) ([]DeadLetter, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DeadLetter), args.Error(1)
+}
+
+func (m *MockRepository) Get(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DeadLetter), args.Error(1)
+}
+
+func (m *MockRepository) TakePending(ctx context.Context, sessionID uuid.UUID) ([]DeadLetter, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]DeadLetter), args.Error(1)
+}
+
+func (m *MockRepository) Requeue(ctx context.Context, ids []uuid.UUID) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetStatus(ctx context.Context, id uuid.UUID, status Status) (*DeadLetter, error) {
+	args := m.Called(ctx, id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*DeadLetter), args.Error(1)
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+	event := models.StreamEvent{Type: models.EventTypeChunk, EventID: "evt-1", Data: "hello"}
+
+	t.Run("stores the event under its session", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Save", ctx, mock.MatchedBy(func(l *DeadLetter) bool {
+			return *l.SessionID == sessionID && l.UserID == nil && l.EventID == "evt-1" &&
+				l.EventType == models.EventTypeChunk && l.Reason == ReasonTimeout
+		})).Return(nil)
+
+		err := NewService(repo, zap.NewNop()).Record(ctx, sessionID, uuid.Nil, ReasonTimeout, event)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("events without an id get one", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Save", ctx, mock.MatchedBy(func(l *DeadLetter) bool { return l.EventID != "" })).Return(nil)
+
+		err := NewService(repo, zap.NewNop()).Record(ctx, sessionID, uuid.Nil, ReasonContextCancelled, models.StreamEvent{Type: models.EventTypeChunk})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Save", ctx, mock.Anything).Return(errors.New("db down"))
+
+		err := NewService(repo, zap.NewNop()).Record(ctx, sessionID, uuid.Nil, ReasonTimeout, event)
+		assert.Error(t, err)
+	})
+}
+
+func TestRedeliver(t *testing.T) {
+	ctx := context.Background()
+	sessionID := uuid.New()
+	letters := []DeadLetter{
+		{ID: uuid.New(), EventID: "a", Event: models.StreamEvent{EventID: "a"}, CreatedAt: time.Now().Add(-2 * time.Minute)},
+		{ID: uuid.New(), EventID: "b", Event: models.StreamEvent{EventID: "b"}, CreatedAt: time.Now().Add(-time.Minute)},
+		{ID: uuid.New(), EventID: "c", Event: models.StreamEvent{EventID: "c"}, CreatedAt: time.Now()},
+	}
+
+	t.Run("delivers the events oldest first", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("TakePending", ctx, sessionID).Return(letters, nil)
+
+		var delivered []string
+		err := NewService(repo, zap.NewNop()).Redeliver(ctx, sessionID, func(e models.StreamEvent) error {
+			delivered = append(delivered, e.EventID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, delivered)
+		repo.AssertNotCalled(t, "Requeue", mock.Anything, mock.Anything)
+	})
+
+	t.Run("events that could not be written stay pending", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("TakePending", ctx, sessionID).Return(letters, nil)
+		repo.On("Requeue", mock.Anything, []uuid.UUID{letters[1].ID, letters[2].ID}).Return(nil)
+
+		writeErr := errors.New("broken pipe")
+		err := NewService(repo, zap.NewNop()).Redeliver(ctx, sessionID, func(e models.StreamEvent) error {
+			if e.EventID == "b" {
+				return writeErr
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, writeErr)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("requeues after the client went away", func(t *testing.T) {
+		gone, cancel := context.WithCancel(ctx)
+		repo := new(MockRepository)
+		repo.On("TakePending", gone, sessionID).Return(letters, nil)
+		repo.On("Requeue", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), mock.Anything).Return(nil)
+
+		err := NewService(repo, zap.NewNop()).Redeliver(gone, sessionID, func(models.StreamEvent) error {
+			cancel()
+			return context.Canceled
+		})
+		assert.Error(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("take error", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("TakePending", ctx, sessionID).Return(nil, errors.New("db down"))
+
+		err := NewService(repo, zap.NewNop()).Redeliver(ctx, sessionID, func(models.StreamEvent) error {
+			t.Fatal("nothing to deliver")
+			return nil
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestList_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown status", func(t *testing.T) {
+		repo := new(MockRepository)
+		_, err := NewService(repo, zap.NewNop()).List(ctx, Filter{Status: "lost"})
+		assert.ErrorIs(t, err, models.ErrBadRequest)
+		repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+	})
+
+	t.Run("limit defaults and caps", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("List", ctx, Filter{Limit: defaultListLimit}).Return([]DeadLetter{}, nil).Once()
+		repo.On("List", ctx, Filter{Status: StatusPending, Limit: maxListLimit}).Return([]DeadLetter{}, nil).Once()
+
+		s := NewService(repo, zap.NewNop())
+		_, err := s.List(ctx, Filter{Offset: -3})
+		require.NoError(t, err)
+		_, err = s.List(ctx, Filter{Status: StatusPending, Limit: 10_000})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New()
+	sessionID := uuid.New()
+
+	t.Run("queues the event again", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Get", ctx, id).Return(&DeadLetter{ID: id, SessionID: &sessionID, Status: StatusDelivered}, nil)
+		repo.On("SetStatus", ctx, id, StatusPending).Return(&DeadLetter{ID: id, SessionID: &sessionID, Status: StatusPending}, nil)
+
+		letter, err := NewService(repo, zap.NewNop()).Replay(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, letter.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("events without a session cannot be replayed", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Get", ctx, id).Return(&DeadLetter{ID: id}, nil)
+
+		_, err := NewService(repo, zap.NewNop()).Replay(ctx, id)
+		assert.ErrorIs(t, err, models.ErrBadRequest)
+		repo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Get", ctx, id).Return(nil, models.ErrNotFound)
+
+		_, err := NewService(repo, zap.NewNop()).Replay(ctx, id)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+}
@@ -0,0 +1,101 @@
+package deadletter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+type stubService struct {
+	lastFilter Filter
+	letter     *DeadLetter
+	err        error
+}
+
+func (s *stubService) Record(context.Context, uuid.UUID, uuid.UUID, string, models.StreamEvent) error {
+	return nil
+}
+
+func (s *stubService) Redeliver(context.Context, uuid.UUID, func(models.StreamEvent) error) error {
+	return nil
+}
+
+func (s *stubService) List(_ context.Context, filter Filter) ([]DeadLetter, error) {
+	s.lastFilter = filter
+	return nil, s.err
+}
+
+func (s *stubService) Get(context.Context, uuid.UUID) (*DeadLetter, error) { return s.letter, s.err }
+
+func (s *stubService) Replay(context.Context, uuid.UUID) (*DeadLetter, error) {
+	return s.letter, s.err
+}
+
+func (s *stubService) Discard(context.Context, uuid.UUID) (*DeadLetter, error) {
+	return s.letter, s.err
+}
+
+func newTestRouter(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/dead-letters", h.List)
+	r.GET("/admin/dead-letters/:id", h.Get)
+	r.POST("/admin/dead-letters/:id/replay", h.Replay)
+	r.POST("/admin/dead-letters/:id/discard", h.Discard)
+	return r
+}
+
+func serve(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestList_Params(t *testing.T) {
+	svc := &stubService{}
+	r := newTestRouter(NewHandler(svc, zap.NewNop()))
+	sessionID := uuid.New()
+
+	w := serve(r, http.MethodGet, "/admin/dead-letters?status=pending&session_id="+sessionID.String()+"&limit=20&offset=40")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"dead_letters":[]}`, w.Body.String())
+	assert.Equal(t, StatusPending, svc.lastFilter.Status)
+	assert.Equal(t, sessionID, *svc.lastFilter.SessionID)
+	assert.Equal(t, 20, svc.lastFilter.Limit)
+	assert.Equal(t, 40, svc.lastFilter.Offset)
+
+	w = serve(r, http.MethodGet, "/admin/dead-letters?session_id=nope")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandler_Errors(t *testing.T) {
+	id := uuid.New().String()
+	tests := []struct {
+		name string
+		err  error
+		path string
+		want int
+	}{
+		{name: "invalid id", path: "/admin/dead-letters/nope/replay", want: http.StatusBadRequest},
+		{name: "not found", err: models.ErrNotFound, path: "/admin/dead-letters/" + id + "/discard", want: http.StatusNotFound},
+		{name: "no session", err: models.ErrBadRequest, path: "/admin/dead-letters/" + id + "/replay", want: http.StatusBadRequest},
+		{name: "replayed", path: "/admin/dead-letters/" + id + "/replay", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stubService{err: tt.err, letter: &DeadLetter{Status: StatusPending}}
+			w := serve(newTestRouter(NewHandler(svc, zap.NewNop())), http.MethodPost, tt.path)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
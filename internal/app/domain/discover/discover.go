@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -21,9 +20,10 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/domain"
 	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/poi"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/quota"
 	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
 
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
 	genai "google.golang.org/genai"
 
 	"github.com/FACorreiaa/go-templui/internal/app/models"
@@ -34,19 +34,12 @@ type DiscoverHandlers struct {
 	poiRepo    poi.Repository
 	chatRepo   llmchat.Repository
 	llmService llmchat.LlmInteractiontService
-	aiClient   *generativeAI.LLMChatClient
+	aiClient   llmprovider.Provider
 	logger     *zap.Logger
 	llmLogger  *llmchat.LLMLogger
 }
 
-func NewDiscoverHandlers(base *domain.BaseHandler, poiRepo poi.Repository, chatRepo llmchat.Repository, llmService llmchat.LlmInteractiontService, logger *zap.Logger) *DiscoverHandlers {
-	// Initialize AI client for discover search
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	aiClient, err := generativeAI.NewLLMChatClient(context.Background(), apiKey)
-	if err != nil {
-		logger.Error("Failed to initialize AI client", zap.Any("error", err))
-	}
-
+func NewDiscoverHandlers(base *domain.BaseHandler, poiRepo poi.Repository, chatRepo llmchat.Repository, llmService llmchat.LlmInteractiontService, aiClient llmprovider.Provider, logger *zap.Logger) *DiscoverHandlers {
 	// Initialize LLM logger
 	llmLogger := llmchat.NewLLMLogger(logger, chatRepo)
 
@@ -250,9 +243,12 @@ func (h *DiscoverHandlers) Search(c *gin.Context) {
 		c.HTML(http.StatusBadRequest, "", `<div class="text-red-500 text-center py-8">Please enter a location</div>`)
 		return
 	}
+	if !quota.ChargeSearch(c) {
+		return
+	}
 
 	// Call LLM with discover search prompt
-	prompt := llmchat.GetDiscoverSearchPrompt(query, location)
+	prompt := llmchat.GetDiscoverSearchPrompt(userIDStr, query, location)
 	h.logger.Info("Calling LLM for discover search", zap.String("query", query), zap.String("location", location))
 
 	// Prepare logging configuration
@@ -268,18 +264,20 @@ func (h *DiscoverHandlers) Search(c *gin.Context) {
 	}
 
 	logConfig := llmchat.LoggingConfig{
-		UserID:      userUUID,
-		SessionID:   sessionID,
-		Intent:      "discover",
-		Prompt:      prompt,
-		CityName:    location,
-		ModelName:   "gemini-2.0-flash",
-		Provider:    "google",
-		Temperature: genai.Ptr[float32](0.5),
-		IsStreaming: false,
-	}
-
-	response, err := h.aiClient.GenerateResponse(ctx, prompt, &genai.GenerateContentConfig{
+		UserID:        userUUID,
+		SessionID:     sessionID,
+		Intent:        "discover",
+		Prompt:        prompt.Text,
+		PromptID:      prompt.ID,
+		PromptVersion: prompt.Version,
+		CityName:      location,
+		ModelName:     h.aiClient.Model(),
+		Provider:      h.aiClient.Name(),
+		Temperature:   genai.Ptr[float32](0.5),
+		IsStreaming:   false,
+	}
+
+	response, err := h.aiClient.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
 		Temperature: genai.Ptr[float32](0.5), // Balanced temperature for diverse but consistent results
 	})
 
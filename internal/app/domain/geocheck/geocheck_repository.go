@@ -0,0 +1,165 @@
+package geocheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	// LocatePoints places every point relative to the city. A city without a bounding box or
+	// centre leaves the matching fields nil.
+	LocatePoints(ctx context.Context, cityID uuid.UUID, points []Point) ([]Placement, error)
+	// StoredLocation returns the coordinates of a POI of the city already stored under name.
+	StoredLocation(ctx context.Context, cityID uuid.UUID, name string) (Point, bool, error)
+	SaveReport(ctx context.Context, report Report) error
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) LocatePoints(ctx context.Context, cityID uuid.UUID, points []Point) ([]Placement, error) {
+	ctx, span := otel.Tracer("GeoCheckRepository").Start(ctx, "LocatePoints", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.Int("points.count", len(points)),
+	))
+	defer span.End()
+
+	lats := make([]float64, len(points))
+	lons := make([]float64, len(points))
+	for i, p := range points {
+		lats[i], lons[i] = p.Lat, p.Lon
+	}
+
+	query := `
+		SELECT
+			p.idx,
+			CASE WHEN c.bounding_box IS NULL THEN NULL
+			     ELSE ST_Covers(c.bounding_box, ST_SetSRID(ST_MakePoint(p.lon, p.lat), 4326)) END,
+			CASE WHEN c.center_location IS NULL THEN NULL
+			     ELSE ST_Distance(c.center_location::geography, ST_SetSRID(ST_MakePoint(p.lon, p.lat), 4326)::geography) END
+		FROM cities c
+		CROSS JOIN unnest($2::float8[], $3::float8[]) WITH ORDINALITY AS p(lat, lon, idx)
+		WHERE c.id = $1
+		ORDER BY p.idx`
+
+	rows, err := r.pgpool.Query(ctx, query, cityID, lats, lons)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to locate points")
+		return nil, fmt.Errorf("failed to locate points in city: %w", err)
+	}
+	defer rows.Close()
+
+	placements := make([]Placement, len(points))
+	for rows.Next() {
+		var idx int
+		var p Placement
+		if err := rows.Scan(&idx, &p.InBoundingBox, &p.DistanceFromCenterM); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan point placement: %w", err)
+		}
+		placements[idx-1] = p
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate point placements: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Points located")
+	return placements, nil
+}
+
+func (r *RepositoryImpl) StoredLocation(ctx context.Context, cityID uuid.UUID, name string) (Point, bool, error) {
+	ctx, span := otel.Tracer("GeoCheckRepository").Start(ctx, "StoredLocation", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.String("poi.name", name),
+	))
+	defer span.End()
+
+	// Curated and enriched POIs first, earlier LLM answers last
+	query := `
+		SELECT ST_Y(location), ST_X(location)
+		FROM points_of_interest
+		WHERE city_id = $1 AND LOWER(name) = LOWER($2) AND location IS NOT NULL
+		ORDER BY (source = 'loci_ai'), updated_at DESC
+		LIMIT 1`
+
+	var p Point
+	err := r.pgpool.QueryRow(ctx, query, cityID, name).Scan(&p.Lat, &p.Lon)
+	if errors.Is(err, pgx.ErrNoRows) {
+		span.SetStatus(codes.Ok, "No stored location")
+		return Point{}, false, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query stored location")
+		return Point{}, false, fmt.Errorf("failed to query stored location: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Stored location found")
+	return p, true, nil
+}
+
+func (r *RepositoryImpl) SaveReport(ctx context.Context, report Report) error {
+	ctx, span := otel.Tracer("GeoCheckRepository").Start(ctx, "SaveReport", trace.WithAttributes(
+		attribute.String("part", report.Part),
+		attribute.Int("total", report.Total),
+		attribute.Int("accepted", report.Accepted),
+	))
+	defer span.End()
+
+	reasons, err := json.Marshal(report.Reasons)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reasons: %w", err)
+	}
+	rejected, err := json.Marshal(report.Rejected)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejected POIs: %w", err)
+	}
+
+	query := `
+		INSERT INTO poi_geo_validations (
+			llm_interaction_id, city_id, part, total, accepted, flagged, dropped, regeocoded, reasons, rejected_pois
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = r.pgpool.Exec(ctx, query,
+		nilIfZero(report.LlmInteractionID), nilIfZero(report.CityID), report.Part,
+		report.Total, report.Accepted, report.Flagged, report.Dropped, report.Regeocoded,
+		reasons, rejected)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to insert report")
+		return fmt.Errorf("failed to insert geo validation report: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Report saved")
+	return nil
+}
+
+func nilIfZero(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,338 @@
+// Package geocheck validates the coordinates of LLM-generated POIs before they are saved. Each
+// POI is checked against its city's bounding box and centre in PostGIS, for plausible
+// coordinates and for coordinates repeated within the batch. POIs failing a check are flagged,
+// dropped or re-geocoded depending on configuration, and every batch is recorded so rejection
+// rates can be followed per prompt.
+package geocheck
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Action is what happens to a POI that failed a check.
+type Action string
+
+const (
+	// ActionFlag keeps the POI as it is.
+	ActionFlag Action = "flag"
+	// ActionDrop removes the POI from the batch.
+	ActionDrop Action = "drop"
+	// ActionRegeocode looks the POI up again and drops it when no valid location is found.
+	ActionRegeocode Action = "regeocode"
+)
+
+// ParseAction returns the action named s.
+func ParseAction(s string) (Action, error) {
+	switch a := Action(s); a {
+	case ActionFlag, ActionDrop, ActionRegeocode:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown geo check action %q", s)
+	}
+}
+
+// Reasons a POI fails a check.
+const (
+	ReasonInvalidCoordinates   = "invalid_coordinates"
+	ReasonOutsideCity          = "outside_city"
+	ReasonTooFar               = "too_far"
+	ReasonDuplicateCoordinates = "duplicate_coordinates"
+)
+
+const (
+	DefaultMaxDistanceKm = 50.0
+
+	// duplicatePrecision is the number of decimals coordinates are compared at, about 11m.
+	duplicatePrecision = 4
+)
+
+// Config tunes the checks.
+type Config struct {
+	MaxDistanceKm float64 // distance from the city centre past which a POI fails, zero disables
+	OnFailure     Action
+}
+
+func DefaultConfig() Config {
+	return Config{MaxDistanceKm: DefaultMaxDistanceKm, OnFailure: ActionRegeocode}
+}
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Placement is where a point lies relative to its city. Nil fields mean the city has no
+// bounding box or centre to check against.
+type Placement struct {
+	InBoundingBox       *bool
+	DistanceFromCenterM *float64
+}
+
+// Source identifies the LLM answer a batch of POIs came from.
+type Source struct {
+	Part             string // response part, e.g. general_pois, itinerary, poi_details
+	LlmInteractionID uuid.UUID
+}
+
+// Rejection is a POI that failed at least one check.
+type Rejection struct {
+	Name      string   `json:"name"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Reasons   []string `json:"reasons"`
+	Action    Action   `json:"action"`
+}
+
+// Report is the outcome of checking one batch.
+type Report struct {
+	Source
+	CityID     uuid.UUID
+	Total      int
+	Accepted   int
+	Flagged    int
+	Dropped    int
+	Regeocoded int
+	Reasons    map[string]int
+	Rejected   []Rejection
+}
+
+// Geocoder finds the location of a POI by name.
+type Geocoder interface {
+	Geocode(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (Point, bool, error)
+}
+
+// storedGeocoder re-geocodes from the POIs already stored for the city.
+type storedGeocoder struct {
+	repo Repository
+}
+
+func (g storedGeocoder) Geocode(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (Point, bool, error) {
+	return g.repo.StoredLocation(ctx, cityID, poi.Name)
+}
+
+type Service interface {
+	// Check returns the POIs that may be saved, with re-geocoded coordinates where needed. It
+	// never fails: when the city cannot be looked up only the checks that need no database run.
+	Check(ctx context.Context, cityID uuid.UUID, pois []models.POIDetailedInfo, src Source) ([]models.POIDetailedInfo, Report)
+}
+
+type ServiceImpl struct {
+	repo     Repository
+	geocoder Geocoder
+	config   Config
+	logger   *zap.Logger
+}
+
+func NewService(repo Repository, config Config, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:     repo,
+		geocoder: storedGeocoder{repo: repo},
+		config:   config,
+		logger:   logger,
+	}
+}
+
+// WithGeocoder replaces the default geocoder, which only knows the POIs already stored.
+func (s *ServiceImpl) WithGeocoder(geocoder Geocoder) *ServiceImpl {
+	s.geocoder = geocoder
+	return s
+}
+
+func (s *ServiceImpl) Check(ctx context.Context, cityID uuid.UUID, pois []models.POIDetailedInfo, src Source) ([]models.POIDetailedInfo, Report) {
+	ctx, span := otel.Tracer("GeoCheckService").Start(ctx, "Check", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.String("part", src.Part),
+		attribute.Int("pois.count", len(pois)),
+	))
+	defer span.End()
+
+	report := Report{Source: src, CityID: cityID, Total: len(pois), Reasons: map[string]int{}}
+	if len(pois) == 0 {
+		return pois, report
+	}
+
+	placements := s.locate(ctx, cityID, pois)
+	seen := make(map[[2]float64]bool, len(pois))
+	kept := make([]models.POIDetailedInfo, 0, len(pois))
+	for i, poi := range pois {
+		reasons := s.failures(poi, placements[i], seen)
+		if len(reasons) == 0 {
+			report.Accepted++
+			kept = append(kept, poi)
+			continue
+		}
+		for _, r := range reasons {
+			report.Reasons[r]++
+		}
+
+		action := s.config.OnFailure
+		if onlyDuplicate(reasons) {
+			// A shared location is suspicious but often right: two venues in one building.
+			action = ActionFlag
+		}
+
+		switch action {
+		case ActionFlag:
+			report.Flagged++
+			kept = append(kept, poi)
+		case ActionRegeocode:
+			if fixed, ok := s.regeocode(ctx, cityID, poi); ok {
+				report.Regeocoded++
+				kept = append(kept, fixed)
+			} else {
+				action = ActionDrop
+				report.Dropped++
+			}
+		default:
+			action = ActionDrop
+			report.Dropped++
+		}
+		report.Rejected = append(report.Rejected, Rejection{
+			Name:      poi.Name,
+			Latitude:  poi.Latitude,
+			Longitude: poi.Longitude,
+			Reasons:   reasons,
+			Action:    action,
+		})
+	}
+
+	span.SetAttributes(
+		attribute.Int("pois.accepted", report.Accepted),
+		attribute.Int("pois.dropped", report.Dropped),
+	)
+	if report.Accepted < report.Total {
+		s.logger.Info("LLM POIs failed geographic checks",
+			zap.String("city_id", cityID.String()),
+			zap.String("part", src.Part),
+			zap.Int("total", report.Total),
+			zap.Int("flagged", report.Flagged),
+			zap.Int("dropped", report.Dropped),
+			zap.Int("regeocoded", report.Regeocoded),
+			zap.Any("reasons", report.Reasons))
+	}
+	if err := s.repo.SaveReport(ctx, report); err != nil {
+		s.logger.Warn("Failed to save geo check report", zap.String("part", src.Part), zap.Error(err))
+	}
+
+	return kept, report
+}
+
+// locate places the valid points of the batch in the city. On failure every placement is left
+// empty so that only the checks needing no database apply.
+func (s *ServiceImpl) locate(ctx context.Context, cityID uuid.UUID, pois []models.POIDetailedInfo) []Placement {
+	placements := make([]Placement, len(pois))
+	if cityID == uuid.Nil {
+		return placements
+	}
+
+	var points []Point
+	var index []int
+	for i, poi := range pois {
+		if validCoordinates(poi.Latitude, poi.Longitude) {
+			points = append(points, Point{Lat: poi.Latitude, Lon: poi.Longitude})
+			index = append(index, i)
+		}
+	}
+	if len(points) == 0 {
+		return placements
+	}
+
+	located, err := s.repo.LocatePoints(ctx, cityID, points)
+	if err != nil {
+		s.logger.Warn("Failed to locate POIs in city, checking coordinates only",
+			zap.String("city_id", cityID.String()),
+			zap.Error(err))
+		return placements
+	}
+	for j, p := range located {
+		placements[index[j]] = p
+	}
+	return placements
+}
+
+// failures returns the checks poi fails, recording its coordinates in seen.
+func (s *ServiceImpl) failures(poi models.POIDetailedInfo, placement Placement, seen map[[2]float64]bool) []string {
+	if !validCoordinates(poi.Latitude, poi.Longitude) {
+		return []string{ReasonInvalidCoordinates}
+	}
+
+	var reasons []string
+	if placement.InBoundingBox != nil && !*placement.InBoundingBox {
+		reasons = append(reasons, ReasonOutsideCity)
+	}
+	if s.tooFar(placement) {
+		reasons = append(reasons, ReasonTooFar)
+	}
+
+	key := [2]float64{round(poi.Latitude), round(poi.Longitude)}
+	if seen[key] {
+		reasons = append(reasons, ReasonDuplicateCoordinates)
+	}
+	seen[key] = true
+	return reasons
+}
+
+func (s *ServiceImpl) tooFar(placement Placement) bool {
+	return s.config.MaxDistanceKm > 0 && placement.DistanceFromCenterM != nil &&
+		*placement.DistanceFromCenterM > s.config.MaxDistanceKm*1000
+}
+
+// regeocode looks poi up again and keeps the new location only if it passes the city checks.
+func (s *ServiceImpl) regeocode(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (models.POIDetailedInfo, bool) {
+	if s.geocoder == nil || cityID == uuid.Nil {
+		return poi, false
+	}
+
+	point, ok, err := s.geocoder.Geocode(ctx, cityID, poi)
+	if err != nil {
+		s.logger.Warn("Failed to re-geocode POI", zap.String("name", poi.Name), zap.Error(err))
+		return poi, false
+	}
+	if !ok || !validCoordinates(point.Lat, point.Lon) {
+		return poi, false
+	}
+
+	placements, err := s.repo.LocatePoints(ctx, cityID, []Point{point})
+	if err != nil || len(placements) != 1 {
+		return poi, false
+	}
+	if p := placements[0]; (p.InBoundingBox != nil && !*p.InBoundingBox) || s.tooFar(p) {
+		return poi, false
+	}
+
+	poi.Latitude, poi.Longitude = point.Lat, point.Lon
+	return poi, true
+}
+
+// validCoordinates rejects out-of-range values and (0, 0), the coordinates models fall back to
+// when they do not know.
+func validCoordinates(lat, lon float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lon) {
+		return false
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return false
+	}
+	return lat != 0 || lon != 0
+}
+
+func onlyDuplicate(reasons []string) bool {
+	return len(reasons) == 1 && reasons[0] == ReasonDuplicateCoordinates
+}
+
+func round(v float64) float64 {
+	p := math.Pow(10, duplicatePrecision)
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,190 @@
+package geocheck
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) LocatePoints(ctx context.Context, cityID uuid.UUID, points []Point) ([]Placement, error) {
+	args := m.Called(ctx, cityID, points)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Placement), args.Error(1)
+}
+
+func (m *MockRepository) StoredLocation(ctx context.Context, cityID uuid.UUID, name string) (Point, bool, error) {
+	args := m.Called(ctx, cityID, name)
+	return args.Get(0).(Point), args.Bool(1), args.Error(2)
+}
+
+func (m *MockRepository) SaveReport(ctx context.Context, report Report) error {
+	args := m.Called(ctx, report)
+	return args.Error(0)
+}
+
+func inside(distanceKm float64) Placement {
+	in, d := true, distanceKm*1000
+	return Placement{InBoundingBox: &in, DistanceFromCenterM: &d}
+}
+
+func outside(distanceKm float64) Placement {
+	in, d := false, distanceKm*1000
+	return Placement{InBoundingBox: &in, DistanceFromCenterM: &d}
+}
+
+func poi(name string, lat, lon float64) models.POIDetailedInfo {
+	return models.POIDetailedInfo{Name: name, Latitude: lat, Longitude: lon}
+}
+
+func names(pois []models.POIDetailedInfo) []string {
+	out := make([]string, len(pois))
+	for i, p := range pois {
+		out[i] = p.Name
+	}
+	return out
+}
+
+func newTestService(repo Repository, onFailure Action) *ServiceImpl {
+	return NewService(repo, Config{MaxDistanceKm: 30, OnFailure: onFailure}, zap.NewNop())
+}
+
+var cityID = uuid.MustParse("5c0e7d3a-1b9f-4a43-9b9d-2f4f3f6a1c11")
+
+func TestCheck_DropsPOIsOutsideTheCity(t *testing.T) {
+	repo := new(MockRepository)
+	pois := []models.POIDetailedInfo{
+		poi("Torre de Belém", 38.6916, -9.2160),
+		poi("Atlantic", 38.5, -12.0),
+		poi("Sintra Palace", 38.7976, -9.3906),
+		poi("Null Island", 0, 0),
+	}
+	repo.On("LocatePoints", mock.Anything, cityID, []Point{
+		{Lat: 38.6916, Lon: -9.2160}, {Lat: 38.5, Lon: -12.0}, {Lat: 38.7976, Lon: -9.3906},
+	}).Return([]Placement{inside(6), outside(240), inside(35)}, nil)
+
+	var saved Report
+	repo.On("SaveReport", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(Report)
+	}).Return(nil)
+
+	src := Source{Part: "general_pois", LlmInteractionID: uuid.New()}
+	kept, report := newTestService(repo, ActionDrop).Check(context.Background(), cityID, pois, src)
+
+	assert.Equal(t, []string{"Torre de Belém"}, names(kept))
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 1, report.Accepted)
+	assert.Equal(t, 3, report.Dropped)
+	assert.Equal(t, map[string]int{
+		ReasonOutsideCity:        1,
+		ReasonTooFar:             2,
+		ReasonInvalidCoordinates: 1,
+	}, report.Reasons)
+	require.Len(t, report.Rejected, 3)
+	assert.Equal(t, []string{ReasonOutsideCity, ReasonTooFar}, report.Rejected[0].Reasons)
+	assert.Equal(t, ActionDrop, report.Rejected[0].Action)
+	assert.Equal(t, src, saved.Source)
+	assert.Equal(t, cityID, saved.CityID)
+	repo.AssertExpectations(t)
+}
+
+func TestCheck_FlagKeepsEveryPOI(t *testing.T) {
+	repo := new(MockRepository)
+	pois := []models.POIDetailedInfo{poi("A", 41.15, -8.61), poi("B", 41.15, -7.0)}
+	repo.On("LocatePoints", mock.Anything, cityID, mock.Anything).Return([]Placement{inside(1), outside(135)}, nil)
+	repo.On("SaveReport", mock.Anything, mock.Anything).Return(nil)
+
+	kept, report := newTestService(repo, ActionFlag).Check(context.Background(), cityID, pois, Source{Part: "itinerary"})
+
+	assert.Equal(t, []string{"A", "B"}, names(kept))
+	assert.Equal(t, 1, report.Flagged)
+	assert.Equal(t, 0, report.Dropped)
+}
+
+func TestCheck_DuplicateCoordinatesAreOnlyFlagged(t *testing.T) {
+	repo := new(MockRepository)
+	pois := []models.POIDetailedInfo{
+		poi("Café", 41.14961, -8.61099),
+		poi("Bookshop", 41.14962, -8.61101),
+		poi("Tower", 41.1457, -8.6146),
+	}
+	repo.On("LocatePoints", mock.Anything, cityID, mock.Anything).Return([]Placement{inside(1), inside(1), inside(1)}, nil)
+	repo.On("SaveReport", mock.Anything, mock.Anything).Return(nil)
+
+	kept, report := newTestService(repo, ActionDrop).Check(context.Background(), cityID, pois, Source{Part: "general_pois"})
+
+	assert.Len(t, kept, 3)
+	assert.Equal(t, 2, report.Accepted)
+	assert.Equal(t, 1, report.Flagged)
+	assert.Equal(t, map[string]int{ReasonDuplicateCoordinates: 1}, report.Reasons)
+	assert.Equal(t, "Bookshop", report.Rejected[0].Name)
+}
+
+func TestCheck_RegeocodesFromStoredPOIs(t *testing.T) {
+	repo := new(MockRepository)
+	pois := []models.POIDetailedInfo{poi("Livraria Lello", 40.0, -8.0), poi("Unknown Bar", 40.0, -8.1)}
+	repo.On("LocatePoints", mock.Anything, cityID, []Point{{Lat: 40.0, Lon: -8.0}, {Lat: 40.0, Lon: -8.1}}).
+		Return([]Placement{outside(130), outside(130)}, nil)
+	repo.On("StoredLocation", mock.Anything, cityID, "Livraria Lello").Return(Point{Lat: 41.1469, Lon: -8.6149}, true, nil)
+	repo.On("StoredLocation", mock.Anything, cityID, "Unknown Bar").Return(Point{}, false, nil)
+	repo.On("LocatePoints", mock.Anything, cityID, []Point{{Lat: 41.1469, Lon: -8.6149}}).Return([]Placement{inside(1)}, nil)
+	repo.On("SaveReport", mock.Anything, mock.Anything).Return(nil)
+
+	kept, report := newTestService(repo, ActionRegeocode).Check(context.Background(), cityID, pois, Source{Part: "poi_details"})
+
+	require.Len(t, kept, 1)
+	assert.Equal(t, "Livraria Lello", kept[0].Name)
+	assert.Equal(t, 41.1469, kept[0].Latitude)
+	assert.Equal(t, -8.6149, kept[0].Longitude)
+	assert.Equal(t, 1, report.Regeocoded)
+	assert.Equal(t, 1, report.Dropped)
+	assert.Equal(t, ActionRegeocode, report.Rejected[0].Action)
+	assert.Equal(t, ActionDrop, report.Rejected[1].Action)
+	repo.AssertExpectations(t)
+}
+
+func TestCheck_DatabaseFailureFallsBackToCoordinateChecks(t *testing.T) {
+	repo := new(MockRepository)
+	pois := []models.POIDetailedInfo{poi("A", 41.15, -8.61), poi("B", math.NaN(), 1), poi("C", 91, 0)}
+	repo.On("LocatePoints", mock.Anything, cityID, mock.Anything).Return(nil, errors.New("connection refused"))
+	repo.On("SaveReport", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+	kept, report := newTestService(repo, ActionDrop).Check(context.Background(), cityID, pois, Source{Part: "general_pois"})
+
+	assert.Equal(t, []string{"A"}, names(kept))
+	assert.Equal(t, 2, report.Reasons[ReasonInvalidCoordinates])
+}
+
+func TestCheck_EmptyBatchIsNotRecorded(t *testing.T) {
+	repo := new(MockRepository)
+
+	kept, report := newTestService(repo, ActionDrop).Check(context.Background(), cityID, nil, Source{Part: "general_pois"})
+
+	assert.Empty(t, kept)
+	assert.Equal(t, 0, report.Total)
+	repo.AssertNotCalled(t, "SaveReport", mock.Anything, mock.Anything)
+}
+
+func TestParseAction(t *testing.T) {
+	a, err := ParseAction("regeocode")
+	require.NoError(t, err)
+	assert.Equal(t, ActionRegeocode, a)
+
+	_, err = ParseAction("ignore")
+	assert.Error(t, err)
+}
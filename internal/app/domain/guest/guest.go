@@ -0,0 +1,142 @@
+package guest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Handler serves the favourites and lists of visitors without an account.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Summary handles GET /guest: what signing up would keep.
+func (h *Handler) Summary(c *gin.Context) {
+	guestID, ok := h.guestID(c)
+	if !ok {
+		return
+	}
+	summary, err := h.service.Summary(c.Request.Context(), guestID)
+	if err != nil {
+		h.respondError(c, "Failed to get guest summary", err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// AddFavorite handles POST /guest/favorites/:id, with llm=true for LLM-suggested POIs.
+func (h *Handler) AddFavorite(c *gin.Context) {
+	guestID, ok := h.guestID(c)
+	if !ok {
+		return
+	}
+	poiID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid POI id"})
+		return
+	}
+	isLLM, _ := strconv.ParseBool(c.Query("llm"))
+	if err := h.service.AddFavorite(c.Request.Context(), guestID, poiID, isLLM); err != nil {
+		h.respondError(c, "Failed to add guest favourite", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RemoveFavorite handles DELETE /guest/favorites/:id.
+func (h *Handler) RemoveFavorite(c *gin.Context) {
+	guestID, ok := h.guestID(c)
+	if !ok {
+		return
+	}
+	poiID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid POI id"})
+		return
+	}
+	if err := h.service.RemoveFavorite(c.Request.Context(), guestID, poiID); err != nil {
+		h.respondError(c, "Failed to remove guest favourite", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateList handles POST /guest/lists.
+func (h *Handler) CreateList(c *gin.Context) {
+	guestID, ok := h.guestID(c)
+	if !ok {
+		return
+	}
+	var req models.CreateGuestListRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	list, err := h.service.CreateList(c.Request.Context(), guestID, req)
+	if err != nil {
+		h.respondError(c, "Failed to create guest list", err)
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+// AddListItem handles POST /guest/lists/:id/items.
+func (h *Handler) AddListItem(c *gin.Context) {
+	guestID, ok := h.guestID(c)
+	if !ok {
+		return
+	}
+	listID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid list id"})
+		return
+	}
+	var req models.AddGuestListItemRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if err := h.service.AddListItem(c.Request.Context(), guestID, listID, req); err != nil {
+		h.respondError(c, "Failed to add guest list item", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// guestID returns the guest of the request. Signed-in users have their own favourites and
+// lists and are turned away.
+func (h *Handler) guestID(c *gin.Context) (uuid.UUID, bool) {
+	if guestID, ok := middleware.GetGuestFromContext(c); ok {
+		return guestID, true
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "only available to guests"})
+	return uuid.Nil, false
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "favourite or list not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
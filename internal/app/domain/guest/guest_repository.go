@@ -0,0 +1,290 @@
+package guest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	// Touch records the guest, or the time it was last seen when it is known already.
+	Touch(ctx context.Context, guestID uuid.UUID) error
+	// Summary returns the sessions, favourites and lists of the guest, empty for unknown guests.
+	Summary(ctx context.Context, guestID uuid.UUID) (*models.GuestSummary, error)
+	AddFavorite(ctx context.Context, guestID, poiID uuid.UUID, isLLM bool) error
+	// RemoveFavorite returns models.ErrNotFound when the guest did not save the POI.
+	RemoveFavorite(ctx context.Context, guestID, poiID uuid.UUID) error
+	CreateList(ctx context.Context, guestID uuid.UUID, req models.CreateGuestListRequest) (*models.GuestList, error)
+	// AddListItem appends an item to a list of the guest, returning models.ErrNotFound when the
+	// list is not the guest's. Items already in the list are left as they are.
+	AddListItem(ctx context.Context, guestID, listID uuid.UUID, req models.AddGuestListItemRequest) error
+	// Claim moves the sessions, their interactions, the favourites and the lists of the guest
+	// onto the user in one transaction. A guest that is unknown or already claimed moves nothing.
+	Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+	// ClaimInTx is Claim within a transaction of the caller, so that the user it moves the
+	// guest onto can be created in the same one.
+	ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) Touch(ctx context.Context, guestID uuid.UUID) error {
+	_, err := r.pgpool.Exec(ctx, `
+		INSERT INTO guests (id) VALUES ($1)
+		ON CONFLICT (id) DO UPDATE SET last_seen_at = NOW()`, guestID)
+	if err != nil {
+		return fmt.Errorf("failed to record guest %s: %w", guestID, err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) Summary(ctx context.Context, guestID uuid.UUID) (*models.GuestSummary, error) {
+	ctx, span := otel.Tracer("GuestRepository").Start(ctx, "Summary", trace.WithAttributes(
+		attribute.String("guest_id", guestID.String()),
+	))
+	defer span.End()
+
+	summary := &models.GuestSummary{GuestID: guestID, Favorites: []models.GuestFavorite{}, Lists: []models.GuestList{}}
+	err := r.pgpool.QueryRow(ctx, `SELECT COUNT(*) FROM chat_sessions WHERE guest_id = $1`, guestID).Scan(&summary.Sessions)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count sessions")
+		return nil, fmt.Errorf("failed to count guest sessions: %w", err)
+	}
+
+	rows, err := r.pgpool.Query(ctx, `
+		SELECT poi_id, is_llm, added_at FROM guest_favorites
+		WHERE guest_id = $1 ORDER BY added_at DESC`, guestID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query favourites")
+		return nil, fmt.Errorf("failed to query guest favourites: %w", err)
+	}
+	for rows.Next() {
+		var f models.GuestFavorite
+		if err := rows.Scan(&f.POIID, &f.IsLLM, &f.AddedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan guest favourite: %w", err)
+		}
+		summary.Favorites = append(summary.Favorites, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating guest favourites: %w", err)
+	}
+
+	rows, err = r.pgpool.Query(ctx, `
+		SELECT id, name, COALESCE(description, ''), item_count, created_at FROM lists
+		WHERE guest_id = $1 ORDER BY created_at DESC`, guestID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query lists")
+		return nil, fmt.Errorf("failed to query guest lists: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l models.GuestList
+		if err := rows.Scan(&l.ID, &l.Name, &l.Description, &l.ItemCount, &l.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan guest list: %w", err)
+		}
+		summary.Lists = append(summary.Lists, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating guest lists: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Guest summary retrieved")
+	return summary, nil
+}
+
+func (r *RepositoryImpl) AddFavorite(ctx context.Context, guestID, poiID uuid.UUID, isLLM bool) error {
+	_, err := r.pgpool.Exec(ctx, `
+		INSERT INTO guest_favorites (guest_id, poi_id, is_llm) VALUES ($1, $2, $3)
+		ON CONFLICT (guest_id, poi_id) DO NOTHING`, guestID, poiID, isLLM)
+	if err != nil {
+		return fmt.Errorf("failed to add guest favourite: %w", err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) RemoveFavorite(ctx context.Context, guestID, poiID uuid.UUID) error {
+	tag, err := r.pgpool.Exec(ctx, `DELETE FROM guest_favorites WHERE guest_id = $1 AND poi_id = $2`, guestID, poiID)
+	if err != nil {
+		return fmt.Errorf("failed to remove guest favourite: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("favourite %s: %w", poiID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) CreateList(ctx context.Context, guestID uuid.UUID, req models.CreateGuestListRequest) (*models.GuestList, error) {
+	list := models.GuestList{ID: uuid.New(), Name: req.Name, Description: req.Description}
+	err := r.pgpool.QueryRow(ctx, `
+		INSERT INTO lists (id, guest_id, name, description)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING created_at`, list.ID, guestID, list.Name, list.Description).Scan(&list.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create guest list: %w", err)
+	}
+	return &list, nil
+}
+
+func (r *RepositoryImpl) AddListItem(ctx context.Context, guestID, listID uuid.UUID, req models.AddGuestListItemRequest) error {
+	var owned bool
+	err := r.pgpool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM lists WHERE id = $1 AND guest_id = $2)`, listID, guestID).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to look up guest list: %w", err)
+	}
+	if !owned {
+		return fmt.Errorf("list %s: %w", listID, models.ErrNotFound)
+	}
+
+	// Only POIs set poi_id, which references points_of_interest
+	var poiID *uuid.UUID
+	if req.ContentType == models.ContentTypePOI {
+		poiID = &req.ItemID
+	}
+	_, err = r.pgpool.Exec(ctx, `
+		INSERT INTO list_items (list_id, item_id, content_type, position, notes, created_at, updated_at, poi_id)
+		SELECT $1, $2, $3, COALESCE(MAX(position), 0) + 1, NULLIF($4, ''), NOW(), NOW(), $5
+		FROM list_items WHERE list_id = $1
+		ON CONFLICT DO NOTHING`, listID, req.ItemID, req.ContentType, req.Notes, poiID)
+	if err != nil {
+		return fmt.Errorf("failed to add guest list item: %w", err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	ctx, span := otel.Tracer("GuestRepository").Start(ctx, "Claim", trace.WithAttributes(
+		attribute.String("guest_id", guestID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	tx, err := r.pgpool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to begin transaction")
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.logger.Error("transaction rollback failed", zap.Error(err))
+		}
+	}()
+
+	claim, err := r.ClaimInTx(ctx, tx, guestID, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to claim guest")
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to commit claim")
+		return nil, fmt.Errorf("failed to commit guest claim: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int("claim.sessions", claim.Sessions),
+		attribute.Int("claim.favorites", claim.Favorites),
+		attribute.Int("claim.lists", claim.Lists),
+	)
+	span.SetStatus(codes.Ok, "Guest claimed")
+	return claim, nil
+}
+
+func (r *RepositoryImpl) ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	ctx, span := otel.Tracer("GuestRepository").Start(ctx, "ClaimInTx", trace.WithAttributes(
+		attribute.String("guest_id", guestID.String()),
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	claim := &models.GuestClaim{}
+	tag, err := tx.Exec(ctx, `
+		UPDATE guests SET claimed_by = $2, claimed_at = NOW()
+		WHERE id = $1 AND claimed_by IS NULL`, guestID, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to claim guest")
+		return nil, fmt.Errorf("failed to claim guest: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		span.SetStatus(codes.Ok, "Nothing to claim")
+		return claim, nil
+	}
+
+	steps := []struct {
+		name  string
+		query string
+		count *int
+	}{
+		{"interactions", `
+			UPDATE llm_interactions SET user_id = $2
+			WHERE user_id IS NULL AND session_id IN (SELECT id FROM chat_sessions WHERE guest_id = $1)`, &claim.Interactions},
+		// Sessions read back with a profile, so they take the user's default one
+		{"sessions", `
+			UPDATE chat_sessions SET
+				user_id = $2,
+				guest_id = NULL,
+				profile_id = COALESCE(profile_id, (SELECT id FROM user_preference_profiles WHERE user_id = $2 AND is_default LIMIT 1)),
+				updated_at = NOW()
+			WHERE guest_id = $1`, &claim.Sessions},
+		// Favourites whose POI has gone since are dropped, and those the user already has are kept
+		{"favourites", `
+			INSERT INTO user_favorite_pois (user_id, poi_id, added_at)
+			SELECT $2, gf.poi_id, gf.added_at
+			FROM guest_favorites gf JOIN points_of_interest p ON p.id = gf.poi_id
+			WHERE gf.guest_id = $1 AND NOT gf.is_llm
+			ON CONFLICT (user_id, poi_id) DO NOTHING`, &claim.Favorites},
+		{"llm favourites", `
+			INSERT INTO user_favorite_llm_pois (user_id, llm_poi_id, added_at)
+			SELECT $2, gf.poi_id, gf.added_at
+			FROM guest_favorites gf JOIN llm_suggested_pois p ON p.id = gf.poi_id
+			WHERE gf.guest_id = $1 AND gf.is_llm
+			ON CONFLICT (user_id, llm_poi_id) DO NOTHING`, &claim.Favorites},
+		{"guest favourites", `DELETE FROM guest_favorites WHERE guest_id = $1`, nil},
+		{"lists", `UPDATE lists SET user_id = $2, guest_id = NULL, updated_at = NOW() WHERE guest_id = $1`, &claim.Lists},
+	}
+	for _, step := range steps {
+		tag, err := tx.Exec(ctx, step.query, guestID, userID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to move "+step.name)
+			return nil, fmt.Errorf("failed to move guest %s: %w", step.name, err)
+		}
+		if step.count != nil {
+			*step.count += int(tag.RowsAffected())
+		}
+	}
+
+	span.SetStatus(codes.Ok, "Guest moved")
+	return claim, nil
+}
@@ -0,0 +1,131 @@
+// Package guest keeps what visitors without an account do in free mode, their sessions,
+// favourites and lists, under the guest id of their signed cookie, and moves it onto the
+// account they sign up or sign in with.
+package guest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+type Service interface {
+	Summary(ctx context.Context, guestID uuid.UUID) (*models.GuestSummary, error)
+	AddFavorite(ctx context.Context, guestID, poiID uuid.UUID, isLLM bool) error
+	RemoveFavorite(ctx context.Context, guestID, poiID uuid.UUID) error
+	CreateList(ctx context.Context, guestID uuid.UUID, req models.CreateGuestListRequest) (*models.GuestList, error)
+	AddListItem(ctx context.Context, guestID, listID uuid.UUID, req models.AddGuestListItemRequest) error
+	// Claim moves everything the guest did onto the user, all of it or nothing.
+	Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+	// ClaimInTx is Claim within the caller's transaction, e.g. the one creating the user.
+	ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error)
+}
+
+type ServiceImpl struct {
+	logger *zap.Logger
+	repo   Repository
+}
+
+// NewService creates a new instance of ServiceImpl
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+func (s *ServiceImpl) Summary(ctx context.Context, guestID uuid.UUID) (*models.GuestSummary, error) {
+	summary, err := s.repo.Summary(ctx, guestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guest summary: %w", err)
+	}
+	return summary, nil
+}
+
+// AddFavorite saves a POI for the guest, recording the guest first since its cookie is
+// issued before it has anything to keep
+func (s *ServiceImpl) AddFavorite(ctx context.Context, guestID, poiID uuid.UUID, isLLM bool) error {
+	if err := s.repo.Touch(ctx, guestID); err != nil {
+		return err
+	}
+	return s.repo.AddFavorite(ctx, guestID, poiID, isLLM)
+}
+
+func (s *ServiceImpl) RemoveFavorite(ctx context.Context, guestID, poiID uuid.UUID) error {
+	return s.repo.RemoveFavorite(ctx, guestID, poiID)
+}
+
+func (s *ServiceImpl) CreateList(ctx context.Context, guestID uuid.UUID, req models.CreateGuestListRequest) (*models.GuestList, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, fmt.Errorf("list name is required: %w", models.ErrBadRequest)
+	}
+	if err := s.repo.Touch(ctx, guestID); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateList(ctx, guestID, req)
+}
+
+func (s *ServiceImpl) AddListItem(ctx context.Context, guestID, listID uuid.UUID, req models.AddGuestListItemRequest) error {
+	if req.ContentType == "" {
+		req.ContentType = models.ContentTypePOI
+	}
+	switch req.ContentType {
+	case models.ContentTypePOI, models.ContentTypeRestaurant, models.ContentTypeHotel, models.ContentTypeItinerary:
+	default:
+		return fmt.Errorf("unknown content type %q: %w", req.ContentType, models.ErrBadRequest)
+	}
+	return s.repo.AddListItem(ctx, guestID, listID, req)
+}
+
+func (s *ServiceImpl) Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	return s.claim(ctx, "Claim", guestID, userID, func(ctx context.Context) (*models.GuestClaim, error) {
+		return s.repo.Claim(ctx, guestID, userID)
+	})
+}
+
+func (s *ServiceImpl) ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	return s.claim(ctx, "ClaimInTx", guestID, userID, func(ctx context.Context) (*models.GuestClaim, error) {
+		return s.repo.ClaimInTx(ctx, tx, guestID, userID)
+	})
+}
+
+func (s *ServiceImpl) claim(ctx context.Context, name string, guestID, userID uuid.UUID, move func(context.Context) (*models.GuestClaim, error)) (*models.GuestClaim, error) {
+	ctx, span := otel.Tracer("GuestService").Start(ctx, name, trace.WithAttributes(
+		attribute.String("guest.id", guestID.String()),
+		attribute.String("user.id", userID.String()),
+	))
+	defer span.End()
+
+	if guestID == uuid.Nil || userID == uuid.Nil {
+		span.SetStatus(codes.Error, "Missing guest or user")
+		return nil, fmt.Errorf("guest and user are required: %w", models.ErrBadRequest)
+	}
+	claim, err := move(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to claim guest")
+		return nil, fmt.Errorf("failed to claim guest: %w", err)
+	}
+
+	s.logger.Info("Guest claimed",
+		zap.String("guestID", guestID.String()),
+		zap.String("userID", userID.String()),
+		zap.Int("sessions", claim.Sessions),
+		zap.Int("favorites", claim.Favorites),
+		zap.Int("lists", claim.Lists))
+	span.SetStatus(codes.Ok, "Guest claimed")
+	return claim, nil
+}
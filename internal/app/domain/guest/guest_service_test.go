@@ -0,0 +1,139 @@
+package guest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Touch(ctx context.Context, guestID uuid.UUID) error {
+	return m.Called(ctx, guestID).Error(0)
+}
+
+func (m *MockRepository) Summary(ctx context.Context, guestID uuid.UUID) (*models.GuestSummary, error) {
+	args := m.Called(ctx, guestID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GuestSummary), args.Error(1)
+}
+
+func (m *MockRepository) AddFavorite(ctx context.Context, guestID, poiID uuid.UUID, isLLM bool) error {
+	return m.Called(ctx, guestID, poiID, isLLM).Error(0)
+}
+
+func (m *MockRepository) RemoveFavorite(ctx context.Context, guestID, poiID uuid.UUID) error {
+	return m.Called(ctx, guestID, poiID).Error(0)
+}
+
+func (m *MockRepository) CreateList(ctx context.Context, guestID uuid.UUID, req models.CreateGuestListRequest) (*models.GuestList, error) {
+	args := m.Called(ctx, guestID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GuestList), args.Error(1)
+}
+
+func (m *MockRepository) AddListItem(ctx context.Context, guestID, listID uuid.UUID, req models.AddGuestListItemRequest) error {
+	return m.Called(ctx, guestID, listID, req).Error(0)
+}
+
+func (m *MockRepository) Claim(ctx context.Context, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	args := m.Called(ctx, guestID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GuestClaim), args.Error(1)
+}
+
+func (m *MockRepository) ClaimInTx(ctx context.Context, tx pgx.Tx, guestID, userID uuid.UUID) (*models.GuestClaim, error) {
+	args := m.Called(ctx, tx, guestID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GuestClaim), args.Error(1)
+}
+
+func TestAddFavorite_RecordsGuestFirst(t *testing.T) {
+	guestID, poiID := uuid.New(), uuid.New()
+	repo := new(MockRepository)
+	repo.On("Touch", mock.Anything, guestID).Return(nil).Once()
+	repo.On("AddFavorite", mock.Anything, guestID, poiID, true).Return(nil).Once()
+
+	require.NoError(t, NewService(repo, zap.NewNop()).AddFavorite(context.Background(), guestID, poiID, true))
+	repo.AssertExpectations(t)
+}
+
+func TestCreateList_RequiresName(t *testing.T) {
+	repo := new(MockRepository)
+	_, err := NewService(repo, zap.NewNop()).CreateList(context.Background(), uuid.New(), models.CreateGuestListRequest{Name: "  "})
+	assert.ErrorIs(t, err, models.ErrBadRequest)
+	repo.AssertNotCalled(t, "Touch", mock.Anything, mock.Anything)
+}
+
+func TestAddListItem_DefaultsToPOI(t *testing.T) {
+	guestID, listID, itemID := uuid.New(), uuid.New(), uuid.New()
+	repo := new(MockRepository)
+	repo.On("AddListItem", mock.Anything, guestID, listID,
+		models.AddGuestListItemRequest{ItemID: itemID, ContentType: models.ContentTypePOI}).Return(nil).Once()
+	service := NewService(repo, zap.NewNop())
+
+	require.NoError(t, service.AddListItem(context.Background(), guestID, listID, models.AddGuestListItemRequest{ItemID: itemID}))
+	err := service.AddListItem(context.Background(), guestID, listID, models.AddGuestListItemRequest{ItemID: itemID, ContentType: "flight"})
+	assert.ErrorIs(t, err, models.ErrBadRequest)
+	repo.AssertExpectations(t)
+}
+
+func TestClaim(t *testing.T) {
+	guestID, userID := uuid.New(), uuid.New()
+
+	t.Run("moves the guest onto the user", func(t *testing.T) {
+		repo := new(MockRepository)
+		want := &models.GuestClaim{Sessions: 2, Interactions: 5, Favorites: 3, Lists: 1}
+		repo.On("Claim", mock.Anything, guestID, userID).Return(want, nil).Once()
+
+		claim, err := NewService(repo, zap.NewNop()).Claim(context.Background(), guestID, userID)
+		require.NoError(t, err)
+		assert.Equal(t, want, claim)
+	})
+
+	t.Run("requires a guest and a user", func(t *testing.T) {
+		repo := new(MockRepository)
+		_, err := NewService(repo, zap.NewNop()).Claim(context.Background(), guestID, uuid.Nil)
+		assert.ErrorIs(t, err, models.ErrBadRequest)
+		repo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		repo := new(MockRepository)
+		repo.On("Claim", mock.Anything, guestID, userID).Return(nil, errors.New("serialization failure")).Once()
+
+		_, err := NewService(repo, zap.NewNop()).Claim(context.Background(), guestID, userID)
+		assert.ErrorContains(t, err, "failed to claim guest")
+	})
+
+	t.Run("in the caller's transaction", func(t *testing.T) {
+		repo := new(MockRepository)
+		want := &models.GuestClaim{Sessions: 1}
+		repo.On("ClaimInTx", mock.Anything, nil, guestID, userID).Return(want, nil).Once()
+
+		claim, err := NewService(repo, zap.NewNop()).ClaimInTx(context.Background(), nil, guestID, userID)
+		require.NoError(t, err)
+		assert.Equal(t, want, claim)
+		repo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,137 @@
+package hotels
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+func TestFilterPOIsForHotels(t *testing.T) {
+	testPOIs := []models.POIDetailedInfo{
+		// Hotels/Accommodation
+		{ID: uuid.New(), Name: "Luxury Hotel", Category: "hotel", Rating: 4.9},
+		{ID: uuid.New(), Name: "Budget Hostel", Category: "hostel", Rating: 4.2},
+		{ID: uuid.New(), Name: "Beach Resort", Category: "resort", Rating: 4.8},
+		{ID: uuid.New(), Name: "Cozy Guesthouse", Category: "guesthouse", Rating: 4.5},
+		{ID: uuid.New(), Name: "City Apartment", Category: "apartment", Rating: 4.4},
+		{ID: uuid.New(), Name: "Mountain Villa", Category: "villa", Rating: 4.7},
+		{ID: uuid.New(), Name: "Roadside Motel", Category: "motel", Rating: 3.8},
+		{ID: uuid.New(), Name: "Historic Inn", Category: "inn", Rating: 4.3},
+		{ID: uuid.New(), Name: "B&B Cottage", Category: "b&b", Rating: 4.2},
+		{ID: uuid.New(), Name: "Accommodation Center", Category: "accommodation", Rating: 4.0},
+		{ID: uuid.New(), Name: "Lodging House", Category: "lodging", Rating: 3.9},
+		{ID: uuid.New(), Name: "BnB Place", Category: "bnb", Rating: 4.1},
+
+		// Other domains and non-relevant categories
+		{ID: uuid.New(), Name: "Louvre Museum", Category: "museum", Rating: 4.8},
+		{ID: uuid.New(), Name: "Fine Restaurant", Category: "restaurant", Rating: 4.8},
+		{ID: uuid.New(), Name: "Transport Station", Category: "transport", Rating: 4.0},
+		{ID: uuid.New(), Name: "Hospital", Category: "healthcare", Rating: 4.2},
+	}
+
+	t.Run("includes only accommodation categories", func(t *testing.T) {
+		filtered := filterPOIsForHotels(testPOIs)
+		assert.Len(t, filtered, 12)
+
+		hotelCategories := map[string]bool{
+			"hotel": true, "hostel": true, "resort": true, "guesthouse": true, "apartment": true,
+			"villa": true, "motel": true, "inn": true, "b&b": true, "accommodation": true, "lodging": true, "bnb": true,
+		}
+		var names []string
+		for _, hotel := range filtered {
+			assert.True(t, hotelCategories[hotel.Category],
+				"POI '%s' with category '%s' should not be in hotels filter", hotel.Name, hotel.Category)
+			names = append(names, hotel.Name)
+		}
+		assert.Contains(t, names, "Luxury Hotel")
+		assert.Contains(t, names, "Beach Resort")
+		assert.NotContains(t, names, "Louvre Museum")
+		assert.NotContains(t, names, "Fine Restaurant")
+		assert.NotContains(t, names, "Transport Station")
+	})
+
+	t.Run("is case insensitive", func(t *testing.T) {
+		filtered := filterPOIsForHotels([]models.POIDetailedInfo{
+			{ID: uuid.New(), Name: "Hotel", Category: "HOTEL"},
+			{ID: uuid.New(), Name: "restaurant", Category: "Restaurant"},
+		})
+		assert.Len(t, filtered, 1)
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		assert.Empty(t, filterPOIsForHotels([]models.POIDetailedInfo{}))
+	})
+}
+
+func TestConvertPOIToHotel(t *testing.T) {
+	t.Run("converts all fields", func(t *testing.T) {
+		poi := models.POIDetailedInfo{
+			ID:          uuid.New(),
+			City:        "Paris",
+			Name:        "Le Meurice",
+			Latitude:    48.8656,
+			Longitude:   2.3272,
+			Category:    "hotel",
+			Description: "Luxury palace hotel",
+			Address:     "228 Rue de Rivoli, 75001 Paris",
+			PhoneNumber: "+33 1 44 58 10 10",
+			Website:     "https://www.lemeurice.com",
+			OpeningHours: map[string]string{
+				"Monday":    "24 hours",
+				"Tuesday":   "24 hours",
+				"Wednesday": "24 hours",
+			},
+			PriceRange:       "$$$$",
+			Rating:           4.9,
+			Tags:             []string{"luxury", "historic", "palace"},
+			Images:           []string{"facade.jpg", "lobby.jpg", "suite.jpg"},
+			LlmInteractionID: uuid.New(),
+		}
+
+		hotel := convertPOIToHotel(poi)
+
+		assert.Equal(t, poi.ID, hotel.ID)
+		assert.Equal(t, poi.City, hotel.City)
+		assert.Equal(t, poi.Name, hotel.Name)
+		assert.Equal(t, poi.Latitude, hotel.Latitude)
+		assert.Equal(t, poi.Longitude, hotel.Longitude)
+		assert.Equal(t, poi.Category, hotel.Category)
+		assert.Equal(t, poi.Description, hotel.Description)
+		assert.Equal(t, poi.Address, hotel.Address)
+		assert.Equal(t, poi.Rating, hotel.Rating)
+		assert.Equal(t, poi.Tags, hotel.Tags)
+		assert.Equal(t, poi.Images, hotel.Images)
+		assert.Equal(t, poi.LlmInteractionID, hotel.LlmInteractionID)
+
+		if assert.NotNil(t, hotel.PhoneNumber) {
+			assert.Equal(t, poi.PhoneNumber, *hotel.PhoneNumber)
+		}
+		if assert.NotNil(t, hotel.Website) {
+			assert.Equal(t, poi.Website, *hotel.Website)
+		}
+		if assert.NotNil(t, hotel.PriceRange) {
+			assert.Equal(t, poi.PriceRange, *hotel.PriceRange)
+		}
+		if assert.NotNil(t, hotel.OpeningHours) {
+			assert.Contains(t, *hotel.OpeningHours, "Monday: 24 hours")
+			assert.Contains(t, *hotel.OpeningHours, "Tuesday: 24 hours")
+			assert.Contains(t, *hotel.OpeningHours, "Wednesday: 24 hours")
+		}
+	})
+
+	t.Run("leaves empty optional fields nil", func(t *testing.T) {
+		hotel := convertPOIToHotel(models.POIDetailedInfo{
+			ID:           uuid.New(),
+			Name:         "Basic POI",
+			OpeningHours: map[string]string{},
+		})
+
+		assert.Nil(t, hotel.PhoneNumber)
+		assert.Nil(t, hotel.Website)
+		assert.Nil(t, hotel.PriceRange)
+		assert.Nil(t, hotel.OpeningHours)
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/middleware"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 	"github.com/FACorreiaa/go-templui/internal/app/services"
+	streamingpkg "github.com/FACorreiaa/go-templui/internal/app/streaming"
 	"github.com/FACorreiaa/go-templui/internal/pkg/cache"
 )
 
@@ -27,9 +28,16 @@ type ChatRepository interface {
 	GetLatestInteractionBySessionID(ctx context.Context, sessionID uuid.UUID) (*models.LlmInteraction, error)
 }
 
+// ItineraryRouter orders the points of interest of an itinerary and sets the legs between them.
+type ItineraryRouter interface {
+	RouteItinerary(ctx context.Context, userID uuid.UUID, itinerary *models.AIItineraryResponse)
+}
+
 type ItineraryHandlers struct {
 	chatRepo         ChatRepository
 	itineraryService *services.ItineraryService
+	router           ItineraryRouter // Routes itineraries stored before routing, nil shows them as stored
+	replays          *streamingpkg.ReplayStore[models.ItinerarySSEEvent]
 	logger           *zap.Logger
 }
 
@@ -39,10 +47,31 @@ func NewItineraryHandlers(chatRepo ChatRepository,
 	return &ItineraryHandlers{
 		chatRepo:         chatRepo,
 		itineraryService: itineraryService,
+		replays:          streamingpkg.NewReplayStore[models.ItinerarySSEEvent](streamingpkg.DefaultReplayCapacity, streamingpkg.DefaultReplayTTL),
 		logger:           logger,
 	}
 }
 
+// WithRouting shows itineraries that were stored without a route ordered by travel time,
+// with the legs between their points of interest.
+func (h *ItineraryHandlers) WithRouting(r ItineraryRouter) *ItineraryHandlers {
+	h.router = r
+	return h
+}
+
+// routed routes an itinerary that has no route yet for the user.
+func (h *ItineraryHandlers) routed(userID string, itinerary models.AIItineraryResponse) models.AIItineraryResponse {
+	if h.router == nil || itinerary.Route != nil {
+		return itinerary
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		id = uuid.Nil
+	}
+	h.router.RouteItinerary(context.Background(), id, &itinerary)
+	return itinerary
+}
+
 func (h *ItineraryHandlers) HandleDestination(c *gin.Context) {
 	destination := c.PostForm("destination-input")
 	if destination == "" {
@@ -320,7 +349,7 @@ func (h *ItineraryHandlers) HandleItineraryPage(c *gin.Context) templ.Component
 	}
 
 	// Load itinerary data for session
-	return h.loadItineraryBySession(sessionIDParam)
+	return h.loadItineraryBySession(userID, sessionIDParam)
 }
 
 // HandleItineraryPageSSE handles the itinerary page with SSE support
@@ -348,10 +377,10 @@ func (h *ItineraryHandlers) HandleItineraryPageSSE(c *gin.Context) templ.Compone
 	}
 
 	// Load itinerary data for session with SSE support
-	return h.loadItineraryBySessionSSE(sessionIDParam, cacheKey)
+	return h.loadItineraryBySessionSSE(userID, sessionIDParam, cacheKey)
 }
 
-func (h *ItineraryHandlers) loadItineraryBySession(sessionIDParam string) templ.Component {
+func (h *ItineraryHandlers) loadItineraryBySession(userID, sessionIDParam string) templ.Component {
 	h.logger.Info("Attempting to load itinerary from cache", zap.String("sessionID", sessionIDParam))
 
 	// Try complete cache first
@@ -374,7 +403,7 @@ func (h *ItineraryHandlers) loadItineraryBySession(sessionIDParam string) templ.
 		return results2.ItineraryResults(
 			completeData.GeneralCityData,
 			completeData.PointsOfInterest,
-			completeData.AIItineraryResponse,
+			h.routed(userID, completeData.AIItineraryResponse),
 			true, true, 15, []string{}, sessionIDParam)
 	}
 
@@ -387,15 +416,15 @@ func (h *ItineraryHandlers) loadItineraryBySession(sessionIDParam string) templ.
 		emptyCityData := models.GeneralCityData{}
 		emptyGeneralPOIs := []models.POIDetailedInfo{}
 
-		return results2.ItineraryResults(emptyCityData, emptyGeneralPOIs, itineraryData, true, true, 5, []string{}, sessionIDParam)
+		return results2.ItineraryResults(emptyCityData, emptyGeneralPOIs, h.routed(userID, itineraryData), true, true, 5, []string{}, sessionIDParam)
 	}
 
 	// Load from database
-	return h.loadItineraryFromDatabase(sessionIDParam)
+	return h.loadItineraryFromDatabase(userID, sessionIDParam)
 }
 
 // loadItineraryBySessionSSE loads itinerary with SSE support
-func (h *ItineraryHandlers) loadItineraryBySessionSSE(sessionIDParam string, cacheKey string) templ.Component {
+func (h *ItineraryHandlers) loadItineraryBySessionSSE(userID, sessionIDParam string, cacheKey string) templ.Component {
 	h.logger.Info("Attempting to load itinerary from cache with SSE",
 		zap.String("sessionID", sessionIDParam),
 		zap.String("cacheKey", cacheKey))
@@ -411,7 +440,7 @@ func (h *ItineraryHandlers) loadItineraryBySessionSSE(sessionIDParam string, cac
 			return results2.ItineraryResults(
 				completeData.GeneralCityData,
 				completeData.PointsOfInterest,
-				completeData.AIItineraryResponse,
+				h.routed(userID, completeData.AIItineraryResponse),
 				true,
 				true,
 				15,
@@ -419,10 +448,16 @@ func (h *ItineraryHandlers) loadItineraryBySessionSSE(sessionIDParam string, cac
 		}
 	}
 
-	return h.loadItineraryFromDatabase(sessionIDParam)
+	return h.loadItineraryFromDatabase(userID, sessionIDParam)
 }
 
-// HandleItinerarySSE handles Server-Sent Events for itinerary updates
+// itineraryMonitorGrace is how long the monitor of a session keeps polling after its last
+// client left, waiting for it to reconnect.
+const itineraryMonitorGrace = 30 * time.Second
+
+// HandleItinerarySSE handles Server-Sent Events for itinerary updates. Every session has one
+// monitor publishing into its replay buffer; connections follow the buffer, and a client that
+// reconnects with Last-Event-ID gets the updates it missed.
 func (h *ItineraryHandlers) HandleItinerarySSE(c *gin.Context) {
 	sessionID := c.Query("sessionId")
 	if sessionID == "" {
@@ -439,43 +474,86 @@ func (h *ItineraryHandlers) HandleItinerarySSE(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	// Create a channel for updates
-	updateChan := make(chan models.ItinerarySSEEvent)
-	defer close(updateChan)
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
 
-	// Start monitoring for updates in a separate goroutine
-	go h.monitorItineraryUpdates(sessionID, updateChan)
+	// Resume the session's stream when the client names an event of it, or join a monitor that
+	// is still running; otherwise start monitoring
+	var lastSeq uint64
+	replay, found := h.replays.Get(sessionID)
+	key, seq, resuming := streamingpkg.LastEventID(c.Request)
+	switch {
+	case found && resuming && key == replay.Key():
+		lastSeq = seq
+		h.logger.Info("Resuming itinerary SSE", zap.String("sessionId", sessionID), zap.Uint64("lastEventSeq", seq))
+	case found && !replay.Closed():
+	default:
+		replay = h.startItineraryMonitor(c.Request.Context(), sessionID)
+	}
 
 	// Stream updates to client
-	flusher := c.Writer.(http.Flusher)
-	for {
-		select {
-		case event := <-updateChan:
-			if event.Type == "complete" {
-				h.logger.Info("Sending completion event",
-					zap.String("sessionId", sessionID))
-
-				// Send final completion event
-				c.SSEvent("itinerary-complete", map[string]interface{}{
-					"sessionId": sessionID,
-					"message":   "Itinerary generation complete",
-				})
-				flusher.Flush()
-				return
+	err := replay.Follow(c.Request.Context(), lastSeq, func(e streamingpkg.Sequenced[models.ItinerarySSEEvent]) error {
+		var name string
+		var data map[string]interface{}
+		switch e.Event.Type {
+		case "complete":
+			h.logger.Info("Sending completion event",
+				zap.String("sessionId", sessionID))
+			name, data = "itinerary-complete", map[string]interface{}{
+				"sessionId": sessionID,
+				"message":   "Itinerary generation complete",
 			}
+		case "progress", "error":
+			name = "itinerary-" + e.Event.Type
+			data, _ = e.Event.Data.(map[string]interface{})
+		default:
+			// Header and content updates carry the cached itinerary the page renders itself
+			return nil
+		}
 
-			flusher.Flush()
-
-		case <-c.Request.Context().Done():
-			h.logger.Info("SSE connection closed",
-				zap.String("sessionId", sessionID))
-			return
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if err := streamingpkg.WriteSSE(c.Writer, replay.EventID(e.Seq), name, payload); err != nil {
+			return err
 		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		h.logger.Info("SSE connection closed",
+			zap.String("sessionId", sessionID), zap.Error(err))
 	}
 }
 
-// monitorItineraryUpdates monitors for itinerary updates and sends SSE events
-func (h *ItineraryHandlers) monitorItineraryUpdates(sessionID string, updateChan chan<- models.ItinerarySSEEvent) {
+// startItineraryMonitor opens the replay buffer of the session and runs its monitor detached
+// from the request, until it finishes or no client has followed it for itineraryMonitorGrace.
+func (h *ItineraryHandlers) startItineraryMonitor(ctx context.Context, sessionID string) *streamingpkg.ReplayBuffer[models.ItinerarySSEEvent] {
+	replay := h.replays.Open(sessionID, "")
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	replay.OnAbandon(itineraryMonitorGrace, cancel)
+
+	updateChan := make(chan models.ItinerarySSEEvent)
+	go h.monitorItineraryUpdates(ctx, sessionID, updateChan)
+	go func() {
+		defer cancel()
+		defer replay.Close()
+		for event := range updateChan {
+			replay.Append(event)
+		}
+	}()
+	return replay
+}
+
+// monitorItineraryUpdates monitors for itinerary updates and sends SSE events. It closes
+// updateChan when done.
+func (h *ItineraryHandlers) monitorItineraryUpdates(ctx context.Context, sessionID string, updateChan chan<- models.ItinerarySSEEvent) {
+	defer close(updateChan)
+
 	// Check for cached data first
 	if completeData, found := cache.CompleteItineraryCache.Get(sessionID); found {
 		h.logger.Info("Complete data found in cache, sending completion immediately",
@@ -577,6 +655,10 @@ func (h *ItineraryHandlers) monitorItineraryUpdates(sessionID string, updateChan
 				},
 			}
 
+		case <-ctx.Done():
+			h.logger.Info("SSE monitoring stopped, no client left", zap.String("sessionId", sessionID))
+			return
+
 		case <-timeout:
 			h.logger.Warn("SSE monitoring timed out", zap.String("sessionId", sessionID))
 			updateChan <- models.ItinerarySSEEvent{
@@ -592,7 +674,7 @@ func (h *ItineraryHandlers) monitorItineraryUpdates(sessionID string, updateChan
 }
 
 // loadItineraryFromDatabase loads itinerary from database when not found in cache
-func (h *ItineraryHandlers) loadItineraryFromDatabase(sessionIDParam string) templ.Component {
+func (h *ItineraryHandlers) loadItineraryFromDatabase(userID, sessionIDParam string) templ.Component {
 	h.logger.Info("Itinerary not found in cache, attempting to load from database", zap.String("sessionID", sessionIDParam))
 
 	// Parse sessionID as UUID
@@ -639,6 +721,6 @@ func (h *ItineraryHandlers) loadItineraryFromDatabase(sessionIDParam string) tem
 	return results2.ItineraryResults(
 		completeData.GeneralCityData,
 		completeData.PointsOfInterest,
-		completeData.AIItineraryResponse,
+		h.routed(userID, completeData.AIItineraryResponse),
 		true, true, 5, []string{}, sessionIDParam)
 }
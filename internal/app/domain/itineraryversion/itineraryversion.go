@@ -0,0 +1,173 @@
+package itineraryversion
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Sessions puts stored versions back into chat sessions.
+type Sessions interface {
+	// RevertItinerary makes a version the current itinerary of its session and returns it
+	// with the number of the version recording the revert.
+	RevertItinerary(ctx context.Context, userID, sessionID uuid.UUID, version int) (*models.AiCityResponse, int, error)
+	// BranchSession starts a new session from a version and returns its id.
+	BranchSession(ctx context.Context, userID, sessionID uuid.UUID, version int) (uuid.UUID, error)
+}
+
+// Handler serves the version history of the itineraries of the user's chat sessions.
+type Handler struct {
+	service  Service
+	sessions Sessions
+	logger   *zap.Logger
+}
+
+func NewHandler(service Service, sessions Sessions, logger *zap.Logger) *Handler {
+	return &Handler{
+		service:  service,
+		sessions: sessions,
+		logger:   logger,
+	}
+}
+
+// List handles GET /chat/sessions/:id/versions.
+func (h *Handler) List(c *gin.Context) {
+	userID, sessionID, ok := h.parseSession(c)
+	if !ok {
+		return
+	}
+	versions, err := h.service.List(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		h.respondError(c, "Failed to list itinerary versions", err)
+		return
+	}
+	if versions == nil {
+		versions = []Version{}
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+// Get handles GET /chat/sessions/:id/versions/:version.
+func (h *Handler) Get(c *gin.Context) {
+	userID, sessionID, ok := h.parseSession(c)
+	if !ok {
+		return
+	}
+	number, ok := h.parseVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	version, err := h.service.Get(c.Request.Context(), userID, sessionID, number)
+	if err != nil {
+		h.respondError(c, "Failed to get itinerary version", err)
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
+
+// Diff handles GET /chat/sessions/:id/versions/diff?from=1&to=3.
+func (h *Handler) Diff(c *gin.Context) {
+	userID, sessionID, ok := h.parseSession(c)
+	if !ok {
+		return
+	}
+	from, ok := h.parseVersion(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.parseVersion(c, c.Query("to"))
+	if !ok {
+		return
+	}
+	diff, err := h.service.Diff(c.Request.Context(), userID, sessionID, from, to)
+	if err != nil {
+		h.respondError(c, "Failed to diff itinerary versions", err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// Revert handles POST /chat/sessions/:id/versions/:version/revert. The revert is recorded as
+// a new version, so it can itself be reverted.
+func (h *Handler) Revert(c *gin.Context) {
+	userID, sessionID, ok := h.parseSession(c)
+	if !ok {
+		return
+	}
+	number, ok := h.parseVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	itinerary, version, err := h.sessions.RevertItinerary(c.Request.Context(), userID, sessionID, number)
+	if err != nil {
+		h.respondError(c, "Failed to revert itinerary", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"session_id": sessionID, "version": version, "itinerary": itinerary})
+}
+
+// Branch handles POST /chat/sessions/:id/versions/:version/branch.
+func (h *Handler) Branch(c *gin.Context) {
+	userID, sessionID, ok := h.parseSession(c)
+	if !ok {
+		return
+	}
+	number, ok := h.parseVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	branchID, err := h.sessions.BranchSession(c.Request.Context(), userID, sessionID, number)
+	if err != nil {
+		h.respondError(c, "Failed to branch session", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"session_id": branchID, "branched_from": sessionID, "version": number})
+}
+
+func (h *Handler) parseSession(c *gin.Context) (userID, sessionID uuid.UUID, ok bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	sessionID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, sessionID, true
+}
+
+func (h *Handler) parseVersion(c *gin.Context, raw string) (int, bool) {
+	number, err := strconv.Atoi(raw)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return 0, false
+	}
+	return number, true
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "session or version not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,178 @@
+package itineraryversion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	SessionOwner(ctx context.Context, sessionID uuid.UUID) (uuid.UUID, error)
+	// Latest returns the number of the latest version of the session, zero without history.
+	Latest(ctx context.Context, sessionID uuid.UUID) (int, error)
+	// Insert stores v as the next version of its session and returns its number.
+	Insert(ctx context.Context, v Version) (int, error)
+	// MarkBranch records on a session the session and version it was branched from.
+	MarkBranch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int) error
+	// List returns the versions of a session, oldest first, without their snapshots.
+	List(ctx context.Context, sessionID uuid.UUID) ([]Version, error)
+	Get(ctx context.Context, sessionID uuid.UUID, number int) (*Version, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) SessionOwner(ctx context.Context, sessionID uuid.UUID) (uuid.UUID, error) {
+	var owner *uuid.UUID
+	err := r.pgpool.QueryRow(ctx, `SELECT user_id FROM chat_sessions WHERE id = $1`, sessionID).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("session %s: %w", sessionID, models.ErrNotFound)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to query session owner: %w", err)
+	}
+	if owner == nil {
+		return uuid.Nil, nil
+	}
+	return *owner, nil
+}
+
+func (r *RepositoryImpl) Latest(ctx context.Context, sessionID uuid.UUID) (int, error) {
+	var latest int
+	err := r.pgpool.QueryRow(ctx, `
+		SELECT COALESCE(MAX(version), 0) FROM chat_session_itinerary_versions WHERE session_id = $1`,
+		sessionID).Scan(&latest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query latest itinerary version: %w", err)
+	}
+	return latest, nil
+}
+
+func (r *RepositoryImpl) Insert(ctx context.Context, v Version) (int, error) {
+	ctx, span := otel.Tracer("ItineraryVersionRepository").Start(ctx, "Insert", trace.WithAttributes(
+		attribute.String("session.id", v.SessionID.String()),
+		attribute.String("change_type", v.ChangeType),
+	))
+	defer span.End()
+
+	snapshot, err := json.Marshal(v.Snapshot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal itinerary snapshot: %w", err)
+	}
+
+	// Turns of one session run one after the other, the unique constraint catches the rest
+	query := `
+		INSERT INTO chat_session_itinerary_versions (session_id, version, change_type, description, based_on, snapshot)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5
+		FROM chat_session_itinerary_versions WHERE session_id = $1
+		RETURNING version`
+	var number int
+	if err := r.pgpool.QueryRow(ctx, query, v.SessionID, v.ChangeType, v.Description, v.BasedOn, snapshot).Scan(&number); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to insert itinerary version")
+		return 0, fmt.Errorf("failed to insert itinerary version: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("version", number))
+	span.SetStatus(codes.Ok, "Itinerary version inserted")
+	return number, nil
+}
+
+func (r *RepositoryImpl) MarkBranch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int) error {
+	tag, err := r.pgpool.Exec(ctx, `
+		UPDATE chat_sessions SET branched_from_session_id = $2, branched_from_version = $3 WHERE id = $1`,
+		branchID, sourceID, basedOn)
+	if err != nil {
+		return fmt.Errorf("failed to mark branched session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("session %s: %w", branchID, models.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) List(ctx context.Context, sessionID uuid.UUID) ([]Version, error) {
+	ctx, span := otel.Tracer("ItineraryVersionRepository").Start(ctx, "List", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT version, change_type, description, based_on,
+		       CASE WHEN jsonb_typeof(snapshot->'itinerary_response'->'points_of_interest') = 'array'
+		            THEN jsonb_array_length(snapshot->'itinerary_response'->'points_of_interest') ELSE 0 END,
+		       created_at
+		FROM chat_session_itinerary_versions
+		WHERE session_id = $1
+		ORDER BY version`
+	rows, err := r.pgpool.Query(ctx, query, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query itinerary versions")
+		return nil, fmt.Errorf("failed to query itinerary versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []Version
+	for rows.Next() {
+		v := Version{SessionID: sessionID}
+		if err := rows.Scan(&v.Number, &v.ChangeType, &v.Description, &v.BasedOn, &v.POICount, &v.CreatedAt); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan itinerary version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate itinerary versions: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Itinerary versions listed")
+	return versions, nil
+}
+
+func (r *RepositoryImpl) Get(ctx context.Context, sessionID uuid.UUID, number int) (*Version, error) {
+	query := `
+		SELECT change_type, description, based_on, snapshot, created_at
+		FROM chat_session_itinerary_versions
+		WHERE session_id = $1 AND version = $2`
+	v := Version{SessionID: sessionID, Number: number}
+	var snapshot []byte
+	err := r.pgpool.QueryRow(ctx, query, sessionID, number).Scan(&v.ChangeType, &v.Description, &v.BasedOn, &snapshot, &v.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("itinerary version %d of session %s: %w", number, sessionID, models.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query itinerary version: %w", err)
+	}
+	if err := json.Unmarshal(snapshot, &v.Snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal itinerary snapshot: %w", err)
+	}
+	if v.Snapshot != nil {
+		v.POICount = len(v.Snapshot.AIItineraryResponse.PointsOfInterest)
+	}
+	return &v, nil
+}
@@ -0,0 +1,224 @@
+// Package itineraryversion keeps the history of the itinerary of a chat session. Every change
+// is stored as a full snapshot, so users can compare versions, go back to one or start a new
+// session from it.
+package itineraryversion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Change types, as stored in chat_session_itinerary_versions.change_type. Chat modifications
+// are stored under their intent (add_poi, remove_poi, ...).
+const (
+	ChangeInitial = "initial"
+	ChangeRevert  = "revert"
+	ChangeBranch  = "branch"
+)
+
+// Version is the itinerary of a session after one change.
+type Version struct {
+	SessionID   uuid.UUID              `json:"session_id"`
+	Number      int                    `json:"version"`
+	ChangeType  string                 `json:"change_type"`
+	Description string                 `json:"description"`
+	BasedOn     *int                   `json:"based_on,omitempty"` // version a revert restored
+	POICount    int                    `json:"poi_count"`
+	Snapshot    *models.AiCityResponse `json:"snapshot,omitempty"` // left out of listings
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// POIRef names a POI in a diff.
+type POIRef struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// Diff is what changed in the itinerary from one version to another.
+type Diff struct {
+	From      int      `json:"from"`
+	To        int      `json:"to"`
+	Added     []POIRef `json:"added"`
+	Removed   []POIRef `json:"removed"`
+	Reordered bool     `json:"reordered"` // the POIs both versions have are in another order
+	// NameFrom and NameTo are set when the itinerary was renamed
+	NameFrom string `json:"name_from,omitempty"`
+	NameTo   string `json:"name_to,omitempty"`
+}
+
+// Empty reports whether the two itineraries have the same POIs in the same order.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && !d.Reordered && d.NameFrom == d.NameTo
+}
+
+type Service interface {
+	// Record stores current as the next version of the session's itinerary. previous is the
+	// itinerary before the change; it becomes the initial version of a session without history.
+	Record(ctx context.Context, sessionID uuid.UUID, previous, current *models.AiCityResponse, changeType, description string) (int, error)
+	// RecordBased stores snapshot as the next version, restored from version basedOn.
+	RecordBased(ctx context.Context, sessionID uuid.UUID, snapshot *models.AiCityResponse, changeType, description string, basedOn int) (int, error)
+	// Branch records that branchID was started from version basedOn of sourceID, with snapshot
+	// as its first version.
+	Branch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int, snapshot *models.AiCityResponse) (int, error)
+	List(ctx context.Context, userID, sessionID uuid.UUID) ([]Version, error)
+	Get(ctx context.Context, userID, sessionID uuid.UUID, number int) (*Version, error)
+	Diff(ctx context.Context, userID, sessionID uuid.UUID, from, to int) (*Diff, error)
+}
+
+type ServiceImpl struct {
+	repo   Repository
+	logger *zap.Logger
+}
+
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *ServiceImpl) Record(ctx context.Context, sessionID uuid.UUID, previous, current *models.AiCityResponse, changeType, description string) (int, error) {
+	if current == nil {
+		return 0, fmt.Errorf("no itinerary to record: %w", models.ErrBadRequest)
+	}
+	if previous != nil {
+		latest, err := s.repo.Latest(ctx, sessionID)
+		if err != nil {
+			return 0, err
+		}
+		if latest == 0 {
+			if _, err := s.repo.Insert(ctx, Version{SessionID: sessionID, ChangeType: ChangeInitial, Snapshot: previous}); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return s.repo.Insert(ctx, Version{SessionID: sessionID, ChangeType: changeType, Description: description, Snapshot: current})
+}
+
+func (s *ServiceImpl) RecordBased(ctx context.Context, sessionID uuid.UUID, snapshot *models.AiCityResponse, changeType, description string, basedOn int) (int, error) {
+	if snapshot == nil {
+		return 0, fmt.Errorf("no itinerary to record: %w", models.ErrBadRequest)
+	}
+	return s.repo.Insert(ctx, Version{SessionID: sessionID, ChangeType: changeType, Description: description, BasedOn: &basedOn, Snapshot: snapshot})
+}
+
+func (s *ServiceImpl) Branch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int, snapshot *models.AiCityResponse) (int, error) {
+	if err := s.repo.MarkBranch(ctx, branchID, sourceID, basedOn); err != nil {
+		return 0, err
+	}
+	return s.repo.Insert(ctx, Version{
+		SessionID:   branchID,
+		ChangeType:  ChangeBranch,
+		Description: fmt.Sprintf("Branched from version %d of session %s", basedOn, sourceID),
+		Snapshot:    snapshot,
+	})
+}
+
+func (s *ServiceImpl) List(ctx context.Context, userID, sessionID uuid.UUID) ([]Version, error) {
+	if err := s.checkOwner(ctx, userID, sessionID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, sessionID)
+}
+
+func (s *ServiceImpl) Get(ctx context.Context, userID, sessionID uuid.UUID, number int) (*Version, error) {
+	if err := s.checkOwner(ctx, userID, sessionID); err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, sessionID, number)
+}
+
+func (s *ServiceImpl) Diff(ctx context.Context, userID, sessionID uuid.UUID, from, to int) (*Diff, error) {
+	if err := s.checkOwner(ctx, userID, sessionID); err != nil {
+		return nil, err
+	}
+	a, err := s.repo.Get(ctx, sessionID, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.repo.Get(ctx, sessionID, to)
+	if err != nil {
+		return nil, err
+	}
+	d := Compare(a.Snapshot, b.Snapshot)
+	d.From, d.To = from, to
+	return &d, nil
+}
+
+// checkOwner hides the sessions of other users as missing.
+func (s *ServiceImpl) checkOwner(ctx context.Context, userID, sessionID uuid.UUID) error {
+	owner, err := s.repo.SessionOwner(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if owner != userID {
+		return fmt.Errorf("session %s: %w", sessionID, models.ErrNotFound)
+	}
+	return nil
+}
+
+// Compare returns the POIs added to and removed from the itinerary between two snapshots.
+// POIs are matched by name: generated POIs only get an id once saved, so the same place can
+// have none in one snapshot and one in the next.
+func Compare(from, to *models.AiCityResponse) Diff {
+	a, b := itineraryPOIs(from), itineraryPOIs(to)
+	var d Diff
+
+	inA := make(map[string]bool, len(a))
+	for _, p := range a {
+		inA[poiKey(p)] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, p := range b {
+		inB[poiKey(p)] = true
+	}
+
+	var keptA, keptB []string
+	for _, p := range a {
+		if !inB[poiKey(p)] {
+			d.Removed = append(d.Removed, POIRef{ID: p.ID, Name: p.Name})
+		} else {
+			keptA = append(keptA, poiKey(p))
+		}
+	}
+	for _, p := range b {
+		if !inA[poiKey(p)] {
+			d.Added = append(d.Added, POIRef{ID: p.ID, Name: p.Name})
+		} else {
+			keptB = append(keptB, poiKey(p))
+		}
+	}
+	d.Reordered = strings.Join(keptA, "\x00") != strings.Join(keptB, "\x00")
+
+	if nameA, nameB := itineraryName(from), itineraryName(to); nameA != nameB {
+		d.NameFrom, d.NameTo = nameA, nameB
+	}
+	return d
+}
+
+func itineraryPOIs(r *models.AiCityResponse) []models.POIDetailedInfo {
+	if r == nil {
+		return nil
+	}
+	return r.AIItineraryResponse.PointsOfInterest
+}
+
+func itineraryName(r *models.AiCityResponse) string {
+	if r == nil {
+		return ""
+	}
+	return r.AIItineraryResponse.ItineraryName
+}
+
+func poiKey(p models.POIDetailedInfo) string {
+	return strings.ToLower(strings.TrimSpace(p.Name))
+}
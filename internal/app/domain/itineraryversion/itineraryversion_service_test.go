@@ -0,0 +1,156 @@
+package itineraryversion
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) SessionOwner(ctx context.Context, sessionID uuid.UUID) (uuid.UUID, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockRepository) Latest(ctx context.Context, sessionID uuid.UUID) (int, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) Insert(ctx context.Context, v Version) (int, error) {
+	args := m.Called(ctx, v)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) MarkBranch(ctx context.Context, branchID, sourceID uuid.UUID, basedOn int) error {
+	args := m.Called(ctx, branchID, sourceID, basedOn)
+	return args.Error(0)
+}
+
+func (m *MockRepository) List(ctx context.Context, sessionID uuid.UUID) ([]Version, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Version), args.Error(1)
+}
+
+func (m *MockRepository) Get(ctx context.Context, sessionID uuid.UUID, number int) (*Version, error) {
+	args := m.Called(ctx, sessionID, number)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Version), args.Error(1)
+}
+
+var (
+	userID    = uuid.MustParse("3d6f0a2b-8c1e-4f7a-9b2d-5e4c3a2b1c01")
+	sessionID = uuid.MustParse("3d6f0a2b-8c1e-4f7a-9b2d-5e4c3a2b1c02")
+)
+
+func itinerary(name string, pois ...string) *models.AiCityResponse {
+	r := &models.AiCityResponse{}
+	r.AIItineraryResponse.ItineraryName = name
+	for _, p := range pois {
+		r.AIItineraryResponse.PointsOfInterest = append(r.AIItineraryResponse.PointsOfInterest, models.POIDetailedInfo{Name: p})
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	from := itinerary("Lisbon", "Belem Tower", "Jeronimos Monastery", "LX Factory")
+	to := itinerary("Lisbon", "jeronimos monastery", "Belem Tower", "Oceanarium")
+
+	d := Compare(from, to)
+	assert.Equal(t, []POIRef{{Name: "LX Factory"}}, d.Removed)
+	assert.Equal(t, []POIRef{{Name: "Oceanarium"}}, d.Added)
+	assert.True(t, d.Reordered)
+	assert.False(t, d.Empty())
+
+	assert.True(t, Compare(from, itinerary("Lisbon", "Belem Tower", "Jeronimos Monastery", "LX Factory")).Empty())
+
+	renamed := Compare(from, itinerary("Lisbon in a day", "Belem Tower", "Jeronimos Monastery", "LX Factory"))
+	assert.Equal(t, "Lisbon", renamed.NameFrom)
+	assert.Equal(t, "Lisbon in a day", renamed.NameTo)
+	assert.False(t, renamed.Empty())
+
+	assert.Len(t, Compare(nil, from).Added, 3)
+}
+
+func TestRecord_StoresInitialVersionFirst(t *testing.T) {
+	repo := new(MockRepository)
+	before, after := itinerary("Lisbon", "Belem Tower"), itinerary("Lisbon", "Belem Tower", "Oceanarium")
+	repo.On("Latest", mock.Anything, sessionID).Return(0, nil)
+	repo.On("Insert", mock.Anything, Version{SessionID: sessionID, ChangeType: ChangeInitial, Snapshot: before}).Return(1, nil).Once()
+	repo.On("Insert", mock.Anything, Version{SessionID: sessionID, ChangeType: "add_poi", Description: "add the oceanarium", Snapshot: after}).Return(2, nil).Once()
+
+	n, err := NewService(repo, zap.NewNop()).Record(context.Background(), sessionID, before, after, "add_poi", "add the oceanarium")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	repo.AssertExpectations(t)
+}
+
+func TestRecord_ExistingHistory(t *testing.T) {
+	repo := new(MockRepository)
+	before, after := itinerary("Lisbon", "Belem Tower"), itinerary("Lisbon")
+	repo.On("Latest", mock.Anything, sessionID).Return(4, nil)
+	repo.On("Insert", mock.Anything, mock.MatchedBy(func(v Version) bool { return v.ChangeType == "remove_poi" })).Return(5, nil).Once()
+
+	n, err := NewService(repo, zap.NewNop()).Record(context.Background(), sessionID, before, after, "remove_poi", "remove belem")
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	repo.AssertNumberOfCalls(t, "Insert", 1)
+
+	_, err = NewService(repo, zap.NewNop()).Record(context.Background(), sessionID, before, nil, "remove_poi", "")
+	assert.True(t, errors.Is(err, models.ErrBadRequest))
+}
+
+func TestDiff(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("SessionOwner", mock.Anything, sessionID).Return(userID, nil)
+	repo.On("Get", mock.Anything, sessionID, 1).Return(&Version{Number: 1, Snapshot: itinerary("Lisbon", "Belem Tower")}, nil)
+	repo.On("Get", mock.Anything, sessionID, 3).Return(&Version{Number: 3, Snapshot: itinerary("Lisbon", "Oceanarium")}, nil)
+
+	d, err := NewService(repo, zap.NewNop()).Diff(context.Background(), userID, sessionID, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, d.From)
+	assert.Equal(t, 3, d.To)
+	assert.Equal(t, []POIRef{{Name: "Oceanarium"}}, d.Added)
+	assert.Equal(t, []POIRef{{Name: "Belem Tower"}}, d.Removed)
+}
+
+func TestList_OtherUsersSessionIsNotFound(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("SessionOwner", mock.Anything, sessionID).Return(uuid.New(), nil)
+
+	_, err := NewService(repo, zap.NewNop()).List(context.Background(), userID, sessionID)
+	assert.True(t, errors.Is(err, models.ErrNotFound))
+	repo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestBranch(t *testing.T) {
+	repo := new(MockRepository)
+	branchID := uuid.New()
+	snapshot := itinerary("Lisbon", "Belem Tower")
+	repo.On("MarkBranch", mock.Anything, branchID, sessionID, 2).Return(nil)
+	repo.On("Insert", mock.Anything, mock.MatchedBy(func(v Version) bool {
+		return v.SessionID == branchID && v.ChangeType == ChangeBranch && v.Snapshot == snapshot
+	})).Return(1, nil)
+
+	n, err := NewService(repo, zap.NewNop()).Branch(context.Background(), branchID, sessionID, 2, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	repo.AssertExpectations(t)
+}
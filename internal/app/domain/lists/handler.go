@@ -1,6 +1,7 @@
 package lists
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
@@ -13,8 +14,14 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 )
 
+// ListRouter reports the travel legs between the items of a list.
+type ListRouter interface {
+	Route(ctx context.Context, userID, listID uuid.UUID) (*models.ListRoute, error)
+}
+
 type Handler struct {
 	service Service
+	routes  ListRouter // nil shows lists without travel legs
 	log     *zap.Logger
 }
 
@@ -25,6 +32,12 @@ func NewHandler(service Service, log *zap.Logger) *Handler {
 	}
 }
 
+// WithRoutes shows the distance and travel time between the items on list detail pages.
+func (h *Handler) WithRoutes(r ListRouter) *Handler {
+	h.routes = r
+	return h
+}
+
 // ShowListsPage renders the main lists page with user's lists
 func (h *Handler) ShowListsPage(c *gin.Context) {
 	// Get user ID from context
@@ -333,10 +346,21 @@ func (h *Handler) ShowListDetail(c *gin.Context) {
 		return
 	}
 
+	// Travel legs are extra, the list shows without them
+	var route *models.ListRoute
+	if h.routes != nil {
+		route, err = h.routes.Route(c.Request.Context(), userID, listID)
+		if err != nil {
+			h.log.Warn("Failed to route list",
+				zap.String("listID", listID.String()),
+				zap.Error(err))
+		}
+	}
+
 	// Render the list detail page
 	c.HTML(http.StatusOK, "", pages.LayoutPage(models.LayoutTempl{
 		Title:   listWithItems.List.Name + " - Lists - Loci",
-		Content: ListDetailPage(listWithItems),
+		Content: ListDetailPage(listWithItems, route),
 		Nav: models.Navigation{
 			Items: []models.NavItem{
 				{Name: "Dashboard", URL: "/dashboard"},
@@ -0,0 +1,168 @@
+package localization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	UserLanguage(ctx context.Context, userID uuid.UUID) (string, error)
+	// POIDescriptions returns the stored description of every POI, with its translation into
+	// language when there is one.
+	POIDescriptions(ctx context.Context, language string, poiIDs []uuid.UUID) ([]Stored, error)
+	CityDescription(ctx context.Context, language string, cityID uuid.UUID) (Stored, error)
+	SavePOITranslation(ctx context.Context, language string, poiID uuid.UUID, description, source string) error
+	SaveCityTranslation(ctx context.Context, language string, cityID uuid.UUID, description, source string) error
+	// RecordPOI stores a generated description, setting the language of the POI row when the
+	// POI was created from it.
+	RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool) error
+	RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool) error
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) UserLanguage(ctx context.Context, userID uuid.UUID) (string, error) {
+	var language *string
+	err := r.pgpool.QueryRow(ctx, `SELECT language FROM users WHERE id = $1`, userID).Scan(&language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("user %s: %w", userID, models.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query user language: %w", err)
+	}
+	if language == nil {
+		return "", nil
+	}
+	return *language, nil
+}
+
+func (r *RepositoryImpl) POIDescriptions(ctx context.Context, language string, poiIDs []uuid.UUID) ([]Stored, error) {
+	ctx, span := otel.Tracer("LocalizationRepository").Start(ctx, "POIDescriptions", trace.WithAttributes(
+		attribute.String("language", language),
+		attribute.Int("pois.count", len(poiIDs)),
+	))
+	defer span.End()
+
+	query := `
+		SELECT p.id, p.content_language, COALESCE(p.description, p.ai_summary, ''), t.description
+		FROM points_of_interest p
+		LEFT JOIN poi_translations t ON t.poi_id = p.id AND t.language = $1
+		WHERE p.id = ANY($2)`
+	rows, err := r.pgpool.Query(ctx, query, language, poiIDs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query POI descriptions")
+		return nil, fmt.Errorf("failed to query POI descriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var stored []Stored
+	for rows.Next() {
+		var st Stored
+		if err := rows.Scan(&st.ID, &st.ContentLanguage, &st.Description, &st.Translation); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan POI description: %w", err)
+		}
+		stored = append(stored, st)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate POI descriptions: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "POI descriptions read")
+	return stored, nil
+}
+
+func (r *RepositoryImpl) CityDescription(ctx context.Context, language string, cityID uuid.UUID) (Stored, error) {
+	ctx, span := otel.Tracer("LocalizationRepository").Start(ctx, "CityDescription", trace.WithAttributes(
+		attribute.String("language", language),
+		attribute.String("city.id", cityID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT c.id, c.content_language, COALESCE(c.ai_summary, ''), t.description
+		FROM cities c
+		LEFT JOIN city_translations t ON t.city_id = c.id AND t.language = $1
+		WHERE c.id = $2`
+	var st Stored
+	err := r.pgpool.QueryRow(ctx, query, language, cityID).Scan(&st.ID, &st.ContentLanguage, &st.Description, &st.Translation)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Stored{}, fmt.Errorf("city %s: %w", cityID, models.ErrNotFound)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query city description")
+		return Stored{}, fmt.Errorf("failed to query city description: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "City description read")
+	return st, nil
+}
+
+// A generated description replaces a translated one; a translation never replaces anything.
+const upsertTranslation = `
+	ON CONFLICT (%[1]s, language) DO UPDATE SET
+		description = EXCLUDED.description, source = EXCLUDED.source, updated_at = NOW()
+	WHERE EXCLUDED.source = 'generated'`
+
+func (r *RepositoryImpl) SavePOITranslation(ctx context.Context, language string, poiID uuid.UUID, description, source string) error {
+	query := `INSERT INTO poi_translations (poi_id, language, description, source) VALUES ($1, $2, $3, $4)` +
+		fmt.Sprintf(upsertTranslation, "poi_id")
+	if _, err := r.pgpool.Exec(ctx, query, poiID, language, description, source); err != nil {
+		return fmt.Errorf("failed to save POI translation: %w", err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) SaveCityTranslation(ctx context.Context, language string, cityID uuid.UUID, description, source string) error {
+	query := `INSERT INTO city_translations (city_id, language, description, source) VALUES ($1, $2, $3, $4)` +
+		fmt.Sprintf(upsertTranslation, "city_id")
+	if _, err := r.pgpool.Exec(ctx, query, cityID, language, description, source); err != nil {
+		return fmt.Errorf("failed to save city translation: %w", err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool) error {
+	if created {
+		if _, err := r.pgpool.Exec(ctx, `UPDATE points_of_interest SET content_language = $1 WHERE id = $2`, language, poiID); err != nil {
+			return fmt.Errorf("failed to set POI content language: %w", err)
+		}
+	}
+	return r.SavePOITranslation(ctx, language, poiID, description, SourceGenerated)
+}
+
+func (r *RepositoryImpl) RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool) error {
+	if created {
+		if _, err := r.pgpool.Exec(ctx, `UPDATE cities SET content_language = $1 WHERE id = $2`, language, cityID); err != nil {
+			return fmt.Errorf("failed to set city content language: %w", err)
+		}
+	}
+	return r.SaveCityTranslation(ctx, language, cityID, description, SourceGenerated)
+}
@@ -0,0 +1,292 @@
+// Package localization serves AI-generated content in the user's language. Prompts ask for
+// answers in the language of users.language, descriptions are stored per language as they are
+// generated, and content only stored in another language is translated on demand and kept.
+package localization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// DefaultLanguage is the language of users without one and of content stored before
+// descriptions were kept per language.
+const DefaultLanguage = "en"
+
+// Translation sources, as stored in poi_translations.source and city_translations.source.
+const (
+	SourceGenerated  = "generated"
+	SourceTranslated = "translated"
+)
+
+// languageNames are the languages content can be requested in, by ISO 639-1 code.
+var languageNames = map[string]string{
+	"ar": "Arabic",
+	"ca": "Catalan",
+	"cs": "Czech",
+	"da": "Danish",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fi": "Finnish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"hu": "Hungarian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"no": "Norwegian",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"th": "Thai",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// Normalize reduces a locale such as "pt-BR" or "PT_pt" to its supported language code,
+// falling back to DefaultLanguage.
+func Normalize(locale string) string {
+	code := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := languageNames[code]; ok {
+		return code
+	}
+	return DefaultLanguage
+}
+
+// Name returns the English name of a language code, as prompts spell it.
+func Name(code string) string {
+	return languageNames[Normalize(code)]
+}
+
+// Stored is the description stored for a POI or city, with the one in the requested language
+// when there is one.
+type Stored struct {
+	ID              uuid.UUID
+	ContentLanguage string // language of the description on the POI or city row
+	Description     string
+	Translation     *string
+}
+
+// Translator translates texts into the language named by code.
+type Translator interface {
+	Translate(ctx context.Context, language string, texts []string) ([]string, error)
+}
+
+type Service interface {
+	// Language returns the language of the user's content, DefaultLanguage when unknown.
+	Language(ctx context.Context, userID uuid.UUID) string
+	// LocalizePOIs replaces the descriptions of stored POIs with those in language,
+	// translating the ones only stored in another. POIs without an id are left as they are.
+	LocalizePOIs(ctx context.Context, language string, pois []models.POIDetailedInfo) []models.POIDetailedInfo
+	// LocalizeCity returns the description of a stored city in language.
+	LocalizeCity(ctx context.Context, language string, cityID uuid.UUID, description string) string
+	// RecordPOI stores a description generated in language. A new POI takes language as the
+	// language of its row.
+	RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool)
+	// RecordCity stores a city description generated in language.
+	RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool)
+}
+
+type ServiceImpl struct {
+	repo       Repository
+	translator Translator
+	logger     *zap.Logger
+}
+
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// WithTranslator translates content stored in another language on demand. Without one such
+// content is served as stored.
+func (s *ServiceImpl) WithTranslator(t Translator) *ServiceImpl {
+	s.translator = t
+	return s
+}
+
+func (s *ServiceImpl) Language(ctx context.Context, userID uuid.UUID) string {
+	if userID == uuid.Nil {
+		return DefaultLanguage
+	}
+	language, err := s.repo.UserLanguage(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to read user language, using default", zap.String("user_id", userID.String()), zap.Error(err))
+		return DefaultLanguage
+	}
+	return Normalize(language)
+}
+
+func (s *ServiceImpl) LocalizePOIs(ctx context.Context, language string, pois []models.POIDetailedInfo) []models.POIDetailedInfo {
+	ctx, span := otel.Tracer("LocalizationService").Start(ctx, "LocalizePOIs", trace.WithAttributes(
+		attribute.String("language", language),
+		attribute.Int("pois.count", len(pois)),
+	))
+	defer span.End()
+
+	var ids []uuid.UUID
+	for _, p := range pois {
+		if p.ID != uuid.Nil {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return pois
+	}
+
+	stored, err := s.repo.POIDescriptions(ctx, language, ids)
+	if err != nil {
+		s.logger.Warn("Failed to read POI translations", zap.String("language", language), zap.Error(err))
+		return pois
+	}
+	descriptions := s.localize(ctx, language, stored, s.repo.SavePOITranslation)
+
+	out := make([]models.POIDetailedInfo, len(pois))
+	for i, p := range pois {
+		if d, ok := descriptions[p.ID]; ok && d != "" {
+			p.DescriptionPOI = d
+		}
+		out[i] = p
+	}
+	return out
+}
+
+func (s *ServiceImpl) LocalizeCity(ctx context.Context, language string, cityID uuid.UUID, description string) string {
+	if cityID == uuid.Nil {
+		return description
+	}
+	stored, err := s.repo.CityDescription(ctx, language, cityID)
+	if err != nil {
+		s.logger.Warn("Failed to read city translation", zap.String("language", language), zap.Error(err))
+		return description
+	}
+	if d := s.localize(ctx, language, []Stored{stored}, s.repo.SaveCityTranslation)[cityID]; d != "" {
+		return d
+	}
+	return description
+}
+
+// localize returns the description in language of every stored row, translating in one call
+// those that have none and saving the translations with save.
+func (s *ServiceImpl) localize(ctx context.Context, language string, stored []Stored,
+	save func(ctx context.Context, language string, id uuid.UUID, description, source string) error) map[uuid.UUID]string {
+	descriptions := make(map[uuid.UUID]string, len(stored))
+	var missing []Stored
+	for _, st := range stored {
+		switch {
+		case st.Translation != nil:
+			descriptions[st.ID] = *st.Translation
+		case st.ContentLanguage == language || strings.TrimSpace(st.Description) == "":
+			descriptions[st.ID] = st.Description
+		default:
+			missing = append(missing, st)
+		}
+	}
+	if len(missing) == 0 {
+		return descriptions
+	}
+	if s.translator == nil {
+		for _, st := range missing {
+			descriptions[st.ID] = st.Description
+		}
+		return descriptions
+	}
+
+	texts := make([]string, len(missing))
+	for i, st := range missing {
+		texts[i] = st.Description
+	}
+	translated, err := s.translator.Translate(ctx, language, texts)
+	if err != nil {
+		s.logger.Warn("Failed to translate stored content, serving it as stored",
+			zap.String("language", language), zap.Int("texts", len(texts)), zap.Error(err))
+		for _, st := range missing {
+			descriptions[st.ID] = st.Description
+		}
+		return descriptions
+	}
+	for i, st := range missing {
+		descriptions[st.ID] = translated[i]
+		if err := save(ctx, language, st.ID, translated[i], SourceTranslated); err != nil {
+			s.logger.Warn("Failed to save translation", zap.String("id", st.ID.String()), zap.Error(err))
+		}
+	}
+	return descriptions
+}
+
+func (s *ServiceImpl) RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool) {
+	if poiID == uuid.Nil || strings.TrimSpace(description) == "" {
+		return
+	}
+	if err := s.repo.RecordPOI(ctx, language, poiID, description, created); err != nil {
+		s.logger.Warn("Failed to record POI description language", zap.String("poi_id", poiID.String()), zap.Error(err))
+	}
+}
+
+func (s *ServiceImpl) RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool) {
+	if cityID == uuid.Nil || strings.TrimSpace(description) == "" {
+		return
+	}
+	if err := s.repo.RecordCity(ctx, language, cityID, description, created); err != nil {
+		s.logger.Warn("Failed to record city description language", zap.String("city_id", cityID.String()), zap.Error(err))
+	}
+}
+
+// LLMTranslator translates with the chat LLM provider.
+type LLMTranslator struct {
+	provider llmprovider.Provider
+}
+
+func NewLLMTranslator(provider llmprovider.Provider) *LLMTranslator {
+	return &LLMTranslator{provider: provider}
+}
+
+func (t *LLMTranslator) Translate(ctx context.Context, language string, texts []string) ([]string, error) {
+	prompt, err := prompts.Translate.Render("", prompts.TranslateParams{Language: Name(language), Texts: texts})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.provider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](0.1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate translation: %w", err)
+	}
+
+	text := strings.TrimSpace(llmprovider.TextFromResponse(resp))
+	text = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```"), "```")
+	var translated []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &translated); err != nil {
+		return nil, fmt.Errorf("failed to parse translation: %w", err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("translation returned %d texts for %d", len(translated), len(texts))
+	}
+	return translated, nil
+}
@@ -0,0 +1,169 @@
+package localization
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) UserLanguage(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRepository) POIDescriptions(ctx context.Context, language string, poiIDs []uuid.UUID) ([]Stored, error) {
+	args := m.Called(ctx, language, poiIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Stored), args.Error(1)
+}
+
+func (m *MockRepository) CityDescription(ctx context.Context, language string, cityID uuid.UUID) (Stored, error) {
+	args := m.Called(ctx, language, cityID)
+	return args.Get(0).(Stored), args.Error(1)
+}
+
+func (m *MockRepository) SavePOITranslation(ctx context.Context, language string, poiID uuid.UUID, description, source string) error {
+	args := m.Called(ctx, language, poiID, description, source)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveCityTranslation(ctx context.Context, language string, cityID uuid.UUID, description, source string) error {
+	args := m.Called(ctx, language, cityID, description, source)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordPOI(ctx context.Context, language string, poiID uuid.UUID, description string, created bool) error {
+	args := m.Called(ctx, language, poiID, description, created)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordCity(ctx context.Context, language string, cityID uuid.UUID, description string, created bool) error {
+	args := m.Called(ctx, language, cityID, description, created)
+	return args.Error(0)
+}
+
+type fakeTranslator struct {
+	calls [][]string
+	err   error
+}
+
+func (t *fakeTranslator) Translate(_ context.Context, language string, texts []string) ([]string, error) {
+	t.calls = append(t.calls, texts)
+	if t.err != nil {
+		return nil, t.err
+	}
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = language + ": " + text
+	}
+	return out, nil
+}
+
+func str(s string) *string { return &s }
+
+var (
+	userID = uuid.MustParse("7a4c1d0e-3f5b-4b6a-8c9d-0e1f2a3b4c01")
+	cityID = uuid.MustParse("7a4c1d0e-3f5b-4b6a-8c9d-0e1f2a3b4c02")
+	poiA   = uuid.MustParse("7a4c1d0e-3f5b-4b6a-8c9d-0e1f2a3b4c03")
+	poiB   = uuid.MustParse("7a4c1d0e-3f5b-4b6a-8c9d-0e1f2a3b4c04")
+	poiC   = uuid.MustParse("7a4c1d0e-3f5b-4b6a-8c9d-0e1f2a3b4c05")
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "pt", Normalize("pt-BR"))
+	assert.Equal(t, "pt", Normalize(" PT_pt "))
+	assert.Equal(t, "de", Normalize("de"))
+	assert.Equal(t, DefaultLanguage, Normalize(""))
+	assert.Equal(t, DefaultLanguage, Normalize("klingon"))
+	assert.Equal(t, "Portuguese", Name("pt-PT"))
+}
+
+func TestLanguage(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("UserLanguage", mock.Anything, userID).Return("fr-FR", nil)
+	s := NewService(repo, zap.NewNop())
+
+	assert.Equal(t, "fr", s.Language(context.Background(), userID))
+	assert.Equal(t, DefaultLanguage, s.Language(context.Background(), uuid.Nil))
+
+	failing := new(MockRepository)
+	failing.On("UserLanguage", mock.Anything, userID).Return("", errors.New("db down"))
+	assert.Equal(t, DefaultLanguage, NewService(failing, zap.NewNop()).Language(context.Background(), userID))
+}
+
+func TestLocalizePOIs_TranslatesOnlyWhatIsMissing(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("POIDescriptions", mock.Anything, "pt", []uuid.UUID{poiA, poiB, poiC}).Return([]Stored{
+		{ID: poiA, ContentLanguage: "en", Description: "A tower", Translation: str("Uma torre")},
+		{ID: poiB, ContentLanguage: "pt", Description: "Um mosteiro"},
+		{ID: poiC, ContentLanguage: "en", Description: "A bridge"},
+	}, nil)
+	repo.On("SavePOITranslation", mock.Anything, "pt", poiC, "pt: A bridge", SourceTranslated).Return(nil)
+
+	translator := &fakeTranslator{}
+	s := NewService(repo, zap.NewNop()).WithTranslator(translator)
+	pois := s.LocalizePOIs(context.Background(), "pt", []models.POIDetailedInfo{
+		{ID: poiA, DescriptionPOI: "A tower"},
+		{ID: poiB, DescriptionPOI: "Um mosteiro"},
+		{ID: poiC, DescriptionPOI: "A bridge"},
+		{Name: "Not stored", DescriptionPOI: "Fresh from the model"},
+	})
+
+	assert.Equal(t, "Uma torre", pois[0].DescriptionPOI)
+	assert.Equal(t, "Um mosteiro", pois[1].DescriptionPOI)
+	assert.Equal(t, "pt: A bridge", pois[2].DescriptionPOI)
+	assert.Equal(t, "Fresh from the model", pois[3].DescriptionPOI)
+	assert.Equal(t, [][]string{{"A bridge"}}, translator.calls)
+	repo.AssertExpectations(t)
+}
+
+func TestLocalizePOIs_TranslatorFailureServesStored(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("POIDescriptions", mock.Anything, "de", []uuid.UUID{poiC}).Return([]Stored{
+		{ID: poiC, ContentLanguage: "en", Description: "A bridge"},
+	}, nil)
+
+	s := NewService(repo, zap.NewNop()).WithTranslator(&fakeTranslator{err: errors.New("quota")})
+	pois := s.LocalizePOIs(context.Background(), "de", []models.POIDetailedInfo{{ID: poiC, DescriptionPOI: "A bridge"}})
+
+	assert.Equal(t, "A bridge", pois[0].DescriptionPOI)
+	repo.AssertNotCalled(t, "SavePOITranslation", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLocalizeCity(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("CityDescription", mock.Anything, "es", cityID).Return(Stored{
+		ID: cityID, ContentLanguage: "en", Description: "A hilly city",
+	}, nil)
+	repo.On("SaveCityTranslation", mock.Anything, "es", cityID, "es: A hilly city", SourceTranslated).Return(nil)
+
+	s := NewService(repo, zap.NewNop()).WithTranslator(&fakeTranslator{})
+	assert.Equal(t, "es: A hilly city", s.LocalizeCity(context.Background(), "es", cityID, "A hilly city"))
+	assert.Equal(t, "unsaved", s.LocalizeCity(context.Background(), "es", uuid.Nil, "unsaved"))
+	repo.AssertExpectations(t)
+}
+
+func TestRecordPOI_SkipsEmptyDescriptions(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("RecordPOI", mock.Anything, "it", poiA, "Una torre", true).Return(nil)
+
+	s := NewService(repo, zap.NewNop())
+	s.RecordPOI(context.Background(), "it", poiA, "Una torre", true)
+	s.RecordPOI(context.Background(), "it", poiB, "  ", false)
+
+	repo.AssertNumberOfCalls(t, "RecordPOI", 1)
+}
@@ -14,9 +14,11 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/genai"
 
-	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/location"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
 )
 
 var upgrader = websocket.Upgrader{
@@ -30,7 +32,7 @@ var upgrader = websocket.Upgrader{
 
 type NearbyHandler struct {
 	logger           *zap.Logger
-	chatService      *llmchat.ServiceImpl
+	llmProvider      llmprovider.Provider
 	locationRepo     location.Repository
 	connections      map[*websocket.Conn]bool
 	connectionsMu    sync.RWMutex
@@ -54,10 +56,10 @@ type MessageRateLimiter struct {
 	logger      *zap.Logger
 }
 
-func NewNearbyHandler(logger *zap.Logger, chatService *llmchat.ServiceImpl, locationRepo location.Repository) *NearbyHandler {
+func NewNearbyHandler(logger *zap.Logger, llmProvider llmprovider.Provider, locationRepo location.Repository) *NearbyHandler {
 	return &NearbyHandler{
 		logger:       logger,
-		chatService:  chatService,
+		llmProvider:  llmProvider,
 		locationRepo: locationRepo,
 		connections:  make(map[*websocket.Conn]bool),
 		messageLimiter: &MessageRateLimiter{
@@ -79,6 +81,10 @@ type LocationUpdate struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 	Radius    float64 `json:"radius"`
+	// OpenNow and OpenAt ("2006-01-02T15:04", local time) keep the places open then
+	OpenNow  bool   `json:"open_now,omitempty"`
+	OpenAt   string `json:"open_at,omitempty"`
+	Timezone string `json:"timezone,omitempty"` // IANA name from the browser, e.g. Europe/Lisbon
 }
 
 // POIResponse represents a single POI in the response
@@ -92,6 +98,26 @@ type POIResponse struct {
 	Distance    float64 `json:"distance"`
 	Latitude    float64 `json:"latitude"`
 	Longitude   float64 `json:"longitude"`
+	// OpeningHours is in OpenStreetMap form, "" when unknown; OpenNow is nil then
+	OpeningHours string `json:"opening_hours,omitempty"`
+	OpenNow      *bool  `json:"open_now,omitempty"`
+}
+
+// UnmarshalJSON reads the opening hours models answer with, as a string or as entries per
+// day, and writes them the OpenStreetMap way.
+func (p *POIResponse) UnmarshalJSON(data []byte) error {
+	type Alias POIResponse
+	aux := &struct {
+		OpeningHours json.RawMessage `json:"opening_hours"`
+		*Alias
+	}{
+		Alias: (*Alias)(p),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	p.OpeningHours = openinghours.Decode(aux.OpeningHours).String()
+	return nil
 }
 
 // WebSocketMessage represents messages sent to the client
@@ -253,33 +279,25 @@ func (h *NearbyHandler) HandleWebSocket(c *gin.Context) {
 // getNearbyPOIs fetches POIs from the AI service
 func (h *NearbyHandler) getNearbyPOIs(ctx context.Context, update LocationUpdate, userID string) ([]POIResponse, error) {
 	// Create a prompt for the AI
-	prompt := fmt.Sprintf(`Find interesting places near coordinates %.6f, %.6f within %.1f km radius.
-
-Return a JSON array of 5-10 diverse places including restaurants, cafes, attractions, parks, museums, etc.
-Each place should have:
-- id: unique identifier
-- name: place name
-- category: type of place (restaurant, cafe, museum, park, etc.)
-- description: brief description (max 100 chars)
-- emoji: relevant emoji for the category
-- rating: rating from 1.0 to 5.0
-- latitude: approximate latitude
-- longitude: approximate longitude
-
-Focus on real, notable places in that area. Return ONLY valid JSON array, no additional text.`,
-		update.Latitude, update.Longitude, update.Radius)
+	prompt := prompts.NearbyPOIs.MustRender(userID, prompts.NearbyParams{
+		Lat:      update.Latitude,
+		Lon:      update.Longitude,
+		RadiusKm: update.Radius,
+	})
 
 	// Call AI client directly to generate nearby POIs
 	config := &genai.GenerateContentConfig{
 		Temperature: genai.Ptr[float32](0.5),
 	}
 
-	// Access the app aiClient field (we need to make it public or add a method)
-	// For now, let's create a simple wrapper method in the service
-	response, err := h.chatService.GenerateNearbyPOIs(ctx, prompt, config)
+	resp, err := h.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate nearby POIs: %w", err)
 	}
+	response := llmprovider.TextFromResponse(resp)
+	if response == "" {
+		return nil, fmt.Errorf("no valid content from AI")
+	}
 
 	// Parse the AI response
 	var pois []POIResponse
@@ -329,7 +347,34 @@ Focus on real, notable places in that area. Return ONLY valid JSON array, no add
 		}
 	}
 
-	return pois, nil
+	return filterOpen(pois, update, time.Now()), nil
+}
+
+// openAtLayout is how datetime-local inputs send the time to be open at.
+const openAtLayout = "2006-01-02T15:04"
+
+// filterOpen tells which places are open at the time asked for, now by default, and keeps
+// only those when the client filters on it. Places with unknown hours are kept.
+func filterOpen(pois []POIResponse, update LocationUpdate, now time.Time) []POIResponse {
+	loc := openinghours.Location(update.Timezone, update.Longitude)
+	when := now.In(loc)
+	if update.OpenAt != "" {
+		if t, err := time.ParseInLocation(openAtLayout, update.OpenAt, loc); err == nil {
+			when = t
+		}
+	}
+	filtered := pois[:0]
+	for _, poi := range pois {
+		open, known := openinghours.ParseString(poi.OpeningHours).OpenAt(when)
+		if known {
+			poi.OpenNow = &open
+		}
+		if (update.OpenNow || update.OpenAt != "") && !open {
+			continue
+		}
+		filtered = append(filtered, poi)
+	}
+	return filtered
 }
 
 // calculateDistance calculates the distance between two coordinates using the Haversine formula
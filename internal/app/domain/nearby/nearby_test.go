@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
 )
 
 // MockLocationRepository implements location.Repository for testing
@@ -81,10 +82,10 @@ func setupTestServer(t *testing.T) (*httptest.Server, *MockLocationRepository) {
 	mockRepo := NewMockLocationRepository()
 	logger := zap.Default()
 
-	// Create a minimal chat service (we won't actually use AI in tests)
-	chatService := &llmchat.ServiceImpl{}
+	// Fake provider keeps the tests offline and deterministic
+	provider := llmprovider.NewFakeProvider().WithDefaultResponse("[]")
 
-	handler := NewNearbyHandler(logger, chatService, mockRepo)
+	handler := NewNearbyHandler(logger, provider, mockRepo)
 
 	router := gin.New()
 	router.GET("/ws/nearby", handler.HandleWebSocket)
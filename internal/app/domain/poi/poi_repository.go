@@ -20,7 +20,9 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/FACorreiaa/go-templui/internal/app/domain/poimatch"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -137,15 +139,15 @@ func (r *RepositoryImpl) SavePoi(ctx context.Context, poi models.POIDetailedInfo
 
 	query := `
         INSERT INTO points_of_interest (
-            name, description, location, city_id, poi_type, source, ai_summary
+            name, description, location, city_id, poi_type, source, ai_summary, time_to_spend
         ) VALUES (
-            $1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326), $5, $6, $7, $8
+            $1, $2, ST_SetSRID(ST_MakePoint($3, $4), 4326), $5, $6, $7, $8, NULLIF($9, '')
         ) RETURNING id
     `
 	var id uuid.UUID
 	if err = tx.QueryRow(ctx, query,
 		poi.Name, poi.DescriptionPOI, poi.Longitude, poi.Latitude, cityID,
-		poi.Category, "loci_ai", poi.DescriptionPOI,
+		poi.Category, "loci_ai", poi.DescriptionPOI, poi.TimeToSpend,
 	).Scan(&id); err != nil {
 		if err == pgx.ErrNoRows {
 			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
@@ -168,14 +170,20 @@ func (r *RepositoryImpl) SavePoi(ctx context.Context, poi models.POIDetailedInfo
 }
 
 func (r *RepositoryImpl) FindPoiByNameAndCity(ctx context.Context, name string, cityID uuid.UUID) (*models.POIDetailedInfo, error) {
+	// Names the POI was resolved or merged under count as its own
 	query := `
-        SELECT name, description, ST_Y(location) as lat, ST_X(location) as lon, poi_type
+        SELECT id, name, description, ST_Y(location) as lat, ST_X(location) as lon, poi_type
         FROM points_of_interest
-        WHERE name = $1 AND city_id = $2
+        WHERE city_id = $2 AND (
+            name = $1
+            OR id = (SELECT poi_id FROM poi_aliases WHERE city_id = $2 AND alias_normalized = $3)
+        )
+        ORDER BY name = $1 DESC
+        LIMIT 1
     `
 	var poi models.POIDetailedInfo
-	if err := r.pgpool.QueryRow(ctx, query, name, cityID).Scan(
-		&poi.Name, &poi.DescriptionPOI, &poi.Latitude, &poi.Longitude, &poi.Category,
+	if err := r.pgpool.QueryRow(ctx, query, name, cityID, poimatch.Normalize(name)).Scan(
+		&poi.ID, &poi.Name, &poi.DescriptionPOI, &poi.Latitude, &poi.Longitude, &poi.Category,
 	); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -1135,10 +1143,10 @@ func (r *RepositoryImpl) SavePOIDetails(ctx context.Context, poi models.POIDetai
         INSERT INTO points_of_interest (
             id, name, description, location, city_id, address, poi_type,
             website, phone_number, opening_hours, category, price_level,
-            average_rating, source, ai_summary, tags
+            average_rating, source, ai_summary, tags, time_to_spend
         ) VALUES (
             $1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, $7, $8,
-            $9, $10, $11, $12, $13, $14, $15, $16, $17
+            $9, $10, $11, $12, $13, $14, $15, $16, $17, NULLIF($18, '')
         )
     `
 	_, err = tx.Exec(ctx, poisQuery,
@@ -1147,7 +1155,7 @@ func (r *RepositoryImpl) SavePOIDetails(ctx context.Context, poi models.POIDetai
 		cityID, poi.Address, poi.Category,
 		poi.Website, poi.PhoneNumber, poi.OpeningHours,
 		poi.Category, priceLevel, poi.Rating,
-		"loci_ai", poi.Description, poi.Tags,
+		"loci_ai", poi.Description, poi.Tags, poi.TimeToSpend,
 	)
 	if err != nil {
 		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
@@ -1556,22 +1564,26 @@ func (r *RepositoryImpl) SearchPOIs(ctx context.Context, filter models.POIFilter
 
 	l := r.logger.With(zap.String("method", "SearchPOIs"))
 
-	// Base query using PostGIS for geospatial filtering
+	// Base query using PostGIS for geospatial filtering. Opening hours are read in the time
+	// zone of the POI's city, so filtering on them happens once they are decoded.
 	query := `
         SELECT
-            id,
-            name,
-            description,
-            ST_X(location::geometry) AS longitude,
-            ST_Y(location::geometry) AS latitude,
-            category,
+            p.id,
+            p.name,
+            p.description,
+            ST_X(p.location::geometry) AS longitude,
+            ST_Y(p.location::geometry) AS latitude,
+            p.category,
             ST_Distance(
-                location,
+                p.location,
                 ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography
-            ) AS distance_meters
-        FROM points_of_interest
+            ) AS distance_meters,
+            p.opening_hours,
+            COALESCE(c.timezone, '') AS timezone
+        FROM points_of_interest p
+        LEFT JOIN cities c ON c.id = p.city_id
         WHERE ST_DWithin(
-            location,
+            p.location,
             ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
             $3
         )
@@ -1584,13 +1596,20 @@ func (r *RepositoryImpl) SearchPOIs(ctx context.Context, filter models.POIFilter
 
 	// Add category filter if provided
 	if filter.Category != "" {
-		query += ` AND category = $4`
+		query += ` AND p.category = $4`
 		args = append(args, filter.Category) // $4
 	}
 
 	// Order by distance
 	query += ` ORDER BY distance_meters ASC`
 
+	when := time.Now()
+	if filter.OpenAt != nil {
+		when = *filter.OpenAt
+	}
+	openFilter := filter.OpenNow || filter.OpenAt != nil
+	span.SetAttributes(attribute.Bool("filter.open", openFilter))
+
 	l.Debug("Executing POI search query", zap.String("query", query), zap.Any("args", args))
 
 	// Execute query
@@ -1609,6 +1628,8 @@ func (r *RepositoryImpl) SearchPOIs(ctx context.Context, filter models.POIFilter
 		var poi models.POIDetailedInfo
 		var distanceMeters float64
 		var description sql.NullString // Handle NULL description
+		var openingHours []byte
+		var timezone string
 
 		err := rows.Scan(
 			&poi.ID,
@@ -1618,6 +1639,8 @@ func (r *RepositoryImpl) SearchPOIs(ctx context.Context, filter models.POIFilter
 			&poi.Latitude,
 			&poi.Category,
 			&distanceMeters,
+			&openingHours,
+			&timezone,
 		)
 		if err != nil {
 			l.Error("Failed to scan POI row", zap.Any("error", err))
@@ -1633,6 +1656,14 @@ func (r *RepositoryImpl) SearchPOIs(ctx context.Context, filter models.POIFilter
 		// Convert distance from meters to kilometers
 		poi.Distance = distanceMeters / 1000
 
+		open, known := openinghours.Decode(openingHours).OpenAt(when.In(openinghours.Location(timezone, poi.Longitude)))
+		if known {
+			poi.OpenNow = &open
+		}
+		if openFilter && !open {
+			continue
+		}
+
 		pois = append(pois, poi)
 	}
 
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -20,12 +19,13 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
-
 	"github.com/FACorreiaa/go-templui/internal/app/domain/city"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 	cache2 "github.com/FACorreiaa/go-templui/internal/pkg/cache"
 	"github.com/FACorreiaa/go-templui/internal/pkg/llmlogging"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
 )
 
 var _ Service = (*ServiceImpl)(nil)
@@ -88,39 +88,37 @@ type Service interface {
 type ServiceImpl struct {
 	logger             *zap.Logger
 	poiRepository      Repository
-	embeddingService   *generativeAI.EmbeddingService
-	aiClient           *generativeAI.LLMChatClient
+	llmProvider        llmprovider.Provider // generation and embeddings, nil disables AI features
 	cityRepo           city.Repository
 	cache              *cache.Cache
 	llmInteractionRepo llmlogging.Repository
 }
 
 func NewServiceImpl(poiRepository Repository,
-	embeddingService *generativeAI.EmbeddingService,
+	llmProvider llmprovider.Provider,
 	cityRepo city.Repository,
 	llmInteractionRepo llmlogging.Repository,
 	logger *zap.Logger) *ServiceImpl {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	aiClient, err := generativeAI.NewLLMChatClient(context.Background(), apiKey)
-	if err != nil {
-		logger.Error("Failed to initialize AI client", zap.Any("error", err))
-		// For now, set to nil and handle gracefully in methods
-		aiClient = nil
-	}
-
 	return &ServiceImpl{
 		logger:             logger,
 		poiRepository:      poiRepository,
-		aiClient:           aiClient,
+		llmProvider:        llmProvider,
 		cityRepo:           cityRepo,
 		cache:              cache.New(5*time.Minute, 10*time.Minute),
-		embeddingService:   embeddingService,
 		llmInteractionRepo: llmInteractionRepo,
 	}
 }
 
 // Helper functions for LLM logging without circular dependency
 
+// llmIdentity returns the model and provider names to log, empty when no provider is configured
+func (s *ServiceImpl) llmIdentity() (modelName, provider string) {
+	if s.llmProvider == nil {
+		return "", ""
+	}
+	return s.llmProvider.Model(), s.llmProvider.Name()
+}
+
 // calculateCost estimates the cost in USD for an LLM interaction
 func calculateCost(modelName string, promptTokens, completionTokens int) float64 {
 	// Gemini pricing (as of 2024)
@@ -145,7 +143,7 @@ func calculateCost(modelName string, promptTokens, completionTokens int) float64
 }
 
 // logLLMInteractionAsync logs an LLM interaction asynchronously
-func (s *ServiceImpl) logLLMInteractionAsync(ctx context.Context, userID, sessionID uuid.UUID, intent, searchType, prompt, modelName, provider, responseText, errorMessage string, temperature *float32, promptTokens, completionTokens, totalTokens, statusCode int, latencyMs int64) {
+func (s *ServiceImpl) logLLMInteractionAsync(ctx context.Context, userID, sessionID uuid.UUID, intent, searchType string, prompt prompts.Rendered, modelName, provider, responseText, errorMessage string, temperature *float32, promptTokens, completionTokens, totalTokens, statusCode int, latencyMs int64) {
 	// Create a new context for async operation to avoid cancellation when request ends
 	asyncCtx := context.WithoutCancel(ctx)
 
@@ -156,7 +154,9 @@ func (s *ServiceImpl) logLLMInteractionAsync(ctx context.Context, userID, sessio
 			RequestID:        uuid.New(),
 			SessionID:        sessionID,
 			UserID:           userID,
-			Prompt:           prompt,
+			Prompt:           prompt.Text,
+			PromptID:         prompt.ID,
+			PromptVersion:    prompt.Version,
 			ResponseText:     responseText,
 			ModelUsed:        modelName,
 			Provider:         provider,
@@ -463,7 +463,7 @@ func (s *ServiceImpl) SearchPOIsSemantic(ctx context.Context, query string, limi
 
 	l := s.logger.With(zap.String("method", "SearchPOIsSemantic"))
 
-	if s.embeddingService == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.Error("Embedding service not initialized", zap.Any("error", err))
 		span.RecordError(err)
@@ -498,7 +498,7 @@ func (s *ServiceImpl) SearchPOIsSemantic(ctx context.Context, query string, limi
 		span.SetAttributes(attribute.Bool("embedding.cached", true))
 	} else {
 		// Generate embedding for the query
-		queryEmbedding, err = s.embeddingService.GenerateQueryEmbedding(ctx, query)
+		queryEmbedding, err = s.llmProvider.GenerateQueryEmbedding(ctx, query)
 		if err != nil {
 			l.Error("Failed to generate query embedding",
 				zap.Any("error", err),
@@ -575,7 +575,7 @@ func (s *ServiceImpl) SearchPOIsSemanticByCity(ctx context.Context, query string
 
 	l := s.logger.With(zap.String("method", "SearchPOIsSemanticByCity"))
 
-	if s.embeddingService == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.Error("Embedding service not initialized", zap.Any("error", err))
 		span.RecordError(err)
@@ -613,7 +613,7 @@ func (s *ServiceImpl) SearchPOIsSemanticByCity(ctx context.Context, query string
 		span.SetAttributes(attribute.Bool("embedding.cached", true))
 	} else {
 		// Generate embedding for the query
-		queryEmbedding, err = s.embeddingService.GenerateQueryEmbedding(ctx, query)
+		queryEmbedding, err = s.llmProvider.GenerateQueryEmbedding(ctx, query)
 		if err != nil {
 			l.Error("Failed to generate query embedding",
 				zap.Any("error", err),
@@ -697,7 +697,7 @@ func (s *ServiceImpl) SearchPOIsHybrid(ctx context.Context, filter models.POIFil
 
 	l := s.logger.With(zap.String("method", "SearchPOIsHybrid"))
 
-	if s.embeddingService == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.Error("Embedding service not initialized", zap.Any("error", err))
 		span.RecordError(err)
@@ -752,7 +752,7 @@ func (s *ServiceImpl) SearchPOIsHybrid(ctx context.Context, filter models.POIFil
 		span.SetAttributes(attribute.Bool("embedding.cached", true))
 	} else {
 		// Generate embedding for the query
-		queryEmbedding, err = s.embeddingService.GenerateQueryEmbedding(ctx, query)
+		queryEmbedding, err = s.llmProvider.GenerateQueryEmbedding(ctx, query)
 		if err != nil {
 			l.Error("Failed to generate query embedding",
 				zap.Any("error", err),
@@ -832,7 +832,7 @@ func (s *ServiceImpl) GenerateEmbeddingForPOI(ctx context.Context, poiID uuid.UU
 
 	l := s.logger.With(zap.String("method", "GenerateEmbeddingForPOI"))
 
-	if s.embeddingService == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.Error("Embedding service not initialized", zap.Any("error", err))
 		span.RecordError(err)
@@ -858,7 +858,7 @@ func (s *ServiceImpl) GenerateEmbeddingForPOI(ctx context.Context, poiID uuid.UU
 	poi := pois[0]
 
 	// Generate embedding using POI information
-	embedding, err := s.embeddingService.GeneratePOIEmbedding(ctx, poi.Name, poi.DescriptionPOI, poi.Category)
+	embedding, err := s.llmProvider.GeneratePOIEmbedding(ctx, poi.Name, poi.DescriptionPOI, poi.Category)
 	if err != nil {
 		l.Error("Failed to generate POI embedding",
 			zap.Any("error", err),
@@ -900,7 +900,7 @@ func (s *ServiceImpl) GenerateEmbeddingsForAllPOIs(ctx context.Context, batchSiz
 
 	l := s.logger.With(zap.String("method", "GenerateEmbeddingsForAllPOIs"))
 
-	if s.embeddingService == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("embedding service not available")
 		l.Error("Embedding service not initialized", zap.Any("error", err))
 		span.RecordError(err)
@@ -935,7 +935,7 @@ func (s *ServiceImpl) GenerateEmbeddingsForAllPOIs(ctx context.Context, batchSiz
 		// Process each POI in the batch
 		for _, poi := range pois {
 			// Generate embedding
-			embedding, err := s.embeddingService.GeneratePOIEmbedding(ctx, poi.Name, poi.DescriptionPOI, poi.Category)
+			embedding, err := s.llmProvider.GeneratePOIEmbedding(ctx, poi.Name, poi.DescriptionPOI, poi.Category)
 			if err != nil {
 				l.Error("Failed to generate embedding for POI",
 					zap.Any("error", err),
@@ -1090,10 +1090,10 @@ func (s *ServiceImpl) getGeneralPOIByDistance(wg *sync.WaitGroup,
 	defer span.End()
 	defer wg.Done()
 
-	prompt := getGeneralPOIByDistance(lat, lon, distance)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getGeneralPOIByDistance(userID.String(), lat, lon, distance)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	if s.aiClient == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("AI client is not available - check API key configuration")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI client unavailable")
@@ -1105,12 +1105,11 @@ func (s *ServiceImpl) getGeneralPOIByDistance(wg *sync.WaitGroup,
 	sessionID := uuid.New()
 	intent := "nearby"
 	searchType := "general"
-	modelName := "gemini-2.0-flash"
-	provider := "google"
+	modelName, provider := s.llmIdentity()
 	temperature := config.Temperature
 
 	startTime := time.Now()
-	response, err := s.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := s.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int64(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", int(latencyMs)))
 
@@ -1177,8 +1176,8 @@ func (s *ServiceImpl) getGeneralPOIByDistance(wg *sync.WaitGroup,
 	span.SetStatus(codes.Ok, "General POIs generated successfully")
 	resultCh <- models.GenAIResponse{
 		GeneralPOI: poiData.PointsOfInterest,
-		ModelName:  s.aiClient.ModelName,
-		Prompt:     prompt,
+		ModelName:  s.llmProvider.Model(),
+		Prompt:     prompt.Text,
 		Response:   cleanTxt,
 	}
 }
@@ -1459,6 +1458,7 @@ func (s *ServiceImpl) GetNearbyAttractions(ctx context.Context, userID uuid.UUID
 	enrichedAttractions := s.enrichAndFilterLLMResponse(genAIResponse.GeneralPOI, lat, lon, distance)
 	for i := range enrichedAttractions {
 		enrichedAttractions[i].Source = "llm_suggested_pois"
+		enrichedAttractions[i].Setting = weather.SettingOf(enrichedAttractions[i].Category, enrichedAttractions[i].Tags)
 	}
 
 	s.cache.Set(cacheKey, enrichedAttractions, cache.DefaultExpiration)
@@ -1528,29 +1528,20 @@ func (s *ServiceImpl) filterHotels(hotels []models.POIDetailedInfo, starRating,
 	return filtered
 }
 
+// filterAttractions marks every attraction as indoor or outdoor and keeps those of the type
+// and setting asked for.
 func (s *ServiceImpl) filterAttractions(attractions []models.POIDetailedInfo, attractionType, isOutdoor string) []models.POIDetailedInfo {
-	if attractionType == "" && isOutdoor == "" {
-		return attractions
-	}
-
-	filtered := make([]models.POIDetailedInfo, 0)
+	filtered := make([]models.POIDetailedInfo, 0, len(attractions))
 	for _, attraction := range attractions {
+		attraction.Setting = weather.SettingOf(attraction.Category, attraction.Tags)
 		// Filter by attraction type
 		if attractionType != "" && attraction.Category != attractionType {
 			continue
 		}
-		// Filter by outdoor/indoor (basic tag matching)
-		if isOutdoor != "" {
-			hasOutdoorTag := false
-			for _, tag := range attraction.Tags {
-				if (isOutdoor == "true" && tag == "outdoor") || (isOutdoor == "false" && tag == "indoor") {
-					hasOutdoorTag = true
-					break
-				}
-			}
-			if !hasOutdoorTag {
-				continue
-			}
+		// Filter by outdoor/indoor, from the tags or else the category
+		if (isOutdoor == "true" && attraction.Setting != models.SettingOutdoor) ||
+			(isOutdoor == "false" && attraction.Setting != models.SettingIndoor) {
+			continue
 		}
 		filtered = append(filtered, attraction)
 	}
@@ -1596,10 +1587,10 @@ func (s *ServiceImpl) getGeneralRestaurantByDistance(wg *sync.WaitGroup,
 		UserLon:        lon,
 		SearchRadiusKm: distance,
 	}
-	prompt := getRestaurantsNearbyPrompt(userLocation)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getRestaurantsNearbyPrompt(userID.String(), userLocation)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	if s.aiClient == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("AI client is not available - check API key configuration")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI client unavailable")
@@ -1611,12 +1602,11 @@ func (s *ServiceImpl) getGeneralRestaurantByDistance(wg *sync.WaitGroup,
 	sessionID := uuid.New()
 	intent := "nearby"
 	searchType := "dining"
-	modelName := "gemini-2.0-flash"
-	provider := "google"
+	modelName, provider := s.llmIdentity()
 	temperature := config.Temperature
 
 	startTime := time.Now()
-	response, err := s.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := s.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int64(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", int(latencyMs)))
 
@@ -1682,8 +1672,8 @@ func (s *ServiceImpl) getGeneralRestaurantByDistance(wg *sync.WaitGroup,
 	span.SetStatus(codes.Ok, "General POIs generated successfully")
 	resultCh <- models.GenAIResponse{
 		GeneralPOI: poiData.PointsOfInterest,
-		ModelName:  s.aiClient.ModelName,
-		Prompt:     prompt,
+		ModelName:  s.llmProvider.Model(),
+		Prompt:     prompt.Text,
 		Response:   cleanTxt,
 	}
 }
@@ -1727,10 +1717,10 @@ func (s *ServiceImpl) getGeneralActivitiesByDistance(wg *sync.WaitGroup,
 		UserLon:        lon,
 		SearchRadiusKm: distance,
 	}
-	prompt := getActivitiesNearbyPrompt(userLocation)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getActivitiesNearbyPrompt(userID.String(), userLocation)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
-	if s.aiClient == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("AI client is not available - check API key configuration")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI client unavailable")
@@ -1742,12 +1732,11 @@ func (s *ServiceImpl) getGeneralActivitiesByDistance(wg *sync.WaitGroup,
 	sessionID := uuid.New()
 	intent := "nearby"
 	searchType := "activities"
-	modelName := "gemini-2.0-flash"
-	provider := "google"
+	modelName, provider := s.llmIdentity()
 	temperature := config.Temperature
 
 	startTime := time.Now()
-	response, err := s.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := s.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int64(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", int(latencyMs)))
 
@@ -1813,8 +1802,8 @@ func (s *ServiceImpl) getGeneralActivitiesByDistance(wg *sync.WaitGroup,
 	span.SetStatus(codes.Ok, "General POIs generated successfully")
 	resultCh <- models.GenAIResponse{
 		GeneralPOI: poiData.PointsOfInterest,
-		ModelName:  s.aiClient.ModelName,
-		Prompt:     prompt,
+		ModelName:  s.llmProvider.Model(),
+		Prompt:     prompt.Text,
 		Response:   cleanTxt,
 	}
 }
@@ -1858,18 +1847,17 @@ func (s *ServiceImpl) getGeneralHotelsByDistance(wg *sync.WaitGroup,
 		UserLon:        lon,
 		SearchRadiusKm: distance,
 	}
-	prompt := getHotelsNeabyPrompt(userLocation)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getHotelsNeabyPrompt(userID.String(), userLocation)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
 	// Prepare LLM logging
 	sessionID := uuid.New()
 	intent := "nearby"
 	searchType := "accommodation"
-	modelName := "gemini-2.0-flash"
-	provider := "google"
+	modelName, provider := s.llmIdentity()
 	temperature := config.Temperature
 
-	if s.aiClient == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("AI client is not available - check API key configuration")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI client unavailable")
@@ -1881,7 +1869,7 @@ func (s *ServiceImpl) getGeneralHotelsByDistance(wg *sync.WaitGroup,
 	}
 
 	startTime := time.Now()
-	response, err := s.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := s.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int64(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", int(latencyMs)))
 
@@ -1950,8 +1938,8 @@ func (s *ServiceImpl) getGeneralHotelsByDistance(wg *sync.WaitGroup,
 	span.SetStatus(codes.Ok, "General POIs generated successfully")
 	resultCh <- models.GenAIResponse{
 		GeneralPOI: poiData.PointsOfInterest,
-		ModelName:  s.aiClient.ModelName,
-		Prompt:     prompt,
+		ModelName:  s.llmProvider.Model(),
+		Prompt:     prompt.Text,
 		Response:   cleanTxt,
 	}
 }
@@ -1995,18 +1983,17 @@ func (s *ServiceImpl) getGeneralAttractionsByDistance(wg *sync.WaitGroup,
 		UserLon:        lon,
 		SearchRadiusKm: distance,
 	}
-	prompt := getAttractionsNeabyPrompt(userLocation)
-	span.SetAttributes(attribute.Int("prompt.length", len(prompt)))
+	prompt := getAttractionsNeabyPrompt(userID.String(), userLocation)
+	span.SetAttributes(attribute.Int("prompt.length", len(prompt.Text)))
 
 	// Prepare LLM logging
 	sessionID := uuid.New()
 	intent := "nearby"
 	searchType := "attractions"
-	modelName := "gemini-2.0-flash"
-	provider := "google"
+	modelName, provider := s.llmIdentity()
 	temperature := config.Temperature
 
-	if s.aiClient == nil {
+	if s.llmProvider == nil {
 		err := fmt.Errorf("AI client is not available - check API key configuration")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "AI client unavailable")
@@ -2018,7 +2005,7 @@ func (s *ServiceImpl) getGeneralAttractionsByDistance(wg *sync.WaitGroup,
 	}
 
 	startTime := time.Now()
-	response, err := s.aiClient.GenerateResponse(ctx, prompt, config)
+	response, err := s.llmProvider.GenerateResponse(ctx, prompt.Text, config)
 	latencyMs := int64(time.Since(startTime).Milliseconds())
 	span.SetAttributes(attribute.Int("response.latency_ms", int(latencyMs)))
 
@@ -2087,8 +2074,8 @@ func (s *ServiceImpl) getGeneralAttractionsByDistance(wg *sync.WaitGroup,
 	span.SetStatus(codes.Ok, "General POIs generated successfully")
 	resultCh <- models.GenAIResponse{
 		GeneralPOI: poiData.PointsOfInterest,
-		ModelName:  s.aiClient.ModelName,
-		Prompt:     prompt,
+		ModelName:  s.llmProvider.Model(),
+		Prompt:     prompt.Text,
 		Response:   cleanTxt,
 	}
 }
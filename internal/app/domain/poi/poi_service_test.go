@@ -8,7 +8,6 @@ import (
 
 	"go.uber.org/zap"
 
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
 	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
 )
 
 type MockCityRepository struct {
@@ -396,8 +396,7 @@ func setupPOIServiceTest() (*ServiceImpl, *MockPOIRepository, *MockCityRepositor
 	mockRepo := new(MockPOIRepository)
 	mockCityRepo := new(MockCityRepository)
 	mockLLMRepo := new(MockLLMRepository)
-	embeddingService := &generativeAI.EmbeddingService{} // Mock or nil
-	service := NewServiceImpl(mockRepo, embeddingService, mockCityRepo, mockLLMRepo, logger)
+	service := NewServiceImpl(mockRepo, llmprovider.NewFakeProvider(), mockCityRepo, mockLLMRepo, logger)
 	return service, mockRepo, mockCityRepo
 }
 
@@ -0,0 +1,74 @@
+package poimatch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Handler serves the admin API over duplicate POIs. Routes are expected behind an admin check.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// MergeCity handles POST /admin/cities/:id/merge-pois?dry_run=true, folding the duplicate POIs
+// of a city into their canonical records.
+func (h *Handler) MergeCity(c *gin.Context) {
+	cityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid city id"})
+		return
+	}
+	report, err := h.service.MergeDuplicates(c.Request.Context(), cityID, c.Query("dry_run") == "true")
+	if err != nil {
+		h.respondError(c, "Failed to merge duplicate POIs", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// AddAlias handles POST /admin/pois/:id/aliases with {"alias": "Belem Tower"}.
+func (h *Handler) AddAlias(c *gin.Context) {
+	poiID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid POI id"})
+		return
+	}
+	var req struct {
+		Alias string `json:"alias" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias is required"})
+		return
+	}
+	if err := h.service.AddAlias(c.Request.Context(), poiID, req.Alias); err != nil {
+		h.respondError(c, "Failed to add POI alias", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"poi_id": poiID, "alias": req.Alias, "normalized": Normalize(req.Alias)})
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "POI not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
@@ -0,0 +1,322 @@
+package poimatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	// FindByAlias returns the POI of the city known under the normalised name.
+	FindByAlias(ctx context.Context, cityID uuid.UUID, normalized string) (uuid.UUID, bool, error)
+	// Candidates returns the POIs of the city with a similar name or within maxDistanceM of
+	// the query, most similar names first.
+	Candidates(ctx context.Context, cityID uuid.UUID, q Query, maxDistanceM float64, limit int) ([]Candidate, error)
+	// DuplicatePairs returns every pair of POIs of the city with similar names or within
+	// maxDistanceM of each other.
+	DuplicatePairs(ctx context.Context, cityID uuid.UUID, maxDistanceM float64) ([]Pair, error)
+	SaveAlias(ctx context.Context, poiID uuid.UUID, alias, normalized, source string) error
+	// Merge re-points everything referencing the duplicate to the canonical POI, keeps the
+	// duplicate's name as an alias and deletes it, in one transaction.
+	Merge(ctx context.Context, m Merge, normalizedName string) error
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) FindByAlias(ctx context.Context, cityID uuid.UUID, normalized string) (uuid.UUID, bool, error) {
+	ctx, span := otel.Tracer("POIMatchRepository").Start(ctx, "FindByAlias", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+	))
+	defer span.End()
+
+	var id uuid.UUID
+	err := r.pgpool.QueryRow(ctx,
+		`SELECT poi_id FROM poi_aliases WHERE city_id = $1 AND alias_normalized = $2`,
+		cityID, normalized).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query alias")
+		return uuid.Nil, false, fmt.Errorf("failed to query POI alias: %w", err)
+	}
+	return id, true, nil
+}
+
+func (r *RepositoryImpl) Candidates(ctx context.Context, cityID uuid.UUID, q Query, maxDistanceM float64, limit int) ([]Candidate, error) {
+	ctx, span := otel.Tracer("POIMatchRepository").Start(ctx, "Candidates", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.String("poi.name", q.Name),
+		attribute.Bool("query.located", q.Lat != nil),
+		attribute.Bool("query.embedded", q.Embedding != nil),
+	))
+	defer span.End()
+
+	var embedding *string
+	if len(q.Embedding) > 0 {
+		v := vectorLiteral(q.Embedding)
+		embedding = &v
+	}
+
+	query := `
+		WITH q AS (
+			SELECT CASE WHEN $3::float8 IS NULL THEN NULL
+			            ELSE ST_SetSRID(ST_MakePoint($4::float8, $3::float8), 4326)::geography END AS point
+		)
+		SELECT p.id, p.name, p.source::text, p.created_at,
+			similarity(LOWER(p.name), LOWER($2)),
+			CASE WHEN q.point IS NULL OR p.location IS NULL THEN NULL
+			     ELSE ST_Distance(p.location::geography, q.point) END,
+			CASE WHEN $5::vector IS NULL OR p.embedding IS NULL THEN NULL
+			     ELSE 1 - (p.embedding <=> $5::vector) END
+		FROM points_of_interest p, q
+		WHERE p.city_id = $1
+		  AND (similarity(LOWER(p.name), LOWER($2)) >= $6
+		       OR (q.point IS NOT NULL AND ST_DWithin(p.location::geography, q.point, $7)))
+		ORDER BY 5 DESC
+		LIMIT $8`
+
+	rows, err := r.pgpool.Query(ctx, query, cityID, q.Name, q.Lat, q.Lon, embedding,
+		minNameSimilarity, maxDistanceM, limit)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query candidates")
+		return nil, fmt.Errorf("failed to query POI candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.ID, &c.Name, &c.Source, &c.CreatedAt,
+			&c.NameSimilarity, &c.DistanceM, &c.EmbeddingSimilarity); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan POI candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate POI candidates: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("candidates.count", len(candidates)))
+	span.SetStatus(codes.Ok, "Candidates found")
+	return candidates, nil
+}
+
+func (r *RepositoryImpl) DuplicatePairs(ctx context.Context, cityID uuid.UUID, maxDistanceM float64) ([]Pair, error) {
+	ctx, span := otel.Tracer("POIMatchRepository").Start(ctx, "DuplicatePairs", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT a.id, a.name, a.source::text, a.created_at,
+		       b.id, b.name, b.source::text, b.created_at,
+		       similarity(LOWER(a.name), LOWER(b.name)),
+		       CASE WHEN a.location IS NULL OR b.location IS NULL THEN NULL
+		            ELSE ST_Distance(a.location::geography, b.location::geography) END,
+		       CASE WHEN a.embedding IS NULL OR b.embedding IS NULL THEN NULL
+		            ELSE 1 - (a.embedding <=> b.embedding) END
+		FROM points_of_interest a
+		JOIN points_of_interest b ON b.city_id = a.city_id AND a.id < b.id
+		WHERE a.city_id = $1
+		  AND (LOWER(a.name) % LOWER(b.name)
+		       OR ST_DWithin(a.location::geography, b.location::geography, $2))`
+
+	rows, err := r.pgpool.Query(ctx, query, cityID, maxDistanceM)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query duplicate pairs")
+		return nil, fmt.Errorf("failed to query duplicate POI pairs: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs []Pair
+	for rows.Next() {
+		var p Pair
+		if err := rows.Scan(&p.A.ID, &p.A.Name, &p.A.Source, &p.A.CreatedAt,
+			&p.B.ID, &p.B.Name, &p.B.Source, &p.B.CreatedAt,
+			&p.NameSimilarity, &p.DistanceM, &p.EmbeddingSimilarity); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan duplicate POI pair: %w", err)
+		}
+		pairs = append(pairs, p)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate duplicate POI pairs: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("pairs.count", len(pairs)))
+	span.SetStatus(codes.Ok, "Duplicate pairs found")
+	return pairs, nil
+}
+
+func (r *RepositoryImpl) SaveAlias(ctx context.Context, poiID uuid.UUID, alias, normalized, source string) error {
+	ctx, span := otel.Tracer("POIMatchRepository").Start(ctx, "SaveAlias", trace.WithAttributes(
+		attribute.String("poi.id", poiID.String()),
+		attribute.String("source", source),
+	))
+	defer span.End()
+
+	// A manual alias may move a name to another POI; automatic ones never steal it
+	query := `
+		INSERT INTO poi_aliases (poi_id, city_id, alias, alias_normalized, source)
+		SELECT id, city_id, $2, $3, $4 FROM points_of_interest WHERE id = $1
+		ON CONFLICT (city_id, alias_normalized) DO UPDATE SET
+			poi_id = EXCLUDED.poi_id, alias = EXCLUDED.alias, source = EXCLUDED.source
+		WHERE EXCLUDED.source = 'manual'`
+	tag, err := r.pgpool.Exec(ctx, query, poiID, alias, normalized, source)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to save alias")
+		return fmt.Errorf("failed to save POI alias: %w", err)
+	}
+	if tag.RowsAffected() == 0 && source == AliasManual {
+		return fmt.Errorf("POI %s: %w", poiID, models.ErrNotFound)
+	}
+
+	span.SetStatus(codes.Ok, "Alias saved")
+	return nil
+}
+
+// mergeStatements move what references the duplicate POI ($2) to the canonical one ($1). Rows
+// that would collide with one the canonical POI already has are left on the duplicate and go
+// with it when it is deleted. Every table with a foreign key into points_of_interest needs one.
+var mergeStatements = []struct {
+	name  string
+	table string
+	query string
+}{
+	{"favorites", "user_favorite_pois", `
+		UPDATE user_favorite_pois f SET poi_id = $1
+		WHERE f.poi_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM user_favorite_pois WHERE user_id = f.user_id AND poi_id = $1)`},
+	{"saved POIs", "saved_pois", `
+		UPDATE saved_pois s SET poi_id = $1
+		WHERE s.poi_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM saved_pois WHERE user_id = s.user_id AND poi_id = $1)`},
+	{"list items", "list_items", `
+		UPDATE list_items li SET item_id = $1, poi_id = CASE WHEN li.poi_id IS NULL THEN NULL ELSE $1 END
+		WHERE li.content_type = 'poi' AND li.item_id = $2
+		  AND NOT EXISTS (
+			SELECT 1 FROM list_items WHERE list_id = li.list_id AND content_type = 'poi' AND item_id = $1)`},
+	{"duplicate list items", "list_items", `DELETE FROM list_items WHERE content_type = 'poi' AND item_id = $2`},
+	{"itinerary POIs", "itinerary_pois", `
+		UPDATE itinerary_pois ip SET poi_id = $1
+		WHERE ip.poi_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM itinerary_pois WHERE itinerary_id = ip.itinerary_id AND poi_id = $1)`},
+	{"reviews", "reviews", `UPDATE reviews SET poi_id = $1 WHERE poi_id = $2`},
+	{"aliases", "poi_aliases", `UPDATE poi_aliases SET poi_id = $1 WHERE poi_id = $2`},
+	{"translations", "poi_translations", `
+		UPDATE poi_translations t SET poi_id = $1
+		WHERE t.poi_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM poi_translations WHERE poi_id = $1 AND language = t.language)`},
+	{"canonical details", "points_of_interest", `
+		UPDATE points_of_interest c SET
+			description = COALESCE(c.description, d.description),
+			embedding = COALESCE(c.embedding, d.embedding)
+		FROM points_of_interest d
+		WHERE c.id = $1 AND d.id = $2`},
+}
+
+func (r *RepositoryImpl) Merge(ctx context.Context, m Merge, normalizedName string) error {
+	ctx, span := otel.Tracer("POIMatchRepository").Start(ctx, "Merge", trace.WithAttributes(
+		attribute.String("poi.canonical_id", m.CanonicalID.String()),
+		attribute.String("poi.duplicate_id", m.DuplicateID.String()),
+	))
+	defer span.End()
+
+	tx, err := r.pgpool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to begin merge transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.logger.Error("Failed to rollback POI merge", zap.Error(err))
+		}
+	}()
+
+	for _, st := range mergeStatements {
+		if _, err := tx.Exec(ctx, st.query, m.CanonicalID, m.DuplicateID); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to re-point "+st.name)
+			return fmt.Errorf("failed to re-point %s: %w", st.name, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO poi_aliases (poi_id, city_id, alias, alias_normalized, source)
+		SELECT $1, city_id, $3, $4, 'merged' FROM points_of_interest WHERE id = $2
+		ON CONFLICT (city_id, alias_normalized) DO NOTHING`,
+		m.CanonicalID, m.DuplicateID, m.DuplicateName, normalizedName); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to save merged name as alias: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO poi_merges (canonical_poi_id, merged_poi_id, merged_name, score)
+		VALUES ($1, $2, $3, $4)`,
+		m.CanonicalID, m.DuplicateID, m.DuplicateName, m.Score); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to record POI merge: %w", err)
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM points_of_interest WHERE id = $1`, m.DuplicateID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete duplicate POI: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("duplicate POI %s: %w", m.DuplicateID, models.ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to commit POI merge: %w", err)
+	}
+
+	r.logger.Info("Merged duplicate POI",
+		zap.String("canonical_id", m.CanonicalID.String()),
+		zap.String("duplicate_id", m.DuplicateID.String()),
+		zap.String("duplicate_name", m.DuplicateName),
+		zap.Float64("score", m.Score))
+	span.SetStatus(codes.Ok, "POIs merged")
+	return nil
+}
+
+// vectorLiteral formats an embedding as a pgvector literal.
+func vectorLiteral(v []float32) string {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(float64(f), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
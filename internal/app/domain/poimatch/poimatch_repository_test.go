@@ -0,0 +1,80 @@
+package poimatch
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const migrationsDir = "../../../db/migrations"
+
+// notRepointed are the tables referencing points_of_interest that Merge handles otherwise.
+var notRepointed = map[string]string{
+	"poi_merges": "records the merge itself, only the canonical POI is referenced",
+}
+
+var (
+	sqlComment   = regexp.MustCompile(`--[^\n]*`)
+	poiReference = regexp.MustCompile(`(?i)\bREFERENCES\s+"?points_of_interest"?\s*\(`)
+	tableName    = regexp.MustCompile(`(?i)^\s*(?:CREATE\s+TABLE|ALTER\s+TABLE)(?:\s+IF\s+(?:NOT\s+)?EXISTS)?(?:\s+ONLY)?\s+"?(\w+)"?`)
+	droppedTable = regexp.MustCompile(`(?i)^\s*DROP\s+TABLE(?:\s+IF\s+EXISTS)?\s+"?(\w+)"?`)
+)
+
+// poiReferencingTables lists the tables with a foreign key into points_of_interest once every
+// migration is applied.
+func poiReferencingTables(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no migrations in %s", migrationsDir)
+	slices.Sort(files)
+
+	tables := map[string]bool{}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		require.NoError(t, err)
+		up, _, _ := strings.Cut(string(raw), "-- +goose Down")
+		for _, stmt := range strings.Split(sqlComment.ReplaceAllString(up, ""), ";") {
+			if m := droppedTable.FindStringSubmatch(stmt); m != nil {
+				delete(tables, strings.ToLower(m[1]))
+				continue
+			}
+			if !poiReference.MatchString(stmt) {
+				continue
+			}
+			m := tableName.FindStringSubmatch(stmt)
+			require.NotNil(t, m, "%s: cannot tell which table references points_of_interest in %q", filepath.Base(file), stmt)
+			tables[strings.ToLower(m[1])] = true
+		}
+	}
+
+	var names []string
+	for name := range tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestMergeStatements_RepointEveryReferenceToPOIs(t *testing.T) {
+	tables := poiReferencingTables(t)
+	require.Contains(t, tables, "poi_translations", "the migrations were not parsed")
+
+	repointed := map[string]bool{}
+	for _, st := range mergeStatements {
+		assert.Contains(t, st.query, st.table, "statement %q", st.name)
+		repointed[st.table] = true
+	}
+	for _, table := range tables {
+		if _, ok := notRepointed[table]; ok {
+			continue
+		}
+		assert.True(t, repointed[table], "%s references points_of_interest ON DELETE CASCADE: Merge must move its rows to the canonical POI", table)
+	}
+}
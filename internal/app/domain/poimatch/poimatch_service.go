@@ -0,0 +1,363 @@
+// Package poimatch resolves POIs named by the LLM to the records already stored for their
+// city, and folds duplicate records into one canonical POI. Candidates are scored on trigram
+// name similarity, PostGIS distance and embedding similarity; every name a record has been
+// matched under is kept as an alias so the next answer naming it that way resolves directly.
+package poimatch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Alias sources, as stored in poi_aliases.source.
+const (
+	AliasResolved = "resolved"
+	AliasMerged   = "merged"
+	AliasManual   = "manual"
+)
+
+const (
+	DefaultNameWeight      = 0.4
+	DefaultDistanceWeight  = 0.3
+	DefaultEmbeddingWeight = 0.3
+	DefaultThreshold       = 0.6
+	DefaultMaxDistanceM    = 250.0
+
+	// minNameSimilarity is the trigram similarity a record needs to be a candidate when it is
+	// not close by.
+	minNameSimilarity = 0.3
+	candidateLimit    = 10
+)
+
+// Config tunes how candidates are scored.
+type Config struct {
+	NameWeight      float64
+	DistanceWeight  float64
+	EmbeddingWeight float64
+	Threshold       float64 // score from which a candidate is the same place
+	MaxDistanceM    float64 // records further apart than this are never the same place
+}
+
+func DefaultConfig() Config {
+	return Config{
+		NameWeight:      DefaultNameWeight,
+		DistanceWeight:  DefaultDistanceWeight,
+		EmbeddingWeight: DefaultEmbeddingWeight,
+		Threshold:       DefaultThreshold,
+		MaxDistanceM:    DefaultMaxDistanceM,
+	}
+}
+
+// Query is a POI to resolve. Nil coordinates or embedding leave that signal out.
+type Query struct {
+	Name      string
+	Lat       *float64
+	Lon       *float64
+	Embedding []float32
+}
+
+// Candidate is a stored POI compared with a query. Nil fields are signals that could not be
+// computed, for lack of a location or an embedding on either side.
+type Candidate struct {
+	ID                  uuid.UUID
+	Name                string
+	Source              string
+	CreatedAt           time.Time
+	NameSimilarity      float64
+	DistanceM           *float64
+	EmbeddingSimilarity *float64
+}
+
+// Pair is two stored POIs of a city that may be the same place.
+type Pair struct {
+	A, B                Candidate
+	NameSimilarity      float64
+	DistanceM           *float64
+	EmbeddingSimilarity *float64
+}
+
+// Merge folds Duplicate into Canonical.
+type Merge struct {
+	CanonicalID   uuid.UUID
+	DuplicateID   uuid.UUID
+	DuplicateName string
+	Score         float64
+}
+
+// MergeReport is the outcome of a duplicate sweep over a city.
+type MergeReport struct {
+	CityID   uuid.UUID `json:"city_id"`
+	Pairs    int       `json:"pairs"`
+	Merged   []Merge   `json:"merged"`
+	DryRun   bool      `json:"dry_run"`
+	Failures int       `json:"failures"`
+}
+
+// Embedder turns a POI into the vector stored in points_of_interest.embedding.
+type Embedder interface {
+	GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error)
+}
+
+type Service interface {
+	// Resolve returns the stored POI of the city that poi names, if any, recording the name it
+	// was found under as an alias.
+	Resolve(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (uuid.UUID, bool, error)
+	// MergeDuplicates folds every pair of POIs of the city scoring as the same place into the
+	// preferred one. A dry run only reports what would be merged.
+	MergeDuplicates(ctx context.Context, cityID uuid.UUID, dryRun bool) (MergeReport, error)
+	// AddAlias makes name resolve to poiID.
+	AddAlias(ctx context.Context, poiID uuid.UUID, name string) error
+}
+
+type ServiceImpl struct {
+	repo     Repository
+	embedder Embedder
+	config   Config
+	logger   *zap.Logger
+}
+
+func NewService(repo Repository, config Config, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:   repo,
+		config: config,
+		logger: logger,
+	}
+}
+
+// WithEmbedder adds embedding similarity to the signals used when resolving a POI. Without it
+// only stored embeddings are compared, during duplicate sweeps.
+func (s *ServiceImpl) WithEmbedder(embedder Embedder) *ServiceImpl {
+	s.embedder = embedder
+	return s
+}
+
+func (s *ServiceImpl) Resolve(ctx context.Context, cityID uuid.UUID, poi models.POIDetailedInfo) (uuid.UUID, bool, error) {
+	ctx, span := otel.Tracer("POIMatchService").Start(ctx, "Resolve", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.String("poi.name", poi.Name),
+	))
+	defer span.End()
+
+	normalized := Normalize(poi.Name)
+	if cityID == uuid.Nil || normalized == "" {
+		return uuid.Nil, false, nil
+	}
+
+	id, ok, err := s.repo.FindByAlias(ctx, cityID, normalized)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	if ok {
+		span.SetAttributes(attribute.String("match", "alias"))
+		return id, true, nil
+	}
+
+	q := Query{Name: poi.Name}
+	if validCoordinates(poi.Latitude, poi.Longitude) {
+		q.Lat, q.Lon = &poi.Latitude, &poi.Longitude
+	}
+	if s.embedder != nil {
+		embedding, err := s.embedder.GeneratePOIEmbedding(ctx, poi.Name, poi.DescriptionPOI, poi.Category)
+		if err != nil {
+			s.logger.Warn("Failed to embed POI for resolution, comparing names and distance only",
+				zap.String("name", poi.Name), zap.Error(err))
+		} else {
+			q.Embedding = embedding
+		}
+	}
+
+	candidates, err := s.repo.Candidates(ctx, cityID, q, s.config.MaxDistanceM, candidateLimit)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	best, score, ok := s.best(normalized, candidates)
+	if !ok {
+		return uuid.Nil, false, nil
+	}
+	span.SetAttributes(attribute.String("match", "score"), attribute.Float64("match.score", score))
+
+	if Normalize(best.Name) != normalized {
+		if err := s.repo.SaveAlias(ctx, best.ID, poi.Name, normalized, AliasResolved); err != nil {
+			s.logger.Warn("Failed to save POI alias", zap.String("alias", poi.Name), zap.Error(err))
+		}
+		s.logger.Info("LLM POI resolved to stored record",
+			zap.String("name", poi.Name),
+			zap.String("stored_name", best.Name),
+			zap.String("poi_id", best.ID.String()),
+			zap.Float64("score", score))
+	}
+	return best.ID, true, nil
+}
+
+// best returns the highest scoring candidate at or above the threshold. A record of the same
+// name always matches, as exact names did before scoring, however far off the LLM placed it.
+func (s *ServiceImpl) best(normalized string, candidates []Candidate) (Candidate, float64, bool) {
+	var best Candidate
+	bestScore := -1.0
+	for _, c := range candidates {
+		if Normalize(c.Name) == normalized {
+			return c, 1, true
+		}
+		score, ok := s.Score(c.NameSimilarity, c.DistanceM, c.EmbeddingSimilarity)
+		if ok && score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore, bestScore >= 0
+}
+
+// Score combines the available signals into a weighted average and reports whether it reaches
+// the threshold. Records further apart than MaxDistanceM never match, whatever their names.
+func (s *ServiceImpl) Score(nameSimilarity float64, distanceM, embeddingSimilarity *float64) (float64, bool) {
+	total := s.config.NameWeight * nameSimilarity
+	weights := s.config.NameWeight
+	if distanceM != nil && s.config.MaxDistanceM > 0 {
+		if *distanceM > s.config.MaxDistanceM {
+			return 0, false
+		}
+		total += s.config.DistanceWeight * (1 - *distanceM/s.config.MaxDistanceM)
+		weights += s.config.DistanceWeight
+	}
+	if embeddingSimilarity != nil {
+		total += s.config.EmbeddingWeight * *embeddingSimilarity
+		weights += s.config.EmbeddingWeight
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	score := total / weights
+	return score, score >= s.config.Threshold
+}
+
+func (s *ServiceImpl) MergeDuplicates(ctx context.Context, cityID uuid.UUID, dryRun bool) (MergeReport, error) {
+	ctx, span := otel.Tracer("POIMatchService").Start(ctx, "MergeDuplicates", trace.WithAttributes(
+		attribute.String("city.id", cityID.String()),
+		attribute.Bool("dry_run", dryRun),
+	))
+	defer span.End()
+
+	report := MergeReport{CityID: cityID, DryRun: dryRun, Merged: []Merge{}}
+	pairs, err := s.repo.DuplicatePairs(ctx, cityID, s.config.MaxDistanceM)
+	if err != nil {
+		return report, err
+	}
+	report.Pairs = len(pairs)
+
+	// Strongest matches first, so a record merged away is folded into its closest twin
+	type scored struct {
+		Pair
+		score float64
+	}
+	var matches []scored
+	for _, p := range pairs {
+		nameSim := p.NameSimilarity
+		if Normalize(p.A.Name) == Normalize(p.B.Name) {
+			nameSim = 1
+		}
+		if score, ok := s.Score(nameSim, p.DistanceM, p.EmbeddingSimilarity); ok {
+			matches = append(matches, scored{Pair: p, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	merged := map[uuid.UUID]bool{}
+	for _, m := range matches {
+		keep, drop := Preferred(m.A, m.B)
+		if merged[keep.ID] || merged[drop.ID] {
+			// One side is already gone; its twin was matched on its own merits
+			continue
+		}
+		merge := Merge{CanonicalID: keep.ID, DuplicateID: drop.ID, DuplicateName: drop.Name, Score: m.score}
+		if !dryRun {
+			if err := s.repo.Merge(ctx, merge, Normalize(drop.Name)); err != nil {
+				s.logger.Warn("Failed to merge duplicate POI",
+					zap.String("canonical_id", keep.ID.String()),
+					zap.String("duplicate_id", drop.ID.String()),
+					zap.Error(err))
+				report.Failures++
+				continue
+			}
+		}
+		merged[drop.ID] = true
+		report.Merged = append(report.Merged, merge)
+	}
+
+	span.SetAttributes(attribute.Int("pairs", report.Pairs), attribute.Int("merged", len(report.Merged)))
+	s.logger.Info("Duplicate POI sweep finished",
+		zap.String("city_id", cityID.String()),
+		zap.Bool("dry_run", dryRun),
+		zap.Int("pairs", report.Pairs),
+		zap.Int("merged", len(report.Merged)),
+		zap.Int("failures", report.Failures))
+	return report, nil
+}
+
+func (s *ServiceImpl) AddAlias(ctx context.Context, poiID uuid.UUID, name string) error {
+	normalized := Normalize(name)
+	if normalized == "" {
+		return fmt.Errorf("%w: alias is empty", models.ErrBadRequest)
+	}
+	return s.repo.SaveAlias(ctx, poiID, name, normalized, AliasManual)
+}
+
+// Preferred orders two records of the same place, canonical first: curated sources over LLM
+// answers, then the older record, which more saved items are likely to point at.
+func Preferred(a, b Candidate) (Candidate, Candidate) {
+	aAI, bAI := a.Source == "loci_ai", b.Source == "loci_ai"
+	switch {
+	case aAI != bAI:
+		if aAI {
+			return b, a
+		}
+		return a, b
+	case b.CreatedAt.Before(a.CreatedAt):
+		return b, a
+	default:
+		return a, b
+	}
+}
+
+// Normalize reduces a name to the form aliases are compared in: lower case, without accents,
+// punctuation or repeated spaces. "Torre de Belém" and "torre de belem!" are the same.
+func Normalize(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// validCoordinates rejects out-of-range values and (0, 0), which carry no location.
+func validCoordinates(lat, lon float64) bool {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return false
+	}
+	return lat != 0 || lon != 0
+}
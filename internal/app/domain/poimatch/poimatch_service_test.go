@@ -0,0 +1,202 @@
+package poimatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) FindByAlias(ctx context.Context, cityID uuid.UUID, normalized string) (uuid.UUID, bool, error) {
+	args := m.Called(ctx, cityID, normalized)
+	return args.Get(0).(uuid.UUID), args.Bool(1), args.Error(2)
+}
+
+func (m *MockRepository) Candidates(ctx context.Context, cityID uuid.UUID, q Query, maxDistanceM float64, limit int) ([]Candidate, error) {
+	args := m.Called(ctx, cityID, q, maxDistanceM, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Candidate), args.Error(1)
+}
+
+func (m *MockRepository) DuplicatePairs(ctx context.Context, cityID uuid.UUID, maxDistanceM float64) ([]Pair, error) {
+	args := m.Called(ctx, cityID, maxDistanceM)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]Pair), args.Error(1)
+}
+
+func (m *MockRepository) SaveAlias(ctx context.Context, poiID uuid.UUID, alias, normalized, source string) error {
+	args := m.Called(ctx, poiID, alias, normalized, source)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Merge(ctx context.Context, merge Merge, normalizedName string) error {
+	args := m.Called(ctx, merge, normalizedName)
+	return args.Error(0)
+}
+
+type fakeEmbedder struct {
+	err error
+}
+
+func (e fakeEmbedder) GeneratePOIEmbedding(context.Context, string, string, string) ([]float32, error) {
+	return []float32{0.1, 0.2}, e.err
+}
+
+func ptr(v float64) *float64 { return &v }
+
+var (
+	cityID  = uuid.MustParse("5c0e7d3a-1b9f-4a43-9b9d-2f4f3f6a1c11")
+	belemID = uuid.MustParse("0b4f9a52-7f3e-4c55-9d4a-3c1e8a9b2d01")
+	otherID = uuid.MustParse("0b4f9a52-7f3e-4c55-9d4a-3c1e8a9b2d02")
+)
+
+func newTestService(repo Repository) *ServiceImpl {
+	return NewService(repo, DefaultConfig(), zap.NewNop())
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "torre de belem", Normalize("Torre de Belém"))
+	assert.Equal(t, "torre de belem", Normalize("  torre-de  BELEM! "))
+	assert.Equal(t, "cafe a brasileira", Normalize("Café «A Brasileira»"))
+	assert.Equal(t, "", Normalize("..."))
+}
+
+func TestScore(t *testing.T) {
+	s := newTestService(nil)
+
+	// Different names, same spot, same meaning: the same place
+	score, ok := s.Score(0.2, ptr(20), ptr(0.9))
+	assert.True(t, ok)
+	assert.InDelta(t, 0.626, score, 0.001)
+
+	// Two venues in one building
+	_, ok = s.Score(0.1, ptr(0), ptr(0.7))
+	assert.False(t, ok)
+
+	// Too far apart, whatever the name
+	_, ok = s.Score(1, ptr(300), ptr(1))
+	assert.False(t, ok)
+
+	// Name only: weights renormalise over the signals available
+	score, ok = s.Score(0.7, nil, nil)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.7, score, 0.001)
+}
+
+func TestResolve_Alias(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("FindByAlias", mock.Anything, cityID, "belem tower").Return(belemID, true, nil)
+
+	id, ok, err := newTestService(repo).Resolve(context.Background(), cityID, models.POIDetailedInfo{Name: "Belem Tower"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, belemID, id)
+	repo.AssertNotCalled(t, "Candidates", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResolve_ScoresCandidatesAndSavesAlias(t *testing.T) {
+	repo := new(MockRepository)
+	poi := models.POIDetailedInfo{Name: "Belem Tower", Latitude: 38.6916, Longitude: -9.2160}
+	repo.On("FindByAlias", mock.Anything, cityID, "belem tower").Return(uuid.Nil, false, nil)
+	repo.On("Candidates", mock.Anything, cityID, mock.MatchedBy(func(q Query) bool {
+		return q.Lat != nil && q.Embedding != nil
+	}), DefaultMaxDistanceM, candidateLimit).Return([]Candidate{
+		{ID: otherID, Name: "Belém Bakery", NameSimilarity: 0.3, DistanceM: ptr(200), EmbeddingSimilarity: ptr(0.4)},
+		{ID: belemID, Name: "Torre de Belém", NameSimilarity: 0.2, DistanceM: ptr(15), EmbeddingSimilarity: ptr(0.92)},
+	}, nil)
+	repo.On("SaveAlias", mock.Anything, belemID, "Belem Tower", "belem tower", AliasResolved).Return(nil)
+
+	s := newTestService(repo).WithEmbedder(fakeEmbedder{})
+	id, ok, err := s.Resolve(context.Background(), cityID, poi)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, belemID, id)
+	repo.AssertExpectations(t)
+}
+
+func TestResolve_SameNameMatchesWithoutAlias(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("FindByAlias", mock.Anything, cityID, "torre de belem").Return(uuid.Nil, false, nil)
+	repo.On("Candidates", mock.Anything, cityID, mock.Anything, DefaultMaxDistanceM, candidateLimit).Return([]Candidate{
+		{ID: belemID, Name: "Torre de Belém", NameSimilarity: 0.8, DistanceM: ptr(2000)},
+	}, nil)
+
+	// The embedder failing only leaves that signal out
+	s := newTestService(repo).WithEmbedder(fakeEmbedder{err: errors.New("quota")})
+	id, ok, err := s.Resolve(context.Background(), cityID, models.POIDetailedInfo{Name: "Torre de Belem", Latitude: 38.7, Longitude: -9.1})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, belemID, id)
+	repo.AssertNotCalled(t, "SaveAlias", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResolve_NoMatch(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("FindByAlias", mock.Anything, cityID, "lx factory").Return(uuid.Nil, false, nil)
+	repo.On("Candidates", mock.Anything, cityID, mock.MatchedBy(func(q Query) bool {
+		return q.Lat == nil // (0, 0) is no location
+	}), DefaultMaxDistanceM, candidateLimit).Return([]Candidate{
+		{ID: otherID, Name: "LX Boutique Hotel", NameSimilarity: 0.35},
+	}, nil)
+
+	_, ok, err := newTestService(repo).Resolve(context.Background(), cityID, models.POIDetailedInfo{Name: "LX Factory"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMergeDuplicates_KeepsPreferredRecordOnce(t *testing.T) {
+	repo := new(MockRepository)
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	curated := Candidate{ID: belemID, Name: "Torre de Belém", Source: "openstreetmap", CreatedAt: old.Add(time.Hour)}
+	generated := Candidate{ID: otherID, Name: "Belem Tower", Source: "loci_ai", CreatedAt: old}
+	third := Candidate{ID: uuid.New(), Name: "Belém Tower", Source: "loci_ai", CreatedAt: old.Add(2 * time.Hour)}
+
+	repo.On("DuplicatePairs", mock.Anything, cityID, DefaultMaxDistanceM).Return([]Pair{
+		{A: generated, B: curated, NameSimilarity: 0.2, DistanceM: ptr(10), EmbeddingSimilarity: ptr(0.95)},
+		{A: generated, B: third, NameSimilarity: 0.6, DistanceM: ptr(5), EmbeddingSimilarity: ptr(0.99)},
+		{A: curated, B: third, NameSimilarity: 0.2, DistanceM: ptr(12), EmbeddingSimilarity: ptr(0.94)},
+	}, nil)
+	repo.On("Merge", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	report, err := newTestService(repo).MergeDuplicates(context.Background(), cityID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Pairs)
+	require.Len(t, report.Merged, 2)
+
+	// The strongest pair goes first: the two generated records, keeping the older
+	assert.Equal(t, generated.ID, report.Merged[0].CanonicalID)
+	assert.Equal(t, third.ID, report.Merged[0].DuplicateID)
+	// Then the generated survivor folds into the curated record
+	assert.Equal(t, curated.ID, report.Merged[1].CanonicalID)
+	assert.Equal(t, generated.ID, report.Merged[1].DuplicateID)
+	repo.AssertCalled(t, "Merge", mock.Anything, report.Merged[1], "belem tower")
+}
+
+func TestMergeDuplicates_DryRun(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("DuplicatePairs", mock.Anything, cityID, DefaultMaxDistanceM).Return([]Pair{
+		{A: Candidate{ID: belemID, Name: "Torre de Belém"}, B: Candidate{ID: otherID, Name: "Torre de Belem"}, NameSimilarity: 0.7},
+	}, nil)
+
+	report, err := newTestService(repo).MergeDuplicates(context.Background(), cityID, true)
+	require.NoError(t, err)
+	assert.Len(t, report.Merged, 1)
+	repo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -83,7 +84,7 @@ func (r *ProfileRepository) GetSearchProfiles(ctx context.Context, userID uuid.U
         SELECT id, user_id, profile_name, is_default, search_radius_km, preferred_time, 
                budget_level, preferred_pace, prefer_accessible_pois, prefer_outdoor_seating, 
                prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs, 
-               created_at, updated_at
+               currency, created_at, updated_at
         FROM user_preference_profiles
         WHERE user_id = $1
         ORDER BY is_default DESC, profile_name`
@@ -104,7 +105,7 @@ func (r *ProfileRepository) GetSearchProfiles(ctx context.Context, userID uuid.U
 			&p.ID, &p.UserID, &p.ProfileName, &p.IsDefault, &p.SearchRadiusKm, &p.PreferredTime,
 			&p.BudgetLevel, &p.PreferredPace, &p.PreferAccessiblePOIs, &p.PreferOutdoorSeating,
 			&p.PreferDogFriendly, &p.PreferredVibes, &p.PreferredTransport, &p.DietaryNeeds,
-			&p.CreatedAt, &p.UpdatedAt,
+			&p.Currency, &p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
 			l.Error("Failed to scan preference profile row", zap.Any("error", err))
@@ -190,7 +191,7 @@ func (r *ProfileRepository) GetSearchProfile(ctx context.Context, userID, profil
         SELECT id, user_id, profile_name, is_default, search_radius_km, preferred_time, 
                budget_level, preferred_pace, prefer_accessible_pois, prefer_outdoor_seating, 
                prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs, 
-               created_at, updated_at
+               currency, created_at, updated_at
         FROM user_preference_profiles
         WHERE id = $1 AND user_id = $2`
 
@@ -199,7 +200,7 @@ func (r *ProfileRepository) GetSearchProfile(ctx context.Context, userID, profil
 		&p.ID, &p.UserID, &p.ProfileName, &p.IsDefault, &p.SearchRadiusKm, &p.PreferredTime,
 		&p.BudgetLevel, &p.PreferredPace, &p.PreferAccessiblePOIs, &p.PreferOutdoorSeating,
 		&p.PreferDogFriendly, &p.PreferredVibes, &p.PreferredTransport, &p.DietaryNeeds,
-		&p.CreatedAt, &p.UpdatedAt,
+		&p.Currency, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		l.Error("Failed to query user preference profile", zap.Any("error", err))
@@ -277,7 +278,7 @@ func (r *ProfileRepository) GetDefaultSearchProfile(ctx context.Context, userID
         SELECT id, user_id, profile_name, is_default, search_radius_km, preferred_time, 
                budget_level, preferred_pace, prefer_accessible_pois, prefer_outdoor_seating, 
                prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs, 
-               created_at, updated_at
+               currency, created_at, updated_at
         FROM user_preference_profiles
         WHERE user_id = $1 AND is_default = TRUE`
 
@@ -286,7 +287,7 @@ func (r *ProfileRepository) GetDefaultSearchProfile(ctx context.Context, userID
 		&p.ID, &p.UserID, &p.ProfileName, &p.IsDefault, &p.SearchRadiusKm, &p.PreferredTime,
 		&p.BudgetLevel, &p.PreferredPace, &p.PreferAccessiblePOIs, &p.PreferOutdoorSeating,
 		&p.PreferDogFriendly, &p.PreferredVibes, &p.PreferredTransport, &p.DietaryNeeds,
-		&p.CreatedAt, &p.UpdatedAt,
+		&p.Currency, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		l.Error("Failed to query default user preference profile", zap.Any("error", err))
@@ -411,6 +412,10 @@ func (r *ProfileRepository) CreateSearchProfile(ctx context.Context, userID uuid
 	if dietaryNeeds == nil {
 		dietaryNeeds = []string{}
 	}
+	currency := "EUR"
+	if params.Currency != nil {
+		currency = strings.ToUpper(*params.Currency)
+	}
 
 	if isDefault {
 		query := "UPDATE user_preference_profiles SET is_default = FALSE WHERE user_id = $1 AND id != $2"
@@ -433,22 +438,22 @@ func (r *ProfileRepository) CreateSearchProfile(ctx context.Context, userID uuid
         INSERT INTO user_preference_profiles (
             user_id, profile_name, is_default, search_radius_km, preferred_time, 
             budget_level, preferred_pace, prefer_accessible_pois, prefer_outdoor_seating, 
-            prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs
+            prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs, currency
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
         ) RETURNING id, user_id, profile_name, is_default, search_radius_km, preferred_time, 
                    budget_level, preferred_pace, prefer_accessible_pois, prefer_outdoor_seating, 
                    prefer_dog_friendly, preferred_vibes, preferred_transport, dietary_needs, 
-                   created_at, updated_at`
+                   currency, created_at, updated_at`
 	err = tx.QueryRow(ctx, query,
 		userID, params.ProfileName, isDefault, searchRadiusKm, preferredTime,
 		budgetLevel, preferredPace, preferAccessiblePOIs, preferOutdoorSeating,
-		preferDogFriendly, preferredVibes, preferredTransport, dietaryNeeds,
+		preferDogFriendly, preferredVibes, preferredTransport, dietaryNeeds, currency,
 	).Scan(
 		&p.ID, &p.UserID, &p.ProfileName, &p.IsDefault, &p.SearchRadiusKm, &p.PreferredTime,
 		&p.BudgetLevel, &p.PreferredPace, &p.PreferAccessiblePOIs, &p.PreferOutdoorSeating,
 		&p.PreferDogFriendly, &p.PreferredVibes, &p.PreferredTransport, &p.DietaryNeeds,
-		&p.CreatedAt, &p.UpdatedAt,
+		&p.Currency, &p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
 		err = tx.Rollback(ctx)
@@ -612,6 +617,10 @@ func (r *ProfileRepository) UpdateSearchProfile(ctx context.Context, userID, pro
 		updateBuilder = updateBuilder.Set("budget_level", *params.BudgetLevel)
 		hasUpdates = true
 	}
+	if params.Currency != nil {
+		updateBuilder = updateBuilder.Set("currency", strings.ToUpper(*params.Currency))
+		hasUpdates = true
+	}
 	if params.PreferredPace != nil {
 		updateBuilder = updateBuilder.Set("preferred_pace", *params.PreferredPace)
 		hasUpdates = true
@@ -254,6 +254,7 @@ func (s *ServiceImpl) CreateSearchProfileCC(ctx context.Context, userID uuid.UUI
 		SearchRadiusKm:       createdProfileCore.SearchRadiusKm,
 		PreferredTime:        createdProfileCore.PreferredTime,
 		BudgetLevel:          createdProfileCore.BudgetLevel,
+		Currency:             createdProfileCore.Currency,
 		PreferredPace:        createdProfileCore.PreferredPace,
 		PreferAccessiblePOIs: createdProfileCore.PreferAccessiblePOIs,
 		PreferOutdoorSeating: createdProfileCore.PreferOutdoorSeating,
@@ -0,0 +1,303 @@
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// UpgradeURL is where refused requests point the user.
+const UpgradeURL = "/pricing"
+
+// SignUpURL is where refused requests point guests: an account lifts the guest limits and
+// keeps what the guest saved.
+const SignUpURL = "/auth/signup"
+
+// Handler turns plan limits into gin middleware placed in front of the handlers that spend
+// them.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+	enforce bool
+	now     func() time.Time
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+		enforce: true,
+		now:     time.Now,
+	}
+}
+
+// WithEnforcement turns the limits off when enforce is false, e.g. for local development.
+func (h *Handler) WithEnforcement(enforce bool) *Handler {
+	h.enforce = enforce
+	return h
+}
+
+// searchChargeKey holds the func charging the search of a request that passed RequireSearch.
+const searchChargeKey = "quota.chargeSearch"
+
+// RequireSearch refuses the request once the daily AI searches of the plan are used up.
+// Guests are held to the guest plan, per client address when their guest id was issued by
+// the request itself. The search is counted only when the handler calls
+// ChargeSearch, so requests it rejects as malformed cost nothing.
+func (h *Handler) RequireSearch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var usage Usage
+		var err error
+		if userID, ok := h.userID(c); ok {
+			usage, err = h.service.CheckSearch(c.Request.Context(), userID)
+		} else if guestID, ok := h.guestID(c); ok && !middleware.IsNewGuest(c) {
+			usage, err = h.service.CheckGuestSearch(c.Request.Context(), guestID)
+		} else if ok {
+			usage, err = h.service.CheckAddressSearch(c.Request.Context(), c.ClientIP())
+		} else {
+			c.Next()
+			return
+		}
+		if h.refuse(c, err) {
+			return
+		}
+		c.Set(string(middleware.UserPlanKey), string(usage.Plan))
+		if usage.SearchLimit != Unlimited {
+			// Remaining once this search is charged
+			c.Header("X-RateLimit-Limit", strconv.Itoa(usage.SearchLimit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(max(usage.SearchLimit-usage.Searches-1, 0)))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(usage.SearchResetAt.Unix(), 10))
+		}
+		charged := false
+		c.Set(searchChargeKey, func() bool {
+			if charged {
+				return true
+			}
+			charged = true
+			_, err := h.service.ChargeSearch(c.Request.Context(), usage)
+			return !h.refuse(c, err)
+		})
+		c.Next()
+	}
+}
+
+// ChargeSearch counts the request as an AI search. Handlers behind RequireSearch call it
+// once they have validated the request and before generating anything. It returns false
+// when searches charged since the check used up the day, the refusal then being written.
+// Requests RequireSearch did not check are not counted.
+func ChargeSearch(c *gin.Context) bool {
+	value, ok := c.Get(searchChargeKey)
+	if !ok {
+		return true
+	}
+	charge, ok := value.(func() bool)
+	return !ok || charge()
+}
+
+// RequireSavedItem refuses favourites and list items beyond the saved locations of the plan.
+// Guests are held to the guest plan.
+func (h *Handler) RequireSavedItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		if userID, ok := h.userID(c); ok {
+			err = h.service.CheckSavedItem(c.Request.Context(), userID)
+		} else if guestID, ok := h.guestID(c); ok {
+			err = h.service.CheckGuestSavedItem(c.Request.Context(), guestID)
+		} else {
+			c.Next()
+			return
+		}
+		if h.refuse(c, err) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireFeature refuses the request unless the plan includes feature.
+func (h *Handler) RequireFeature(feature Feature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := h.userID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if h.refuse(c, h.service.CheckFeature(c.Request.Context(), userID, feature)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// userID returns the authenticated user to check. Anonymous requests are left to the
+// handler, which already decides whether they need to sign in.
+func (h *Handler) userID(c *gin.Context) (uuid.UUID, bool) {
+	if !h.enforce {
+		return uuid.Nil, false
+	}
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(user.ID)
+	if err != nil {
+		h.logger.Warn("Invalid user ID in context, skipping quota check", zap.String("userID", user.ID))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// guestID returns the guest to check when the request has no user.
+func (h *Handler) guestID(c *gin.Context) (uuid.UUID, bool) {
+	if !h.enforce {
+		return uuid.Nil, false
+	}
+	return middleware.GetGuestFromContext(c)
+}
+
+// refuse aborts the request when err is a plan limit. Other errors are logged and the request
+// goes through: a quota lookup failing must not take the product down with it.
+func (h *Handler) refuse(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		h.logger.Error("Quota check failed, allowing request", zap.String("path", c.FullPath()), zap.Error(err))
+		return false
+	}
+
+	h.logger.Info("Request refused by plan limit",
+		zap.String("path", c.FullPath()),
+		zap.String("plan", string(limitErr.Plan)),
+		zap.String("resource", string(limitErr.Resource)),
+		zap.String("feature", string(limitErr.Feature)))
+
+	status := http.StatusPaymentRequired
+	if limitErr.Resource == ResourceSearches {
+		status = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.Itoa(int(max(limitErr.ResetAt.Sub(h.now()), 0).Seconds())))
+	}
+
+	switch {
+	case strings.Contains(c.GetHeader("Accept"), "text/event-stream"):
+		h.writeStreamEvent(c, limitErr)
+	case c.GetHeader("HX-Request") == "true":
+		h.writeUpgradePrompt(c, limitErr)
+	default:
+		c.AbortWithStatusJSON(status, errorBody(limitErr))
+	}
+	c.Abort()
+	return true
+}
+
+// writeUpgradePrompt appends the upgrade prompt to the page. htmx does not swap error
+// responses, so the prompt is sent with 200 and retargeted away from the element that made
+// the request; the quota-exceeded event carries the details for pages that want them.
+func (h *Handler) writeUpgradePrompt(c *gin.Context, err *LimitError) {
+	trigger, _ := json.Marshal(map[string]any{"quota-exceeded": errorBody(err)})
+	c.Header("HX-Retarget", "body")
+	c.Header("HX-Reswap", "beforeend")
+	c.Header("HX-Trigger", string(trigger))
+	c.Status(http.StatusOK)
+
+	title, description := describe(err)
+	props := UpgradePromptProps{Title: title, Description: description, UpgradeURL: upgradeURL(err)}
+	if err.Plan == PlanGuest {
+		props.Action = "Create free account"
+	}
+	if renderErr := UpgradePrompt(props).Render(c.Request.Context(), c.Writer); renderErr != nil {
+		h.logger.Error("Failed to render upgrade prompt", zap.Error(renderErr))
+	}
+}
+
+// writeStreamEvent answers an EventSource with a single error event. A non-200 response
+// would only make the browser reconnect.
+func (h *Handler) writeStreamEvent(c *gin.Context, err *LimitError) {
+	title, description := describe(err)
+	event := models.StreamEvent{
+		Type:      models.EventTypeError,
+		Message:   title + ". " + description,
+		Error:     err.Error(),
+		Timestamp: h.now(),
+		EventID:   uuid.New().String(),
+		IsFinal:   true,
+		Navigation: &models.NavigationData{
+			URL:       upgradeURL(err),
+			RouteType: "pricing",
+		},
+	}
+	data, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		h.logger.Error("Failed to marshal quota event", zap.Error(marshalErr))
+		return
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	fmt.Fprintf(c.Writer, "data: {\"type\":\"sse-close\"}\n\n")
+	c.Writer.Flush()
+}
+
+func errorBody(err *LimitError) gin.H {
+	body := gin.H{
+		"error":       err.Error(),
+		"plan":        err.Plan,
+		"upgrade_url": upgradeURL(err),
+	}
+	if err.Feature != "" {
+		body["code"] = "feature_not_in_plan"
+		body["feature"] = err.Feature
+		return body
+	}
+	body["code"] = "quota_exceeded"
+	body["resource"] = err.Resource
+	body["limit"] = err.Limit
+	body["used"] = err.Used
+	if !err.ResetAt.IsZero() {
+		body["reset_at"] = err.ResetAt
+	}
+	return body
+}
+
+// upgradeURL is the pricing page, or the sign up page for guests.
+func upgradeURL(err *LimitError) string {
+	if err.Plan == PlanGuest {
+		return SignUpURL
+	}
+	return UpgradeURL
+}
+
+// describe words a refusal for the upgrade prompt.
+func describe(err *LimitError) (string, string) {
+	switch {
+	case err.Plan == PlanGuest && err.Resource == ResourceSearches:
+		return "Daily search limit reached",
+			fmt.Sprintf("Guests get %d searches a day. Create a free account to keep searching; your results come with you.", err.Limit)
+	case err.Plan == PlanGuest:
+		return "Saved locations limit reached",
+			fmt.Sprintf("Guests can save up to %d locations. Create a free account to save more; what you saved comes with you.", err.Limit)
+	case err.Feature == FeatureCustomLists:
+		return "Custom lists are a paid feature", "Upgrade to Explorer to create your own lists and collections."
+	case err.Feature != "":
+		return "Not included in your plan", fmt.Sprintf("Upgrade to Pro to use %s.", strings.ReplaceAll(string(err.Feature), "_", " "))
+	case err.Resource == ResourceSearches:
+		return "Daily search limit reached",
+			fmt.Sprintf("You have used all %d searches included in the %s plan today. Upgrade for unlimited searches.", err.Limit, err.Plan)
+	default:
+		return "Saved locations limit reached",
+			fmt.Sprintf("The %s plan includes up to %d saved locations. Remove some or upgrade to save more.", err.Plan, err.Limit)
+	}
+}
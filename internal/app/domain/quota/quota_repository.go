@@ -0,0 +1,185 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+// searchIntents are the llm_interactions intents logged by a new search: the unified chat
+// stream logs its domain, the discover page logs "discover". Follow-up messages in a session
+// (add_poi, remove_poi, ...) and nearby lookups are not searches.
+var searchIntents = []string{"discover", "general", "itinerary", "accommodation", "dining", "activities"}
+
+type Repository interface {
+	// GetSubscription returns the user's current subscription, or nil when the user never
+	// subscribed or the subscription has lapsed.
+	GetSubscription(ctx context.Context, userID uuid.UUID) (*Subscription, error)
+	// CountSearchesSince counts the distinct search sessions the user started since the given time.
+	CountSearchesSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	// CountSavedItems counts the user's favourites across POIs, hotels and restaurants plus
+	// the items in lists they own.
+	CountSavedItems(ctx context.Context, userID uuid.UUID) (int, error)
+	// CountGuestSearchesSince counts the free-mode sessions the guest started since the given time.
+	CountGuestSearchesSince(ctx context.Context, guestID uuid.UUID, since time.Time) (int, error)
+	// CountGuestSavedItems counts the guest's favourites plus the items in its lists.
+	CountGuestSavedItems(ctx context.Context, guestID uuid.UUID) (int, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+// GetSubscription fetches the active, trialing or past due subscription of a user
+func (r *RepositoryImpl) GetSubscription(ctx context.Context, userID uuid.UUID) (*Subscription, error) {
+	ctx, span := otel.Tracer("QuotaRepository").Start(ctx, "GetSubscription", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT plan::text, status::text, end_date
+		FROM subscriptions
+		WHERE user_id = $1
+		  AND status IN ('active', 'trialing', 'past_due')
+		  AND (end_date IS NULL OR end_date > NOW())`
+
+	var sub Subscription
+	err := r.pgpool.QueryRow(ctx, query, userID).Scan(&sub.Plan, &sub.Status, &sub.EndDate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		span.SetStatus(codes.Ok, "no active subscription")
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to get subscription")
+		return nil, fmt.Errorf("failed to get subscription for user %s: %w", userID, err)
+	}
+
+	span.SetAttributes(attribute.String("subscription.plan", sub.Plan))
+	span.SetStatus(codes.Ok, "Subscription found")
+	return &sub, nil
+}
+
+// CountSearchesSince counts search sessions logged in llm_interactions. A single search
+// logs one row per streamed part, all with the same session id.
+func (r *RepositoryImpl) CountSearchesSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	ctx, span := otel.Tracer("QuotaRepository").Start(ctx, "CountSearchesSince", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+		attribute.String("since", since.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	query := `
+		SELECT COUNT(DISTINCT COALESCE(session_id, id))
+		FROM llm_interactions
+		WHERE user_id = $1
+		  AND created_at >= $2
+		  AND intent = ANY($3)`
+
+	var count int
+	if err := r.pgpool.QueryRow(ctx, query, userID, since, searchIntents).Scan(&count); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count searches")
+		return 0, fmt.Errorf("failed to count searches for user %s: %w", userID, err)
+	}
+
+	span.SetAttributes(attribute.Int("searches.count", count))
+	span.SetStatus(codes.Ok, "Searches counted")
+	return count, nil
+}
+
+// CountSavedItems counts favourites and list items owned by the user
+func (r *RepositoryImpl) CountSavedItems(ctx context.Context, userID uuid.UUID) (int, error) {
+	ctx, span := otel.Tracer("QuotaRepository").Start(ctx, "CountSavedItems", trace.WithAttributes(
+		attribute.String("user_id", userID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM user_favorite_pois WHERE user_id = $1) +
+			(SELECT COUNT(*) FROM user_favorite_llm_pois WHERE user_id = $1) +
+			(SELECT COUNT(*) FROM user_favorite_hotels WHERE user_id = $1) +
+			(SELECT COUNT(*) FROM user_favorite_restaurants WHERE user_id = $1) +
+			(SELECT COUNT(*) FROM list_items li JOIN lists l ON l.id = li.list_id WHERE l.user_id = $1)`
+
+	var count int
+	if err := r.pgpool.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count saved items")
+		return 0, fmt.Errorf("failed to count saved items for user %s: %w", userID, err)
+	}
+
+	span.SetAttributes(attribute.Int("saved_items.count", count))
+	span.SetStatus(codes.Ok, "Saved items counted")
+	return count, nil
+}
+
+// CountGuestSearchesSince counts guest chat sessions. Free-mode interactions are logged
+// without a user, so the sessions are what ties searches to the guest.
+func (r *RepositoryImpl) CountGuestSearchesSince(ctx context.Context, guestID uuid.UUID, since time.Time) (int, error) {
+	ctx, span := otel.Tracer("QuotaRepository").Start(ctx, "CountGuestSearchesSince", trace.WithAttributes(
+		attribute.String("guest_id", guestID.String()),
+		attribute.String("since", since.Format(time.RFC3339)),
+	))
+	defer span.End()
+
+	query := `SELECT COUNT(*) FROM chat_sessions WHERE guest_id = $1 AND created_at >= $2`
+
+	var count int
+	if err := r.pgpool.QueryRow(ctx, query, guestID, since).Scan(&count); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count guest searches")
+		return 0, fmt.Errorf("failed to count searches for guest %s: %w", guestID, err)
+	}
+
+	span.SetAttributes(attribute.Int("searches.count", count))
+	span.SetStatus(codes.Ok, "Guest searches counted")
+	return count, nil
+}
+
+// CountGuestSavedItems counts favourites and list items owned by the guest
+func (r *RepositoryImpl) CountGuestSavedItems(ctx context.Context, guestID uuid.UUID) (int, error) {
+	ctx, span := otel.Tracer("QuotaRepository").Start(ctx, "CountGuestSavedItems", trace.WithAttributes(
+		attribute.String("guest_id", guestID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM guest_favorites WHERE guest_id = $1) +
+			(SELECT COUNT(*) FROM list_items li JOIN lists l ON l.id = li.list_id WHERE l.guest_id = $1)`
+
+	var count int
+	if err := r.pgpool.QueryRow(ctx, query, guestID).Scan(&count); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count guest saved items")
+		return 0, fmt.Errorf("failed to count saved items for guest %s: %w", guestID, err)
+	}
+
+	span.SetAttributes(attribute.Int("saved_items.count", count))
+	span.SetStatus(codes.Ok, "Guest saved items counted")
+	return count, nil
+}
@@ -0,0 +1,392 @@
+// Package quota enforces the plan limits advertised on the pricing page: daily AI searches,
+// saved locations and features reserved for paid plans.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Plan is a pricing tier.
+type Plan string
+
+const (
+	PlanFree     Plan = "free"
+	PlanExplorer Plan = "explorer"
+	PlanPro      Plan = "pro"
+	// PlanGuest limits visitors without an account, identified by their guest cookie.
+	PlanGuest Plan = "guest"
+)
+
+// Feature is a capability only some plans include.
+type Feature string
+
+const (
+	FeatureCustomLists    Feature = "custom_lists"
+	FeatureMultiCity      Feature = "multi_city"
+	FeatureExport         Feature = "export"
+	FeatureSemanticSearch Feature = "semantic_search"
+)
+
+// Unlimited disables a numeric limit.
+const Unlimited = -1
+
+// Limits of a plan.
+type Limits struct {
+	DailySearches int
+	SavedItems    int
+	Features      []Feature
+}
+
+// PlanLimits mirrors the comparison table on the pricing page.
+var PlanLimits = map[Plan]Limits{
+	PlanGuest: {
+		DailySearches: 3,
+		SavedItems:    5,
+	},
+	PlanFree: {
+		DailySearches: 5,
+		SavedItems:    10,
+	},
+	PlanExplorer: {
+		DailySearches: Unlimited,
+		SavedItems:    100,
+		Features:      []Feature{FeatureCustomLists},
+	},
+	PlanPro: {
+		DailySearches: Unlimited,
+		SavedItems:    Unlimited,
+		Features:      []Feature{FeatureCustomLists, FeatureMultiCity, FeatureExport, FeatureSemanticSearch},
+	},
+}
+
+// Allows reports whether the plan includes feature.
+func (l Limits) Allows(feature Feature) bool {
+	return slices.Contains(l.Features, feature)
+}
+
+// Subscription is the current row of the subscriptions table.
+type Subscription struct {
+	Plan    string
+	Status  string
+	EndDate *time.Time
+}
+
+// PlanFromSubscription maps a subscription to a pricing tier. The legacy premium plans
+// predate Explorer and keep everything they paid for.
+func PlanFromSubscription(sub *Subscription) Plan {
+	if sub == nil {
+		return PlanFree
+	}
+	switch sub.Plan {
+	case string(PlanExplorer):
+		return PlanExplorer
+	case string(PlanPro), "premium_monthly", "premium_annual":
+		return PlanPro
+	default:
+		return PlanFree
+	}
+}
+
+var (
+	// ErrQuotaExceeded is returned when a counted limit, e.g. daily searches, is used up.
+	ErrQuotaExceeded = errors.New("plan quota exceeded")
+	// ErrFeatureNotInPlan is returned when the plan does not include a feature.
+	ErrFeatureNotInPlan = errors.New("feature not included in plan")
+)
+
+// Resource names a counted limit.
+type Resource string
+
+const (
+	ResourceSearches   Resource = "daily_searches"
+	ResourceSavedItems Resource = "saved_items"
+)
+
+// LimitError describes a refused request. It matches ErrQuotaExceeded or
+// ErrFeatureNotInPlan with errors.Is.
+type LimitError struct {
+	Plan     Plan
+	Resource Resource // empty for feature gates
+	Feature  Feature  // empty for counted limits
+	Limit    int
+	Used     int
+	ResetAt  time.Time // zero unless the limit resets, e.g. at midnight UTC for searches
+}
+
+func (e *LimitError) Error() string {
+	if e.Feature != "" {
+		return fmt.Sprintf("%s: %s is not available on the %s plan", ErrFeatureNotInPlan, e.Feature, e.Plan)
+	}
+	return fmt.Sprintf("%s: %d of %d %s used on the %s plan", ErrQuotaExceeded, e.Used, e.Limit, e.Resource, e.Plan)
+}
+
+func (e *LimitError) Is(target error) bool {
+	if e.Feature != "" {
+		return target == ErrFeatureNotInPlan
+	}
+	return target == ErrQuotaExceeded
+}
+
+// Usage is a user's plan and daily search consumption.
+type Usage struct {
+	Plan          Plan
+	Searches      int
+	SearchLimit   int
+	SearchResetAt time.Time
+
+	subject uuid.UUID // The user or guest the searches are counted against
+}
+
+type Service interface {
+	GetPlan(ctx context.Context, userID uuid.UUID) (Plan, error)
+	// CheckSearch allows one more AI search today, or returns a *LimitError. The search is
+	// only counted by ChargeSearch, once the request has been accepted.
+	CheckSearch(ctx context.Context, userID uuid.UUID) (Usage, error)
+	// ChargeSearch counts the search a check allowed, returning a *LimitError when searches
+	// charged since the check used up the day.
+	ChargeSearch(ctx context.Context, usage Usage) (Usage, error)
+	// CheckSavedItem allows one more favourite or list item, or returns a *LimitError.
+	CheckSavedItem(ctx context.Context, userID uuid.UUID) error
+	// CheckFeature returns a *LimitError when the user's plan does not include feature.
+	CheckFeature(ctx context.Context, userID uuid.UUID, feature Feature) error
+	// CheckGuestSearch is CheckSearch for a visitor without an account, on the guest plan.
+	CheckGuestSearch(ctx context.Context, guestID uuid.UUID) (Usage, error)
+	// CheckAddressSearch is CheckGuestSearch for a visitor whose guest id was just issued,
+	// counted against the client address instead.
+	CheckAddressSearch(ctx context.Context, address string) (Usage, error)
+	// CheckGuestSavedItem is CheckSavedItem for a visitor without an account.
+	CheckGuestSavedItem(ctx context.Context, guestID uuid.UUID) error
+}
+
+type ServiceImpl struct {
+	logger *zap.Logger
+	repo   Repository
+	now    func() time.Time
+
+	// LLM interactions are logged asynchronously, so a burst of searches is not visible in
+	// the table yet. Searches charged by this instance today fill the gap.
+	mu      sync.Mutex
+	day     time.Time
+	allowed map[uuid.UUID]int
+}
+
+// NewService creates a new instance of ServiceImpl
+func NewService(repo Repository, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		logger:  logger,
+		repo:    repo,
+		now:     time.Now,
+		allowed: make(map[uuid.UUID]int),
+	}
+}
+
+// GetPlan returns the pricing tier of the user
+func (s *ServiceImpl) GetPlan(ctx context.Context, userID uuid.UUID) (Plan, error) {
+	sub, err := s.repo.GetSubscription(ctx, userID)
+	if err != nil {
+		return PlanFree, fmt.Errorf("failed to get plan: %w", err)
+	}
+	return PlanFromSubscription(sub), nil
+}
+
+// CheckSearch counts today's searches, in UTC, against the daily limit of the plan
+func (s *ServiceImpl) CheckSearch(ctx context.Context, userID uuid.UUID) (Usage, error) {
+	ctx, span := otel.Tracer("QuotaService").Start(ctx, "CheckSearch", trace.WithAttributes(
+		attribute.String("user.id", userID.String()),
+	))
+	defer span.End()
+
+	plan, err := s.GetPlan(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to get plan")
+		return Usage{}, err
+	}
+	return s.checkSearch(ctx, span, userID, plan, s.repo.CountSearchesSince)
+}
+
+// CheckGuestSearch counts the free-mode sessions the guest started today, in UTC
+func (s *ServiceImpl) CheckGuestSearch(ctx context.Context, guestID uuid.UUID) (Usage, error) {
+	ctx, span := otel.Tracer("QuotaService").Start(ctx, "CheckGuestSearch", trace.WithAttributes(
+		attribute.String("guest.id", guestID.String()),
+	))
+	defer span.End()
+
+	return s.checkSearch(ctx, span, guestID, PlanGuest, s.repo.CountGuestSearchesSince)
+}
+
+// addressNamespace derives the ids client addresses are counted under from the address.
+var addressNamespace = uuid.MustParse("5b0e6a4e-3c1f-4f57-9a43-8d6f2b9c7e10")
+
+// CheckAddressSearch holds visitors without a guest cookie to the guest plan per client
+// address. Clients dropping the cookie would otherwise get a fresh allowance on every
+// request. Nothing is logged under an address, so only the searches this instance charged
+// today count.
+func (s *ServiceImpl) CheckAddressSearch(ctx context.Context, address string) (Usage, error) {
+	ctx, span := otel.Tracer("QuotaService").Start(ctx, "CheckAddressSearch", trace.WithAttributes(
+		attribute.String("client.address", address),
+	))
+	defer span.End()
+
+	noneLogged := func(context.Context, uuid.UUID, time.Time) (int, error) { return 0, nil }
+	return s.checkSearch(ctx, span, uuid.NewSHA1(addressNamespace, []byte(address)), PlanGuest, noneLogged)
+}
+
+// checkSearch allows id, a user or a guest, one more search when both the searches count
+// finds today and those this instance charged are below the daily limit of plan.
+func (s *ServiceImpl) checkSearch(ctx context.Context, span trace.Span, id uuid.UUID, plan Plan,
+	count func(ctx context.Context, id uuid.UUID, since time.Time) (int, error)) (Usage, error) {
+	limits := PlanLimits[plan]
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	usage := Usage{Plan: plan, SearchLimit: limits.DailySearches, SearchResetAt: today.Add(24 * time.Hour), subject: id}
+	span.SetAttributes(attribute.String("quota.plan", string(plan)))
+
+	if limits.DailySearches == Unlimited {
+		span.SetStatus(codes.Ok, "Unlimited searches")
+		return usage, nil
+	}
+
+	logged, err := count(ctx, id, today)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count searches")
+		return usage, fmt.Errorf("failed to check search quota: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage.Searches = max(logged, s.chargedLocked(id, today))
+	span.SetAttributes(attribute.Int("quota.searches", usage.Searches))
+
+	if usage.Searches >= limits.DailySearches {
+		span.SetStatus(codes.Error, "Daily searches exceeded")
+		return usage, newSearchLimitErr(usage)
+	}
+	span.SetStatus(codes.Ok, "Search allowed")
+	return usage, nil
+}
+
+// ChargeSearch counts the search against the subject of usage. The count of the check is
+// compared again with what this instance charged since, so concurrent requests that all
+// passed the check cannot go over the limit together.
+func (s *ServiceImpl) ChargeSearch(ctx context.Context, usage Usage) (Usage, error) {
+	_, span := otel.Tracer("QuotaService").Start(ctx, "ChargeSearch", trace.WithAttributes(
+		attribute.String("quota.plan", string(usage.Plan)),
+	))
+	defer span.End()
+
+	if usage.SearchLimit == Unlimited || usage.subject == uuid.Nil {
+		span.SetStatus(codes.Ok, "Search not counted")
+		return usage, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage.Searches = max(usage.Searches, s.chargedLocked(usage.subject, usage.SearchResetAt.Add(-24*time.Hour)))
+	if usage.Searches >= usage.SearchLimit {
+		span.SetStatus(codes.Error, "Daily searches exceeded")
+		return usage, newSearchLimitErr(usage)
+	}
+	usage.Searches++
+	s.allowed[usage.subject] = usage.Searches
+	span.SetAttributes(attribute.Int("quota.searches", usage.Searches))
+	span.SetStatus(codes.Ok, "Search charged")
+	return usage, nil
+}
+
+// chargedLocked returns the searches charged to id on day, forgetting earlier days.
+func (s *ServiceImpl) chargedLocked(id uuid.UUID, day time.Time) int {
+	if !s.day.Equal(day) {
+		s.day = day
+		clear(s.allowed)
+	}
+	return s.allowed[id]
+}
+
+func newSearchLimitErr(usage Usage) *LimitError {
+	return &LimitError{
+		Plan:     usage.Plan,
+		Resource: ResourceSearches,
+		Limit:    usage.SearchLimit,
+		Used:     usage.Searches,
+		ResetAt:  usage.SearchResetAt,
+	}
+}
+
+// CheckSavedItem compares the saved favourites and list items with the plan limit
+func (s *ServiceImpl) CheckSavedItem(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := otel.Tracer("QuotaService").Start(ctx, "CheckSavedItem", trace.WithAttributes(
+		attribute.String("user.id", userID.String()),
+	))
+	defer span.End()
+
+	plan, err := s.GetPlan(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to get plan")
+		return err
+	}
+	return s.checkSavedItem(ctx, span, userID, plan, s.repo.CountSavedItems)
+}
+
+// CheckGuestSavedItem compares the guest's favourites and list items with the guest plan limit
+func (s *ServiceImpl) CheckGuestSavedItem(ctx context.Context, guestID uuid.UUID) error {
+	ctx, span := otel.Tracer("QuotaService").Start(ctx, "CheckGuestSavedItem", trace.WithAttributes(
+		attribute.String("guest.id", guestID.String()),
+	))
+	defer span.End()
+
+	return s.checkSavedItem(ctx, span, guestID, PlanGuest, s.repo.CountGuestSavedItems)
+}
+
+func (s *ServiceImpl) checkSavedItem(ctx context.Context, span trace.Span, id uuid.UUID, plan Plan,
+	count func(ctx context.Context, id uuid.UUID) (int, error)) error {
+	limits := PlanLimits[plan]
+	span.SetAttributes(attribute.String("quota.plan", string(plan)))
+	if limits.SavedItems == Unlimited {
+		span.SetStatus(codes.Ok, "Unlimited saved items")
+		return nil
+	}
+
+	saved, err := count(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to count saved items")
+		return fmt.Errorf("failed to check saved items quota: %w", err)
+	}
+	span.SetAttributes(attribute.Int("quota.saved_items", saved))
+
+	if saved >= limits.SavedItems {
+		span.SetStatus(codes.Error, "Saved items exceeded")
+		return &LimitError{Plan: plan, Resource: ResourceSavedItems, Limit: limits.SavedItems, Used: saved}
+	}
+	span.SetStatus(codes.Ok, "Saved item allowed")
+	return nil
+}
+
+// CheckFeature looks the feature up in the limits of the user's plan
+func (s *ServiceImpl) CheckFeature(ctx context.Context, userID uuid.UUID, feature Feature) error {
+	plan, err := s.GetPlan(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !PlanLimits[plan].Allows(feature) {
+		return &LimitError{Plan: plan, Feature: feature}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetSubscription(ctx context.Context, userID uuid.UUID) (*Subscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Subscription), args.Error(1)
+}
+
+func (m *MockRepository) CountSearchesSince(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) CountSavedItems(ctx context.Context, userID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) CountGuestSearchesSince(ctx context.Context, guestID uuid.UUID, since time.Time) (int, error) {
+	args := m.Called(ctx, guestID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) CountGuestSavedItems(ctx context.Context, guestID uuid.UUID) (int, error) {
+	args := m.Called(ctx, guestID)
+	return args.Int(0), args.Error(1)
+}
+
+func newTestService(repo Repository, now time.Time) *ServiceImpl {
+	s := NewService(repo, zap.NewNop())
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestPlanFromSubscription(t *testing.T) {
+	assert.Equal(t, PlanFree, PlanFromSubscription(nil))
+	assert.Equal(t, PlanFree, PlanFromSubscription(&Subscription{Plan: "free"}))
+	assert.Equal(t, PlanExplorer, PlanFromSubscription(&Subscription{Plan: "explorer"}))
+	assert.Equal(t, PlanPro, PlanFromSubscription(&Subscription{Plan: "pro"}))
+	assert.Equal(t, PlanPro, PlanFromSubscription(&Subscription{Plan: "premium_annual"}), "legacy premium plans keep everything")
+}
+
+func TestCheckSearch_FreePlanDailyLimit(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	midnight := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockRepository)
+	repo.On("GetSubscription", mock.Anything, userID).Return(nil, nil)
+	repo.On("CountSearchesSince", mock.Anything, userID, midnight).Return(4, nil)
+	service := newTestService(repo, now)
+
+	usage, err := service.CheckSearch(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Plan: PlanFree, Searches: 4, SearchLimit: 5, SearchResetAt: midnight.Add(24 * time.Hour), subject: userID}, usage)
+
+	// Checking alone counts nothing
+	_, err = service.CheckSearch(ctx, userID)
+	require.NoError(t, err)
+
+	charged, err := service.ChargeSearch(ctx, usage)
+	require.NoError(t, err)
+	assert.Equal(t, 5, charged.Searches)
+
+	// The fifth search has not been logged yet, the service still remembers charging it
+	_, err = service.CheckSearch(ctx, userID)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.NotErrorIs(t, err, ErrFeatureNotInPlan)
+
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, &LimitError{
+		Plan:     PlanFree,
+		Resource: ResourceSearches,
+		Limit:    5,
+		Used:     5,
+		ResetAt:  midnight.Add(24 * time.Hour),
+	}, limitErr)
+
+	// A request that passed the check before the fifth search was charged is refused
+	_, err = service.ChargeSearch(ctx, usage)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+
+	// A new day starts from the logged count again
+	service.now = func() time.Time { return now.Add(12 * time.Hour) }
+	repo.On("CountSearchesSince", mock.Anything, userID, midnight.Add(24*time.Hour)).Return(0, nil).Once()
+	usage, err = service.CheckSearch(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, usage.Searches)
+	repo.AssertExpectations(t)
+}
+
+func TestCheckSearch_PaidPlansAreUnlimited(t *testing.T) {
+	userID := uuid.New()
+	repo := new(MockRepository)
+	repo.On("GetSubscription", mock.Anything, userID).Return(&Subscription{Plan: "explorer", Status: "active"}, nil)
+	service := newTestService(repo, time.Now())
+
+	usage, err := service.CheckSearch(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, PlanExplorer, usage.Plan)
+	assert.Equal(t, Unlimited, usage.SearchLimit)
+	repo.AssertNotCalled(t, "CountSearchesSince", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckSearch_RepositoryError(t *testing.T) {
+	userID := uuid.New()
+	repo := new(MockRepository)
+	repo.On("GetSubscription", mock.Anything, userID).Return(nil, nil)
+	repo.On("CountSearchesSince", mock.Anything, userID, mock.Anything).Return(0, errors.New("connection refused"))
+	service := newTestService(repo, time.Now())
+
+	_, err := service.CheckSearch(context.Background(), userID)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrQuotaExceeded)
+}
+
+func TestCheckSavedItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     *Subscription
+		saved   int
+		wantErr bool
+	}{
+		{name: "free under limit", saved: 9},
+		{name: "free at limit", saved: 10, wantErr: true},
+		{name: "explorer under limit", sub: &Subscription{Plan: "explorer"}, saved: 99},
+		{name: "explorer at limit", sub: &Subscription{Plan: "explorer"}, saved: 100, wantErr: true},
+		{name: "pro is unlimited", sub: &Subscription{Plan: "pro"}, saved: 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			repo := new(MockRepository)
+			repo.On("GetSubscription", mock.Anything, userID).Return(tt.sub, nil)
+			repo.On("CountSavedItems", mock.Anything, userID).Return(tt.saved, nil)
+			service := newTestService(repo, time.Now())
+
+			err := service.CheckSavedItem(context.Background(), userID)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrQuotaExceeded)
+			var limitErr *LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, ResourceSavedItems, limitErr.Resource)
+			assert.Equal(t, tt.saved, limitErr.Used)
+		})
+	}
+}
+
+func TestCheckFeature(t *testing.T) {
+	userID := uuid.New()
+	repo := new(MockRepository)
+	repo.On("GetSubscription", mock.Anything, userID).Return(nil, nil).Once()
+	service := newTestService(repo, time.Now())
+
+	err := service.CheckFeature(context.Background(), userID, FeatureCustomLists)
+	assert.ErrorIs(t, err, ErrFeatureNotInPlan)
+	assert.EqualError(t, err, "feature not included in plan: custom_lists is not available on the free plan")
+
+	repo.On("GetSubscription", mock.Anything, userID).Return(&Subscription{Plan: "explorer"}, nil)
+	assert.NoError(t, service.CheckFeature(context.Background(), userID, FeatureCustomLists))
+	assert.ErrorIs(t, service.CheckFeature(context.Background(), userID, FeatureMultiCity), ErrFeatureNotInPlan)
+}
+
+func TestCheckGuest_GuestPlanLimits(t *testing.T) {
+	ctx := context.Background()
+	guestID := uuid.New()
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+	midnight := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	repo := new(MockRepository)
+	repo.On("CountGuestSearchesSince", mock.Anything, guestID, midnight).Return(2, nil)
+	repo.On("CountGuestSavedItems", mock.Anything, guestID).Return(5, nil)
+	service := newTestService(repo, now)
+
+	usage, err := service.CheckGuestSearch(ctx, guestID)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Plan: PlanGuest, Searches: 2, SearchLimit: 3, SearchResetAt: midnight.Add(24 * time.Hour), subject: guestID}, usage)
+	_, err = service.ChargeSearch(ctx, usage)
+	require.NoError(t, err)
+
+	_, err = service.CheckGuestSearch(ctx, guestID)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, PlanGuest, limitErr.Plan)
+	assert.Equal(t, 3, limitErr.Used)
+
+	err = service.CheckGuestSavedItem(ctx, guestID)
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, &LimitError{Plan: PlanGuest, Resource: ResourceSavedItems, Limit: 5, Used: 5}, limitErr)
+	repo.AssertNotCalled(t, "GetSubscription", mock.Anything, mock.Anything)
+}
+
+func TestCheckAddressSearch(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(new(MockRepository), time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC))
+
+	for range PlanLimits[PlanGuest].DailySearches {
+		usage, err := service.CheckAddressSearch(ctx, "203.0.113.7")
+		require.NoError(t, err)
+		_, err = service.ChargeSearch(ctx, usage)
+		require.NoError(t, err)
+	}
+
+	_, err := service.CheckAddressSearch(ctx, "203.0.113.7")
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, PlanGuest, limitErr.Plan)
+
+	_, err = service.CheckAddressSearch(ctx, "198.51.100.2")
+	assert.NoError(t, err, "other addresses keep their own allowance")
+}
@@ -0,0 +1,275 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+type stubService struct {
+	usage      Usage
+	searchErr  error
+	savedErr   error
+	featureErr error
+	guestErr   error
+	chargeErr  error
+	guest      uuid.UUID // last guest checked
+	address    string    // last client address checked
+	charged    int
+}
+
+func (s *stubService) GetPlan(context.Context, uuid.UUID) (Plan, error) { return s.usage.Plan, nil }
+
+func (s *stubService) CheckSearch(context.Context, uuid.UUID) (Usage, error) {
+	return s.usage, s.searchErr
+}
+
+func (s *stubService) ChargeSearch(_ context.Context, usage Usage) (Usage, error) {
+	if s.chargeErr != nil {
+		return usage, s.chargeErr
+	}
+	s.charged++
+	usage.Searches++
+	return usage, nil
+}
+
+func (s *stubService) CheckSavedItem(context.Context, uuid.UUID) error { return s.savedErr }
+
+func (s *stubService) CheckFeature(context.Context, uuid.UUID, Feature) error { return s.featureErr }
+
+func (s *stubService) CheckGuestSearch(_ context.Context, guestID uuid.UUID) (Usage, error) {
+	s.guest = guestID
+	return s.usage, s.guestErr
+}
+
+func (s *stubService) CheckAddressSearch(_ context.Context, address string) (Usage, error) {
+	s.address = address
+	return s.usage, s.guestErr
+}
+
+func (s *stubService) CheckGuestSavedItem(_ context.Context, guestID uuid.UUID) error {
+	s.guest = guestID
+	return s.guestErr
+}
+
+var testNow = time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+
+func newTestRouter(h *Handler, userID string, guards ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h.now = func() time.Time { return testNow }
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set(string(middleware.UserContextKey), &models.User{ID: userID})
+		}
+	})
+	r.POST("/guarded", append(guards, func(c *gin.Context) {
+		if c.Query("invalid") != "" {
+			c.String(http.StatusBadRequest, "invalid")
+			return
+		}
+		if !ChargeSearch(c) {
+			return
+		}
+		c.String(http.StatusOK, "handled")
+	})...)
+	return r
+}
+
+func searchLimitErr() *LimitError {
+	return &LimitError{Plan: PlanFree, Resource: ResourceSearches, Limit: 5, Used: 5, ResetAt: testNow.Add(2 * time.Hour)}
+}
+
+func TestRequireSearch_JSON(t *testing.T) {
+	h := NewHandler(&stubService{searchErr: searchLimitErr()}, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "7200", w.Header().Get("Retry-After"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "quota_exceeded", body["code"])
+	assert.Equal(t, "daily_searches", body["resource"])
+	assert.Equal(t, float64(5), body["limit"])
+	assert.Equal(t, "/pricing", body["upgrade_url"])
+	assert.NotContains(t, w.Body.String(), "handled")
+}
+
+func TestRequireSearch_HTMX(t *testing.T) {
+	h := NewHandler(&stubService{searchErr: searchLimitErr()}, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	req := httptest.NewRequest(http.MethodPost, "/guarded", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "htmx only swaps successful responses")
+	assert.Equal(t, "body", w.Header().Get("HX-Retarget"))
+	assert.Equal(t, "beforeend", w.Header().Get("HX-Reswap"))
+	assert.Contains(t, w.Header().Get("HX-Trigger"), `"quota-exceeded"`)
+	assert.Contains(t, w.Body.String(), "Daily search limit reached")
+	assert.Contains(t, w.Body.String(), `href="/pricing"`)
+	assert.NotContains(t, w.Body.String(), "handled")
+}
+
+func TestRequireSearch_EventStream(t *testing.T) {
+	h := NewHandler(&stubService{searchErr: searchLimitErr()}, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	req := httptest.NewRequest(http.MethodPost, "/guarded", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"type":"error"`)
+	assert.Contains(t, w.Body.String(), `"navigation":{"url":"/pricing"`)
+	assert.Contains(t, w.Body.String(), `data: {"type":"sse-close"}`)
+}
+
+func TestRequireSearch_AllowedSetsRateLimitHeaders(t *testing.T) {
+	service := &stubService{usage: Usage{Plan: PlanFree, Searches: 2, SearchLimit: 5, SearchResetAt: testNow.Add(2 * time.Hour)}}
+	h := NewHandler(service, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "handled", w.Body.String())
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 1, service.charged)
+}
+
+func TestRequireSearch_RejectedRequestsAreNotCharged(t *testing.T) {
+	service := &stubService{usage: Usage{Plan: PlanFree, Searches: 2, SearchLimit: 5}}
+	h := NewHandler(service, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded?invalid=1", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Zero(t, service.charged)
+}
+
+func TestRequireSearch_ChargeRefusedAfterConcurrentSearches(t *testing.T) {
+	service := &stubService{usage: Usage{Plan: PlanFree, Searches: 4, SearchLimit: 5}, chargeErr: searchLimitErr()}
+	h := NewHandler(service, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSearch())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotContains(t, w.Body.String(), "handled")
+}
+
+func TestRequireFeature_PaymentRequired(t *testing.T) {
+	h := NewHandler(&stubService{featureErr: &LimitError{Plan: PlanFree, Feature: FeatureCustomLists}}, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireFeature(FeatureCustomLists))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"feature_not_in_plan"`)
+	assert.Contains(t, w.Body.String(), `"feature":"custom_lists"`)
+}
+
+func TestRequireSavedItem_PaymentRequired(t *testing.T) {
+	h := NewHandler(&stubService{savedErr: &LimitError{Plan: PlanFree, Resource: ResourceSavedItems, Limit: 10, Used: 10}}, zap.NewNop())
+	r := newTestRouter(h, uuid.NewString(), h.RequireSavedItem())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"resource":"saved_items"`)
+}
+
+func TestHandler_PassesThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		service *stubService
+		userID  string
+		enforce bool
+	}{
+		{name: "quota lookup failure", service: &stubService{searchErr: errors.New("connection refused")}, userID: uuid.NewString(), enforce: true},
+		{name: "anonymous user", service: &stubService{searchErr: searchLimitErr()}, enforce: true},
+		{name: "enforcement disabled", service: &stubService{searchErr: searchLimitErr()}, userID: uuid.NewString()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.service, zap.NewNop()).WithEnforcement(tt.enforce)
+			r := newTestRouter(h, tt.userID, h.RequireSearch())
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/guarded", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "handled", w.Body.String())
+		})
+	}
+}
+
+func TestRequireSearch_GuestPointsToSignUp(t *testing.T) {
+	guestID := uuid.New()
+	service := &stubService{
+		searchErr: errors.New("users are not checked"),
+		guestErr:  &LimitError{Plan: PlanGuest, Resource: ResourceSearches, Limit: 3, Used: 3, ResetAt: testNow.Add(2 * time.Hour)},
+	}
+	h := NewHandler(service, zap.NewNop())
+	r := newTestRouter(h, "", func(c *gin.Context) {
+		c.Set(string(middleware.GuestIDKey), guestID)
+		h.RequireSearch()(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/guarded", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, guestID, service.guest)
+	assert.Contains(t, w.Body.String(), "Guests get 3 searches a day")
+	assert.Contains(t, w.Body.String(), `href="/auth/signup"`)
+	assert.Contains(t, w.Body.String(), "Create free account")
+	assert.NotContains(t, w.Body.String(), "handled")
+}
+
+func TestRequireSearch_NewGuestCountedPerAddress(t *testing.T) {
+	service := &stubService{usage: Usage{Plan: PlanGuest, Searches: 1, SearchLimit: 3}}
+	h := NewHandler(service, zap.NewNop())
+	identity := middleware.NewGuestIdentity("test-secret")
+	r := newTestRouter(h, "", identity.Issue(), h.RequireSearch())
+
+	req := httptest.NewRequest(http.MethodPost, "/guarded", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "203.0.113.7", service.address)
+	assert.Equal(t, uuid.Nil, service.guest, "an id issued by the request is not a quota identity")
+	assert.Equal(t, 1, service.charged)
+}
@@ -0,0 +1,149 @@
+package restaurants
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+func TestFilterRestaurants(t *testing.T) {
+	testPOIs := []models.POIDetailedInfo{
+		// Restaurants/Dining
+		{ID: uuid.New(), Name: "Fine Restaurant", Category: "restaurant", Rating: 4.8},
+		{ID: uuid.New(), Name: "Local Cafe", Category: "cafe", Rating: 4.5},
+		{ID: uuid.New(), Name: "Coffee Shop", Category: "coffee", Rating: 4.3},
+		{ID: uuid.New(), Name: "Wine Bar", Category: "bar", Rating: 4.6},
+		{ID: uuid.New(), Name: "Traditional Pub", Category: "pub", Rating: 4.4},
+		{ID: uuid.New(), Name: "French Bistro", Category: "bistro", Rating: 4.7},
+		{ID: uuid.New(), Name: "Elegant Brasserie", Category: "brasserie", Rating: 4.5},
+		{ID: uuid.New(), Name: "Italian Pizzeria", Category: "pizzeria", Rating: 4.2},
+		{ID: uuid.New(), Name: "Local Bakery", Category: "bakery", Rating: 4.1},
+		{ID: uuid.New(), Name: "Farmers Market", Category: "market", Rating: 4.0},
+		{ID: uuid.New(), Name: "Food Court", Category: "foodcourt", Rating: 3.8},
+		{ID: uuid.New(), Name: "Fast Food", Category: "fastfood", Rating: 3.5},
+		{ID: uuid.New(), Name: "Takeaway Place", Category: "takeaway", Rating: 3.7},
+		{ID: uuid.New(), Name: "Dining Hall", Category: "dining", Rating: 4.0},
+		{ID: uuid.New(), Name: "Food Truck", Category: "food", Rating: 3.9},
+
+		// Other domains and non-relevant categories
+		{ID: uuid.New(), Name: "Louvre Museum", Category: "museum", Rating: 4.8},
+		{ID: uuid.New(), Name: "Luxury Hotel", Category: "hotel", Rating: 4.9},
+		{ID: uuid.New(), Name: "Transport Station", Category: "transport", Rating: 4.0},
+		{ID: uuid.New(), Name: "Office Building", Category: "office", Rating: 3.5},
+	}
+
+	t.Run("includes only dining categories", func(t *testing.T) {
+		filtered := filterRestaurants(testPOIs)
+		assert.Len(t, filtered, 15)
+
+		restaurantCategories := map[string]bool{
+			"restaurant": true, "cafe": true, "coffee": true, "bar": true, "pub": true,
+			"bistro": true, "brasserie": true, "pizzeria": true, "bakery": true, "market": true,
+			"foodcourt": true, "fastfood": true, "takeaway": true, "dining": true, "food": true,
+		}
+		var names []string
+		for _, restaurant := range filtered {
+			assert.True(t, restaurantCategories[restaurant.Category],
+				"POI '%s' with category '%s' should not be in restaurants filter", restaurant.Name, restaurant.Category)
+			names = append(names, restaurant.Name)
+		}
+		assert.Contains(t, names, "Fine Restaurant")
+		assert.Contains(t, names, "Wine Bar")
+		assert.NotContains(t, names, "Louvre Museum")
+		assert.NotContains(t, names, "Luxury Hotel")
+		assert.NotContains(t, names, "Transport Station")
+	})
+
+	t.Run("is case insensitive", func(t *testing.T) {
+		filtered := filterRestaurants([]models.POIDetailedInfo{
+			{ID: uuid.New(), Name: "restaurant", Category: "Restaurant"},
+			{ID: uuid.New(), Name: "MUSEUM", Category: "MUSEUM"},
+		})
+		assert.Len(t, filtered, 1)
+	})
+
+	t.Run("handles empty input", func(t *testing.T) {
+		assert.Empty(t, filterRestaurants([]models.POIDetailedInfo{}))
+	})
+}
+
+func TestConvertPOIToRestaurant(t *testing.T) {
+	t.Run("converts all fields", func(t *testing.T) {
+		poi := models.POIDetailedInfo{
+			ID:          uuid.New(),
+			City:        "Rome",
+			Name:        "La Pergola",
+			Latitude:    41.9109,
+			Longitude:   12.4818,
+			Category:    "restaurant",
+			Description: "Three Michelin star restaurant",
+			Address:     "Via Alberto Cadlolo, 101, 00136 Roma RM",
+			PhoneNumber: "+39 06 3509 2152",
+			Website:     "https://www.lapergolaroma.com",
+			OpeningHours: map[string]string{
+				"Tuesday":   "19:30-23:30",
+				"Wednesday": "19:30-23:30",
+				"Thursday":  "19:30-23:30",
+			},
+			PriceLevel:       "$$$$",
+			CuisineType:      "Mediterranean",
+			Rating:           4.9,
+			Tags:             []string{"michelin", "fine-dining", "rooftop"},
+			Images:           []string{"dining-room.jpg", "dish1.jpg", "terrace.jpg"},
+			LlmInteractionID: uuid.New(),
+		}
+
+		restaurant := convertPOIToRestaurant(poi)
+
+		assert.Equal(t, poi.ID, restaurant.ID)
+		assert.Equal(t, poi.City, restaurant.City)
+		assert.Equal(t, poi.Name, restaurant.Name)
+		assert.Equal(t, poi.Latitude, restaurant.Latitude)
+		assert.Equal(t, poi.Longitude, restaurant.Longitude)
+		assert.Equal(t, poi.Category, restaurant.Category)
+		assert.Equal(t, poi.Description, restaurant.Description)
+		assert.Equal(t, poi.Rating, restaurant.Rating)
+		assert.Equal(t, poi.Tags, restaurant.Tags)
+		assert.Equal(t, poi.Images, restaurant.Images)
+		assert.Equal(t, poi.LlmInteractionID, restaurant.LlmInteractionID)
+
+		if assert.NotNil(t, restaurant.Address) {
+			assert.Equal(t, poi.Address, *restaurant.Address)
+		}
+		if assert.NotNil(t, restaurant.PhoneNumber) {
+			assert.Equal(t, poi.PhoneNumber, *restaurant.PhoneNumber)
+		}
+		if assert.NotNil(t, restaurant.Website) {
+			assert.Equal(t, poi.Website, *restaurant.Website)
+		}
+		if assert.NotNil(t, restaurant.PriceLevel) {
+			assert.Equal(t, poi.PriceLevel, *restaurant.PriceLevel)
+		}
+		if assert.NotNil(t, restaurant.CuisineType) {
+			assert.Equal(t, poi.CuisineType, *restaurant.CuisineType)
+		}
+		if assert.NotNil(t, restaurant.OpeningHours) {
+			assert.Contains(t, *restaurant.OpeningHours, "Tuesday: 19:30-23:30")
+			assert.Contains(t, *restaurant.OpeningHours, "Wednesday: 19:30-23:30")
+			assert.Contains(t, *restaurant.OpeningHours, "Thursday: 19:30-23:30")
+		}
+	})
+
+	t.Run("leaves empty optional fields nil", func(t *testing.T) {
+		restaurant := convertPOIToRestaurant(models.POIDetailedInfo{
+			ID:           uuid.New(),
+			Name:         "Basic POI",
+			OpeningHours: map[string]string{},
+		})
+
+		assert.Nil(t, restaurant.Address)
+		assert.Nil(t, restaurant.PhoneNumber)
+		assert.Nil(t, restaurant.Website)
+		assert.Nil(t, restaurant.PriceLevel)
+		assert.Nil(t, restaurant.CuisineType)
+		assert.Nil(t, restaurant.OpeningHours)
+	})
+}
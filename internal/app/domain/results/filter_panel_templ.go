@@ -47,7 +47,7 @@ func FilterPanel(domain string, cacheKey string, sessionID string) templ.Compone
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" hx-target=\"#results-list-container\" hx-swap=\"innerHTML\" hx-trigger=\"change delay:300ms\" hx-include=\"[name='categories[]'],[name='priceRange[]'],[name='rating'],[name='features[]']\" hx-vals=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "\" hx-target=\"#results-list-container\" hx-swap=\"innerHTML\" hx-trigger=\"change delay:300ms\" hx-include=\"[name='categories[]'],[name='priceRange[]'],[name='rating'],[name='features[]'],[name='openNow'],[name='openAt']\" hx-vals=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -522,12 +522,28 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, " ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = CheckboxFilter("openNow", "Open now", "1").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, " ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = OpenAtFilter().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
 		} else if domain == "hotels" {
 			templ_7745c5c3_Err = CheckboxFilter("features[]", "Pool", "pool").Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -535,7 +551,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -543,7 +559,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -551,7 +567,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -559,7 +575,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -572,7 +588,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -580,7 +596,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -588,7 +604,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -596,7 +612,7 @@ func FeatureFilters(domain string) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, " ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, " ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -609,8 +625,8 @@ func FeatureFilters(domain string) templ.Component {
 	})
 }
 
-// CheckboxFilter renders a single checkbox filter option
-func CheckboxFilter(name string, label string, value string) templ.Component {
+// OpenAtFilter picks a date and time, in the city's time, the places should be open at
+func OpenAtFilter() templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -631,59 +647,89 @@ func CheckboxFilter(name string, label string, value string) templ.Component {
 			templ_7745c5c3_Var9 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "<label class=\"flex items-center gap-2 text-sm text-gray-700 dark:text-gray-300 hover:bg-gray-50 dark:hover:bg-gray-700 p-2 rounded cursor-pointer\"><input type=\"checkbox\" name=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "<label class=\"flex flex-col gap-1 text-sm text-gray-700 dark:text-gray-300 p-2\">Open at <input type=\"datetime-local\" name=\"openAt\" class=\"w-full text-sm border-gray-300 dark:border-gray-600 rounded-md focus:border-blue-500 focus:ring-blue-500 dark:bg-gray-700 dark:text-white\"></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var10 string
-		templ_7745c5c3_Var10, templ_7745c5c3_Err = templ.JoinStringErrs(name)
-		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 171, Col: 14}
+		return nil
+	})
+}
+
+// CheckboxFilter renders a single checkbox filter option
+func CheckboxFilter(name string, label string, value string) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var10))
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var10 == nil {
+			templ_7745c5c3_Var10 = templ.NopComponent
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "\" value=\"")
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "<label class=\"flex items-center gap-2 text-sm text-gray-700 dark:text-gray-300 hover:bg-gray-50 dark:hover:bg-gray-700 p-2 rounded cursor-pointer\"><input type=\"checkbox\" name=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var11 string
-		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(value)
+		templ_7745c5c3_Var11, templ_7745c5c3_Err = templ.JoinStringErrs(name)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 172, Col: 16}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 185, Col: 14}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var11))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "\" class=\"filter-checkbox rounded border-gray-300 text-blue-600 focus:ring-blue-500 dark:border-gray-600 dark:bg-gray-700\" data-label=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "\" value=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var12 string
-		templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+		templ_7745c5c3_Var12, templ_7745c5c3_Err = templ.JoinStringErrs(value)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 174, Col: 21}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 186, Col: 16}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var12))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, "\"> <span>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "\" class=\"filter-checkbox rounded border-gray-300 text-blue-600 focus:ring-blue-500 dark:border-gray-600 dark:bg-gray-700\" data-label=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var13 string
 		templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(label)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 176, Col: 15}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 188, Col: 21}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, "</span></label>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "\"> <span>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var14 string
+		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(label)
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/app/domain/results/filter_panel.templ`, Line: 190, Col: 15}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "</span></label>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -708,12 +754,12 @@ func FilterBadgesScript() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var14 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var14 == nil {
-			templ_7745c5c3_Var14 = templ.NopComponent
+		templ_7745c5c3_Var15 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var15 == nil {
+			templ_7745c5c3_Var15 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "<script type=\"text/javascript\">\n\tfunction updateFilterBadges() {\n\t\tconst container = document.getElementById('filter-badges-container');\n\t\tif (!container) return;\n\n\t\tconst badges = [];\n\n\t\tconst checkboxes = document.querySelectorAll('.filter-checkbox:checked');\n\t\tcheckboxes.forEach(checkbox => {\n\t\t\tconst label = checkbox.getAttribute('data-label');\n\t\t\tconst name = checkbox.name;\n\t\t\tconst value = checkbox.value;\n\n\t\t\tbadges.push({\n\t\t\t\tlabel: label,\n\t\t\t\tname: name,\n\t\t\t\tvalue: value,\n\t\t\t\telement: checkbox\n\t\t\t});\n\t\t});\n\n\t\tconst ratingSelect = document.querySelector('select[name=\"rating\"]');\n\t\tif (ratingSelect && ratingSelect.value) {\n\t\t\tbadges.push({\n\t\t\t\tlabel: 'Rating: ' + ratingSelect.value + '+ stars',\n\t\t\t\tname: 'rating',\n\t\t\t\tvalue: ratingSelect.value,\n\t\t\t\telement: ratingSelect\n\t\t\t});\n\t\t}\n\n\t\tif (badges.length === 0) {\n\t\t\tcontainer.innerHTML = '';\n\t\t\tcontainer.classList.add('hidden');\n\t\t} else {\n\t\t\tcontainer.classList.remove('hidden');\n\t\t\tconst badgeHTML = badges.map(badge => {\n\t\t\t\treturn '<button type=\"button\" class=\"inline-flex items-center gap-1.5 px-3 py-1 text-sm bg-blue-100 dark:bg-blue-900/30 text-blue-800 dark:text-blue-300 rounded-full hover:bg-blue-200 dark:hover:bg-blue-900/50 transition-colors\" onclick=\"removeFilter(\\'' + badge.name + '\\', \\'' + badge.value + '\\')\"><span>' + badge.label + '</span><svg class=\"w-3 h-3\" fill=\"none\" stroke=\"currentColor\" viewBox=\"0 0 24 24\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M6 18L18 6M6 6l12 12\"></path></svg></button>';\n\t\t\t}).join('');\n\t\t\tcontainer.innerHTML = '<div class=\"flex items-center gap-2 flex-wrap\"><span class=\"text-sm font-medium text-gray-700 dark:text-gray-300\">Active Filters:</span>' + badgeHTML + '</div>';\n\t\t}\n\t}\n\n\twindow.removeFilter = function(name, value) {\n\t\tif (name === 'rating') {\n\t\t\tconst select = document.querySelector('select[name=\"rating\"]');\n\t\t\tif (select) {\n\t\t\t\tselect.value = '';\n\t\t\t\tselect.dispatchEvent(new Event('change'));\n\t\t\t}\n\t\t} else {\n\t\t\tconst checkbox = document.querySelector('.filter-checkbox[name=\"' + name + '\"][value=\"' + value + '\"]');\n\t\t\tif (checkbox) {\n\t\t\t\tcheckbox.checked = false;\n\t\t\t\tcheckbox.dispatchEvent(new Event('change'));\n\t\t\t}\n\t\t}\n\t\tupdateFilterBadges();\n\t};\n\n\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\tdocument.querySelectorAll('.filter-checkbox, select[name=\"rating\"]').forEach(function(input) {\n\t\t\tinput.addEventListener('change', updateFilterBadges);\n\t\t});\n\n\t\tupdateFilterBadges();\n\t});\n\n\tdocument.body.addEventListener('htmx:afterSwap', updateFilterBadges);\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "<script type=\"text/javascript\">\n\tfunction updateFilterBadges() {\n\t\tconst container = document.getElementById('filter-badges-container');\n\t\tif (!container) return;\n\n\t\tconst badges = [];\n\n\t\tconst checkboxes = document.querySelectorAll('.filter-checkbox:checked');\n\t\tcheckboxes.forEach(checkbox => {\n\t\t\tconst label = checkbox.getAttribute('data-label');\n\t\t\tconst name = checkbox.name;\n\t\t\tconst value = checkbox.value;\n\n\t\t\tbadges.push({\n\t\t\t\tlabel: label,\n\t\t\t\tname: name,\n\t\t\t\tvalue: value,\n\t\t\t\telement: checkbox\n\t\t\t});\n\t\t});\n\n\t\tconst ratingSelect = document.querySelector('select[name=\"rating\"]');\n\t\tif (ratingSelect && ratingSelect.value) {\n\t\t\tbadges.push({\n\t\t\t\tlabel: 'Rating: ' + ratingSelect.value + '+ stars',\n\t\t\t\tname: 'rating',\n\t\t\t\tvalue: ratingSelect.value,\n\t\t\t\telement: ratingSelect\n\t\t\t});\n\t\t}\n\n\t\tif (badges.length === 0) {\n\t\t\tcontainer.innerHTML = '';\n\t\t\tcontainer.classList.add('hidden');\n\t\t} else {\n\t\t\tcontainer.classList.remove('hidden');\n\t\t\tconst badgeHTML = badges.map(badge => {\n\t\t\t\treturn '<button type=\"button\" class=\"inline-flex items-center gap-1.5 px-3 py-1 text-sm bg-blue-100 dark:bg-blue-900/30 text-blue-800 dark:text-blue-300 rounded-full hover:bg-blue-200 dark:hover:bg-blue-900/50 transition-colors\" onclick=\"removeFilter(\\'' + badge.name + '\\', \\'' + badge.value + '\\')\"><span>' + badge.label + '</span><svg class=\"w-3 h-3\" fill=\"none\" stroke=\"currentColor\" viewBox=\"0 0 24 24\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M6 18L18 6M6 6l12 12\"></path></svg></button>';\n\t\t\t}).join('');\n\t\t\tcontainer.innerHTML = '<div class=\"flex items-center gap-2 flex-wrap\"><span class=\"text-sm font-medium text-gray-700 dark:text-gray-300\">Active Filters:</span>' + badgeHTML + '</div>';\n\t\t}\n\t}\n\n\twindow.removeFilter = function(name, value) {\n\t\tif (name === 'rating') {\n\t\t\tconst select = document.querySelector('select[name=\"rating\"]');\n\t\t\tif (select) {\n\t\t\t\tselect.value = '';\n\t\t\t\tselect.dispatchEvent(new Event('change'));\n\t\t\t}\n\t\t} else {\n\t\t\tconst checkbox = document.querySelector('.filter-checkbox[name=\"' + name + '\"][value=\"' + value + '\"]');\n\t\t\tif (checkbox) {\n\t\t\t\tcheckbox.checked = false;\n\t\t\t\tcheckbox.dispatchEvent(new Event('change'));\n\t\t\t}\n\t\t}\n\t\tupdateFilterBadges();\n\t};\n\n\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\tdocument.querySelectorAll('.filter-checkbox, select[name=\"rating\"]').forEach(function(input) {\n\t\t\tinput.addEventListener('change', updateFilterBadges);\n\t\t});\n\n\t\tupdateFilterBadges();\n\t});\n\n\tdocument.body.addEventListener('htmx:afterSwap', updateFilterBadges);\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,130 @@
+package schedule
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Handler serves the schedules of the user's itinerary lists.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Build handles POST /lists/:id/schedule and (re)schedules every item of the list.
+func (h *Handler) Build(c *gin.Context) {
+	userID, listID, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	var req models.BuildScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	schedule, err := h.service.Build(c.Request.Context(), userID, listID, req)
+	if err != nil {
+		h.respondError(c, "Failed to build schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// Get handles GET /lists/:id/schedule.
+func (h *Handler) Get(c *gin.Context) {
+	userID, listID, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	schedule, err := h.service.Get(c.Request.Context(), userID, listID)
+	if err != nil {
+		h.respondError(c, "Failed to get schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// Move handles PATCH /lists/:id/schedule/items/:itemId. The item is pinned to the day and
+// time given, or unpinned with {"pinned": false}, and the other items are re-planned.
+func (h *Handler) Move(c *gin.Context) {
+	userID, listID, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+	var req models.MoveScheduleItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	schedule, err := h.service.Move(c.Request.Context(), userID, listID, itemID, req)
+	if err != nil {
+		h.respondError(c, "Failed to move schedule item", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// Route handles GET /lists/:id/route with the travel legs between the items of a list, per
+// scheduled day.
+func (h *Handler) Route(c *gin.Context) {
+	userID, listID, ok := h.ids(c)
+	if !ok {
+		return
+	}
+	route, err := h.service.Route(c.Request.Context(), userID, listID)
+	if err != nil {
+		h.respondError(c, "Failed to route list", err)
+		return
+	}
+	c.JSON(http.StatusOK, route)
+}
+
+func (h *Handler) ids(c *gin.Context) (userID, listID uuid.UUID, ok bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	listID, err = uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid list id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, listID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "list, schedule or item not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
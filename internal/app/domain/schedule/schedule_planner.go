@@ -0,0 +1,480 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
+)
+
+// Item is a list item to place in a schedule.
+type Item struct {
+	ID          uuid.UUID
+	ContentType models.ContentType
+	Name        string
+	Category    string
+	TimeToSpend string // as suggested by the model, e.g. "1-2 hours"
+	Duration    int    // minutes set on the list item, used as is when set
+	Hours       openinghours.Hours
+	Position    int
+	Pinned      bool
+	Day         int            // 1-based day the item is placed on, 0 when it is not
+	Prefer      int            // 1-based day Plan puts the item on when it has room, 0 to spread items over the days
+	Start       time.Time      // when the item is placed on its day
+	Point       *routing.Point // where the item is, nil when unknown
+}
+
+// Options describe the days to schedule and the traveller.
+type Options struct {
+	Days      int
+	StartDate time.Time // midnight of the first day
+	Pace      models.SearchPace
+	Time      models.DayPreference
+	Mode      routing.Mode // how the traveller gets from one place to the next
+}
+
+// shape is what a day looks like for a pace and time of day preference.
+type shape struct {
+	start, end int // minutes since midnight
+	maxVisits  int
+	gap        int     // minutes between two slots, to get from one place to the next
+	factor     float64 // applied to the suggested time at each place
+}
+
+func shapeFor(opts Options) shape {
+	s := shape{start: 9 * 60, end: 21 * 60, maxVisits: 5, gap: 20, factor: 1}
+	switch opts.Time {
+	case models.DayPreferenceDay:
+		s.start, s.end = 8*60+30, 18*60+30
+	case models.DayPreferenceNight:
+		s.start, s.end = 12*60, 23*60+30
+	}
+	switch opts.Pace {
+	case models.SearchPaceRelaxed:
+		s.start += 60
+		s.maxVisits, s.gap, s.factor = 3, 30, 1.25
+	case models.SearchPaceFast:
+		s.start -= 30
+		s.maxVisits, s.gap, s.factor = 7, 10, 0.85
+	}
+	return s
+}
+
+// meal is a window meals start in, in minutes since midnight.
+type meal struct {
+	name         string
+	from, latest int
+}
+
+var meals = []meal{
+	{name: "lunch", from: 12 * 60, latest: 14 * 60},
+	{name: "dinner", from: 19 * 60, latest: 21*60 + 30},
+}
+
+// mealMinutes is how long a meal takes when nothing says otherwise
+const mealMinutes = 75
+
+// Plan places items over the days of opts. Pinned items stay where they are, restaurants go
+// to the lunch and dinner windows and the other items fill the days in list order, each at a
+// time it is open when its hours are known. When an item fits nowhere it is placed anyway and
+// the conflict is reported. Items with a preferred day go to that day first.
+func Plan(items []Item, opts Options) ([]models.ScheduleDay, []models.ScheduleConflict) {
+	p := newPlanner(opts)
+	var visits, restaurants []Item
+	for _, it := range sortedByPosition(items) {
+		switch {
+		case it.Pinned && it.Day >= 1 && it.Day <= len(p.days):
+			d := p.days[it.Day-1]
+			p.place(d, it, minutesInto(d.date, it.Start), true)
+		case isMeal(it):
+			restaurants = append(restaurants, it)
+		default:
+			visits = append(visits, it)
+		}
+	}
+
+	for i, it := range restaurants {
+		if !p.placeMeal(it, p.target(it, i, len(restaurants))) {
+			visits = append(visits, it)
+		}
+	}
+	for i, it := range visits {
+		p.placeVisit(it, p.target(it, i, len(visits)))
+	}
+	return p.result(items)
+}
+
+// Arrange builds the days of a schedule already made from the days and times of its items,
+// and checks them for conflicts. Items without a day are left out.
+func Arrange(items []Item, opts Options) ([]models.ScheduleDay, []models.ScheduleConflict) {
+	p := newPlanner(opts)
+	for _, it := range sortedByPosition(items) {
+		if it.Day < 1 || it.Day > len(p.days) {
+			continue
+		}
+		d := p.days[it.Day-1]
+		p.place(d, it, minutesInto(d.date, it.Start), it.Pinned)
+	}
+	return p.result(items)
+}
+
+// target is the index of the day to place the i-th of n items on: its preferred day, or
+// the days in turn.
+func (p *planner) target(it Item, i, n int) int {
+	if it.Prefer >= 1 && it.Prefer <= len(p.days) {
+		return it.Prefer - 1
+	}
+	return i * len(p.days) / n
+}
+
+type day struct {
+	number int
+	date   time.Time
+	slots  []models.ScheduleSlot
+	meals  map[string]bool
+}
+
+type planner struct {
+	shape shape
+	days  []*day
+}
+
+func newPlanner(opts Options) *planner {
+	p := &planner{shape: shapeFor(opts)}
+	for i := 0; i < opts.Days; i++ {
+		p.days = append(p.days, &day{number: i + 1, date: opts.StartDate.AddDate(0, 0, i), meals: map[string]bool{}})
+	}
+	return p
+}
+
+func (p *planner) place(d *day, it Item, at int, pinned bool) {
+	length := p.duration(it)
+	slot := models.ScheduleSlot{
+		ItemID:          it.ID,
+		ContentType:     it.ContentType,
+		Name:            it.Name,
+		Category:        it.Category,
+		Day:             d.number,
+		Start:           d.date.Add(time.Duration(at) * time.Minute),
+		End:             d.date.Add(time.Duration(at+length) * time.Minute),
+		DurationMinutes: length,
+		Kind:            models.SlotKindVisit,
+		Pinned:          pinned,
+		Setting:         weather.SettingOf(it.Category, nil),
+	}
+	if isMeal(it) {
+		if m, ok := mealAt(at); ok {
+			slot.Kind, slot.Meal = models.SlotKindMeal, m.name
+			d.meals[m.name] = true
+		}
+	}
+	d.slots = append(d.slots, slot)
+}
+
+// placeMeal puts a restaurant in a free meal window, trying the days nearest target first.
+func (p *planner) placeMeal(it Item, target int) bool {
+	length := p.duration(it)
+	for _, checkHours := range []bool{true, false} {
+		for _, di := range dayOrder(target, len(p.days)) {
+			d := p.days[di]
+			for _, m := range meals {
+				if d.meals[m.name] {
+					continue
+				}
+				if at, ok := p.fit(d, length, m.from, m.latest, it.Hours, checkHours, false); ok {
+					p.place(d, it, at, false)
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// placeVisit puts an item on the day nearest target with room for it. With no room anywhere
+// it goes after the last slot of the least booked day.
+func (p *planner) placeVisit(it Item, target int) {
+	length := p.duration(it)
+	for _, checkHours := range []bool{true, false} {
+		for _, di := range dayOrder(target, len(p.days)) {
+			d := p.days[di]
+			if visitsOn(d) >= p.shape.maxVisits {
+				continue
+			}
+			if at, ok := p.fit(d, length, p.shape.start, p.shape.end-length, it.Hours, checkHours, true); ok {
+				p.place(d, it, at, false)
+				return
+			}
+		}
+	}
+
+	least := p.days[0]
+	for _, d := range p.days[1:] {
+		if booked(d) < booked(least) {
+			least = d
+		}
+	}
+	at := p.shape.start
+	for _, s := range least.slots {
+		at = max(at, minutesInto(least.date, s.End)+p.shape.gap)
+	}
+	p.place(least, it, at, false)
+}
+
+// fit finds the earliest start between from and latest where an item of length minutes
+// overlaps no slot of d, keeping the gap between slots, and, when checkHours is set, where
+// the place is open throughout.
+func (p *planner) fit(d *day, length, from, latest int, hours openinghours.Hours, checkHours, withinDay bool) (int, bool) {
+	candidates := []int{from}
+	for _, s := range d.slots {
+		candidates = append(candidates, minutesInto(d.date, s.End)+p.shape.gap)
+	}
+	for _, w := range hours.Windows(d.date.Weekday()) {
+		candidates = append(candidates, w.Open)
+	}
+	sort.Ints(candidates)
+
+	for _, at := range candidates {
+		if at < from || at > latest {
+			continue
+		}
+		if withinDay && at+length > p.shape.end {
+			continue
+		}
+		if p.overlaps(d, at, at+length) {
+			continue
+		}
+		if checkHours {
+			start := d.date.Add(time.Duration(at) * time.Minute)
+			if open, _ := hours.OpenBetween(start, start.Add(time.Duration(length)*time.Minute)); !open {
+				continue
+			}
+		}
+		return at, true
+	}
+	return 0, false
+}
+
+func (p *planner) overlaps(d *day, from, to int) bool {
+	for _, s := range d.slots {
+		start, end := minutesInto(d.date, s.Start), minutesInto(d.date, s.End)
+		if from < end+p.shape.gap && to+p.shape.gap > start {
+			return true
+		}
+	}
+	return false
+}
+
+// result sorts the slots of every day and checks the schedule.
+func (p *planner) result(items []Item) ([]models.ScheduleDay, []models.ScheduleConflict) {
+	hours := make(map[uuid.UUID]openinghours.Hours, len(items))
+	for _, it := range items {
+		hours[it.ID] = it.Hours
+	}
+
+	days := make([]models.ScheduleDay, len(p.days))
+	var conflicts []models.ScheduleConflict
+	for i, d := range p.days {
+		sort.SliceStable(d.slots, func(a, b int) bool { return d.slots[a].Start.Before(d.slots[b].Start) })
+		days[i] = models.ScheduleDay{Day: d.number, Date: d.date, Slots: d.slots, BookedMinutes: booked(d)}
+		if days[i].Slots == nil {
+			days[i].Slots = []models.ScheduleSlot{}
+		}
+		conflicts = append(conflicts, p.check(d, hours)...)
+	}
+	return days, conflicts
+}
+
+// check reports the slots of d at a time their place is closed, the slots that overlap and
+// a day holding more than the pace allows.
+func (p *planner) check(d *day, hours map[uuid.UUID]openinghours.Hours) []models.ScheduleConflict {
+	var conflicts []models.ScheduleConflict
+	for i, s := range d.slots {
+		id := s.ItemID
+		if open, known := hours[id].OpenBetween(s.Start, s.End); known && !open {
+			conflicts = append(conflicts, models.ScheduleConflict{
+				Type:    models.ConflictClosed,
+				Day:     d.number,
+				ItemID:  &id,
+				Message: fmt.Sprintf("%s is closed at %s on day %d", s.Name, s.Start.Format("15:04"), d.number),
+			})
+		}
+		if i > 0 && s.Start.Before(d.slots[i-1].End) {
+			conflicts = append(conflicts, models.ScheduleConflict{
+				Type:    models.ConflictOverlap,
+				Day:     d.number,
+				ItemID:  &id,
+				Message: fmt.Sprintf("%s starts before %s ends", s.Name, d.slots[i-1].Name),
+			})
+		}
+	}
+
+	visits := visitsOn(d)
+	lateEnd := false
+	for _, s := range d.slots {
+		if s.Kind == models.SlotKindVisit && minutesInto(d.date, s.End) > p.shape.end {
+			lateEnd = true
+		}
+	}
+	switch {
+	case visits > p.shape.maxVisits:
+		conflicts = append(conflicts, models.ScheduleConflict{
+			Type:    models.ConflictOverbooked,
+			Day:     d.number,
+			Message: fmt.Sprintf("day %d has %d visits, more than the %d the pace allows", d.number, visits, p.shape.maxVisits),
+		})
+	case lateEnd:
+		conflicts = append(conflicts, models.ScheduleConflict{
+			Type:    models.ConflictOverbooked,
+			Day:     d.number,
+			Message: fmt.Sprintf("day %d runs past %s", d.number, clock(p.shape.end)),
+		})
+	}
+	return conflicts
+}
+
+func visitsOn(d *day) int {
+	n := 0
+	for _, s := range d.slots {
+		if s.Kind == models.SlotKindVisit {
+			n++
+		}
+	}
+	return n
+}
+
+func booked(d *day) int {
+	total := 0
+	for _, s := range d.slots {
+		total += s.DurationMinutes
+	}
+	return total
+}
+
+// dayOrder lists the day indexes from target outwards: target, target+1, target-1, ...
+func dayOrder(target, days int) []int {
+	order := make([]int, 0, days)
+	for offset := 0; len(order) < days; offset++ {
+		if i := target + offset; i < days {
+			order = append(order, i)
+		}
+		if i := target - offset; offset > 0 && i >= 0 {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+func sortedByPosition(items []Item) []Item {
+	sorted := append([]Item(nil), items...)
+	sort.SliceStable(sorted, func(a, b int) bool { return sorted[a].Position < sorted[b].Position })
+	return sorted
+}
+
+func minutesInto(date, t time.Time) int {
+	return int(math.Round(t.Sub(date).Minutes()))
+}
+
+func mealAt(minutes int) (meal, bool) {
+	for _, m := range meals {
+		if minutes >= m.from && minutes <= m.latest {
+			return m, true
+		}
+	}
+	return meal{}, false
+}
+
+func clock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+}
+
+var mealWords = []string{"restaurant", "cafe", "café", "bistro", "brasserie", "tavern", "eatery", "food", "dining", "tasca"}
+
+// isMeal tells restaurants, which are scheduled at meal times, from the other places.
+func isMeal(it Item) bool {
+	if it.ContentType == models.ContentTypeRestaurant {
+		return true
+	}
+	category := strings.ToLower(it.Category)
+	for _, w := range mealWords {
+		if strings.Contains(category, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// duration is how long an item is scheduled for, rounded to a quarter of an hour.
+func (p *planner) duration(it Item) int {
+	if it.Duration > 0 {
+		return it.Duration
+	}
+	minutes := parseTimeToSpend(it.TimeToSpend)
+	if minutes == 0 {
+		minutes = defaultDuration(it)
+	}
+	if !isMeal(it) {
+		minutes = int(float64(minutes) * p.shape.factor)
+	}
+	return max(15, (minutes+7)/15*15)
+}
+
+func defaultDuration(it Item) int {
+	if isMeal(it) {
+		return mealMinutes
+	}
+	category := strings.ToLower(it.Category)
+	switch {
+	case strings.Contains(category, "museum"), strings.Contains(category, "gallery"):
+		return 120
+	case strings.Contains(category, "park"), strings.Contains(category, "garden"),
+		strings.Contains(category, "beach"), strings.Contains(category, "zoo"):
+		return 90
+	case strings.Contains(category, "church"), strings.Contains(category, "cathedral"),
+		strings.Contains(category, "monument"), strings.Contains(category, "viewpoint"),
+		strings.Contains(category, "square"):
+		return 45
+	}
+	return 60
+}
+
+var spendPart = regexp.MustCompile(`(\d+(?:[.,]\d+)?)(?:\s*(?:-|–|to)\s*(\d+(?:[.,]\d+)?))?\s*(hours?|hrs?|h|minutes?|mins?|m)\b`)
+
+// parseTimeToSpend reads suggestions such as "1-2 hours", "45 min", "1 hour 30 minutes" or
+// "half a day" into minutes. Ranges count as their middle. It returns 0 when it cannot tell.
+func parseTimeToSpend(s string) int {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "half") && strings.Contains(s, "day"):
+		return 240
+	case strings.Contains(s, "full day"), strings.Contains(s, "whole day"), strings.Contains(s, "all day"):
+		return 420
+	}
+	total := 0.0
+	for _, m := range spendPart.FindAllStringSubmatch(s, -1) {
+		value := number(m[1])
+		if m[2] != "" {
+			value = (value + number(m[2])) / 2
+		}
+		if strings.HasPrefix(m[3], "h") {
+			value *= 60
+		}
+		total += value
+	}
+	return int(math.Round(total))
+}
+
+func number(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return f
+}
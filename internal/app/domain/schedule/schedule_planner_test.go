@@ -0,0 +1,162 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
+)
+
+// monday is the first day of the schedules under test
+var monday = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func item(position int, name, category, timeToSpend, hours string) Item {
+	return Item{
+		ID:          uuid.New(),
+		ContentType: models.ContentTypePOI,
+		Name:        name,
+		Category:    category,
+		TimeToSpend: timeToSpend,
+		Hours:       openinghours.ParseString(hours),
+		Position:    position,
+	}
+}
+
+func slotOf(t *testing.T, days []models.ScheduleDay, id uuid.UUID) models.ScheduleSlot {
+	t.Helper()
+	for _, d := range days {
+		for _, s := range d.Slots {
+			if s.ItemID == id {
+				return s
+			}
+		}
+	}
+	t.Fatalf("item %s not scheduled", id)
+	return models.ScheduleSlot{}
+}
+
+func TestParseTimeToSpend(t *testing.T) {
+	tests := map[string]int{
+		"1-2 hours":         90,
+		"2 hours":           120,
+		"45 min":            45,
+		"1 hour 30 minutes": 90,
+		"1.5h":              90,
+		"half a day":        240,
+		"a while":           0,
+		"":                  0,
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, parseTimeToSpend(in), in)
+	}
+}
+
+func TestPlan_RestaurantsAtMealTimes(t *testing.T) {
+	museum := item(0, "Gulbenkian Museum", "museum", "2 hours", "")
+	tasca := item(1, "Tasca do Chico", "Restaurant", "", "")
+	cafe := item(2, "A Brasileira", "cafe", "1 hour", "")
+
+	days, conflicts := Plan([]Item{museum, tasca, cafe}, Options{Days: 1, StartDate: monday})
+	require.Len(t, days, 1)
+	assert.Empty(t, conflicts)
+
+	lunch := slotOf(t, days, tasca.ID)
+	assert.Equal(t, models.SlotKindMeal, lunch.Kind)
+	assert.Equal(t, "lunch", lunch.Meal)
+	assert.Equal(t, 12, lunch.Start.Hour())
+
+	dinner := slotOf(t, days, cafe.ID)
+	assert.Equal(t, "dinner", dinner.Meal)
+	assert.Equal(t, 19, dinner.Start.Hour())
+
+	visit := slotOf(t, days, museum.ID)
+	assert.Equal(t, models.SlotKindVisit, visit.Kind)
+	assert.Equal(t, 120, visit.DurationMinutes)
+	assert.Equal(t, 9, visit.Start.Hour())
+}
+
+func TestPlan_OpeningHours(t *testing.T) {
+	// Closed on Mondays, so it goes to the Tuesday even though it comes first in the list
+	museum := item(0, "Azulejo Museum", "museum", "2 hours", "Tue-Sun 10:00-18:00")
+	late := item(1, "Fado House", "music venue", "1 hour", "Daily 16:00-23:00")
+
+	days, conflicts := Plan([]Item{museum, late}, Options{Days: 2, StartDate: monday})
+	assert.Empty(t, conflicts)
+
+	m := slotOf(t, days, museum.ID)
+	assert.Equal(t, 2, m.Day)
+	assert.Equal(t, 10, m.Start.Hour())
+
+	f := slotOf(t, days, late.ID)
+	assert.GreaterOrEqual(t, f.Start.Hour(), 16)
+}
+
+func TestPlan_ClosedEverywhereIsAConflict(t *testing.T) {
+	closed := item(0, "Palace", "palace", "1 hour", "Sun 10:00-12:00")
+
+	days, conflicts := Plan([]Item{closed}, Options{Days: 1, StartDate: monday})
+	slotOf(t, days, closed.ID)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, models.ConflictClosed, conflicts[0].Type)
+	assert.Equal(t, closed.ID, *conflicts[0].ItemID)
+}
+
+func TestPlan_PaceLimitsVisitsPerDay(t *testing.T) {
+	var items []Item
+	for i := 0; i < 6; i++ {
+		items = append(items, item(i, "Sight", "monument", "30 minutes", ""))
+	}
+
+	relaxed, conflicts := Plan(items, Options{Days: 2, StartDate: monday, Pace: models.SearchPaceRelaxed})
+	assert.Empty(t, conflicts)
+	assert.Len(t, relaxed[0].Slots, 3)
+	assert.Len(t, relaxed[1].Slots, 3)
+
+	// One day cannot hold them all at a relaxed pace
+	overbooked, conflicts := Plan(items, Options{Days: 1, StartDate: monday, Pace: models.SearchPaceRelaxed})
+	assert.Len(t, overbooked[0].Slots, 6)
+	require.NotEmpty(t, conflicts)
+	assert.Equal(t, models.ConflictOverbooked, conflicts[0].Type)
+	assert.Nil(t, conflicts[0].ItemID)
+}
+
+func TestPlan_PinnedItemsStayAndOthersMoveAround(t *testing.T) {
+	first := item(0, "Castle", "castle", "2 hours", "")
+	pinned := item(1, "Tram 28", "tour", "1 hour", "")
+	pinned.Pinned, pinned.Day = true, 1
+	pinned.Start = monday.Add(9 * time.Hour)
+
+	days, conflicts := Plan([]Item{first, pinned}, Options{Days: 1, StartDate: monday})
+	assert.Empty(t, conflicts)
+
+	p := slotOf(t, days, pinned.ID)
+	assert.True(t, p.Pinned)
+	assert.Equal(t, monday.Add(9*time.Hour), p.Start)
+
+	c := slotOf(t, days, first.ID)
+	assert.False(t, c.Start.Before(p.End), "castle must start after the pinned tram")
+	assert.Equal(t, first.ID, days[0].Slots[1].ItemID)
+}
+
+func TestArrange_ReportsPinnedOverlapAndClosed(t *testing.T) {
+	a := item(0, "Market", "market", "2 hours", "Mon-Sat 06:00-14:00")
+	b := item(1, "Gallery", "gallery", "1 hour", "Tue-Sun 10:00-18:00")
+	a.Day, a.Start, a.Duration = 1, monday.Add(10*time.Hour), 120
+	b.Day, b.Start, b.Duration = 1, monday.Add(11*time.Hour), 60
+
+	days, conflicts := Arrange([]Item{a, b}, Options{Days: 1, StartDate: monday})
+	require.Len(t, days[0].Slots, 2)
+	assert.Equal(t, 180, days[0].BookedMinutes)
+
+	types := map[string]uuid.UUID{}
+	for _, c := range conflicts {
+		types[c.Type] = *c.ItemID
+	}
+	assert.Equal(t, b.ID, types[models.ConflictClosed])
+	assert.Equal(t, b.ID, types[models.ConflictOverlap])
+}
@@ -0,0 +1,177 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/openinghours"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+// ListInfo is what the scheduler needs to know about a list.
+type ListInfo struct {
+	UserID      uuid.UUID
+	IsItinerary bool
+	StartDate   *time.Time // nil while the list has no schedule
+	Days        int
+}
+
+type Repository interface {
+	List(ctx context.Context, listID uuid.UUID) (ListInfo, error)
+	// Items returns the POIs and restaurants of a list with where they are scheduled.
+	Items(ctx context.Context, listID uuid.UUID) ([]Item, error)
+	// SaveSchedule stores the days a list is scheduled over and the slot of each item. Items
+	// without a slot are unscheduled.
+	SaveSchedule(ctx context.Context, listID uuid.UUID, startDate time.Time, days int, slots []models.ScheduleSlot) error
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) List(ctx context.Context, listID uuid.UUID) (ListInfo, error) {
+	var info ListInfo
+	var days *int
+	err := r.pgpool.QueryRow(ctx, `
+		SELECT user_id, is_itinerary, schedule_start_date, schedule_days
+		FROM lists WHERE id = $1`, listID).Scan(&info.UserID, &info.IsItinerary, &info.StartDate, &days)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ListInfo{}, fmt.Errorf("list %s: %w", listID, models.ErrNotFound)
+	}
+	if err != nil {
+		return ListInfo{}, fmt.Errorf("failed to query list: %w", err)
+	}
+	if days != nil {
+		info.Days = *days
+	}
+	return info, nil
+}
+
+func (r *RepositoryImpl) Items(ctx context.Context, listID uuid.UUID) ([]Item, error) {
+	ctx, span := otel.Tracer("ScheduleRepository").Start(ctx, "Items", trace.WithAttributes(
+		attribute.String("list.id", listID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT li.item_id, li.content_type, li.position, li.day_number, li.time_slot, li.duration, li.pinned,
+		       COALESCE(p.name, rd.name, ''), COALESCE(p.category, rd.category, ''),
+		       COALESCE(p.time_to_spend, ''), COALESCE(p.opening_hours, rd.opening_hours),
+		       ST_Y(COALESCE(p.location, rd.location)::geometry), ST_X(COALESCE(p.location, rd.location)::geometry)
+		FROM list_items li
+		LEFT JOIN points_of_interest p ON p.id = li.item_id
+		LEFT JOIN restaurant_details rd ON li.content_type = 'restaurant' AND rd.id = li.item_id
+		WHERE li.list_id = $1 AND li.content_type IN ('poi', 'restaurant')
+		ORDER BY li.position`
+	rows, err := r.pgpool.Query(ctx, query, listID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query list items")
+		return nil, fmt.Errorf("failed to query list items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var it Item
+		var day, duration *int
+		var slot *time.Time
+		var hours []byte
+		var lat, lon *float64
+		if err := rows.Scan(&it.ID, &it.ContentType, &it.Position, &day, &slot, &duration, &it.Pinned,
+			&it.Name, &it.Category, &it.TimeToSpend, &hours, &lat, &lon); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list item: %w", err)
+		}
+		if day != nil && slot != nil {
+			it.Day, it.Start = *day, slot.UTC()
+		}
+		if duration != nil {
+			it.Duration = *duration
+		}
+		it.Hours = openinghours.Decode(hours)
+		if lat != nil && lon != nil {
+			it.Point = &routing.Point{Latitude: *lat, Longitude: *lon}
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate list items: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "List items loaded")
+	return items, nil
+}
+
+func (r *RepositoryImpl) SaveSchedule(ctx context.Context, listID uuid.UUID, startDate time.Time, days int, slots []models.ScheduleSlot) error {
+	ctx, span := otel.Tracer("ScheduleRepository").Start(ctx, "SaveSchedule", trace.WithAttributes(
+		attribute.String("list.id", listID.String()),
+		attribute.Int("schedule.days", days),
+		attribute.Int("schedule.slots", len(slots)),
+	))
+	defer span.End()
+
+	tx, err := r.pgpool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.logger.Error("transaction rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE lists SET schedule_start_date = $2, schedule_days = $3, updated_at = NOW()
+		WHERE id = $1`, listID, startDate, days); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to update list schedule: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE list_items SET day_number = NULL, time_slot = NULL, pinned = FALSE, updated_at = NOW()
+		WHERE list_id = $1 AND content_type IN ('poi', 'restaurant')`, listID); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to clear list schedule: %w", err)
+	}
+	for _, s := range slots {
+		if _, err := tx.Exec(ctx, `
+			UPDATE list_items SET day_number = $4, time_slot = $5, duration = $6, pinned = $7, updated_at = NOW()
+			WHERE list_id = $1 AND item_id = $2 AND content_type = $3`,
+			listID, s.ItemID, s.ContentType, s.Day, s.Start, s.DurationMinutes, s.Pinned); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to save schedule slot")
+			return fmt.Errorf("failed to save slot of %s: %w", s.Name, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to commit schedule: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Schedule saved")
+	return nil
+}
@@ -0,0 +1,390 @@
+// Package schedule splits the items of an itinerary list into days and timed slots. Times
+// follow what the models suggest spending at each place, its opening hours, the pace and time
+// of day of the user's profile and the lunch and dinner windows for restaurants. With routing,
+// places are visited in the order that keeps travel short by the user's preferred transport.
+// Items the user pins keep their slot when the rest of the schedule is re-planned. With
+// forecasts, outdoor stops on wet days are reported and swapped with indoor ones on dry days.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+// Preferences gives the profile whose pace and time of day shape the days.
+type Preferences interface {
+	GetDefaultSearchProfile(ctx context.Context, userID uuid.UUID) (*models.UserPreferenceProfileResponse, error)
+}
+
+type Service interface {
+	// Build schedules every item of a list over req.Days days. Pinned items keep their slot.
+	Build(ctx context.Context, userID, listID uuid.UUID, req models.BuildScheduleRequest) (*models.Schedule, error)
+	Get(ctx context.Context, userID, listID uuid.UUID) (*models.Schedule, error)
+	// Move pins an item to a day and time, or unpins it, and re-plans the other items.
+	Move(ctx context.Context, userID, listID, itemID uuid.UUID, req models.MoveScheduleItemRequest) (*models.Schedule, error)
+	// Route returns the legs between the items of any list of the user, per day once it is
+	// scheduled and in list order before.
+	Route(ctx context.Context, userID, listID uuid.UUID) (*models.ListRoute, error)
+}
+
+type ServiceImpl struct {
+	repo        Repository
+	preferences Preferences
+	routing     routing.Provider // nil keeps items in list order and reports no legs
+	weather     weather.Provider // nil schedules without forecasts
+	logger      *zap.Logger
+}
+
+func NewService(repo Repository, preferences Preferences, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:        repo,
+		preferences: preferences,
+		logger:      logger,
+	}
+}
+
+// WithRouting orders the items of new schedules to keep travel short and reports the legs
+// between the slots of every day.
+func (s *ServiceImpl) WithRouting(provider routing.Provider) *ServiceImpl {
+	s.routing = provider
+	return s
+}
+
+func (s *ServiceImpl) Build(ctx context.Context, userID, listID uuid.UUID, req models.BuildScheduleRequest) (*models.Schedule, error) {
+	ctx, span := otel.Tracer("ScheduleService").Start(ctx, "Build", trace.WithAttributes(
+		attribute.String("list.id", listID.String()),
+		attribute.Int("schedule.days", req.Days),
+	))
+	defer span.End()
+
+	if _, err := s.list(ctx, userID, listID); err != nil {
+		return nil, err
+	}
+	start, err := startDate(req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	if req.Days < 1 {
+		return nil, fmt.Errorf("a schedule needs at least one day: %w", models.ErrBadRequest)
+	}
+	items, err := s.repo.Items(ctx, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	schedule, err := s.plan(ctx, userID, listID, items, s.options(ctx, userID, start, req.Days), req.AdaptToWeather)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to save schedule")
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("schedule.conflicts", len(schedule.Conflicts)), attribute.Int("schedule.swaps", len(schedule.Swaps)))
+	span.SetStatus(codes.Ok, "Schedule built")
+	return schedule, nil
+}
+
+func (s *ServiceImpl) Get(ctx context.Context, userID, listID uuid.UUID) (*models.Schedule, error) {
+	info, err := s.scheduled(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.Items(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	opts := s.options(ctx, userID, *info.StartDate, info.Days)
+	days, conflicts := Arrange(items, opts)
+	forecasts := s.forecasts(ctx, items, opts)
+	swaps := Swaps(days, forecasts)
+	conflicts = append(conflicts, forecastDays(days, forecasts)...)
+	s.routeDays(ctx, days, items, opts.Mode)
+	return assemble(listID, opts.StartDate, days, conflicts, swaps), nil
+}
+
+func (s *ServiceImpl) Move(ctx context.Context, userID, listID, itemID uuid.UUID, req models.MoveScheduleItemRequest) (*models.Schedule, error) {
+	ctx, span := otel.Tracer("ScheduleService").Start(ctx, "Move", trace.WithAttributes(
+		attribute.String("list.id", listID.String()),
+		attribute.String("item.id", itemID.String()),
+	))
+	defer span.End()
+
+	info, err := s.scheduled(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.Items(ctx, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	var item *Item
+	for i := range items {
+		if items[i].ID == itemID {
+			item = &items[i]
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("item %s is not in the schedule: %w", itemID, models.ErrNotFound)
+	}
+
+	opts := s.options(ctx, userID, *info.StartDate, info.Days)
+	if req.Pinned != nil && !*req.Pinned {
+		item.Pinned = false
+	} else {
+		if req.Day < 1 || req.Day > info.Days {
+			return nil, fmt.Errorf("day must be between 1 and %d: %w", info.Days, models.ErrBadRequest)
+		}
+		at, err := time.Parse("15:04", req.Time)
+		if err != nil {
+			return nil, fmt.Errorf("time must be HH:MM: %w", models.ErrBadRequest)
+		}
+		date := opts.StartDate.AddDate(0, 0, req.Day-1)
+		item.Pinned, item.Day = true, req.Day
+		item.Start = date.Add(time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute)
+	}
+
+	schedule, err := s.plan(ctx, userID, listID, items, opts, false)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to save schedule")
+		return nil, err
+	}
+	span.SetAttributes(attribute.Bool("item.pinned", item.Pinned), attribute.Int("schedule.conflicts", len(schedule.Conflicts)))
+	span.SetStatus(codes.Ok, "Schedule re-planned")
+	return schedule, nil
+}
+
+func (s *ServiceImpl) Route(ctx context.Context, userID, listID uuid.UUID) (*models.ListRoute, error) {
+	ctx, span := otel.Tracer("ScheduleService").Start(ctx, "Route", trace.WithAttributes(
+		attribute.String("list.id", listID.String()),
+	))
+	defer span.End()
+
+	info, err := s.repo.List(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if info.UserID != userID {
+		return nil, fmt.Errorf("list %s: %w", listID, models.ErrNotFound)
+	}
+	items, err := s.repo.Items(ctx, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	listRoute := &models.ListRoute{ListID: listID, Routes: []models.Route{}}
+	if info.IsItinerary && info.StartDate != nil && info.Days > 0 {
+		opts := s.options(ctx, userID, *info.StartDate, info.Days)
+		days, _ := Arrange(items, opts)
+		s.routeDays(ctx, days, items, opts.Mode)
+		for _, d := range days {
+			if d.Route != nil {
+				listRoute.Routes = append(listRoute.Routes, *d.Route)
+			}
+		}
+	} else {
+		mode := s.options(ctx, userID, time.Time{}, 0).Mode
+		if route := s.route(ctx, sortedByPosition(items), mode); route != nil {
+			listRoute.Routes = append(listRoute.Routes, *route)
+		}
+	}
+	span.SetAttributes(attribute.Int("routes.count", len(listRoute.Routes)))
+	span.SetStatus(codes.Ok, "List routed")
+	return listRoute, nil
+}
+
+// plan places the items and stores their slots. With adapt, the weather swaps are made by
+// planning again with the swapped items on each other's day; otherwise they are suggested.
+func (s *ServiceImpl) plan(ctx context.Context, userID, listID uuid.UUID, items []Item, opts Options, adapt bool) (*models.Schedule, error) {
+	ordered := s.ordered(ctx, items, opts.Mode)
+	days, conflicts := Plan(ordered, opts)
+	forecasts := s.forecasts(ctx, items, opts)
+	swaps := Swaps(days, forecasts)
+	if adapt && len(swaps) > 0 {
+		days, conflicts = Plan(swapped(ordered, days, swaps), opts)
+		for i := range swaps {
+			swaps[i].Applied = true
+		}
+	}
+	conflicts = append(conflicts, forecastDays(days, forecasts)...)
+	s.routeDays(ctx, days, items, opts.Mode)
+	var slots []models.ScheduleSlot
+	for _, d := range days {
+		slots = append(slots, d.Slots...)
+	}
+	if err := s.repo.SaveSchedule(ctx, listID, opts.StartDate, opts.Days, slots); err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		s.logger.Debug("Schedule has conflicts",
+			zap.String("user_id", userID.String()),
+			zap.String("list_id", listID.String()),
+			zap.Int("conflicts", len(conflicts)))
+	}
+	return assemble(listID, opts.StartDate, days, conflicts, swaps), nil
+}
+
+// options shapes the days after the user's default profile; without one the days have a
+// moderate pace and no time of day preference.
+func (s *ServiceImpl) options(ctx context.Context, userID uuid.UUID, start time.Time, days int) Options {
+	opts := Options{Days: days, StartDate: start, Pace: models.SearchPaceAny, Time: models.DayPreferenceAny, Mode: routing.ModeWalk}
+	profile, err := s.preferences.GetDefaultSearchProfile(ctx, userID)
+	if err != nil || profile == nil {
+		s.logger.Warn("Scheduling without a profile", zap.String("user_id", userID.String()), zap.Error(err))
+		return opts
+	}
+	opts.Pace, opts.Time = profile.PreferredPace, profile.PreferredTime
+	opts.Mode = routing.ModeFor(string(profile.PreferredTransport))
+	return opts
+}
+
+// ordered gives the unpinned items with a location the list positions of the shortest route
+// through them, so the planner fills each day with places close to one another. The other
+// items keep their positions. Without routing, or when it fails, items keep the list order.
+func (s *ServiceImpl) ordered(ctx context.Context, items []Item, mode routing.Mode) []Item {
+	if s.routing == nil {
+		return items
+	}
+	var routed []int
+	var points []routing.Point
+	for i, it := range sortedByPosition(items) {
+		if !it.Pinned && it.Point != nil {
+			routed = append(routed, i)
+			points = append(points, *it.Point)
+		}
+	}
+	if len(points) < 3 {
+		return items
+	}
+	order, _, err := routing.Route(ctx, s.routing, points, mode)
+	if err != nil {
+		s.logger.Warn("Scheduling in list order, routing failed", zap.String("mode", string(mode)), zap.Error(err))
+		return items
+	}
+	sorted := sortedByPosition(items)
+	out := append([]Item(nil), sorted...)
+	for k, i := range order {
+		out[routed[k]] = sorted[routed[i]]
+		out[routed[k]].Position = sorted[routed[k]].Position
+	}
+	return out
+}
+
+// routeDays sets the legs between the slots of every day.
+func (s *ServiceImpl) routeDays(ctx context.Context, days []models.ScheduleDay, items []Item, mode routing.Mode) {
+	if s.routing == nil {
+		return
+	}
+	byID := make(map[uuid.UUID]Item, len(items))
+	for _, it := range items {
+		byID[it.ID] = it
+	}
+	for i := range days {
+		stops := make([]Item, 0, len(days[i].Slots))
+		for _, slot := range days[i].Slots {
+			stops = append(stops, byID[slot.ItemID])
+		}
+		if route := s.route(ctx, stops, mode); route != nil {
+			route.Day = days[i].Day
+			days[i].Route = route
+		}
+	}
+}
+
+// route returns the legs between the items with a location, in the order given. It is nil
+// without routing or when the provider fails.
+func (s *ServiceImpl) route(ctx context.Context, items []Item, mode routing.Mode) *models.Route {
+	if s.routing == nil {
+		return nil
+	}
+	var located []Item
+	var points []routing.Point
+	for _, it := range items {
+		if it.Point != nil {
+			located = append(located, it)
+			points = append(points, *it.Point)
+		}
+	}
+	legs, err := routing.Legs(ctx, s.routing, points, mode)
+	if err != nil {
+		s.logger.Warn("Failed to route list items", zap.String("mode", string(mode)), zap.Error(err))
+		return nil
+	}
+	route := &models.Route{Mode: string(mode), Provider: s.routing.Name(), Legs: []models.RouteLeg{}}
+	for i, leg := range legs {
+		route.Legs = append(route.Legs, models.RouteLeg{
+			From:       located[i].Name,
+			To:         located[i+1].Name,
+			DistanceKm: leg.DistanceKm,
+			Minutes:    leg.Minutes,
+		})
+		route.DistanceKm += leg.DistanceKm
+		route.Minutes += leg.Minutes
+	}
+	return route
+}
+
+// list hides the lists of other users as missing; only itinerary lists have a schedule.
+func (s *ServiceImpl) list(ctx context.Context, userID, listID uuid.UUID) (ListInfo, error) {
+	info, err := s.repo.List(ctx, listID)
+	if err != nil {
+		return ListInfo{}, err
+	}
+	if info.UserID != userID {
+		return ListInfo{}, fmt.Errorf("list %s: %w", listID, models.ErrNotFound)
+	}
+	if !info.IsItinerary {
+		return ListInfo{}, fmt.Errorf("only itinerary lists can be scheduled: %w", models.ErrBadRequest)
+	}
+	return info, nil
+}
+
+func (s *ServiceImpl) scheduled(ctx context.Context, userID, listID uuid.UUID) (ListInfo, error) {
+	info, err := s.list(ctx, userID, listID)
+	if err != nil {
+		return ListInfo{}, err
+	}
+	if info.StartDate == nil || info.Days < 1 {
+		return ListInfo{}, fmt.Errorf("list %s has no schedule: %w", listID, models.ErrNotFound)
+	}
+	return info, nil
+}
+
+// startDate reads a YYYY-MM-DD date; schedules start tomorrow when none is given.
+func startDate(s string) (time.Time, error) {
+	if s == "" {
+		y, m, d := time.Now().UTC().AddDate(0, 0, 1).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("start_date must be YYYY-MM-DD: %w", models.ErrBadRequest)
+	}
+	return date, nil
+}
+
+func assemble(listID uuid.UUID, start time.Time, days []models.ScheduleDay, conflicts []models.ScheduleConflict, swaps []models.WeatherSwap) *models.Schedule {
+	if conflicts == nil {
+		conflicts = []models.ScheduleConflict{}
+	}
+	if swaps == nil {
+		swaps = []models.WeatherSwap{}
+	}
+	return &models.Schedule{ListID: listID, StartDate: start, Days: days, Conflicts: conflicts, Swaps: swaps}
+}
@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+)
+
+func at(it Item, lat, lon float64) Item {
+	it.Point = &routing.Point{Latitude: lat, Longitude: lon}
+	return it
+}
+
+func TestOrdered_FollowsTheRouteAndKeepsPinnedItems(t *testing.T) {
+	s := &ServiceImpl{routing: routing.NewHaversine(), logger: zap.NewNop()}
+
+	// Across Lisbon and back: Baixa, Oriente, Belém, Alfama
+	baixa := at(item(0, "Baixa", "square", "", ""), 38.7100, -9.1370)
+	oriente := at(item(1, "Oceanário", "aquarium", "", ""), 38.7635, -9.0937)
+	belem := at(item(2, "Belém Tower", "monument", "", ""), 38.6916, -9.2160)
+	pinned := at(item(3, "Tram 28", "tour", "", ""), 38.7139, -9.1334)
+	pinned.Pinned = true
+	alfama := at(item(4, "Alfama", "neighbourhood", "", ""), 38.7120, -9.1300)
+	unknown := item(5, "Somewhere", "", "", "")
+
+	out := s.ordered(context.Background(), []Item{baixa, oriente, belem, pinned, alfama, unknown}, routing.ModeWalk)
+	require.Len(t, out, 6)
+
+	names := make([]string, len(out))
+	for i, it := range out {
+		names[i] = it.Name
+		assert.Equal(t, i, it.Position)
+	}
+	assert.Equal(t, "Tram 28", names[3])
+	assert.Equal(t, "Somewhere", names[5])
+	// Oriente and Belém are at opposite ends of the route, Baixa and Alfama in between
+	route := []string{names[0], names[1], names[2], names[4]}
+	if route[0] != "Belém Tower" {
+		route[0], route[1], route[2], route[3] = route[3], route[2], route[1], route[0]
+	}
+	assert.Equal(t, []string{"Belém Tower", "Baixa", "Alfama", "Oceanário"}, route)
+}
+
+func TestRoute_LegsBetweenLocatedItems(t *testing.T) {
+	s := &ServiceImpl{routing: routing.NewHaversine(), logger: zap.NewNop()}
+	baixa := at(item(0, "Baixa", "square", "", ""), 38.7100, -9.1370)
+	alfama := at(item(1, "Alfama", "neighbourhood", "", ""), 38.7120, -9.1300)
+
+	route := s.route(context.Background(), []Item{baixa, item(2, "Somewhere", "", "", ""), alfama}, routing.ModeCar)
+	require.NotNil(t, route)
+	require.Len(t, route.Legs, 1)
+	assert.Equal(t, "Baixa", route.Legs[0].From)
+	assert.Equal(t, "Alfama", route.Legs[0].To)
+	assert.Equal(t, "car", route.Mode)
+	assert.Equal(t, "haversine", route.Provider)
+	assert.InDelta(t, route.Legs[0].Minutes, route.Minutes, 1e-9)
+
+	assert.Nil(t, (&ServiceImpl{logger: zap.NewNop()}).route(context.Background(), []Item{baixa, alfama}, routing.ModeWalk))
+}
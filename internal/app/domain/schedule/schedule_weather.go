@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
+)
+
+// WithWeather forecasts the days of schedules, reports outdoor stops on wet days and
+// suggests indoor stops to swap them with.
+func (s *ServiceImpl) WithWeather(provider weather.Provider) *ServiceImpl {
+	s.weather = provider
+	return s
+}
+
+// forecasts returns the forecasts of the days of a schedule by day number, at the middle of
+// the items with a location. It is empty without weather, located items or forecasts.
+func (s *ServiceImpl) forecasts(ctx context.Context, items []Item, opts Options) map[int]models.Forecast {
+	if s.weather == nil || opts.Days < 1 {
+		return nil
+	}
+	var lat, lon float64
+	located := 0
+	for _, it := range items {
+		if it.Point != nil {
+			lat += it.Point.Latitude
+			lon += it.Point.Longitude
+			located++
+		}
+	}
+	if located == 0 {
+		return nil
+	}
+	forecasts, err := s.weather.Daily(ctx, lat/float64(located), lon/float64(located), opts.StartDate, opts.Days)
+	if err != nil {
+		s.logger.Warn("Scheduling without forecasts", zap.String("provider", s.weather.Name()), zap.Error(err))
+		return nil
+	}
+	byDay := make(map[int]models.Forecast, len(forecasts))
+	for _, f := range forecasts {
+		day := int(math.Round(f.Date.Sub(opts.StartDate).Hours()/24)) + 1
+		if day >= 1 && day <= opts.Days {
+			byDay[day] = f
+		}
+	}
+	return byDay
+}
+
+// Swaps pairs every outdoor visit on a wet day with an indoor visit on a dry day, the
+// nearest days first. Pinned items stay where the user put them, and days without a
+// forecast are neither wet nor dry.
+func Swaps(days []models.ScheduleDay, forecasts map[int]models.Forecast) []models.WeatherSwap {
+	used := map[uuid.UUID]bool{}
+	var swaps []models.WeatherSwap
+	for i, d := range days {
+		f, ok := forecasts[d.Day]
+		if !ok || !f.Wet {
+			continue
+		}
+		for _, slot := range d.Slots {
+			if !movable(slot, models.SettingOutdoor) || used[slot.ItemID] {
+				continue
+			}
+			other, ok := indoorSlot(days, forecasts, i, used)
+			if !ok {
+				continue
+			}
+			used[slot.ItemID], used[other.ItemID] = true, true
+			swaps = append(swaps, models.WeatherSwap{
+				Day:        d.Day,
+				ItemID:     slot.ItemID,
+				Name:       slot.Name,
+				SwapDay:    other.Day,
+				SwapItemID: other.ItemID,
+				SwapName:   other.Name,
+				Description: fmt.Sprintf("%s is forecast on day %d: visit %s on day %d and %s on day %d instead",
+					wetWeather(f), d.Day, slot.Name, other.Day, other.Name, d.Day),
+			})
+		}
+	}
+	return swaps
+}
+
+// indoorSlot finds an indoor visit not swapped yet on the dry day nearest the day at index i.
+func indoorSlot(days []models.ScheduleDay, forecasts map[int]models.Forecast, i int, used map[uuid.UUID]bool) (models.ScheduleSlot, bool) {
+	for _, di := range dayOrder(i, len(days)) {
+		f, ok := forecasts[days[di].Day]
+		if di == i || !ok || f.Wet {
+			continue
+		}
+		for _, slot := range days[di].Slots {
+			if movable(slot, models.SettingIndoor) && !used[slot.ItemID] {
+				return slot, true
+			}
+		}
+	}
+	return models.ScheduleSlot{}, false
+}
+
+func movable(slot models.ScheduleSlot, setting string) bool {
+	return !slot.Pinned && slot.Kind == models.SlotKindVisit && slot.Setting == setting
+}
+
+// swapped gives every unpinned item the day it has in days as its preferred one, with the
+// days of the items of each swap exchanged, so that planning again makes the swaps and
+// keeps the other items where they were.
+func swapped(items []Item, days []models.ScheduleDay, swaps []models.WeatherSwap) []Item {
+	dayOf := map[uuid.UUID]int{}
+	for _, d := range days {
+		for _, slot := range d.Slots {
+			dayOf[slot.ItemID] = d.Day
+		}
+	}
+	for _, sw := range swaps {
+		dayOf[sw.ItemID], dayOf[sw.SwapItemID] = sw.SwapDay, sw.Day
+	}
+	out := append([]Item(nil), items...)
+	for i := range out {
+		if !out[i].Pinned {
+			out[i].Prefer = dayOf[out[i].ID]
+		}
+	}
+	return out
+}
+
+// forecastDays sets the forecast of every day and reports the outdoor visits on wet days.
+func forecastDays(days []models.ScheduleDay, forecasts map[int]models.Forecast) []models.ScheduleConflict {
+	var conflicts []models.ScheduleConflict
+	for i := range days {
+		f, ok := forecasts[days[i].Day]
+		if !ok {
+			continue
+		}
+		days[i].Forecast = &f
+		if !f.Wet {
+			continue
+		}
+		for _, slot := range days[i].Slots {
+			if slot.Kind != models.SlotKindVisit || slot.Setting != models.SettingOutdoor {
+				continue
+			}
+			id := slot.ItemID
+			conflicts = append(conflicts, models.ScheduleConflict{
+				Type:    models.ConflictWeather,
+				Day:     days[i].Day,
+				ItemID:  &id,
+				Message: fmt.Sprintf("%s is forecast on day %d and %s is outdoors", wetWeather(f), days[i].Day, slot.Name),
+			})
+		}
+	}
+	return conflicts
+}
+
+// wetWeather names the weather of a wet forecast, e.g. "Rain".
+func wetWeather(f models.Forecast) string {
+	switch f.Condition {
+	case models.ConditionSnow:
+		return "Snow"
+	case models.ConditionStorm:
+		return "A storm"
+	}
+	return "Rain"
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
+)
+
+// savingRepository keeps the slots of the last schedule saved.
+type savingRepository struct {
+	Repository
+	slots []models.ScheduleSlot
+}
+
+func (r *savingRepository) SaveSchedule(_ context.Context, _ uuid.UUID, _ time.Time, _ int, slots []models.ScheduleSlot) error {
+	r.slots = slots
+	return nil
+}
+
+// rainyTuesday plans a sunny Monday and a rainy Tuesday in Lisbon: a park and a museum on
+// Monday, a garden and a gallery on Tuesday.
+func rainyTuesday(t *testing.T) (*ServiceImpl, []Item, Options) {
+	t.Helper()
+	forecasts, err := weather.NewFile("testdata/forecast.json")
+	require.NoError(t, err)
+	s := (&ServiceImpl{repo: &savingRepository{}, logger: zap.NewNop()}).WithWeather(forecasts)
+
+	items := []Item{
+		at(item(0, "Eduardo VII Park", "park", "1 hour", ""), 38.7287, -9.1538),
+		at(item(1, "Gulbenkian Museum", "museum", "1 hour", ""), 38.7375, -9.1545),
+		at(item(2, "Estrela Garden", "garden", "1 hour", ""), 38.7140, -9.1600),
+		at(item(3, "MAAT", "art gallery", "1 hour", ""), 38.6957, -9.1943),
+	}
+	return s, items, Options{Days: 2, StartDate: monday, Pace: models.SearchPaceAny, Time: models.DayPreferenceAny}
+}
+
+func TestPlan_SuggestsIndoorSwapsOnWetDays(t *testing.T) {
+	s, items, opts := rainyTuesday(t)
+
+	schedule, err := s.plan(context.Background(), uuid.New(), uuid.New(), items, opts, false)
+	require.NoError(t, err)
+
+	require.NotNil(t, schedule.Days[0].Forecast)
+	assert.False(t, schedule.Days[0].Forecast.Wet)
+	require.NotNil(t, schedule.Days[1].Forecast)
+	assert.True(t, schedule.Days[1].Forecast.Wet)
+
+	garden := slotOf(t, schedule.Days, items[2].ID)
+	assert.Equal(t, 2, garden.Day)
+	assert.Equal(t, models.SettingOutdoor, garden.Setting)
+
+	require.Len(t, schedule.Swaps, 1)
+	swap := schedule.Swaps[0]
+	assert.Equal(t, items[2].ID, swap.ItemID)
+	assert.Equal(t, items[1].ID, swap.SwapItemID)
+	assert.Equal(t, 1, swap.SwapDay)
+	assert.False(t, swap.Applied)
+	assert.Contains(t, swap.Description, "Rain is forecast on day 2")
+
+	var weatherConflicts int
+	for _, c := range schedule.Conflicts {
+		if c.Type == models.ConflictWeather {
+			weatherConflicts++
+			assert.Equal(t, items[2].ID, *c.ItemID)
+		}
+	}
+	assert.Equal(t, 1, weatherConflicts)
+}
+
+func TestPlan_AppliesIndoorSwaps(t *testing.T) {
+	s, items, opts := rainyTuesday(t)
+
+	schedule, err := s.plan(context.Background(), uuid.New(), uuid.New(), items, opts, true)
+	require.NoError(t, err)
+
+	require.Len(t, schedule.Swaps, 1)
+	assert.True(t, schedule.Swaps[0].Applied)
+	assert.Equal(t, 1, slotOf(t, schedule.Days, items[0].ID).Day, "the park stays on the sunny day")
+	assert.Equal(t, 1, slotOf(t, schedule.Days, items[2].ID).Day)
+	assert.Equal(t, 2, slotOf(t, schedule.Days, items[1].ID).Day)
+	assert.Equal(t, 2, slotOf(t, schedule.Days, items[3].ID).Day)
+	for _, c := range schedule.Conflicts {
+		assert.NotEqual(t, models.ConflictWeather, c.Type)
+	}
+	assert.Len(t, s.repo.(*savingRepository).slots, 4, "the adapted schedule is saved")
+}
+
+func TestSwaps_KeepPinnedItems(t *testing.T) {
+	forecasts := map[int]models.Forecast{1: {Condition: models.ConditionClear}, 2: {Condition: models.ConditionRain, Wet: true}}
+	days := []models.ScheduleDay{
+		{Day: 1, Slots: []models.ScheduleSlot{{ItemID: uuid.New(), Kind: models.SlotKindVisit, Setting: models.SettingIndoor, Pinned: true}}},
+		{Day: 2, Slots: []models.ScheduleSlot{{ItemID: uuid.New(), Kind: models.SlotKindVisit, Setting: models.SettingOutdoor}}},
+	}
+	assert.Empty(t, Swaps(days, forecasts))
+
+	days[0].Slots[0].Pinned = false
+	assert.Len(t, Swaps(days, forecasts), 1)
+
+	delete(forecasts, 1)
+	assert.Empty(t, Swaps(days, forecasts), "days without a forecast are not known to be dry")
+}
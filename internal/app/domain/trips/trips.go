@@ -0,0 +1,121 @@
+package trips
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Handler serves the multi-city trips of the user.
+type Handler struct {
+	service Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Plan handles POST /trips. Every city is generated before the response is sent; a city that
+// failed comes back without an itinerary and can be regenerated with UpdateCity.
+func (h *Handler) Plan(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	var req models.PlanTripRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	trip, err := h.service.Plan(c.Request.Context(), userID, req)
+	if err != nil {
+		h.respondError(c, "Failed to plan trip", err)
+		return
+	}
+	c.JSON(http.StatusCreated, trip)
+}
+
+// Get handles GET /trips/:id, where id is the chat session of the trip.
+func (h *Handler) Get(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trip id"})
+		return
+	}
+	trip, err := h.service.Get(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		h.respondError(c, "Failed to get trip", err)
+		return
+	}
+	c.JSON(http.StatusOK, trip)
+}
+
+// UpdateCity handles PATCH /trips/:id/cities/:position. Only that city is regenerated.
+func (h *Handler) UpdateCity(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid trip id"})
+		return
+	}
+	position, err := strconv.Atoi(c.Param("position"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid city position"})
+		return
+	}
+	var req models.UpdateTripCityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	trip, err := h.service.UpdateCity(c.Request.Context(), userID, sessionID, position, req)
+	if err != nil {
+		h.respondError(c, "Failed to update trip city", err)
+		return
+	}
+	c.JSON(http.StatusOK, trip)
+}
+
+func (h *Handler) userID(c *gin.Context) (uuid.UUID, bool) {
+	user := middleware.GetUserFromContext(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(user.ID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (h *Handler) respondError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, models.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "trip or city not found"})
+	case errors.Is(err, models.ErrBadRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(msg, zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+}
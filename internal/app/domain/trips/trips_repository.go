@@ -0,0 +1,187 @@
+package trips
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Repository = (*RepositoryImpl)(nil)
+
+type Repository interface {
+	// Session returns the user and search profile of a chat session.
+	Session(ctx context.Context, sessionID uuid.UUID) (userID, profileID uuid.UUID, err error)
+	// SaveCities stores the cities of a trip, replacing any it had.
+	SaveCities(ctx context.Context, sessionID uuid.UUID, cities []models.TripCity) error
+	// SaveCity replaces the city at city.Position.
+	SaveCity(ctx context.Context, sessionID uuid.UUID, city models.TripCity) error
+	// Cities returns the cities of a trip in travel order, none when the session has no trip.
+	Cities(ctx context.Context, sessionID uuid.UUID) ([]models.TripCity, error)
+}
+
+type RepositoryImpl struct {
+	pgpool *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewRepository(pgpool *pgxpool.Pool, logger *zap.Logger) *RepositoryImpl {
+	return &RepositoryImpl{
+		pgpool: pgpool,
+		logger: logger,
+	}
+}
+
+func (r *RepositoryImpl) Session(ctx context.Context, sessionID uuid.UUID) (uuid.UUID, uuid.UUID, error) {
+	var owner, profile *uuid.UUID
+	err := r.pgpool.QueryRow(ctx, `SELECT user_id, profile_id FROM chat_sessions WHERE id = $1`, sessionID).Scan(&owner, &profile)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("session %s: %w", sessionID, models.ErrNotFound)
+	}
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed to query session: %w", err)
+	}
+	var userID, profileID uuid.UUID
+	if owner != nil {
+		userID = *owner
+	}
+	if profile != nil {
+		profileID = *profile
+	}
+	return userID, profileID, nil
+}
+
+const upsertCityQuery = `
+	INSERT INTO chat_session_trip_cities (
+		session_id, position, city_name, country, city_id, nights, latitude, longitude, itinerary, llm_interaction_id, updated_at
+	) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7::DOUBLE PRECISION, 0), NULLIF($8::DOUBLE PRECISION, 0), $9, $10, NOW())
+	ON CONFLICT (session_id, position) DO UPDATE SET
+		city_name = EXCLUDED.city_name,
+		country = EXCLUDED.country,
+		city_id = EXCLUDED.city_id,
+		nights = EXCLUDED.nights,
+		latitude = EXCLUDED.latitude,
+		longitude = EXCLUDED.longitude,
+		itinerary = EXCLUDED.itinerary,
+		llm_interaction_id = EXCLUDED.llm_interaction_id,
+		updated_at = NOW()`
+
+func cityArgs(sessionID uuid.UUID, c models.TripCity) ([]any, error) {
+	var itinerary []byte
+	if c.Itinerary != nil {
+		var err error
+		if itinerary, err = json.Marshal(c.Itinerary); err != nil {
+			return nil, fmt.Errorf("failed to marshal itinerary of %s: %w", c.City, err)
+		}
+	}
+	return []any{sessionID, c.Position, c.City, c.Country, c.CityID, c.Nights, c.Latitude, c.Longitude, itinerary, c.LlmInteractionID}, nil
+}
+
+func (r *RepositoryImpl) SaveCities(ctx context.Context, sessionID uuid.UUID, cities []models.TripCity) error {
+	ctx, span := otel.Tracer("TripsRepository").Start(ctx, "SaveCities", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+		attribute.Int("trip.cities", len(cities)),
+	))
+	defer span.End()
+
+	tx, err := r.pgpool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			r.logger.Error("transaction rollback failed", zap.Error(err))
+		}
+	}()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM chat_session_trip_cities WHERE session_id = $1`, sessionID); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to clear trip cities: %w", err)
+	}
+	for _, c := range cities {
+		args, err := cityArgs(sessionID, c)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, upsertCityQuery, args...); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Failed to insert trip city")
+			return fmt.Errorf("failed to insert trip city %s: %w", c.City, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to commit trip cities: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Trip cities saved")
+	return nil
+}
+
+func (r *RepositoryImpl) SaveCity(ctx context.Context, sessionID uuid.UUID, city models.TripCity) error {
+	args, err := cityArgs(sessionID, city)
+	if err != nil {
+		return err
+	}
+	if _, err := r.pgpool.Exec(ctx, upsertCityQuery, args...); err != nil {
+		return fmt.Errorf("failed to save trip city %s: %w", city.City, err)
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) Cities(ctx context.Context, sessionID uuid.UUID) ([]models.TripCity, error) {
+	ctx, span := otel.Tracer("TripsRepository").Start(ctx, "Cities", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+	))
+	defer span.End()
+
+	query := `
+		SELECT position, city_name, country, city_id, nights,
+		       COALESCE(latitude, 0), COALESCE(longitude, 0), itinerary, llm_interaction_id, updated_at
+		FROM chat_session_trip_cities
+		WHERE session_id = $1
+		ORDER BY position`
+	rows, err := r.pgpool.Query(ctx, query, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to query trip cities")
+		return nil, fmt.Errorf("failed to query trip cities: %w", err)
+	}
+	defer rows.Close()
+
+	var cities []models.TripCity
+	for rows.Next() {
+		var c models.TripCity
+		var itinerary []byte
+		if err := rows.Scan(&c.Position, &c.City, &c.Country, &c.CityID, &c.Nights,
+			&c.Latitude, &c.Longitude, &itinerary, &c.LlmInteractionID, &c.UpdatedAt); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan trip city: %w", err)
+		}
+		if len(itinerary) > 0 {
+			if err := json.Unmarshal(itinerary, &c.Itinerary); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal itinerary of %s: %w", c.City, err)
+			}
+		}
+		cities = append(cities, c)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate trip cities: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Trip cities loaded")
+	return cities, nil
+}
@@ -0,0 +1,365 @@
+// Package trips plans multi-city trips: an ordered list of cities with a number of nights in
+// each, an itinerary per city and the transfer legs between them. A trip lives in the chat
+// session it was planned in, and each city can be changed without regenerating the others.
+package trips
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Service = (*ServiceImpl)(nil)
+
+const (
+	MaxCities      = 8
+	MaxTotalNights = 60
+
+	ModeTrain   = "train"
+	ModeFlight  = "flight"
+	ModeUnknown = "unknown"
+
+	// Legs up to this distance are taken by train, longer ones by plane
+	trainMaxKm = 600
+	trainKmh   = 100
+	flightKmh  = 750
+	// Getting to the airport, security and boarding, and into town at the other end
+	flightOverheadMinutes = 150
+)
+
+// CityRequest asks for the itinerary of the city at Position of Stops.
+type CityRequest struct {
+	UserID       uuid.UUID
+	ProfileID    uuid.UUID
+	SessionID    uuid.UUID
+	Stops        []models.TripStop
+	Position     int
+	Instructions string
+}
+
+// Planner generates what a trip is made of. The chat service implements it, so trip cities
+// are generated with the same preferences, language and budget rules as single-city chats.
+type Planner interface {
+	// StartTripSession creates the chat session a trip is kept in. An empty profileID picks
+	// the user's default profile; the session returned carries the one used.
+	StartTripSession(ctx context.Context, userID, profileID uuid.UUID, stops []models.TripStop) (*models.ChatSession, error)
+	GenerateTripCity(ctx context.Context, req CityRequest) (*models.TripCity, error)
+}
+
+// Coster estimates what a trip costs. The chat service implements it.
+type Coster interface {
+	TripCost(ctx context.Context, userID uuid.UUID, trip *models.Trip)
+}
+
+type Service interface {
+	Plan(ctx context.Context, userID uuid.UUID, req models.PlanTripRequest) (*models.Trip, error)
+	Get(ctx context.Context, userID, sessionID uuid.UUID) (*models.Trip, error)
+	// UpdateCity changes the city at position and regenerates its itinerary only.
+	UpdateCity(ctx context.Context, userID, sessionID uuid.UUID, position int, req models.UpdateTripCityRequest) (*models.Trip, error)
+}
+
+type ServiceImpl struct {
+	repo    Repository
+	planner Planner
+	costs   Coster // nil leaves trips without a cost
+	logger  *zap.Logger
+}
+
+func NewService(repo Repository, planner Planner, logger *zap.Logger) *ServiceImpl {
+	return &ServiceImpl{
+		repo:    repo,
+		planner: planner,
+		logger:  logger,
+	}
+}
+
+// WithCosts estimates the cost of every trip returned.
+func (s *ServiceImpl) WithCosts(c Coster) *ServiceImpl {
+	s.costs = c
+	return s
+}
+
+func (s *ServiceImpl) Plan(ctx context.Context, userID uuid.UUID, req models.PlanTripRequest) (*models.Trip, error) {
+	ctx, span := otel.Tracer("TripsService").Start(ctx, "Plan", trace.WithAttributes(
+		attribute.Int("trip.cities", len(req.Cities)),
+	))
+	defer span.End()
+
+	stops := normalizeStops(req.Cities)
+	if err := validateStops(stops); err != nil {
+		return nil, err
+	}
+	profileID := uuid.Nil
+	if req.ProfileID != nil {
+		profileID = *req.ProfileID
+	}
+	session, err := s.planner.StartTripSession(ctx, userID, profileID, stops)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to start trip session")
+		return nil, fmt.Errorf("failed to start trip session: %w", err)
+	}
+	span.SetAttributes(attribute.String("session.id", session.ID.String()))
+
+	// Cities are independent of each other, they are generated side by side
+	cities := make([]models.TripCity, len(stops))
+	errs := make([]error, len(stops))
+	var wg sync.WaitGroup
+	for i := range stops {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cities[i], errs[i] = s.generate(ctx, CityRequest{
+				UserID:    userID,
+				ProfileID: session.ProfileID,
+				SessionID: session.ID,
+				Stops:     stops,
+				Position:  i,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	// A city that failed is kept without an itinerary, it can be regenerated on its own
+	failed := 0
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		failed++
+		s.logger.Warn("Failed to generate trip city",
+			zap.String("session_id", session.ID.String()),
+			zap.String("city", stops[i].City),
+			zap.Error(err))
+		cities[i] = models.TripCity{Position: i, City: stops[i].City, Country: stops[i].Country, Nights: stops[i].Nights}
+	}
+	if failed == len(stops) {
+		err := fmt.Errorf("failed to generate any city of the trip: %w", errors.Join(errs...))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "No city generated")
+		return nil, err
+	}
+
+	if err := s.repo.SaveCities(ctx, session.ID, cities); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to save trip")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("trip.failed_cities", failed))
+	span.SetStatus(codes.Ok, "Trip planned")
+	return s.assemble(ctx, userID, session.ID, cities), nil
+}
+
+func (s *ServiceImpl) Get(ctx context.Context, userID, sessionID uuid.UUID) (*models.Trip, error) {
+	if _, err := s.checkOwner(ctx, userID, sessionID); err != nil {
+		return nil, err
+	}
+	cities, err := s.cities(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.assemble(ctx, userID, sessionID, cities), nil
+}
+
+func (s *ServiceImpl) UpdateCity(ctx context.Context, userID, sessionID uuid.UUID, position int, req models.UpdateTripCityRequest) (*models.Trip, error) {
+	ctx, span := otel.Tracer("TripsService").Start(ctx, "UpdateCity", trace.WithAttributes(
+		attribute.String("session.id", sessionID.String()),
+		attribute.Int("trip.position", position),
+	))
+	defer span.End()
+
+	profileID, err := s.checkOwner(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := s.cities(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if position < 0 || position >= len(cities) {
+		return nil, fmt.Errorf("trip has no city at position %d: %w", position, models.ErrNotFound)
+	}
+
+	stops := make([]models.TripStop, len(cities))
+	for i, c := range cities {
+		stops[i] = models.TripStop{City: c.City, Country: c.Country, Nights: c.Nights}
+	}
+	stop := &stops[position]
+	if req.City != nil {
+		stop.City = *req.City
+		// A new city without a country must not keep the country of the old one
+		stop.Country = ""
+	}
+	if req.Country != nil {
+		stop.Country = *req.Country
+	}
+	if req.Nights != nil {
+		stop.Nights = *req.Nights
+	}
+	stops = normalizeStops(stops)
+	if err := validateStops(stops); err != nil {
+		return nil, err
+	}
+
+	city, err := s.generate(ctx, CityRequest{
+		UserID:       userID,
+		ProfileID:    profileID,
+		SessionID:    sessionID,
+		Stops:        stops,
+		Position:     position,
+		Instructions: strings.TrimSpace(req.Instructions),
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to regenerate trip city")
+		return nil, fmt.Errorf("failed to regenerate %s: %w", stops[position].City, err)
+	}
+	if err := s.repo.SaveCity(ctx, sessionID, city); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	cities[position] = city
+
+	span.SetStatus(codes.Ok, "Trip city updated")
+	return s.assemble(ctx, userID, sessionID, cities), nil
+}
+
+// generate asks the planner for one city and fills in what it left out from the stop.
+func (s *ServiceImpl) generate(ctx context.Context, req CityRequest) (models.TripCity, error) {
+	stop := req.Stops[req.Position]
+	city, err := s.planner.GenerateTripCity(ctx, req)
+	if err != nil {
+		return models.TripCity{}, err
+	}
+	city.Position = req.Position
+	city.Nights = stop.Nights
+	if city.City == "" {
+		city.City = stop.City
+	}
+	if city.Country == "" {
+		city.Country = stop.Country
+	}
+	return *city, nil
+}
+
+func (s *ServiceImpl) cities(ctx context.Context, sessionID uuid.UUID) ([]models.TripCity, error) {
+	cities, err := s.repo.Cities(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("session %s has no trip: %w", sessionID, models.ErrNotFound)
+	}
+	return cities, nil
+}
+
+// checkOwner hides the sessions of other users as missing and returns the session's profile.
+func (s *ServiceImpl) checkOwner(ctx context.Context, userID, sessionID uuid.UUID) (uuid.UUID, error) {
+	owner, profileID, err := s.repo.Session(ctx, sessionID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if owner != userID {
+		return uuid.Nil, fmt.Errorf("session %s: %w", sessionID, models.ErrNotFound)
+	}
+	return profileID, nil
+}
+
+func normalizeStops(stops []models.TripStop) []models.TripStop {
+	out := make([]models.TripStop, len(stops))
+	for i, stop := range stops {
+		out[i] = models.TripStop{City: strings.TrimSpace(stop.City), Country: strings.TrimSpace(stop.Country), Nights: stop.Nights}
+	}
+	return out
+}
+
+func validateStops(stops []models.TripStop) error {
+	if len(stops) < 2 {
+		return fmt.Errorf("a multi-city trip needs at least 2 cities: %w", models.ErrBadRequest)
+	}
+	if len(stops) > MaxCities {
+		return fmt.Errorf("a trip can have at most %d cities: %w", MaxCities, models.ErrBadRequest)
+	}
+	total := 0
+	for i, stop := range stops {
+		if stop.City == "" {
+			return fmt.Errorf("city %d has no name: %w", i+1, models.ErrBadRequest)
+		}
+		if stop.Nights < 1 {
+			return fmt.Errorf("%s needs at least one night: %w", stop.City, models.ErrBadRequest)
+		}
+		total += stop.Nights
+	}
+	if total > MaxTotalNights {
+		return fmt.Errorf("a trip can last at most %d nights: %w", MaxTotalNights, models.ErrBadRequest)
+	}
+	return nil
+}
+
+// assemble builds the trip and estimates its cost.
+func (s *ServiceImpl) assemble(ctx context.Context, userID, sessionID uuid.UUID, cities []models.TripCity) *models.Trip {
+	trip := Assemble(sessionID, cities)
+	if s.costs != nil {
+		s.costs.TripCost(ctx, userID, trip)
+	}
+	return trip
+}
+
+// Assemble builds a trip from its cities, in travel order, with the legs between them.
+func Assemble(sessionID uuid.UUID, cities []models.TripCity) *models.Trip {
+	trip := &models.Trip{SessionID: sessionID, Cities: cities, Legs: Legs(cities)}
+	for _, c := range cities {
+		trip.TotalNights += c.Nights
+	}
+	return trip
+}
+
+// Legs returns the transfers between consecutive cities. The mode and duration are estimated
+// from the straight-line distance between the city centres.
+func Legs(cities []models.TripCity) []models.TransferLeg {
+	legs := make([]models.TransferLeg, 0, max(len(cities)-1, 0))
+	for i := 1; i < len(cities); i++ {
+		from, to := cities[i-1], cities[i]
+		leg := models.TransferLeg{From: from.City, To: to.City, Mode: ModeUnknown}
+		if hasCoordinates(from) && hasCoordinates(to) {
+			km := distanceKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+			leg.DistanceKm = math.Round(km*10) / 10
+			if km <= trainMaxKm {
+				leg.Mode = ModeTrain
+				leg.DurationMinutes = int(math.Round(km / trainKmh * 60))
+			} else {
+				leg.Mode = ModeFlight
+				leg.DurationMinutes = int(math.Round(km/flightKmh*60)) + flightOverheadMinutes
+			}
+		}
+		legs = append(legs, leg)
+	}
+	return legs
+}
+
+func hasCoordinates(c models.TripCity) bool {
+	return c.Latitude != 0 || c.Longitude != 0
+}
+
+// distanceKm is the haversine distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,285 @@
+package trips
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// MockRepository is a mock implementation of Repository
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Session(ctx context.Context, sessionID uuid.UUID) (uuid.UUID, uuid.UUID, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(uuid.UUID), args.Get(1).(uuid.UUID), args.Error(2)
+}
+
+func (m *MockRepository) SaveCities(ctx context.Context, sessionID uuid.UUID, cities []models.TripCity) error {
+	args := m.Called(ctx, sessionID, cities)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SaveCity(ctx context.Context, sessionID uuid.UUID, city models.TripCity) error {
+	args := m.Called(ctx, sessionID, city)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Cities(ctx context.Context, sessionID uuid.UUID) ([]models.TripCity, error) {
+	args := m.Called(ctx, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TripCity), args.Error(1)
+}
+
+// fakePlanner generates an itinerary named after the city, placing the city at its coordinates
+// in coords. Cities in fail return an error.
+type fakePlanner struct {
+	mu       sync.Mutex
+	coords   map[string][2]float64
+	fail     map[string]bool
+	requests []CityRequest
+}
+
+func (p *fakePlanner) StartTripSession(_ context.Context, userID, profileID uuid.UUID, _ []models.TripStop) (*models.ChatSession, error) {
+	if profileID == uuid.Nil {
+		profileID = defaultProfileID
+	}
+	return &models.ChatSession{ID: sessionID, UserID: userID, ProfileID: profileID}, nil
+}
+
+func (p *fakePlanner) GenerateTripCity(_ context.Context, req CityRequest) (*models.TripCity, error) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+
+	stop := req.Stops[req.Position]
+	if p.fail[stop.City] {
+		return nil, errors.New("model unavailable")
+	}
+	c := p.coords[stop.City]
+	return &models.TripCity{
+		City:      stop.City,
+		Latitude:  c[0],
+		Longitude: c[1],
+		Itinerary: &models.AIItineraryResponse{ItineraryName: stop.City},
+	}, nil
+}
+
+var (
+	userID           = uuid.MustParse("5b1e7c2d-4a3f-4e8b-9c1d-2f3e4a5b6c01")
+	sessionID        = uuid.MustParse("5b1e7c2d-4a3f-4e8b-9c1d-2f3e4a5b6c02")
+	defaultProfileID = uuid.MustParse("5b1e7c2d-4a3f-4e8b-9c1d-2f3e4a5b6c03")
+
+	coords = map[string][2]float64{
+		"Lisbon": {38.7223, -9.1393},
+		"Porto":  {41.1579, -8.6291},
+		"Berlin": {52.5200, 13.4050},
+	}
+)
+
+func newPlanner() *fakePlanner {
+	return &fakePlanner{coords: coords, fail: map[string]bool{}}
+}
+
+func TestLegs(t *testing.T) {
+	cities := []models.TripCity{
+		{City: "Lisbon", Latitude: coords["Lisbon"][0], Longitude: coords["Lisbon"][1]},
+		{City: "Porto", Latitude: coords["Porto"][0], Longitude: coords["Porto"][1]},
+		{City: "Berlin", Latitude: coords["Berlin"][0], Longitude: coords["Berlin"][1]},
+		{City: "Nowhere"},
+	}
+
+	legs := Legs(cities)
+	require.Len(t, legs, 3)
+
+	assert.Equal(t, "Lisbon", legs[0].From)
+	assert.Equal(t, "Porto", legs[0].To)
+	assert.Equal(t, ModeTrain, legs[0].Mode)
+	assert.InDelta(t, 274, legs[0].DistanceKm, 5)
+	assert.InDelta(t, 165, legs[0].DurationMinutes, 5)
+
+	assert.Equal(t, ModeFlight, legs[1].Mode)
+	assert.Greater(t, legs[1].DistanceKm, 2000.0)
+	assert.Greater(t, legs[1].DurationMinutes, flightOverheadMinutes)
+
+	assert.Equal(t, ModeUnknown, legs[2].Mode)
+	assert.Zero(t, legs[2].DistanceKm)
+	assert.Zero(t, legs[2].DurationMinutes)
+
+	assert.Empty(t, Legs(cities[:1]))
+}
+
+func TestValidateStops(t *testing.T) {
+	tests := []struct {
+		name  string
+		stops []models.TripStop
+	}{
+		{"one city", []models.TripStop{{City: "Lisbon", Nights: 2}}},
+		{"no name", []models.TripStop{{City: "Lisbon", Nights: 2}, {City: "", Nights: 2}}},
+		{"no nights", []models.TripStop{{City: "Lisbon", Nights: 2}, {City: "Porto", Nights: 0}}},
+		{"too long", []models.TripStop{{City: "Lisbon", Nights: 40}, {City: "Porto", Nights: 21}}},
+		{"too many cities", make([]models.TripStop, MaxCities+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, validateStops(tt.stops), models.ErrBadRequest)
+		})
+	}
+
+	assert.NoError(t, validateStops([]models.TripStop{{City: "Lisbon", Nights: 3}, {City: "Porto", Nights: 2}}))
+}
+
+func TestPlan_KeepsFailedCityWithoutItinerary(t *testing.T) {
+	repo := new(MockRepository)
+	planner := newPlanner()
+	planner.fail["Porto"] = true
+	service := NewService(repo, planner, zap.NewNop())
+
+	repo.On("SaveCities", mock.Anything, sessionID, mock.MatchedBy(func(cities []models.TripCity) bool {
+		return len(cities) == 2 && cities[0].Itinerary != nil && cities[1].Itinerary == nil
+	})).Return(nil)
+
+	trip, err := service.Plan(context.Background(), userID, models.PlanTripRequest{
+		Cities: []models.TripStop{{City: " Lisbon ", Nights: 3}, {City: "Porto", Country: "Portugal", Nights: 2}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, sessionID, trip.SessionID)
+	assert.Equal(t, 5, trip.TotalNights)
+	require.Len(t, trip.Cities, 2)
+	assert.Equal(t, "Lisbon", trip.Cities[0].City)
+	assert.Equal(t, 0, trip.Cities[0].Position)
+	assert.Equal(t, 3, trip.Cities[0].Nights)
+	assert.Equal(t, models.TripCity{Position: 1, City: "Porto", Country: "Portugal", Nights: 2}, trip.Cities[1])
+	require.Len(t, trip.Legs, 1)
+	assert.Equal(t, ModeUnknown, trip.Legs[0].Mode)
+
+	for _, req := range planner.requests {
+		assert.Equal(t, defaultProfileID, req.ProfileID)
+	}
+	repo.AssertExpectations(t)
+}
+
+func TestPlan_AllCitiesFailed(t *testing.T) {
+	repo := new(MockRepository)
+	planner := newPlanner()
+	planner.fail["Lisbon"] = true
+	planner.fail["Porto"] = true
+	service := NewService(repo, planner, zap.NewNop())
+
+	_, err := service.Plan(context.Background(), userID, models.PlanTripRequest{
+		Cities: []models.TripStop{{City: "Lisbon", Nights: 3}, {City: "Porto", Nights: 2}},
+	})
+	require.Error(t, err)
+	repo.AssertNotCalled(t, "SaveCities", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateCity_RegeneratesOnlyThatCity(t *testing.T) {
+	repo := new(MockRepository)
+	planner := newPlanner()
+	service := NewService(repo, planner, zap.NewNop())
+
+	lisbon := models.TripCity{
+		Position: 0, City: "Lisbon", Country: "Portugal", Nights: 3,
+		Latitude: coords["Lisbon"][0], Longitude: coords["Lisbon"][1],
+		Itinerary: &models.AIItineraryResponse{ItineraryName: "Lisbon"},
+	}
+	porto := models.TripCity{Position: 1, City: "Porto", Country: "Portugal", Nights: 2}
+
+	repo.On("Session", mock.Anything, sessionID).Return(userID, defaultProfileID, nil)
+	repo.On("Cities", mock.Anything, sessionID).Return([]models.TripCity{lisbon, porto}, nil)
+	repo.On("SaveCity", mock.Anything, sessionID, mock.MatchedBy(func(c models.TripCity) bool {
+		return c.Position == 1 && c.City == "Berlin" && c.Country == "" && c.Nights == 4
+	})).Return(nil)
+
+	city, nights := "Berlin", 4
+	trip, err := service.UpdateCity(context.Background(), userID, sessionID, 1, models.UpdateTripCityRequest{
+		City:         &city,
+		Nights:       &nights,
+		Instructions: " winter ",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, planner.requests, 1)
+	req := planner.requests[0]
+	assert.Equal(t, 1, req.Position)
+	assert.Equal(t, "winter", req.Instructions)
+	assert.Equal(t, defaultProfileID, req.ProfileID)
+	assert.Equal(t, "Lisbon", req.Stops[0].City)
+
+	assert.Equal(t, lisbon, trip.Cities[0])
+	assert.Equal(t, "Berlin", trip.Cities[1].City)
+	assert.Equal(t, 7, trip.TotalNights)
+	assert.Equal(t, ModeFlight, trip.Legs[0].Mode)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateCity_OtherUsersTripIsNotFound(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewService(repo, newPlanner(), zap.NewNop())
+
+	repo.On("Session", mock.Anything, sessionID).Return(uuid.New(), defaultProfileID, nil)
+
+	nights := 2
+	_, err := service.UpdateCity(context.Background(), userID, sessionID, 0, models.UpdateTripCityRequest{Nights: &nights})
+	assert.ErrorIs(t, err, models.ErrNotFound)
+
+	_, err = service.Get(context.Background(), userID, sessionID)
+	assert.ErrorIs(t, err, models.ErrNotFound)
+	repo.AssertNotCalled(t, "Cities", mock.Anything, mock.Anything)
+}
+
+func TestUpdateCity_UnknownPosition(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewService(repo, newPlanner(), zap.NewNop())
+
+	repo.On("Session", mock.Anything, sessionID).Return(userID, defaultProfileID, nil)
+	repo.On("Cities", mock.Anything, sessionID).Return([]models.TripCity{
+		{Position: 0, City: "Lisbon", Nights: 3},
+		{Position: 1, City: "Porto", Nights: 2},
+	}, nil)
+
+	_, err := service.UpdateCity(context.Background(), userID, sessionID, 2, models.UpdateTripCityRequest{})
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+// fakeCoster prices every night of a trip at 100 euros.
+type fakeCoster struct {
+	users []uuid.UUID
+}
+
+func (c *fakeCoster) TripCost(_ context.Context, userID uuid.UUID, trip *models.Trip) {
+	c.users = append(c.users, userID)
+	total := float64(100 * trip.TotalNights)
+	trip.Cost = &models.TripCost{Currency: "EUR", Total: models.CostBreakdown{Total: models.CostRange{Min: total, Max: total}}}
+}
+
+func TestGet_EstimatesCost(t *testing.T) {
+	repo := new(MockRepository)
+	coster := &fakeCoster{}
+	service := NewService(repo, newPlanner(), zap.NewNop()).WithCosts(coster)
+
+	repo.On("Session", mock.Anything, sessionID).Return(userID, defaultProfileID, nil)
+	repo.On("Cities", mock.Anything, sessionID).Return([]models.TripCity{
+		{Position: 0, City: "Lisbon", Nights: 3},
+		{Position: 1, City: "Porto", Nights: 2},
+	}, nil)
+
+	trip, err := service.Get(context.Background(), userID, sessionID)
+	require.NoError(t, err)
+	require.NotNil(t, trip.Cost)
+	assert.Equal(t, 500.0, trip.Cost.Total.Total.Max)
+	assert.Equal(t, []uuid.UUID{userID}, coster.users)
+}
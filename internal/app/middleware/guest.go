@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GuestCookieName is the cookie holding the signed guest id of anonymous visitors.
+const GuestCookieName = "guest_id"
+
+const GuestIDKey contextKey = "guestID"
+
+// guestIssuedKey marks requests whose guest id was issued by the request itself.
+const guestIssuedKey = "guestIssued"
+
+// guestCookieMaxAge keeps a guest's free-mode work around long enough to sign up later.
+const guestCookieMaxAge = 30 * 24 * time.Hour
+
+// GuestIdentity ties anonymous visitors to a guest id kept in a cookie signed with an
+// HMAC of the JWT secret, so the id cannot be forged to reach another guest's sessions.
+type GuestIdentity struct {
+	secret []byte
+}
+
+func NewGuestIdentity(secret string) *GuestIdentity {
+	return &GuestIdentity{secret: []byte(secret)}
+}
+
+// Issue puts the guest id of anonymous visitors in the context, giving a new one to those
+// without a valid cookie. Authenticated users are left alone, so it goes after
+// OptionalAuthMiddleware.
+func (g *GuestIdentity) Issue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserFromContext(c) != nil {
+			c.Next()
+			return
+		}
+		guestID, ok := g.read(c)
+		if !ok {
+			guestID = uuid.New()
+			http.SetCookie(c.Writer, &http.Cookie{
+				Name:     GuestCookieName,
+				Value:    g.sign(guestID),
+				MaxAge:   int(guestCookieMaxAge.Seconds()),
+				HttpOnly: true,
+				Secure:   false, // Set to true in production with HTTPS
+				SameSite: http.SameSiteLaxMode,
+				Path:     "/",
+			})
+			c.Set(guestIssuedKey, true)
+		}
+		setGuest(c, guestID)
+		c.Next()
+	}
+}
+
+// Identify puts the guest id of a valid cookie in the context without issuing one, e.g. on
+// sign in and sign up where the guest is about to be claimed.
+func (g *GuestIdentity) Identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if guestID, ok := g.read(c); ok && GetUserFromContext(c) == nil {
+			setGuest(c, guestID)
+		}
+		c.Next()
+	}
+}
+
+func (g *GuestIdentity) read(c *gin.Context) (uuid.UUID, bool) {
+	value, err := c.Cookie(GuestCookieName)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return g.verify(value)
+}
+
+// sign encodes a guest id as <id>.<base64url HMAC-SHA256 of id>.
+func (g *GuestIdentity) sign(guestID uuid.UUID) string {
+	return guestID.String() + "." + base64.RawURLEncoding.EncodeToString(g.mac(guestID.String()))
+}
+
+func (g *GuestIdentity) verify(value string) (uuid.UUID, bool) {
+	id, sig, found := strings.Cut(value, ".")
+	if !found {
+		return uuid.Nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, g.mac(id)) {
+		return uuid.Nil, false
+	}
+	guestID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return guestID, true
+}
+
+func (g *GuestIdentity) mac(id string) []byte {
+	h := hmac.New(sha256.New, g.secret)
+	h.Write([]byte("guest:" + id))
+	return h.Sum(nil)
+}
+
+// setGuest stores the guest id in the gin context and in the request context, which is what
+// services see.
+func setGuest(c *gin.Context, guestID uuid.UUID) {
+	c.Set(string(GuestIDKey), guestID)
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), GuestIDKey, guestID))
+}
+
+// GetGuestFromContext returns the guest id set by GuestIdentity.
+func GetGuestFromContext(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get(string(GuestIDKey))
+	if !exists {
+		return uuid.Nil, false
+	}
+	guestID, ok := value.(uuid.UUID)
+	return guestID, ok
+}
+
+// IsNewGuest reports whether the guest id of the request was issued by this very request.
+// Clients that drop the cookie get a new id every time, so such an id identifies nobody and
+// must not be what limits are counted against.
+func IsNewGuest(c *gin.Context) bool {
+	return c.GetBool(guestIssuedKey)
+}
+
+// GuestIDFromContext returns the guest id of the request a context was derived from.
+func GuestIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	guestID, ok := ctx.Value(GuestIDKey).(uuid.UUID)
+	return guestID, ok
+}
+
+// ClearGuestCookie drops the guest cookie once the guest has become a user.
+func ClearGuestCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     GuestCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
+	})
+}
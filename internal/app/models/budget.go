@@ -0,0 +1,33 @@
+package models
+
+// CostRange is an amount of money known within bounds.
+type CostRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// CostBreakdown splits a cost by what the money goes on.
+type CostBreakdown struct {
+	Food       CostRange `json:"food"`
+	Lodging    CostRange `json:"lodging"`
+	Activities CostRange `json:"activities"`
+	Transport  CostRange `json:"transport"`
+	Total      CostRange `json:"total"`
+}
+
+// DayCost is the cost of one day of a trip.
+type DayCost struct {
+	Day int `json:"day"` // 1-based
+	CostBreakdown
+}
+
+// TripCost is what an itinerary or trip is estimated to cost one traveller, per day and in
+// total, in the traveller's currency.
+type TripCost struct {
+	Currency   string        `json:"currency"`
+	Days       []DayCost     `json:"days"`
+	Total      CostBreakdown `json:"total"`
+	Budget     *float64      `json:"budget,omitempty"` // what the profile's budget level allows, nil without one
+	OverBudget bool          `json:"over_budget"`
+	Rates      string        `json:"rates"` // where the exchange rates came from
+}
@@ -103,6 +103,19 @@ type LlmInteraction struct {
 	StreamChunksCount *int `json:"stream_chunks_count,omitempty" db:"stream_chunks_count"`
 	StreamDurationMs  *int `json:"stream_duration_ms,omitempty" db:"stream_duration_ms"`
 
+	// Prompt template that produced the request, see internal/pkg/prompts
+	PromptID      string `json:"prompt_id,omitempty" db:"prompt_id"`
+	PromptVersion int    `json:"prompt_version,omitempty" db:"prompt_version"`
+
+	// Schema validation of structured responses
+	SchemaName       string          `json:"schema_name,omitempty" db:"schema_name"`
+	SchemaValid      *bool           `json:"schema_valid,omitempty" db:"schema_valid"`
+	ValidationErrors json.RawMessage `json:"validation_errors,omitempty" db:"validation_errors"` // Failed attempts with their errors
+	RepairAttempts   int             `json:"repair_attempts" db:"repair_attempts"`
+
+	// Tool calls made by the chat agent before it answered, see internal/pkg/agent
+	ToolCalls json.RawMessage `json:"tool_calls,omitempty" db:"tool_calls"`
+
 	// Location data (for backward compatibility)
 	Latitude  *float64 `json:"latitude,omitempty" db:"latitude"`
 	Longitude *float64 `json:"longitude,omitempty" db:"longitude"`
@@ -122,6 +135,8 @@ type AIItineraryResponse struct {
 	PointsOfInterest   []POIDetailedInfo `json:"points_of_interest"`
 	Restaurants        []POIDetailedInfo `json:"restaurants,omitempty"`
 	Bars               []POIDetailedInfo `json:"bars,omitempty"`
+	Route              *Route            `json:"route,omitempty"` // legs between the points of interest, in order
+	Cost               *TripCost         `json:"cost,omitempty"`  // estimated in the traveller's currency
 }
 
 type GeneralCityData struct {
@@ -248,6 +263,10 @@ type POIFilter struct {
 	Location GeoPoint `json:"location"` // e.g., "restaurant", "hotel", "bar"
 	Radius   float64  `json:"radius"`   // Radius in kilometers for filtering POIs
 	Category string   `json:"category"` // e.g., "restaurant", "hotel", "bar"
+	// OpenNow and OpenAt keep the POIs open at that moment in their city's time zone.
+	// POIs whose opening hours are unknown are kept.
+	OpenNow bool       `json:"open_now,omitempty"`
+	OpenAt  *time.Time `json:"open_at,omitempty"`
 }
 
 type GeoPoint struct {
@@ -15,6 +15,7 @@ const (
 type ChatSession struct {
 	ID                  uuid.UUID             `json:"id"`
 	UserID              uuid.UUID             `json:"user_id"`
+	GuestID             *uuid.UUID            `json:"guest_id,omitempty"` // free-mode sessions of a visitor without an account
 	ProfileID           uuid.UUID             `json:"profile_id"`
 	CityName            string                `json:"city_name"`
 	CurrentItinerary    *AiCityResponse       `json:"current_itinerary,omitempty"`
@@ -74,6 +75,10 @@ type SessionContext struct {
 	ActiveTags          []string                       `json:"active_tags"`
 	ConversationSummary string                         `json:"conversation_summary"`
 	ModificationHistory []ModificationRecord           `json:"modification_history"`
+	// SummarizedMessages is how many of the oldest ConversationHistory messages are already
+	// folded into ConversationSummary, see chatmemory.Manager
+	SummarizedMessages int      `json:"summarized_messages,omitempty"`
+	RejectedItems      []string `json:"rejected_items,omitempty"` // Places or kinds of places the user asked to remove
 }
 
 type ModificationRecord struct {
@@ -81,6 +86,7 @@ type ModificationRecord struct {
 	Description string    `json:"description"`
 	Timestamp   time.Time `json:"timestamp"`
 	Applied     bool      `json:"applied"`
+	Version     int       `json:"version,omitempty"` // itinerary version the change produced
 }
 
 type SessionStatus string
@@ -238,6 +244,13 @@ const (
 	EventTypeItemAdded       = "item_added"
 	EventTypeItemRemoved     = "item_removed"
 	EventTypeItemUpdated     = "item_updated"
+	EventTypePartRepaired    = "part_repaired"    // A streamed part failed schema validation and was replaced by a repaired response
+	EventTypePartValidated   = "part_validated"   // Internal to the chat service: the schema validation of a generated part, never sent to clients
+	EventTypeRestaurant      = "restaurant"       // One restaurant parsed while its part is still streaming
+	EventTypeHotel           = "hotel"            // One hotel parsed while its part is still streaming
+	EventTypeItemsReconciled = "items_reconciled" // The full parse of a part that streamed items, replacing them
+	EventTypeToolCall        = "tool_call"        // The chat agent called one of its tools
+	EventTypeToolResult      = "tool_result"      // What a tool call returned, or the error it failed with
 )
 
 // StreamingResponse wraps the streaming channel and metadata
@@ -11,4 +11,5 @@ type CityDetail struct {
 	AiSummary       string    `json:"ai_summary"`
 	CenterLatitude  float64   `json:"center_latitude,omitempty"`
 	CenterLongitude float64   `json:"center_longitude,omitempty"`
+	Timezone        string    `json:"timezone,omitempty"` // IANA name, e.g. Europe/Lisbon
 }
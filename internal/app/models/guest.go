@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GuestFavorite is a POI a visitor without an account saved.
+type GuestFavorite struct {
+	POIID   uuid.UUID `json:"poi_id"`
+	IsLLM   bool      `json:"is_llm"` // the POI is an llm_suggested_pois row
+	AddedAt time.Time `json:"added_at"`
+}
+
+// GuestList is a list a visitor without an account started.
+type GuestList struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	ItemCount   int       `json:"item_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GuestSummary is what a guest would bring to a new account.
+type GuestSummary struct {
+	GuestID   uuid.UUID       `json:"guest_id"`
+	Sessions  int             `json:"sessions"`
+	Favorites []GuestFavorite `json:"favorites"`
+	Lists     []GuestList     `json:"lists"`
+}
+
+// CreateGuestListRequest starts a list for a guest.
+type CreateGuestListRequest struct {
+	Name        string `json:"name" form:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" form:"description" binding:"max=500"`
+}
+
+// AddGuestListItemRequest adds a POI, restaurant, hotel or itinerary to a guest list.
+type AddGuestListItemRequest struct {
+	ItemID      uuid.UUID   `json:"item_id" form:"item_id" binding:"required"`
+	ContentType ContentType `json:"content_type" form:"content_type"`
+	Notes       string      `json:"notes" form:"notes"`
+}
+
+// GuestClaim counts what was moved from a guest onto a user.
+type GuestClaim struct {
+	Sessions     int `json:"sessions"`
+	Interactions int `json:"interactions"`
+	Favorites    int `json:"favorites"`
+	Lists        int `json:"lists"`
+}
@@ -90,6 +90,7 @@ type POIDetailedInfo struct {
 	Reviews          []string          `json:"reviews"`
 	LlmInteractionID uuid.UUID         `json:"llm_interaction_id"`
 	Tags             []string          `json:"tags,omitempty"`
+	Setting          string            `json:"setting,omitempty"`  // indoor or outdoor, empty when unknown
 	Priority         int               `json:"priority,omitempty"` // Popularity score 1-10
 	CreatedAt        time.Time         `json:"created_at"`
 	CuisineType      string            `json:"cuisine_type,omitempty"` // For restaurants
@@ -98,7 +99,8 @@ type POIDetailedInfo struct {
 	TimeToSpend      string            `json:"time_to_spend"`
 	Budget           string            `json:"budget"`
 	Err              error             `json:"-"`
-	Source           string            `json:"source,omitempty"` // Source of the POI data (e.g., "google", "yelp", etc.)
+	Source           string            `json:"source,omitempty"`   // Source of the POI data (e.g., "google", "yelp", etc.)
+	OpenNow          *bool             `json:"open_now,omitempty"` // Open at the time searched for, nil when the hours are unknown
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for POIDetailedInfo
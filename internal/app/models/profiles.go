@@ -137,6 +137,7 @@ type UserPreferenceProfileResponse struct {
 	SearchRadiusKm       float64             `json:"search_radius_km"`
 	PreferredTime        DayPreference       `json:"preferred_time"`
 	BudgetLevel          int                 `json:"budget_level"`
+	Currency             string              `json:"currency"` // ISO 4217 code cost estimates are shown in
 	PreferredPace        SearchPace          `json:"preferred_pace"`
 	PreferAccessiblePOIs bool                `json:"prefer_accessible_pois"`
 	PreferOutdoorSeating bool                `json:"prefer_outdoor_seating"`
@@ -165,6 +166,7 @@ type CreateUserPreferenceProfileParams struct {
 	SearchRadiusKm           *float64                  `json:"search_radius_km,omitempty"`
 	PreferredTime            *DayPreference            `json:"preferred_time,omitempty"`
 	BudgetLevel              *int                      `json:"budget_level,omitempty"`
+	Currency                 *string                   `json:"currency,omitempty" binding:"omitempty,iso4217"`
 	PreferredPace            *SearchPace               `json:"preferred_pace,omitempty"`
 	PreferAccessiblePOIs     *bool                     `json:"prefer_accessible_pois,omitempty"`
 	PreferOutdoorSeating     *bool                     `json:"prefer_outdoor_seating,omitempty"`
@@ -238,6 +240,7 @@ type UpdateSearchProfileParams struct {
 	SearchRadiusKm       *float64             `json:"search_radius_km,omitempty"`
 	PreferredTime        *DayPreference       `json:"preferred_time,omitempty"`
 	BudgetLevel          *int                 `json:"budget_level,omitempty"`
+	Currency             *string              `json:"currency,omitempty" binding:"omitempty,iso4217"`
 	PreferredPace        *SearchPace          `json:"preferred_pace,omitempty"`
 	PreferAccessiblePOIs *bool                `json:"prefer_accessible_pois,omitempty"`
 	PreferOutdoorSeating *bool                `json:"prefer_outdoor_seating,omitempty"`
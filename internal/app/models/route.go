@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// RouteLeg is the trip from one stop of a route to the next.
+type RouteLeg struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	DistanceKm float64 `json:"distance_km"`
+	Minutes    float64 `json:"minutes"`
+}
+
+// Route is the way through the stops of an itinerary or of one of its days: Legs[i] goes
+// from stop i to stop i+1. Stops without coordinates are left out.
+type Route struct {
+	Day        int        `json:"day,omitempty"` // 1-based, 0 for a route over a whole list
+	Mode       string     `json:"mode"`          // walk, public or car
+	Provider   string     `json:"provider"`      // what estimated the legs, e.g. haversine
+	Legs       []RouteLeg `json:"legs"`
+	DistanceKm float64    `json:"distance_km"`
+	Minutes    float64    `json:"minutes"`
+}
+
+// ListRoute is the route of a list, one per day once the list is scheduled.
+type ListRoute struct {
+	ListID uuid.UUID `json:"list_id"`
+	Routes []Route   `json:"routes"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of schedule slots.
+const (
+	SlotKindVisit = "visit"
+	SlotKindMeal  = "meal"
+)
+
+// Kinds of schedule conflicts.
+const (
+	ConflictClosed     = "closed"     // the place is closed during its slot
+	ConflictOverlap    = "overlap"    // two slots share the same time
+	ConflictOverbooked = "overbooked" // a day holds more than the pace allows
+	ConflictWeather    = "weather"    // an outdoor place on a day rain, snow or storms are forecast
+)
+
+// ScheduleSlot is a list item placed at a day and time of an itinerary.
+type ScheduleSlot struct {
+	ItemID          uuid.UUID   `json:"item_id"`
+	ContentType     ContentType `json:"content_type"`
+	Name            string      `json:"name"`
+	Category        string      `json:"category,omitempty"`
+	Day             int         `json:"day"` // 1-based
+	Start           time.Time   `json:"start"`
+	End             time.Time   `json:"end"`
+	DurationMinutes int         `json:"duration_minutes"`
+	Kind            string      `json:"kind"`              // visit or meal
+	Meal            string      `json:"meal,omitempty"`    // lunch or dinner, for meals
+	Pinned          bool        `json:"pinned"`            // placed by the user, kept by re-planning
+	Setting         string      `json:"setting,omitempty"` // indoor or outdoor, empty when unknown
+}
+
+// ScheduleConflict is something wrong with a schedule. ItemID is nil for conflicts about a
+// whole day.
+type ScheduleConflict struct {
+	Type    string     `json:"type"`
+	Day     int        `json:"day"`
+	ItemID  *uuid.UUID `json:"item_id,omitempty"`
+	Message string     `json:"message"`
+}
+
+// ScheduleDay is one day of a schedule with its slots in time order.
+type ScheduleDay struct {
+	Day           int            `json:"day"`
+	Date          time.Time      `json:"date"`
+	Slots         []ScheduleSlot `json:"slots"`
+	BookedMinutes int            `json:"booked_minutes"`
+	Route         *Route         `json:"route,omitempty"`    // legs between the slots, without routing nil
+	Forecast      *Forecast      `json:"forecast,omitempty"` // nil without a forecast for the date
+}
+
+// Schedule splits the items of an itinerary list into days and timed slots.
+type Schedule struct {
+	ListID    uuid.UUID          `json:"list_id"`
+	StartDate time.Time          `json:"start_date"`
+	Days      []ScheduleDay      `json:"days"`
+	Conflicts []ScheduleConflict `json:"conflicts"`
+	Swaps     []WeatherSwap      `json:"swaps"` // moves that keep outdoor stops off wet days
+}
+
+// BuildScheduleRequest asks for the items of a list to be scheduled over a number of days.
+// Pinned items keep their day and time.
+type BuildScheduleRequest struct {
+	Days           int    `json:"days" binding:"required,min=1,max=30"`
+	StartDate      string `json:"start_date,omitempty"`       // YYYY-MM-DD, tomorrow when empty
+	AdaptToWeather bool   `json:"adapt_to_weather,omitempty"` // apply the weather swaps instead of only suggesting them
+}
+
+// MoveScheduleItemRequest pins an item to a day and time, or unpins it, and re-plans the
+// rest of the schedule around it.
+type MoveScheduleItemRequest struct {
+	Day    int    `json:"day,omitempty" binding:"omitempty,min=1"`
+	Time   string `json:"time,omitempty"` // HH:MM
+	Pinned *bool  `json:"pinned,omitempty"`
+}
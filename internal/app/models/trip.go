@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TripStop is one city of a multi-city trip, in travel order.
+type TripStop struct {
+	City    string `json:"city" binding:"required"`
+	Country string `json:"country,omitempty"`
+	Nights  int    `json:"nights" binding:"required,min=1"`
+}
+
+// TripCity is a stop of a trip with the itinerary generated for it.
+type TripCity struct {
+	Position         int                  `json:"position"` // 0-based travel order
+	City             string               `json:"city"`
+	Country          string               `json:"country,omitempty"`
+	CityID           *uuid.UUID           `json:"city_id,omitempty"` // nil while the city is not stored
+	Nights           int                  `json:"nights"`
+	Latitude         float64              `json:"latitude,omitempty"`
+	Longitude        float64              `json:"longitude,omitempty"`
+	Itinerary        *AIItineraryResponse `json:"itinerary,omitempty"`
+	LlmInteractionID *uuid.UUID           `json:"llm_interaction_id,omitempty"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// TransferLeg is the journey between two consecutive cities of a trip.
+type TransferLeg struct {
+	From            string  `json:"from"`
+	To              string  `json:"to"`
+	DistanceKm      float64 `json:"distance_km"`
+	Mode            string  `json:"mode"` // train, flight, or unknown when a city has no coordinates
+	DurationMinutes int     `json:"duration_minutes,omitempty"`
+}
+
+// Trip is a multi-city plan. It belongs to a chat session; the session's CityName is the
+// first city of the trip.
+type Trip struct {
+	SessionID   uuid.UUID     `json:"session_id"`
+	Cities      []TripCity    `json:"cities"`
+	Legs        []TransferLeg `json:"legs"`
+	TotalNights int           `json:"total_nights"`
+	Cost        *TripCost     `json:"cost,omitempty"` // estimated in the traveller's currency
+}
+
+// PlanTripRequest asks for a new multi-city trip.
+type PlanTripRequest struct {
+	ProfileID *uuid.UUID `json:"profile_id,omitempty"` // the user's default profile when nil
+	Cities    []TripStop `json:"cities" binding:"required,min=2,dive"`
+}
+
+// UpdateTripCityRequest changes one city of a trip. Only that city is regenerated; fields
+// left nil keep their value.
+type UpdateTripCityRequest struct {
+	City         *string `json:"city,omitempty"`
+	Country      *string `json:"country,omitempty"`
+	Nights       *int    `json:"nights,omitempty" binding:"omitempty,min=1"`
+	Instructions string  `json:"instructions,omitempty"` // e.g. "more food markets, no museums"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Weather conditions of a forecast.
+const (
+	ConditionClear  = "clear"
+	ConditionCloudy = "cloudy"
+	ConditionFog    = "fog"
+	ConditionRain   = "rain"
+	ConditionSnow   = "snow"
+	ConditionStorm  = "storm"
+)
+
+// Settings of a place: whether it is visited indoors or outdoors.
+const (
+	SettingIndoor  = "indoor"
+	SettingOutdoor = "outdoor"
+)
+
+// Forecast is the weather expected on a day.
+type Forecast struct {
+	Date                time.Time `json:"date"`
+	Condition           string    `json:"condition"`
+	Summary             string    `json:"summary,omitempty"`
+	TempMinC            float64   `json:"temp_min_c"`
+	TempMaxC            float64   `json:"temp_max_c"`
+	PrecipitationMm     float64   `json:"precipitation_mm"`
+	PrecipitationChance int       `json:"precipitation_chance"` // percent, 0 when unknown
+	Wet                 bool      `json:"wet"`                  // rain, snow or storms likely, outdoor stops are better moved
+}
+
+// WeatherSwap exchanges an outdoor stop on a wet day with an indoor stop on a dry one.
+type WeatherSwap struct {
+	Day         int       `json:"day"` // the wet day
+	ItemID      uuid.UUID `json:"item_id"`
+	Name        string    `json:"name"`
+	SwapDay     int       `json:"swap_day"`
+	SwapItemID  uuid.UUID `json:"swap_item_id"`
+	SwapName    string    `json:"swap_name"`
+	Applied     bool      `json:"applied"` // false for suggestions the user can make with a move
+	Description string    `json:"description"`
+}
@@ -0,0 +1,333 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReplayCapacity is how many events of a session are kept for clients that reconnect.
+	DefaultReplayCapacity = 512
+	// DefaultReplayTTL is how long a session's events are kept after its last event.
+	DefaultReplayTTL = 10 * time.Minute
+)
+
+// ErrReplayGap is returned by Follow when events after the client's last event were already
+// evicted from the buffer; the client has to start over.
+var ErrReplayGap = errors.New("events after the last event id are no longer buffered")
+
+// Sequenced is an event with its sequence id within the stream.
+type Sequenced[T any] struct {
+	Seq   uint64
+	Event T
+}
+
+// ReplayBuffer keeps the last events of a stream with sequence ids, so a client that drops
+// mid-generation can reconnect with Last-Event-ID, get the events it missed and follow the
+// rest live. Producers Append, clients Follow.
+type ReplayBuffer[T any] struct {
+	key      string
+	session  string
+	owner    string
+	capacity int
+
+	mu        sync.Mutex
+	events    []Sequenced[T] // oldest first, at most capacity
+	lastSeq   uint64
+	closed    bool
+	wake      chan struct{} // closed and replaced on every Append and on Close
+	updatedAt time.Time
+
+	followers int
+	grace     time.Duration
+	onAbandon func()
+	idle      *time.Timer
+}
+
+func newReplayBuffer[T any](session, owner string, capacity int) *ReplayBuffer[T] {
+	return &ReplayBuffer[T]{
+		key:       session + "." + newStreamNonce(),
+		session:   session,
+		owner:     owner,
+		capacity:  capacity,
+		wake:      make(chan struct{}),
+		updatedAt: time.Now(),
+	}
+}
+
+// Key identifies the stream, <session>.<nonce>. Every stream of a session gets its own nonce
+// so that the sequence ids of a newer stream, which start at 1 again, are never mistaken for
+// those of the one a client last saw.
+func (b *ReplayBuffer[T]) Key() string { return b.key }
+
+// Session is the session the stream belongs to.
+func (b *ReplayBuffer[T]) Session() string { return b.session }
+
+// Owner is the user that started the stream, empty for anonymous streams.
+func (b *ReplayBuffer[T]) Owner() string { return b.owner }
+
+// EventID is the SSE id of the event with the given sequence id.
+func (b *ReplayBuffer[T]) EventID(seq uint64) string { return FormatEventID(b.key, seq) }
+
+// Append gives the event the next sequence id and keeps it, evicting the oldest event when the
+// buffer is full. Events appended after Close are dropped.
+func (b *ReplayBuffer[T]) Append(event T) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return 0
+	}
+	b.lastSeq++
+	if len(b.events) == b.capacity {
+		b.events = append(b.events[:0], b.events[1:]...)
+	}
+	b.events = append(b.events, Sequenced[T]{Seq: b.lastSeq, Event: event})
+	b.updatedAt = time.Now()
+	b.notifyLocked()
+	return b.lastSeq
+}
+
+// Close marks the stream finished. Followers get the remaining events and return.
+func (b *ReplayBuffer[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.updatedAt = time.Now()
+	if b.idle != nil {
+		b.idle.Stop()
+	}
+	b.notifyLocked()
+}
+
+// Closed reports whether the stream has finished.
+func (b *ReplayBuffer[T]) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// OnAbandon calls fn once the open stream has had no follower for grace, so that producers
+// stop generating for a client that is not coming back.
+func (b *ReplayBuffer[T]) OnAbandon(grace time.Duration, fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.grace, b.onAbandon = grace, fn
+}
+
+// Follow calls fn with every event after lastSeq, oldest first, then with every event appended
+// until the stream is closed, ctx is done or fn fails. lastSeq 0 starts from the beginning.
+func (b *ReplayBuffer[T]) Follow(ctx context.Context, lastSeq uint64, fn func(Sequenced[T]) error) error {
+	b.attach()
+	defer b.detach()
+
+	cursor := lastSeq
+	for {
+		b.mu.Lock()
+		if len(b.events) > 0 && cursor+1 < b.events[0].Seq {
+			b.mu.Unlock()
+			return ErrReplayGap
+		}
+		var pending []Sequenced[T]
+		for _, e := range b.events {
+			if e.Seq > cursor {
+				pending = append(pending, e)
+			}
+		}
+		closed, wake := b.closed, b.wake
+		b.mu.Unlock()
+
+		for _, e := range pending {
+			if err := fn(e); err != nil {
+				return err
+			}
+			cursor = e.Seq
+		}
+		if closed {
+			return nil
+		}
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *ReplayBuffer[T]) attach() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.followers++
+	if b.idle != nil {
+		b.idle.Stop()
+		b.idle = nil
+	}
+}
+
+func (b *ReplayBuffer[T]) detach() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.followers--
+	if b.followers > 0 || b.closed || b.onAbandon == nil {
+		return
+	}
+	b.idle = time.AfterFunc(b.grace, func() {
+		b.mu.Lock()
+		abandoned := b.followers == 0 && !b.closed
+		b.mu.Unlock()
+		if abandoned {
+			b.onAbandon()
+		}
+	})
+}
+
+func (b *ReplayBuffer[T]) notifyLocked() {
+	close(b.wake)
+	b.wake = make(chan struct{})
+}
+
+// ReplayStore holds the replay buffer of the latest stream of each session streaming one kind
+// of event.
+type ReplayStore[T any] struct {
+	capacity int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	buffers map[string]*ReplayBuffer[T] // by session
+}
+
+// NewReplayStore keeps up to capacity events per session, for ttl after the session's last event.
+func NewReplayStore[T any](capacity int, ttl time.Duration) *ReplayStore[T] {
+	return &ReplayStore[T]{
+		capacity: capacity,
+		ttl:      ttl,
+		buffers:  make(map[string]*ReplayBuffer[T]),
+	}
+}
+
+// Open starts a new stream for the session, closing the one a previous stream left behind.
+func (s *ReplayStore[T]) Open(session, owner string) *ReplayBuffer[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweepLocked()
+	if previous, ok := s.buffers[session]; ok {
+		previous.Close()
+	}
+	b := newReplayBuffer[T](session, owner, s.capacity)
+	s.buffers[session] = b
+	return b
+}
+
+// Get returns the buffer of the latest stream of the session, if it has not expired.
+func (s *ReplayStore[T]) Get(session string) (*ReplayBuffer[T], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buffers[session]
+	if !ok || s.expired(b) {
+		return nil, false
+	}
+	return b, true
+}
+
+// Resume returns the buffer of the stream with the given key, as read from a Last-Event-ID.
+// Once the session has started another stream the key no longer resumes anything.
+func (s *ReplayStore[T]) Resume(key string) (*ReplayBuffer[T], bool) {
+	i := strings.LastIndexByte(key, '.')
+	if i <= 0 {
+		return nil, false
+	}
+	b, ok := s.Get(key[:i])
+	if !ok || b.key != key {
+		return nil, false
+	}
+	return b, true
+}
+
+// sweepLocked drops the buffers of sessions that have been quiet for longer than the ttl.
+func (s *ReplayStore[T]) sweepLocked() {
+	for key, b := range s.buffers {
+		if s.expired(b) {
+			delete(s.buffers, key)
+		}
+	}
+}
+
+func (s *ReplayStore[T]) expired(b *ReplayBuffer[T]) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Since(b.updatedAt) > s.ttl
+}
+
+// newStreamNonce tells apart the streams of a session. It is random rather than counted so that
+// ids handed out before a restart do not match the streams started after it.
+func newStreamNonce() string {
+	return strings.ToLower(rand.Text()[:10])
+}
+
+// FormatEventID builds the SSE id of an event: the stream key and the sequence id within it, so
+// a reconnecting client names the stream it resumes without any other parameter.
+func FormatEventID(key string, seq uint64) string {
+	return key + ":" + strconv.FormatUint(seq, 10)
+}
+
+// ParseEventID splits an id built by FormatEventID.
+func ParseEventID(id string) (key string, seq uint64, ok bool) {
+	i := strings.LastIndexByte(id, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+// LastEventID reads the id of the last event a reconnecting client received. EventSource sends
+// it in the Last-Event-ID header; clients that cannot set headers pass last_event_id.
+func LastEventID(r *http.Request) (key string, seq uint64, ok bool) {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		id = r.URL.Query().Get("last_event_id")
+	}
+	if id == "" {
+		return "", 0, false
+	}
+	return ParseEventID(id)
+}
+
+// WriteSSE writes one SSE frame. id and event are left out when empty; data spanning several
+// lines is sent as several data fields.
+func WriteSSE(w io.Writer, id, event string, data []byte) error {
+	var frame bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&frame, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&frame, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&frame, "data: %s\n", line)
+	}
+	frame.WriteString("\n")
+	_, err := w.Write(frame.Bytes())
+	return err
+}
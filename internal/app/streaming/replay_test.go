@@ -0,0 +1,175 @@
+package streaming
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func follow(t *testing.T, b *ReplayBuffer[string], lastSeq uint64) ([]string, []uint64, error) {
+	t.Helper()
+	var events []string
+	var seqs []uint64
+	err := b.Follow(context.Background(), lastSeq, func(e Sequenced[string]) error {
+		events = append(events, e.Event)
+		seqs = append(seqs, e.Seq)
+		return nil
+	})
+	return events, seqs, err
+}
+
+func TestFollow_ResumesAfterLastSeqThenFollowsLive(t *testing.T) {
+	store := NewReplayStore[string](10, time.Minute)
+	b := store.Open("session", "user")
+	b.Append("a")
+	b.Append("b")
+
+	done := make(chan []string)
+	go func() {
+		events, _, err := follow(t, b, 1)
+		assert.NoError(t, err)
+		done <- events
+	}()
+
+	b.Append("c")
+	b.Close()
+	assert.Equal(t, []string{"b", "c"}, <-done)
+
+	// A finished stream can still be resumed
+	events, seqs, err := follow(t, b, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, events)
+	assert.Equal(t, []uint64{3}, seqs)
+}
+
+func TestFollow_GapWhenMissedEventsWereEvicted(t *testing.T) {
+	b := NewReplayStore[string](2, time.Minute).Open("session", "")
+	for _, e := range []string{"a", "b", "c", "d"} {
+		b.Append(e)
+	}
+	b.Close()
+
+	_, _, err := follow(t, b, 1)
+	assert.ErrorIs(t, err, ErrReplayGap)
+
+	events, _, err := follow(t, b, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, events)
+}
+
+func TestOnAbandon(t *testing.T) {
+	b := NewReplayStore[string](10, time.Minute).Open("session", "")
+	var abandoned atomic.Int32
+	b.OnAbandon(20*time.Millisecond, func() { abandoned.Add(1) })
+
+	// The client drops and comes back within the grace period
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, b.Follow(ctx, 0, func(Sequenced[string]) error { return nil }), context.Canceled)
+
+	resumed := make(chan error)
+	go func() { resumed <- b.Follow(context.Background(), 0, func(Sequenced[string]) error { return nil }) }()
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, abandoned.Load())
+	b.Close()
+	require.NoError(t, <-resumed)
+
+	// Nobody comes back to an open stream
+	b = NewReplayStore[string](10, time.Minute).Open("other", "")
+	b.OnAbandon(10*time.Millisecond, func() { abandoned.Add(1) })
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_ = b.Follow(ctx, 0, func(Sequenced[string]) error { return nil })
+	assert.Eventually(t, func() bool { return abandoned.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestReplayStore_OpenReplacesAndExpires(t *testing.T) {
+	store := NewReplayStore[string](10, 20*time.Millisecond)
+	first := store.Open("session", "")
+	second := store.Open("session", "")
+	assert.True(t, first.Closed())
+
+	got, ok := store.Get("session")
+	require.True(t, ok)
+	assert.Same(t, second, got)
+
+	time.Sleep(30 * time.Millisecond)
+	_, ok = store.Get("session")
+	assert.False(t, ok)
+}
+
+func TestReplayStore_ResumeOnlyTheStreamTheClientSaw(t *testing.T) {
+	store := NewReplayStore[string](10, time.Minute)
+	first := store.Open("session", "")
+	first.Append("a")
+	first.Append("b")
+	staleID := first.EventID(1)
+
+	second := store.Open("session", "")
+	for _, e := range []string{"x", "y", "z"} {
+		second.Append(e)
+	}
+	assert.NotEqual(t, first.Key(), second.Key())
+	assert.Equal(t, "session", second.Session())
+
+	// The newer stream restarts at 1, the stale id must not skip its first event
+	key, _, ok := ParseEventID(staleID)
+	require.True(t, ok)
+	_, ok = store.Resume(key)
+	assert.False(t, ok)
+
+	key, seq, ok := ParseEventID(second.EventID(1))
+	require.True(t, ok)
+	got, ok := store.Resume(key)
+	require.True(t, ok)
+	assert.Same(t, second, got)
+	second.Close()
+	events, _, err := follow(t, got, seq)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"y", "z"}, events)
+
+	for _, bad := range []string{"", "session", ".nonce", "other." + second.Key()} {
+		_, ok := store.Resume(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestEventIDs(t *testing.T) {
+	id := FormatEventID("3f2a:session", 42)
+	key, seq, ok := ParseEventID(id)
+	require.True(t, ok)
+	assert.Equal(t, "3f2a:session", key)
+	assert.Equal(t, uint64(42), seq)
+
+	for _, bad := range []string{"", "42", ":42", "session:", "session:x"} {
+		_, _, ok := ParseEventID(bad)
+		assert.False(t, ok, bad)
+	}
+
+	r := httptest.NewRequest("GET", "/chat/stream?last_event_id=s:3", nil)
+	key, seq, ok = LastEventID(r)
+	require.True(t, ok)
+	assert.Equal(t, "s", key)
+	assert.Equal(t, uint64(3), seq)
+
+	r.Header.Set("Last-Event-ID", "h:7")
+	key, seq, _ = LastEventID(r)
+	assert.Equal(t, "h", key)
+	assert.Equal(t, uint64(7), seq)
+}
+
+func TestWriteSSE(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteSSE(&buf, "s:1", "itinerary-progress", []byte("<p>\nhi</p>")))
+	assert.Equal(t, "id: s:1\nevent: itinerary-progress\ndata: <p>\ndata: hi</p>\n\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteSSE(&buf, "", "", []byte(`{"type":"chunk"}`)))
+	assert.Equal(t, "data: {\"type\":\"chunk\"}\n\n", buf.String())
+}
@@ -69,6 +69,7 @@ type StreamChannel struct {
 // StreamManager manages all active streaming sessions
 type StreamManager struct {
 	channels map[string]*StreamChannel
+	replays  *ReplayStore[UnifiedStreamEvent]
 	mutex    sync.RWMutex
 }
 
@@ -76,10 +77,12 @@ type StreamManager struct {
 func NewStreamManager() *StreamManager {
 	return &StreamManager{
 		channels: make(map[string]*StreamChannel),
+		replays:  NewReplayStore[UnifiedStreamEvent](DefaultReplayCapacity, DefaultReplayTTL),
 	}
 }
 
-// CreateStream creates a new streaming channel for a session
+// CreateStream creates a new streaming channel for a session. Events sent on it are kept in the
+// session's replay buffer, which clients read through Replay.
 func (sm *StreamManager) CreateStream(sessionID string, requestType RequestType) chan UnifiedStreamEvent {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
@@ -92,10 +95,24 @@ func (sm *StreamManager) CreateStream(sessionID string, requestType RequestType)
 		SessionID:   sessionID,
 	}
 
+	replay := sm.replays.Open(sessionID, "")
+	go func() {
+		defer replay.Close()
+		for event := range ch {
+			replay.Append(event)
+		}
+	}()
+
 	return ch
 }
 
-// GetStream retrieves a streaming channel for a session
+// Replay returns the replay buffer of a session, which outlives its channel so that clients
+// can still resume a stream that just finished.
+func (sm *StreamManager) Replay(sessionID string) (*ReplayBuffer[UnifiedStreamEvent], bool) {
+	return sm.replays.Get(sessionID)
+}
+
+// GetStream retrieves the producer side of a session's streaming channel
 func (sm *StreamManager) GetStream(sessionID string) (chan UnifiedStreamEvent, bool) {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Track JSON schema validation of structured LLM responses and the repair re-prompts
+-- needed to make them valid
+ALTER TABLE llm_interactions
+    ADD COLUMN IF NOT EXISTS schema_name VARCHAR(100), -- e.g., 'itinerary', 'restaurants', 'hotels', 'activities', 'city_data'
+    ADD COLUMN IF NOT EXISTS schema_valid BOOLEAN, -- NULL when the response was not validated
+    ADD COLUMN IF NOT EXISTS validation_errors JSONB, -- [{"attempt": 0, "errors": ["/points_of_interest/0: missing required property \"latitude\""]}]
+    ADD COLUMN IF NOT EXISTS repair_attempts INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_llm_interactions_schema_valid ON llm_interactions(schema_name, schema_valid, created_at DESC)
+    WHERE schema_name IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_llm_interactions_schema_valid;
+
+ALTER TABLE llm_interactions
+    DROP COLUMN IF EXISTS repair_attempts,
+    DROP COLUMN IF EXISTS validation_errors,
+    DROP COLUMN IF EXISTS schema_valid,
+    DROP COLUMN IF EXISTS schema_name;
@@ -0,0 +1,48 @@
+-- +goose Up
+-- Record which prompt template (internal/pkg/prompts) and version produced each interaction
+-- so two versions of a template can be compared while traffic is split between them
+ALTER TABLE llm_interactions
+    ADD COLUMN IF NOT EXISTS prompt_id VARCHAR(100), -- e.g., 'personalized_itinerary', 'dining', 'nearby_pois'
+    ADD COLUMN IF NOT EXISTS prompt_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_llm_interactions_prompt_version ON llm_interactions(prompt_id, prompt_version, created_at DESC)
+    WHERE prompt_id IS NOT NULL;
+
+COMMENT ON COLUMN llm_interactions.prompt_id IS 'Prompt template id the prompt was rendered from';
+COMMENT ON COLUMN llm_interactions.prompt_version IS 'Version of the prompt template';
+
+-- Parse success and engagement per template version. A response counts as engaged when the
+-- user saved the itinerary or added one of its items to a list.
+CREATE OR REPLACE VIEW llm_prompt_version_stats AS
+SELECT
+    li.prompt_id,
+    li.prompt_version,
+    COUNT(*) AS interactions,
+    COUNT(*) FILTER (WHERE li.status_code = 200) AS successful,
+    COUNT(li.schema_valid) AS validated,
+    COUNT(*) FILTER (WHERE li.schema_valid) AS schema_valid,
+    ROUND(COUNT(*) FILTER (WHERE li.schema_valid)::NUMERIC / NULLIF(COUNT(li.schema_valid), 0), 4) AS parse_success_rate,
+    ROUND(AVG(li.repair_attempts), 2) AS avg_repair_attempts,
+    ROUND(AVG(li.latency_ms)) AS avg_latency_ms,
+    ROUND(AVG(li.user_feedback_rating), 2) AS avg_feedback_rating,
+    COUNT(*) FILTER (WHERE engaged.llm_interaction_id IS NOT NULL) AS engaged,
+    ROUND(COUNT(*) FILTER (WHERE engaged.llm_interaction_id IS NOT NULL)::NUMERIC / COUNT(*), 4) AS engagement_rate,
+    MIN(li.created_at) AS first_seen,
+    MAX(li.created_at) AS last_seen
+FROM llm_interactions li
+LEFT JOIN (
+    SELECT source_llm_interaction_id AS llm_interaction_id FROM user_saved_itineraries WHERE source_llm_interaction_id IS NOT NULL
+    UNION
+    SELECT source_llm_interaction_id FROM list_items WHERE source_llm_interaction_id IS NOT NULL
+) engaged ON engaged.llm_interaction_id = li.id
+WHERE li.prompt_id IS NOT NULL
+GROUP BY li.prompt_id, li.prompt_version;
+
+-- +goose Down
+DROP VIEW IF EXISTS llm_prompt_version_stats;
+
+DROP INDEX IF EXISTS idx_llm_interactions_prompt_version;
+
+ALTER TABLE llm_interactions
+    DROP COLUMN IF EXISTS prompt_version,
+    DROP COLUMN IF EXISTS prompt_id;
@@ -0,0 +1,10 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- The pricing page sells Free, Explorer and Pro. The original enum only knew 'premium_*',
+-- which the quota service treats as Pro.
+ALTER TYPE subscription_plan_type ADD VALUE IF NOT EXISTS 'explorer';
+ALTER TYPE subscription_plan_type ADD VALUE IF NOT EXISTS 'pro';
+
+-- +goose Down
+-- Postgres cannot drop enum values; move rows back to the legacy plans instead
+UPDATE subscriptions SET plan = 'premium_monthly' WHERE plan IN ('explorer', 'pro');
@@ -0,0 +1,67 @@
+-- +goose Up
+-- Cost ledger over llm_interactions: hourly spend per user, intent and model. Daily figures
+-- are rolled up from the hourly buckets at query time.
+-- With TimescaleDB this is a real-time continuous aggregate (materialised buckets plus the
+-- rows not yet materialised); without it, a plain view over the same columns.
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+    CREATE MATERIALIZED VIEW IF NOT EXISTS llm_cost_hourly
+        WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+    SELECT
+        time_bucket('1 hour', created_at) AS hour,
+        user_id,
+        COALESCE(intent, 'unknown') AS intent,
+        COALESCE(model_name, 'unknown') AS model_name,
+        COUNT(*) AS requests,
+        COUNT(*) FILTER (WHERE status_code = 200) AS successful_requests,
+        COUNT(*) FILTER (WHERE cache_hit = TRUE) AS cache_hits,
+        COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+        COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+        COALESCE(SUM(total_tokens), 0) AS total_tokens,
+        COALESCE(SUM(cost_estimate_usd), 0) AS cost_usd
+    FROM llm_interactions
+    GROUP BY hour, user_id, COALESCE(intent, 'unknown'), COALESCE(model_name, 'unknown')
+    WITH NO DATA;
+
+    PERFORM add_continuous_aggregate_policy('llm_cost_hourly',
+        start_offset => INTERVAL '3 days',
+        end_offset => INTERVAL '1 hour',
+        schedule_interval => INTERVAL '30 minutes');
+EXCEPTION
+    WHEN OTHERS THEN
+        RAISE NOTICE 'TimescaleDB continuous aggregate llm_cost_hourly not available (%), creating a plain view', SQLERRM;
+        CREATE OR REPLACE VIEW llm_cost_hourly AS
+        SELECT
+            date_trunc('hour', created_at) AS hour,
+            user_id,
+            COALESCE(intent, 'unknown') AS intent,
+            COALESCE(model_name, 'unknown') AS model_name,
+            COUNT(*) AS requests,
+            COUNT(*) FILTER (WHERE status_code = 200) AS successful_requests,
+            COUNT(*) FILTER (WHERE cache_hit = TRUE) AS cache_hits,
+            COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens,
+            COALESCE(SUM(completion_tokens), 0) AS completion_tokens,
+            COALESCE(SUM(total_tokens), 0) AS total_tokens,
+            COALESCE(SUM(cost_estimate_usd), 0) AS cost_usd
+        FROM llm_interactions
+        GROUP BY 1, 2, 3, 4;
+END $$;
+-- +goose StatementEnd
+
+-- Budget checks sum today's spend per user straight from the table
+CREATE INDEX IF NOT EXISTS idx_llm_interactions_user_created ON llm_interactions(user_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_llm_interactions_user_created;
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+    DROP MATERIALIZED VIEW IF EXISTS llm_cost_hourly;
+EXCEPTION
+    WHEN wrong_object_type THEN
+        DROP VIEW IF EXISTS llm_cost_hourly;
+END $$;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Stream events that could not be delivered to the client (disconnect, slow consumer). They
+-- are re-delivered when the client reconnects to the session, or replayed by an admin.
+CREATE TABLE stream_dead_letters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id UUID NULL,  -- chat session the event belongs to; free sessions are not persisted, so no FK
+    user_id UUID NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    reason TEXT NOT NULL,  -- e.g. 'context_cancelled', 'timeout'
+    payload JSONB NOT NULL, -- the models.StreamEvent as sent over SSE
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'discarded')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ NULL,
+    -- An event retried several times before giving up is stored once
+    CONSTRAINT unique_stream_dead_letter_event UNIQUE (session_id, event_id)
+);
+
+-- Re-delivery on reconnect reads the pending events of a session in order
+CREATE INDEX idx_stream_dead_letters_session_pending ON stream_dead_letters(session_id, created_at) WHERE status = 'pending';
+CREATE INDEX idx_stream_dead_letters_status_created ON stream_dead_letters(status, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_stream_dead_letters_status_created;
+DROP INDEX IF EXISTS idx_stream_dead_letters_session_pending;
+DROP TABLE IF EXISTS stream_dead_letters;
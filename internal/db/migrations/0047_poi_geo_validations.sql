@@ -0,0 +1,43 @@
+-- +goose Up
+-- Outcome of the geographic checks run on every batch of LLM-generated POIs before it is saved:
+-- coordinates outside the city's bounding box, too far from its centre, invalid or repeated.
+CREATE TABLE poi_geo_validations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    llm_interaction_id UUID NULL, -- no FK: llm_interactions may be a hypertable
+    city_id UUID NULL REFERENCES cities(id) ON DELETE CASCADE,
+    part TEXT NOT NULL, -- response part the POIs came from, e.g. 'general_pois', 'itinerary', 'poi_details'
+    total INTEGER NOT NULL,
+    accepted INTEGER NOT NULL, -- passed every check
+    flagged INTEGER NOT NULL, -- failed a check but were kept
+    dropped INTEGER NOT NULL,
+    regeocoded INTEGER NOT NULL, -- kept with coordinates found again
+    reasons JSONB NOT NULL DEFAULT '{}', -- failed checks by reason
+    rejected_pois JSONB NOT NULL DEFAULT '[]', -- name, reasons and action of every POI that failed a check
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_poi_geo_validations_interaction ON poi_geo_validations(llm_interaction_id) WHERE llm_interaction_id IS NOT NULL;
+CREATE INDEX idx_poi_geo_validations_created ON poi_geo_validations(created_at DESC);
+
+-- Share of POIs failing a check per prompt template version. Batches without an interaction
+-- are reported under their part.
+CREATE OR REPLACE VIEW poi_geo_rejection_rates AS
+SELECT
+    COALESCE(li.prompt_id, v.part) AS prompt_id,
+    li.prompt_version,
+    COUNT(*) AS batches,
+    SUM(v.total) AS total,
+    SUM(v.flagged) AS flagged,
+    SUM(v.dropped) AS dropped,
+    SUM(v.regeocoded) AS regeocoded,
+    ROUND(SUM(v.total - v.accepted)::NUMERIC / NULLIF(SUM(v.total), 0), 4) AS rejection_rate,
+    MAX(v.created_at) AS last_seen
+FROM poi_geo_validations v
+LEFT JOIN llm_interactions li ON li.id = v.llm_interaction_id
+GROUP BY COALESCE(li.prompt_id, v.part), li.prompt_version;
+
+-- +goose Down
+DROP VIEW IF EXISTS poi_geo_rejection_rates;
+DROP INDEX IF EXISTS idx_poi_geo_validations_created;
+DROP INDEX IF EXISTS idx_poi_geo_validations_interaction;
+DROP TABLE IF EXISTS poi_geo_validations;
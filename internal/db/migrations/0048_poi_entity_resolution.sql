@@ -0,0 +1,39 @@
+-- +goose Up
+-- Other names a POI is known by, so LLM output naming a place differently ("Belem Tower" for
+-- "Torre de Belém") resolves to the existing record. Names are stored normalised: lower case,
+-- without accents or punctuation.
+CREATE TABLE poi_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    poi_id UUID NOT NULL REFERENCES points_of_interest(id) ON DELETE CASCADE,
+    city_id UUID NOT NULL REFERENCES cities(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL, -- name as it was seen
+    alias_normalized TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('resolved', 'merged', 'manual')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_poi_alias_per_city UNIQUE (city_id, alias_normalized)
+);
+
+CREATE INDEX idx_poi_aliases_poi_id ON poi_aliases(poi_id);
+
+-- Audit of duplicate POIs folded into a canonical record. The duplicate row is deleted, so it
+-- is not referenced.
+CREATE TABLE poi_merges (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    canonical_poi_id UUID NOT NULL REFERENCES points_of_interest(id) ON DELETE CASCADE,
+    merged_poi_id UUID NOT NULL,
+    merged_name TEXT NOT NULL,
+    score NUMERIC(5, 4) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_poi_merges_canonical ON poi_merges(canonical_poi_id);
+
+-- Trigram index for fuzzy name matching within a city
+CREATE INDEX idx_points_of_interest_name_trgm ON points_of_interest USING GIN (LOWER(name) gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_points_of_interest_name_trgm;
+DROP INDEX IF EXISTS idx_poi_merges_canonical;
+DROP TABLE IF EXISTS poi_merges;
+DROP INDEX IF EXISTS idx_poi_aliases_poi_id;
+DROP TABLE IF EXISTS poi_aliases;
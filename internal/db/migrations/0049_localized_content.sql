@@ -0,0 +1,33 @@
+-- +goose Up
+-- Language the descriptions stored on a POI or city were generated in. Existing rows were all
+-- generated from English prompts.
+ALTER TABLE points_of_interest ADD COLUMN content_language TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE cities ADD COLUMN content_language TEXT NOT NULL DEFAULT 'en';
+
+-- Descriptions per language, so content generated for one user can be served to another in
+-- theirs. Languages are ISO 639-1 codes.
+CREATE TABLE poi_translations (
+    poi_id UUID NOT NULL REFERENCES points_of_interest(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    description TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('generated', 'translated')), -- answered in the language, or translated on demand
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (poi_id, language)
+);
+
+CREATE TABLE city_translations (
+    city_id UUID NOT NULL REFERENCES cities(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    description TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('generated', 'translated')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (city_id, language)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS city_translations;
+DROP TABLE IF EXISTS poi_translations;
+ALTER TABLE cities DROP COLUMN IF EXISTS content_language;
+ALTER TABLE points_of_interest DROP COLUMN IF EXISTS content_language;
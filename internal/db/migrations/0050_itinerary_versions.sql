@@ -0,0 +1,24 @@
+-- +goose Up
+-- Every change to the itinerary of a chat session, as a full snapshot. Versions are only
+-- appended: reverting records the reverted-to itinerary as a new version, so a revert can be
+-- undone like any other change.
+CREATE TABLE chat_session_itinerary_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    session_id UUID NOT NULL REFERENCES chat_sessions(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    change_type TEXT NOT NULL,  -- 'initial', 'add_poi', 'remove_poi', 'replace_poi', 'revert', 'branch'
+    description TEXT NOT NULL DEFAULT '',
+    based_on INTEGER NULL,      -- version a revert restored
+    snapshot JSONB NOT NULL,    -- the models.AiCityResponse of the session at this version
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_chat_session_itinerary_version UNIQUE (session_id, version)
+);
+
+-- Sessions branched from a version of another session
+ALTER TABLE chat_sessions ADD COLUMN branched_from_session_id UUID NULL REFERENCES chat_sessions(id) ON DELETE SET NULL;
+ALTER TABLE chat_sessions ADD COLUMN branched_from_version INTEGER NULL;
+
+-- +goose Down
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS branched_from_version;
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS branched_from_session_id;
+DROP TABLE IF EXISTS chat_session_itinerary_versions;
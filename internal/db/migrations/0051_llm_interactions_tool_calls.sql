@@ -0,0 +1,10 @@
+-- +goose Up
+-- Tool calls the chat agent (internal/pkg/agent) made before answering, in call order
+ALTER TABLE llm_interactions
+    ADD COLUMN IF NOT EXISTS tool_calls JSONB;
+
+COMMENT ON COLUMN llm_interactions.tool_calls IS 'Agent tool calls of the turn: iteration, tool, arguments, result or error, duration_ms';
+
+-- +goose Down
+ALTER TABLE llm_interactions
+    DROP COLUMN IF EXISTS tool_calls;
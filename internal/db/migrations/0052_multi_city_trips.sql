@@ -0,0 +1,22 @@
+-- +goose Up
+-- The cities of a multi-city trip, in travel order. The trip belongs to the chat session it
+-- was planned in; each city keeps its own itinerary so one can be regenerated alone.
+CREATE TABLE chat_session_trip_cities (
+    session_id UUID NOT NULL REFERENCES chat_sessions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    city_name TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    city_id UUID NULL REFERENCES cities(id) ON DELETE SET NULL,
+    nights INTEGER NOT NULL CHECK (nights > 0),
+    latitude DOUBLE PRECISION NULL,  -- city centre, used for the transfer legs
+    longitude DOUBLE PRECISION NULL,
+    itinerary JSONB NULL,            -- models.AIItineraryResponse generated for the stay
+    llm_interaction_id UUID NULL REFERENCES llm_interactions(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (session_id, position)
+);
+
+CREATE INDEX idx_chat_session_trip_cities_city_id ON chat_session_trip_cities (city_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_session_trip_cities;
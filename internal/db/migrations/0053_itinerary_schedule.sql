@@ -0,0 +1,16 @@
+-- +goose Up
+-- Items placed by the user are kept where they are when the schedule is re-planned
+ALTER TABLE list_items ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The days the schedule of an itinerary list covers
+ALTER TABLE lists ADD COLUMN schedule_start_date DATE;
+ALTER TABLE lists ADD COLUMN schedule_days INTEGER CHECK (schedule_days > 0);
+
+-- How long models suggest spending at a POI, e.g. "1-2 hours"
+ALTER TABLE points_of_interest ADD COLUMN time_to_spend TEXT;
+
+-- +goose Down
+ALTER TABLE points_of_interest DROP COLUMN IF EXISTS time_to_spend;
+ALTER TABLE lists DROP COLUMN IF EXISTS schedule_days;
+ALTER TABLE lists DROP COLUMN IF EXISTS schedule_start_date;
+ALTER TABLE list_items DROP COLUMN IF EXISTS pinned;
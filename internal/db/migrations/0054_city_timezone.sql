@@ -0,0 +1,6 @@
+-- +goose Up
+-- The IANA time zone of a city, e.g. "Europe/Lisbon", to tell whether its places are open
+ALTER TABLE cities ADD COLUMN timezone TEXT;
+
+-- +goose Down
+ALTER TABLE cities DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- The currency cost estimates are shown in, an ISO 4217 code
+ALTER TABLE user_preference_profiles ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'EUR';
+
+-- +goose Down
+ALTER TABLE user_preference_profiles DROP COLUMN IF EXISTS currency;
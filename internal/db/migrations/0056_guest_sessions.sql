@@ -0,0 +1,47 @@
+-- +goose Up
+-- Visitors without an account, identified by a signed cookie. What they do in free mode is
+-- kept under their guest id and moved onto their account when they sign up or sign in.
+CREATE TABLE guests (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    claimed_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    claimed_at TIMESTAMPTZ
+);
+
+-- Free-mode sessions belong to a guest until the guest is claimed
+ALTER TABLE chat_sessions ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE chat_sessions ADD COLUMN guest_id UUID REFERENCES guests (id) ON DELETE CASCADE;
+ALTER TABLE chat_sessions ADD CONSTRAINT chat_sessions_owner_check CHECK (user_id IS NOT NULL OR guest_id IS NOT NULL);
+CREATE INDEX idx_chat_sessions_guest_id ON chat_sessions (guest_id, created_at) WHERE guest_id IS NOT NULL;
+
+ALTER TABLE lists ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE lists ADD COLUMN guest_id UUID REFERENCES guests (id) ON DELETE CASCADE;
+ALTER TABLE lists ADD CONSTRAINT lists_owner_check CHECK (user_id IS NOT NULL OR guest_id IS NOT NULL);
+CREATE INDEX idx_lists_guest_id ON lists (guest_id) WHERE guest_id IS NOT NULL;
+
+-- Interim favourites; poi_id is in llm_suggested_pois when is_llm is set, in points_of_interest otherwise
+CREATE TABLE guest_favorites (
+    guest_id UUID NOT NULL REFERENCES guests (id) ON DELETE CASCADE,
+    poi_id UUID NOT NULL,
+    is_llm BOOLEAN NOT NULL DEFAULT FALSE,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (guest_id, poi_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS guest_favorites;
+
+DELETE FROM lists WHERE user_id IS NULL;
+DROP INDEX IF EXISTS idx_lists_guest_id;
+ALTER TABLE lists DROP CONSTRAINT IF EXISTS lists_owner_check;
+ALTER TABLE lists DROP COLUMN IF EXISTS guest_id;
+ALTER TABLE lists ALTER COLUMN user_id SET NOT NULL;
+
+DELETE FROM chat_sessions WHERE user_id IS NULL;
+DROP INDEX IF EXISTS idx_chat_sessions_guest_id;
+ALTER TABLE chat_sessions DROP CONSTRAINT IF EXISTS chat_sessions_owner_check;
+ALTER TABLE chat_sessions DROP COLUMN IF EXISTS guest_id;
+ALTER TABLE chat_sessions ALTER COLUMN user_id SET NOT NULL;
+
+DROP TABLE IF EXISTS guests;
@@ -0,0 +1,262 @@
+// Package agent lets the model call the app's own search and list operations before it
+// answers. Providers are only given a prompt, so the loop keeps the transcript itself: every
+// turn the agent_turn prompt is rendered again with the tool calls made so far, and the model
+// replies with a JSON object asking for one more tool call or giving the answer.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+// DefaultMaxIterations is the number of tool calls allowed per turn when none is configured.
+const DefaultMaxIterations = 4
+
+// maxResultBytes caps the JSON of one tool result in the prompt. Tools should return compact
+// results; this only protects the prompt from one that does not.
+const maxResultBytes = 4000
+
+// Step is one tool call made during a turn.
+type Step struct {
+	Iteration  int             `json:"iteration"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// Observer is told about tool calls as they happen, e.g. to stream them to the client.
+// Either function may be nil.
+type Observer struct {
+	ToolCall   func(Step) // before the tool runs
+	ToolResult func(Step) // after it ran, with Result or Error set
+}
+
+// Result is the outcome of a turn.
+type Result struct {
+	Answer     string
+	Steps      []Step
+	Prompt     prompts.Rendered // last prompt sent, it holds every step of the turn
+	ModelCalls int
+	// Token usage summed over every model call of the turn
+	PromptTokens     int
+	CompletionTokens int
+	CapReached       bool // the model still wanted tools after the last allowed iteration
+}
+
+// Loop runs a model with a fixed set of tools.
+type Loop struct {
+	provider      llmprovider.Provider
+	tools         []Tool
+	byName        map[string]Tool
+	maxIterations int
+	config        *genai.GenerateContentConfig
+}
+
+// New creates a loop allowing up to maxIterations tool calls per turn, DefaultMaxIterations
+// when it is not positive.
+func New(provider llmprovider.Provider, maxIterations int, tools ...Tool) *Loop {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	byName := make(map[string]Tool, len(tools))
+	for _, t := range tools {
+		byName[t.Name()] = t
+	}
+	return &Loop{
+		provider:      provider,
+		tools:         tools,
+		byName:        byName,
+		maxIterations: maxIterations,
+		config:        &genai.GenerateContentConfig{Temperature: genai.Ptr[float32](0.2)},
+	}
+}
+
+// Run answers question. subject picks the agent_turn template version (see prompts.Registry).
+// Unknown tools, invalid arguments and tool errors are reported back to the model rather
+// than ending the turn; only a failing model call does.
+func (l *Loop) Run(ctx context.Context, subject, question, conversation string, obs Observer) (*Result, error) {
+	ctx, span := otel.Tracer("Agent").Start(ctx, "Run", trace.WithAttributes(
+		attribute.Int("agent.max_iterations", l.maxIterations),
+		attribute.Int("agent.tools", len(l.tools)),
+	))
+	defer span.End()
+
+	params := prompts.AgentTurnParams{Question: question, Conversation: conversation}
+	for _, t := range l.tools {
+		params.Tools = append(params.Tools, prompts.AgentTool{Name: t.Name(), Description: t.Description(), Schema: t.Schema().Source()})
+	}
+
+	result := &Result{}
+	for iteration := 1; iteration <= l.maxIterations; iteration++ {
+		r, err := l.generate(ctx, subject, params, result)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Model call failed")
+			return result, err
+		}
+		if r.ToolCall == nil {
+			result.Answer = r.answer()
+			span.SetAttributes(attribute.Int("agent.tool_calls", len(result.Steps)))
+			span.SetStatus(codes.Ok, "Answered")
+			return result, nil
+		}
+
+		step := Step{Iteration: iteration, Tool: r.ToolCall.Name, Arguments: r.ToolCall.Arguments}
+		if len(step.Arguments) == 0 {
+			step.Arguments = json.RawMessage("{}")
+		}
+		if obs.ToolCall != nil {
+			obs.ToolCall(step)
+		}
+		l.call(ctx, &step)
+		if obs.ToolResult != nil {
+			obs.ToolResult(step)
+		}
+		result.Steps = append(result.Steps, step)
+		params.Steps = append(params.Steps, promptStep(step))
+	}
+
+	// Out of iterations: one more call, without tools, for the answer
+	result.CapReached = true
+	params.Final = true
+	r, err := l.generate(ctx, subject, params, result)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Model call failed")
+		return result, err
+	}
+	result.Answer = r.answer()
+	span.SetAttributes(
+		attribute.Int("agent.tool_calls", len(result.Steps)),
+		attribute.Bool("agent.cap_reached", true),
+	)
+	span.SetStatus(codes.Ok, "Answered at the iteration cap")
+	return result, nil
+}
+
+func (l *Loop) generate(ctx context.Context, subject string, params prompts.AgentTurnParams, result *Result) (reply, error) {
+	prompt, err := prompts.AgentTurn.Render(subject, params)
+	if err != nil {
+		return reply{}, err
+	}
+	result.Prompt = prompt
+	result.ModelCalls++
+
+	resp, err := l.provider.GenerateResponse(ctx, prompt.Text, l.config)
+	if err != nil {
+		return reply{}, fmt.Errorf("failed to generate agent turn: %w", err)
+	}
+	if resp.UsageMetadata != nil {
+		result.PromptTokens += int(resp.UsageMetadata.PromptTokenCount)
+		result.CompletionTokens += int(resp.UsageMetadata.CandidatesTokenCount)
+	}
+	text := strings.TrimSpace(llmprovider.TextFromResponse(resp))
+	if text == "" {
+		return reply{}, errors.New("empty agent turn from LLM")
+	}
+	r := parseReply(text)
+	if params.Final && r.ToolCall != nil {
+		// Tools are not offered any more, and a tool call is not an answer either
+		r.ToolCall, r.text = nil, ""
+	}
+	return r, nil
+}
+
+// call runs the tool a step names and stores its result or error on the step.
+func (l *Loop) call(ctx context.Context, step *Step) {
+	start := time.Now()
+	defer func() { step.DurationMs = time.Since(start).Milliseconds() }()
+
+	tool, ok := l.byName[step.Tool]
+	if !ok {
+		step.Error = fmt.Sprintf("unknown tool %q", step.Tool)
+		return
+	}
+	ctx, span := otel.Tracer("Agent").Start(ctx, "CallTool", trace.WithAttributes(
+		attribute.String("agent.tool", step.Tool),
+		attribute.Int("agent.iteration", step.Iteration),
+	))
+	defer span.End()
+
+	out, err := tool.Call(ctx, step.Arguments)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Tool failed")
+		step.Error = err.Error()
+		return
+	}
+	encoded, err := json.Marshal(out)
+	if err != nil {
+		step.Error = fmt.Sprintf("failed to encode result: %v", err)
+		return
+	}
+	step.Result = encoded
+}
+
+func promptStep(s Step) prompts.AgentStep {
+	result := string(s.Result)
+	if s.Error != "" {
+		encoded, _ := json.Marshal(map[string]string{"error": s.Error})
+		result = string(encoded)
+	}
+	if len(result) > maxResultBytes {
+		result = result[:maxResultBytes] + " ...(truncated)"
+	}
+	return prompts.AgentStep{Tool: s.Tool, Arguments: string(s.Arguments), Result: result}
+}
+
+type toolCall struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type reply struct {
+	ToolCall *toolCall `json:"tool_call"`
+	Answer   *string   `json:"answer"`
+	text     string
+}
+
+// answer falls back to the raw text when the model answered without the JSON envelope.
+func (r reply) answer() string {
+	if r.Answer != nil {
+		return strings.TrimSpace(*r.Answer)
+	}
+	return r.text
+}
+
+// parseReply reads the JSON object of a model turn, tolerating markdown fences and text
+// around it. Anything else is taken as a plain text answer.
+func parseReply(text string) reply {
+	r := reply{text: text}
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return r
+	}
+	var parsed reply
+	if err := json.Unmarshal([]byte(text[start:end+1]), &parsed); err != nil {
+		return r
+	}
+	if parsed.ToolCall != nil && parsed.ToolCall.Name == "" {
+		parsed.ToolCall = nil
+	}
+	if parsed.ToolCall == nil && parsed.Answer == nil {
+		return r
+	}
+	parsed.text = text
+	return parsed
+}
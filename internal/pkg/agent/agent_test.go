@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/jsonschema"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+type nearArgs struct {
+	RadiusKm float64 `json:"radius_km"`
+}
+
+func nearTool(calls *[]float64) Tool {
+	schema := jsonschema.MustCompile([]byte(`{"type":"object","properties":{"radius_km":{"type":"number","minimum":0.1,"maximum":20}},"required":["radius_km"]}`))
+	return NewTool("pois_near", "POIs around the user", schema, func(_ context.Context, args nearArgs) (any, error) {
+		*calls = append(*calls, args.RadiusKm)
+		return []map[string]string{{"name": "Time Out Market"}}, nil
+	})
+}
+
+func TestRun_CallsToolThenAnswers(t *testing.T) {
+	provider := llmprovider.NewFakeProvider().
+		WithResponse(`Result: [{"name":"Time Out Market"}]`, `{"answer": "Time Out Market is a short walk away."}`).
+		WithDefaultResponse("```json\n{\"tool_call\": {\"name\": \"pois_near\", \"arguments\": {\"radius_km\": 1}}}\n```")
+
+	var calls []float64
+	var events []string
+	result, err := New(provider, 3, nearTool(&calls)).Run(context.Background(), "session-1", "Anything to eat nearby?", "Trip city: Lisbon", Observer{
+		ToolCall:   func(s Step) { events = append(events, "call:"+s.Tool) },
+		ToolResult: func(s Step) { events = append(events, "result:"+string(s.Result)) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Time Out Market is a short walk away.", result.Answer)
+	assert.Equal(t, []float64{1}, calls)
+	assert.Equal(t, []string{"call:pois_near", `result:[{"name":"Time Out Market"}]`}, events)
+	require.Len(t, result.Steps, 1)
+	assert.Equal(t, 1, result.Steps[0].Iteration)
+	assert.JSONEq(t, `{"radius_km": 1}`, string(result.Steps[0].Arguments))
+	assert.Equal(t, 2, result.ModelCalls)
+	assert.False(t, result.CapReached)
+	assert.Positive(t, result.PromptTokens)
+	assert.Equal(t, "agent_turn", result.Prompt.ID)
+	assert.Contains(t, provider.Prompts()[0], "pois_near: POIs around the user")
+}
+
+func TestRun_ToolErrorsAreFedBack(t *testing.T) {
+	provider := llmprovider.NewFakeProvider().
+		WithResponse("greater than the maximum", `{"answer": "done"}`).
+		WithResponse("unknown tool", `{"tool_call": {"name": "pois_near", "arguments": {"radius_km": 50}}}`).
+		WithDefaultResponse(`{"tool_call": {"name": "weather", "arguments": {}}}`)
+
+	var calls []float64
+	result, err := New(provider, 5, nearTool(&calls)).Run(context.Background(), "", "Is it raining?", "", Observer{})
+	require.NoError(t, err)
+
+	require.Len(t, result.Steps, 2)
+	assert.Equal(t, `unknown tool "weather"`, result.Steps[0].Error)
+	assert.Contains(t, result.Steps[1].Error, "invalid arguments for pois_near")
+	assert.Empty(t, calls, "invalid arguments must not reach the tool")
+	assert.Equal(t, "done", result.Answer)
+}
+
+func TestRun_StopsAtIterationCap(t *testing.T) {
+	provider := llmprovider.NewFakeProvider().
+		WithResponse("No more tool calls are allowed", `{"answer": "Here is what I found."}`).
+		WithDefaultResponse(`{"tool_call": {"name": "pois_near", "arguments": {"radius_km": 2}}}`)
+
+	var calls []float64
+	result, err := New(provider, 2, nearTool(&calls)).Run(context.Background(), "", "More places?", "", Observer{})
+	require.NoError(t, err)
+
+	assert.Len(t, calls, 2)
+	assert.True(t, result.CapReached)
+	assert.Equal(t, 3, result.ModelCalls)
+	assert.Equal(t, "Here is what I found.", result.Answer)
+	assert.NotContains(t, provider.Prompts()[2], "Tools:", "the final call offers no tools")
+}
+
+func TestRun_PlainTextIsTheAnswer(t *testing.T) {
+	provider := llmprovider.NewFakeProvider().WithDefaultResponse("The castle opens at 9am.")
+	result, err := New(provider, 0).Run(context.Background(), "", "When does the castle open?", "", Observer{})
+	require.NoError(t, err)
+	assert.Equal(t, "The castle opens at 9am.", result.Answer)
+	assert.Empty(t, result.Steps)
+}
+
+func TestNewTool_ToolErrors(t *testing.T) {
+	schema := jsonschema.MustCompile([]byte(`{"type":"object"}`))
+	failing := NewTool("favorites", "the user's favourites", schema, func(context.Context, struct{}) (any, error) {
+		return nil, errors.New("database unavailable")
+	})
+	_, err := failing.Call(context.Background(), nil)
+	assert.EqualError(t, err, "database unavailable")
+
+	_, err = failing.Call(context.Background(), json.RawMessage(`[1]`))
+	var argErr *ArgumentError
+	require.ErrorAs(t, err, &argErr)
+	assert.True(t, strings.HasPrefix(argErr.Error(), "invalid arguments for favorites"))
+}
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/jsonschema"
+)
+
+// Tool is something the model can call during a turn.
+type Tool interface {
+	Name() string
+	// Description tells the model what the tool returns and when to use it.
+	Description() string
+	// Schema validates the arguments of a call. Its source is shown to the model.
+	Schema() *jsonschema.Schema
+	// Call runs the tool. The result is encoded as JSON and fed back to the model.
+	Call(ctx context.Context, arguments json.RawMessage) (any, error)
+}
+
+// ArgumentError is returned for a call whose arguments do not match the tool's schema. It is
+// fed back to the model like any other tool error, so it can fix the arguments.
+type ArgumentError struct {
+	Tool string
+	Err  error
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid arguments for %s: %v", e.Tool, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error { return e.Err }
+
+// NewTool builds a tool whose arguments are validated against schema and decoded into A
+// before fn runs.
+func NewTool[A any](name, description string, schema *jsonschema.Schema, fn func(ctx context.Context, args A) (any, error)) Tool {
+	return &typedTool[A]{name: name, description: description, schema: schema, fn: fn}
+}
+
+type typedTool[A any] struct {
+	name        string
+	description string
+	schema      *jsonschema.Schema
+	fn          func(ctx context.Context, args A) (any, error)
+}
+
+func (t *typedTool[A]) Name() string               { return t.name }
+func (t *typedTool[A]) Description() string        { return t.description }
+func (t *typedTool[A]) Schema() *jsonschema.Schema { return t.schema }
+
+func (t *typedTool[A]) Call(ctx context.Context, arguments json.RawMessage) (any, error) {
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+	if err := jsonschema.Errors(t.schema.ValidateJSON(arguments)); err != nil {
+		return nil, &ArgumentError{Tool: t.name, Err: err}
+	}
+	var args A
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, &ArgumentError{Tool: t.name, Err: err}
+	}
+	return t.fn(ctx, args)
+}
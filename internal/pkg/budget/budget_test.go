@@ -0,0 +1,145 @@
+package budget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Price
+	}{
+		{"$$", Price{Level: 2, Currency: "USD"}},
+		{"€€€€", Price{Level: 4, Currency: "EUR"}},
+		{"Moderate", Price{Level: 2}},
+		{"€10-20", Price{Min: 10, Max: 20, Currency: "EUR"}},
+		{"15 – 25 EUR per person", Price{Min: 15, Max: 25, Currency: "EUR"}},
+		{"£8", Price{Min: 8, Max: 8, Currency: "GBP"}},
+		{"R$ 40-80", Price{Min: 40, Max: 80, Currency: "BRL"}},
+		{"¥1,200", Price{Min: 1200, Max: 1200, Currency: "JPY"}},
+		{"12,50 €", Price{Min: 12.5, Max: 12.5, Currency: "EUR"}},
+		{"Under $15", Price{Min: 0, Max: 15, Currency: "USD"}},
+		{"From €20", Price{Min: 20, Max: 30, Currency: "EUR"}},
+		{"Free entry", Price{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParsePrice(tt.in)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := ParsePrice("")
+	assert.False(t, ok)
+	_, ok = ParsePrice("ask at the door")
+	assert.False(t, ok)
+}
+
+func TestTable(t *testing.T) {
+	table := NewTable(map[string]float64{"USD": 1.1, "GBP": 0.85})
+	r, err := table.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.1, r, 1e-9)
+	r, err = table.Rate(context.Background(), "usd", "GBP")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.85/1.1, r, 1e-9)
+
+	_, err = table.Rate(context.Background(), "EUR", "XYZ")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestHTTP_FallsBack(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"amount":1.0,"base":"EUR","date":"2025-06-02","rates":{"USD":1.2}}`))
+	}))
+	defer server.Close()
+
+	rates := NewHTTP(server.URL, time.Second)
+	r, err := rates.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	assert.InDelta(t, 1.2, r, 1e-9)
+	_, _ = rates.Rate(context.Background(), "EUR", "USD")
+	assert.Equal(t, 1, calls, "rates are kept")
+
+	down := NewHTTP("http://127.0.0.1:1", 100*time.Millisecond)
+	provider := WithFallback(down, NewTable(nil))
+	assert.Equal(t, "http+offline", provider.Name())
+	r, err = provider.Rate(context.Background(), "EUR", "GBP")
+	require.NoError(t, err)
+	assert.Greater(t, r, 0.0)
+}
+
+func TestEstimate(t *testing.T) {
+	e := NewEstimator(NewTable(map[string]float64{"USD": 2}))
+	plan := Plan{
+		Days:   2,
+		Nights: 1,
+		Level:  1,
+		Mode:   "walk",
+		Items: []Item{
+			{Category: Food, Price: "€10-20", Day: 1},
+			{Category: Activities, Price: "Free", Day: 1},
+			{Category: Activities, Price: "$$$", Day: 2},
+			{Category: Lodging, Price: "€50-70"},
+		},
+		Transfers: []Transfer{{Mode: "train", DistanceKm: 100, Day: 2}},
+	}
+
+	cost, err := e.Estimate(context.Background(), plan, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "USD", cost.Currency)
+	assert.Equal(t, "offline", cost.Rates)
+	require.Len(t, cost.Days, 2)
+
+	day1 := cost.Days[0]
+	// The named meal, another one and breakfast at level 1, in dollars
+	assert.Equal(t, 2*(10+1.5*6.0), day1.Food.Min)
+	assert.Equal(t, 2*(20+1.5*15.0), day1.Food.Max)
+	assert.Equal(t, 100.0, day1.Lodging.Min)
+	assert.Equal(t, 0.0, day1.Activities.Max)
+
+	day2 := cost.Days[1]
+	assert.Zero(t, day2.Lodging.Max)
+	assert.Equal(t, 2*25.0, day2.Activities.Min)
+	assert.Equal(t, 2*(8+0.0), day2.Transport.Min)
+
+	total := day1.Total.Min + day2.Total.Min
+	assert.Equal(t, total, cost.Total.Total.Min)
+	require.NotNil(t, cost.Budget)
+	assert.Equal(t, 2*80*2.0, *cost.Budget)
+
+	plan.Level = 0
+	cost, err = e.Estimate(context.Background(), plan, "EUR")
+	require.NoError(t, err)
+	assert.Nil(t, cost.Budget)
+
+	plan.Level = 3
+	cost, err = e.Estimate(context.Background(), plan, "EUR")
+	require.NoError(t, err)
+	assert.False(t, cost.OverBudget)
+
+	plan.Items = append(plan.Items, Item{Category: Activities, Price: "€1000"})
+	cost, err = e.Estimate(context.Background(), plan, "EUR")
+	require.NoError(t, err)
+	assert.True(t, cost.OverBudget)
+
+	_, err = e.Estimate(context.Background(), plan, "XYZ")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestCategoryOf(t *testing.T) {
+	assert.Equal(t, Food, CategoryOf("Seafood Restaurant"))
+	assert.Equal(t, Food, CategoryOf("cafe"))
+	assert.Equal(t, Lodging, CategoryOf("Boutique Hotel"))
+	assert.Equal(t, Activities, CategoryOf("museum"))
+}
@@ -0,0 +1,257 @@
+package budget
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Category is what money is spent on.
+type Category string
+
+const (
+	Food       Category = "food"
+	Lodging    Category = "lodging"
+	Activities Category = "activities"
+	Transport  Category = "transport"
+)
+
+// CategoryOf reads the category of a place: places to eat and drink are food, places to
+// stay are lodging, everything else is an activity.
+func CategoryOf(placeCategory string) Category {
+	c := strings.ToLower(placeCategory)
+	for _, w := range []string{"restaurant", "cafe", "café", "bar", "food", "bakery", "pub", "bistro", "eatery", "market"} {
+		if strings.Contains(c, w) {
+			return Food
+		}
+	}
+	for _, w := range []string{"hotel", "hostel", "guest", "apartment", "lodging", "b&b", "inn", "resort"} {
+		if strings.Contains(c, w) {
+			return Lodging
+		}
+	}
+	return Activities
+}
+
+// Item is something of the trip that costs money.
+type Item struct {
+	Category Category
+	Price    string // as the source wrote it, e.g. "$$" or "€10-20"
+	Day      int    // 1-based, 0 to spread items over the days in turn
+}
+
+// Transfer is a journey between cities, taken on a day of the trip.
+type Transfer struct {
+	Mode       string // train or flight
+	DistanceKm float64
+	Day        int // 1-based
+}
+
+// Plan is what to estimate.
+type Plan struct {
+	Days      int    // at least one
+	Nights    int    // nights of lodging, on the first days
+	Level     int    // the traveller's budget level, 1-4, 0 without one
+	Mode      string // how the traveller gets around town: walk, public or car
+	Currency  string // of amounts whose source does not say, EUR when empty
+	Items     []Item
+	Transfers []Transfer
+}
+
+// tier is the typical cost in euros of a meal, a night or a visit, at budget levels 1 to 4.
+var tier = map[Category][4]models.CostRange{
+	Food:       {{Min: 6, Max: 15}, {Min: 15, Max: 30}, {Min: 30, Max: 60}, {Min: 60, Max: 150}},
+	Lodging:    {{Min: 25, Max: 60}, {Min: 60, Max: 120}, {Min: 120, Max: 250}, {Min: 250, Max: 600}},
+	Activities: {{Min: 0, Max: 10}, {Min: 10, Max: 25}, {Min: 25, Max: 50}, {Min: 50, Max: 120}},
+}
+
+// dailyBudget is what a day of travel may cost in euros at budget levels 1 to 4.
+var dailyBudget = [4]float64{80, 160, 320, 800}
+
+// Getting around town for a day, in euros
+var localTransport = map[string]models.CostRange{
+	"walk":   {Min: 0, Max: 3},
+	"public": {Min: 4, Max: 12},
+	"car":    {Min: 30, Max: 60},
+}
+
+const (
+	// Meals a day beyond breakfast, which counts as half a meal
+	mealsPerDay = 2
+	// Travellers without a budget level are costed as mid-range
+	defaultLevel = 2
+)
+
+// Estimator estimates the cost of plans.
+type Estimator struct {
+	rates Rates
+}
+
+// NewEstimator returns an estimator converting with rates.
+func NewEstimator(rates Rates) *Estimator {
+	return &Estimator{rates: rates}
+}
+
+// Estimate returns the cost of a plan per day and category in currency, and whether it goes
+// over the budget of the plan's level: when the middle of the estimate is above it.
+func (e *Estimator) Estimate(ctx context.Context, plan Plan, currency string) (*models.TripCost, error) {
+	if plan.Days < 1 {
+		plan.Days = 1
+	}
+	if plan.Currency == "" {
+		plan.Currency = "EUR"
+	}
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = "EUR"
+	}
+	level := plan.Level
+	if level < 1 || level > 4 {
+		level = defaultLevel
+	}
+	// Amounts are added up in euros and converted once
+	toTarget, err := e.rates.Rate(ctx, "EUR", currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert estimate to %s: %w", currency, err)
+	}
+
+	days := make([]models.DayCost, plan.Days)
+	for i := range days {
+		days[i].Day = i + 1
+	}
+	meals := make([]int, plan.Days)
+	var stays []models.CostRange
+	spread := 0
+	for _, it := range plan.Items {
+		cost := e.cost(ctx, it, level, plan.Currency)
+		if it.Category == Lodging {
+			stays = append(stays, cost)
+			continue
+		}
+		d := it.Day - 1
+		if d < 0 || d >= plan.Days {
+			d = spread % plan.Days
+			spread++
+		}
+		switch it.Category {
+		case Food:
+			add(&days[d].Food, cost)
+			meals[d]++
+		case Transport:
+			add(&days[d].Transport, cost)
+		default:
+			add(&days[d].Activities, cost)
+		}
+	}
+
+	meal := tier[Food][level-1]
+	night := average(stays, tier[Lodging][level-1])
+	around, ok := localTransport[plan.Mode]
+	if !ok {
+		around = localTransport["walk"]
+	}
+	for d := range days {
+		// Breakfast, and the meals the plan does not name
+		add(&days[d].Food, scale(meal, 0.5+float64(max(0, mealsPerDay-meals[d]))))
+		if d < plan.Nights {
+			add(&days[d].Lodging, night)
+		}
+		add(&days[d].Transport, around)
+	}
+	for _, t := range plan.Transfers {
+		d := min(max(t.Day-1, 0), plan.Days-1)
+		add(&days[d].Transport, transferCost(t))
+	}
+
+	result := &models.TripCost{Currency: currency, Days: days, Rates: e.rates.Name()}
+	for i := range days {
+		b := &days[i].CostBreakdown
+		convert(b, toTarget)
+		add(&result.Total.Food, b.Food)
+		add(&result.Total.Lodging, b.Lodging)
+		add(&result.Total.Activities, b.Activities)
+		add(&result.Total.Transport, b.Transport)
+		add(&result.Total.Total, b.Total)
+	}
+	if plan.Level >= 1 && plan.Level <= 4 {
+		allowed := math.Round(dailyBudget[plan.Level-1] * float64(plan.Days) * toTarget)
+		result.Budget = &allowed
+		result.OverBudget = (result.Total.Total.Min+result.Total.Total.Max)/2 > allowed
+	}
+	return result, nil
+}
+
+// cost is what an item costs in euros. Prices that cannot be read cost what the traveller's
+// level usually pays, activities what a cheap visit costs, as many sights are free.
+func (e *Estimator) cost(ctx context.Context, it Item, level int, currency string) models.CostRange {
+	fallback := func() models.CostRange {
+		switch it.Category {
+		case Activities:
+			return tier[Activities][0]
+		case Transport:
+			return models.CostRange{}
+		}
+		return tier[it.Category][level-1]
+	}
+	p, ok := ParsePrice(it.Price)
+	if !ok {
+		return fallback()
+	}
+	if p.Level > 0 {
+		if it.Category == Transport {
+			return models.CostRange{}
+		}
+		return tier[it.Category][p.Level-1]
+	}
+	if p.Currency == "" {
+		p.Currency = currency
+	}
+	rate, err := e.rates.Rate(ctx, p.Currency, "EUR")
+	if err != nil {
+		return fallback()
+	}
+	return models.CostRange{Min: p.Min * rate, Max: p.Max * rate}
+}
+
+// transferCost is what a journey between cities costs in euros: a train fare by distance,
+// or a flight with its fixed costs.
+func transferCost(t Transfer) models.CostRange {
+	if t.Mode == "flight" {
+		return models.CostRange{Min: 40 + 0.05*t.DistanceKm, Max: 80 + 0.12*t.DistanceKm}
+	}
+	return models.CostRange{Min: 0.08 * t.DistanceKm, Max: 0.2 * t.DistanceKm}
+}
+
+func average(ranges []models.CostRange, otherwise models.CostRange) models.CostRange {
+	if len(ranges) == 0 {
+		return otherwise
+	}
+	var sum models.CostRange
+	for _, r := range ranges {
+		add(&sum, r)
+	}
+	return scale(sum, 1/float64(len(ranges)))
+}
+
+func add(to *models.CostRange, r models.CostRange) {
+	to.Min += r.Min
+	to.Max += r.Max
+}
+
+func scale(r models.CostRange, f float64) models.CostRange {
+	return models.CostRange{Min: r.Min * f, Max: r.Max * f}
+}
+
+// convert turns a day from euros into the target currency, in whole units, and totals it.
+func convert(b *models.CostBreakdown, rate float64) {
+	for _, r := range []*models.CostRange{&b.Food, &b.Lodging, &b.Activities, &b.Transport} {
+		r.Min, r.Max = math.Round(r.Min*rate), math.Round(r.Max*rate)
+	}
+	b.Total = models.CostRange{}
+	for _, r := range []models.CostRange{b.Food, b.Lodging, b.Activities, b.Transport} {
+		add(&b.Total, r)
+	}
+}
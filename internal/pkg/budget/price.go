@@ -0,0 +1,125 @@
+// Package budget estimates what a trip costs. Models and sources describe prices their own
+// way: tiers such as "$$" or "Moderate", ranges such as "€10-20" or "15-25 EUR", "Free".
+// ParsePrice reads them into numbers, the Estimator adds them up per day and category and
+// converts the result into the traveller's currency with pluggable exchange rates.
+package budget
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Price is what something costs: a range of amounts in a currency, or a tier from 1
+// (cheapest) to 4 when the source only says how expensive it is.
+type Price struct {
+	Min      float64
+	Max      float64
+	Currency string // ISO 4217 code, empty when the source does not say
+	Level    int    // 1-4 for tiers, 0 for amounts
+}
+
+var (
+	amount = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	// Thousands separators, as in "1,200" or "1 200"
+	thousands = regexp.MustCompile(`(\d)[, ](\d{3})\b`)
+	isoCode   = regexp.MustCompile(`\b[A-Z]{3}\b`)
+
+	// Symbols, longest first so that "R$" is not read as "$"
+	symbols = []struct{ symbol, code string }{
+		{"R$", "BRL"}, {"A$", "AUD"}, {"AU$", "AUD"}, {"C$", "CAD"}, {"CA$", "CAD"},
+		{"HK$", "HKD"}, {"NZ$", "NZD"}, {"S$", "SGD"}, {"US$", "USD"},
+		{"€", "EUR"}, {"£", "GBP"}, {"¥", "JPY"}, {"₹", "INR"}, {"₩", "KRW"}, {"฿", "THB"},
+		{"₺", "TRY"}, {"zł", "PLN"}, {"Kč", "CZK"}, {"$", "USD"},
+	}
+	tierWords = []struct {
+		word  string
+		level int
+	}{
+		{"very expensive", 4}, {"luxury", 4}, {"fine dining", 4}, {"inexpensive", 1},
+		{"expensive", 3}, {"upscale", 3}, {"high-end", 3}, {"moderate", 2}, {"mid-range", 2},
+		{"mid range", 2}, {"midrange", 2}, {"budget", 1}, {"cheap", 1}, {"affordable", 1},
+	}
+	freeWords = []string{"free", "gratis", "no charge", "no entry fee"}
+)
+
+// ParsePrice reads a price as models and sources write it. ok is false when nothing can be
+// read from s.
+func ParsePrice(s string) (p Price, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Price{}, false
+	}
+	lower := strings.ToLower(s)
+	for _, w := range freeWords {
+		if strings.Contains(lower, w) && !amount.MatchString(s) {
+			return Price{}, true
+		}
+	}
+
+	p.Currency = currencyOf(s)
+	text := thousands.ReplaceAllString(strings.ReplaceAll(s, "–", "-"), "$1$2")
+	// Decimal commas, as in "12,50 €"
+	text = strings.ReplaceAll(text, ",", ".")
+	if numbers := amount.FindAllString(text, 2); numbers != nil {
+		p.Min, _ = strconv.ParseFloat(numbers[0], 64)
+		p.Max = p.Min
+		if len(numbers) == 2 {
+			p.Max, _ = strconv.ParseFloat(numbers[1], 64)
+		}
+		if p.Max < p.Min {
+			p.Min, p.Max = p.Max, p.Min
+		}
+		switch {
+		case strings.Contains(lower, "under"), strings.Contains(lower, "up to"),
+			strings.Contains(lower, "less than"), strings.HasPrefix(lower, "<"):
+			p.Min = 0
+		case strings.Contains(lower, "from"), strings.HasSuffix(lower, "+"):
+			if len(numbers) == 1 {
+				p.Max = p.Min * 1.5
+			}
+		}
+		return p, true
+	}
+
+	// Tiers: "$$", "€€€", or a word
+	if n := tierSymbols(s); n > 0 {
+		return Price{Level: min(n, 4), Currency: p.Currency}, true
+	}
+	for _, t := range tierWords {
+		if strings.Contains(lower, t.word) {
+			return Price{Level: t.level}, true
+		}
+	}
+	return Price{}, false
+}
+
+// currencyOf finds the currency a price is written in, by ISO code or symbol.
+func currencyOf(s string) string {
+	for _, code := range isoCode.FindAllString(s, -1) {
+		if _, ok := defaultPerEUR[code]; ok || code == "EUR" {
+			return code
+		}
+	}
+	for _, sym := range symbols {
+		if strings.Contains(s, sym.symbol) {
+			return sym.code
+		}
+	}
+	return ""
+}
+
+// tierSymbols counts the currency symbols of a tier such as "$$$", 0 when s is not one.
+func tierSymbols(s string) int {
+	s = strings.TrimSpace(strings.SplitN(s, " ", 2)[0])
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	for _, r := range runes {
+		if r != runes[0] || !strings.ContainsRune("$€£¥₹", r) {
+			return 0
+		}
+	}
+	return len(runes)
+}
@@ -0,0 +1,150 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownCurrency is returned for currencies a rate source has no rate for.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Rates converts between currencies.
+type Rates interface {
+	Name() string
+	// Rate returns how many units of to one unit of from is worth.
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// defaultPerEUR holds approximate mid-market rates against the euro. They keep estimates
+// working offline and are close enough for budgeting, not for payments.
+var defaultPerEUR = map[string]float64{
+	"USD": 1.08, "GBP": 0.85, "CHF": 0.95, "JPY": 165, "CNY": 7.8, "AUD": 1.65, "CAD": 1.48,
+	"NZD": 1.8, "SEK": 11.3, "NOK": 11.7, "DKK": 7.46, "PLN": 4.3, "CZK": 25, "HUF": 395,
+	"RON": 4.97, "ISK": 150, "TRY": 38, "BRL": 6.0, "MXN": 20, "ARS": 1000, "INR": 92,
+	"THB": 39, "SGD": 1.45, "HKD": 8.4, "KRW": 1480, "IDR": 17500, "VND": 27500, "ZAR": 20,
+	"AED": 3.97, "MAD": 10.8, "EGP": 52,
+}
+
+// Table converts with a fixed table of rates against the euro.
+type Table struct {
+	perEUR map[string]float64
+}
+
+// NewTable returns a table of rates against the euro, the built-in offline table when
+// perEUR is nil.
+func NewTable(perEUR map[string]float64) *Table {
+	if perEUR == nil {
+		perEUR = defaultPerEUR
+	}
+	return &Table{perEUR: perEUR}
+}
+
+func (t *Table) Name() string { return "offline" }
+
+func (t *Table) Rate(_ context.Context, from, to string) (float64, error) {
+	f, err := t.perOne(from)
+	if err != nil {
+		return 0, err
+	}
+	r, err := t.perOne(to)
+	if err != nil {
+		return 0, err
+	}
+	return r / f, nil
+}
+
+// perOne is how many units of code one euro is worth.
+func (t *Table) perOne(code string) (float64, error) {
+	code = strings.ToUpper(code)
+	if code == "EUR" {
+		return 1, nil
+	}
+	if r, ok := t.perEUR[code]; ok && r > 0 {
+		return r, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, code)
+}
+
+// HTTP fetches rates against the euro from a Frankfurter-compatible service, e.g.
+// https://api.frankfurter.app/latest, answering {"base":"EUR","rates":{"USD":1.08,...}}.
+// Rates are kept for a day.
+type HTTP struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	table   *Table
+	fetched time.Time
+}
+
+// NewHTTP returns rates fetched from url.
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	return &HTTP{url: url, client: &http.Client{Timeout: timeout}, ttl: 24 * time.Hour}
+}
+
+func (h *HTTP) Name() string { return "http" }
+
+func (h *HTTP) Rate(ctx context.Context, from, to string) (float64, error) {
+	table, err := h.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return table.Rate(ctx, from, to)
+}
+
+func (h *HTTP) current(ctx context.Context) (*Table, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.table != nil && time.Since(h.fetched) < h.ttl {
+		return h.table, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rates request: %w", err)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates service answered %s", resp.Status)
+	}
+	var body struct {
+		Base  string             `json:"base"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode rates: %w", err)
+	}
+	if body.Base != "" && !strings.EqualFold(body.Base, "EUR") {
+		return nil, fmt.Errorf("rates are against %s, want EUR", body.Base)
+	}
+	h.table, h.fetched = NewTable(body.Rates), time.Now()
+	return h.table, nil
+}
+
+type fallback struct {
+	primary, secondary Rates
+}
+
+// WithFallback asks primary for rates and secondary when primary fails.
+func WithFallback(primary, secondary Rates) Rates {
+	return &fallback{primary: primary, secondary: secondary}
+}
+
+func (f *fallback) Name() string { return f.primary.Name() + "+" + f.secondary.Name() }
+
+func (f *fallback) Rate(ctx context.Context, from, to string) (float64, error) {
+	if r, err := f.primary.Rate(ctx, from, to); err == nil {
+		return r, nil
+	}
+	return f.secondary.Rate(ctx, from, to)
+}
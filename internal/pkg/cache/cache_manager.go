@@ -22,6 +22,10 @@ type CacheManager struct {
 	// City data cache (can be shared across domains)
 	CityData *UnifiedCache[models.GeneralCityData]
 
+	// Raw responses of streamed LLM parts keyed by prompt hash, served instead of new
+	// generations once the LLM cost budget is exceeded
+	PartResponses *UnifiedCache[string]
+
 	// Vector/Embedding caches for semantic search
 	VectorSearch *VectorCache    // Caches vector search results with semantic matching
 	Embeddings   *EmbeddingCache // Caches raw embeddings (user profiles, queries)
@@ -46,6 +50,9 @@ func NewCacheManager(logger *zap.Logger) *CacheManager {
 		// City data has longer TTL since it changes less frequently (15 minutes)
 		CityData: NewUnifiedCache[models.GeneralCityData](15*time.Minute, "city_data", logger),
 
+		// Part responses are a budget fallback, a day old answer beats no answer (24 hours)
+		PartResponses: NewUnifiedCache[string](24*time.Hour, "part_responses", logger),
+
 		// Vector caches for semantic search (longer TTL since embeddings are expensive to compute)
 		VectorSearch: NewVectorCache(20*time.Minute, 0.95, "vector_search", logger), // 95% similarity threshold
 		Embeddings:   NewEmbeddingCache(30 * time.Minute),                           // Query embeddings cache
@@ -59,15 +66,16 @@ var Cache = NewCacheManager(nil)
 // GetAllMetrics returns metrics for all caches
 func (cm *CacheManager) GetAllMetrics() map[string]CacheMetrics {
 	return map[string]CacheMetrics{
-		"complete":      cm.Complete.GetMetrics(),
-		"restaurants":   cm.Restaurants.GetMetrics(),
-		"hotels":        cm.Hotels.GetMetrics(),
-		"activities":    cm.Activities.GetMetrics(),
-		"itineraries":   cm.Itineraries.GetMetrics(),
-		"city_data":     cm.CityData.GetMetrics(),
-		"vector_search": cm.VectorSearch.GetMetrics(),
-		"embeddings":    cm.Embeddings.GetMetrics(),
-		"user_profiles": cm.UserProfiles.GetMetrics(),
+		"complete":       cm.Complete.GetMetrics(),
+		"restaurants":    cm.Restaurants.GetMetrics(),
+		"hotels":         cm.Hotels.GetMetrics(),
+		"activities":     cm.Activities.GetMetrics(),
+		"itineraries":    cm.Itineraries.GetMetrics(),
+		"city_data":      cm.CityData.GetMetrics(),
+		"part_responses": cm.PartResponses.GetMetrics(),
+		"vector_search":  cm.VectorSearch.GetMetrics(),
+		"embeddings":     cm.Embeddings.GetMetrics(),
+		"user_profiles":  cm.UserProfiles.GetMetrics(),
 	}
 }
 
@@ -79,6 +87,7 @@ func (cm *CacheManager) ClearAll() {
 	cm.Activities.Clear()
 	cm.Itineraries.Clear()
 	cm.CityData.Clear()
+	cm.PartResponses.Clear()
 	cm.VectorSearch.Clear()
 	// Note: We intentionally don't clear Embeddings and UserProfiles
 	// as they are expensive to regenerate. Clear them manually if needed.
@@ -0,0 +1,213 @@
+// Package chatmemory keeps the conversation context sent to the model within a token
+// budget.
+//
+// Chat sessions keep their full ConversationHistory for display. What the model sees is a
+// Window: the pinned facts of the trip (city, chosen POIs, rejected items), a running
+// summary of older turns and the most recent messages verbatim. Once the unsummarised
+// messages exceed the budget, Manager.Compact rolls the oldest of them into the summary.
+package chatmemory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+const (
+	defaultMaxTokens  = 3000
+	defaultKeepRecent = 6
+	// maxRejectedItems bounds the pinned rejections so they cannot outgrow the budget.
+	maxRejectedItems = 20
+	// messageOverheadTokens accounts for the role prefix and separators of a message.
+	messageOverheadTokens = 4
+)
+
+// EstimateTokens approximates the token count of text at four characters per token, which
+// is close enough for English prompts and avoids a tokenizer per provider.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func messageTokens(messages []models.ConversationMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += EstimateTokens(m.Content) + messageOverheadTokens
+	}
+	return total
+}
+
+// Manager compacts session histories and builds the context windows sent to the model.
+type Manager struct {
+	logger     *zap.Logger
+	summarizer Summarizer
+	maxTokens  int
+	keepRecent int
+}
+
+// NewManager creates a manager summarising with summarizer. The default budget is 3000
+// tokens, of which the last 6 messages are always kept verbatim.
+func NewManager(summarizer Summarizer, logger *zap.Logger) *Manager {
+	return &Manager{
+		logger:     logger,
+		summarizer: summarizer,
+		maxTokens:  defaultMaxTokens,
+		keepRecent: defaultKeepRecent,
+	}
+}
+
+// WithBudget sets the token budget of the summary plus the verbatim messages, and how many
+// of the latest messages are never summarised.
+func (m *Manager) WithBudget(maxTokens, keepRecent int) *Manager {
+	if maxTokens > 0 {
+		m.maxTokens = maxTokens
+	}
+	if keepRecent > 0 {
+		m.keepRecent = keepRecent
+	}
+	return m
+}
+
+// pending returns the messages not yet folded into the summary.
+func pending(session *models.ChatSession) []models.ConversationMessage {
+	done := min(max(session.SessionContext.SummarizedMessages, 0), len(session.ConversationHistory))
+	return session.ConversationHistory[done:]
+}
+
+// Compact folds the oldest unsummarised messages into the session summary when the summary
+// and those messages exceed the budget. It reports whether the session changed; the caller
+// persists it. On error the session is left untouched and Window trims instead.
+func (m *Manager) Compact(ctx context.Context, session *models.ChatSession) (bool, error) {
+	msgs := pending(session)
+	used := EstimateTokens(session.SessionContext.ConversationSummary) + messageTokens(msgs)
+	if used <= m.maxTokens || len(msgs) <= m.keepRecent {
+		return false, nil
+	}
+
+	fold := msgs[:len(msgs)-m.keepRecent]
+	summary, err := m.summarizer.Summarize(ctx, SummaryRequest{
+		CityName:        cityName(session),
+		PreviousSummary: session.SessionContext.ConversationSummary,
+		Messages:        fold,
+		MaxWords:        m.summaryWords(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to summarise %d messages: %w", len(fold), err)
+	}
+
+	session.SessionContext.ConversationSummary = summary
+	session.SessionContext.SummarizedMessages = len(session.ConversationHistory) - len(msgs) + len(fold)
+	m.logger.Info("Compacted conversation history",
+		zap.String("session_id", session.ID.String()),
+		zap.Int("summarized_messages", len(fold)),
+		zap.Int("tokens_before", used),
+		zap.Int("tokens_after", EstimateTokens(summary)+messageTokens(msgs[len(fold):])))
+	return true, nil
+}
+
+// summaryWords keeps the summary to about a quarter of the budget, at roughly 0.75 words
+// per token.
+func (m *Manager) summaryWords() int {
+	return max(m.maxTokens/4*3/4, 50)
+}
+
+// Window is the compacted conversation context for one model call.
+type Window struct {
+	Pinned  PinnedFacts
+	Summary string
+	Recent  []models.ConversationMessage
+}
+
+// Window returns the context for the next model call. When Compact could not run, the
+// oldest messages are dropped until the window fits, always keeping the latest one.
+func (m *Manager) Window(session *models.ChatSession) Window {
+	recent := pending(session)
+	budget := m.maxTokens - EstimateTokens(session.SessionContext.ConversationSummary)
+	for len(recent) > 1 && messageTokens(recent) > budget {
+		recent = recent[1:]
+	}
+	return Window{
+		Pinned:  Pinned(session),
+		Summary: session.SessionContext.ConversationSummary,
+		Recent:  recent,
+	}
+}
+
+// String renders the window as a prompt section.
+func (w Window) String() string {
+	var b strings.Builder
+	if w.Pinned.CityName != "" {
+		fmt.Fprintf(&b, "Trip city: %s\n", w.Pinned.CityName)
+	}
+	if len(w.Pinned.ChosenPOIs) > 0 {
+		fmt.Fprintf(&b, "Already in the itinerary: %s\n", strings.Join(w.Pinned.ChosenPOIs, ", "))
+	}
+	if len(w.Pinned.Rejected) > 0 {
+		fmt.Fprintf(&b, "The user does not want: %s\n", strings.Join(w.Pinned.Rejected, "; "))
+	}
+	if w.Summary != "" {
+		fmt.Fprintf(&b, "Conversation summary: %s\n", w.Summary)
+	}
+	if len(w.Recent) > 0 {
+		b.WriteString("Recent messages:\n")
+		for _, msg := range w.Recent {
+			fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// PinnedFacts are kept in every window regardless of the summary, so the model never
+// forgets where the trip is, what was chosen and what the user turned down.
+type PinnedFacts struct {
+	CityName   string
+	ChosenPOIs []string
+	Rejected   []string
+}
+
+// Pinned extracts the pinned facts of a session.
+func Pinned(session *models.ChatSession) PinnedFacts {
+	facts := PinnedFacts{
+		CityName: cityName(session),
+		Rejected: session.SessionContext.RejectedItems,
+	}
+	if session.CurrentItinerary != nil {
+		for _, p := range session.CurrentItinerary.AIItineraryResponse.PointsOfInterest {
+			if p.Name != "" {
+				facts.ChosenPOIs = append(facts.ChosenPOIs, p.Name)
+			}
+		}
+	}
+	return facts
+}
+
+// Reject pins an item the user does not want, e.g. a removed POI or "museums". Repeats are
+// ignored and only the latest rejections are kept.
+func Reject(session *models.ChatSession, item string) {
+	item = strings.TrimSpace(item)
+	if item == "" {
+		return
+	}
+	rejected := session.SessionContext.RejectedItems
+	for _, r := range rejected {
+		if strings.EqualFold(r, item) {
+			return
+		}
+	}
+	rejected = append(rejected, item)
+	if len(rejected) > maxRejectedItems {
+		rejected = rejected[len(rejected)-maxRejectedItems:]
+	}
+	session.SessionContext.RejectedItems = rejected
+}
+
+func cityName(session *models.ChatSession) string {
+	if session.SessionContext.CityName != "" {
+		return session.SessionContext.CityName
+	}
+	return session.CityName
+}
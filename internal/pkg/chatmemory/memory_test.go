@@ -0,0 +1,157 @@
+package chatmemory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+type recordingSummarizer struct {
+	requests []SummaryRequest
+	err      error
+}
+
+func (s *recordingSummarizer) Summarize(_ context.Context, req SummaryRequest) (string, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return "", s.err
+	}
+	return fmt.Sprintf("summary of %d messages", len(req.Messages)), nil
+}
+
+func sessionWithTurns(turns int) *models.ChatSession {
+	session := &models.ChatSession{
+		CityName:       "Lisbon",
+		SessionContext: models.SessionContext{CityName: "Lisbon", ConversationSummary: "Trip plan for Lisbon"},
+		CurrentItinerary: &models.AiCityResponse{AIItineraryResponse: models.AIItineraryResponse{
+			PointsOfInterest: []models.POIDetailedInfo{{Name: "Belem Tower"}, {Name: "Time Out Market"}},
+		}},
+	}
+	for i := range turns {
+		session.ConversationHistory = append(session.ConversationHistory,
+			models.ConversationMessage{Role: models.RoleUser, Content: fmt.Sprintf("request %d %s", i, strings.Repeat("word ", 20))},
+			models.ConversationMessage{Role: models.RoleAssistant, Content: fmt.Sprintf("answer %d %s", i, strings.Repeat("word ", 20))},
+		)
+	}
+	return session
+}
+
+func TestManager_CompactWithinBudgetIsNoop(t *testing.T) {
+	summarizer := &recordingSummarizer{}
+	m := NewManager(summarizer, zap.NewNop())
+	session := sessionWithTurns(3)
+
+	changed, err := m.Compact(context.Background(), session)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, summarizer.requests)
+	assert.Len(t, m.Window(session).Recent, 6)
+}
+
+func TestManager_CompactRollsOldTurnsIntoSummary(t *testing.T) {
+	summarizer := &recordingSummarizer{}
+	m := NewManager(summarizer, zap.NewNop()).WithBudget(200, 4)
+	session := sessionWithTurns(5) // 10 messages of ~30 tokens
+
+	changed, err := m.Compact(context.Background(), session)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Len(t, summarizer.requests, 1)
+	req := summarizer.requests[0]
+	assert.Equal(t, "Lisbon", req.CityName)
+	assert.Equal(t, "Trip plan for Lisbon", req.PreviousSummary)
+	assert.Len(t, req.Messages, 6)
+	assert.Equal(t, session.ConversationHistory[0], req.Messages[0])
+
+	assert.Equal(t, "summary of 6 messages", session.SessionContext.ConversationSummary)
+	assert.Equal(t, 6, session.SessionContext.SummarizedMessages)
+	assert.Len(t, session.ConversationHistory, 10, "the raw history is kept for display")
+
+	window := m.Window(session)
+	assert.Equal(t, session.ConversationHistory[6:], window.Recent)
+	assert.Equal(t, "summary of 6 messages", window.Summary)
+
+	// The next compaction only sees messages after the summarised ones
+	session.ConversationHistory = append(session.ConversationHistory, sessionWithTurns(2).ConversationHistory...)
+	changed, err = m.Compact(context.Background(), session)
+	require.NoError(t, err)
+	require.True(t, changed)
+	assert.Equal(t, "summary of 6 messages", summarizer.requests[1].PreviousSummary)
+	assert.Equal(t, session.ConversationHistory[6:10], summarizer.requests[1].Messages)
+	assert.Equal(t, 10, session.SessionContext.SummarizedMessages)
+}
+
+func TestManager_SummaryFailureTrimsWindow(t *testing.T) {
+	m := NewManager(&recordingSummarizer{err: errors.New("quota exceeded")}, zap.NewNop()).WithBudget(100, 2)
+	session := sessionWithTurns(5)
+
+	changed, err := m.Compact(context.Background(), session)
+	assert.Error(t, err)
+	assert.False(t, changed)
+	assert.Zero(t, session.SessionContext.SummarizedMessages)
+
+	window := m.Window(session)
+	require.NotEmpty(t, window.Recent)
+	assert.Less(t, len(window.Recent), len(session.ConversationHistory))
+	assert.LessOrEqual(t, messageTokens(window.Recent), 100-EstimateTokens(window.Summary))
+	assert.Equal(t, session.ConversationHistory[len(session.ConversationHistory)-1], window.Recent[len(window.Recent)-1])
+}
+
+func TestWindow_PinnedFactsSurviveSummaries(t *testing.T) {
+	session := sessionWithTurns(1)
+	Reject(session, "museums")
+	Reject(session, "Museums")
+	Reject(session, "  ")
+	Reject(session, "Lisbon Zoo")
+
+	window := NewManager(&recordingSummarizer{}, zap.NewNop()).Window(session)
+	assert.Equal(t, PinnedFacts{
+		CityName:   "Lisbon",
+		ChosenPOIs: []string{"Belem Tower", "Time Out Market"},
+		Rejected:   []string{"museums", "Lisbon Zoo"},
+	}, window.Pinned)
+
+	text := window.String()
+	assert.Contains(t, text, "Trip city: Lisbon\n")
+	assert.Contains(t, text, "Already in the itinerary: Belem Tower, Time Out Market\n")
+	assert.Contains(t, text, "The user does not want: museums; Lisbon Zoo\n")
+	assert.Contains(t, text, "Conversation summary: Trip plan for Lisbon\n")
+	assert.Contains(t, text, "Recent messages:\nuser: request 0")
+	assert.False(t, strings.HasSuffix(text, "\n"))
+}
+
+func TestReject_KeepsLatestItems(t *testing.T) {
+	session := &models.ChatSession{}
+	for i := range maxRejectedItems + 5 {
+		Reject(session, fmt.Sprintf("place %d", i))
+	}
+	require.Len(t, session.SessionContext.RejectedItems, maxRejectedItems)
+	assert.Equal(t, "place 5", session.SessionContext.RejectedItems[0])
+}
+
+func TestLLMSummarizer(t *testing.T) {
+	provider := llmprovider.NewFakeProvider().
+		WithResponse("memory of a travel planning conversation", "  The user wants food markets and rejected museums. Extra words here.  ")
+	summary, err := NewLLMSummarizer(provider).Summarize(context.Background(), SummaryRequest{
+		CityName:        "Lisbon",
+		PreviousSummary: "Trip plan for Lisbon",
+		Messages:        []models.ConversationMessage{{Role: models.RoleUser, Content: "I don't want to visit museums"}},
+		MaxWords:        9,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "The user wants food markets and rejected museums. Extra", summary)
+
+	prompt := provider.Prompts()[0]
+	assert.Contains(t, prompt, "Summary so far:\nTrip plan for Lisbon\n")
+	assert.Contains(t, prompt, "user: I don't want to visit museums\n")
+	assert.Contains(t, prompt, "at most 9 words")
+}
@@ -0,0 +1,67 @@
+package chatmemory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+// SummaryRequest asks for the previous summary to be extended with older messages.
+type SummaryRequest struct {
+	CityName        string
+	PreviousSummary string
+	Messages        []models.ConversationMessage
+	MaxWords        int
+}
+
+// Summarizer condenses conversation turns into a running summary.
+type Summarizer interface {
+	Summarize(ctx context.Context, req SummaryRequest) (string, error)
+}
+
+// LLMSummarizer summarises with the chat LLM provider.
+type LLMSummarizer struct {
+	provider llmprovider.Provider
+}
+
+// NewLLMSummarizer creates a summarizer backed by provider.
+func NewLLMSummarizer(provider llmprovider.Provider) *LLMSummarizer {
+	return &LLMSummarizer{provider: provider}
+}
+
+func (s *LLMSummarizer) Summarize(ctx context.Context, req SummaryRequest) (string, error) {
+	params := prompts.ConversationSummaryParams{
+		CityName:        req.CityName,
+		PreviousSummary: req.PreviousSummary,
+		MaxWords:        req.MaxWords,
+	}
+	for _, m := range req.Messages {
+		params.Messages = append(params.Messages, prompts.ConversationTurn{Role: string(m.Role), Content: m.Content})
+	}
+	prompt, err := prompts.ConversationSummary.Render("", params)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.provider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](0.2),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate conversation summary: %w", err)
+	}
+	summary := strings.TrimSpace(llmprovider.TextFromResponse(resp))
+	if summary == "" {
+		return "", fmt.Errorf("empty conversation summary")
+	}
+	// Models overshoot word limits, keep the budget honest
+	if words := strings.Fields(summary); req.MaxWords > 0 && len(words) > req.MaxWords {
+		summary = strings.Join(words[:req.MaxWords], " ")
+	}
+	return summary, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -31,6 +32,69 @@ type RepositoriesConfig struct {
 
 type LLMConfig struct {
 	StreamEndpoint string
+	Provider       string // gemini, openai or fake; empty picks gemini when GEMINI_API_KEY is set
+	Model          string
+	EmbeddingModel string
+	BaseURL        string // OpenAI-compatible endpoint, e.g. http://localhost:11434/v1 for Ollama
+	APIKey         string
+	CassetteMode   string // record or replay LLM fixtures, see llmprovider.CassetteProvider
+	CassetteDir    string
+
+	SchemaRepairAttempts int    // Re-prompts allowed when a structured response fails its JSON schema
+	PromptSplits         string // Traffic split between prompt template versions, see prompts.Registry.ParseSplits
+
+	IntentClassifier  string  // embedding (default) or rules, the keyword classifier used before internal/pkg/intent
+	IntentConfidence  float64 // Nearest neighbour confidence below which the LLM labels the message instead
+	IntentLLMFallback bool    // Ask the LLM about low confidence messages
+
+	ChatContextTokens       int // Token budget of the conversation context sent to the model, older turns are summarised
+	ChatContextKeepMessages int // Latest messages always sent verbatim
+
+	ChatAgentMaxIterations int // Tool calls the chat agent may make per question, 0 answers questions without tools
+}
+
+type QuotaConfig struct {
+	Disabled bool // Skip the plan limits on searches, saved locations and paid features
+}
+
+// BudgetConfig holds the daily LLM spend limits in USD, zero disables a limit. Past a soft
+// limit cached answers are reused, past a hard limit the model is not called at all.
+type BudgetConfig struct {
+	UserDailySoftUSD   float64
+	UserDailyHardUSD   float64
+	GlobalDailySoftUSD float64
+	GlobalDailyHardUSD float64
+}
+
+// GeoCheckConfig tunes the geographic checks run on LLM-generated POIs before they are saved.
+type GeoCheckConfig struct {
+	MaxDistanceKm float64 // Distance from the city centre past which a POI fails, 0 disables the check
+	OnFailure     string  // flag, drop or regeocode
+}
+
+// POIMatchConfig tunes how generated POIs are matched to stored ones and duplicates merged.
+type POIMatchConfig struct {
+	Threshold    float64 // Weighted similarity score from which two POIs are the same place
+	MaxDistanceM float64 // POIs further apart than this are never the same place
+}
+
+// RoutingConfig selects how travel times between places are estimated.
+type RoutingConfig struct {
+	OSRMURL string        // OSRM-compatible server asked first, empty estimates from straight lines only
+	Timeout time.Duration // How long to wait for the server before estimating from straight lines
+}
+
+// FXConfig selects where exchange rates for cost estimates come from.
+type FXConfig struct {
+	RatesURL string        // Frankfurter-compatible service asked first, empty uses the offline table only
+	Timeout  time.Duration // How long to wait for the service before using the offline table
+}
+
+// WeatherConfig selects where forecasts for scheduled days come from.
+type WeatherConfig struct {
+	ForecastURL string        // Open-Meteo-compatible forecast endpoint, empty without forecasts
+	File        string        // JSON file of forecasts used instead of the service, for tests and demos
+	Timeout     time.Duration // How long to wait for the service before scheduling without forecasts
 }
 
 type MapConfig struct {
@@ -46,6 +110,13 @@ type Config struct {
 	ServerPort   string
 	JWT          JWTConfig
 	LLM          LLMConfig
+	Quota        QuotaConfig
+	Budget       BudgetConfig
+	GeoCheck     GeoCheckConfig
+	POIMatch     POIMatchConfig
+	Routing      RoutingConfig
+	FX           FXConfig
+	Weather      WeatherConfig
 	Map          MapConfig
 	OTEL         OTELConfig
 }
@@ -90,8 +161,133 @@ func Load() (*Config, error) {
 		RefreshTokenTTL: refreshTTL,
 	}
 
+	repairAttempts, err := strconv.Atoi(getEnvOrDefault("LLM_SCHEMA_REPAIR_ATTEMPTS", "2"))
+	if err != nil || repairAttempts < 0 {
+		return nil, fmt.Errorf("invalid LLM_SCHEMA_REPAIR_ATTEMPTS: %q", os.Getenv("LLM_SCHEMA_REPAIR_ATTEMPTS"))
+	}
+
+	intentConfidence, err := strconv.ParseFloat(getEnvOrDefault("INTENT_CONFIDENCE_THRESHOLD", "0.6"), 64)
+	if err != nil || intentConfidence < 0 || intentConfidence > 1 {
+		return nil, fmt.Errorf("invalid INTENT_CONFIDENCE_THRESHOLD: %q", os.Getenv("INTENT_CONFIDENCE_THRESHOLD"))
+	}
+	intentLLMFallback, err := strconv.ParseBool(getEnvOrDefault("INTENT_LLM_FALLBACK", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid INTENT_LLM_FALLBACK: %q", os.Getenv("INTENT_LLM_FALLBACK"))
+	}
+
+	chatContextTokens, err := strconv.Atoi(getEnvOrDefault("CHAT_CONTEXT_TOKENS", "3000"))
+	if err != nil || chatContextTokens <= 0 {
+		return nil, fmt.Errorf("invalid CHAT_CONTEXT_TOKENS: %q", os.Getenv("CHAT_CONTEXT_TOKENS"))
+	}
+	chatContextKeep, err := strconv.Atoi(getEnvOrDefault("CHAT_CONTEXT_KEEP_MESSAGES", "6"))
+	if err != nil || chatContextKeep <= 0 {
+		return nil, fmt.Errorf("invalid CHAT_CONTEXT_KEEP_MESSAGES: %q", os.Getenv("CHAT_CONTEXT_KEEP_MESSAGES"))
+	}
+
+	chatAgentIterations, err := strconv.Atoi(getEnvOrDefault("CHAT_AGENT_MAX_ITERATIONS", "4"))
+	if err != nil || chatAgentIterations < 0 {
+		return nil, fmt.Errorf("invalid CHAT_AGENT_MAX_ITERATIONS: %q", os.Getenv("CHAT_AGENT_MAX_ITERATIONS"))
+	}
+
 	cfg.LLM = LLMConfig{
 		StreamEndpoint: getEnvOrDefault("LLM_STREAM_ENDPOINT", "http://localhost:8000/api/v1/llm"),
+		Provider:       getEnvOrDefault("LLM_PROVIDER", ""),
+		Model:          getEnvOrDefault("LLM_MODEL", ""),
+		EmbeddingModel: getEnvOrDefault("LLM_EMBEDDING_MODEL", ""),
+		BaseURL:        getEnvOrDefault("LLM_BASE_URL", ""),
+		APIKey:         getEnvOrDefault("LLM_API_KEY", ""),
+		CassetteMode:   getEnvOrDefault("LLM_CASSETTE_MODE", ""),
+		CassetteDir:    getEnvOrDefault("LLM_CASSETTE_DIR", "testdata/cassettes"),
+
+		SchemaRepairAttempts: repairAttempts,
+		PromptSplits:         getEnvOrDefault("PROMPT_SPLITS", ""),
+
+		IntentClassifier:  getEnvOrDefault("INTENT_CLASSIFIER", "embedding"),
+		IntentConfidence:  intentConfidence,
+		IntentLLMFallback: intentLLMFallback,
+
+		ChatContextTokens:       chatContextTokens,
+		ChatContextKeepMessages: chatContextKeep,
+
+		ChatAgentMaxIterations: chatAgentIterations,
+	}
+
+	quotaDisabled, err := strconv.ParseBool(getEnvOrDefault("QUOTA_DISABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUOTA_DISABLED: %q", os.Getenv("QUOTA_DISABLED"))
+	}
+	cfg.Quota = QuotaConfig{
+		Disabled: quotaDisabled,
+	}
+
+	budgetLimits := map[string]*float64{
+		"LLM_BUDGET_USER_DAILY_SOFT_USD":   &cfg.Budget.UserDailySoftUSD,
+		"LLM_BUDGET_USER_DAILY_HARD_USD":   &cfg.Budget.UserDailyHardUSD,
+		"LLM_BUDGET_GLOBAL_DAILY_SOFT_USD": &cfg.Budget.GlobalDailySoftUSD,
+		"LLM_BUDGET_GLOBAL_DAILY_HARD_USD": &cfg.Budget.GlobalDailyHardUSD,
+	}
+	for key, limit := range budgetLimits {
+		v, err := strconv.ParseFloat(getEnvOrDefault(key, "0"), 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid %s: %q", key, os.Getenv(key))
+		}
+		*limit = v
+	}
+
+	maxDistanceKm, err := strconv.ParseFloat(getEnvOrDefault("GEOCHECK_MAX_DISTANCE_KM", "50"), 64)
+	if err != nil || maxDistanceKm < 0 {
+		return nil, fmt.Errorf("invalid GEOCHECK_MAX_DISTANCE_KM: %q", os.Getenv("GEOCHECK_MAX_DISTANCE_KM"))
+	}
+	onFailure := getEnvOrDefault("GEOCHECK_ON_FAILURE", "regeocode")
+	switch onFailure {
+	case "flag", "drop", "regeocode":
+	default:
+		return nil, fmt.Errorf("invalid GEOCHECK_ON_FAILURE: %q", onFailure)
+	}
+	cfg.GeoCheck = GeoCheckConfig{
+		MaxDistanceKm: maxDistanceKm,
+		OnFailure:     onFailure,
+	}
+
+	matchThreshold, err := strconv.ParseFloat(getEnvOrDefault("POI_MATCH_THRESHOLD", "0.6"), 64)
+	if err != nil || matchThreshold <= 0 || matchThreshold > 1 {
+		return nil, fmt.Errorf("invalid POI_MATCH_THRESHOLD: %q", os.Getenv("POI_MATCH_THRESHOLD"))
+	}
+	matchDistanceM, err := strconv.ParseFloat(getEnvOrDefault("POI_MATCH_MAX_DISTANCE_M", "250"), 64)
+	if err != nil || matchDistanceM <= 0 {
+		return nil, fmt.Errorf("invalid POI_MATCH_MAX_DISTANCE_M: %q", os.Getenv("POI_MATCH_MAX_DISTANCE_M"))
+	}
+	cfg.POIMatch = POIMatchConfig{
+		Threshold:    matchThreshold,
+		MaxDistanceM: matchDistanceM,
+	}
+
+	routingTimeout, err := time.ParseDuration(getEnvOrDefault("ROUTING_TIMEOUT", "3s"))
+	if err != nil || routingTimeout <= 0 {
+		return nil, fmt.Errorf("invalid ROUTING_TIMEOUT: %q", os.Getenv("ROUTING_TIMEOUT"))
+	}
+	cfg.Routing = RoutingConfig{
+		OSRMURL: getEnvOrDefault("ROUTING_OSRM_URL", ""),
+		Timeout: routingTimeout,
+	}
+
+	fxTimeout, err := time.ParseDuration(getEnvOrDefault("FX_TIMEOUT", "3s"))
+	if err != nil || fxTimeout <= 0 {
+		return nil, fmt.Errorf("invalid FX_TIMEOUT: %q", os.Getenv("FX_TIMEOUT"))
+	}
+	cfg.FX = FXConfig{
+		RatesURL: getEnvOrDefault("FX_RATES_URL", ""),
+		Timeout:  fxTimeout,
+	}
+
+	weatherTimeout, err := time.ParseDuration(getEnvOrDefault("WEATHER_TIMEOUT", "3s"))
+	if err != nil || weatherTimeout <= 0 {
+		return nil, fmt.Errorf("invalid WEATHER_TIMEOUT: %q", os.Getenv("WEATHER_TIMEOUT"))
+	}
+	cfg.Weather = WeatherConfig{
+		ForecastURL: getEnvOrDefault("WEATHER_FORECAST_URL", ""),
+		File:        getEnvOrDefault("WEATHER_FILE", ""),
+		Timeout:     weatherTimeout,
 	}
 
 	cfg.Map = MapConfig{
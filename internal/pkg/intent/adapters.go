@@ -0,0 +1,79 @@
+package intent
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+// IntentClassifier decides whether a chat message adds, removes, asks about or otherwise
+// modifies an itinerary. It satisfies llmchat.IntentClassifier.
+type IntentClassifier struct {
+	*Classifier
+}
+
+// NewIntentClassifier builds the itinerary intent classifier from the embedded examples,
+// falling back to models.SimpleIntentClassifier when embeddings fail.
+func NewIntentClassifier(provider llmprovider.Provider, logger *zap.Logger) (*IntentClassifier, error) {
+	set, err := LoadExampleSet("intents")
+	if err != nil {
+		return nil, err
+	}
+	rules := &models.SimpleIntentClassifier{}
+	c := NewClassifier(set, provider, logger).WithRules(func(ctx context.Context, text string) (string, error) {
+		intent, err := rules.Classify(ctx, text)
+		return string(intent), err
+	})
+	return &IntentClassifier{Classifier: c}, nil
+}
+
+// Classify returns the intent of message.
+func (c *IntentClassifier) Classify(ctx context.Context, message string) (models.IntentType, error) {
+	pred, err := c.Classifier.Classify(ctx, message)
+	if err != nil {
+		return models.IntentModifyItinerary, err
+	}
+	c.logger.Debug("Intent classified",
+		zap.String("intent", pred.Label),
+		zap.Float64("confidence", pred.Confidence),
+		zap.String("source", pred.Source))
+	return models.IntentType(pred.Label), nil
+}
+
+// DomainClassifier decides which search domain (hotels, restaurants, activities, itinerary)
+// a message is about. It has the same method as models.DomainDetector.
+type DomainClassifier struct {
+	*Classifier
+}
+
+// NewDomainClassifier builds the domain classifier from the embedded examples, falling
+// back to models.DomainDetector when embeddings fail.
+func NewDomainClassifier(provider llmprovider.Provider, logger *zap.Logger) (*DomainClassifier, error) {
+	set, err := LoadExampleSet("domains")
+	if err != nil {
+		return nil, err
+	}
+	rules := &models.DomainDetector{}
+	c := NewClassifier(set, provider, logger).WithRules(func(ctx context.Context, text string) (string, error) {
+		return string(rules.DetectDomain(ctx, text)), nil
+	})
+	return &DomainClassifier{Classifier: c}, nil
+}
+
+// DetectDomain returns the domain of message, or models.DomainGeneral when it cannot be
+// classified at all.
+func (c *DomainClassifier) DetectDomain(ctx context.Context, message string) models.DomainType {
+	pred, err := c.Classifier.Classify(ctx, message)
+	if err != nil {
+		c.logger.Warn("Domain classification failed", zap.Error(err))
+		return models.DomainGeneral
+	}
+	c.logger.Debug("Domain classified",
+		zap.String("domain", pred.Label),
+		zap.Float64("confidence", pred.Confidence),
+		zap.String("source", pred.Source))
+	return models.DomainType(pred.Label)
+}
@@ -0,0 +1,282 @@
+// Package intent classifies chat messages by comparing their embeddings with labelled
+// example utterances.
+//
+// A message is embedded with the configured provider and its k nearest examples vote for
+// a label, weighted by cosine similarity. The winning share of the vote is the prediction
+// confidence. Below the confidence threshold the LLM is asked to pick a label instead, with
+// the nearest examples as hints, and when embeddings are unavailable altogether the
+// keyword rules in models take over so chat keeps working.
+package intent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+)
+
+const (
+	defaultK         = 5
+	defaultThreshold = 0.6
+)
+
+// Where a prediction came from.
+const (
+	SourceEmbedding = "embedding"
+	SourceLLM       = "llm"
+	SourceRules     = "rules"
+)
+
+// Neighbour is a labelled example close to the classified message.
+type Neighbour struct {
+	Example
+	Similarity float64
+}
+
+// Prediction is the label picked for a message.
+type Prediction struct {
+	Label      string
+	Confidence float64
+	Source     string
+	Nearest    []Neighbour
+}
+
+// RuleFunc is the last resort classifier used when no embedding can be computed.
+type RuleFunc func(ctx context.Context, text string) (string, error)
+
+// Classifier is a nearest neighbour classifier over the embeddings of an ExampleSet.
+type Classifier struct {
+	logger    *zap.Logger
+	provider  llmprovider.Provider
+	set       ExampleSet
+	k         int
+	threshold float64
+	useLLM    bool
+	rules     RuleFunc
+
+	mu      sync.Mutex
+	vectors [][]float32 // Embeddings of set.Examples, computed on first use
+}
+
+// NewClassifier creates a classifier for set, embedding with provider. The LLM fallback is
+// enabled by default.
+func NewClassifier(set ExampleSet, provider llmprovider.Provider, logger *zap.Logger) *Classifier {
+	return &Classifier{
+		logger:    logger,
+		provider:  provider,
+		set:       set,
+		k:         defaultK,
+		threshold: defaultThreshold,
+		useLLM:    true,
+	}
+}
+
+// WithK sets how many nearest examples vote.
+func (c *Classifier) WithK(k int) *Classifier {
+	if k > 0 {
+		c.k = k
+	}
+	return c
+}
+
+// WithThreshold sets the confidence below which the LLM fallback is consulted.
+func (c *Classifier) WithThreshold(threshold float64) *Classifier {
+	if threshold >= 0 && threshold <= 1 {
+		c.threshold = threshold
+	}
+	return c
+}
+
+// WithLLMFallback enables or disables asking the LLM about low confidence messages.
+func (c *Classifier) WithLLMFallback(enabled bool) *Classifier {
+	c.useLLM = enabled
+	return c
+}
+
+// WithRules sets the classifier used when embeddings fail.
+func (c *Classifier) WithRules(rules RuleFunc) *Classifier {
+	c.rules = rules
+	return c
+}
+
+// Classify predicts the label of text.
+func (c *Classifier) Classify(ctx context.Context, text string) (Prediction, error) {
+	ctx, span := otel.Tracer("IntentClassifier").Start(ctx, "Classify")
+	defer span.End()
+	span.SetAttributes(attribute.String("classifier.task", c.set.Task))
+
+	pred, err := c.nearest(ctx, text)
+	if err != nil {
+		span.RecordError(err)
+		if c.rules == nil {
+			span.SetStatus(codes.Error, "embedding failed")
+			return Prediction{}, err
+		}
+		c.logger.Warn("Embedding classification failed, using keyword rules",
+			zap.String("task", c.set.Task), zap.Error(err))
+		label, ruleErr := c.rules(ctx, text)
+		if ruleErr != nil {
+			span.SetStatus(codes.Error, "rules failed")
+			return Prediction{}, fmt.Errorf("failed to classify message: %w", ruleErr)
+		}
+		pred = Prediction{Label: label, Source: SourceRules}
+	} else if pred.Confidence < c.threshold && c.useLLM {
+		llmPred, llmErr := c.askLLM(ctx, text, pred.Nearest)
+		if llmErr != nil {
+			span.RecordError(llmErr)
+			c.logger.Warn("LLM classification fallback failed, keeping nearest neighbour label",
+				zap.String("task", c.set.Task), zap.String("label", pred.Label),
+				zap.Float64("confidence", pred.Confidence), zap.Error(llmErr))
+		} else {
+			llmPred.Nearest = pred.Nearest
+			pred = llmPred
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("classifier.label", pred.Label),
+		attribute.Float64("classifier.confidence", pred.Confidence),
+		attribute.String("classifier.source", pred.Source),
+	)
+	span.SetStatus(codes.Ok, "classified")
+	return pred, nil
+}
+
+// nearest runs the k nearest neighbour vote.
+func (c *Classifier) nearest(ctx context.Context, text string) (Prediction, error) {
+	vectors, err := c.exampleVectors(ctx)
+	if err != nil {
+		return Prediction{}, err
+	}
+	query, err := c.provider.GenerateQueryEmbedding(ctx, text)
+	if err != nil {
+		return Prediction{}, fmt.Errorf("failed to embed message: %w", err)
+	}
+
+	neighbours := make([]Neighbour, len(vectors))
+	for i, v := range vectors {
+		neighbours[i] = Neighbour{Example: c.set.Examples[i], Similarity: cosine(query, v)}
+	}
+	sort.SliceStable(neighbours, func(i, j int) bool {
+		return neighbours[i].Similarity > neighbours[j].Similarity
+	})
+	nearest := neighbours[:min(c.k, len(neighbours))]
+
+	votes := make(map[string]float64)
+	var total float64
+	for _, n := range nearest {
+		if n.Similarity <= 0 {
+			continue
+		}
+		votes[n.Label] += n.Similarity
+		total += n.Similarity
+	}
+	if total == 0 {
+		// Nothing in common with any example, let the fallback decide
+		return Prediction{Label: c.set.Default, Source: SourceEmbedding, Nearest: nearest}, nil
+	}
+
+	var best string
+	for _, label := range c.set.labelNames() {
+		if votes[label] > votes[best] {
+			best = label
+		}
+	}
+	return Prediction{
+		Label:      best,
+		Confidence: votes[best] / total,
+		Source:     SourceEmbedding,
+		Nearest:    nearest,
+	}, nil
+}
+
+// exampleVectors embeds the examples once. A failure is not cached so the next message
+// retries, e.g. after the embedding API recovers.
+func (c *Classifier) exampleVectors(ctx context.Context) ([][]float32, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.vectors != nil {
+		return c.vectors, nil
+	}
+
+	vectors := make([][]float32, len(c.set.Examples))
+	for i, ex := range c.set.Examples {
+		v, err := c.provider.GenerateQueryEmbedding(ctx, ex.Text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed %s example %q: %w", c.set.Task, ex.Text, err)
+		}
+		vectors[i] = v
+	}
+	c.vectors = vectors
+	c.logger.Info("Embedded classifier examples",
+		zap.String("task", c.set.Task), zap.Int("examples", len(vectors)))
+	return vectors, nil
+}
+
+type llmLabel struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+}
+
+// askLLM asks the model to pick one of the labels, showing it the nearest examples.
+func (c *Classifier) askLLM(ctx context.Context, text string, nearest []Neighbour) (Prediction, error) {
+	params := prompts.ClassifyParams{Task: c.set.Task, Message: text}
+	for _, l := range c.set.Labels {
+		params.Labels = append(params.Labels, prompts.ClassifyLabel{Name: l.Name, Description: l.Description})
+	}
+	for _, n := range nearest {
+		params.Examples = append(params.Examples, prompts.ClassifyExample{Text: n.Text, Label: n.Label})
+	}
+	prompt, err := prompts.ClassifyUtterance.Render("", params)
+	if err != nil {
+		return Prediction{}, err
+	}
+
+	resp, err := c.provider.GenerateResponse(ctx, prompt.Text, &genai.GenerateContentConfig{
+		Temperature: genai.Ptr[float32](0),
+	})
+	if err != nil {
+		return Prediction{}, fmt.Errorf("failed to generate classification: %w", err)
+	}
+
+	raw := llmprovider.TextFromResponse(resp)
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return Prediction{}, fmt.Errorf("classification response is not JSON: %q", raw)
+	}
+	var out llmLabel
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &out); err != nil {
+		return Prediction{}, fmt.Errorf("failed to parse classification response: %w", err)
+	}
+	out.Label = strings.TrimSpace(strings.ToLower(out.Label))
+	if !slices.Contains(c.set.labelNames(), out.Label) {
+		return Prediction{}, fmt.Errorf("classification response has unknown label %q", out.Label)
+	}
+	return Prediction{Label: out.Label, Confidence: min(max(out.Confidence, 0), 1), Source: SourceLLM}, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,48 @@
+//go:build integration
+
+package intent
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+// TestClassifiers_AccuracyWithConfiguredProvider runs the evaluation sets against the
+// provider configured through LLM_PROVIDER, LLM_EMBEDDING_MODEL, etc., with the LLM
+// fallback enabled as in production.
+func TestClassifiers_AccuracyWithConfiguredProvider(t *testing.T) {
+	if os.Getenv("LLM_PROVIDER") == "" && os.Getenv("GEMINI_API_KEY") == "" {
+		t.Skip("LLM_PROVIDER or GEMINI_API_KEY not set, skipping classifier evaluation against a real provider")
+	}
+	ctx := context.Background()
+	logger := zap.NewNop()
+	provider, err := llmprovider.New(ctx, llmprovider.Config{
+		Provider:       os.Getenv("LLM_PROVIDER"),
+		Model:          os.Getenv("LLM_MODEL"),
+		EmbeddingModel: os.Getenv("LLM_EMBEDDING_MODEL"),
+		BaseURL:        os.Getenv("LLM_BASE_URL"),
+		APIKey:         os.Getenv("LLM_API_KEY"),
+	}, logger)
+	require.NoError(t, err)
+
+	intents, err := NewIntentClassifier(provider, logger)
+	require.NoError(t, err)
+	report, err := Evaluate(ctx, readEvalSet(t, "intents"), intents.Classifier.Classify)
+	require.NoError(t, err)
+	logReport(t, provider.Name()+" intents", report)
+	assert.GreaterOrEqual(t, report.Accuracy, minIntentAccuracy)
+
+	domains, err := NewDomainClassifier(provider, logger)
+	require.NoError(t, err)
+	report, err = Evaluate(ctx, readEvalSet(t, "domains"), domains.Classifier.Classify)
+	require.NoError(t, err)
+	logReport(t, provider.Name()+" domains", report)
+	assert.GreaterOrEqual(t, report.Accuracy, minDomainAccuracy)
+}
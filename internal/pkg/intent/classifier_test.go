@@ -0,0 +1,276 @@
+package intent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+const (
+	minIntentAccuracy = 0.85
+	minDomainAccuracy = 0.85
+	minLabelAccuracy  = 0.7 // Of the evaluation examples of every single label
+)
+
+// cassetteDir holds embeddings recorded from a real provider for every example and
+// evaluation text. The fake provider's hashed embeddings carry no meaning, so accuracy is
+// only ever measured on these.
+const cassetteDir = "testdata/cassettes"
+
+// recordedEmbeddings returns a provider replaying the recorded embeddings of the example set
+// and evaluation set called name. With LLM_CASSETTE_MODE=record it embeds through the
+// provider configured by LLM_PROVIDER, LLM_EMBEDDING_MODEL, etc. and records the results.
+// The test fails when any text has no embedding recorded from a real provider.
+func recordedEmbeddings(t *testing.T, name string) llmprovider.Provider {
+	t.Helper()
+	logger := zap.NewNop()
+	if os.Getenv("LLM_CASSETTE_MODE") == llmprovider.CassetteRecord {
+		provider, err := llmprovider.New(context.Background(), llmprovider.Config{
+			Provider:       os.Getenv("LLM_PROVIDER"),
+			Model:          os.Getenv("LLM_MODEL"),
+			EmbeddingModel: os.Getenv("LLM_EMBEDDING_MODEL"),
+			BaseURL:        os.Getenv("LLM_BASE_URL"),
+			APIKey:         os.Getenv("LLM_API_KEY"),
+			CassetteMode:   llmprovider.CassetteRecord,
+			CassetteDir:    cassetteDir,
+		}, logger)
+		require.NoError(t, err)
+		require.NotEqual(t, llmprovider.ProviderFake, provider.Name(), "record embeddings from a real provider")
+		return provider
+	}
+
+	player, err := llmprovider.NewCassetteProvider(llmprovider.CassetteReplay, cassetteDir, nil, logger)
+	require.NoError(t, err)
+	set, err := LoadExampleSet(name)
+	require.NoError(t, err)
+	var missing []string
+	for _, ex := range append(set.Examples, readEvalSet(t, name)...) {
+		c, err := player.Load("embedding", ex.Text)
+		if err != nil || c.Provider == llmprovider.ProviderFake || len(c.Embedding) == 0 {
+			missing = append(missing, ex.Text)
+		}
+	}
+	if len(missing) > 0 {
+		t.Fatalf("%d %s texts have no embedding recorded from a real provider in %s, e.g. %q. Record them with "+
+			"LLM_CASSETTE_MODE=record GEMINI_API_KEY=... go test -run Accuracy ./internal/pkg/intent",
+			len(missing), name, cassetteDir, missing[0])
+	}
+	return player
+}
+
+func readEvalSet(t *testing.T, name string) []Example {
+	t.Helper()
+	f, err := os.Open("testdata/" + name + "_eval.jsonl")
+	require.NoError(t, err)
+	defer f.Close()
+	examples, err := ReadExamples(f)
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+	return examples
+}
+
+func logReport(t *testing.T, name string, report Report) {
+	t.Helper()
+	t.Logf("%s: %d/%d correct (%.1f%%), sources %v", name, report.Correct, report.Total, report.Accuracy*100, report.Sources)
+	for _, m := range report.Misses {
+		t.Logf("  miss: %q expected %s, got %s (%.2f via %s)", m.Text, m.Label, m.Predicted, m.Confidence, m.Source)
+	}
+}
+
+func assertLabelAccuracy(t *testing.T, set string, report Report) {
+	t.Helper()
+	labels, err := LoadExampleSet(set)
+	require.NoError(t, err)
+	accuracy := report.LabelAccuracy()
+	for _, label := range labels.labelNames() {
+		if _, evaluated := report.Confusion[label]; !evaluated {
+			continue
+		}
+		assert.GreaterOrEqual(t, accuracy[label], minLabelAccuracy, "accuracy of %s", label)
+	}
+}
+
+func TestExampleSets_AreValid(t *testing.T) {
+	for _, name := range []string{"intents", "domains"} {
+		set, err := LoadExampleSet(name)
+		require.NoError(t, err, name)
+
+		seen := make(map[string]bool)
+		for _, ex := range set.Examples {
+			assert.False(t, seen[ex.Text], "duplicate example %q", ex.Text)
+			seen[ex.Text] = true
+		}
+		for _, ex := range readEvalSet(t, name) {
+			assert.False(t, seen[ex.Text], "evaluation example %q is also a training example", ex.Text)
+			assert.Contains(t, set.labelNames(), ex.Label)
+		}
+	}
+}
+
+func TestIntentClassifier_Accuracy(t *testing.T) {
+	ctx := context.Background()
+	examples := readEvalSet(t, "intents")
+
+	classifier, err := NewIntentClassifier(recordedEmbeddings(t, "intents"), zap.NewNop())
+	require.NoError(t, err)
+	classifier.WithLLMFallback(false)
+
+	report, err := Evaluate(ctx, examples, classifier.Classifier.Classify)
+	require.NoError(t, err)
+	logReport(t, "embedding", report)
+	require.Equal(t, report.Total, report.Sources[SourceEmbedding], "every message must be classified by its recorded embedding")
+
+	rules := &models.SimpleIntentClassifier{}
+	baseline, err := Evaluate(ctx, examples, func(ctx context.Context, text string) (Prediction, error) {
+		intent, err := rules.Classify(ctx, text)
+		return Prediction{Label: string(intent), Source: SourceRules}, err
+	})
+	require.NoError(t, err)
+	logReport(t, "keyword rules", baseline)
+
+	assert.GreaterOrEqual(t, report.Accuracy, minIntentAccuracy)
+	assertLabelAccuracy(t, "intents", report)
+	assert.Greater(t, report.Accuracy, baseline.Accuracy)
+}
+
+func TestDomainClassifier_Accuracy(t *testing.T) {
+	ctx := context.Background()
+	examples := readEvalSet(t, "domains")
+
+	classifier, err := NewDomainClassifier(recordedEmbeddings(t, "domains"), zap.NewNop())
+	require.NoError(t, err)
+	classifier.WithLLMFallback(false)
+
+	report, err := Evaluate(ctx, examples, classifier.Classifier.Classify)
+	require.NoError(t, err)
+	logReport(t, "embedding", report)
+	require.Equal(t, report.Total, report.Sources[SourceEmbedding], "every message must be classified by its recorded embedding")
+
+	detector := &models.DomainDetector{}
+	baseline, err := Evaluate(ctx, examples, func(ctx context.Context, text string) (Prediction, error) {
+		return Prediction{Label: string(detector.DetectDomain(ctx, text)), Source: SourceRules}, nil
+	})
+	require.NoError(t, err)
+	logReport(t, "keyword rules", baseline)
+
+	assert.GreaterOrEqual(t, report.Accuracy, minDomainAccuracy)
+	assertLabelAccuracy(t, "domains", report)
+	assert.Greater(t, report.Accuracy, baseline.Accuracy)
+}
+
+func TestIntentClassifier_Negation(t *testing.T) {
+	classifier, err := NewIntentClassifier(recordedEmbeddings(t, "intents"), zap.NewNop())
+	require.NoError(t, err)
+	classifier.WithLLMFallback(false)
+
+	intent, err := classifier.Classify(context.Background(), "I don't want to visit museums")
+	require.NoError(t, err)
+	assert.Equal(t, models.IntentRemovePOI, intent)
+
+	rules, err := (&models.SimpleIntentClassifier{}).Classify(context.Background(), "I don't want to visit museums")
+	require.NoError(t, err)
+	assert.Equal(t, models.IntentAddPOI, rules, "the keyword rules get this wrong, which is why the classifier exists")
+}
+
+func TestClassifier_LLMFallbackBelowThreshold(t *testing.T) {
+	set, err := LoadExampleSet("intents")
+	require.NoError(t, err)
+	provider := llmprovider.NewFakeProvider().
+		WithResponse("Classify a message", "```json\n{\"label\": \"Remove_POI\", \"confidence\": 0.9}\n```")
+
+	// A threshold of 1 sends every message that is not unanimous to the LLM
+	c := NewClassifier(set, provider, zap.NewNop()).WithThreshold(1)
+	pred, err := c.Classify(context.Background(), "museums are not really our thing")
+	require.NoError(t, err)
+	assert.Equal(t, SourceLLM, pred.Source)
+	assert.Equal(t, "remove_poi", pred.Label)
+	assert.InDelta(t, 0.9, pred.Confidence, 1e-9)
+	assert.NotEmpty(t, pred.Nearest)
+
+	prompts := provider.Prompts()
+	require.NotEmpty(t, prompts)
+	assert.Contains(t, prompts[len(prompts)-1], `Message: "museums are not really our thing"`)
+	assert.Contains(t, prompts[len(prompts)-1], "- remove_poi: ")
+
+	// An unknown label from the LLM keeps the nearest neighbour answer
+	provider = llmprovider.NewFakeProvider().WithResponse("Classify a message", `{"label": "book_flight", "confidence": 1}`)
+	c = NewClassifier(set, provider, zap.NewNop()).WithThreshold(1)
+	pred, err = c.Classify(context.Background(), "museums are not really our thing")
+	require.NoError(t, err)
+	assert.Equal(t, SourceEmbedding, pred.Source)
+	assert.Contains(t, set.labelNames(), pred.Label)
+}
+
+type failingEmbedder struct {
+	*llmprovider.FakeProvider
+	err error
+}
+
+func (p *failingEmbedder) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.FakeProvider.GenerateQueryEmbedding(ctx, query)
+}
+
+func TestClassifier_RulesWhenEmbeddingsFail(t *testing.T) {
+	provider := &failingEmbedder{FakeProvider: llmprovider.NewFakeProvider(), err: errors.New("quota exceeded")}
+	classifier, err := NewIntentClassifier(provider, zap.NewNop())
+	require.NoError(t, err)
+
+	pred, err := classifier.Classifier.Classify(context.Background(), "Remove the castle")
+	require.NoError(t, err)
+	assert.Equal(t, Prediction{Label: string(models.IntentRemovePOI), Source: SourceRules}, pred)
+
+	// Example embeddings are retried once the provider recovers
+	provider.err = nil
+	pred, err = classifier.Classifier.Classify(context.Background(), "Remove the castle")
+	require.NoError(t, err)
+	assert.Equal(t, SourceEmbedding, pred.Source)
+
+	_, err = NewClassifier(classifier.set, &failingEmbedder{FakeProvider: llmprovider.NewFakeProvider(), err: errors.New("down")}, zap.NewNop()).
+		Classify(context.Background(), "Remove the castle")
+	assert.Error(t, err, "without rules an embedding failure is returned")
+}
+
+func TestReport_LabelAccuracy(t *testing.T) {
+	examples := []Example{
+		{Text: "add the castle", Label: "add_poi"},
+		{Text: "add the museum", Label: "add_poi"},
+		{Text: "drop the castle", Label: "remove_poi"},
+		{Text: "drop the museum", Label: "remove_poi"},
+	}
+	report, err := Evaluate(context.Background(), examples, func(_ context.Context, text string) (Prediction, error) {
+		return Prediction{Label: "add_poi", Source: SourceEmbedding}, nil
+	})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, report.Accuracy, 1e-9)
+	assert.Equal(t, map[string]float64{"add_poi": 1, "remove_poi": 0}, report.LabelAccuracy(),
+		"a label that is never predicted fails even when the overall accuracy looks fine")
+}
+
+func TestExampleSet_Validate(t *testing.T) {
+	set := ExampleSet{
+		Task:     "test",
+		Default:  "a",
+		Labels:   []Label{{Name: "a"}},
+		Examples: []Example{{Text: "x", Label: "a"}},
+	}
+	require.NoError(t, set.Validate())
+
+	bad := set
+	bad.Default = "b"
+	assert.Error(t, bad.Validate())
+
+	bad = set
+	bad.Examples = []Example{{Text: "x", Label: "b"}}
+	assert.Error(t, bad.Validate())
+}
@@ -0,0 +1,69 @@
+package intent
+
+import (
+	"context"
+	"fmt"
+)
+
+// Miss is an evaluation example that was classified wrongly.
+type Miss struct {
+	Example
+	Predicted  string
+	Confidence float64
+	Source     string
+}
+
+// Report summarises a classifier run over a labelled evaluation set.
+type Report struct {
+	Total     int
+	Correct   int
+	Accuracy  float64
+	Sources   map[string]int            // Predictions per source (embedding, llm, rules)
+	Confusion map[string]map[string]int // Expected label -> predicted label -> count
+	Misses    []Miss
+}
+
+// Evaluate classifies every example with classify and compares the result to its label.
+func Evaluate(ctx context.Context, examples []Example, classify func(ctx context.Context, text string) (Prediction, error)) (Report, error) {
+	report := Report{
+		Sources:   make(map[string]int),
+		Confusion: make(map[string]map[string]int),
+	}
+	for _, ex := range examples {
+		pred, err := classify(ctx, ex.Text)
+		if err != nil {
+			return Report{}, fmt.Errorf("failed to classify %q: %w", ex.Text, err)
+		}
+		report.Total++
+		report.Sources[pred.Source]++
+		if report.Confusion[ex.Label] == nil {
+			report.Confusion[ex.Label] = make(map[string]int)
+		}
+		report.Confusion[ex.Label][pred.Label]++
+		if pred.Label == ex.Label {
+			report.Correct++
+			continue
+		}
+		report.Misses = append(report.Misses, Miss{Example: ex, Predicted: pred.Label, Confidence: pred.Confidence, Source: pred.Source})
+	}
+	if report.Total > 0 {
+		report.Accuracy = float64(report.Correct) / float64(report.Total)
+	}
+	return report, nil
+}
+
+// LabelAccuracy returns the share of the examples of each label that were classified
+// correctly, so a label the classifier keeps missing is not hidden by the others.
+func (r Report) LabelAccuracy() map[string]float64 {
+	accuracy := make(map[string]float64, len(r.Confusion))
+	for label, predicted := range r.Confusion {
+		var total int
+		for _, n := range predicted {
+			total += n
+		}
+		if total > 0 {
+			accuracy[label] = float64(predicted[label]) / float64(total)
+		}
+	}
+	return accuracy
+}
@@ -0,0 +1,99 @@
+package intent
+
+import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//go:embed examples/*.json
+var exampleFiles embed.FS
+
+// Example is a labelled utterance.
+type Example struct {
+	Text  string `json:"text"`
+	Label string `json:"label"`
+}
+
+// Label is a class the classifier can predict. The description is shown to the LLM fallback.
+type Label struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ExampleSet is the labelled data for one classification task.
+type ExampleSet struct {
+	Task     string    `json:"task"`
+	Default  string    `json:"default"` // Label used when a message shares nothing with any example
+	Labels   []Label   `json:"labels"`
+	Examples []Example `json:"examples"`
+}
+
+func (s ExampleSet) labelNames() []string {
+	names := make([]string, len(s.Labels))
+	for i, l := range s.Labels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// Validate checks that the default and every example use a declared label.
+func (s ExampleSet) Validate() error {
+	known := make(map[string]bool, len(s.Labels))
+	for _, l := range s.Labels {
+		known[l.Name] = true
+	}
+	if !known[s.Default] {
+		return fmt.Errorf("%s examples: default label %q is not declared", s.Task, s.Default)
+	}
+	if len(s.Examples) == 0 {
+		return fmt.Errorf("%s examples: no examples", s.Task)
+	}
+	for _, ex := range s.Examples {
+		if !known[ex.Label] {
+			return fmt.Errorf("%s examples: %q has undeclared label %q", s.Task, ex.Text, ex.Label)
+		}
+	}
+	return nil
+}
+
+// LoadExampleSet reads one of the embedded example sets, e.g. "intents" or "domains".
+func LoadExampleSet(name string) (ExampleSet, error) {
+	data, err := exampleFiles.ReadFile("examples/" + name + ".json")
+	if err != nil {
+		return ExampleSet{}, fmt.Errorf("failed to read %s examples: %w", name, err)
+	}
+	var set ExampleSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return ExampleSet{}, fmt.Errorf("failed to parse %s examples: %w", name, err)
+	}
+	if err := set.Validate(); err != nil {
+		return ExampleSet{}, err
+	}
+	return set, nil
+}
+
+// ReadExamples parses JSON lines of {"text": ..., "label": ...}, the format of the
+// labelled evaluation sets in testdata. Blank lines and lines starting with # are skipped.
+func ReadExamples(r io.Reader) ([]Example, error) {
+	var examples []Example
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var ex Example
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		examples = append(examples, ex)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read examples: %w", err)
+	}
+	return examples, nil
+}
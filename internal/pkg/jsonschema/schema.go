@@ -0,0 +1,259 @@
+// Package jsonschema validates decoded JSON documents against a pragmatic subset of
+// JSON Schema (draft 2020-12): type, properties, required, items, enum, minimum,
+// maximum, minItems, maxItems, minLength and local $ref into $defs. It is meant for the
+// LLM payload schemas shipped with the app, not for arbitrary third-party schemas.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is a compiled schema node.
+type Schema struct {
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        typeList           `json:"type,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Defs        map[string]*Schema `json:"$defs,omitempty"`
+
+	source []byte
+	ref    *Schema
+}
+
+// typeList accepts both "type": "string" and "type": ["string", "null"].
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings: %w", err)
+	}
+	*t = many
+	return nil
+}
+
+// ValidationError describes one violation. Path is a JSON Pointer to the offending value.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return "/: " + e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Errors joins validation errors into a single error, or returns nil when there are none.
+func Errors(errs []ValidationError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Errorf("schema validation failed: %s", strings.Join(msgs, "; "))
+}
+
+// Compile parses a schema document and resolves its references.
+func Compile(data []byte) (*Schema, error) {
+	var root Schema
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	root.source = bytes.TrimSpace(data)
+	if err := root.resolve(&root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// MustCompile is like Compile but panics on error. Use it for embedded schemas only.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Source returns the schema document the root was compiled from.
+func (s *Schema) Source() string {
+	return string(s.source)
+}
+
+func (s *Schema) resolve(root *Schema) error {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		if !ok {
+			return fmt.Errorf("unsupported $ref %q, only local #/$defs references are allowed", s.Ref)
+		}
+		target, ok := root.Defs[name]
+		if !ok {
+			return fmt.Errorf("unresolved $ref %q", s.Ref)
+		}
+		s.ref = target
+	}
+	for _, child := range s.Defs {
+		if err := child.resolve(root); err != nil {
+			return err
+		}
+	}
+	for _, child := range s.Properties {
+		if err := child.resolve(root); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.resolve(root)
+	}
+	return nil
+}
+
+// ValidateJSON decodes data and validates it. Malformed JSON is reported as a validation
+// error at the document root so callers can treat both cases alike.
+func (s *Schema) ValidateJSON(data []byte) []ValidationError {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []ValidationError{{Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+	return s.Validate(doc)
+}
+
+// Validate checks a document produced by encoding/json (map[string]any, []any, float64,
+// string, bool or nil).
+func (s *Schema) Validate(doc any) []ValidationError {
+	var errs []ValidationError
+	s.validate(doc, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(v any, path string, errs *[]ValidationError) {
+	if s.ref != nil {
+		s.ref.validate(v, path, errs)
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), typeOf(v))})
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v is not one of %v", v, s.Enum)})
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+			}
+		}
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names) // Stable error order keeps repair prompts and logs reproducible
+		for _, name := range names {
+			if child, ok := val[name]; ok {
+				s.Properties[name].validate(child, path+"/"+escapePointer(name), errs)
+			}
+		}
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d items, got %d", *s.MinItems, len(val))})
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected at most %d items, got %d", *s.MaxItems, len(val))})
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("%v is less than the minimum %v", val, *s.Minimum)})
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("%v is greater than the maximum %v", val, *s.Maximum)})
+		}
+	case string:
+		if s.MinLength != nil && len([]rune(val)) < *s.MinLength {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected at least %d characters", *s.MinLength)})
+		}
+	}
+}
+
+func (t typeList) matches(v any) bool {
+	actual := typeOf(v)
+	for _, want := range t {
+		if want == actual {
+			return true
+		}
+		if want == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func inEnum(enum []any, v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return false // Only scalar enums are supported, composite values are not comparable
+	}
+	for _, candidate := range enum {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
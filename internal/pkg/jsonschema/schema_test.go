@@ -0,0 +1,96 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const placesSchema = `{
+  "type": "object",
+  "required": ["places"],
+  "properties": {
+    "places": {
+      "type": "array",
+      "minItems": 1,
+      "items": {"$ref": "#/$defs/place"}
+    }
+  },
+  "$defs": {
+    "place": {
+      "type": "object",
+      "required": ["name", "latitude"],
+      "properties": {
+        "name": {"type": "string", "minLength": 1},
+        "latitude": {"type": "number", "minimum": -90, "maximum": 90},
+        "price": {"type": ["string", "null"], "enum": ["$", "$$", null]}
+      }
+    }
+  }
+}`
+
+func TestValidateJSON(t *testing.T) {
+	schema, err := Compile([]byte(placesSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []ValidationError
+	}{
+		{
+			name:  "valid document",
+			input: `{"places":[{"name":"Belem Tower","latitude":38.69,"price":null},{"name":"Cafe","latitude":38,"price":"$"}]}`,
+		},
+		{
+			name:  "missing required property",
+			input: `{"places":[{"latitude":38.69}]}`,
+			want:  []ValidationError{{Path: "/places/0", Message: `missing required property "name"`}},
+		},
+		{
+			name:  "wrong type and out of range",
+			input: `{"places":[{"name":"","latitude":"38.69"},{"name":"x","latitude":138}]}`,
+			want: []ValidationError{
+				{Path: "/places/0/latitude", Message: "expected number, got string"},
+				{Path: "/places/0/name", Message: "expected at least 1 characters"},
+				{Path: "/places/1/latitude", Message: "138 is greater than the maximum 90"},
+			},
+		},
+		{
+			name:  "enum and min items",
+			input: `{"places":[]}`,
+			want:  []ValidationError{{Path: "/places", Message: "expected at least 1 items, got 0"}},
+		},
+		{
+			name:  "enum violation",
+			input: `{"places":[{"name":"x","latitude":1,"price":"cheap"}]}`,
+			want:  []ValidationError{{Path: "/places/0/price", Message: "value cheap is not one of [$ $$ <nil>]"}},
+		},
+		{
+			name:  "malformed JSON",
+			input: `{"places":[`,
+			want:  []ValidationError{{Message: "invalid JSON: unexpected end of JSON input"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schema.ValidateJSON([]byte(tt.input)))
+		})
+	}
+}
+
+func TestCompile_RejectsUnknownRef(t *testing.T) {
+	_, err := Compile([]byte(`{"type":"object","properties":{"a":{"$ref":"#/$defs/missing"}}}`))
+	assert.Error(t, err)
+
+	_, err = Compile([]byte(`{"$ref":"https://example.com/schema.json"}`))
+	assert.Error(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	assert.NoError(t, Errors(nil))
+	err := Errors([]ValidationError{{Path: "/a", Message: "bad"}, {Message: "worse"}})
+	assert.EqualError(t, err, "schema validation failed: /a: bad; /: worse")
+}
@@ -0,0 +1,150 @@
+// Package jsonstream picks the complete items out of JSON arrays while the document is still
+// streaming in, so LLM answers can be rendered item by item instead of all at once. It is
+// tolerant of what models wrap around their JSON: text before the first brace or bracket and
+// after the document closes is skipped, and a document that never completes simply yields the
+// items completed so far. Items are not validated; callers decode them and skip the ones that
+// fail, the full parse at the end of the stream has the final say.
+package jsonstream
+
+import "encoding/json"
+
+// Item is an object completed inside a watched array.
+type Item struct {
+	Array string          // key of the array, empty for a top-level array
+	Index int             // position of the item within its array
+	Raw   json.RawMessage // the item as streamed
+}
+
+// frame is an open object or array.
+type frame struct {
+	object    bool
+	start     int    // offset of the opening brace or bracket
+	name      string // key this container is the value of
+	key       string // objects: the last key read
+	expectKey bool   // objects: the next string is a key
+	watched   bool   // arrays: object items are emitted
+	items     int    // arrays: object items emitted so far
+}
+
+// Scanner finds the objects completed in watched arrays as chunks of a document arrive.
+type Scanner struct {
+	arrays map[string]bool
+
+	buf      []byte
+	pos      int
+	stack    []frame
+	started  bool
+	done     bool
+	inString bool
+	escaped  bool
+	strStart int
+}
+
+// NewScanner watches the arrays stored under the given keys, at any depth. The empty key
+// watches a top-level array.
+func NewScanner(arrays ...string) *Scanner {
+	watched := make(map[string]bool, len(arrays))
+	for _, a := range arrays {
+		watched[a] = true
+	}
+	return &Scanner{arrays: watched}
+}
+
+// Write feeds the next chunk of the document and returns the items it completed.
+func (s *Scanner) Write(chunk string) []Item {
+	s.buf = append(s.buf, chunk...)
+
+	var items []Item
+	for ; s.pos < len(s.buf) && !s.done; s.pos++ {
+		c := s.buf[s.pos]
+		if !s.started {
+			if c != '{' && c != '[' {
+				continue
+			}
+			s.started = true
+		}
+
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				s.endString()
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+			s.strStart = s.pos + 1
+		case '{', '[':
+			s.open(c == '{')
+		case '}', ']':
+			if item, ok := s.close(); ok {
+				items = append(items, item)
+			}
+		case ':':
+			if top := s.top(); top != nil && top.object {
+				top.expectKey = false
+			}
+		case ',':
+			if top := s.top(); top != nil && top.object {
+				top.expectKey = true
+			}
+		}
+	}
+	return items
+}
+
+func (s *Scanner) open(object bool) {
+	f := frame{object: object, start: s.pos, expectKey: object}
+	if parent := s.top(); parent != nil && parent.object {
+		f.name = parent.key
+	}
+	f.watched = !object && s.arrays[f.name]
+	s.stack = append(s.stack, f)
+}
+
+// close pops the innermost container, returning it as an item when it is an object directly
+// inside a watched array. Mismatched brackets are tolerated: whatever is open is closed.
+func (s *Scanner) close() (Item, bool) {
+	if len(s.stack) == 0 {
+		s.done = true
+		return Item{}, false
+	}
+	f := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+
+	parent := s.top()
+	if parent == nil {
+		s.done = true
+		return Item{}, false
+	}
+	if !f.object || parent.object || !parent.watched {
+		return Item{}, false
+	}
+
+	raw := make(json.RawMessage, s.pos+1-f.start)
+	copy(raw, s.buf[f.start:s.pos+1])
+	item := Item{Array: parent.name, Index: parent.items, Raw: raw}
+	parent.items++
+	return item, true
+}
+
+// endString records an object key. Keys are read raw: the ones watched never need escapes.
+func (s *Scanner) endString() {
+	if top := s.top(); top != nil && top.object && top.expectKey {
+		top.key = string(s.buf[s.strStart:s.pos])
+	}
+}
+
+func (s *Scanner) top() *frame {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return &s.stack[len(s.stack)-1]
+}
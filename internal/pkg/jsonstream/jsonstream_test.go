@@ -0,0 +1,79 @@
+package jsonstream
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feed writes doc to the scanner in chunks of size n and collects the items.
+func feed(s *Scanner, doc string, n int) []Item {
+	var items []Item
+	for i := 0; i < len(doc); i += n {
+		items = append(items, s.Write(doc[i:min(i+n, len(doc))])...)
+	}
+	return items
+}
+
+func names(t *testing.T, items []Item) []string {
+	t.Helper()
+	var out []string
+	for _, it := range items {
+		var v struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal(it.Raw, &v), string(it.Raw))
+		out = append(out, v.Name)
+	}
+	return out
+}
+
+func TestScanner_EmitsItemsAsTheyComplete(t *testing.T) {
+	s := NewScanner("points_of_interest")
+
+	assert.Empty(t, s.Write(`{"itinerary_name":"Porto","points_of_interest":[{"name":"Ribeira","tags":["river",`))
+	items := s.Write(`"old town"],"desc":"a {brace} and \"quote\""},{"name":"Livra`)
+	require.Len(t, items, 1)
+	assert.Equal(t, "points_of_interest", items[0].Array)
+	assert.Equal(t, 0, items[0].Index)
+	assert.Equal(t, []string{"Ribeira"}, names(t, items))
+
+	items = s.Write(`ria Lello"}]}`)
+	require.Len(t, items, 1)
+	assert.Equal(t, 1, items[0].Index)
+	assert.Equal(t, []string{"Livraria Lello"}, names(t, items))
+}
+
+func TestScanner_ChunkBoundaries(t *testing.T) {
+	doc := "Here you go:\n```json\n" +
+		`{"city":"Lisbon","restaurants":[{"name":"A","cuisine":"x\"}"},{"name":"B","nested":{"hotels":[{"name":"skip"}]}}],` +
+		`"hotels":[{"name":"H1"}]}` + "\n```"
+
+	for _, n := range []int{1, 2, 3, 7, len(doc)} {
+		items := feed(NewScanner("restaurants", "hotels"), doc, n)
+		require.Len(t, items, 4, "chunk size %d", n)
+		assert.Equal(t, []string{"A", "skip", "B", "H1"}, names(t, items), "chunk size %d", n)
+		assert.Equal(t, "hotels", items[3].Array)
+	}
+}
+
+func TestScanner_TopLevelArray(t *testing.T) {
+	items := feed(NewScanner(""), `[{"name":"A"},{"name":"B"}]`, 5)
+	assert.Equal(t, []string{"A", "B"}, names(t, items))
+
+	assert.Empty(t, feed(NewScanner("restaurants"), `[{"name":"A"}]`, 5))
+}
+
+func TestScanner_IgnoresUnwatchedAndTrailingText(t *testing.T) {
+	s := NewScanner("points_of_interest")
+	items := feed(s, `{"general":[{"name":"x"}],"points_of_interest":[1,"two",{"name":"C"}]} trailing {"points_of_interest":[{"name":"D"}]}`, 4)
+	assert.Equal(t, []string{"C"}, names(t, items))
+	assert.Equal(t, 0, items[0].Index)
+}
+
+func TestScanner_TruncatedDocument(t *testing.T) {
+	items := feed(NewScanner("hotels"), `{"hotels":[{"name":"H1"},{"name":"H2","addr`, 3)
+	assert.Equal(t, []string{"H1"}, names(t, items))
+}
@@ -20,6 +20,10 @@ type LoggingConfig struct {
 	Intent    string // e.g., "itinerary", "restaurant", "hotel", "discover", "nearby"
 	Prompt    string
 
+	// Prompt template the prompt was rendered from, see internal/pkg/prompts
+	PromptID      string
+	PromptVersion int
+
 	// Optional context fields
 	CityID     *uuid.UUID
 	CityName   string
@@ -66,4 +70,22 @@ type LLMResponse struct {
 	// Raw payloads (optional)
 	RequestPayload  interface{}
 	ResponsePayload interface{}
+
+	// Schema validation outcome (optional, set for structured JSON responses)
+	Validation *SchemaValidation
+}
+
+// SchemaValidation records how a structured response fared against its JSON schema,
+// including every repair re-prompt that was needed to make it valid.
+type SchemaValidation struct {
+	SchemaName     string
+	Valid          bool                // Whether the final response, after repairs, passed validation
+	RepairAttempts int                 // Number of repair re-prompts sent to the model
+	Failures       []ValidationFailure // One entry per failed validation, in attempt order
+}
+
+// ValidationFailure lists the errors found on one attempt. Attempt 0 is the original response.
+type ValidationFailure struct {
+	Attempt int      `json:"attempt"`
+	Errors  []string `json:"errors"`
 }
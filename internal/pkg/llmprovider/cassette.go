@@ -0,0 +1,335 @@
+package llmprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+var _ Provider = (*CassetteProvider)(nil)
+
+// Cassette modes, selected through LLM_CASSETTE_MODE.
+const (
+	CassetteRecord = "record" // Call the wrapped provider and write every interaction to disk
+	CassetteReplay = "replay" // Serve interactions from disk only, failing on unknown prompts
+)
+
+// Cassette kinds, one sub-directory each inside the cassette directory.
+const (
+	cassetteKindStream    = "stream"
+	cassetteKindResponse  = "response"
+	cassetteKindEmbedding = "embedding"
+)
+
+// ErrCassetteNotFound is returned in replay mode when no fixture exists for a prompt.
+var ErrCassetteNotFound = errors.New("cassette not found")
+
+// HashPrompt returns the hex SHA256 of a prompt. Cassettes are stored under this key, the
+// same value logged as llm_interactions.prompt_hash.
+func HashPrompt(prompt string) string {
+	hash := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(hash[:])
+}
+
+// CassetteChunk is one streamed response part. Delay is measured from the previous chunk,
+// or from the start of the request for the first one.
+type CassetteChunk struct {
+	Text    string                                      `json:"text"`
+	DelayMs int64                                       `json:"delay_ms"`
+	Usage   *genai.GenerateContentResponseUsageMetadata `json:"usage,omitempty"`
+}
+
+// Cassette is the on-disk fixture for a single prompt.
+type Cassette struct {
+	PromptHash  string          `json:"prompt_hash"`
+	Kind        string          `json:"kind"`
+	Provider    string          `json:"provider"`
+	Model       string          `json:"model"`
+	Prompt      string          `json:"prompt"`
+	Chunks      []CassetteChunk `json:"chunks,omitempty"`
+	Embedding   []float32       `json:"embedding,omitempty"`
+	Error       string          `json:"error,omitempty"`        // The call itself failed
+	StreamError string          `json:"stream_error,omitempty"` // The stream failed after Chunks were delivered
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// Text returns the concatenated response text.
+func (c *Cassette) Text() string {
+	var out []byte
+	for _, chunk := range c.Chunks {
+		out = append(out, chunk.Text...)
+	}
+	return string(out)
+}
+
+// CassetteProvider records interactions of a wrapped provider to fixture files, or replays
+// them without any network access. Streams keep their original chunk boundaries and the
+// delay between chunks, scaled by TimeScale on replay.
+type CassetteProvider struct {
+	mode      string
+	dir       string
+	inner     Provider
+	timeScale float64
+	logger    *zap.Logger
+	mu        sync.Mutex
+}
+
+// NewCassetteProvider wraps inner with a cassette stored in dir. inner may be nil in
+// replay mode.
+func NewCassetteProvider(mode, dir string, inner Provider, logger *zap.Logger) (*CassetteProvider, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("cassette directory is required")
+	}
+
+	switch mode {
+	case CassetteRecord:
+		if inner == nil {
+			return nil, fmt.Errorf("cassette record mode requires a provider to record from")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	case CassetteReplay:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	return &CassetteProvider{
+		mode:      mode,
+		dir:       dir,
+		inner:     inner,
+		timeScale: 1,
+		logger:    logger,
+	}, nil
+}
+
+// WithTimeScale scales replayed delays: 1 keeps the recorded timing, 0 replays instantly.
+func (p *CassetteProvider) WithTimeScale(scale float64) *CassetteProvider {
+	if scale >= 0 {
+		p.timeScale = scale
+	}
+	return p
+}
+
+func (p *CassetteProvider) Name() string {
+	if p.inner != nil {
+		return p.inner.Name()
+	}
+	return "cassette"
+}
+
+func (p *CassetteProvider) Model() string {
+	if p.inner != nil {
+		return p.inner.Model()
+	}
+	return "cassette-replay"
+}
+
+func (p *CassetteProvider) path(kind, hash string) string {
+	return filepath.Join(p.dir, kind, hash+".json")
+}
+
+// Load reads the fixture recorded for prompt.
+func (p *CassetteProvider) Load(kind, prompt string) (*Cassette, error) {
+	hash := HashPrompt(prompt)
+	data, err := os.ReadFile(p.path(kind, hash))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s/%s", ErrCassetteNotFound, kind, hash)
+		}
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %s/%s: %w", kind, hash, err)
+	}
+	return &c, nil
+}
+
+func (p *CassetteProvider) save(c *Cassette) {
+	c.RecordedAt = time.Now().UTC()
+	if p.inner != nil {
+		c.Provider = p.inner.Name()
+		c.Model = p.inner.Model()
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		p.logger.Error("Failed to encode cassette", zap.String("hash", c.PromptHash), zap.Error(err))
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	path := p.path(c.Kind, c.PromptHash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		p.logger.Error("Failed to create cassette directory", zap.String("path", path), zap.Error(err))
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		p.logger.Error("Failed to write cassette", zap.String("path", path), zap.Error(err))
+		return
+	}
+	p.logger.Debug("Recorded cassette", zap.String("kind", c.Kind), zap.String("hash", c.PromptHash), zap.Int("chunks", len(c.Chunks)))
+}
+
+func newCassette(kind, prompt string) *Cassette {
+	return &Cassette{PromptHash: HashPrompt(prompt), Kind: kind, Prompt: prompt}
+}
+
+func (p *CassetteProvider) GenerateResponse(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	if p.mode == CassetteReplay {
+		c, err := p.Load(cassetteKindResponse, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if c.Error != "" {
+			return nil, errors.New(c.Error)
+		}
+		var usage *genai.GenerateContentResponseUsageMetadata
+		if len(c.Chunks) > 0 {
+			usage = c.Chunks[len(c.Chunks)-1].Usage
+		}
+		return NewTextResponse(c.Text(), usage), nil
+	}
+
+	c := newCassette(cassetteKindResponse, prompt)
+	start := time.Now()
+	resp, err := p.inner.GenerateResponse(ctx, prompt, config)
+	if err != nil {
+		c.Error = err.Error()
+		p.save(c)
+		return nil, err
+	}
+	c.Chunks = []CassetteChunk{{Text: TextFromResponse(resp), DelayMs: time.Since(start).Milliseconds(), Usage: resp.UsageMetadata}}
+	p.save(c)
+	return resp, nil
+}
+
+func (p *CassetteProvider) GenerateContentStream(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	if p.mode == CassetteReplay {
+		return p.replayStream(ctx, prompt)
+	}
+	return p.recordStream(prompt, func() (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+		return p.inner.GenerateContentStream(ctx, prompt, config)
+	})
+}
+
+func (p *CassetteProvider) GenerateContentStreamWithCache(ctx context.Context, prompt string, config *genai.GenerateContentConfig, cacheKey string) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	if p.mode == CassetteReplay {
+		return p.replayStream(ctx, prompt)
+	}
+	return p.recordStream(prompt, func() (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+		return p.inner.GenerateContentStreamWithCache(ctx, prompt, config, cacheKey)
+	})
+}
+
+func (p *CassetteProvider) recordStream(prompt string, open func() (iter.Seq2[*genai.GenerateContentResponse, error], error)) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	c := newCassette(cassetteKindStream, prompt)
+	last := time.Now()
+	stream, err := open()
+	if err != nil {
+		c.Error = err.Error()
+		p.save(c)
+		return nil, err
+	}
+
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for resp, err := range stream {
+			now := time.Now()
+			if err != nil {
+				c.StreamError = err.Error()
+				p.save(c)
+				yield(nil, err)
+				return
+			}
+			c.Chunks = append(c.Chunks, CassetteChunk{
+				Text:    TextFromResponse(resp),
+				DelayMs: now.Sub(last).Milliseconds(),
+				Usage:   resp.UsageMetadata,
+			})
+			last = now
+			if !yield(resp, nil) {
+				// A partial recording would replay as a truncated answer, keep the old fixture
+				p.logger.Warn("Stream abandoned by consumer, cassette not saved", zap.String("hash", c.PromptHash))
+				return
+			}
+		}
+		p.save(c)
+	}, nil
+}
+
+func (p *CassetteProvider) replayStream(ctx context.Context, prompt string) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	c, err := p.Load(cassetteKindStream, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if c.Error != "" {
+		return nil, errors.New(c.Error)
+	}
+
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for _, chunk := range c.Chunks {
+			if delay := time.Duration(float64(chunk.DelayMs)*p.timeScale) * time.Millisecond; delay > 0 {
+				select {
+				case <-ctx.Done():
+					yield(nil, ctx.Err())
+					return
+				case <-time.After(delay):
+				}
+			}
+			if !yield(NewTextResponse(chunk.Text, chunk.Usage), nil) {
+				return
+			}
+		}
+		if c.StreamError != "" {
+			yield(nil, errors.New(c.StreamError))
+		}
+	}, nil
+}
+
+func (p *CassetteProvider) embedding(key string, generate func() ([]float32, error)) ([]float32, error) {
+	if p.mode == CassetteReplay {
+		c, err := p.Load(cassetteKindEmbedding, key)
+		if err != nil {
+			return nil, err
+		}
+		if c.Error != "" {
+			return nil, errors.New(c.Error)
+		}
+		return c.Embedding, nil
+	}
+
+	c := newCassette(cassetteKindEmbedding, key)
+	emb, err := generate()
+	if err != nil {
+		c.Error = err.Error()
+	}
+	c.Embedding = emb
+	p.save(c)
+	return emb, err
+}
+
+func (p *CassetteProvider) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return p.embedding(query, func() ([]float32, error) {
+		return p.inner.GenerateQueryEmbedding(ctx, query)
+	})
+}
+
+func (p *CassetteProvider) GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error) {
+	return p.embedding(poiEmbeddingText(name, description, category), func() ([]float32, error) {
+		return p.inner.GeneratePOIEmbedding(ctx, name, description, category)
+	})
+}
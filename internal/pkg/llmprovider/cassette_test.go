@@ -0,0 +1,80 @@
+package llmprovider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCassette_RecordThenReplayStream(t *testing.T) {
+	dir := t.TempDir()
+	fake := NewFakeProvider().WithDefaultResponse(`{"itinerary_name":"Porto in a day"}`).WithChunkSize(5)
+
+	recorder, err := NewCassetteProvider(CassetteRecord, dir, fake, zap.NewNop())
+	require.NoError(t, err)
+	recorded := collectStream(t, recorder, "porto prompt")
+
+	_, err = os.Stat(filepath.Join(dir, cassetteKindStream, HashPrompt("porto prompt")+".json"))
+	require.NoError(t, err, "stream cassette should be written under its prompt hash")
+
+	player, err := NewCassetteProvider(CassetteReplay, dir, nil, zap.NewNop())
+	require.NoError(t, err)
+	replayed := collectStream(t, player.WithTimeScale(0), "porto prompt")
+
+	assert.Equal(t, recorded, replayed, "replay must keep the original chunk boundaries")
+	assert.Len(t, fake.Prompts(), 1, "replay must not reach the wrapped provider")
+}
+
+func TestCassette_ReplayKeepsTiming(t *testing.T) {
+	dir := t.TempDir()
+	player, err := NewCassetteProvider(CassetteReplay, dir, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	c := newCassette(cassetteKindStream, "slow prompt")
+	c.Chunks = []CassetteChunk{{Text: "a", DelayMs: 0}, {Text: "b", DelayMs: 40}, {Text: "c", DelayMs: 40}}
+	player.save(c)
+
+	start := time.Now()
+	chunks := collectStream(t, player, "slow prompt")
+	assert.Equal(t, []string{"a", "b", "c"}, chunks)
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
+
+func TestCassette_ReplayResponseAndEmbeddings(t *testing.T) {
+	dir := t.TempDir()
+	fake := NewFakeProvider().WithResponse("city", `{"city":"Lisbon"}`)
+
+	recorder, err := NewCassetteProvider(CassetteRecord, dir, fake, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = recorder.GenerateResponse(ctx, "which city?", nil)
+	require.NoError(t, err)
+	wantEmb, err := recorder.GeneratePOIEmbedding(ctx, "Belem Tower", "Fortified tower", "Monument")
+	require.NoError(t, err)
+
+	player, err := NewCassetteProvider(CassetteReplay, dir, nil, zap.NewNop())
+	require.NoError(t, err)
+
+	resp, err := player.GenerateResponse(ctx, "which city?", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"city":"Lisbon"}`, TextFromResponse(resp))
+
+	emb, err := player.GeneratePOIEmbedding(ctx, "Belem Tower", "Fortified tower", "Monument")
+	require.NoError(t, err)
+	assert.Equal(t, wantEmb, emb)
+}
+
+func TestCassette_ReplayMissingFixture(t *testing.T) {
+	player, err := NewCassetteProvider(CassetteReplay, t.TempDir(), nil, zap.NewNop())
+	require.NoError(t, err)
+
+	_, err = player.GenerateContentStream(context.Background(), "never recorded", nil)
+	assert.True(t, errors.Is(err, ErrCassetteNotFound))
+}
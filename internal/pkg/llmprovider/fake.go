@@ -0,0 +1,192 @@
+package llmprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"iter"
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+)
+
+var _ Provider = (*FakeProvider)(nil)
+
+// FakeModelName is reported as the model for every fake interaction.
+const FakeModelName = "fake-llm"
+
+// defaultFakeResponse is shaped so the itinerary, domain and city parsers all accept it.
+const defaultFakeResponse = `{"city":"","country":"","description":"Offline response from the fake LLM provider.","itinerary_name":"Offline itinerary","overall_description":"Offline response from the fake LLM provider.","points_of_interest":[],"restaurants":[],"hotels":[],"activities":[]}`
+
+// FakeProvider is a deterministic in-process Provider for offline development and tests.
+// Responses are picked from rules matched against the prompt, streams are split into
+// fixed-size chunks and embeddings are derived from a hash of the input text.
+type FakeProvider struct {
+	mu              sync.RWMutex
+	rules           []fakeRule
+	defaultResponse string
+	chunkSize       int
+	prompts         []string
+}
+
+type fakeRule struct {
+	contains string
+	response string
+}
+
+// NewFakeProvider creates a fake provider returning an empty but parseable JSON document.
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		defaultResponse: defaultFakeResponse,
+		chunkSize:       64,
+	}
+}
+
+// WithResponse registers response for prompts containing substr. Rules are checked in
+// registration order.
+func (p *FakeProvider) WithResponse(substr, response string) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rules = append(p.rules, fakeRule{contains: substr, response: response})
+	return p
+}
+
+// WithDefaultResponse replaces the response used when no rule matches.
+func (p *FakeProvider) WithDefaultResponse(response string) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaultResponse = response
+	return p
+}
+
+// WithChunkSize sets how many bytes each streamed chunk carries, a few more when a chunk
+// would end inside a multi-byte character.
+func (p *FakeProvider) WithChunkSize(n int) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n > 0 {
+		p.chunkSize = n
+	}
+	return p
+}
+
+// Prompts returns every prompt the provider received, in order.
+func (p *FakeProvider) Prompts() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	out := make([]string, len(p.prompts))
+	copy(out, p.prompts)
+	return out
+}
+
+func (p *FakeProvider) Name() string { return ProviderFake }
+
+func (p *FakeProvider) Model() string { return FakeModelName }
+
+func (p *FakeProvider) respond(prompt string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, prompt)
+	for _, rule := range p.rules {
+		if strings.Contains(prompt, rule.contains) {
+			return rule.response
+		}
+	}
+	return p.defaultResponse
+}
+
+func fakeUsage(prompt, response string) *genai.GenerateContentResponseUsageMetadata {
+	// Rough 4-characters-per-token estimate, good enough for cost bookkeeping in tests
+	promptTokens := int32(len(prompt)/4 + 1)
+	completionTokens := int32(len(response)/4 + 1)
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     promptTokens,
+		CandidatesTokenCount: completionTokens,
+		TotalTokenCount:      promptTokens + completionTokens,
+	}
+}
+
+func (p *FakeProvider) GenerateResponse(ctx context.Context, prompt string, _ *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	response := p.respond(prompt)
+	return NewTextResponse(response, fakeUsage(prompt, response)), nil
+}
+
+func (p *FakeProvider) GenerateContentStream(ctx context.Context, prompt string, _ *genai.GenerateContentConfig) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	response := p.respond(prompt)
+	p.mu.RLock()
+	size := p.chunkSize
+	p.mu.RUnlock()
+
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		for start, end := 0, 0; start < len(response); start = end {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			// Providers never split a character across chunks, and cassettes cannot store half of one
+			end = min(start+size, len(response))
+			for end < len(response) && !utf8.RuneStart(response[end]) {
+				end++
+			}
+			var usage *genai.GenerateContentResponseUsageMetadata
+			if end == len(response) {
+				usage = fakeUsage(prompt, response)
+			}
+			if !yield(NewTextResponse(response[start:end], usage), nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+func (p *FakeProvider) GenerateContentStreamWithCache(ctx context.Context, prompt string, config *genai.GenerateContentConfig, _ string) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	return p.GenerateContentStream(ctx, prompt, config)
+}
+
+func (p *FakeProvider) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return HashEmbedding(query, EmbeddingDimensions), nil
+}
+
+func (p *FakeProvider) GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return HashEmbedding(poiEmbeddingText(name, description, category), EmbeddingDimensions), nil
+}
+
+// HashEmbedding derives a unit-length vector from text. Equal inputs always map to the
+// same vector, which is all the fake needs for deterministic similarity searches.
+func HashEmbedding(text string, dims int) []float32 {
+	vec := make([]float32, dims)
+	var block [sha256.Size]byte
+	var sumSquares float64
+	for i := 0; i < dims; i++ {
+		if i%8 == 0 {
+			var counter [4]byte
+			binary.BigEndian.PutUint32(counter[:], uint32(i/8))
+			block = sha256.Sum256(append([]byte(text), counter[:]...))
+		}
+		raw := binary.BigEndian.Uint32(block[(i%8)*4:])
+		v := float64(raw)/math.MaxUint32*2 - 1
+		vec[i] = float32(v)
+		sumSquares += v * v
+	}
+	if norm := math.Sqrt(sumSquares); norm > 0 {
+		for i := range vec {
+			vec[i] = float32(float64(vec[i]) / norm)
+		}
+	}
+	return vec
+}
@@ -0,0 +1,71 @@
+package llmprovider
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+
+	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
+)
+
+var _ Provider = (*GeminiProvider)(nil)
+
+// GeminiProvider adapts the go-genai-sdk chat client and embedding service to Provider.
+type GeminiProvider struct {
+	client     *generativeAI.LLMChatClient
+	embeddings *generativeAI.EmbeddingService
+}
+
+// NewGeminiProvider creates the Gemini chat client and embedding service.
+func NewGeminiProvider(ctx context.Context, apiKey, modelName string, logger *zap.Logger) (*GeminiProvider, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("gemini provider requires GEMINI_API_KEY")
+	}
+
+	client, err := generativeAI.NewLLMChatClient(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gemini client: %w", err)
+	}
+	if modelName != "" {
+		client.ModelName = modelName
+	}
+
+	// The embedding service expects an slog.Logger, bridge it onto zap
+	stdLogger := zap.NewStdLog(logger)
+	slogLogger := slog.New(slog.NewTextHandler(stdLogger.Writer(), nil))
+
+	embeddings, err := generativeAI.NewEmbeddingService(ctx, slogLogger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gemini embedding service: %w", err)
+	}
+
+	return &GeminiProvider{client: client, embeddings: embeddings}, nil
+}
+
+func (p *GeminiProvider) Name() string { return "google" }
+
+func (p *GeminiProvider) Model() string { return p.client.ModelName }
+
+func (p *GeminiProvider) GenerateResponse(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	return p.client.GenerateResponse(ctx, prompt, config)
+}
+
+func (p *GeminiProvider) GenerateContentStream(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	return p.client.GenerateContentStream(ctx, prompt, config)
+}
+
+func (p *GeminiProvider) GenerateContentStreamWithCache(ctx context.Context, prompt string, config *genai.GenerateContentConfig, cacheKey string) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	return p.client.GenerateContentStreamWithCache(ctx, prompt, config, cacheKey)
+}
+
+func (p *GeminiProvider) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return p.embeddings.GenerateQueryEmbedding(ctx, query)
+}
+
+func (p *GeminiProvider) GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error) {
+	return p.embeddings.GeneratePOIEmbedding(ctx, name, description, category)
+}
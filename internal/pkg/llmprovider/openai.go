@@ -0,0 +1,252 @@
+package llmprovider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+var _ Provider = (*OpenAIProvider)(nil)
+
+// OpenAIProvider talks to any server implementing the OpenAI chat completions and
+// embeddings endpoints, which covers Ollama, llama.cpp server, vLLM and LocalAI.
+type OpenAIProvider struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	httpClient     *http.Client
+	logger         *zap.Logger
+}
+
+// NewOpenAIProvider creates a provider for an OpenAI-compatible endpoint. baseURL should
+// include the API version prefix, e.g. http://localhost:11434/v1.
+func NewOpenAIProvider(baseURL, apiKey, modelName, embeddingModel string, logger *zap.Logger) (*OpenAIProvider, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("openai provider requires LLM_BASE_URL")
+	}
+	if modelName == "" {
+		return nil, fmt.Errorf("openai provider requires LLM_MODEL")
+	}
+	if embeddingModel == "" {
+		embeddingModel = modelName
+	}
+
+	return &OpenAIProvider{
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         apiKey,
+		model:          modelName,
+		embeddingModel: embeddingModel,
+		// No overall timeout: streamed generations can legitimately run for minutes,
+		// callers bound them through the request context instead.
+		httpClient: &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: 2 * time.Minute}},
+		logger:     logger,
+	}, nil
+}
+
+func (p *OpenAIProvider) Name() string { return ProviderOpenAI }
+
+func (p *OpenAIProvider) Model() string { return p.model }
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	Temperature    *float32          `json:"temperature,omitempty"`
+	TopP           *float32          `json:"top_p,omitempty"`
+	MaxTokens      int32             `json:"max_tokens,omitempty"`
+	Stream         bool              `json:"stream,omitempty"`
+	StreamOptions  map[string]bool   `json:"stream_options,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int32 `json:"prompt_tokens"`
+	CompletionTokens int32 `json:"completion_tokens"`
+	TotalTokens      int32 `json:"total_tokens"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+		Delta   openAIMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (u *openAIUsage) toGenai() *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     u.PromptTokens,
+		CandidatesTokenCount: u.CompletionTokens,
+		TotalTokenCount:      u.TotalTokens,
+	}
+}
+
+func (p *OpenAIProvider) buildChatRequest(prompt string, config *genai.GenerateContentConfig, stream bool) openAIChatRequest {
+	req := openAIChatRequest{Model: p.model, Stream: stream}
+	if config != nil {
+		if config.SystemInstruction != nil {
+			var sb strings.Builder
+			for _, part := range config.SystemInstruction.Parts {
+				if part != nil {
+					sb.WriteString(part.Text)
+				}
+			}
+			if sb.Len() > 0 {
+				req.Messages = append(req.Messages, openAIMessage{Role: "system", Content: sb.String()})
+			}
+		}
+		req.Temperature = config.Temperature
+		req.TopP = config.TopP
+		req.MaxTokens = config.MaxOutputTokens
+		if config.ResponseMIMEType == "application/json" {
+			req.ResponseFormat = map[string]string{"type": "json_object"}
+		}
+	}
+	req.Messages = append(req.Messages, openAIMessage{Role: "user", Content: prompt})
+	if stream {
+		req.StreamOptions = map[string]bool{"include_usage": true}
+	}
+	return req
+}
+
+func (p *OpenAIProvider) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%s returned status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+func (p *OpenAIProvider) GenerateResponse(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	resp, err := p.post(ctx, "/chat/completions", p.buildChatRequest(prompt, config, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out openAIChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode chat completion: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("chat completion returned no choices")
+	}
+
+	return NewTextResponse(out.Choices[0].Message.Content, out.Usage.toGenai()), nil
+}
+
+func (p *OpenAIProvider) GenerateContentStream(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	resp, err := p.post(ctx, "/chat/completions", p.buildChatRequest(prompt, config, true))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				return
+			}
+
+			var chunk openAIChatResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				p.logger.Warn("Skipping malformed stream chunk", zap.String("data", data), zap.Error(err))
+				continue
+			}
+
+			var text string
+			if len(chunk.Choices) > 0 {
+				text = chunk.Choices[0].Delta.Content
+			}
+			if text == "" && chunk.Usage == nil {
+				continue
+			}
+			if !yield(NewTextResponse(text, chunk.Usage.toGenai()), nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(nil, fmt.Errorf("failed to read stream: %w", err))
+		}
+	}, nil
+}
+
+func (p *OpenAIProvider) GenerateContentStreamWithCache(ctx context.Context, prompt string, config *genai.GenerateContentConfig, _ string) (iter.Seq2[*genai.GenerateContentResponse, error], error) {
+	return p.GenerateContentStream(ctx, prompt, config)
+}
+
+func (p *OpenAIProvider) embed(ctx context.Context, input string) ([]float32, error) {
+	resp, err := p.post(ctx, "/embeddings", map[string]string{"model": p.embeddingModel, "input": input})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	if len(out.Data) == 0 || len(out.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("embedding response was empty")
+	}
+	return out.Data[0].Embedding, nil
+}
+
+func (p *OpenAIProvider) GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error) {
+	return p.embed(ctx, query)
+}
+
+func (p *OpenAIProvider) GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error) {
+	return p.embed(ctx, poiEmbeddingText(name, description, category))
+}
@@ -0,0 +1,124 @@
+package llmprovider
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+// Supported provider names, selected through LLM_PROVIDER.
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai" // Any OpenAI-compatible HTTP server (Ollama, llama.cpp, vLLM, ...)
+	ProviderFake   = "fake"
+)
+
+// EmbeddingDimensions matches the VECTOR(768) columns used by points_of_interest and cities.
+const EmbeddingDimensions = 768
+
+// Provider is the contract every LLM backend implements. Requests and responses keep the
+// genai types so the existing stream processing and response parsing work unchanged
+// regardless of which backend produced the content.
+type Provider interface {
+	// Name returns the provider identifier logged in llm_interactions.provider.
+	Name() string
+	// Model returns the model identifier logged in llm_interactions.model_name.
+	Model() string
+
+	GenerateResponse(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+	GenerateContentStream(ctx context.Context, prompt string, config *genai.GenerateContentConfig) (iter.Seq2[*genai.GenerateContentResponse, error], error)
+	// GenerateContentStreamWithCache lets backends with server-side context caching reuse it.
+	// Backends without one treat it as GenerateContentStream.
+	GenerateContentStreamWithCache(ctx context.Context, prompt string, config *genai.GenerateContentConfig, cacheKey string) (iter.Seq2[*genai.GenerateContentResponse, error], error)
+
+	GenerateQueryEmbedding(ctx context.Context, query string) ([]float32, error)
+	GeneratePOIEmbedding(ctx context.Context, name, description, category string) ([]float32, error)
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	Provider       string // gemini, openai or fake. Empty picks gemini when GEMINI_API_KEY is set, fake otherwise
+	Model          string
+	EmbeddingModel string
+	BaseURL        string // OpenAI-compatible base URL, e.g. http://localhost:11434/v1
+	APIKey         string
+	CassetteMode   string // record or replay, empty disables cassettes
+	CassetteDir    string
+}
+
+// New builds the provider described by cfg, wrapped in a cassette when CassetteMode is set.
+func New(ctx context.Context, cfg Config, logger *zap.Logger) (Provider, error) {
+	provider, err := newBackend(ctx, cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CassetteMode == "" {
+		return provider, nil
+	}
+	return NewCassetteProvider(cfg.CassetteMode, cfg.CassetteDir, provider, logger)
+}
+
+func newBackend(ctx context.Context, cfg Config, logger *zap.Logger) (Provider, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		if os.Getenv("GEMINI_API_KEY") != "" {
+			name = ProviderGemini
+		} else {
+			logger.Warn("GEMINI_API_KEY not set and no LLM_PROVIDER configured, using fake LLM provider")
+			name = ProviderFake
+		}
+	}
+
+	switch name {
+	case ProviderGemini:
+		apiKey := cfg.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("GEMINI_API_KEY")
+		}
+		return NewGeminiProvider(ctx, apiKey, cfg.Model, logger)
+	case ProviderOpenAI:
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.EmbeddingModel, logger)
+	case ProviderFake:
+		return NewFakeProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", cfg.Provider)
+	}
+}
+
+// TextFromResponse returns the text of the first candidate part, mirroring how callers
+// have always read Gemini responses.
+func TextFromResponse(resp *genai.GenerateContentResponse) string {
+	if resp == nil {
+		return ""
+	}
+	for _, candidate := range resp.Candidates {
+		if candidate != nil && candidate.Content != nil && len(candidate.Content.Parts) > 0 {
+			return candidate.Content.Parts[0].Text
+		}
+	}
+	return ""
+}
+
+// NewTextResponse wraps plain text into a single-candidate genai response.
+func NewTextResponse(text string, usage *genai.GenerateContentResponseUsageMetadata) *genai.GenerateContentResponse {
+	return &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{
+			{
+				Content: &genai.Content{
+					Role:  "model",
+					Parts: []*genai.Part{{Text: text}},
+				},
+			},
+		},
+		UsageMetadata: usage,
+	}
+}
+
+func poiEmbeddingText(name, description, category string) string {
+	return fmt.Sprintf("%s. %s. Category: %s", name, description, category)
+}
@@ -0,0 +1,169 @@
+package llmprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+func collectStream(t *testing.T, p Provider, prompt string) []string {
+	t.Helper()
+	stream, err := p.GenerateContentStream(context.Background(), prompt, nil)
+	require.NoError(t, err)
+
+	var chunks []string
+	for resp, err := range stream {
+		require.NoError(t, err)
+		if text := TextFromResponse(resp); text != "" {
+			chunks = append(chunks, text)
+		}
+	}
+	return chunks
+}
+
+func TestFakeProvider_RulesAndDefault(t *testing.T) {
+	p := NewFakeProvider().
+		WithResponse("Lisbon", `{"city":"Lisbon"}`).
+		WithDefaultResponse("fallback")
+
+	resp, err := p.GenerateResponse(context.Background(), "plan a trip to Lisbon", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"city":"Lisbon"}`, TextFromResponse(resp))
+	require.NotNil(t, resp.UsageMetadata)
+	assert.Positive(t, resp.UsageMetadata.TotalTokenCount)
+
+	resp, err = p.GenerateResponse(context.Background(), "plan a trip to Porto", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", TextFromResponse(resp))
+
+	assert.Equal(t, []string{"plan a trip to Lisbon", "plan a trip to Porto"}, p.Prompts())
+}
+
+func TestFakeProvider_StreamIsChunkedDeterministically(t *testing.T) {
+	p := NewFakeProvider().WithDefaultResponse("abcdefghij").WithChunkSize(4)
+
+	first := collectStream(t, p, "anything")
+	second := collectStream(t, p, "anything")
+
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, first)
+	assert.Equal(t, first, second)
+}
+
+func TestFakeProvider_StreamKeepsCharactersWhole(t *testing.T) {
+	p := NewFakeProvider().WithDefaultResponse("Belém, São Jorge").WithChunkSize(4)
+
+	chunks := collectStream(t, p, "anything")
+	for _, chunk := range chunks {
+		assert.True(t, utf8.ValidString(chunk), "chunk %q splits a character", chunk)
+	}
+	assert.Equal(t, "Belém, São Jorge", strings.Join(chunks, ""))
+}
+
+func TestFakeProvider_DefaultResponseIsJSON(t *testing.T) {
+	resp, err := NewFakeProvider().GenerateResponse(context.Background(), "hello", nil)
+	require.NoError(t, err)
+	assert.True(t, json.Valid([]byte(TextFromResponse(resp))))
+}
+
+func TestHashEmbedding(t *testing.T) {
+	a := HashEmbedding("museum in Berlin", EmbeddingDimensions)
+	b := HashEmbedding("museum in Berlin", EmbeddingDimensions)
+	c := HashEmbedding("beach in Faro", EmbeddingDimensions)
+
+	require.Len(t, a, EmbeddingDimensions)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+
+	var norm float64
+	for _, v := range a {
+		norm += float64(v) * float64(v)
+	}
+	assert.InDelta(t, 1.0, norm, 1e-4)
+}
+
+func TestNew_FallsBackToFakeWithoutAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+
+	p, err := New(context.Background(), Config{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, ProviderFake, p.Name())
+
+	_, err = New(context.Background(), Config{Provider: "nope"}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestNew_MisconfiguredProviderFails(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+
+	_, err := New(context.Background(), Config{Provider: ProviderGemini}, zap.NewNop())
+	assert.ErrorContains(t, err, "GEMINI_API_KEY")
+
+	_, err = New(context.Background(), Config{Provider: ProviderOpenAI, Model: "llama3"}, zap.NewNop())
+	assert.ErrorContains(t, err, "LLM_BASE_URL")
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	var lastRequest openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&lastRequest))
+			if lastRequest.Stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, piece := range []string{"Hel", "lo"} {
+					fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", piece)
+				}
+				fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\n")
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				return
+			}
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"}}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+		case "/v1/embeddings":
+			fmt.Fprint(w, `{"data":[{"embedding":[0.1,0.2,0.3]}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewOpenAIProvider(server.URL+"/v1/", "secret", "llama3", "", zap.NewNop())
+	require.NoError(t, err)
+
+	t.Run("non-streaming", func(t *testing.T) {
+		resp, err := p.GenerateResponse(context.Background(), "hi", &genai.GenerateContentConfig{
+			Temperature:       genai.Ptr[float32](0.2),
+			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: "be brief"}}},
+			ResponseMIMEType:  "application/json",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", TextFromResponse(resp))
+		assert.Equal(t, int32(5), resp.UsageMetadata.TotalTokenCount)
+
+		require.Len(t, lastRequest.Messages, 2)
+		assert.Equal(t, "system", lastRequest.Messages[0].Role)
+		assert.Equal(t, "llama3", lastRequest.Model)
+		assert.Equal(t, "json_object", lastRequest.ResponseFormat["type"])
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		chunks := collectStream(t, p, "hi")
+		assert.Equal(t, "Hello", strings.Join(chunks, ""))
+	})
+
+	t.Run("embeddings", func(t *testing.T) {
+		emb, err := p.GenerateQueryEmbedding(context.Background(), "hi")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, emb)
+	})
+}
@@ -0,0 +1,474 @@
+// Package openinghours reads the opening hours attached to POIs. They come from models and
+// from sources that each write them their own way: "Mon-Fri 9:00-17:00, Sat 10:00-15:00",
+// "9am - 5pm", "Daily 24 hours", one entry per day, or OpenStreetMap opening_hours such as
+// "Mo-Fr 08:00-18:00; Sa 10:00-14:00; Su off; Dec 25 off". They are read into a weekly
+// schedule with exceptions for dates of the year. Whatever cannot be read is reported as
+// unknown rather than closed, so callers can tell "closed" from "we don't know".
+package openinghours
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// Window is an opening period in minutes since midnight. Close is past minutesPerDay when
+// the place closes after midnight.
+type Window struct {
+	Open  int
+	Close int
+}
+
+// Exception replaces the weekly hours on a date of the year, e.g. "Dec 25 off".
+type Exception struct {
+	Year    int // 0 for every year
+	Month   time.Month
+	Day     int
+	Windows []Window // none when the place is closed all day
+}
+
+func (e Exception) on(t time.Time) bool {
+	y, m, d := t.Date()
+	return m == e.Month && d == e.Day && (e.Year == 0 || e.Year == y)
+}
+
+// Hours is a weekly schedule, indexed by time.Weekday, with its exceptions.
+type Hours struct {
+	week       [7][]Window
+	exceptions []Exception
+	known      bool
+}
+
+// Known reports whether anything could be read from the source.
+func (h Hours) Known() bool {
+	return h.known
+}
+
+// Windows returns the opening periods that start on day.
+func (h Hours) Windows(day time.Weekday) []Window {
+	return h.week[day]
+}
+
+// Exceptions returns the dates whose hours differ from the weekly schedule.
+func (h Hours) Exceptions() []Exception {
+	return h.exceptions
+}
+
+// windowsOn returns the opening periods that start on the date of t, exceptions first.
+func (h Hours) windowsOn(t time.Time) []Window {
+	for _, e := range h.exceptions {
+		if e.on(t) {
+			return e.Windows
+		}
+	}
+	return h.week[t.Weekday()]
+}
+
+// OpenAt reports whether the place is open at t. When the hours are unknown, known is false
+// and open is true: nothing says the place is closed.
+func (h Hours) OpenAt(t time.Time) (open, known bool) {
+	return h.OpenBetween(t, t)
+}
+
+// OpenBetween reports whether the place stays open from start to end, both on the same
+// opening period. Periods running past midnight are taken into account.
+func (h Hours) OpenBetween(start, end time.Time) (open, known bool) {
+	if !h.known {
+		return true, false
+	}
+	from := start.Hour()*60 + start.Minute()
+	to := from + int(end.Sub(start).Minutes())
+	for _, w := range h.windowsOn(start) {
+		if from >= w.Open && to <= w.Close {
+			return true, true
+		}
+	}
+	// A period of the day before that runs past midnight
+	for _, w := range h.windowsOn(start.AddDate(0, 0, -1)) {
+		if w.Close > minutesPerDay && from+minutesPerDay >= w.Open && to+minutesPerDay <= w.Close {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// Parse reads the opening hours of a POI as stored: entries keyed by day name or by date,
+// or a single free-form entry under any other key ("general" for models that answered with
+// a string).
+func Parse(src map[string]string) Hours {
+	var h Hours
+	for key, value := range src {
+		key = strings.ToLower(key)
+		if e, rest, ok := parseDate(key); ok && strings.TrimSpace(rest) == "" {
+			if windows, _, ok := parseTimes(strings.ToLower(value)); ok {
+				e.Windows = windows
+				h.exceptions = append(h.exceptions, e)
+				h.known = true
+			}
+			continue
+		}
+		days := parseDays(key)
+		if days == nil {
+			// Not a day: the value carries its own days
+			h.merge(ParseString(value))
+			continue
+		}
+		windows, closed, ok := parseTimes(strings.ToLower(value))
+		if !ok {
+			continue
+		}
+		h.known = true
+		for _, d := range days {
+			if !closed {
+				h.week[d] = append(h.week[d], windows...)
+			}
+		}
+	}
+	return h
+}
+
+// ParseString reads free-form opening hours, e.g. "Mon-Fri 9:00-17:00, Sat 10:00-15:00",
+// and OpenStreetMap opening_hours, e.g. "Mo-Fr 09:00-12:00,13:00-17:00; PH off".
+// Times without days apply to the days before them, or to every day when none were given.
+// Days named again replace what was said about them before, as in "Daily 10-18; Mon closed".
+// Days not mentioned once some were are closed. Dates such as "Dec 25 off" are exceptions;
+// public and school holidays are not known and their rules are skipped.
+func ParseString(s string) Hours {
+	var h Hours
+	s = strings.ToLower(strings.NewReplacer("–", "-", "—", "-", "\u00a0", " ").Replace(s))
+	days := everyDay
+	// The part that last set each day: a later part naming the day replaces it
+	var setBy [7]int
+	part := 0
+	// Days listed without times, as in "Mon, Wed 9-17", share the times that follow
+	var pending []time.Weekday
+	for _, text := range splitter.Split(s, -1) {
+		text = strings.TrimSpace(text)
+		if text == "" || holiday.MatchString(text) {
+			continue
+		}
+		if e, rest, ok := parseDate(text); ok {
+			if windows, _, ok := parseTimes(rest); ok {
+				e.Windows = windows
+				h.exceptions = append(h.exceptions, e)
+				h.known = true
+			}
+			continue
+		}
+		timesAt := len(text)
+		if loc := timesStart.FindStringIndex(text); loc != nil {
+			timesAt = loc[0]
+		}
+		d := parseDays(text[:timesAt])
+		windows, closed, ok := parseTimes(text[timesAt:])
+		if !ok {
+			pending = append(pending, d...)
+			continue
+		}
+		if d == nil && closed {
+			// "Closed on Mondays"
+			d = parseDays(text[timesAt:])
+		}
+		if d != nil || pending != nil {
+			days = append(pending, d...)
+			part++
+		}
+		pending = nil
+		h.known = true
+		for _, d := range days {
+			if setBy[d] != part {
+				h.week[d] = nil
+				setBy[d] = part
+			}
+			if !closed {
+				h.week[d] = append(h.week[d], windows...)
+			}
+		}
+	}
+	return h
+}
+
+func (h *Hours) merge(o Hours) {
+	if !o.known {
+		return
+	}
+	h.known = true
+	for d := range h.week {
+		h.week[d] = append(h.week[d], o.week[d]...)
+	}
+	h.exceptions = append(h.exceptions, o.exceptions...)
+}
+
+// Decode reads opening hours stored as JSON, either an object of entries or a single string,
+// as in points_of_interest.opening_hours.
+func Decode(raw []byte) Hours {
+	if len(raw) == 0 {
+		return Hours{}
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		return Parse(entries)
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return ParseString(text)
+	}
+	return Hours{}
+}
+
+// String writes the hours the OpenStreetMap way, e.g. "Mo-Fr 09:00-17:00; Sa,Su off;
+// Dec 25 off", and "" when they are unknown. Days with the same hours are grouped.
+func (h Hours) String() string {
+	if !h.known {
+		return ""
+	}
+	var parts []string
+	// Monday first, as OSM does
+	order := append(append([]time.Weekday{}, everyDay[1:]...), time.Sunday)
+	for i := 0; i < len(order); {
+		j := i
+		var days []string
+		for j < len(order) && sameWindows(h.week[order[j]], h.week[order[i]]) {
+			j++
+		}
+		// Runs of three days or more read as ranges, shorter ones as lists
+		if j-i >= 3 {
+			days = []string{shortDay(order[i]) + "-" + shortDay(order[j-1])}
+		} else {
+			for _, d := range order[i:j] {
+				days = append(days, shortDay(d))
+			}
+		}
+		parts = append(parts, strings.Join(days, ",")+" "+formatWindows(h.week[order[i]]))
+		i = j
+	}
+	if len(parts) == 1 && parts[0] == "Mo-Su 00:00-24:00" {
+		parts = []string{"24/7"}
+	} else if len(parts) == 1 {
+		parts[0] = strings.TrimPrefix(parts[0], "Mo-Su ")
+	}
+	for _, e := range h.exceptions {
+		date := months[e.Month-1] + " " + strconv.Itoa(e.Day)
+		if e.Year != 0 {
+			date = strconv.Itoa(e.Year) + " " + date
+		}
+		parts = append(parts, strings.ToUpper(date[:1])+date[1:]+" "+formatWindows(e.Windows))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func sameWindows(a, b []Window) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func shortDay(d time.Weekday) string {
+	return d.String()[:2]
+}
+
+func formatWindows(windows []Window) string {
+	if len(windows) == 0 {
+		return "off"
+	}
+	out := make([]string, len(windows))
+	for i, w := range windows {
+		// Past midnight reads as the time on the next day, "22:00-03:00"
+		end := w.Close
+		if end > minutesPerDay {
+			end -= minutesPerDay
+		}
+		out[i] = fmt.Sprintf("%02d:%02d-%02d:%02d", w.Open/60, w.Open%60, end/60, end%60)
+	}
+	return strings.Join(out, ",")
+}
+
+var (
+	everyDay = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	weekdays = everyDay[1:6]
+	weekends = []time.Weekday{time.Saturday, time.Sunday}
+
+	// Parts are separated by commas, semicolons, pipes or new lines
+	splitter = regexp.MustCompile(`[,;|\n]+`)
+	// Where the times of a part start: a digit, or a word for all day or closed
+	timesStart = regexp.MustCompile(`\d|closed|\boff\b|open 24|24 hours|around the clock|noon|midnight`)
+	// Rules for public and school holidays, which depend on a calendar we do not have
+	holiday = regexp.MustCompile(`^(ph|sh|public holidays?|school holidays?|holidays?)\b`)
+	// Dates of the year: "dec 25", "2025 dec 25", "25 dec", "december 25th"
+	monthDay  = regexp.MustCompile(`^(?:(\d{4})\s+)?(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+(\d{1,2})(?:st|nd|rd|th)?\b:?(.*)$`)
+	dayMonth  = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?\s+(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?(?:\s+(\d{4}))?\b:?(.*)$`)
+	offWord   = regexp.MustCompile(`\boff\b`)
+	months    = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayWord   = regexp.MustCompile(`[a-z]+`)
+	timeRange = regexp.MustCompile(`(\d{1,2})(?:[:.h](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?\s*(?:-|to|until)\s*(\d{1,2})(?:[:.h](\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?`)
+)
+
+// parseDays reads a day specification such as "mon-fri", "sat & sun", "weekdays" or
+// "daily". It returns nil when there is none.
+func parseDays(s string) []time.Weekday {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	switch {
+	case strings.Contains(s, "daily"), strings.Contains(s, "every day"), strings.Contains(s, "everyday"):
+		return everyDay
+	case strings.Contains(s, "weekday"):
+		return weekdays
+	case strings.Contains(s, "weekend"):
+		return weekends
+	}
+
+	var days []time.Weekday
+	seen := map[time.Weekday]bool{}
+	add := func(d time.Weekday) {
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	words := dayWord.FindAllStringIndex(s, -1)
+	for i := 0; i < len(words); i++ {
+		from, ok := weekday(s[words[i][0]:words[i][1]])
+		if !ok {
+			continue
+		}
+		// "mon-fri" and "mon to fri" are ranges
+		if i+1 < len(words) {
+			between := strings.TrimSpace(s[words[i][1]:words[i+1][0]])
+			next := s[words[i+1][0]:words[i+1][1]]
+			if next == "to" && i+2 < len(words) {
+				if to, ok := weekday(s[words[i+2][0]:words[i+2][1]]); ok {
+					addRange(add, from, to)
+					i += 2
+					continue
+				}
+			}
+			if between == "-" {
+				if to, ok := weekday(next); ok {
+					addRange(add, from, to)
+					i++
+					continue
+				}
+			}
+		}
+		add(from)
+	}
+	if len(days) == 0 {
+		return nil
+	}
+	return days
+}
+
+func addRange(add func(time.Weekday), from, to time.Weekday) {
+	for d := from; ; d = (d + 1) % 7 {
+		add(d)
+		if d == to {
+			return
+		}
+	}
+}
+
+// weekday reads a day name in full or abbreviated to two or three letters.
+func weekday(word string) (time.Weekday, bool) {
+	if len(word) < 2 {
+		return 0, false
+	}
+	for _, d := range everyDay {
+		name := strings.ToLower(d.String())
+		if strings.HasPrefix(name, word) || strings.HasPrefix(word, name) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// parseDate reads a date of the year at the start of s and returns what follows it.
+func parseDate(s string) (e Exception, rest string, ok bool) {
+	s = strings.TrimSpace(s)
+	var year, month, day string
+	if m := monthDay.FindStringSubmatch(s); m != nil {
+		year, month, day, rest = m[1], m[2], m[3], m[4]
+	} else if m := dayMonth.FindStringSubmatch(s); m != nil {
+		day, month, year, rest = m[1], m[2], m[3], m[4]
+	} else {
+		return Exception{}, "", false
+	}
+	d, _ := strconv.Atoi(day)
+	if d < 1 || d > 31 {
+		return Exception{}, "", false
+	}
+	e.Day = d
+	e.Year, _ = strconv.Atoi(year)
+	for i, name := range months {
+		if name == month {
+			e.Month = time.Month(i + 1)
+		}
+	}
+	return e, rest, true
+}
+
+// parseTimes reads the times of a part: ranges such as "9:00-17:00" or "9am - 5pm", or a
+// word for open all day or closed. ok is false when nothing could be read.
+func parseTimes(s string) (windows []Window, closed, ok bool) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Contains(s, "closed"), offWord.MatchString(s):
+		return nil, true, true
+	case strings.Contains(s, "24 hours"), strings.Contains(s, "24/7"), strings.Contains(s, "open 24"),
+		strings.Contains(s, "around the clock"):
+		return []Window{{Open: 0, Close: minutesPerDay}}, false, true
+	}
+	s = strings.NewReplacer("noon", "12:00", "midnight", "24:00").Replace(s)
+	for _, m := range timeRange.FindAllStringSubmatch(s, -1) {
+		open, ok1 := clock(m[1], m[2], m[3])
+		end, ok2 := clock(m[4], m[5], m[6])
+		if !ok1 || !ok2 {
+			continue
+		}
+		// "9-5" reads as nine to five
+		if m[3] == "" && m[6] == "" && end <= open && end < 12*60 && open < 12*60 {
+			end += 12 * 60
+		}
+		if end <= open {
+			end += minutesPerDay
+		}
+		windows = append(windows, Window{Open: open, Close: end})
+	}
+	return windows, false, len(windows) > 0
+}
+
+func clock(hour, minute, meridiem string) (int, bool) {
+	h, err := strconv.Atoi(hour)
+	if err != nil || h > 24 {
+		return 0, false
+	}
+	m := 0
+	if minute != "" {
+		if m, err = strconv.Atoi(minute); err != nil || m > 59 {
+			return 0, false
+		}
+	}
+	switch strings.ReplaceAll(meridiem, ".", "") {
+	case "am":
+		if h == 12 {
+			h = 0
+		}
+	case "pm":
+		if h < 12 {
+			h += 12
+		}
+	}
+	return h*60 + m, true
+}
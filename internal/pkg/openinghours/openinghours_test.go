@@ -0,0 +1,147 @@
+package openinghours
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// at returns the given weekday of the week of 2025-06-02, a Monday, at hh:mm.
+func at(day time.Weekday, hh, mm int) time.Time {
+	monday := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	offset := (int(day) + 6) % 7
+	return monday.AddDate(0, 0, offset).Add(time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute)
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours string
+		when  time.Time
+		open  bool
+		known bool
+	}{
+		{"weekday range open", "Mon-Fri 9:00-17:00, Sat 10:00-15:00", at(time.Wednesday, 10, 0), true, true},
+		{"weekday range after closing", "Mon-Fri 9:00-17:00, Sat 10:00-15:00", at(time.Wednesday, 17, 30), false, true},
+		{"saturday", "Mon-Fri 9:00-17:00, Sat 10:00-15:00", at(time.Saturday, 14, 0), true, true},
+		{"unmentioned day is closed", "Mon-Fri 9:00-17:00, Sat 10:00-15:00", at(time.Sunday, 12, 0), false, true},
+		{"twelve hour clock", "9am - 5pm", at(time.Sunday, 16, 59), true, true},
+		{"split day", "Tue-Sun 10:00-13:00, 14:00-18:00", at(time.Thursday, 13, 30), false, true},
+		{"split day afternoon", "Tue-Sun 10:00-13:00, 14:00-18:00", at(time.Thursday, 15, 0), true, true},
+		{"explicit closed day", "Daily 10:00-18:00; Mon closed", at(time.Monday, 12, 0), false, true},
+		{"past midnight", "Fri-Sat 22:00-03:00", at(time.Sunday, 2, 0), true, true},
+		{"always open", "Open 24 hours", at(time.Tuesday, 3, 0), true, true},
+		{"listed days share times", "Mon, Wed 9-17", at(time.Monday, 10, 0), true, true},
+		{"nine to five", "Weekdays 9-5", at(time.Friday, 16, 0), true, true},
+		{"unreadable", "Varies by season", at(time.Friday, 16, 0), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, known := ParseString(tt.hours).OpenAt(tt.when)
+			assert.Equal(t, tt.open, open)
+			assert.Equal(t, tt.known, known)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	h := Parse(map[string]string{
+		"monday":  "Closed",
+		"tuesday": "09:00-18:00",
+	})
+	assert.True(t, h.Known())
+	open, _ := h.OpenAt(at(time.Monday, 10, 0))
+	assert.False(t, open)
+	open, _ = h.OpenAt(at(time.Tuesday, 10, 0))
+	assert.True(t, open)
+
+	general := Parse(map[string]string{"general": "Mon-Fri 9:00-17:00"})
+	open, known := general.OpenAt(at(time.Monday, 9, 0))
+	assert.True(t, known)
+	assert.True(t, open)
+
+	open, known = Parse(nil).OpenAt(at(time.Monday, 9, 0))
+	assert.False(t, known)
+	assert.True(t, open)
+}
+
+func TestOpenBetween(t *testing.T) {
+	h := ParseString("Mon-Sun 10:00-18:00")
+	open, _ := h.OpenBetween(at(time.Monday, 16, 0), at(time.Monday, 18, 0))
+	assert.True(t, open)
+	open, _ = h.OpenBetween(at(time.Monday, 17, 0), at(time.Monday, 19, 0))
+	assert.False(t, open)
+}
+
+func TestParseString_OSM(t *testing.T) {
+	h := ParseString("Mo-Fr 09:00-12:00,13:00-17:00; Sa 10:00-14:00; Su off; PH off; Jun 4 off; 2025 Jun 7 10:00-12:00")
+	assert.True(t, h.Known())
+
+	tests := []struct {
+		name string
+		when time.Time
+		open bool
+	}{
+		{"morning", at(time.Monday, 10, 0), true},
+		{"lunch break", at(time.Monday, 12, 30), false},
+		{"afternoon", at(time.Friday, 16, 0), true},
+		{"sunday off", at(time.Sunday, 11, 0), false},
+		{"date off", at(time.Wednesday, 10, 0), false},
+		{"date with its own hours", at(time.Saturday, 13, 0), false},
+		{"date with its own hours open", at(time.Saturday, 11, 0), true},
+		{"same date another year", at(time.Wednesday, 10, 0).AddDate(0, 0, 364), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, known := h.OpenAt(tt.when)
+			assert.True(t, known)
+			assert.Equal(t, tt.open, open)
+		})
+	}
+}
+
+func TestParseString_ClosedOnNamedDays(t *testing.T) {
+	h := ParseString("10:00-18:00, closed Mondays")
+	open, _ := h.OpenAt(at(time.Monday, 12, 0))
+	assert.False(t, open)
+	open, _ = h.OpenAt(at(time.Tuesday, 12, 0))
+	assert.True(t, open)
+}
+
+func TestParse_DateKeys(t *testing.T) {
+	h := Parse(map[string]string{"general": "Daily 9:00-17:00", "25 December": "Closed"})
+	open, _ := h.OpenAt(time.Date(2025, 12, 25, 10, 0, 0, 0, time.UTC))
+	assert.False(t, open)
+	open, _ = h.OpenAt(time.Date(2025, 12, 26, 10, 0, 0, 0, time.UTC))
+	assert.True(t, open)
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "Mo-Fr 09:00-17:00; Sa,Su off; Dec 25 off",
+		ParseString("Mon-Fri 9:00-17:00; Dec 25 closed").String())
+	assert.Equal(t, "Mo-Th 10:00-18:00; Fr,Sa 10:00-18:00,22:00-03:00; Su 10:00-18:00",
+		ParseString("Daily 10-18; Fri-Sat 10:00-18:00, 22:00-03:00").String())
+	assert.Equal(t, "24/7", ParseString("Open 24 hours").String())
+	assert.Equal(t, "", ParseString("By appointment").String())
+
+	// What String writes reads back the same
+	h := ParseString("Mo-Fr 09:00-12:00,13:00-17:00; Sa 10:00-14:00; Su off; 2025 Dec 24 10:00-14:00")
+	assert.Equal(t, h.String(), ParseString(h.String()).String())
+}
+
+func TestDecode(t *testing.T) {
+	assert.True(t, Decode([]byte(`{"monday":"9-17"}`)).Known())
+	assert.True(t, Decode([]byte(`"Mo-Fr 09:00-17:00"`)).Known())
+	assert.False(t, Decode(nil).Known())
+	assert.False(t, Decode([]byte(`[1,2]`)).Known())
+}
+
+func TestLocation(t *testing.T) {
+	assert.Equal(t, "Europe/Lisbon", Location("Europe/Lisbon", 0).String())
+
+	_, offset := time.Date(2025, 6, 2, 12, 0, 0, 0, Location("", 139.7)).Zone()
+	assert.Equal(t, 9*60*60, offset)
+	_, offset = time.Date(2025, 6, 2, 12, 0, 0, 0, Location("Not/AZone", -74.0)).Zone()
+	assert.Equal(t, -5*60*60, offset)
+}
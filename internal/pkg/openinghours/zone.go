@@ -0,0 +1,24 @@
+package openinghours
+
+import (
+	"time"
+	// Time zones are looked up by name wherever the binary runs, including images without
+	// a zoneinfo database
+	_ "time/tzdata"
+)
+
+// Location returns the time zone of a place: the IANA zone when name is one, e.g.
+// "Europe/Lisbon", otherwise a fixed offset estimated from the longitude, which is off by
+// an hour or so around daylight saving time and odd borders but right for most places.
+func Location(name string, longitude float64) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	hours := int(longitude/15 + 0.5)
+	if longitude < 0 {
+		hours = int(longitude/15 - 0.5)
+	}
+	return time.FixedZone("", hours*60*60)
+}
@@ -0,0 +1,75 @@
+// Package prompteval scores the itinerary pipeline offline against a golden set of cases,
+// so a prompt or model change can be compared with what it replaces before it ships.
+//
+// A Pipeline turns a Case (a city, a profile and the query of a traveller) into the parts
+// the model answered with. Each case is scored on parse success, schema validity,
+// coordinate plausibility, category coverage, duplicate rate and adherence to the dietary
+// needs and budget of the profile. Run averages the scores of a variant and Compare puts
+// two variants side by side.
+package prompteval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// defaultMaxDistanceKm bounds how far from the city centre a POI may be when a case does
+// not say otherwise. It leaves room for day trips without accepting another city.
+const defaultMaxDistanceKm = 30
+
+// Case is one golden request: who asks for what, where, and what a good answer covers.
+type Case struct {
+	Name    string                               `json:"name"`
+	City    string                               `json:"city"`
+	Query   string                               `json:"query"`
+	Profile models.UserPreferenceProfileResponse `json:"profile"`
+	// Center of the city. POIs further than MaxDistanceKm from it are implausible.
+	CenterLatitude  float64 `json:"center_latitude"`
+	CenterLongitude float64 `json:"center_longitude"`
+	MaxDistanceKm   float64 `json:"max_distance_km,omitempty"`
+	// ExpectedCategories are matched against the category and tags of the POIs.
+	ExpectedCategories []string `json:"expected_categories,omitempty"`
+}
+
+// Part is the answer of the model to one prompt of the pipeline.
+type Part struct {
+	Name          string                   `json:"name"` // Part type, such as itinerary or restaurants
+	PromptID      string                   `json:"prompt_id"`
+	PromptVersion int                      `json:"prompt_version"`
+	Raw           string                   `json:"raw"`
+	SchemaErrors  []string                 `json:"schema_errors,omitempty"`
+	ParseError    string                   `json:"parse_error,omitempty"`
+	POIs          []models.POIDetailedInfo `json:"pois,omitempty"`
+}
+
+// Output is everything the pipeline produced for a case.
+type Output struct {
+	Parts []Part `json:"parts"`
+}
+
+// Pipeline generates the answer to a case with one prompt version and model.
+type Pipeline interface {
+	Generate(ctx context.Context, c Case) (*Output, error)
+}
+
+// LoadCases reads a golden set, a JSON array of cases.
+func LoadCases(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden set: %w", err)
+	}
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse golden set %s: %w", path, err)
+	}
+	for i, c := range cases {
+		if c.Name == "" || c.City == "" {
+			return nil, fmt.Errorf("golden case %d needs a name and a city", i)
+		}
+	}
+	return cases, nil
+}
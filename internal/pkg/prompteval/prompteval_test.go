@@ -0,0 +1,138 @@
+package prompteval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+func lisbonCase() Case {
+	return Case{
+		Name:               "lisbon",
+		City:               "Lisbon",
+		Profile:            models.UserPreferenceProfileResponse{BudgetLevel: 2, DietaryNeeds: []string{"vegetarian"}},
+		CenterLatitude:     38.7223,
+		CenterLongitude:    -9.1393,
+		ExpectedCategories: []string{"museum", "viewpoint", "beach"},
+	}
+}
+
+func lisbonOutput() *Output {
+	return &Output{Parts: []Part{{
+		Name: "itinerary",
+		POIs: []models.POIDetailedInfo{
+			{Name: "The Jerónimos Monastery", Category: "Museum", Latitude: 38.6979, Longitude: -9.2068, PriceLevel: "$$"},
+			{Name: "Jerónimos Monastery", Category: "Museum", Latitude: 38.6979, Longitude: -9.2068},
+			{Name: "Miradouro da Graça", Category: "Viewpoint", Latitude: 38.7163, Longitude: -9.1311, PriceLevel: "Free"},
+			{Name: "Ao 26 Vegan Food Project", Category: "Restaurant", Latitude: 38.7104, Longitude: -9.1427, PriceLevel: "$$"},
+			{Name: "Cervejaria Ramiro", Category: "Seafood restaurant", Latitude: 38.7209, Longitude: -9.1356, PriceLevel: "$$$"},
+			// Porto, 270 km away
+			{Name: "Livraria Lello", Category: "Bookshop", Latitude: 41.1466, Longitude: -8.6149},
+			{Name: "Unknown", Category: "Landmark"},
+		},
+	}}}
+}
+
+func TestScore(t *testing.T) {
+	result := Score(lisbonCase(), lisbonOutput())
+
+	assert.Equal(t, "lisbon", result.Case)
+	assert.Equal(t, 7, result.POIs)
+	assert.InDelta(t, 1.0, result.Metrics[MetricParseSuccess], 1e-9)
+	assert.InDelta(t, 1.0, result.Metrics[MetricSchemaValidity], 1e-9)
+	assert.InDelta(t, 5.0/7, result.Metrics[MetricCoordinatePlausibility], 1e-9, "Porto and 0,0 are implausible")
+	assert.InDelta(t, 2.0/3, result.Metrics[MetricCategoryCoverage], 1e-9, "no beach")
+	assert.InDelta(t, 1.0/7, result.Metrics[MetricDuplicateRate], 1e-9, "the monastery is listed twice")
+	assert.InDelta(t, 0.5, result.Metrics[MetricDietaryAdherence], 1e-9, "one of two restaurants is vegetarian")
+	assert.InDelta(t, 2.0/3, result.Metrics[MetricBudgetAdherence], 1e-9, "free has no tier, $$$ is over budget")
+}
+
+func TestScore_LeavesOutMetricsThatDoNotApply(t *testing.T) {
+	c := Case{Name: "paris", City: "Paris"}
+	out := &Output{Parts: []Part{{Name: "itinerary", POIs: []models.POIDetailedInfo{
+		{Name: "Louvre", Category: "Museum", Latitude: 48.8606, Longitude: 2.3376},
+	}}}}
+
+	result := Score(c, out)
+	assert.InDelta(t, 1.0, result.Metrics[MetricCoordinatePlausibility], 1e-9)
+	for _, metric := range []string{MetricCategoryCoverage, MetricDietaryAdherence, MetricBudgetAdherence} {
+		assert.NotContains(t, result.Metrics, metric)
+	}
+}
+
+func TestScore_FailedParts(t *testing.T) {
+	out := &Output{Parts: []Part{
+		{Name: "itinerary", Raw: "Sorry, I cannot help", ParseError: "failed to parse itinerary", SchemaErrors: []string{"invalid JSON"}},
+		{Name: "restaurants", POIs: []models.POIDetailedInfo{{Name: "Ramiro", Latitude: 38.72, Longitude: -9.13}}},
+	}}
+
+	result := Score(lisbonCase(), out)
+	assert.InDelta(t, 0.5, result.Metrics[MetricParseSuccess], 1e-9)
+	assert.InDelta(t, 0.5, result.Metrics[MetricSchemaValidity], 1e-9)
+	assert.InDelta(t, 0.0, result.Metrics[MetricDietaryAdherence], 1e-9)
+
+	empty := Score(lisbonCase(), nil)
+	assert.InDelta(t, 0.0, empty.Metrics[MetricParseSuccess], 1e-9)
+	assert.NotContains(t, empty.Metrics, MetricDuplicateRate)
+}
+
+type stubPipeline map[string]*Output
+
+func (p stubPipeline) Generate(_ context.Context, c Case) (*Output, error) {
+	out, ok := p[c.Name]
+	if !ok {
+		return nil, errors.New("provider unavailable")
+	}
+	return out, nil
+}
+
+func TestRunAndCompare(t *testing.T) {
+	cases := []Case{lisbonCase(), {Name: "porto", City: "Porto"}}
+	clean := lisbonOutput()
+	clean.Parts[0].POIs = clean.Parts[0].POIs[2:5]
+
+	baseline := Run(context.Background(), "v1", stubPipeline{"lisbon": lisbonOutput()}, cases)
+	candidate := Run(context.Background(), "v2", stubPipeline{"lisbon": clean, "porto": clean}, cases)
+
+	require.Len(t, baseline.Cases, 2)
+	assert.Equal(t, 1, baseline.Errors)
+	assert.Equal(t, "provider unavailable", baseline.Cases[1].Error)
+	assert.InDelta(t, 0.5, baseline.Summary[MetricParseSuccess], 1e-9, "a failed case counts as unparsed")
+	assert.InDelta(t, 1.0/7, baseline.Summary[MetricDuplicateRate], 1e-9, "averaged over the cases with POIs")
+
+	cmp := Compare(baseline, candidate)
+	deltas := make(map[string]MetricDelta)
+	for _, d := range cmp.Deltas {
+		deltas[d.Metric] = d
+	}
+	assert.InDelta(t, 0.5, deltas[MetricParseSuccess].Delta, 1e-9)
+	assert.False(t, deltas[MetricDuplicateRate].Regressed, "fewer duplicates is better")
+	assert.True(t, deltas[MetricCategoryCoverage].Regressed, "dropping the museum loses coverage")
+	assert.Contains(t, cmp.Regressions(), deltas[MetricCategoryCoverage])
+
+	var report strings.Builder
+	require.NoError(t, cmp.WriteMarkdown(&report))
+	assert.Contains(t, report.String(), "# Prompt evaluation: v1 vs v2")
+	assert.Contains(t, report.String(), "| category_coverage | 0.667 | 0.333 | -0.333 ⚠ |")
+	assert.Contains(t, report.String(), "- v1 failed porto: provider unavailable")
+}
+
+func TestLoadCases(t *testing.T) {
+	cases, err := LoadCases("testdata/golden.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, cases)
+	for _, c := range cases {
+		assert.NotEmpty(t, c.Query, c.Name)
+		assert.NotZero(t, c.CenterLatitude, c.Name)
+	}
+	assert.Equal(t, []string{"vegetarian"}, cases[0].Profile.DietaryNeeds)
+
+	_, err = LoadCases("testdata/missing.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package prompteval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RunResult holds the scores of one variant, a prompt version or model, over a golden set.
+type RunResult struct {
+	Label string       `json:"label"`
+	Cases []CaseResult `json:"cases"`
+	// Summary averages each metric over the cases it applies to.
+	Summary map[string]float64 `json:"summary"`
+	Errors  int                `json:"errors"`
+}
+
+// Run generates and scores every case with one pipeline. A case the pipeline fails on is
+// scored as unparsed rather than stopping the run.
+func Run(ctx context.Context, label string, pipeline Pipeline, cases []Case) *RunResult {
+	run := &RunResult{Label: label, Summary: make(map[string]float64)}
+	for _, c := range cases {
+		out, err := pipeline.Generate(ctx, c)
+		result := Score(c, out)
+		if err != nil {
+			result.Error = err.Error()
+			run.Errors++
+		}
+		run.Cases = append(run.Cases, result)
+	}
+
+	for _, m := range Metrics {
+		sum, n := 0.0, 0
+		for _, result := range run.Cases {
+			if v, ok := result.Metrics[m.Name]; ok {
+				sum += v
+				n++
+			}
+		}
+		if n > 0 {
+			run.Summary[m.Name] = sum / float64(n)
+		}
+	}
+	return run
+}
+
+// MetricDelta compares the summary of one metric between two runs.
+type MetricDelta struct {
+	Metric    string  `json:"metric"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	Delta     float64 `json:"delta"`
+	// Regressed is set when the candidate does worse, whichever way the metric reads.
+	Regressed bool `json:"regressed"`
+}
+
+// Comparison puts the runs of two variants over the same golden set side by side.
+type Comparison struct {
+	Baseline  *RunResult    `json:"baseline"`
+	Candidate *RunResult    `json:"candidate"`
+	Deltas    []MetricDelta `json:"deltas"`
+}
+
+// Compare compares candidate with baseline on every metric both runs scored.
+func Compare(baseline, candidate *RunResult) *Comparison {
+	cmp := &Comparison{Baseline: baseline, Candidate: candidate}
+	for _, m := range Metrics {
+		b, okB := baseline.Summary[m.Name]
+		c, okC := candidate.Summary[m.Name]
+		if !okB || !okC {
+			continue
+		}
+		delta := c - b
+		cmp.Deltas = append(cmp.Deltas, MetricDelta{
+			Metric:    m.Name,
+			Baseline:  b,
+			Candidate: c,
+			Delta:     delta,
+			Regressed: delta < 0 && !m.LowerIsBetter || delta > 0 && m.LowerIsBetter,
+		})
+	}
+	return cmp
+}
+
+// Regressions returns the metrics the candidate does worse on.
+func (c *Comparison) Regressions() []MetricDelta {
+	var regressed []MetricDelta
+	for _, d := range c.Deltas {
+		if d.Regressed {
+			regressed = append(regressed, d)
+		}
+	}
+	return regressed
+}
+
+// WriteMarkdown writes the comparison as a summary table followed by the scores per case.
+func (c *Comparison) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Prompt evaluation: %s vs %s\n\n", c.Baseline.Label, c.Candidate.Label)
+	fmt.Fprintf(&b, "%d cases, %d failed for %s and %d for %s.\n\n",
+		len(c.Baseline.Cases), c.Baseline.Errors, c.Baseline.Label, c.Candidate.Errors, c.Candidate.Label)
+
+	fmt.Fprintf(&b, "| Metric | %s | %s | Delta |\n|---|---:|---:|---:|\n", c.Baseline.Label, c.Candidate.Label)
+	for _, d := range c.Deltas {
+		mark := ""
+		if d.Regressed {
+			mark = " ⚠"
+		}
+		fmt.Fprintf(&b, "| %s | %.3f | %.3f | %+.3f%s |\n", d.Metric, d.Baseline, d.Candidate, d.Delta, mark)
+	}
+
+	b.WriteString("\n## Cases\n\n| Case |")
+	for _, m := range Metrics {
+		fmt.Fprintf(&b, " %s |", m.Name)
+	}
+	b.WriteString("\n|---|")
+	b.WriteString(strings.Repeat("---|", len(Metrics)))
+	b.WriteString("\n")
+	candidates := make(map[string]CaseResult, len(c.Candidate.Cases))
+	for _, result := range c.Candidate.Cases {
+		candidates[result.Case] = result
+	}
+	for _, base := range c.Baseline.Cases {
+		cand := candidates[base.Case]
+		fmt.Fprintf(&b, "| %s |", base.Case)
+		for _, m := range Metrics {
+			fmt.Fprintf(&b, " %s → %s |", formatMetric(base, m.Name), formatMetric(cand, m.Name))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, run := range []*RunResult{c.Baseline, c.Candidate} {
+		for _, result := range run.Cases {
+			if result.Error != "" {
+				fmt.Fprintf(&b, "\n- %s failed %s: %s", run.Label, result.Case, result.Error)
+			}
+		}
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatMetric(result CaseResult, metric string) string {
+	v, ok := result.Metrics[metric]
+	if !ok {
+		return "–"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,269 @@
+package prompteval
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/budget"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+)
+
+// Metric names. Every metric is a share between 0 and 1.
+const (
+	MetricParseSuccess           = "parse_success"
+	MetricSchemaValidity         = "schema_validity"
+	MetricCoordinatePlausibility = "coordinate_plausibility"
+	MetricCategoryCoverage       = "category_coverage"
+	MetricDuplicateRate          = "duplicate_rate"
+	MetricDietaryAdherence       = "dietary_adherence"
+	MetricBudgetAdherence        = "budget_adherence"
+)
+
+// Metric describes how a metric reads in a report.
+type Metric struct {
+	Name          string
+	LowerIsBetter bool
+}
+
+// Metrics lists every metric in report order.
+var Metrics = []Metric{
+	{Name: MetricParseSuccess},
+	{Name: MetricSchemaValidity},
+	{Name: MetricCoordinatePlausibility},
+	{Name: MetricCategoryCoverage},
+	{Name: MetricDuplicateRate, LowerIsBetter: true},
+	{Name: MetricDietaryAdherence},
+	{Name: MetricBudgetAdherence},
+}
+
+// dietaryTerms are the words a place meeting a dietary need is described with. Needs not
+// listed match themselves.
+var dietaryTerms = map[string][]string{
+	"vegetarian":  {"vegetarian", "veggie", "vegan", "plant-based", "plant based"},
+	"vegan":       {"vegan", "plant-based", "plant based"},
+	"gluten-free": {"gluten-free", "gluten free", "celiac", "coeliac"},
+	"dairy-free":  {"dairy-free", "dairy free", "lactose"},
+	"halal":       {"halal"},
+	"kosher":      {"kosher"},
+}
+
+// foodWords mark itinerary POIs that are places to eat or drink.
+var foodWords = []string{"restaurant", "cafe", "café", "food", "dining", "bakery", "bistro", "eatery", "market", "bar", "tavern"}
+
+// CaseResult is the score of one case. Metrics that do not apply to the case, such as
+// dietary adherence for a profile without dietary needs, are left out.
+type CaseResult struct {
+	Case    string             `json:"case"`
+	POIs    int                `json:"pois"`
+	Metrics map[string]float64 `json:"metrics"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// Score scores what the pipeline produced for a case.
+func Score(c Case, out *Output) CaseResult {
+	result := CaseResult{Case: c.Name, Metrics: make(map[string]float64)}
+	if out == nil || len(out.Parts) == 0 {
+		result.Metrics[MetricParseSuccess] = 0
+		result.Metrics[MetricSchemaValidity] = 0
+		return result
+	}
+
+	var parsed, valid int
+	var pois, food []models.POIDetailedInfo
+	for _, part := range out.Parts {
+		if part.ParseError == "" {
+			parsed++
+		}
+		if len(part.SchemaErrors) == 0 {
+			valid++
+		}
+		for _, poi := range part.POIs {
+			pois = append(pois, poi)
+			if part.Name == "restaurants" || isFood(poi) {
+				food = append(food, poi)
+			}
+		}
+	}
+	result.POIs = len(pois)
+	result.Metrics[MetricParseSuccess] = ratio(parsed, len(out.Parts))
+	result.Metrics[MetricSchemaValidity] = ratio(valid, len(out.Parts))
+
+	if len(pois) > 0 {
+		result.Metrics[MetricCoordinatePlausibility] = coordinatePlausibility(c, pois)
+		result.Metrics[MetricDuplicateRate] = duplicateRate(pois)
+	}
+	if len(c.ExpectedCategories) > 0 {
+		result.Metrics[MetricCategoryCoverage] = categoryCoverage(c.ExpectedCategories, pois)
+	}
+	if len(c.Profile.DietaryNeeds) > 0 && len(food) > 0 {
+		result.Metrics[MetricDietaryAdherence] = dietaryAdherence(c.Profile.DietaryNeeds, food)
+	}
+	if c.Profile.BudgetLevel > 0 {
+		if share, ok := budgetAdherence(c.Profile.BudgetLevel, pois); ok {
+			result.Metrics[MetricBudgetAdherence] = share
+		}
+	}
+	return result
+}
+
+// coordinatePlausibility is the share of POIs within reach of the city centre, or with
+// coordinates on the globe at all when the case has no centre.
+func coordinatePlausibility(c Case, pois []models.POIDetailedInfo) float64 {
+	maxKm := c.MaxDistanceKm
+	if maxKm <= 0 {
+		maxKm = defaultMaxDistanceKm
+	}
+	hasCenter := c.CenterLatitude != 0 || c.CenterLongitude != 0
+	center := routing.Point{Latitude: c.CenterLatitude, Longitude: c.CenterLongitude}
+
+	plausible := 0
+	for _, poi := range pois {
+		// 0,0 is what models answer when they do not know
+		if poi.Latitude == 0 && poi.Longitude == 0 ||
+			math.Abs(poi.Latitude) > 90 || math.Abs(poi.Longitude) > 180 {
+			continue
+		}
+		if hasCenter && routing.DistanceKm(center, routing.Point{Latitude: poi.Latitude, Longitude: poi.Longitude}) > maxKm {
+			continue
+		}
+		plausible++
+	}
+	return ratio(plausible, len(pois))
+}
+
+// categoryCoverage is the share of expected categories some POI falls under.
+func categoryCoverage(expected []string, pois []models.POIDetailedInfo) float64 {
+	covered := 0
+	for _, want := range expected {
+		want = strings.ToLower(strings.TrimSpace(want))
+		for _, poi := range pois {
+			if matchesCategory(want, poi) {
+				covered++
+				break
+			}
+		}
+	}
+	return ratio(covered, len(expected))
+}
+
+func matchesCategory(want string, poi models.POIDetailedInfo) bool {
+	labels := append([]string{poi.Category}, poi.Tags...)
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label != "" && (strings.Contains(label, want) || strings.Contains(want, label)) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateRate is the share of POIs repeating one listed before them, by name.
+func duplicateRate(pois []models.POIDetailedInfo) float64 {
+	seen := make(map[string]bool, len(pois))
+	duplicates := 0
+	for _, poi := range pois {
+		key := normalizeName(poi.Name)
+		if seen[key] {
+			duplicates++
+			continue
+		}
+		seen[key] = true
+	}
+	return ratio(duplicates, len(pois))
+}
+
+// normalizeName folds case, punctuation and a leading article, so "The Louvre" and
+// "Louvre" are the same place.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	fields := strings.Fields(b.String())
+	if len(fields) > 1 && (fields[0] == "the" || fields[0] == "la" || fields[0] == "le" || fields[0] == "el") {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// dietaryAdherence is the share of places to eat described as meeting any of the needs.
+func dietaryAdherence(needs []string, food []models.POIDetailedInfo) float64 {
+	var terms []string
+	for _, need := range needs {
+		need = strings.ToLower(strings.TrimSpace(need))
+		if known, ok := dietaryTerms[need]; ok {
+			terms = append(terms, known...)
+		} else if need != "" {
+			terms = append(terms, need)
+		}
+	}
+
+	meets := 0
+	for _, poi := range food {
+		text := strings.ToLower(strings.Join(append([]string{poi.Name, poi.Description, poi.CuisineType, poi.Category}, poi.Tags...), " "))
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				meets++
+				break
+			}
+		}
+	}
+	return ratio(meets, len(food))
+}
+
+// budgetAdherence is the share of priced POIs within the budget level of the profile.
+// ok is false when no POI has a price tier.
+func budgetAdherence(level int, pois []models.POIDetailedInfo) (float64, bool) {
+	priced, within := 0, 0
+	for _, poi := range pois {
+		tier := priceTier(poi)
+		if tier == 0 {
+			continue
+		}
+		priced++
+		if tier <= level {
+			within++
+		}
+	}
+	if priced == 0 {
+		return 0, false
+	}
+	return ratio(within, priced), true
+}
+
+// priceTier reads the 1-4 tier of a POI, 0 when it has none.
+func priceTier(poi models.POIDetailedInfo) int {
+	for _, s := range []string{poi.PriceLevel, poi.PriceRange, poi.Budget} {
+		if p, ok := budget.ParsePrice(s); ok && p.Level > 0 {
+			return p.Level
+		}
+	}
+	return 0
+}
+
+func isFood(poi models.POIDetailedInfo) bool {
+	if poi.CuisineType != "" {
+		return true
+	}
+	category := strings.ToLower(poi.Category)
+	for _, w := range foodWords {
+		if strings.Contains(category, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,244 @@
+package prompts
+
+// CityParams is used by the prompts that only need the city name.
+type CityParams struct {
+	CityName string
+}
+
+// CityPreferencesParams adds the formatted user preferences block.
+type CityPreferencesParams struct {
+	CityName    string
+	Preferences string
+}
+
+// CityLocationParams anchors a personalised search to coordinates within the city.
+type CityLocationParams struct {
+	CityName    string
+	Lat         float64
+	Lon         float64
+	Preferences string
+}
+
+// POIDetailsParams identifies a POI by the city and its coordinates.
+type POIDetailsParams struct {
+	CityName string
+	Lat      float64
+	Lon      float64
+}
+
+// ContinueConversationParams asks for details on a POI mentioned in a follow-up message.
+// Conversation is the compacted session context (see chatmemory.Window), used from v2.
+type ContinueConversationParams struct {
+	POIName      string
+	CityName     string
+	Conversation string
+}
+
+// ConversationTurn is one message of a chat session.
+type ConversationTurn struct {
+	Role    string
+	Content string
+}
+
+// ConversationSummaryParams folds older chat messages into the running session summary.
+type ConversationSummaryParams struct {
+	CityName        string
+	PreviousSummary string
+	Messages        []ConversationTurn
+	MaxWords        int
+}
+
+// AnswerQuestionParams answers a question asked during a chat session.
+type AnswerQuestionParams struct {
+	Question     string
+	Conversation string
+}
+
+// TripCityParams asks for the itinerary of one city of a multi-city trip. Stops is the whole
+// trip, so the stay fits between the cities before and after it.
+type TripCityParams struct {
+	CityName     string
+	Nights       int
+	Position     int // 1-based
+	Stops        []TripStop
+	Previous     string
+	Next         string
+	Preferences  string
+	Instructions string
+}
+
+// TripStop is one city of a trip as the trip prompt lists it.
+type TripStop struct {
+	Position int
+	City     string
+	Country  string
+	Nights   int
+}
+
+// AgentTurnParams is one turn of the chat agent loop (internal/pkg/agent). Steps are the tool
+// calls made earlier in the turn; Final asks for an answer once the iteration cap is reached.
+type AgentTurnParams struct {
+	Question     string
+	Conversation string
+	Tools        []AgentTool
+	Steps        []AgentStep
+	Final        bool
+}
+
+// AgentTool describes a tool the model may call. Schema is the JSON schema of its arguments.
+type AgentTool struct {
+	Name        string
+	Description string
+	Schema      string
+}
+
+// AgentStep is a tool call and its JSON encoded result, or the error it failed with.
+type AgentStep struct {
+	Tool      string
+	Arguments string
+	Result    string
+}
+
+// DiscoverSearchParams is a free text search in a location, e.g. "5 star hotel" in "Madrid".
+type DiscoverSearchParams struct {
+	Query    string
+	Location string
+}
+
+// SchemaRepairParams carries a response that failed its JSON schema.
+type SchemaRepairParams struct {
+	SchemaName string
+	Schema     string
+	Errors     []string
+	Response   string
+}
+
+// LanguageParams names the language generated text must be written in, e.g. "Portuguese".
+type LanguageParams struct {
+	Language string
+}
+
+// TranslateParams asks for stored descriptions to be translated into Language.
+type TranslateParams struct {
+	Language string
+	Texts    []string
+}
+
+// MessageParams wraps a raw user message.
+type MessageParams struct {
+	Message string
+}
+
+// PersonalizedPOIParams describes the user for the personalised POI worker.
+type PersonalizedPOIParams struct {
+	CityName       string
+	InterestNames  []string
+	TagsPromptPart string
+	UserPrefs      string
+}
+
+// SemanticPOI is a POI found by semantic search and given to the model as context.
+type SemanticPOI struct {
+	Name        string
+	Category    string
+	Description string
+	Lat         float64
+	Lon         float64
+}
+
+// PersonalizedPOISemanticParams adds semantic search matches to PersonalizedPOIParams.
+type PersonalizedPOISemanticParams struct {
+	PersonalizedPOIParams
+	SemanticPOIs []SemanticPOI
+}
+
+// EnhancedPOIParams drives the domain-aware personalised POI prompt. Domain is one of
+// accommodation, dining, activities or itinerary; anything else asks for a balanced mix.
+type EnhancedPOIParams struct {
+	CityName    string
+	Preferences string
+	Domain      string
+}
+
+// NearbyParams is a search around the user's position.
+type NearbyParams struct {
+	Lat      float64
+	Lon      float64
+	RadiusKm float64
+}
+
+// ClassifyLabel is one of the labels a message can be classified as.
+type ClassifyLabel struct {
+	Name        string
+	Description string
+}
+
+// ClassifyExample is a labelled utterance shown to the model as a hint.
+type ClassifyExample struct {
+	Text  string
+	Label string
+}
+
+// ClassifyParams asks the model to label a message when the embedding classifier is unsure.
+type ClassifyParams struct {
+	Task     string
+	Message  string
+	Labels   []ClassifyLabel
+	Examples []ClassifyExample
+}
+
+// Chat prompts.
+var (
+	CityData              = Prompt[CityParams]{ID: "city_data"}
+	CityDescription       = Prompt[CityParams]{ID: "city_description"}
+	GeneralPOIs           = Prompt[CityParams]{ID: "general_pois"}
+	PersonalizedItinerary = Prompt[CityPreferencesParams]{ID: "personalized_itinerary"}
+	TripCity              = Prompt[TripCityParams]{ID: "trip_city"}
+	GeneralItinerary      = Prompt[CityParams]{ID: "general_itinerary"}
+	Accommodation         = Prompt[CityLocationParams]{ID: "accommodation"}
+	GeneralAccommodation  = Prompt[CityParams]{ID: "general_accommodation"}
+	Dining                = Prompt[CityLocationParams]{ID: "dining"}
+	GeneralDining         = Prompt[CityParams]{ID: "general_dining"}
+	Activities            = Prompt[CityLocationParams]{ID: "activities"}
+	GeneralActivities     = Prompt[CityParams]{ID: "general_activities"}
+	POIDetails            = Prompt[POIDetailsParams]{ID: "poi_details"}
+	ContinueConversation  = Prompt[ContinueConversationParams]{ID: "continue_conversation"}
+	DiscoverSearch        = Prompt[DiscoverSearchParams]{ID: "discover_search"}
+	SchemaRepair          = Prompt[SchemaRepairParams]{ID: "schema_repair"}
+	ExtractCity           = Prompt[MessageParams]{ID: "extract_city"}
+
+	PersonalizedPOI         = Prompt[PersonalizedPOIParams]{ID: "personalized_poi"}
+	PersonalizedPOISemantic = Prompt[PersonalizedPOISemanticParams]{ID: "personalized_poi_semantic"}
+	PersonalizedPOIEnhanced = Prompt[EnhancedPOIParams]{ID: "personalized_poi_enhanced"}
+
+	ClassifyUtterance = Prompt[ClassifyParams]{ID: "classify_utterance"}
+
+	ConversationSummary = Prompt[ConversationSummaryParams]{ID: "conversation_summary"}
+	AnswerQuestion      = Prompt[AnswerQuestionParams]{ID: "answer_question"}
+	AgentTurn           = Prompt[AgentTurnParams]{ID: "agent_turn"}
+
+	RespondInLanguage = Prompt[LanguageParams]{ID: "respond_in_language"}
+	Translate         = Prompt[TranslateParams]{ID: "translate"}
+)
+
+// Localize asks for the free text of a rendered prompt to be written in language. The id and
+// version stay those of the prompt, so llm_interactions still attribute the answer to it.
+// English, the language every template is written in, leaves the prompt unchanged.
+func Localize(r Rendered, language string) Rendered {
+	if language == "" || language == "English" {
+		return r
+	}
+	instruction := RespondInLanguage.MustRender("", LanguageParams{Language: language})
+	r.Text += "\n" + instruction.Text
+	return r
+}
+
+// Nearby and distance based prompts.
+var (
+	NearbyRestaurants = Prompt[NearbyParams]{ID: "nearby_restaurants"}
+	NearbyHotels      = Prompt[NearbyParams]{ID: "nearby_hotels"}
+	NearbyActivities  = Prompt[NearbyParams]{ID: "nearby_activities"}
+	NearbyAttractions = Prompt[NearbyParams]{ID: "nearby_attractions"}
+	POIsByDistance    = Prompt[NearbyParams]{ID: "pois_by_distance"}
+	NearbyPOIs        = Prompt[NearbyParams]{ID: "nearby_pois"}
+)
@@ -0,0 +1,291 @@
+// Package prompts holds the LLM prompt templates as named, versioned text/template files
+// embedded from templates/<id>/v<version>.tmpl, and renders them with typed parameters.
+//
+// Every rendered prompt carries its id and version so llm_interactions can record which
+// template produced a response. Traffic for one template can be split between versions
+// (see Registry.SetSplit) to compare parse success and engagement of a new wording before
+// promoting it.
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math/rand/v2"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var versionFile = regexp.MustCompile(`^v(\d+)\.tmpl$`)
+
+// Rendered is a prompt ready to be sent to a provider.
+type Rendered struct {
+	ID      string
+	Version int
+	Text    string
+}
+
+func (r Rendered) String() string {
+	return r.Text
+}
+
+// Split allocates a share of a template's traffic to one version. Weights are relative.
+type Split struct {
+	Version int
+	Weight  int
+}
+
+// Registry resolves template versions and renders them.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]map[int]*template.Template
+	splits    map[string][]Split
+}
+
+// Default is the registry built from the embedded templates. Its splits are configured at
+// startup from PROMPT_SPLITS.
+var Default = MustNewRegistry(templateFiles)
+
+// NewRegistry loads templates/<id>/v<version>.tmpl files from fsys.
+func NewRegistry(fsys fs.FS) (*Registry, error) {
+	r := &Registry{
+		templates: make(map[string]map[int]*template.Template),
+		splits:    make(map[string][]Split),
+	}
+
+	files, err := fs.Glob(fsys, "templates/*/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	for _, file := range files {
+		id := path.Base(path.Dir(file))
+		match := versionFile.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("prompt template %s must be named v<version>.tmpl", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template %s: %w", file, err)
+		}
+		// Editors add a final newline, prompts never relied on one
+		text := strings.TrimSuffix(string(data), "\n")
+		tmpl, err := template.New(fmt.Sprintf("%s@v%d", id, version)).
+			Option("missingkey=error").
+			Funcs(template.FuncMap{"join": strings.Join}).
+			Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %s: %w", file, err)
+		}
+
+		if r.templates[id] == nil {
+			r.templates[id] = make(map[int]*template.Template)
+		}
+		r.templates[id][version] = tmpl
+	}
+	return r, nil
+}
+
+// MustNewRegistry is like NewRegistry but panics on error. Use it for embedded templates only.
+func MustNewRegistry(fsys fs.FS) *Registry {
+	r, err := NewRegistry(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// IDs returns every template id, sorted.
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.templates))
+	for id := range r.templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Versions returns the versions available for a template, oldest first.
+func (r *Registry) Versions(id string) []int {
+	versions := make([]int, 0, len(r.templates[id]))
+	for v := range r.templates[id] {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Latest returns the newest version of a template, or 0 when the template does not exist.
+func (r *Registry) Latest(id string) int {
+	latest := 0
+	for v := range r.templates[id] {
+		latest = max(latest, v)
+	}
+	return latest
+}
+
+// SetSplit routes traffic for a template across versions. Passing no splits removes the
+// split, sending all traffic back to the latest version.
+func (r *Registry) SetSplit(id string, splits []Split) error {
+	if err := r.validateSplit(id, splits); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setSplitLocked(id, splits)
+	return nil
+}
+
+func (r *Registry) validateSplit(id string, splits []Split) error {
+	if _, ok := r.templates[id]; !ok {
+		return fmt.Errorf("unknown prompt template %q", id)
+	}
+	total := 0
+	for _, s := range splits {
+		if _, ok := r.templates[id][s.Version]; !ok {
+			return fmt.Errorf("prompt template %q has no version %d", id, s.Version)
+		}
+		if s.Weight < 0 {
+			return fmt.Errorf("prompt template %q version %d has a negative weight", id, s.Version)
+		}
+		total += s.Weight
+	}
+	if len(splits) > 0 && total == 0 {
+		return fmt.Errorf("prompt template %q split has no traffic", id)
+	}
+	return nil
+}
+
+func (r *Registry) setSplitLocked(id string, splits []Split) {
+	if len(splits) == 0 {
+		delete(r.splits, id)
+		return
+	}
+	r.splits[id] = append([]Split(nil), splits...)
+}
+
+// ParseSplits applies a split specification such as
+// "personalized_itinerary=1:50,2:50;dining=2:90,3:10". Templates not listed keep serving
+// their latest version. Nothing is applied when any entry is invalid.
+func (r *Registry) ParseSplits(spec string) error {
+	parsed := make(map[string][]Split)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, allocation, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid prompt split %q, expected id=version:weight,...", entry)
+		}
+		id = strings.TrimSpace(id)
+		var splits []Split
+		for _, part := range strings.Split(allocation, ",") {
+			versionStr, weightStr, ok := strings.Cut(strings.TrimSpace(part), ":")
+			if !ok {
+				return fmt.Errorf("invalid prompt split %q, expected version:weight", part)
+			}
+			version, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(versionStr), "v"))
+			if err != nil {
+				return fmt.Errorf("invalid version in prompt split %q: %w", part, err)
+			}
+			weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil {
+				return fmt.Errorf("invalid weight in prompt split %q: %w", part, err)
+			}
+			splits = append(splits, Split{Version: version, Weight: weight})
+		}
+		if err := r.validateSplit(id, splits); err != nil {
+			return err
+		}
+		parsed[id] = splits
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, splits := range parsed {
+		r.setSplitLocked(id, splits)
+	}
+	return nil
+}
+
+// Resolve picks the version served to subject. The same subject (a user or session id)
+// always lands on the same version while the split is unchanged, so one conversation never
+// mixes wordings. An empty subject is assigned at random.
+func (r *Registry) Resolve(id, subject string) int {
+	r.mu.RLock()
+	splits := r.splits[id]
+	r.mu.RUnlock()
+	if len(splits) == 0 {
+		return r.Latest(id)
+	}
+
+	total := 0
+	for _, s := range splits {
+		total += s.Weight
+	}
+	var bucket int
+	if subject == "" {
+		bucket = rand.IntN(total)
+	} else {
+		h := fnv.New32a()
+		h.Write([]byte(id + ":" + subject))
+		bucket = int(h.Sum32() % uint32(total))
+	}
+	for _, s := range splits {
+		if bucket < s.Weight {
+			return s.Version
+		}
+		bucket -= s.Weight
+	}
+	return splits[len(splits)-1].Version
+}
+
+// Render executes a specific template version.
+func (r *Registry) Render(id string, version int, params any) (Rendered, error) {
+	tmpl, ok := r.templates[id][version]
+	if !ok {
+		return Rendered{}, fmt.Errorf("prompt template %q version %d not found", id, version)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render prompt template %q version %d: %w", id, version, err)
+	}
+	return Rendered{ID: id, Version: version, Text: buf.String()}, nil
+}
+
+// Prompt is a typed handle on a template, tying its id to the parameters it expects.
+type Prompt[P any] struct {
+	ID string
+}
+
+// Render resolves the version for subject in the Default registry and renders it.
+func (p Prompt[P]) Render(subject string, params P) (Rendered, error) {
+	return p.RenderWith(Default, subject, params)
+}
+
+// RenderWith resolves the version for subject in r and renders it.
+func (p Prompt[P]) RenderWith(r *Registry, subject string, params P) (Rendered, error) {
+	return r.Render(p.ID, r.Resolve(p.ID, subject), params)
+}
+
+// MustRender is like Render but panics on error. The embedded templates are all rendered
+// with their parameter types by the package tests, so a failure here is a programming error.
+func (p Prompt[P]) MustRender(subject string, params P) Rendered {
+	rendered, err := p.Render(subject, params)
+	if err != nil {
+		panic(err)
+	}
+	return rendered
+}
@@ -0,0 +1,245 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r, err := NewRegistry(fstest.MapFS{
+		"templates/greeting/v1.tmpl": {Data: []byte("Hello {{.Name}}\n")},
+		"templates/greeting/v2.tmpl": {Data: []byte("Hi {{.Name}}, welcome to {{.City}}\n")},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRegistry_RenderLatestByDefault(t *testing.T) {
+	r := testRegistry(t)
+	assert.Equal(t, []int{1, 2}, r.Versions("greeting"))
+
+	rendered, err := r.Render("greeting", r.Resolve("greeting", "user-1"), map[string]string{"Name": "Ana", "City": "Porto"})
+	require.NoError(t, err)
+	assert.Equal(t, Rendered{ID: "greeting", Version: 2, Text: "Hi Ana, welcome to Porto"}, rendered)
+
+	_, err = r.Render("greeting", 3, nil)
+	assert.Error(t, err)
+	_, err = r.Render("greeting", 2, map[string]string{"Name": "Ana"})
+	assert.Error(t, err, "missing parameters must fail instead of rendering <no value>")
+}
+
+func TestRegistry_SplitIsStickyPerSubject(t *testing.T) {
+	r := testRegistry(t)
+	require.NoError(t, r.SetSplit("greeting", []Split{{Version: 1, Weight: 50}, {Version: 2, Weight: 50}}))
+
+	counts := map[int]int{}
+	for i := range 1000 {
+		subject := fmt.Sprintf("session-%d", i)
+		version := r.Resolve("greeting", subject)
+		assert.Equal(t, version, r.Resolve("greeting", subject))
+		counts[version]++
+	}
+	assert.InDelta(t, 500, counts[1], 75)
+	assert.InDelta(t, 500, counts[2], 75)
+
+	require.NoError(t, r.SetSplit("greeting", nil))
+	assert.Equal(t, 2, r.Resolve("greeting", "session-1"))
+}
+
+func TestRegistry_ParseSplits(t *testing.T) {
+	r := testRegistry(t)
+	require.NoError(t, r.ParseSplits(" greeting = v1:100, 2:0 ; "))
+	assert.Equal(t, 1, r.Resolve("greeting", "anyone"))
+	assert.Equal(t, 1, r.Resolve("greeting", ""))
+
+	for _, spec := range []string{
+		"greeting",
+		"greeting=1",
+		"greeting=x:10",
+		"greeting=1:ten",
+		"greeting=3:100",
+		"greeting=1:0",
+		"greeting=1:-5,2:10",
+		"unknown=1:100",
+	} {
+		assert.Error(t, r.ParseSplits(spec), spec)
+	}
+
+	// A bad entry leaves the current splits untouched
+	assert.Error(t, r.ParseSplits("greeting=2:100;unknown=1:100"))
+	assert.Equal(t, 1, r.Resolve("greeting", "anyone"))
+}
+
+func TestNewRegistry_RejectsBadFileNames(t *testing.T) {
+	_, err := NewRegistry(fstest.MapFS{"templates/greeting/latest.tmpl": {Data: []byte("Hello")}})
+	assert.Error(t, err)
+}
+
+func TestCatalog_RendersEveryTemplate(t *testing.T) {
+	personalized := PersonalizedPOIParams{
+		CityName:       "Lisbon",
+		InterestNames:  []string{"history", "food"},
+		TagsPromptPart: "tags",
+		UserPrefs:      "prefs",
+	}
+	city := CityParams{CityName: "Lisbon"}
+	location := CityLocationParams{CityName: "Lisbon", Lat: 38.7223, Lon: -9.1393, Preferences: "prefs"}
+	nearby := NearbyParams{Lat: 38.7223, Lon: -9.1393, RadiusKm: 5}
+
+	renders := map[string]func() (Rendered, error){
+		CityData.ID:             func() (Rendered, error) { return CityData.Render("", city) },
+		CityDescription.ID:      func() (Rendered, error) { return CityDescription.Render("", city) },
+		GeneralPOIs.ID:          func() (Rendered, error) { return GeneralPOIs.Render("", city) },
+		GeneralItinerary.ID:     func() (Rendered, error) { return GeneralItinerary.Render("", city) },
+		GeneralAccommodation.ID: func() (Rendered, error) { return GeneralAccommodation.Render("", city) },
+		GeneralDining.ID:        func() (Rendered, error) { return GeneralDining.Render("", city) },
+		GeneralActivities.ID:    func() (Rendered, error) { return GeneralActivities.Render("", city) },
+		Accommodation.ID:        func() (Rendered, error) { return Accommodation.Render("", location) },
+		Dining.ID:               func() (Rendered, error) { return Dining.Render("", location) },
+		Activities.ID:           func() (Rendered, error) { return Activities.Render("", location) },
+		NearbyRestaurants.ID:    func() (Rendered, error) { return NearbyRestaurants.Render("", nearby) },
+		NearbyHotels.ID:         func() (Rendered, error) { return NearbyHotels.Render("", nearby) },
+		NearbyActivities.ID:     func() (Rendered, error) { return NearbyActivities.Render("", nearby) },
+		NearbyAttractions.ID:    func() (Rendered, error) { return NearbyAttractions.Render("", nearby) },
+		POIsByDistance.ID:       func() (Rendered, error) { return POIsByDistance.Render("", nearby) },
+		NearbyPOIs.ID:           func() (Rendered, error) { return NearbyPOIs.Render("", nearby) },
+		PersonalizedPOI.ID:      func() (Rendered, error) { return PersonalizedPOI.Render("", personalized) },
+		PersonalizedItinerary.ID: func() (Rendered, error) {
+			return PersonalizedItinerary.Render("", CityPreferencesParams{CityName: "Lisbon", Preferences: "prefs"})
+		},
+		TripCity.ID: func() (Rendered, error) {
+			return TripCity.Render("", TripCityParams{
+				CityName: "Porto", Nights: 2, Position: 2, Previous: "Lisbon", Next: "Madrid", Preferences: "prefs",
+				Stops: []TripStop{{Position: 1, City: "Lisbon", Nights: 3}, {Position: 2, City: "Porto", Nights: 2}, {Position: 3, City: "Madrid", Country: "Spain", Nights: 1}},
+			})
+		},
+		POIDetails.ID: func() (Rendered, error) {
+			return POIDetails.Render("", POIDetailsParams{CityName: "Lisbon", Lat: 38.72, Lon: -9.14})
+		},
+		ContinueConversation.ID: func() (Rendered, error) {
+			return ContinueConversation.Render("", ContinueConversationParams{POIName: "Belem Tower", CityName: "Lisbon", Conversation: "Trip city: Lisbon"})
+		},
+		ConversationSummary.ID: func() (Rendered, error) {
+			return ConversationSummary.Render("", ConversationSummaryParams{
+				CityName:        "Lisbon",
+				PreviousSummary: "Trip plan for Lisbon",
+				Messages:        []ConversationTurn{{Role: "user", Content: "No museums"}, {Role: "assistant", Content: "Removed"}},
+				MaxWords:        150,
+			})
+		},
+		AnswerQuestion.ID: func() (Rendered, error) {
+			return AnswerQuestion.Render("", AnswerQuestionParams{Question: "Is it far?", Conversation: "Trip city: Lisbon"})
+		},
+		AgentTurn.ID: func() (Rendered, error) {
+			return AgentTurn.Render("", AgentTurnParams{
+				Question:     "Any vegan places near me?",
+				Conversation: "Trip city: Lisbon",
+				Tools:        []AgentTool{{Name: "pois_near", Description: "POIs around a point", Schema: `{"type":"object"}`}},
+				Steps:        []AgentStep{{Tool: "pois_near", Arguments: `{"radius_km":1}`, Result: `[]`}},
+			})
+		},
+		DiscoverSearch.ID: func() (Rendered, error) {
+			return DiscoverSearch.Render("", DiscoverSearchParams{Query: "romantic restaurants", Location: "Paris"})
+		},
+		SchemaRepair.ID: func() (Rendered, error) {
+			return SchemaRepair.Render("", SchemaRepairParams{SchemaName: "hotels", Schema: "{}", Errors: []string{"a", "b"}, Response: "{}"})
+		},
+		ExtractCity.ID: func() (Rendered, error) {
+			return ExtractCity.Render("", MessageParams{Message: "Find restaurants in Barcelona"})
+		},
+		PersonalizedPOISemantic.ID: func() (Rendered, error) {
+			return PersonalizedPOISemantic.Render("", PersonalizedPOISemanticParams{
+				PersonalizedPOIParams: personalized,
+				SemanticPOIs:          []SemanticPOI{{Name: "Belem Tower", Category: "Monument", Description: "Fortress", Lat: 38.6916, Lon: -9.216}},
+			})
+		},
+		PersonalizedPOIEnhanced.ID: func() (Rendered, error) {
+			return PersonalizedPOIEnhanced.Render("", EnhancedPOIParams{CityName: "Lisbon", Preferences: "prefs", Domain: "dining"})
+		},
+		RespondInLanguage.ID: func() (Rendered, error) {
+			return RespondInLanguage.Render("", LanguageParams{Language: "Portuguese"})
+		},
+		Translate.ID: func() (Rendered, error) {
+			return Translate.Render("", TranslateParams{Language: "Portuguese", Texts: []string{"A fortress", "A monastery"}})
+		},
+		ClassifyUtterance.ID: func() (Rendered, error) {
+			return ClassifyUtterance.Render("", ClassifyParams{
+				Task:     "intent",
+				Message:  "I don't want to visit museums",
+				Labels:   []ClassifyLabel{{Name: "add_poi", Description: "add a place"}, {Name: "remove_poi", Description: "remove a place"}},
+				Examples: []ClassifyExample{{Text: "skip the cathedral", Label: "remove_poi"}},
+			})
+		},
+	}
+
+	assert.ElementsMatch(t, Default.IDs(), mapKeys(renders), "every embedded template needs a typed handle in the catalog")
+	for id, render := range renders {
+		t.Run(id, func(t *testing.T) {
+			rendered, err := render()
+			require.NoError(t, err)
+			assert.Equal(t, id, rendered.ID)
+			assert.Equal(t, Default.Latest(id), rendered.Version)
+			assert.NotContains(t, rendered.Text, "<no value>")
+			assert.NotContains(t, rendered.Text, "%!")
+		})
+	}
+}
+
+func TestCatalog_OptionalSections(t *testing.T) {
+	base := PersonalizedPOIParams{CityName: "Lisbon", InterestNames: []string{"history", "food"}}
+
+	plain := PersonalizedPOI.MustRender("", base)
+	assert.Contains(t, plain.Text, "tailored to user interests [history, food]")
+	assert.NotContains(t, plain.Text, "prefs")
+
+	with := base
+	with.UserPrefs = "prefs"
+	assert.Equal(t, plain.Text+"\nprefs", PersonalizedPOI.MustRender("", with).Text)
+
+	semantic := PersonalizedPOISemantic.MustRender("", PersonalizedPOISemanticParams{PersonalizedPOIParams: base})
+	assert.NotContains(t, semantic.Text, "Contextually Relevant POIs")
+
+	repair := SchemaRepair.MustRender("", SchemaRepairParams{SchemaName: "hotels", Errors: []string{"/a: bad", "/b: worse"}})
+	assert.Contains(t, repair.Text, "VALIDATION ERRORS:\n- /a: bad\n- /b: worse\n")
+
+	v1, err := Default.Render(ContinueConversation.ID, 1, ContinueConversationParams{POIName: "Belem Tower", CityName: "Lisbon"})
+	require.NoError(t, err)
+	v2, err := Default.Render(ContinueConversation.ID, 2, ContinueConversationParams{POIName: "Belem Tower", CityName: "Lisbon"})
+	require.NoError(t, err)
+	assert.Equal(t, v1.Text, v2.Text, "without conversation context v2 is the v1 prompt")
+	withContext := ContinueConversation.MustRender("", ContinueConversationParams{POIName: "Belem Tower", CityName: "Lisbon", Conversation: "The user does not want: museums"})
+	assert.Contains(t, withContext.Text, "do not suggest anything they rejected:\nThe user does not want: museums\n")
+
+	enhanced := PersonalizedPOIEnhanced.MustRender("", EnhancedPOIParams{CityName: "Lisbon", Domain: "transport"})
+	assert.Contains(t, enhanced.Text, "Domain Focus: Provide a balanced mix")
+}
+
+func TestLocalize(t *testing.T) {
+	base := CityData.MustRender("", CityParams{CityName: "Lisbon"})
+	assert.Equal(t, base, Localize(base, "English"))
+	assert.Equal(t, base, Localize(base, ""))
+
+	localized := Localize(base, "Portuguese")
+	assert.Equal(t, base.ID, localized.ID)
+	assert.Equal(t, base.Version, localized.Version)
+	assert.True(t, strings.HasPrefix(localized.Text, base.Text))
+	assert.Contains(t, localized.Text, "free text in Portuguese")
+
+	translate := Translate.MustRender("", TranslateParams{Language: "German", Texts: []string{"A fortress", "A monastery"}})
+	assert.Contains(t, translate.Text, "0: A fortress\n1: A monastery\n")
+	assert.Contains(t, translate.Text, "JSON array of 2 strings")
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,77 @@
+package routing
+
+import (
+	"context"
+	"math"
+)
+
+// Profile turns a straight-line distance into a trip for one mode.
+type Profile struct {
+	SpeedKmh float64 // average speed along the way
+	Detour   float64 // how much longer the way is than the straight line
+	Overhead float64 // minutes added to every trip: waiting for transport, parking
+	// WalkUnderKm trips shorter than this are walked, e.g. to the next stop
+	WalkUnderKm float64
+}
+
+// DefaultProfiles are city averages.
+var DefaultProfiles = map[Mode]Profile{
+	ModeWalk:   {SpeedKmh: 4.8, Detour: 1.3},
+	ModePublic: {SpeedKmh: 20, Detour: 1.3, Overhead: 6, WalkUnderKm: 1},
+	ModeCar:    {SpeedKmh: 25, Detour: 1.4, Overhead: 5, WalkUnderKm: 0.5},
+}
+
+// Haversine estimates trips from straight-line distances. It needs no network and knows
+// every mode in its profiles.
+type Haversine struct {
+	profiles map[Mode]Profile
+}
+
+func NewHaversine() *Haversine {
+	return &Haversine{profiles: DefaultProfiles}
+}
+
+// NewHaversineWithProfiles uses the given profiles instead of the defaults.
+func NewHaversineWithProfiles(profiles map[Mode]Profile) *Haversine {
+	return &Haversine{profiles: profiles}
+}
+
+func (h *Haversine) Name() string {
+	return "haversine"
+}
+
+func (h *Haversine) Matrix(_ context.Context, points []Point, mode Mode) ([][]Leg, error) {
+	if _, ok := h.profiles[mode]; !ok {
+		return nil, ErrUnsupportedMode
+	}
+	m := make([][]Leg, len(points))
+	for i := range points {
+		m[i] = make([]Leg, len(points))
+		for j := range points {
+			if i != j {
+				m[i][j] = h.leg(points[i], points[j], mode)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (h *Haversine) leg(from, to Point, mode Mode) Leg {
+	p := h.profiles[mode]
+	km := DistanceKm(from, to)
+	if walk, ok := h.profiles[ModeWalk]; ok && km < p.WalkUnderKm {
+		p = walk
+	}
+	km *= p.Detour
+	return Leg{DistanceKm: km, Minutes: km/p.SpeedKmh*60 + p.Overhead}
+}
+
+// DistanceKm is the great-circle distance between two points.
+func DistanceKm(from, to Point) float64 {
+	const earthRadiusKm = 6371
+	lat1, lat2 := from.Latitude*math.Pi/180, to.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (to.Longitude - from.Longitude) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,124 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Provider = (*OSRM)(nil)
+
+// osrmProfiles are the OSRM profiles of the modes it routes. OSRM has no public transport.
+var osrmProfiles = map[Mode]string{
+	ModeWalk: "foot",
+	ModeCar:  "driving",
+}
+
+// OSRM asks an OSRM-compatible server, such as a local osrm-routed, for its table service.
+type OSRM struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOSRM creates a provider for the server at baseURL, e.g. http://localhost:5000.
+func NewOSRM(baseURL string, timeout time.Duration) *OSRM {
+	return &OSRM{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (o *OSRM) Name() string {
+	return "osrm"
+}
+
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Durations [][]*float64 `json:"durations"` // seconds, null when there is no route
+	Distances [][]*float64 `json:"distances"` // meters
+}
+
+func (o *OSRM) Matrix(ctx context.Context, points []Point, mode Mode) ([][]Leg, error) {
+	profile, ok := osrmProfiles[mode]
+	if !ok {
+		return nil, ErrUnsupportedMode
+	}
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = strconv.FormatFloat(p.Longitude, 'f', 6, 64) + "," + strconv.FormatFloat(p.Latitude, 'f', 6, 64)
+	}
+	url := fmt.Sprintf("%s/table/v1/%s/%s?annotations=duration,distance", o.baseURL, profile, strings.Join(coords, ";"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create osrm request: %w", err)
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("osrm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read osrm response: %w", err)
+	}
+	var table osrmTable
+	if err := json.Unmarshal(body, &table); err != nil {
+		return nil, fmt.Errorf("failed to decode osrm response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || table.Code != "Ok" {
+		return nil, fmt.Errorf("osrm table failed with status %d: %s %s", resp.StatusCode, table.Code, table.Message)
+	}
+	if len(table.Durations) != len(points) || len(table.Distances) != len(points) {
+		return nil, fmt.Errorf("osrm table has %d rows for %d points", len(table.Durations), len(points))
+	}
+
+	m := make([][]Leg, len(points))
+	for i := range points {
+		if len(table.Durations[i]) != len(points) || len(table.Distances[i]) != len(points) {
+			return nil, fmt.Errorf("osrm table row %d is incomplete", i)
+		}
+		m[i] = make([]Leg, len(points))
+		for j := range points {
+			d, km := table.Durations[i][j], table.Distances[i][j]
+			if d == nil || km == nil {
+				return nil, fmt.Errorf("osrm has no route from point %d to point %d", i, j)
+			}
+			m[i][j] = Leg{DistanceKm: *km / 1000, Minutes: *d / 60}
+		}
+	}
+	return m, nil
+}
+
+// fallback answers from its secondary provider whenever the primary fails.
+type fallback struct {
+	primary, secondary Provider
+}
+
+// WithFallback returns a provider that asks primary first and secondary when primary fails,
+// including for the modes primary does not route.
+func WithFallback(primary, secondary Provider) Provider {
+	return &fallback{primary: primary, secondary: secondary}
+}
+
+func (f *fallback) Name() string {
+	return f.primary.Name() + "+" + f.secondary.Name()
+}
+
+func (f *fallback) Matrix(ctx context.Context, points []Point, mode Mode) ([][]Leg, error) {
+	m, err := f.primary.Matrix(ctx, points, mode)
+	if err == nil {
+		return m, nil
+	}
+	m, fallbackErr := f.secondary.Matrix(ctx, points, mode)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%s: %w; %s: %w", f.primary.Name(), err, f.secondary.Name(), fallbackErr)
+	}
+	return m, nil
+}
@@ -0,0 +1,172 @@
+// Package routing estimates how long it takes to get between places and orders places to
+// keep travel short. Providers are pluggable: Haversine works offline from straight-line
+// distances and speed profiles, OSRM asks an OSRM-compatible server for real road and path
+// networks, and WithFallback keeps answering when the server does not.
+package routing
+
+import (
+	"context"
+	"errors"
+)
+
+// Mode is a way of getting around town.
+type Mode string
+
+const (
+	ModeWalk   Mode = "walk"
+	ModePublic Mode = "public"
+	ModeCar    Mode = "car"
+)
+
+// ModeFor reads a preferred means of transport; anything but public transport and car,
+// including no preference, is walked.
+func ModeFor(transport string) Mode {
+	switch m := Mode(transport); m {
+	case ModePublic, ModeCar:
+		return m
+	default:
+		return ModeWalk
+	}
+}
+
+// ErrUnsupportedMode is returned by providers that cannot route a mode.
+var ErrUnsupportedMode = errors.New("routing mode not supported")
+
+// Point is a place to route between.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Leg is the trip between two points.
+type Leg struct {
+	DistanceKm float64
+	Minutes    float64
+}
+
+// Provider estimates the trips between points.
+type Provider interface {
+	Name() string
+	// Matrix returns the leg from every point to every other one: m[i][j] goes from
+	// points[i] to points[j].
+	Matrix(ctx context.Context, points []Point, mode Mode) ([][]Leg, error)
+}
+
+// Route orders points to minimise travel time and returns the order, as indexes into points,
+// and the legs between consecutive points of that order.
+func Route(ctx context.Context, provider Provider, points []Point, mode Mode) ([]int, []Leg, error) {
+	if len(points) < 2 {
+		order := make([]int, len(points))
+		for i := range order {
+			order[i] = i
+		}
+		return order, nil, nil
+	}
+	m, err := provider.Matrix(ctx, points, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	order := Optimise(m)
+	return order, LegsOf(m, order), nil
+}
+
+// Legs returns the legs between consecutive points, in the order given.
+func Legs(ctx context.Context, provider Provider, points []Point, mode Mode) ([]Leg, error) {
+	if len(points) < 2 {
+		return nil, nil
+	}
+	m, err := provider.Matrix(ctx, points, mode)
+	if err != nil {
+		return nil, err
+	}
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	return LegsOf(m, order), nil
+}
+
+// LegsOf picks the legs of a path out of a matrix.
+func LegsOf(m [][]Leg, order []int) []Leg {
+	if len(order) < 2 {
+		return nil
+	}
+	legs := make([]Leg, len(order)-1)
+	for i := 1; i < len(order); i++ {
+		legs[i-1] = m[order[i-1]][order[i]]
+	}
+	return legs
+}
+
+// Optimise finds a short open path through every point of a matrix, by travel time. It
+// starts a nearest-neighbour path from each point, keeps the shortest and improves it with
+// 2-opt, which is plenty for the dozen or so places of an itinerary day.
+func Optimise(m [][]Leg) []int {
+	n := len(m)
+	if n == 0 {
+		return nil
+	}
+	var best []int
+	bestCost := 0.0
+	for start := 0; start < n; start++ {
+		path := nearestNeighbour(m, start)
+		twoOpt(m, path)
+		if c := cost(m, path); best == nil || c < bestCost {
+			best, bestCost = path, c
+		}
+	}
+	return best
+}
+
+func nearestNeighbour(m [][]Leg, start int) []int {
+	n := len(m)
+	visited := make([]bool, n)
+	path := make([]int, 0, n)
+	current := start
+	for {
+		visited[current] = true
+		path = append(path, current)
+		if len(path) == n {
+			return path
+		}
+		next := -1
+		for j := 0; j < n; j++ {
+			if !visited[j] && (next < 0 || m[current][j].Minutes < m[current][next].Minutes) {
+				next = j
+			}
+		}
+		current = next
+	}
+}
+
+// twoOpt reverses segments of the path while that makes it shorter.
+func twoOpt(m [][]Leg, path []int) {
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(path)-1; i++ {
+			for j := i + 1; j < len(path); j++ {
+				before := cost(m, path)
+				reverse(path[i : j+1])
+				if cost(m, path) < before-1e-9 {
+					improved = true
+				} else {
+					reverse(path[i : j+1])
+				}
+			}
+		}
+	}
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+func cost(m [][]Leg, path []int) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		total += m[path[i-1]][path[i]].Minutes
+	}
+	return total
+}
@@ -0,0 +1,126 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Places of Lisbon, west to east
+var (
+	belem     = Point{Latitude: 38.6916, Longitude: -9.2160}
+	alcantara = Point{Latitude: 38.7050, Longitude: -9.1760}
+	baixa     = Point{Latitude: 38.7100, Longitude: -9.1370}
+	alfama    = Point{Latitude: 38.7120, Longitude: -9.1300}
+	oriente   = Point{Latitude: 38.7680, Longitude: -9.0990}
+)
+
+func TestHaversine_Profiles(t *testing.T) {
+	h := NewHaversine()
+	ctx := context.Background()
+
+	walk, err := h.Matrix(ctx, []Point{baixa, belem}, ModeWalk)
+	require.NoError(t, err)
+	car, err := h.Matrix(ctx, []Point{baixa, belem}, ModeCar)
+	require.NoError(t, err)
+
+	assert.Zero(t, walk[0][0])
+	assert.InDelta(t, 9.3, walk[0][1].DistanceKm, 0.5)
+	assert.Greater(t, walk[0][1].Minutes, 100.0)
+	assert.Less(t, car[0][1].Minutes, walk[0][1].Minutes)
+
+	// Short hops are walked whatever the mode
+	public, err := h.Matrix(ctx, []Point{baixa, alfama}, ModePublic)
+	require.NoError(t, err)
+	short, _ := h.Matrix(ctx, []Point{baixa, alfama}, ModeWalk)
+	assert.Equal(t, short[0][1], public[0][1])
+
+	_, err = h.Matrix(ctx, []Point{baixa, alfama}, Mode("boat"))
+	assert.ErrorIs(t, err, ErrUnsupportedMode)
+}
+
+func TestModeFor(t *testing.T) {
+	assert.Equal(t, ModeWalk, ModeFor("any"))
+	assert.Equal(t, ModeWalk, ModeFor(""))
+	assert.Equal(t, ModeWalk, ModeFor("walk"))
+	assert.Equal(t, ModePublic, ModeFor("public"))
+	assert.Equal(t, ModeCar, ModeFor("car"))
+}
+
+func TestRoute_RemovesZigZag(t *testing.T) {
+	points := []Point{baixa, oriente, belem, alfama, alcantara}
+
+	order, legs, err := Route(context.Background(), NewHaversine(), points, ModeWalk)
+	require.NoError(t, err)
+	require.Len(t, order, len(points))
+	require.Len(t, legs, len(points)-1)
+
+	// The straight line from one end of town to the other, in either direction
+	want := []int{2, 4, 0, 3, 1}
+	if order[0] != want[0] {
+		want = []int{1, 3, 0, 4, 2}
+	}
+	assert.Equal(t, want, order)
+
+	zigzag, err := Legs(context.Background(), NewHaversine(), points, ModeWalk)
+	require.NoError(t, err)
+	assert.Less(t, total(legs), total(zigzag))
+}
+
+func TestRoute_FewPoints(t *testing.T) {
+	order, legs, err := Route(context.Background(), NewHaversine(), []Point{baixa}, ModeWalk)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0}, order)
+	assert.Empty(t, legs)
+}
+
+func TestOSRM_Table(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"code":"Ok","durations":[[0,600],[660,0]],"distances":[[0,1200],[1300,0]]}`))
+	}))
+	defer server.Close()
+
+	m, err := NewOSRM(server.URL, time.Second).Matrix(context.Background(), []Point{baixa, alfama}, ModeWalk)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, "/table/v1/foot/-9.137000,38.710000;"), path)
+	assert.Equal(t, Leg{DistanceKm: 1.2, Minutes: 10}, m[0][1])
+	assert.Equal(t, Leg{DistanceKm: 1.3, Minutes: 11}, m[1][0])
+}
+
+func TestOSRM_FallsBack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"InvalidQuery","message":"Query string malformed"}`))
+	}))
+	defer server.Close()
+
+	osrm := NewOSRM(server.URL, time.Second)
+	_, err := osrm.Matrix(context.Background(), []Point{baixa, alfama}, ModeWalk)
+	require.Error(t, err)
+	_, err = osrm.Matrix(context.Background(), []Point{baixa, alfama}, ModePublic)
+	assert.True(t, errors.Is(err, ErrUnsupportedMode))
+
+	provider := WithFallback(osrm, NewHaversine())
+	assert.Equal(t, "osrm+haversine", provider.Name())
+	m, err := provider.Matrix(context.Background(), []Point{baixa, alfama}, ModePublic)
+	require.NoError(t, err)
+	assert.Greater(t, m[0][1].Minutes, 0.0)
+}
+
+func total(legs []Leg) float64 {
+	sum := 0.0
+	for _, l := range legs {
+		sum += l.Minutes
+	}
+	return sum
+}
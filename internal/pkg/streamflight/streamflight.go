@@ -0,0 +1,138 @@
+// Package streamflight coalesces identical streaming generations, in the spirit of
+// golang.org/x/sync/singleflight but for streams.
+//
+// The first caller for a key starts the generation. Callers arriving while it runs attach
+// to it: they receive every item produced so far, then follow the live stream. All of them
+// see the same items in the same order and the same final error.
+//
+// The generation runs detached from the context of the caller that started it, so that
+// caller leaving does not cut the stream for the others. It is cancelled once every caller
+// has left.
+package streamflight
+
+import (
+	"context"
+	"sync"
+)
+
+// Group holds the generations in flight, keyed by what makes two generations identical.
+type Group[T any] struct {
+	mu      sync.Mutex
+	flights map[string]*flight[T]
+}
+
+type flight[T any] struct {
+	mu          sync.Mutex
+	items       []T
+	updated     chan struct{} // closed and replaced whenever items grow or the flight ends
+	done        bool
+	err         error
+	subscribers int
+	abandoned   bool // every caller left and the generation was cancelled
+	cancel      context.CancelFunc
+}
+
+// Do streams the generation for key to onItem, starting it with fn unless an identical one
+// is already in flight. fn publishes items through publish and runs at most once per flight.
+// Do returns once the generation has ended, with its error, or once ctx is done, with
+// ctx.Err(). shared reports whether the caller attached to a generation started by another.
+func (g *Group[T]) Do(ctx context.Context, key string, fn func(ctx context.Context, publish func(T)) error, onItem func(T)) (shared bool, err error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight[T])
+	}
+	f, shared := g.flights[key]
+	if shared {
+		f.mu.Lock()
+		if f.abandoned {
+			shared = false
+		} else {
+			f.subscribers++
+		}
+		f.mu.Unlock()
+	}
+	if !shared {
+		genCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight[T]{updated: make(chan struct{}), subscribers: 1, cancel: cancel}
+		g.flights[key] = f
+		go g.run(genCtx, key, f, fn)
+	}
+	g.mu.Unlock()
+
+	return shared, f.follow(ctx, onItem)
+}
+
+// InFlight reports how many generations are running.
+func (g *Group[T]) InFlight() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.flights)
+}
+
+func (g *Group[T]) run(ctx context.Context, key string, f *flight[T], fn func(ctx context.Context, publish func(T)) error) {
+	err := fn(ctx, f.publish)
+
+	// Callers arriving from now on start a new generation
+	g.mu.Lock()
+	if g.flights[key] == f {
+		delete(g.flights, key)
+	}
+	g.mu.Unlock()
+
+	f.mu.Lock()
+	f.done = true
+	f.err = err
+	close(f.updated)
+	f.mu.Unlock()
+	f.cancel()
+}
+
+func (f *flight[T]) publish(item T) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.done {
+		return
+	}
+	f.items = append(f.items, item)
+	close(f.updated)
+	f.updated = make(chan struct{})
+}
+
+// follow replays the items produced so far to onItem, then the live ones, until the flight
+// ends or ctx is done.
+func (f *flight[T]) follow(ctx context.Context, onItem func(T)) error {
+	next := 0
+	for {
+		f.mu.Lock()
+		pending := f.items[next:]
+		next = len(f.items)
+		done, err, updated := f.done, f.err, f.updated
+		f.mu.Unlock()
+
+		for _, item := range pending {
+			onItem(item)
+		}
+		if done {
+			return err
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			f.leave()
+			return ctx.Err()
+		}
+	}
+}
+
+// leave drops a caller, cancelling the generation when nobody is left to receive it.
+func (f *flight[T]) leave() {
+	f.mu.Lock()
+	f.subscribers--
+	f.abandoned = f.subscribers == 0 && !f.done
+	abandoned := f.abandoned
+	f.mu.Unlock()
+	if abandoned {
+		f.cancel()
+	}
+}
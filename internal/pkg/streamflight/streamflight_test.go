@@ -0,0 +1,196 @@
+package streamflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector gathers the items a caller receives.
+type collector struct {
+	mu    sync.Mutex
+	items []string
+}
+
+func (c *collector) add(s string) {
+	c.mu.Lock()
+	c.items = append(c.items, s)
+	c.mu.Unlock()
+}
+
+func (c *collector) get() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.items...)
+}
+
+func TestDo_LateCallerReplaysThenFollows(t *testing.T) {
+	var g Group[string]
+	firstChunk := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	gen := func(ctx context.Context, publish func(string)) error {
+		calls++
+		publish("a")
+		close(firstChunk)
+		<-release
+		publish("b")
+		publish("c")
+		return nil
+	}
+
+	var leader, follower collector
+	var wg sync.WaitGroup
+	var leaderShared, followerShared bool
+	wg.Go(func() {
+		leaderShared, _ = g.Do(context.Background(), "key", gen, leader.add)
+	})
+	<-firstChunk
+
+	followerJoined := make(chan struct{})
+	wg.Go(func() {
+		close(followerJoined)
+		var err error
+		followerShared, err = g.Do(context.Background(), "key", func(context.Context, func(string)) error {
+			t.Error("an identical generation must not start twice")
+			return nil
+		}, follower.add)
+		assert.NoError(t, err)
+	})
+	<-followerJoined
+	require.Eventually(t, func() bool { return len(follower.get()) == 1 }, time.Second, time.Millisecond, "the follower replays what was already produced")
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls)
+	assert.False(t, leaderShared)
+	assert.True(t, followerShared)
+	assert.Equal(t, []string{"a", "b", "c"}, leader.get())
+	assert.Equal(t, []string{"a", "b", "c"}, follower.get())
+	assert.Equal(t, 0, g.InFlight())
+}
+
+func TestDo_SharesError(t *testing.T) {
+	var g Group[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	boom := errors.New("model unavailable")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	wg.Go(func() {
+		_, errs[0] = g.Do(context.Background(), "key", func(ctx context.Context, publish func(string)) error {
+			close(started)
+			<-release
+			return boom
+		}, func(string) {})
+	})
+	<-started
+	wg.Go(func() {
+		_, errs[1] = g.Do(context.Background(), "key", nil, func(string) {})
+	})
+	require.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		f := g.flights["key"]
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.subscribers == 2
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.ErrorIs(t, errs[0], boom)
+	assert.ErrorIs(t, errs[1], boom)
+}
+
+func TestDo_LeaderLeavingKeepsStreamForOthers(t *testing.T) {
+	var g Group[string]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+
+	var genErr error
+	genDone := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		_, err := g.Do(leaderCtx, "key", func(ctx context.Context, publish func(string)) error {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+				genErr = ctx.Err()
+			}
+			close(genDone)
+			publish("done")
+			return nil
+		}, func(string) {})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+	<-started
+
+	var follower collector
+	wg.Go(func() {
+		_, err := g.Do(context.Background(), "key", nil, follower.add)
+		assert.NoError(t, err)
+	})
+	require.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		f := g.flights["key"]
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.subscribers == 2
+	}, time.Second, time.Millisecond)
+
+	cancelLeader()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	<-genDone
+
+	assert.NoError(t, genErr, "the generation outlives the caller that started it")
+	assert.Equal(t, []string{"done"}, follower.get())
+}
+
+func TestDo_CancelledWhenEveryoneLeaves(t *testing.T) {
+	var g Group[string]
+	ctx, cancel := context.WithCancel(context.Background())
+	genCancelled := make(chan struct{})
+
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := g.Do(ctx, "key", func(genCtx context.Context, publish func(string)) error {
+		close(started)
+		<-genCtx.Done()
+		close(genCancelled)
+		return genCtx.Err()
+	}, func(string) {})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	select {
+	case <-genCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the generation kept running with nobody following it")
+	}
+
+	// A new caller for the same key starts afresh
+	require.Eventually(t, func() bool { return g.InFlight() == 0 }, time.Second, time.Millisecond)
+	var c collector
+	shared, err := g.Do(context.Background(), "key", func(ctx context.Context, publish func(string)) error {
+		publish("fresh")
+		return nil
+	}, c.add)
+	require.NoError(t, err)
+	assert.False(t, shared)
+	assert.Equal(t, []string{"fresh"}, c.get())
+}
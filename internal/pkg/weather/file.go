@@ -0,0 +1,79 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Provider = (*File)(nil)
+
+// File forecasts from a JSON file, the same weather everywhere:
+//
+//	{"forecasts": [{"date": "2025-06-02", "condition": "rain", "summary": "Showers",
+//	  "temp_min_c": 12, "temp_max_c": 17, "precipitation_mm": 8, "precipitation_chance": 90}]}
+//
+// Dates the file does not list have no forecast.
+type File struct {
+	byDate map[time.Time]models.Forecast
+}
+
+type fileForecast struct {
+	Date                string  `json:"date"` // YYYY-MM-DD
+	Condition           string  `json:"condition"`
+	Summary             string  `json:"summary"`
+	TempMinC            float64 `json:"temp_min_c"`
+	TempMaxC            float64 `json:"temp_max_c"`
+	PrecipitationMm     float64 `json:"precipitation_mm"`
+	PrecipitationChance int     `json:"precipitation_chance"`
+}
+
+// NewFile reads the forecasts of the file at path.
+func NewFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forecasts: %w", err)
+	}
+	var body struct {
+		Forecasts []fileForecast `json:"forecasts"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode forecasts in %s: %w", path, err)
+	}
+	f := &File{byDate: make(map[time.Time]models.Forecast, len(body.Forecasts))}
+	for _, ff := range body.Forecasts {
+		date, err := time.Parse(time.DateOnly, ff.Date)
+		if err != nil {
+			return nil, fmt.Errorf("forecast date %q in %s is not YYYY-MM-DD", ff.Date, path)
+		}
+		forecast := models.Forecast{
+			Date:                date,
+			Condition:           ff.Condition,
+			Summary:             ff.Summary,
+			TempMinC:            ff.TempMinC,
+			TempMaxC:            ff.TempMaxC,
+			PrecipitationMm:     ff.PrecipitationMm,
+			PrecipitationChance: ff.PrecipitationChance,
+		}
+		forecast.Wet = wet(forecast)
+		f.byDate[date] = forecast
+	}
+	return f, nil
+}
+
+func (f *File) Name() string { return "file" }
+
+func (f *File) Daily(_ context.Context, _, _ float64, from time.Time, days int) ([]models.Forecast, error) {
+	var forecasts []models.Forecast
+	start := midnight(from)
+	for i := 0; i < days; i++ {
+		if forecast, ok := f.byDate[start.AddDate(0, 0, i)]; ok {
+			forecasts = append(forecasts, forecast)
+		}
+	}
+	return forecasts, nil
+}
@@ -0,0 +1,147 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+var _ Provider = (*OpenMeteo)(nil)
+
+// openMeteoDays is how far ahead Open-Meteo forecasts, today included.
+const openMeteoDays = 16
+
+// OpenMeteo asks an Open-Meteo-compatible service for daily forecasts.
+type OpenMeteo struct {
+	endpoint   string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewOpenMeteo creates a provider for the forecast endpoint at endpoint, e.g.
+// https://api.open-meteo.com/v1/forecast.
+func NewOpenMeteo(endpoint string, timeout time.Duration) *OpenMeteo {
+	return &OpenMeteo{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+	}
+}
+
+func (o *OpenMeteo) Name() string {
+	return "open-meteo"
+}
+
+type openMeteoDaily struct {
+	Daily struct {
+		Time        []string   `json:"time"`
+		WeatherCode []*int     `json:"weather_code"`
+		TempMax     []*float64 `json:"temperature_2m_max"`
+		TempMin     []*float64 `json:"temperature_2m_min"`
+		Rain        []*float64 `json:"precipitation_sum"`
+		RainChance  []*float64 `json:"precipitation_probability_max"`
+	} `json:"daily"`
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+func (o *OpenMeteo) Daily(ctx context.Context, latitude, longitude float64, from time.Time, days int) ([]models.Forecast, error) {
+	// Only the days the service forecasts are asked for
+	today := midnight(o.now())
+	start, end := midnight(from), midnight(from).AddDate(0, 0, days-1)
+	if start.Before(today) {
+		start = today
+	}
+	if last := today.AddDate(0, 0, openMeteoDays-1); end.After(last) {
+		end = last
+	}
+	if end.Before(start) {
+		return nil, nil
+	}
+
+	query := url.Values{
+		"latitude":   {strconv.FormatFloat(latitude, 'f', 4, 64)},
+		"longitude":  {strconv.FormatFloat(longitude, 'f', 4, 64)},
+		"daily":      {"weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max"},
+		"timezone":   {"UTC"},
+		"start_date": {start.Format(time.DateOnly)},
+		"end_date":   {end.Format(time.DateOnly)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create forecast request: %w", err)
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("forecast request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forecast response: %w", err)
+	}
+	var daily openMeteoDaily
+	if err := json.Unmarshal(body, &daily); err != nil {
+		return nil, fmt.Errorf("failed to decode forecast response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || daily.Error {
+		return nil, fmt.Errorf("forecast service answered %d: %s", resp.StatusCode, daily.Reason)
+	}
+
+	d := daily.Daily
+	forecasts := make([]models.Forecast, 0, len(d.Time))
+	for i, day := range d.Time {
+		date, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, fmt.Errorf("forecast date %q is not YYYY-MM-DD", day)
+		}
+		forecast := models.Forecast{
+			Date:                date,
+			TempMinC:            valueAt(d.TempMin, i),
+			TempMaxC:            valueAt(d.TempMax, i),
+			PrecipitationMm:     valueAt(d.Rain, i),
+			PrecipitationChance: int(valueAt(d.RainChance, i)),
+		}
+		if i < len(d.WeatherCode) && d.WeatherCode[i] != nil {
+			forecast.Condition, forecast.Summary = condition(*d.WeatherCode[i])
+		}
+		forecast.Wet = wet(forecast)
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts, nil
+}
+
+func valueAt(values []*float64, i int) float64 {
+	if i < len(values) && values[i] != nil {
+		return *values[i]
+	}
+	return 0
+}
+
+// condition reads a WMO weather interpretation code.
+func condition(code int) (string, string) {
+	switch {
+	case code == 0:
+		return models.ConditionClear, "Clear sky"
+	case code <= 3:
+		return models.ConditionCloudy, "Partly cloudy"
+	case code == 45 || code == 48:
+		return models.ConditionFog, "Fog"
+	case code >= 51 && code <= 57:
+		return models.ConditionRain, "Drizzle"
+	case code >= 61 && code <= 67, code >= 80 && code <= 82:
+		return models.ConditionRain, "Rain"
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return models.ConditionSnow, "Snow"
+	case code >= 95:
+		return models.ConditionStorm, "Thunderstorm"
+	}
+	return models.ConditionCloudy, ""
+}
@@ -0,0 +1,79 @@
+// Package weather forecasts the days of an itinerary and tells the places that are visited
+// outdoors from those visited indoors, so outdoor stops can be kept off wet days. Providers
+// are pluggable: OpenMeteo asks an Open-Meteo-compatible service and File reads forecasts from
+// a JSON file, for tests and demos.
+package weather
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+// Provider forecasts the weather at a place.
+type Provider interface {
+	Name() string
+	// Daily returns the forecasts it has for the days from from, at midnight UTC, onwards.
+	// Days beyond what the provider forecasts are left out.
+	Daily(ctx context.Context, latitude, longitude float64, from time.Time, days int) ([]models.Forecast, error)
+}
+
+// wet tells whether a forecast is bad enough to move outdoor stops: storms, and rain or snow
+// that is likely or whose chance is unknown.
+func wet(f models.Forecast) bool {
+	switch f.Condition {
+	case models.ConditionStorm:
+		return true
+	case models.ConditionRain, models.ConditionSnow:
+		return f.PrecipitationChance == 0 || f.PrecipitationChance >= 50
+	}
+	return f.PrecipitationChance >= 60 && f.PrecipitationMm >= 1
+}
+
+var (
+	indoorWords = []string{
+		"museum", "gallery", "church", "cathedral", "basilica", "chapel", "mosque", "synagogue",
+		"temple", "monastery", "theatre", "theater", "cinema", "opera", "concert", "aquarium",
+		"library", "palace", "exhibition", "shopping", "mall", "spa", "restaurant", "cafe", "café",
+		"bar", "pub", "bistro", "tasca", "bakery", "indoor",
+	}
+	outdoorWords = []string{
+		"park", "garden", "beach", "viewpoint", "miradouro", "lookout", "square", "plaza", "zoo",
+		"hike", "hiking", "trail", "walk", "bridge", "monument", "lake", "river", "harbour",
+		"harbor", "promenade", "waterfront", "nature", "mountain", "street", "neighbourhood",
+		"neighborhood", "district", "outdoor", "ruins", "cemetery", "vineyard",
+	}
+)
+
+// SettingOf tells whether a place is visited indoors or outdoors, from an "indoor" or
+// "outdoor" tag first and its category otherwise. It returns "" when it cannot tell.
+func SettingOf(category string, tags []string) string {
+	for _, tag := range tags {
+		switch strings.ToLower(strings.TrimSpace(tag)) {
+		case models.SettingIndoor:
+			return models.SettingIndoor
+		case models.SettingOutdoor:
+			return models.SettingOutdoor
+		}
+	}
+	c := strings.ToLower(category)
+	for _, w := range indoorWords {
+		if strings.Contains(c, w) {
+			return models.SettingIndoor
+		}
+	}
+	for _, w := range outdoorWords {
+		if strings.Contains(c, w) {
+			return models.SettingOutdoor
+		}
+	}
+	return ""
+}
+
+// midnight is the UTC date of t.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,73 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+)
+
+func date(s string) time.Time {
+	d, _ := time.Parse(time.DateOnly, s)
+	return d
+}
+
+func TestFile_Daily(t *testing.T) {
+	f, err := NewFile("testdata/forecast.json")
+	require.NoError(t, err)
+
+	forecasts, err := f.Daily(context.Background(), 38.71, -9.14, date("2025-06-01"), 3)
+	require.NoError(t, err)
+	require.Len(t, forecasts, 2, "June 1st is not in the file")
+	assert.Equal(t, date("2025-06-02"), forecasts[0].Date)
+	assert.False(t, forecasts[0].Wet)
+	assert.Equal(t, models.ConditionRain, forecasts[1].Condition)
+	assert.True(t, forecasts[1].Wet)
+
+	_, err = NewFile("testdata/missing.json")
+	assert.Error(t, err)
+}
+
+func TestOpenMeteo_Daily(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"daily":{"time":["2025-06-02","2025-06-03"],"weather_code":[1,95],
+			"temperature_2m_max":[24.5,20],"temperature_2m_min":[15,null],
+			"precipitation_sum":[0,12.4],"precipitation_probability_max":[10,80]}}`))
+	}))
+	defer server.Close()
+
+	o := NewOpenMeteo(server.URL, time.Second)
+	o.now = func() time.Time { return date("2025-06-01").Add(15 * time.Hour) }
+	forecasts, err := o.Daily(context.Background(), 38.71, -9.14, date("2025-06-02"), 30)
+	require.NoError(t, err)
+	assert.Contains(t, query, "start_date=2025-06-02")
+	assert.Contains(t, query, "end_date=2025-06-16", "only the days the service forecasts")
+
+	require.Len(t, forecasts, 2)
+	assert.Equal(t, models.ConditionCloudy, forecasts[0].Condition)
+	assert.False(t, forecasts[0].Wet)
+	assert.Equal(t, models.ConditionStorm, forecasts[1].Condition)
+	assert.True(t, forecasts[1].Wet)
+	assert.Zero(t, forecasts[1].TempMinC)
+
+	// Days past the forecast horizon are not asked for
+	forecasts, err = o.Daily(context.Background(), 38.71, -9.14, date("2025-07-01"), 3)
+	require.NoError(t, err)
+	assert.Empty(t, forecasts)
+}
+
+func TestSettingOf(t *testing.T) {
+	assert.Equal(t, models.SettingIndoor, SettingOf("Art Museum", nil))
+	assert.Equal(t, models.SettingOutdoor, SettingOf("Botanical Garden", nil))
+	assert.Equal(t, models.SettingOutdoor, SettingOf("museum", []string{"history", "Outdoor"}), "tags first")
+	assert.Equal(t, models.SettingIndoor, SettingOf("Seafood restaurant", nil))
+	assert.Equal(t, "", SettingOf("landmark", nil))
+}
@@ -2,6 +2,8 @@ package routes
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/pprof"
@@ -14,12 +16,15 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/domain"
 	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/home"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/itineraryversion"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/lists"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/localization"
 	pages2 "github.com/FACorreiaa/go-templui/internal/app/domain/pages"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/user"
 	"github.com/FACorreiaa/go-templui/internal/app/middleware"
 	"github.com/FACorreiaa/go-templui/internal/app/services"
 
+	"github.com/FACorreiaa/go-templui/internal/app/domain/poimatch"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/profiles"
 
 	"github.com/FACorreiaa/go-templui/internal/app/domain/reviews"
@@ -29,24 +34,35 @@ import (
 	"github.com/FACorreiaa/go-templui/internal/app/domain/billing"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/bookmarks"
 	cityPkg "github.com/FACorreiaa/go-templui/internal/app/domain/city"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/costs"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/deadletter"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/discover"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/favorites"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/geocheck"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/guest"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/hotels"
 	interestsPkg "github.com/FACorreiaa/go-templui/internal/app/domain/interests"
 	locationPkg "github.com/FACorreiaa/go-templui/internal/app/domain/location"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/nearby"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/poi"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/quota"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/recents"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/restaurants"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/results"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/schedule"
 	"github.com/FACorreiaa/go-templui/internal/app/domain/settings"
 	streamingfeatures "github.com/FACorreiaa/go-templui/internal/app/domain/streaming"
 	tagsPkg "github.com/FACorreiaa/go-templui/internal/app/domain/tags"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/trips"
 	"github.com/FACorreiaa/go-templui/internal/app/models"
 	"github.com/FACorreiaa/go-templui/internal/app/renderer"
+	"github.com/FACorreiaa/go-templui/internal/pkg/budget"
 	"github.com/FACorreiaa/go-templui/internal/pkg/config"
-
-	generativeAI "github.com/FACorreiaa/go-genai-sdk/lib"
+	"github.com/FACorreiaa/go-templui/internal/pkg/intent"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+	"github.com/FACorreiaa/go-templui/internal/pkg/prompts"
+	"github.com/FACorreiaa/go-templui/internal/pkg/routing"
+	"github.com/FACorreiaa/go-templui/internal/pkg/weather"
 
 	"github.com/FACorreiaa/go-templui/internal/app/domain/auth"
 
@@ -69,6 +85,14 @@ type AppHandlers struct {
 	Chat                *llmchat.ChatHandlers
 	Nearby              *nearby.NearbyHandler
 	Recents             *recents.RecentsHandlers
+	Quota               *quota.Handler
+	Guest               *guest.Handler
+	Costs               *costs.Handler
+	DeadLetters         *deadletter.Handler
+	POIMatch            *poimatch.Handler
+	ItineraryVersions   *itineraryversion.Handler
+	Trips               *trips.Handler
+	Schedule            *schedule.Handler
 	Settings            *settings.SettingsHandlers
 	//Billing             *billing.BillingHandlers
 	//Reviews             *reviews.ReviewsHandlers
@@ -121,21 +145,34 @@ func setupDependencies(dbPool *pgxpool.Pool, log *zap.Logger, slogLog *slog.Logg
 	userService := user.NewUserService(userRepo, log)
 	listsService := lists.NewService(listsRepo, log)
 
-	// Create embedding service for POI (can be nil if not using semantic search)
-	// Change later GeminiAPIKey env variable
-	var embeddingService *generativeAI.EmbeddingService
-	if os.Getenv("GEMINI_API_KEY") != "" { // Check env var directly
-		ctx := context.Background()                                            // Use a background context for initialization
-		embeddingService, err = generativeAI.NewEmbeddingService(ctx, slogLog) // Call with correct args
-		if err != nil {
-			log.Error("Failed to create embedding service", zap.Error(err))
-		}
+	// Create the LLM provider shared by chat, POI, discover and nearby (generation + embeddings).
+	// Selected through LLM_PROVIDER. The offline fake is used only when no provider is configured
+	// or LLM_PROVIDER=fake asks for it; a misconfigured provider stops startup instead.
+	llmProvider, err := llmprovider.New(context.Background(), llmprovider.Config{
+		Provider:       cfg.LLM.Provider,
+		Model:          cfg.LLM.Model,
+		EmbeddingModel: cfg.LLM.EmbeddingModel,
+		BaseURL:        cfg.LLM.BaseURL,
+		APIKey:         cfg.LLM.APIKey,
+		CassetteMode:   cfg.LLM.CassetteMode,
+		CassetteDir:    cfg.LLM.CassetteDir,
+	}, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM provider %q: %w", cfg.LLM.Provider, err)
+	}
+	log.Info("LLM provider initialised", zap.String("provider", llmProvider.Name()), zap.String("model", llmProvider.Model()))
+
+	// Split traffic between prompt template versions, e.g. PROMPT_SPLITS="dining=1:50,2:50"
+	if err := prompts.Default.ParseSplits(cfg.LLM.PromptSplits); err != nil {
+		log.Error("Invalid PROMPT_SPLITS, serving the latest prompt versions", zap.Error(err))
+	} else if cfg.LLM.PromptSplits != "" {
+		log.Info("Prompt template splits configured", zap.String("splits", cfg.LLM.PromptSplits))
 	}
 
 	// Create chat LLM repository (needed by poiService for LLM logging)
 	chatRepo := llmchat.NewRepositoryImpl(dbPool, log)
 
-	poiService := poi.NewServiceImpl(poiRepo, embeddingService, cityRepo, chatRepo, log)
+	poiService := poi.NewServiceImpl(poiRepo, llmProvider, cityRepo, chatRepo, log)
 
 	// Create recents repository and service
 	recentsRepo := recents.NewRepository(dbPool, log)
@@ -148,8 +185,97 @@ func setupDependencies(dbPool *pgxpool.Pool, log *zap.Logger, slogLog *slog.Logg
 		chatRepo,
 		cityRepo,
 		poiRepo,
+		llmProvider,
 		log,
-	)
+	).WithSchemaRepairAttempts(cfg.LLM.SchemaRepairAttempts).
+		WithContextBudget(cfg.LLM.ChatContextTokens, cfg.LLM.ChatContextKeepMessages)
+
+	intentClassifier, domainClassifier := newClassifiers(cfg.LLM, llmProvider, log)
+	chatService.WithIntentClassifier(intentClassifier).WithDomainDetector(domainClassifier)
+
+	// LLM cost ledger: daily budgets degrade streamed answers to cached or stored data
+	costsService := costs.NewService(costs.NewRepository(dbPool, log), log).WithBudget(costs.Budget{
+		UserDailySoftUSD:   cfg.Budget.UserDailySoftUSD,
+		UserDailyHardUSD:   cfg.Budget.UserDailyHardUSD,
+		GlobalDailySoftUSD: cfg.Budget.GlobalDailySoftUSD,
+		GlobalDailyHardUSD: cfg.Budget.GlobalDailyHardUSD,
+	})
+	chatService.WithCostLedger(costsService)
+
+	// Stream events a client missed are kept in Postgres and re-delivered on reconnect
+	deadLetterService := deadletter.NewService(deadletter.NewRepository(dbPool, log), log)
+	chatService.WithDeadLetters(deadLetterService)
+
+	// Generated POIs outside their city are flagged, dropped or re-geocoded before saving
+	geoCheckConfig := geocheck.DefaultConfig()
+	if action, err := geocheck.ParseAction(cfg.GeoCheck.OnFailure); err == nil {
+		geoCheckConfig = geocheck.Config{MaxDistanceKm: cfg.GeoCheck.MaxDistanceKm, OnFailure: action}
+	}
+	chatService.WithGeoCheck(geocheck.NewService(geocheck.NewRepository(dbPool, log), geoCheckConfig, log))
+
+	// Generated POIs resolve to the stored record under any name it is known by
+	poiMatchConfig := poimatch.DefaultConfig()
+	poiMatchConfig.Threshold = cfg.POIMatch.Threshold
+	poiMatchConfig.MaxDistanceM = cfg.POIMatch.MaxDistanceM
+	poiMatchService := poimatch.NewService(poimatch.NewRepository(dbPool, log), poiMatchConfig, log).WithEmbedder(llmProvider)
+	chatService.WithPOIResolver(poiMatchService)
+
+	// Answers in the user's language; stored content only kept in another one is translated once
+	localizationService := localization.NewService(localization.NewRepository(dbPool, log), log).
+		WithTranslator(localization.NewLLMTranslator(llmProvider))
+	chatService.WithLocalizer(localizationService)
+
+	// Every itinerary change is kept, so sessions can be reverted or branched
+	itineraryVersionService := itineraryversion.NewService(itineraryversion.NewRepository(dbPool, log), log)
+	chatService.WithItineraryVersions(itineraryVersionService)
+
+	// Travel times between stops, from an OSRM server when one is configured and straight-line
+	// estimates otherwise
+	var routingProvider routing.Provider = routing.NewHaversine()
+	if cfg.Routing.OSRMURL != "" {
+		routingProvider = routing.WithFallback(routing.NewOSRM(cfg.Routing.OSRMURL, cfg.Routing.Timeout), routingProvider)
+	}
+	chatService.WithRouting(routingProvider)
+
+	// Trip costs in the profile's currency, with rates from a rates service when one is
+	// configured and the built-in table otherwise
+	var rates budget.Rates = budget.NewTable(nil)
+	if cfg.FX.RatesURL != "" {
+		rates = budget.WithFallback(budget.NewHTTP(cfg.FX.RatesURL, cfg.FX.Timeout), rates)
+	}
+	chatService.WithCostEstimates(budget.NewEstimator(rates))
+
+	// Multi-city trips: one itinerary per city, generated by the chat service
+	tripsService := trips.NewService(trips.NewRepository(dbPool, log), chatService, log).WithCosts(chatService)
+
+	// Day-by-day schedules of itinerary lists, shaped by the user's default profile
+	scheduleService := schedule.NewService(schedule.NewRepository(dbPool, log), profilesService, log).WithRouting(routingProvider)
+
+	// Forecasts for scheduled days, from a file when one is given and a forecast service otherwise
+	switch {
+	case cfg.Weather.File != "":
+		forecasts, err := weather.NewFile(cfg.Weather.File)
+		if err != nil {
+			log.Warn("Scheduling without forecasts", zap.String("file", cfg.Weather.File), zap.Error(err))
+			break
+		}
+		scheduleService.WithWeather(forecasts)
+	case cfg.Weather.ForecastURL != "":
+		scheduleService.WithWeather(weather.NewOpenMeteo(cfg.Weather.ForecastURL, cfg.Weather.Timeout))
+	}
+
+	// Questions are answered by an agent that can search POIs, cities, favourites and lists first
+	if cfg.LLM.ChatAgentMaxIterations > 0 {
+		chatService.WithAgent(cfg.LLM.ChatAgentMaxIterations, listsService)
+	}
+
+	// Plan limits: daily searches, saved locations and paid features
+	quotaService := quota.NewService(quota.NewRepository(dbPool, log), log)
+
+	// Guests keep their free-mode sessions, favourites and lists until they sign up or sign in
+	guestService := guest.NewService(guest.NewRepository(dbPool, log), log)
+	authService.WithGuests(guestService)
+
 	itineraryService := services.NewItineraryService()
 	locationRepo := locationPkg.NewRepository(dbPool)
 
@@ -157,25 +283,33 @@ func setupDependencies(dbPool *pgxpool.Pool, log *zap.Logger, slogLog *slog.Logg
 		Home:                home.NewHomeHandlers(baseHandler),
 		User:                user.NewHandler(baseHandler, userService),
 		Auth:                auth.NewAuthHandlers(baseHandler, authService, log),
-		Discover:            discover.NewDiscoverHandlers(baseHandler, poiRepo, chatRepo, chatService, log),
+		Discover:            discover.NewDiscoverHandlers(baseHandler, poiRepo, chatRepo, chatService, llmProvider, log),
 		Favorites:           favorites.NewFavoritesHandlers(poiService, log, baseHandler),
 		HotelFavorites:      favorites.NewHotelFavoritesHandlers(poiService, log),
 		RestaurantFavorites: favorites.NewRestaurantFavoritesHandlers(poiService, log),
 		Bookmarks:           bookmarks.NewBookmarksHandlers(poiService, log),
-		Lists:               lists.NewHandler(listsService, log),
+		Lists:               lists.NewHandler(listsService, log).WithRoutes(scheduleService),
 		Profiles:            profiles.NewProfilesHandler(profilesService, log),
 		Interests:           interestsPkg.NewInterestsHandler(interestsRepo, log),
 		Tags:                tagsPkg.NewTagsHandler(tagsRepo, log),
-		Chat:                llmchat.NewChatHandlers(chatService, profilesService, chatRepo, log),
-		Nearby:              nearby.NewNearbyHandler(log, chatService, locationRepo),
+		Chat:                llmchat.NewChatHandlers(chatService, profilesService, chatRepo, log).WithDomainDetector(domainClassifier).WithDeadLetters(deadLetterService),
+		Nearby:              nearby.NewNearbyHandler(log, llmProvider, locationRepo),
 		Recents:             recents.NewRecentsHandlers(recentsService, log),
+		Quota:               quota.NewHandler(quotaService, log).WithEnforcement(!cfg.Quota.Disabled),
+		Guest:               guest.NewHandler(guestService, log),
+		Costs:               costs.NewHandler(costsService, log),
+		DeadLetters:         deadletter.NewHandler(deadLetterService, log),
+		POIMatch:            poimatch.NewHandler(poiMatchService, log),
+		ItineraryVersions:   itineraryversion.NewHandler(itineraryVersionService, chatService, log),
+		Trips:               trips.NewHandler(tripsService, log),
+		Schedule:            schedule.NewHandler(scheduleService, log),
 		Settings:            settings.NewSettingsHandlers(baseHandler, log),
 		//Billing:             billing.NewBillingHandlers(baseHandler),
 		//Reviews:             reviews.NewReviewsHandlers(baseHandler),
 		Activities:  activities.NewActivitiesHandlers(chatRepo, log),
 		Hotels:      hotels.NewHotelsHandlers(chatRepo, log),
 		Restaurants: restaurants.NewRestaurantsHandlers(chatRepo, log),
-		Itinerary:   interestsPkg.NewItineraryHandlers(chatRepo, itineraryService, log),
+		Itinerary:   interestsPkg.NewItineraryHandlers(chatRepo, itineraryService, log).WithRouting(chatService),
 		Results:     results.NewResultsHandlers(log),
 		Filter:      common.NewFilterHandlers(log.Sugar()),
 		StaticPages: domain.NewBaseHandler(log),
@@ -185,6 +319,36 @@ func setupDependencies(dbPool *pgxpool.Pool, log *zap.Logger, slogLog *slog.Logg
 
 }
 
+// newClassifiers picks the intent and domain classifiers of the chat. The embedding classifiers
+// are used unless INTENT_CLASSIFIER=rules or the provider is the fake one, whose hashed
+// embeddings carry no meaning and would label messages at random.
+func newClassifiers(cfg config.LLMConfig, provider llmprovider.Provider, log *zap.Logger) (llmchat.IntentClassifier, llmchat.DomainDetector) {
+	rules := func(reason string) (llmchat.IntentClassifier, llmchat.DomainDetector) {
+		log.Info("Keyword intent classifier enabled", zap.String("reason", reason))
+		return &models.SimpleIntentClassifier{}, &models.DomainDetector{}
+	}
+	switch {
+	case cfg.IntentClassifier == "rules":
+		return rules("INTENT_CLASSIFIER=rules")
+	case provider.Name() == llmprovider.ProviderFake:
+		return rules("the fake LLM provider has no real embeddings")
+	}
+
+	intents, intentErr := intent.NewIntentClassifier(provider, log)
+	domains, domainErr := intent.NewDomainClassifier(provider, log)
+	if err := errors.Join(intentErr, domainErr); err != nil {
+		log.Error("Failed to create intent classifiers", zap.Error(err))
+		return rules("the embedding classifiers failed to load")
+	}
+	intents.WithThreshold(cfg.IntentConfidence).WithLLMFallback(cfg.IntentLLMFallback)
+	domains.WithThreshold(cfg.IntentConfidence).WithLLMFallback(cfg.IntentLLMFallback)
+	log.Info("Embedding intent classifier enabled",
+		zap.String("provider", provider.Name()),
+		zap.Float64("confidence_threshold", cfg.IntentConfidence),
+		zap.Bool("llm_fallback", cfg.IntentLLMFallback))
+	return intents, domains
+}
+
 func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 	// Pprof debugging routes
 	debugGroup := r.Group("/debug/pprof")
@@ -203,8 +367,18 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 		debugGroup.GET("/threadcreate", gin.WrapH(pprof.Handler("threadcreate")))
 	}
 
+	jwtSecret := os.Getenv("JWT_SECRET_KEY")
+	if jwtSecret == "" {
+		jwtSecret = "default-secret-key-change-in-production-min-32-chars"
+		log.Warn("JWT_SECRET_KEY not set, using default (INSECURE - set environment variable in production)")
+	}
+
+	// Visitors without an account are tied to a guest id by a signed cookie, issued with the
+	// pages they search from so the stream request already carries it
+	guests := middleware.NewGuestIdentity(jwtSecret)
+
 	public := r.Group("/")
-	public.Use(middleware.OptionalAuthMiddleware())
+	public.Use(middleware.OptionalAuthMiddleware(), guests.Issue())
 	{
 		public.GET("/", h.Home.ShowHomePage)
 		public.GET("/discover", h.Discover.ShowDiscoverPage)
@@ -221,12 +395,6 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 
 	// WebSocket endpoint for real-time nearby POI updates
 	// Configure JWT authentication (optional - allows anonymous users)
-	jwtSecret := os.Getenv("JWT_SECRET_KEY")
-	if jwtSecret == "" {
-		jwtSecret = "default-secret-key-change-in-production-min-32-chars"
-		log.Warn("JWT_SECRET_KEY not set, using default (INSECURE - set environment variable in production)")
-	}
-
 	jwtConfig := middleware.JWTConfig{
 		SecretKey:       jwtSecret,
 		TokenExpiration: 24 * time.Hour,
@@ -255,14 +423,30 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 		authGroup.GET("/signup", h.Auth.ShowSignUpPage)
 		authGroup.GET("/forgot-password", h.Auth.ShowForgotPasswordPage)
 
-		authGroup.POST("/signin", h.Auth.LoginHandler)
-		authGroup.POST("/signup", gin.WrapF(h.Auth.RegisterHandler))
+		// The guest of the request is moved onto the user that signs in or signs up
+		authGroup.POST("/signin", guests.Identify(), h.Auth.LoginHandler)
+		authGroup.POST("/signup", guests.Identify(), gin.WrapF(h.Auth.RegisterHandler))
 		authGroup.POST("/logout", gin.WrapF(h.Auth.LogoutHandler))
 		authGroup.POST("/forgot-password", gin.WrapF(h.Auth.ForgotPasswordHandler))
 		authGroup.POST("/change-password", gin.WrapF(h.Auth.ChangePasswordHandler))
 		authGroup.POST("/check-username", gin.WrapF(h.Auth.CheckUsernameHandler))
 	}
 
+	// Guest routes: signed-in users as usual, everyone else in free mode under a guest id
+	guestGroup := r.Group("/")
+	guestGroup.Use(middleware.OptionalAuthMiddleware(), guests.Issue())
+	{
+		// SSE streaming endpoints
+		guestGroup.GET("/chat/stream", h.Chat.ResumeStream(), h.Quota.RequireSearch(), h.Chat.ProcessUnifiedChatMessageStream)
+		guestGroup.POST("/chat/stream", h.Chat.ResumeStream(), h.Quota.RequireSearch(), h.Chat.ProcessUnifiedChatMessageStream)
+
+		guestGroup.GET("/guest", h.Guest.Summary)
+		guestGroup.POST("/guest/favorites/:id", h.Quota.RequireSavedItem(), h.Guest.AddFavorite)
+		guestGroup.DELETE("/guest/favorites/:id", h.Guest.RemoveFavorite)
+		guestGroup.POST("/guest/lists", h.Guest.CreateList)
+		guestGroup.POST("/guest/lists/:id/items", h.Quota.RequireSavedItem(), h.Guest.AddListItem)
+	}
+
 	// Protected routes
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware())
@@ -488,10 +672,10 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 
 		// Lists modal and actions
 		protected.GET("/lists/new", h.Lists.ShowCreateModal)
-		protected.POST("/lists/create", h.Lists.CreateList)
+		protected.POST("/lists/create", h.Quota.RequireFeature(quota.FeatureCustomLists), h.Lists.CreateList)
 		protected.GET("/lists/select", h.Lists.ShowAddToListModal)
 		protected.GET("/lists/:id", h.Lists.ShowListDetail)
-		protected.POST("/lists/:id/items", h.Lists.AddItemToList)
+		protected.POST("/lists/:id/items", h.Quota.RequireSavedItem(), h.Lists.AddItemToList)
 		protected.DELETE("/lists/:id/items/:itemId", h.Lists.RemoveListItem)
 		protected.GET("/lists/:id/edit", h.Lists.ShowEditModal)
 		protected.PUT("/lists/:id", h.Lists.UpdateList)
@@ -562,33 +746,29 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 	htmxGroup.Use(middleware.AuthMiddleware())
 	{
 		// Search endpoint (public - no auth required)
-		htmxGroup.POST("/search", h.Chat.HandleSearch)
+		htmxGroup.POST("/search", h.Quota.RequireSearch(), h.Chat.HandleSearch)
 
 		// Discover endpoint (requires auth)
-		htmxGroup.POST("/discover", middleware.AuthMiddleware(), h.Chat.HandleDiscover)
+		htmxGroup.POST("/discover", middleware.AuthMiddleware(), h.Quota.RequireSearch(), h.Chat.HandleDiscover)
 
 		// Chat endpoints
-		htmxGroup.POST("/chat/message", h.Chat.SendMessage)
+		htmxGroup.POST("/chat/message", h.Quota.RequireSearch(), h.Chat.SendMessage)
 		htmxGroup.POST("/chat/stream/connect", middleware.OptionalAuthMiddleware(), h.Chat.HandleChatStreamConnect)
 
-		// SSE streaming endpoints
-		htmxGroup.GET("/chat/stream", middleware.OptionalAuthMiddleware(), h.Chat.ProcessUnifiedChatMessageStream)
-		htmxGroup.POST("/chat/stream", middleware.OptionalAuthMiddleware(), h.Chat.ProcessUnifiedChatMessageStream)
-
 		// Continue chat session endpoint (for adding/removing items to existing sessions)
-		htmxGroup.POST("/chat/continue/:sessionID", middleware.OptionalAuthMiddleware(), h.Chat.ContinueChatSession)
+		htmxGroup.POST("/chat/continue/:sessionID", middleware.OptionalAuthMiddleware(), h.Chat.ResumeStream(), h.Chat.ContinueChatSession)
 
 		// Favorites endpoints
-		htmxGroup.POST("/favorites/add/:id", h.Favorites.AddFavorite)
+		htmxGroup.POST("/favorites/add/:id", h.Quota.RequireSavedItem(), h.Favorites.AddFavorite)
 		htmxGroup.DELETE("/favorites/:id", h.Favorites.RemoveFavorite)
 		htmxGroup.POST("/favorites/search", h.Favorites.SearchFavorites)
 
 		// Hotel favorites endpoints
-		htmxGroup.POST("/favorites/hotels/:id", h.HotelFavorites.AddHotelFavorite)
+		htmxGroup.POST("/favorites/hotels/:id", h.Quota.RequireSavedItem(), h.HotelFavorites.AddHotelFavorite)
 		htmxGroup.DELETE("/favorites/hotels/:id", h.HotelFavorites.RemoveHotelFavorite)
 
 		// Restaurant favorites endpoints
-		htmxGroup.POST("/favorites/restaurants/:id", h.RestaurantFavorites.AddRestaurantFavorite)
+		htmxGroup.POST("/favorites/restaurants/:id", h.Quota.RequireSavedItem(), h.RestaurantFavorites.AddRestaurantFavorite)
 		htmxGroup.DELETE("/favorites/restaurants/:id", h.RestaurantFavorites.RemoveRestaurantFavorite)
 
 		// Bookmarks endpoints
@@ -597,7 +777,7 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 		// htmxGroup.POST("/bookmarks/search", bookmarksHandlers.SearchBookmarks) // TODO: Implement SearchBookmarks
 
 		// Discover endpoints
-		htmxGroup.POST("/discover/search", h.Discover.Search)
+		htmxGroup.POST("/discover/search", h.Quota.RequireSearch(), h.Discover.Search)
 		htmxGroup.GET("/discover/recent", h.Discover.GetRecentDiscoveries)
 		htmxGroup.GET("/discover/category/:category", h.Discover.GetCategory)
 
@@ -620,7 +800,7 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 		htmxGroup.POST("/itinerary/add/:id", h.Itinerary.AddPOI)
 		htmxGroup.DELETE("/itinerary/remove/:id", h.Itinerary.RemovePOI)
 		htmxGroup.GET("/itinerary/summary", h.Itinerary.GetItinerarySummary)
-		htmxGroup.GET("/itinerary/stream", h.Chat.HandleItineraryStream)
+		htmxGroup.GET("/itinerary/stream", h.Chat.ResumeStream(), h.Quota.RequireSearch(), h.Chat.HandleItineraryStream)
 		htmxGroup.GET("/itinerary/sse", h.Itinerary.HandleItinerarySSE)
 
 		// Filter endpoints (HTMX fragments)
@@ -685,6 +865,49 @@ func setupRouter(r *gin.Engine, h *AppHandlers, log *zap.Logger) {
 				tagsGroup.PUT("/:id", h.Tags.UpdateTag)
 				tagsGroup.DELETE("/:id", h.Tags.DeleteTag)
 			}
+
+			// Itinerary version history of chat sessions
+			versionsGroup := protectedAPI.Group("/chat/sessions/:id/versions")
+			{
+				versionsGroup.GET("", h.ItineraryVersions.List)
+				versionsGroup.GET("/diff", h.ItineraryVersions.Diff)
+				versionsGroup.GET("/:version", h.ItineraryVersions.Get)
+				versionsGroup.POST("/:version/revert", h.ItineraryVersions.Revert)
+				versionsGroup.POST("/:version/branch", h.ItineraryVersions.Branch)
+			}
+
+			// Multi-city trips, a Pro feature
+			tripsGroup := protectedAPI.Group("/trips")
+			{
+				tripsGroup.POST("", h.Quota.RequireFeature(quota.FeatureMultiCity), h.Trips.Plan)
+				tripsGroup.GET("/:id", h.Trips.Get)
+				tripsGroup.PATCH("/:id/cities/:position", h.Quota.RequireFeature(quota.FeatureMultiCity), h.Trips.UpdateCity)
+			}
+
+			// Day-by-day schedules of itinerary lists
+			scheduleGroup := protectedAPI.Group("/lists/:id/schedule")
+			{
+				scheduleGroup.POST("", h.Schedule.Build)
+				scheduleGroup.GET("", h.Schedule.Get)
+				scheduleGroup.PATCH("/items/:itemId", h.Schedule.Move)
+			}
+			protectedAPI.GET("/lists/:id/route", h.Schedule.Route)
+
+			// Admin endpoints
+			adminGroup := protectedAPI.Group("/admin")
+			adminGroup.Use(h.Costs.RequireAdmin())
+			{
+				adminGroup.GET("/costs/report", h.Costs.Report)
+				adminGroup.GET("/costs/rollups", h.Costs.Rollups)
+
+				adminGroup.GET("/dead-letters", h.DeadLetters.List)
+				adminGroup.GET("/dead-letters/:id", h.DeadLetters.Get)
+				adminGroup.POST("/dead-letters/:id/replay", h.DeadLetters.Replay)
+				adminGroup.POST("/dead-letters/:id/discard", h.DeadLetters.Discard)
+
+				adminGroup.POST("/cities/:id/merge-pois", h.POIMatch.MergeCity)
+				adminGroup.POST("/pois/:id/aliases", h.POIMatch.AddAlias)
+			}
 		}
 	}
 
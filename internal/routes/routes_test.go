@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	llmchat "github.com/FACorreiaa/go-templui/internal/app/domain/chat_prompt"
+	"github.com/FACorreiaa/go-templui/internal/app/domain/quota"
+	"github.com/FACorreiaa/go-templui/internal/app/middleware"
+	"github.com/FACorreiaa/go-templui/internal/app/models"
+	"github.com/FACorreiaa/go-templui/internal/pkg/config"
+	"github.com/FACorreiaa/go-templui/internal/pkg/intent"
+	"github.com/FACorreiaa/go-templui/internal/pkg/llmprovider"
+)
+
+// searchesUsedUp refuses every AI search of a Free user.
+type searchesUsedUp struct {
+	quota.Service
+	charged atomic.Int32
+}
+
+func (s *searchesUsedUp) CheckSearch(context.Context, uuid.UUID) (quota.Usage, error) {
+	return quota.Usage{}, &quota.LimitError{
+		Plan:     quota.PlanFree,
+		Resource: quota.ResourceSearches,
+		Limit:    5,
+		Used:     5,
+		ResetAt:  time.Now().Add(time.Hour),
+	}
+}
+
+func (s *searchesUsedUp) ChargeSearch(_ context.Context, usage quota.Usage) (quota.Usage, error) {
+	s.charged.Add(1)
+	return usage, nil
+}
+
+// generationCounter counts the chat generations it is asked for.
+type generationCounter struct {
+	llmchat.LlmInteractiontService
+	calls atomic.Int32
+}
+
+func (s *generationCounter) ProcessUnifiedChatMessageStream(_ context.Context, _, _ uuid.UUID, _, _ string, _ *models.UserLocation, eventCh chan<- models.StreamEvent) error {
+	s.calls.Add(1)
+	close(eventCh)
+	return nil
+}
+
+func (s *generationCounter) ProcessUnifiedChatMessageStreamFree(_ context.Context, _, _ string, _ *models.UserLocation, eventCh chan<- models.StreamEvent) error {
+	s.calls.Add(1)
+	close(eventCh)
+	return nil
+}
+
+func TestSetupRouter_SearchRoutesRefuseUsersOverTheLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	quotas := &searchesUsedUp{}
+	llm := &generationCounter{}
+	r := gin.New()
+	setupRouter(r, &AppHandlers{
+		Chat:  llmchat.NewChatHandlers(llm, nil, nil, zap.NewNop()),
+		Quota: quota.NewHandler(quotas, zap.NewNop()),
+	}, zap.NewNop())
+
+	token, err := middleware.NewJWTService().GenerateToken(middleware.JWTConfig{
+		SecretKey:       "default-secret-key-change-in-production-min-32-chars",
+		TokenExpiration: time.Hour,
+	}, uuid.NewString(), "free@example.com", "free")
+	require.NoError(t, err)
+
+	form := url.Values{"search-input": {"Museums in Lisbon"}, "message": {"Museums in Lisbon"}}.Encode()
+	requests := map[string]func() *http.Request{
+		"POST /search": func() *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return req
+		},
+		"POST /chat/message": func() *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/chat/message", strings.NewReader(form))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return req
+		},
+		"GET /itinerary/stream": func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/itinerary/stream?message=Museums+in+Lisbon", nil)
+		},
+	}
+	for name, newRequest := range requests {
+		t.Run(name, func(t *testing.T) {
+			req := newRequest()
+			req.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			assert.Contains(t, w.Body.String(), quota.UpgradeURL)
+
+			req = newRequest()
+			req.AddCookie(&http.Cookie{Name: "auth_token", Value: token})
+			req.Header.Set("HX-Request", "true")
+			w = httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("HX-Trigger"), "quota-exceeded", "htmx gets the upgrade prompt")
+		})
+	}
+	assert.Zero(t, llm.calls.Load(), "nothing is generated once the searches are used up")
+	assert.Zero(t, quotas.charged.Load())
+}
+
+// namedProvider passes for a real provider in front of the fake.
+type namedProvider struct {
+	*llmprovider.FakeProvider
+	name string
+}
+
+func (p *namedProvider) Name() string { return p.name }
+
+func TestNewClassifiers(t *testing.T) {
+	cfg := config.LLMConfig{IntentClassifier: "embedding", IntentConfidence: 0.6}
+	choose := func(cfg config.LLMConfig, provider llmprovider.Provider) (llmchat.IntentClassifier, llmchat.DomainDetector, string) {
+		core, logs := observer.New(zap.InfoLevel)
+		intents, domains := newClassifiers(cfg, provider, zap.New(core))
+		require.Equal(t, 1, logs.Len())
+		return intents, domains, logs.All()[0].Message
+	}
+
+	intents, domains, msg := choose(cfg, llmprovider.NewFakeProvider())
+	assert.IsType(t, &models.SimpleIntentClassifier{}, intents, "hashed embeddings would label messages at random")
+	assert.IsType(t, &models.DomainDetector{}, domains)
+	assert.Equal(t, "Keyword intent classifier enabled", msg)
+
+	gemini := &namedProvider{FakeProvider: llmprovider.NewFakeProvider(), name: llmprovider.ProviderGemini}
+	rules := cfg
+	rules.IntentClassifier = "rules"
+	intents, _, _ = choose(rules, gemini)
+	assert.IsType(t, &models.SimpleIntentClassifier{}, intents)
+
+	intents, domains, msg = choose(cfg, gemini)
+	assert.IsType(t, &intent.IntentClassifier{}, intents)
+	assert.IsType(t, &intent.DomainClassifier{}, domains)
+	assert.Equal(t, "Embedding intent classifier enabled", msg)
+}